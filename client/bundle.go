@@ -0,0 +1,183 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	gocrypto "crypto"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto"
+	"github.com/google/trillian/merkle"
+)
+
+// BundleVersion is the version of the ProofBundle format produced by this
+// package.
+const BundleVersion = 1
+
+// ProofBundle holds everything needed to show a third party that a leaf is
+// included in a log: the leaf, its audit path, and the signed root the path
+// leads to. It can be checked with VerifyBundle given only the log's public key,
+// or with VerifyBundleWithRootKeys if the log's roots are signed by several keys.
+type ProofBundle struct {
+	// Version is the format version of the bundle, see BundleVersion.
+	Version int `json:"version"`
+	// LogID is the ID of the log the leaf is included in.
+	LogID int64 `json:"log_id"`
+	// LeafValue is the value of the included leaf.
+	LeafValue []byte `json:"leaf_value"`
+	// LeafIndex is the index of the leaf in the log.
+	LeafIndex int64 `json:"leaf_index"`
+	// AuditPath holds the hashes needed to compute the root hash of
+	// SignedLogRoot from the hash of the leaf.
+	AuditPath [][]byte `json:"audit_path"`
+	// SignedLogRoot is the root the leaf is included in.
+	SignedLogRoot *trillian.SignedLogRoot `json:"signed_log_root"`
+	// KeyID identifies the key that signed SignedLogRoot, see crypto.KeyID.
+	KeyID []byte `json:"key_id"`
+}
+
+// NewBundle fetches the latest root of logID and a proof that the leaf at
+// leafIndex is included in it, and returns them as a ProofBundle. The bundle
+// is verified against pubKey before being returned.
+func NewBundle(ctx context.Context, client trillian.TrillianLogClient, logID int64, pubKey gocrypto.PublicKey, leafIndex int64) (*ProofBundle, error) {
+	keyID, err := crypto.KeyID(pubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	rootResp, err := client.GetLatestSignedLogRoot(ctx, &trillian.GetLatestSignedLogRootRequest{LogId: logID})
+	if err != nil {
+		return nil, err
+	}
+	root := rootResp.SignedLogRoot
+	if root == nil {
+		return nil, fmt.Errorf("no signed root returned for log %d", logID)
+	}
+	if leafIndex >= root.TreeSize {
+		return nil, fmt.Errorf("leaf %d isn't in the latest root of log %d, which has tree size %d", leafIndex, logID, root.TreeSize)
+	}
+
+	entryResp, err := client.GetEntryAndProof(ctx, &trillian.GetEntryAndProofRequest{
+		LogId:     logID,
+		LeafIndex: leafIndex,
+		TreeSize:  root.TreeSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if entryResp.Leaf == nil || entryResp.Proof == nil {
+		return nil, fmt.Errorf("incomplete entry and proof returned for leaf %d", leafIndex)
+	}
+
+	bundle := &ProofBundle{
+		Version:       BundleVersion,
+		LogID:         logID,
+		LeafValue:     entryResp.Leaf.LeafValue,
+		LeafIndex:     leafIndex,
+		AuditPath:     convertProof(entryResp.Proof),
+		SignedLogRoot: root,
+		KeyID:         keyID,
+	}
+	if err := VerifyBundle(bundle, pubKey); err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// MarshalBundle serializes bundle, for ParseBundle to read back.
+func MarshalBundle(bundle *ProofBundle) ([]byte, error) {
+	return json.Marshal(bundle)
+}
+
+// ParseBundle reads a bundle serialized by MarshalBundle. It fails if the
+// bundle is of a version this package doesn't understand, but doesn't verify
+// the bundle.
+func ParseBundle(data []byte) (*ProofBundle, error) {
+	var bundle ProofBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle: %v", err)
+	}
+	if bundle.Version != BundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d, want %d", bundle.Version, BundleVersion)
+	}
+	return &bundle, nil
+}
+
+// VerifyBundle checks that bundle's root is signed by pubKey, either as its
+// signature or among its signatures, and that its leaf is included in that root.
+func VerifyBundle(bundle *ProofBundle, pubKey gocrypto.PublicKey) error {
+	if err := checkBundleRoot(bundle); err != nil {
+		return err
+	}
+	keyID, err := crypto.KeyID(pubKey)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(bundle.KeyID, keyID) {
+		return fmt.Errorf("bundle was signed by key %x, not %x", bundle.KeyID, keyID)
+	}
+	if err := verifySignedBy(pubKey, *bundle.SignedLogRoot); err != nil {
+		return fmt.Errorf("invalid root signature: %v", err)
+	}
+	return verifyBundleInclusion(bundle)
+}
+
+// VerifyBundleWithRootKeys checks that bundle's root is signed by at least
+// threshold of rootKeys, as for a client created with NewWithRootKeys, and that
+// its leaf is included in that root. The bundle's KeyID is ignored.
+func VerifyBundleWithRootKeys(bundle *ProofBundle, rootKeys []gocrypto.PublicKey, threshold int) error {
+	if threshold <= 0 || threshold > len(rootKeys) {
+		return fmt.Errorf("threshold must be between 1 and %d, got %d", len(rootKeys), threshold)
+	}
+	if err := checkBundleRoot(bundle); err != nil {
+		return err
+	}
+	if err := crypto.VerifyLogRootSignatures(rootKeys, *bundle.SignedLogRoot, threshold); err != nil {
+		return fmt.Errorf("invalid root signatures: %v", err)
+	}
+	return verifyBundleInclusion(bundle)
+}
+
+// checkBundleRoot checks that bundle is of a known version and has a root for
+// its log.
+func checkBundleRoot(bundle *ProofBundle) error {
+	if bundle.Version != BundleVersion {
+		return fmt.Errorf("unsupported bundle version %d, want %d", bundle.Version, BundleVersion)
+	}
+	root := bundle.SignedLogRoot
+	if root == nil {
+		return fmt.Errorf("bundle has no signed root")
+	}
+	if root.LogId != bundle.LogID {
+		return fmt.Errorf("bundle is for log %d but its root is for log %d", bundle.LogID, root.LogId)
+	}
+	return nil
+}
+
+// verifyBundleInclusion checks that bundle's leaf is included in its root.
+func verifyBundleInclusion(bundle *ProofBundle) error {
+	root := bundle.SignedLogRoot
+	// TODO(al): Hasher must be selected based on log config.
+	hasher, err := merkle.Factory(merkle.RFC6962SHA256Type)
+	if err != nil {
+		return err
+	}
+	v := merkle.NewLogVerifier(hasher)
+	return v.VerifyInclusionProof(bundle.LeafIndex, root.TreeSize, bundle.AuditPath, root.RootHash, hasher.HashLeaf(bundle.LeafValue))
+}
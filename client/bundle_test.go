@@ -0,0 +1,254 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	gocrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto"
+	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/mockclient"
+	"github.com/google/trillian/testonly"
+)
+
+const bundleLogID = 6962
+
+func newTestSigner(t *testing.T) *crypto.Signer {
+	key, err := keys.NewFromPrivatePEM(testonly.DemoPrivateKey, testonly.DemoPrivateKeyPass)
+	if err != nil {
+		t.Fatalf("NewFromPrivatePEM(): %v", err)
+	}
+	return crypto.NewSigner(key)
+}
+
+// newBundleLog returns a signer, and a signed root and entries with inclusion
+// proofs for a log of size leaves.
+func newBundleLog(t *testing.T, size int64) (*crypto.Signer, *trillian.SignedLogRoot, []*trillian.GetEntryAndProofResponse) {
+	signer := newTestSigner(t)
+
+	tree := merkle.NewInMemoryMerkleTree(testonly.Hasher)
+	var entries []*trillian.GetEntryAndProofResponse
+	for i := int64(0); i < size; i++ {
+		value := []byte(fmt.Sprintf("leaf %d", i))
+		tree.AddLeaf(value)
+		entries = append(entries, &trillian.GetEntryAndProofResponse{
+			Leaf: &trillian.LogLeaf{LeafIndex: i, LeafValue: value},
+		})
+	}
+	for i, entry := range entries {
+		proof := &trillian.Proof{LeafIndex: int64(i)}
+		for _, node := range tree.PathToCurrentRoot(int64(i + 1)) {
+			proof.ProofNode = append(proof.ProofNode, &trillian.Node{NodeHash: node.Value.Hash()})
+		}
+		entry.Proof = proof
+	}
+
+	root := &trillian.SignedLogRoot{
+		LogId:          bundleLogID,
+		TreeSize:       size,
+		RootHash:       tree.CurrentRoot().Hash(),
+		TimestampNanos: 1000,
+	}
+	var err error
+	if root.Signature, err = signer.SignLogRoot(*root); err != nil {
+		t.Fatalf("SignLogRoot(): %v", err)
+	}
+	return signer, root, entries
+}
+
+func TestNewBundle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	signer, root, entries := newBundleLog(t, 5)
+	client := mockclient.NewMockTrillianLogClient(ctrl)
+	client.EXPECT().GetLatestSignedLogRoot(ctx, &trillian.GetLatestSignedLogRootRequest{LogId: bundleLogID}).Return(&trillian.GetLatestSignedLogRootResponse{SignedLogRoot: root}, nil)
+	client.EXPECT().GetEntryAndProof(ctx, &trillian.GetEntryAndProofRequest{LogId: bundleLogID, LeafIndex: 3, TreeSize: 5}).Return(entries[3], nil)
+
+	bundle, err := NewBundle(ctx, client, bundleLogID, signer.Public(), 3)
+	if err != nil {
+		t.Fatalf("NewBundle(): %v", err)
+	}
+
+	// The bundle should survive serialization and still verify.
+	data, err := MarshalBundle(bundle)
+	if err != nil {
+		t.Fatalf("MarshalBundle(): %v", err)
+	}
+	parsed, err := ParseBundle(data)
+	if err != nil {
+		t.Fatalf("ParseBundle(): %v", err)
+	}
+	if !reflect.DeepEqual(parsed, bundle) {
+		t.Errorf("ParseBundle(MarshalBundle(%+v))=%+v", bundle, parsed)
+	}
+	if err := VerifyBundle(parsed, signer.Public()); err != nil {
+		t.Errorf("VerifyBundle(): %v", err)
+	}
+}
+
+func TestNewBundleLeafNotInRoot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	signer, root, _ := newBundleLog(t, 5)
+	client := mockclient.NewMockTrillianLogClient(ctrl)
+	client.EXPECT().GetLatestSignedLogRoot(ctx, gomock.Any()).Return(&trillian.GetLatestSignedLogRootResponse{SignedLogRoot: root}, nil)
+
+	if _, err := NewBundle(ctx, client, bundleLogID, signer.Public(), 5); err == nil {
+		t.Error("NewBundle() for leaf beyond the root succeeded, want error")
+	}
+}
+
+func TestVerifyBundle(t *testing.T) {
+	signer, root, entries := newBundleLog(t, 7)
+	keyID, err := crypto.KeyID(signer.Public())
+	if err != nil {
+		t.Fatalf("KeyID(): %v", err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey(): %v", err)
+	}
+	newBundle := func() *ProofBundle {
+		rootCopy := *root
+		return &ProofBundle{
+			Version:       BundleVersion,
+			LogID:         bundleLogID,
+			LeafValue:     entries[4].Leaf.LeafValue,
+			LeafIndex:     4,
+			AuditPath:     convertProof(entries[4].Proof),
+			SignedLogRoot: &rootCopy,
+			KeyID:         keyID,
+		}
+	}
+
+	for _, test := range []struct {
+		desc    string
+		modify  func(b *ProofBundle)
+		wantErr bool
+	}{
+		{desc: "valid", modify: func(b *ProofBundle) {}},
+		{desc: "unknown version", modify: func(b *ProofBundle) { b.Version = BundleVersion + 1 }, wantErr: true},
+		{desc: "no root", modify: func(b *ProofBundle) { b.SignedLogRoot = nil }, wantErr: true},
+		{desc: "wrong log", modify: func(b *ProofBundle) { b.LogID++ }, wantErr: true},
+		{desc: "wrong key ID", modify: func(b *ProofBundle) { b.KeyID = []byte("other") }, wantErr: true},
+		{desc: "modified root", modify: func(b *ProofBundle) { b.SignedLogRoot.TreeSize++ }, wantErr: true},
+		{desc: "modified value", modify: func(b *ProofBundle) { b.LeafValue = []byte("other") }, wantErr: true},
+		{desc: "wrong index", modify: func(b *ProofBundle) { b.LeafIndex = 5 }, wantErr: true},
+		{desc: "index beyond root", modify: func(b *ProofBundle) { b.LeafIndex = 7 }, wantErr: true},
+		{desc: "truncated path", modify: func(b *ProofBundle) { b.AuditPath = b.AuditPath[1:] }, wantErr: true},
+	} {
+		bundle := newBundle()
+		test.modify(bundle)
+		if err := VerifyBundle(bundle, signer.Public()); (err != nil) != test.wantErr {
+			t.Errorf("%s: VerifyBundle()=%v, want error: %v", test.desc, err, test.wantErr)
+		}
+	}
+
+	if err := VerifyBundle(newBundle(), otherKey.Public()); err == nil {
+		t.Error("VerifyBundle() with the wrong key succeeded, want error")
+	}
+}
+
+func TestVerifyBundleWithRootKeys(t *testing.T) {
+	signer, root, entries := newBundleLog(t, 7)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey(): %v", err)
+	}
+	other := crypto.NewSigner(otherKey)
+	thirdKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey(): %v", err)
+	}
+
+	// The root is cosigned by other, as by a log with a cosigner.
+	for _, s := range []*crypto.Signer{signer, other} {
+		keyID, err := crypto.KeyID(s.Public())
+		if err != nil {
+			t.Fatalf("KeyID(): %v", err)
+		}
+		sig, err := s.SignLogRoot(*root)
+		if err != nil {
+			t.Fatalf("SignLogRoot(): %v", err)
+		}
+		root.Signatures = append(root.Signatures, &trillian.LogRootSignature{KeyId: keyID, Signature: sig})
+	}
+	otherID, err := crypto.KeyID(other.Public())
+	if err != nil {
+		t.Fatalf("KeyID(): %v", err)
+	}
+	bundle := &ProofBundle{
+		Version:       BundleVersion,
+		LogID:         bundleLogID,
+		LeafValue:     entries[4].Leaf.LeafValue,
+		LeafIndex:     4,
+		AuditPath:     convertProof(entries[4].Proof),
+		SignedLogRoot: root,
+		KeyID:         otherID,
+	}
+
+	// A cosigner's signature is enough for VerifyBundle.
+	if err := VerifyBundle(bundle, other.Public()); err != nil {
+		t.Errorf("VerifyBundle() with the cosigner's key: %v", err)
+	}
+
+	allKeys := []gocrypto.PublicKey{signer.Public(), other.Public(), thirdKey.Public()}
+	for _, test := range []struct {
+		desc      string
+		keys      []gocrypto.PublicKey
+		threshold int
+		wantErr   bool
+	}{
+		{desc: "all signed", keys: allKeys[:2], threshold: 2},
+		{desc: "enough signed", keys: allKeys, threshold: 2},
+		{desc: "too few signed", keys: allKeys, threshold: 3, wantErr: true},
+		{desc: "same key twice", keys: []gocrypto.PublicKey{signer.Public(), signer.Public()}, threshold: 2, wantErr: true},
+		{desc: "zero threshold", keys: allKeys, threshold: 0, wantErr: true},
+		{desc: "threshold above keys", keys: allKeys[:1], threshold: 2, wantErr: true},
+	} {
+		if err := VerifyBundleWithRootKeys(bundle, test.keys, test.threshold); (err != nil) != test.wantErr {
+			t.Errorf("%s: VerifyBundleWithRootKeys()=%v, want error: %v", test.desc, err, test.wantErr)
+		}
+	}
+
+	modified := *bundle
+	modified.LeafIndex = 5
+	if err := VerifyBundleWithRootKeys(&modified, allKeys, 2); err == nil {
+		t.Error("VerifyBundleWithRootKeys() with the wrong index succeeded, want error")
+	}
+}
+
+func TestParseBundleUnknownVersion(t *testing.T) {
+	if _, err := ParseBundle([]byte(`{"version": 2}`)); err == nil {
+		t.Error("ParseBundle() of unknown version succeeded, want error")
+	}
+	if _, err := ParseBundle([]byte(`not json`)); err == nil {
+		t.Error("ParseBundle() of garbage succeeded, want error")
+	}
+}
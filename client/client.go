@@ -41,6 +41,12 @@ type LogClient struct {
 	hasher merkle.TreeHasher
 	root   trillian.SignedLogRoot
 	pubKey gocrypto.PublicKey
+	// If threshold is non-zero, roots must be signed by at least threshold of
+	// rootKeys instead of by pubKey.
+	rootKeys  []gocrypto.PublicKey
+	threshold int
+	// If evidenceDir is set, evidence of log misbehaviour is written there.
+	evidenceDir string
 }
 
 // New returns a new LogClient.
@@ -53,6 +59,27 @@ func New(logID int64, client trillian.TrillianLogClient, hasher merkle.TreeHashe
 	}
 }
 
+// NewWithRootKeys returns a new LogClient for a log whose roots are signed by
+// multiple keys, and which accepts roots signed by at least threshold of rootKeys.
+func NewWithRootKeys(logID int64, client trillian.TrillianLogClient, hasher merkle.TreeHasher, rootKeys []gocrypto.PublicKey, threshold int) (VerifyingLogClient, error) {
+	if threshold <= 0 || threshold > len(rootKeys) {
+		return nil, fmt.Errorf("threshold must be between 1 and %d, got %d", len(rootKeys), threshold)
+	}
+	return &LogClient{
+		LogID:     logID,
+		client:    client,
+		hasher:    hasher,
+		rootKeys:  rootKeys,
+		threshold: threshold,
+	}, nil
+}
+
+// SetEvidenceDir makes the client write MisbehaviourEvidence to a file in dir
+// whenever it finds that the log has signed inconsistent roots.
+func (c *LogClient) SetEvidenceDir(dir string) {
+	c.evidenceDir = dir
+}
+
 // Root returns the last valid root seen by UpdateRoot.
 // Returns an empty SignedLogRoot if UpdateRoot has not been called.
 func (c *LogClient) Root() trillian.SignedLogRoot {
@@ -180,8 +207,7 @@ func (c *LogClient) UpdateRoot(ctx context.Context) error {
 	str := resp.SignedLogRoot
 
 	// Verify SignedLogRoot signature.
-	hash := crypto.HashLogRoot(*str)
-	if err := crypto.Verify(c.pubKey, hash, str.Signature); err != nil {
+	if err := c.verifyRootSignature(*str); err != nil {
 		return err
 	}
 
@@ -193,6 +219,16 @@ func (c *LogClient) UpdateRoot(ctx context.Context) error {
 	}
 
 	// Implicitly trust the first root we get.
+	if c.root.TreeSize != 0 && str.TreeSize == c.root.TreeSize {
+		return c.misbehaviour(ctx, *str, nil, errors.New("roots for the same tree size have different hashes"))
+	}
+	if str.TreeSize < c.root.TreeSize {
+		if str.TimestampNanos > c.root.TimestampNanos {
+			return c.misbehaviour(ctx, *str, nil, fmt.Errorf("root for tree size %d is newer than root for tree size %d", str.TreeSize, c.root.TreeSize))
+		}
+		// An older root, e.g. served by a lagging replica, isn't misbehaviour.
+		return fmt.Errorf("log served root for tree size %d, older than the root for tree size %d already seen", str.TreeSize, c.root.TreeSize)
+	}
 	if c.root.TreeSize != 0 {
 		// Get consistency proof.
 		req := &trillian.GetConsistencyProofRequest{
@@ -206,10 +242,14 @@ func (c *LogClient) UpdateRoot(ctx context.Context) error {
 		}
 		// Verify consistency proof.
 		v := merkle.NewLogVerifier(c.hasher)
+		neighbors := convertProof(proof.Proof)
 		if err := v.VerifyConsistencyProof(
 			c.root.TreeSize, str.TreeSize,
 			c.root.RootHash, str.RootHash,
-			convertProof(proof.Proof)); err != nil {
+			neighbors); err != nil {
+			if str.TreeSize > c.root.TreeSize {
+				return c.misbehaviour(ctx, *str, neighbors, err)
+			}
 			return err
 		}
 	}
@@ -217,6 +257,121 @@ func (c *LogClient) UpdateRoot(ctx context.Context) error {
 	return nil
 }
 
+// verifyRootSignature checks the signature of root, or its signatures if the
+// client was created with NewWithRootKeys.
+func (c *LogClient) verifyRootSignature(root trillian.SignedLogRoot) error {
+	if c.threshold > 0 {
+		return crypto.VerifyLogRootSignatures(c.rootKeys, root, c.threshold)
+	}
+	return crypto.Verify(c.pubKey, crypto.HashLogRoot(root), root.Signature)
+}
+
+// misbehaviour returns a MisbehaviourError for the inconsistency, described by
+// err, between the client's current root and the validly signed newer root, and
+// writes its evidence to a file if the client has an evidence directory. Unless
+// the roots are conclusive by themselves, it asks the log for conflicting
+// leaves, without which the evidence is inconclusive.
+func (c *LogClient) misbehaviour(ctx context.Context, newRoot trillian.SignedLogRoot, consistencyProof [][]byte, err error) error {
+	// The evidence holds the roots in order of tree size.
+	first, second := c.root, newRoot
+	if first.TreeSize > second.TreeSize {
+		first, second = second, first
+	}
+	keyID, kerr := c.signingKeyID(first, second)
+	if kerr != nil {
+		return fmt.Errorf("%v (no evidence: %v)", err, kerr)
+	}
+	misErr := &MisbehaviourError{
+		Evidence: &MisbehaviourEvidence{
+			Version:          EvidenceVersion,
+			LogID:            c.LogID,
+			KeyID:            keyID,
+			FirstRoot:        &first,
+			SecondRoot:       &second,
+			ConsistencyProof: consistencyProof,
+		},
+		Err: err,
+	}
+	if first.TreeSize < second.TreeSize && first.TimestampNanos <= second.TimestampNanos {
+		l1, l2, lerr := c.conflictingLeaves(ctx, first, second)
+		switch {
+		case lerr != nil:
+			misErr.Err = fmt.Errorf("%v (evidence inconclusive, failed to get conflicting leaves: %v)", err, lerr)
+		case l1 == nil:
+			misErr.Err = fmt.Errorf("%v (evidence inconclusive, log served no conflicting leaves)", err)
+		default:
+			misErr.Evidence.FirstLeaf, misErr.Evidence.SecondLeaf = l1, l2
+		}
+	}
+	if len(c.evidenceDir) > 0 {
+		file, werr := WriteEvidence(c.evidenceDir, misErr.Evidence)
+		if werr != nil {
+			misErr.Err = fmt.Errorf("%v (failed to write evidence: %v)", err, werr)
+		}
+		misErr.EvidenceFile = file
+	}
+	return misErr
+}
+
+// conflictingLeaves returns leaves at the same index with different values,
+// which the log proves are included in first and second respectively, or nil
+// leaves if it serves none. It reads every leaf of the smaller tree at worst,
+// so is only for use once the log has been seen to misbehave.
+func (c *LogClient) conflictingLeaves(ctx context.Context, first, second trillian.SignedLogRoot) (*LeafProof, *LeafProof, error) {
+	for i := int64(0); i < first.TreeSize; i++ {
+		l1, err := c.provedLeaf(ctx, i, first)
+		if err != nil {
+			return nil, nil, err
+		}
+		l2, err := c.provedLeaf(ctx, i, second)
+		if err != nil {
+			return nil, nil, err
+		}
+		if l1 != nil && l2 != nil && !bytes.Equal(l1.LeafValue, l2.LeafValue) {
+			return l1, l2, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+// provedLeaf returns the leaf at index in the tree of root with its inclusion
+// proof, or nil if the log doesn't serve a proof which verifies against root.
+func (c *LogClient) provedLeaf(ctx context.Context, index int64, root trillian.SignedLogRoot) (*LeafProof, error) {
+	req := &trillian.GetEntryAndProofRequest{
+		LogId:     c.LogID,
+		LeafIndex: index,
+		TreeSize:  root.TreeSize,
+	}
+	resp, err := c.client.GetEntryAndProof(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Leaf == nil || resp.Proof == nil {
+		return nil, nil
+	}
+	path := convertProof(resp.Proof)
+	v := merkle.NewLogVerifier(c.hasher)
+	if err := v.VerifyInclusionProof(index, root.TreeSize, path, root.RootHash, c.hasher.HashLeaf(resp.Leaf.LeafValue)); err != nil {
+		return nil, nil
+	}
+	return &LeafProof{LeafValue: resp.Leaf.LeafValue, LeafIndex: index, AuditPath: path}, nil
+}
+
+// signingKeyID returns the ID of a key whose signature is on both roots, either
+// as the root's signature or among its signatures.
+func (c *LogClient) signingKeyID(root1, root2 trillian.SignedLogRoot) ([]byte, error) {
+	keys := c.rootKeys
+	if c.threshold == 0 {
+		keys = []gocrypto.PublicKey{c.pubKey}
+	}
+	for _, key := range keys {
+		if verifySignedBy(key, root1) == nil && verifySignedBy(key, root2) == nil {
+			return crypto.KeyID(key)
+		}
+	}
+	return nil, errors.New("no key signed both roots")
+}
+
 func (c *LogClient) getInclusionProof(ctx context.Context, leafHash []byte, treeSize int64) error {
 	req := &trillian.GetInclusionProofByHashRequest{
 		LogId:    c.LogID,
@@ -97,3 +97,28 @@ func (c *MockLogClient) GetLeavesByHash(ctx context.Context, in *trillian.GetLea
 func (c *MockLogClient) GetEntryAndProof(ctx context.Context, in *trillian.GetEntryAndProofRequest, opts ...grpc.CallOption) (*trillian.GetEntryAndProofResponse, error) {
 	return c.c.GetEntryAndProof(ctx, in)
 }
+
+// GetLeafSubmitters forwards requests.
+func (c *MockLogClient) GetLeafSubmitters(ctx context.Context, in *trillian.GetLeafSubmittersRequest, opts ...grpc.CallOption) (*trillian.GetLeafSubmittersResponse, error) {
+	return c.c.GetLeafSubmitters(ctx, in)
+}
+
+// WaitForInclusion forwards requests.
+func (c *MockLogClient) WaitForInclusion(ctx context.Context, in *trillian.WaitForInclusionRequest, opts ...grpc.CallOption) (*trillian.WaitForInclusionResponse, error) {
+	return c.c.WaitForInclusion(ctx, in)
+}
+
+// ListDeadLetterLeaves forwards requests.
+func (c *MockLogClient) ListDeadLetterLeaves(ctx context.Context, in *trillian.ListDeadLetterLeavesRequest, opts ...grpc.CallOption) (*trillian.ListDeadLetterLeavesResponse, error) {
+	return c.c.ListDeadLetterLeaves(ctx, in)
+}
+
+// RequeueDeadLetterLeaves forwards requests.
+func (c *MockLogClient) RequeueDeadLetterLeaves(ctx context.Context, in *trillian.RequeueDeadLetterLeavesRequest, opts ...grpc.CallOption) (*trillian.RequeueDeadLetterLeavesResponse, error) {
+	return c.c.RequeueDeadLetterLeaves(ctx, in)
+}
+
+// DiscardDeadLetterLeaves forwards requests.
+func (c *MockLogClient) DiscardDeadLetterLeaves(ctx context.Context, in *trillian.DiscardDeadLetterLeavesRequest, opts ...grpc.CallOption) (*trillian.DiscardDeadLetterLeavesResponse, error) {
+	return c.c.DiscardDeadLetterLeaves(ctx, in)
+}
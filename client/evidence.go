@@ -0,0 +1,222 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	gocrypto "crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto"
+	"github.com/google/trillian/merkle"
+)
+
+// EvidenceVersion is the version of the MisbehaviourEvidence format produced
+// by this package.
+const EvidenceVersion = 1
+
+// LeafProof is a leaf along with the audit path proving its inclusion in a
+// root.
+type LeafProof struct {
+	LeafValue []byte   `json:"leaf_value"`
+	LeafIndex int64    `json:"leaf_index"`
+	AuditPath [][]byte `json:"audit_path"`
+}
+
+// MisbehaviourEvidence shows that a log signed two roots which can't both be
+// part of a single append-only history. It can be checked with
+// VerifyMisbehaviour given only the log's public key.
+//
+// Two roots for trees of the same size but with different hashes are
+// conclusive by themselves, as is a root for a smaller tree with a later
+// timestamp than a root for a bigger one, since the log's tree shrank.
+// Otherwise, the evidence must be a pair of leaves at the same index with
+// different values, each proved to be included in one of the roots. The
+// consistency proof the log served between the roots is kept for reference,
+// but proofs aren't signed, so anyone could make one which fails to verify,
+// and it's inconclusive by itself.
+type MisbehaviourEvidence struct {
+	// Version is the format version of the evidence, see EvidenceVersion.
+	Version int `json:"version"`
+	// LogID is the ID of the misbehaving log.
+	LogID int64 `json:"log_id"`
+	// KeyID identifies the key that signed both roots, see crypto.KeyID. Its
+	// signature may be either the root's signature or among its signatures.
+	KeyID []byte `json:"key_id"`
+	// FirstRoot and SecondRoot are the inconsistent roots. FirstRoot is not
+	// for a bigger tree than SecondRoot.
+	FirstRoot  *trillian.SignedLogRoot `json:"first_root"`
+	SecondRoot *trillian.SignedLogRoot `json:"second_root"`
+	// ConsistencyProof is the consistency proof from FirstRoot to SecondRoot
+	// served by the log. It's inconclusive, see ErrInconclusive.
+	ConsistencyProof [][]byte `json:"consistency_proof,omitempty"`
+	// FirstLeaf and SecondLeaf are conflicting leaves included in FirstRoot and
+	// SecondRoot respectively.
+	FirstLeaf  *LeafProof `json:"first_leaf,omitempty"`
+	SecondLeaf *LeafProof `json:"second_leaf,omitempty"`
+}
+
+// ErrInconclusive is returned by VerifyMisbehaviour for evidence of roots for
+// trees of different sizes without conflicting leaves, whose only evidence of
+// misbehaviour is an unsigned consistency proof which fails to verify.
+var ErrInconclusive = errors.New("evidence is inconclusive without conflicting leaves")
+
+// MisbehaviourError is returned when a client detects that a log has
+// misbehaved.
+type MisbehaviourError struct {
+	Evidence *MisbehaviourEvidence
+	// EvidenceFile is the file Evidence was written to, if any.
+	EvidenceFile string
+	// Err describes the failed check.
+	Err error
+}
+
+func (e *MisbehaviourError) Error() string {
+	return fmt.Sprintf("log %d misbehaved: %v", e.Evidence.LogID, e.Err)
+}
+
+// VerifyMisbehaviour returns nil if evidence shows that the log whose public key
+// is pubKey misbehaved, or an error saying why it doesn't, which is
+// ErrInconclusive if the evidence may be true but can't be proved.
+func VerifyMisbehaviour(evidence *MisbehaviourEvidence, pubKey gocrypto.PublicKey) error {
+	if evidence.Version != EvidenceVersion {
+		return fmt.Errorf("unsupported evidence version %d, want %d", evidence.Version, EvidenceVersion)
+	}
+	keyID, err := crypto.KeyID(pubKey)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(evidence.KeyID, keyID) {
+		return fmt.Errorf("evidence is for key %x, not %x", evidence.KeyID, keyID)
+	}
+
+	first, second := evidence.FirstRoot, evidence.SecondRoot
+	if first == nil || second == nil {
+		return errors.New("evidence needs two roots")
+	}
+	for _, root := range []*trillian.SignedLogRoot{first, second} {
+		if root.LogId != evidence.LogID {
+			return fmt.Errorf("evidence is for log %d but has a root for log %d", evidence.LogID, root.LogId)
+		}
+		if err := verifySignedBy(pubKey, *root); err != nil {
+			return fmt.Errorf("invalid root signature: %v", err)
+		}
+	}
+	if first.TreeSize > second.TreeSize {
+		return fmt.Errorf("first root has tree size %d, more than second root's %d", first.TreeSize, second.TreeSize)
+	}
+
+	// TODO(al): Hasher must be selected based on log config.
+	hasher, err := merkle.Factory(merkle.RFC6962SHA256Type)
+	if err != nil {
+		return err
+	}
+	v := merkle.NewLogVerifier(hasher)
+
+	switch {
+	case evidence.FirstLeaf != nil || evidence.SecondLeaf != nil:
+		l1, l2 := evidence.FirstLeaf, evidence.SecondLeaf
+		if l1 == nil || l2 == nil {
+			return errors.New("evidence needs two conflicting leaves")
+		}
+		if l1.LeafIndex != l2.LeafIndex || bytes.Equal(l1.LeafValue, l2.LeafValue) {
+			return errors.New("leaves don't conflict")
+		}
+		if err := v.VerifyInclusionProof(l1.LeafIndex, first.TreeSize, l1.AuditPath, first.RootHash, hasher.HashLeaf(l1.LeafValue)); err != nil {
+			return fmt.Errorf("first leaf isn't included in first root: %v", err)
+		}
+		if err := v.VerifyInclusionProof(l2.LeafIndex, second.TreeSize, l2.AuditPath, second.RootHash, hasher.HashLeaf(l2.LeafValue)); err != nil {
+			return fmt.Errorf("second leaf isn't included in second root: %v", err)
+		}
+		return nil
+	case first.TreeSize == second.TreeSize:
+		if bytes.Equal(first.RootHash, second.RootHash) {
+			return errors.New("roots are for the same tree")
+		}
+		return nil
+	case first.TimestampNanos > second.TimestampNanos:
+		// The tree shrank.
+		return nil
+	default:
+		if err := v.VerifyConsistencyProof(first.TreeSize, second.TreeSize, first.RootHash, second.RootHash, evidence.ConsistencyProof); err == nil {
+			return errors.New("consistency proof verifies")
+		}
+		return ErrInconclusive
+	}
+}
+
+// verifySignedBy returns nil if root is validly signed by pubKey, either as its
+// signature or among its signatures.
+func verifySignedBy(pubKey gocrypto.PublicKey, root trillian.SignedLogRoot) error {
+	hash := crypto.HashLogRoot(root)
+	err := crypto.Verify(pubKey, hash, root.Signature)
+	if err == nil {
+		return nil
+	}
+	keyID, kerr := crypto.KeyID(pubKey)
+	if kerr != nil {
+		return kerr
+	}
+	for _, sig := range root.Signatures {
+		if bytes.Equal(sig.KeyId, keyID) {
+			return crypto.Verify(pubKey, hash, sig.Signature)
+		}
+	}
+	return err
+}
+
+// MarshalEvidence serializes evidence, for ParseEvidence to read back.
+func MarshalEvidence(evidence *MisbehaviourEvidence) ([]byte, error) {
+	return json.Marshal(evidence)
+}
+
+// ParseEvidence reads evidence serialized by MarshalEvidence. It fails if the
+// evidence is of a version this package doesn't understand, but doesn't verify
+// it.
+func ParseEvidence(data []byte) (*MisbehaviourEvidence, error) {
+	var evidence MisbehaviourEvidence
+	if err := json.Unmarshal(data, &evidence); err != nil {
+		return nil, fmt.Errorf("failed to parse evidence: %v", err)
+	}
+	if evidence.Version != EvidenceVersion {
+		return nil, fmt.Errorf("unsupported evidence version %d, want %d", evidence.Version, EvidenceVersion)
+	}
+	return &evidence, nil
+}
+
+// WriteEvidence writes evidence to a new file in dir, and returns the file's
+// path.
+func WriteEvidence(dir string, evidence *MisbehaviourEvidence) (string, error) {
+	data, err := MarshalEvidence(evidence)
+	if err != nil {
+		return "", err
+	}
+	f, err := ioutil.TempFile(dir, fmt.Sprintf("log-%d-misbehaviour-", evidence.LogID))
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	return f.Name(), nil
+}
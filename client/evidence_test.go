@@ -0,0 +1,383 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	gocrypto "crypto"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/mockclient"
+	"github.com/google/trillian/testonly"
+)
+
+// signedTree returns a tree holding values, and its root signed by signer.
+func signedTree(t *testing.T, signer *crypto.Signer, values ...string) (*merkle.InMemoryMerkleTree, *trillian.SignedLogRoot) {
+	tree := merkle.NewInMemoryMerkleTree(testonly.Hasher)
+	for _, value := range values {
+		tree.AddLeaf([]byte(value))
+	}
+	root := &trillian.SignedLogRoot{
+		LogId:    bundleLogID,
+		TreeSize: tree.LeafCount(),
+		RootHash: tree.CurrentRoot().Hash(),
+	}
+	var err error
+	if root.Signature, err = signer.SignLogRoot(*root); err != nil {
+		t.Fatalf("SignLogRoot(): %v", err)
+	}
+	return tree, root
+}
+
+// signedAt returns a copy of root with the given timestamp, signed by signer.
+func signedAt(t *testing.T, signer *crypto.Signer, root *trillian.SignedLogRoot, timestamp int64) *trillian.SignedLogRoot {
+	r := *root
+	r.TimestampNanos = timestamp
+	var err error
+	if r.Signature, err = signer.SignLogRoot(r); err != nil {
+		t.Fatalf("SignLogRoot(): %v", err)
+	}
+	return &r
+}
+
+func hashes(path []merkle.TreeEntryDescriptor) [][]byte {
+	h := make([][]byte, len(path))
+	for i, node := range path {
+		h[i] = node.Value.Hash()
+	}
+	return h
+}
+
+// proof returns path as served by the log.
+func proof(path []merkle.TreeEntryDescriptor) *trillian.Proof {
+	p := &trillian.Proof{}
+	for _, h := range hashes(path) {
+		p.ProofNode = append(p.ProofNode, &trillian.Node{NodeHash: h})
+	}
+	return p
+}
+
+func TestVerifyMisbehaviour(t *testing.T) {
+	signer := newTestSigner(t)
+	keyID, err := crypto.KeyID(signer.Public())
+	if err != nil {
+		t.Fatalf("KeyID(): %v", err)
+	}
+
+	tree3, root3 := signedTree(t, signer, "a", "b", "c")
+	tree5, root5 := signedTree(t, signer, "a", "b", "c", "d", "e")
+	forkTree3, forkRoot3 := signedTree(t, signer, "a", "x", "c")
+	forkTree5, forkRoot5 := signedTree(t, signer, "a", "x", "c", "d", "e")
+
+	for _, test := range []struct {
+		desc             string
+		evidence         MisbehaviourEvidence
+		wantErr          bool
+		wantInconclusive bool
+	}{
+		{
+			desc:     "same size, different hashes",
+			evidence: MisbehaviourEvidence{FirstRoot: root3, SecondRoot: forkRoot3},
+		},
+		{
+			desc:     "same root",
+			evidence: MisbehaviourEvidence{FirstRoot: root3, SecondRoot: root3},
+			wantErr:  true,
+		},
+		{
+			desc: "conflicting leaves",
+			evidence: MisbehaviourEvidence{
+				FirstRoot:  root3,
+				SecondRoot: forkRoot5,
+				FirstLeaf:  &LeafProof{LeafValue: []byte("b"), LeafIndex: 1, AuditPath: hashes(tree3.PathToCurrentRoot(2))},
+				SecondLeaf: &LeafProof{LeafValue: []byte("x"), LeafIndex: 1, AuditPath: hashes(forkTree5.PathToCurrentRoot(2))},
+			},
+		},
+		{
+			desc: "leaves not included",
+			evidence: MisbehaviourEvidence{
+				FirstRoot:  root3,
+				SecondRoot: forkRoot5,
+				FirstLeaf:  &LeafProof{LeafValue: []byte("b"), LeafIndex: 1, AuditPath: hashes(tree3.PathToCurrentRoot(2))},
+				SecondLeaf: &LeafProof{LeafValue: []byte("y"), LeafIndex: 1, AuditPath: hashes(forkTree5.PathToCurrentRoot(2))},
+			},
+			wantErr: true,
+		},
+		{
+			desc: "same leaves",
+			evidence: MisbehaviourEvidence{
+				FirstRoot:  root3,
+				SecondRoot: root5,
+				FirstLeaf:  &LeafProof{LeafValue: []byte("b"), LeafIndex: 1, AuditPath: hashes(tree3.PathToCurrentRoot(2))},
+				SecondLeaf: &LeafProof{LeafValue: []byte("b"), LeafIndex: 1, AuditPath: hashes(tree5.PathToCurrentRoot(2))},
+			},
+			wantErr: true,
+		},
+		{
+			desc:             "failed consistency proof",
+			evidence:         MisbehaviourEvidence{FirstRoot: root3, SecondRoot: forkRoot5, ConsistencyProof: hashes(forkTree5.SnapshotConsistency(3, 5))},
+			wantErr:          true,
+			wantInconclusive: true,
+		},
+		{
+			desc:             "missing consistency proof",
+			evidence:         MisbehaviourEvidence{FirstRoot: root3, SecondRoot: root5},
+			wantErr:          true,
+			wantInconclusive: true,
+		},
+		{
+			desc:     "valid consistency proof",
+			evidence: MisbehaviourEvidence{FirstRoot: root3, SecondRoot: root5, ConsistencyProof: hashes(tree5.SnapshotConsistency(3, 5))},
+			wantErr:  true,
+		},
+		{
+			desc:     "smaller root signed later",
+			evidence: MisbehaviourEvidence{FirstRoot: signedAt(t, signer, root3, 2), SecondRoot: signedAt(t, signer, root5, 1)},
+		},
+		{
+			desc:     "smaller root signed earlier",
+			evidence: MisbehaviourEvidence{FirstRoot: signedAt(t, signer, root3, 1), SecondRoot: signedAt(t, signer, root5, 2), ConsistencyProof: hashes(tree5.SnapshotConsistency(3, 5))},
+			wantErr:  true,
+		},
+		{
+			desc:     "roots out of order",
+			evidence: MisbehaviourEvidence{FirstRoot: forkRoot5, SecondRoot: root3},
+			wantErr:  true,
+		},
+		{
+			desc:     "missing root",
+			evidence: MisbehaviourEvidence{FirstRoot: root3},
+			wantErr:  true,
+		},
+		{
+			desc:     "unsigned root",
+			evidence: MisbehaviourEvidence{FirstRoot: root3, SecondRoot: &trillian.SignedLogRoot{LogId: bundleLogID, TreeSize: 3, RootHash: forkTree3.CurrentRoot().Hash()}},
+			wantErr:  true,
+		},
+	} {
+		test.evidence.Version = EvidenceVersion
+		test.evidence.LogID = bundleLogID
+		test.evidence.KeyID = keyID
+
+		// The evidence should survive serialization.
+		data, err := MarshalEvidence(&test.evidence)
+		if err != nil {
+			t.Errorf("%s: MarshalEvidence(): %v", test.desc, err)
+			continue
+		}
+		evidence, err := ParseEvidence(data)
+		if err != nil {
+			t.Errorf("%s: ParseEvidence(): %v", test.desc, err)
+			continue
+		}
+		err = VerifyMisbehaviour(evidence, signer.Public())
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: VerifyMisbehaviour()=%v, want error: %v", test.desc, err, test.wantErr)
+		}
+		if (err == ErrInconclusive) != test.wantInconclusive {
+			t.Errorf("%s: VerifyMisbehaviour()=%v, want ErrInconclusive: %v", test.desc, err, test.wantInconclusive)
+		}
+	}
+}
+
+func TestUpdateRootWritesEvidence(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir, err := ioutil.TempDir("", "evidence")
+	if err != nil {
+		t.Fatalf("TempDir(): %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	signer := newTestSigner(t)
+	_, root3 := signedTree(t, signer, "a", "b", "c")
+	_, forkRoot3 := signedTree(t, signer, "a", "x", "c")
+
+	mockClient := mockclient.NewMockTrillianLogClient(ctrl)
+	mockClient.EXPECT().GetLatestSignedLogRoot(ctx, gomock.Any()).Return(&trillian.GetLatestSignedLogRootResponse{SignedLogRoot: forkRoot3}, nil)
+
+	c := New(bundleLogID, mockClient, testonly.Hasher, signer.Public()).(*LogClient)
+	c.SetEvidenceDir(dir)
+	c.root = *root3
+
+	err = c.UpdateRoot(ctx)
+	misErr, ok := err.(*MisbehaviourError)
+	if !ok {
+		t.Fatalf("UpdateRoot()=%v, want MisbehaviourError", err)
+	}
+	data, err := ioutil.ReadFile(misErr.EvidenceFile)
+	if err != nil {
+		t.Fatalf("ReadFile(): %v", err)
+	}
+	evidence, err := ParseEvidence(data)
+	if err != nil {
+		t.Fatalf("ParseEvidence(): %v", err)
+	}
+	if err := VerifyMisbehaviour(evidence, signer.Public()); err != nil {
+		t.Errorf("VerifyMisbehaviour(): %v", err)
+	}
+	if got, want := c.Root(), *root3; got.TreeSize != want.TreeSize || string(got.RootHash) != string(want.RootHash) {
+		t.Errorf("UpdateRoot() changed root to %v, want %v", got, want)
+	}
+}
+
+func TestUpdateRootShrinkingTree(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	signer := newTestSigner(t)
+	_, root3 := signedTree(t, signer, "a", "b", "c")
+	_, root5 := signedTree(t, signer, "a", "b", "c", "d", "e")
+	oldRoot5 := signedAt(t, signer, root5, 2)
+
+	for _, test := range []struct {
+		desc           string
+		root           *trillian.SignedLogRoot
+		wantMisbehaved bool
+	}{
+		{desc: "newer", root: signedAt(t, signer, root3, 3), wantMisbehaved: true},
+		{desc: "older", root: signedAt(t, signer, root3, 1)},
+	} {
+		mockClient := mockclient.NewMockTrillianLogClient(ctrl)
+		mockClient.EXPECT().GetLatestSignedLogRoot(ctx, gomock.Any()).Return(&trillian.GetLatestSignedLogRootResponse{SignedLogRoot: test.root}, nil)
+
+		c := New(bundleLogID, mockClient, testonly.Hasher, signer.Public()).(*LogClient)
+		c.root = *oldRoot5
+
+		err := c.UpdateRoot(ctx)
+		if err == nil {
+			t.Errorf("%s: UpdateRoot()=nil, want error", test.desc)
+			continue
+		}
+		misErr, ok := err.(*MisbehaviourError)
+		if ok != test.wantMisbehaved {
+			t.Errorf("%s: UpdateRoot()=%v, want MisbehaviourError: %v", test.desc, err, test.wantMisbehaved)
+		}
+		if ok {
+			if err := VerifyMisbehaviour(misErr.Evidence, signer.Public()); err != nil {
+				t.Errorf("%s: VerifyMisbehaviour(): %v", test.desc, err)
+			}
+		}
+		if got := c.Root(); got.TreeSize != oldRoot5.TreeSize {
+			t.Errorf("%s: UpdateRoot() changed root to %v, want %v", test.desc, got, oldRoot5)
+		}
+	}
+}
+
+func TestUpdateRootEvidenceWithRootKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	signer := newTestSigner(t)
+	keyID, err := crypto.KeyID(signer.Public())
+	if err != nil {
+		t.Fatalf("KeyID(): %v", err)
+	}
+	// Move each root's signature into its signatures, as for a log with
+	// multiple root keys.
+	_, root3 := signedTree(t, signer, "a", "b", "c")
+	_, forkRoot3 := signedTree(t, signer, "a", "x", "c")
+	for _, root := range []*trillian.SignedLogRoot{root3, forkRoot3} {
+		root.Signatures = []*trillian.LogRootSignature{{KeyId: keyID, Signature: root.Signature}}
+		root.Signature = nil
+	}
+
+	mockClient := mockclient.NewMockTrillianLogClient(ctrl)
+	mockClient.EXPECT().GetLatestSignedLogRoot(ctx, gomock.Any()).Return(&trillian.GetLatestSignedLogRootResponse{SignedLogRoot: forkRoot3}, nil)
+
+	vc, err := NewWithRootKeys(bundleLogID, mockClient, testonly.Hasher, []gocrypto.PublicKey{signer.Public()}, 1)
+	if err != nil {
+		t.Fatalf("NewWithRootKeys(): %v", err)
+	}
+	c := vc.(*LogClient)
+	c.root = *root3
+
+	err = c.UpdateRoot(ctx)
+	misErr, ok := err.(*MisbehaviourError)
+	if !ok {
+		t.Fatalf("UpdateRoot()=%v, want MisbehaviourError", err)
+	}
+	if err := VerifyMisbehaviour(misErr.Evidence, signer.Public()); err != nil {
+		t.Errorf("VerifyMisbehaviour(): %v", err)
+	}
+}
+
+func TestUpdateRootFindsConflictingLeaves(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	signer := newTestSigner(t)
+	_, root3 := signedTree(t, signer, "a", "b", "c")
+	newValues := []string{"a", "x", "c", "d", "e"}
+	newTree, newRoot := signedTree(t, signer, newValues...)
+
+	for _, test := range []struct {
+		desc string
+		// oldValues are the leaves the log serves for the tree of root3.
+		oldValues []string
+		// leaves is how many leaves the client should read from each tree.
+		leaves           int64
+		wantInconclusive bool
+	}{
+		{desc: "split view", oldValues: []string{"a", "b", "c"}, leaves: 2},
+		{desc: "rewritten history", oldValues: newValues[:3], leaves: 3, wantInconclusive: true},
+	} {
+		oldTree, _ := signedTree(t, signer, test.oldValues...)
+		mockClient := mockclient.NewMockTrillianLogClient(ctrl)
+		mockClient.EXPECT().GetLatestSignedLogRoot(ctx, gomock.Any()).Return(&trillian.GetLatestSignedLogRootResponse{SignedLogRoot: newRoot}, nil)
+		mockClient.EXPECT().GetConsistencyProof(ctx, gomock.Any()).Return(&trillian.GetConsistencyProofResponse{Proof: proof(newTree.SnapshotConsistency(3, 5))}, nil)
+		for i := int64(0); i < test.leaves; i++ {
+			for _, served := range []struct {
+				tree   *merkle.InMemoryMerkleTree
+				values []string
+			}{{oldTree, test.oldValues}, {newTree, newValues}} {
+				req := &trillian.GetEntryAndProofRequest{LogId: bundleLogID, LeafIndex: i, TreeSize: served.tree.LeafCount()}
+				resp := &trillian.GetEntryAndProofResponse{
+					Leaf:  &trillian.LogLeaf{LeafIndex: i, LeafValue: []byte(served.values[i])},
+					Proof: proof(served.tree.PathToCurrentRoot(i + 1)),
+				}
+				mockClient.EXPECT().GetEntryAndProof(ctx, req).Return(resp, nil)
+			}
+		}
+
+		c := New(bundleLogID, mockClient, testonly.Hasher, signer.Public()).(*LogClient)
+		c.root = *root3
+
+		err := c.UpdateRoot(ctx)
+		misErr, ok := err.(*MisbehaviourError)
+		if !ok {
+			t.Errorf("%s: UpdateRoot()=%v, want MisbehaviourError", test.desc, err)
+			continue
+		}
+		err = VerifyMisbehaviour(misErr.Evidence, signer.Public())
+		if test.wantInconclusive && err != ErrInconclusive {
+			t.Errorf("%s: VerifyMisbehaviour()=%v, want ErrInconclusive", test.desc, err)
+		}
+		if !test.wantInconclusive && err != nil {
+			t.Errorf("%s: VerifyMisbehaviour(): %v", test.desc, err)
+		}
+	}
+}
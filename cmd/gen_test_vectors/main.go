@@ -0,0 +1,61 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package main contains the implementation and entry point for the
+// gen_test_vectors command, which writes a JSON corpus of proof and signed
+// root test vectors for verifiers written in other languages.
+//
+// Example usage:
+// $ ./gen_test_vectors --output=trillian_test_vectors.json
+//
+// Every vector is checked against Trillian's own verifiers before the corpus
+// is written.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian/testonly/vectors"
+)
+
+var output = flag.String("output", "", "File to write the corpus to, or empty for stdout")
+
+func main() {
+	flag.Parse()
+
+	c, err := vectors.Generate()
+	if err != nil {
+		glog.Exitf("Failed to generate test vectors: %v", err)
+	}
+	if err := vectors.Verify(c); err != nil {
+		glog.Exitf("Generated test vectors failed verification: %v", err)
+	}
+	j, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		glog.Exitf("Failed to marshal test vectors: %v", err)
+	}
+	j = append(j, '\n')
+
+	if *output == "" {
+		os.Stdout.Write(j)
+		return
+	}
+	if err := ioutil.WriteFile(*output, j, 0644); err != nil {
+		glog.Exitf("Failed to write %s: %v", *output, err)
+	}
+}
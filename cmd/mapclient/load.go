@@ -0,0 +1,188 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/trillian"
+)
+
+// loadOpts configure a load test.
+type loadOpts struct {
+	duration time.Duration
+	workers  int
+	// batchSize is the number of leaves in each request.
+	batchSize int
+	// readFraction is the fraction of requests which are GetLeaves.
+	readFraction float64
+	keySpace     int
+	distribution string
+	valueSize    int
+}
+
+// keyChooser returns the number of a key in [0, keySpace).
+type keyChooser func(r *rand.Rand) int
+
+// newKeyChooser returns a keyChooser for the named distribution.
+func newKeyChooser(distribution string, keySpace int) (func(r *rand.Rand) keyChooser, error) {
+	switch distribution {
+	case "uniform":
+		return func(*rand.Rand) keyChooser {
+			return func(r *rand.Rand) int { return r.Intn(keySpace) }
+		}, nil
+	case "zipf":
+		// Each worker needs its own Zipf, as they aren't safe for concurrent use.
+		return func(r *rand.Rand) keyChooser {
+			z := rand.NewZipf(r, 1.1, 1, uint64(keySpace-1))
+			return func(*rand.Rand) int { return int(z.Uint64()) }
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown key distribution %q", distribution)
+}
+
+// latencies records the outcomes of one kind of request.
+type latencies struct {
+	mu        sync.Mutex
+	durations []time.Duration
+	errors    int
+}
+
+func (l *latencies) record(d time.Duration, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err != nil {
+		l.errors++
+		return
+	}
+	l.durations = append(l.durations, d)
+}
+
+// percentile returns the p-th percentile of sorted, which must not be empty.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	i := int(float64(len(sorted))*p/100+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
+
+// report writes the throughput and latency percentiles of the successful
+// requests, which each handled batchSize leaves over elapsed.
+func (l *latencies) report(w io.Writer, name string, elapsed time.Duration, batchSize int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := len(l.durations)
+	fmt.Fprintf(w, "%s: %d ok, %d errors", name, n, l.errors)
+	if n == 0 {
+		fmt.Fprintln(w)
+		return
+	}
+	sorted := append([]time.Duration(nil), l.durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	secs := elapsed.Seconds()
+	fmt.Fprintf(w, ", %.1f req/s, %.1f leaves/s, latency p50 %v p90 %v p99 %v max %v\n",
+		float64(n)/secs, float64(n*batchSize)/secs,
+		percentile(sorted, 50), percentile(sorted, 90), percentile(sorted, 99), sorted[n-1])
+}
+
+// runLoad sends GetLeaves and SetLeaves requests to a map from opts.workers
+// goroutines for opts.duration, and then writes a report to w.
+func runLoad(ctx context.Context, client trillian.TrillianMapClient, mapID int64, opts loadOpts, w io.Writer) error {
+	switch {
+	case opts.workers < 1:
+		return fmt.Errorf("need at least one worker, got %d", opts.workers)
+	case opts.batchSize < 1:
+		return fmt.Errorf("need a batch size of at least one, got %d", opts.batchSize)
+	case opts.keySpace < opts.batchSize:
+		// Each batch uses distinct keys.
+		return fmt.Errorf("key space %d is smaller than batch size %d", opts.keySpace, opts.batchSize)
+	case opts.readFraction < 0 || opts.readFraction > 1:
+		return fmt.Errorf("read fraction %v is not between 0 and 1", opts.readFraction)
+	}
+	newChooser, err := newKeyChooser(opts.distribution, opts.keySpace)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Running %v of load on map %d with %d workers, batches of %d, %s keys from %d\n",
+		opts.duration, mapID, opts.workers, opts.batchSize, opts.distribution, opts.keySpace)
+
+	var gets, sets latencies
+	ctx, cancel := context.WithTimeout(ctx, opts.duration)
+	defer cancel()
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < opts.workers; i++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(seed))
+			choose := newChooser(r)
+			for ctx.Err() == nil {
+				indexes := chooseIndexes(r, choose, opts.batchSize)
+				reqStart := time.Now()
+				if r.Float64() < opts.readFraction {
+					_, err := client.GetLeaves(ctx, &trillian.GetMapLeavesRequest{MapId: mapID, Index: indexes, Revision: -1})
+					if ctx.Err() == nil {
+						gets.record(time.Since(reqStart), err)
+					}
+					continue
+				}
+				req := &trillian.SetMapLeavesRequest{MapId: mapID}
+				for _, idx := range indexes {
+					value := make([]byte, opts.valueSize)
+					r.Read(value)
+					req.Leaves = append(req.Leaves, &trillian.MapLeaf{Index: idx, LeafValue: value})
+				}
+				_, err := client.SetLeaves(ctx, req)
+				// Requests cut short by the end of the test aren't counted.
+				if ctx.Err() == nil {
+					sets.record(time.Since(reqStart), err)
+				}
+			}
+		}(time.Now().UnixNano() + int64(i))
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	gets.report(w, "GetLeaves", elapsed, opts.batchSize)
+	sets.report(w, "SetLeaves", elapsed, opts.batchSize)
+	return nil
+}
+
+// chooseIndexes returns n distinct map indexes of keys picked by choose.
+func chooseIndexes(r *rand.Rand, choose keyChooser, n int) [][]byte {
+	seen := make(map[int]bool)
+	indexes := make([][]byte, 0, n)
+	for len(indexes) < n {
+		k := choose(r)
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		indexes = append(indexes, index(fmt.Sprintf("key-%d", k)))
+	}
+	return indexes
+}
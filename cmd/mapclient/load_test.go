@@ -0,0 +1,122 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/trillian"
+	"github.com/google/trillian/mockclient"
+	"google.golang.org/grpc"
+)
+
+func TestKeyChooser(t *testing.T) {
+	for _, dist := range []string{"uniform", "zipf"} {
+		newChooser, err := newKeyChooser(dist, 10)
+		if err != nil {
+			t.Fatalf("newKeyChooser(%q): %v", dist, err)
+		}
+		r := rand.New(rand.NewSource(1))
+		choose := newChooser(r)
+		for i := 0; i < 1000; i++ {
+			if k := choose(r); k < 0 || k >= 10 {
+				t.Fatalf("%s: chose key %d, want in [0, 10)", dist, k)
+			}
+		}
+		if got := len(chooseIndexes(r, choose, 10)); got != 10 {
+			t.Errorf("%s: chooseIndexes() returned %d indexes, want 10", dist, got)
+		}
+	}
+	if _, err := newKeyChooser("gaussian", 10); err == nil {
+		t.Error("newKeyChooser(gaussian) succeeded, want error")
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i))
+	}
+	for _, test := range []struct {
+		p    float64
+		want time.Duration
+	}{
+		{p: 0, want: 1},
+		{p: 50, want: 50},
+		{p: 99, want: 99},
+		{p: 100, want: 100},
+	} {
+		if got := percentile(sorted, test.p); got != test.want {
+			t.Errorf("percentile(%v)=%v, want %v", test.p, got, test.want)
+		}
+	}
+}
+
+func TestRunLoad(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := mockclient.NewMockTrillianMapClient(ctrl)
+	client.EXPECT().GetLeaves(gomock.Any(), gomock.Any()).AnyTimes().Return(&trillian.GetMapLeavesResponse{}, nil)
+	client.EXPECT().SetLeaves(gomock.Any(), gomock.Any()).AnyTimes().Do(func(_ context.Context, req *trillian.SetMapLeavesRequest, _ ...grpc.CallOption) {
+		if got, want := len(req.Leaves), 3; got != want {
+			t.Errorf("SetLeaves() got %d leaves, want %d", got, want)
+		}
+		time.Sleep(time.Millisecond)
+	}).Return(nil, errors.New("write failed"))
+
+	opts := loadOpts{
+		duration:     50 * time.Millisecond,
+		workers:      2,
+		batchSize:    3,
+		readFraction: 0.5,
+		keySpace:     5,
+		distribution: "uniform",
+		valueSize:    8,
+	}
+	var out bytes.Buffer
+	if err := runLoad(context.Background(), client, testMapID, opts, &out); err != nil {
+		t.Fatalf("runLoad(): %v", err)
+	}
+	for _, want := range []string{"GetLeaves: ", "latency p50", "SetLeaves: 0 ok"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("runLoad() output %q does not contain %q", out.String(), want)
+		}
+	}
+}
+
+func TestRunLoadInvalidOpts(t *testing.T) {
+	valid := loadOpts{duration: time.Second, workers: 1, batchSize: 2, keySpace: 2, distribution: "uniform"}
+	for _, modify := range []func(o *loadOpts){
+		func(o *loadOpts) { o.workers = 0 },
+		func(o *loadOpts) { o.batchSize = 0 },
+		func(o *loadOpts) { o.keySpace = 1 },
+		func(o *loadOpts) { o.readFraction = 2 },
+		func(o *loadOpts) { o.distribution = "unknown" },
+	} {
+		opts := valid
+		modify(&opts)
+		if err := runLoad(context.Background(), nil, testMapID, opts, &bytes.Buffer{}); err == nil {
+			t.Errorf("runLoad(%+v) succeeded, want error", opts)
+		}
+	}
+}
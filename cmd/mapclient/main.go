@@ -0,0 +1,324 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package main contains the implementation and entry point for the mapclient
+// command, which reads from, writes to and load tests a Trillian Map.
+//
+// Example usage:
+// $ ./mapclient --map_server=host:port --map_id=1 set key1 value1 key2 value2
+// $ ./mapclient --map_server=host:port --map_id=1 get key1 key2
+// $ ./mapclient --map_server=host:port --map_id=1 root
+// $ ./mapclient --map_server=host:port --map_id=1 --from_revision=1 history key1
+// $ ./mapclient --map_server=host:port --map_id=1 --public_key=key.pem verify key1
+// $ ./mapclient --map_server=host:port --map_id=1 --load_duration=1m load
+//
+// Keys are hashed with SHA-256 to get their map indexes. Proofs are checked
+// against the map roots returned by the server, and root signatures are checked
+// too if --public_key is set.
+package main
+
+import (
+	"bytes"
+	"context"
+	gocrypto "crypto"
+	"crypto/sha256"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto"
+	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/merkle"
+	"google.golang.org/grpc"
+)
+
+var (
+	mapServerAddr = flag.String("map_server", "localhost:8090", "Address of the gRPC Trillian Map Server (host:port)")
+	mapID         = flag.Int64("map_id", 0, "ID of the map")
+	timeout       = flag.Duration("timeout", 10*time.Second, "Timeout for each request, apart from load tests")
+	revision      = flag.Int64("revision", -1, "Revision to read with get, or -1 for the latest")
+	fromRevision  = flag.Int64("from_revision", 0, "First revision returned by history")
+	toRevision    = flag.Int64("to_revision", -1, "Last revision returned by history, or -1 for the latest")
+	publicKeyPath = flag.String("public_key", "", "Path to the PEM public key of the map, used to verify root signatures")
+
+	loadDuration     = flag.Duration("load_duration", 30*time.Second, "How long load runs for")
+	loadWorkers      = flag.Int("load_workers", 4, "Number of concurrent requests made by load")
+	loadBatchSize    = flag.Int("load_batch_size", 10, "Number of leaves read or written by each load request")
+	loadReadFraction = flag.Float64("load_read_fraction", 0.5, "Fraction of load requests which are GetLeaves rather than SetLeaves")
+	loadKeySpace     = flag.Int("load_key_space", 10000, "Number of distinct keys used by load")
+	loadKeyDist      = flag.String("load_key_distribution", "uniform", "Distribution of the keys used by load: uniform or zipf")
+	loadValueSize    = flag.Int("load_value_size", 32, "Size in bytes of the values written by load")
+)
+
+const usage = `Usage: mapclient [flags] <command> [args]
+
+Commands:
+  get <key>...                 print the values of keys
+  set <key> <value>...         set the values of keys
+  root                         print the latest map root
+  history <key>                print the values a key had over a range of revisions
+  verify <key>...              check the inclusion proofs of keys in the latest revision
+  load                         run a load test and report throughput and latencies
+
+Flags:
+`
+
+// mapClient runs the commands against a single map.
+type mapClient struct {
+	client trillian.TrillianMapClient
+	mapID  int64
+	hasher merkle.MapHasher
+	// pubKey is used to verify root signatures, if set.
+	pubKey gocrypto.PublicKey
+	out    io.Writer
+}
+
+// index returns the map index of key.
+func index(key string) []byte {
+	h := sha256.Sum256([]byte(key))
+	return h[:]
+}
+
+func (m *mapClient) get(ctx context.Context, names []string) error {
+	if len(names) == 0 {
+		return errors.New("get needs at least one key")
+	}
+	req := &trillian.GetMapLeavesRequest{MapId: m.mapID, Revision: *revision}
+	for _, k := range names {
+		req.Index = append(req.Index, index(k))
+	}
+	resp, err := m.client.GetLeaves(ctx, req)
+	if err != nil {
+		return err
+	}
+	values := make(map[string][]byte)
+	for _, inc := range resp.MapLeafInclusion {
+		values[string(inc.GetLeaf().GetIndex())] = inc.GetLeaf().GetLeafValue()
+	}
+	for _, k := range names {
+		if v, ok := values[string(index(k))]; ok {
+			fmt.Fprintf(m.out, "%s: %q\n", k, v)
+		} else {
+			fmt.Fprintf(m.out, "%s: not set\n", k)
+		}
+	}
+	return nil
+}
+
+func (m *mapClient) set(ctx context.Context, args []string) error {
+	if len(args) == 0 || len(args)%2 != 0 {
+		return errors.New("set needs pairs of keys and values")
+	}
+	req := &trillian.SetMapLeavesRequest{MapId: m.mapID}
+	for i := 0; i < len(args); i += 2 {
+		req.Leaves = append(req.Leaves, &trillian.MapLeaf{Index: index(args[i]), LeafValue: []byte(args[i+1])})
+	}
+	resp, err := m.client.SetLeaves(ctx, req)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(m.out, "Set %d keys, map is now at revision %d with root hash %x\n", len(req.Leaves), resp.GetMapRoot().GetMapRevision(), resp.GetMapRoot().GetRootHash())
+	return nil
+}
+
+func (m *mapClient) root(ctx context.Context) error {
+	root, err := m.latestRoot(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(m.out, "Revision:  %d\nTimestamp: %v\nRoot hash: %x\n", root.MapRevision, time.Unix(0, root.TimestampNanos).UTC(), root.RootHash)
+	return nil
+}
+
+func (m *mapClient) history(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errors.New("history needs a single key")
+	}
+	idx := index(args[0])
+	resp, err := m.client.GetMapLeafHistory(ctx, &trillian.GetMapLeafHistoryRequest{
+		MapId:        m.mapID,
+		Index:        idx,
+		FromRevision: *fromRevision,
+		ToRevision:   *toRevision,
+	})
+	if err != nil {
+		return err
+	}
+	for _, e := range resp.Entries {
+		root := e.GetMapRoot()
+		if err := m.verifyRoot(root); err != nil {
+			return err
+		}
+		if err := m.verifyInclusion(idx, e.GetMapLeafInclusion(), root); err != nil {
+			return fmt.Errorf("revision %d: %v", root.GetMapRevision(), err)
+		}
+		fmt.Fprintf(m.out, "%d: %q\n", root.GetMapRevision(), e.GetMapLeafInclusion().GetLeaf().GetLeafValue())
+	}
+	return nil
+}
+
+func (m *mapClient) verify(ctx context.Context, names []string) error {
+	if len(names) == 0 {
+		return errors.New("verify needs at least one key")
+	}
+	root, err := m.latestRoot(ctx)
+	if err != nil {
+		return err
+	}
+	req := &trillian.GetMapLeavesRequest{MapId: m.mapID, Revision: root.MapRevision}
+	for _, k := range names {
+		req.Index = append(req.Index, index(k))
+	}
+	resp, err := m.client.GetLeaves(ctx, req)
+	if err != nil {
+		return err
+	}
+	found := make(map[string]*trillian.MapLeafInclusion)
+	for _, inc := range resp.MapLeafInclusion {
+		found[string(inc.GetLeaf().GetIndex())] = inc
+	}
+	for _, k := range names {
+		inc, ok := found[string(index(k))]
+		if !ok {
+			fmt.Fprintf(m.out, "%s: not set at revision %d\n", k, root.MapRevision)
+			continue
+		}
+		if err := m.verifyInclusion(index(k), inc, root); err != nil {
+			return fmt.Errorf("%s: %v", k, err)
+		}
+		fmt.Fprintf(m.out, "%s: verified at revision %d\n", k, root.MapRevision)
+	}
+	return nil
+}
+
+// latestRoot returns the latest root of the map, with its signature checked.
+func (m *mapClient) latestRoot(ctx context.Context) (*trillian.SignedMapRoot, error) {
+	resp, err := m.client.GetSignedMapRoot(ctx, &trillian.GetSignedMapRootRequest{MapId: m.mapID})
+	if err != nil {
+		return nil, err
+	}
+	root := resp.GetMapRoot()
+	if root == nil {
+		return nil, errors.New("server returned no map root")
+	}
+	if err := m.verifyRoot(root); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// verifyRoot checks the signature of root, if a public key was given.
+func (m *mapClient) verifyRoot(root *trillian.SignedMapRoot) error {
+	if m.pubKey == nil {
+		return nil
+	}
+	// Map roots are signed without their signature set.
+	unsigned := *root
+	unsigned.Signature = nil
+	if err := crypto.VerifyObject(m.pubKey, unsigned, root.GetSignature()); err != nil {
+		return fmt.Errorf("invalid signature on root of revision %d: %v", root.GetMapRevision(), err)
+	}
+	return nil
+}
+
+// verifyInclusion checks that inc proves the leaf at idx is included in root.
+func (m *mapClient) verifyInclusion(idx []byte, inc *trillian.MapLeafInclusion, root *trillian.SignedMapRoot) error {
+	leaf := inc.GetLeaf()
+	if !bytes.Equal(leaf.GetIndex(), idx) {
+		return fmt.Errorf("got leaf with index %x, want %x", leaf.GetIndex(), idx)
+	}
+	leafHash := m.hasher.HashLeaf(leaf.GetLeafValue())
+	if err := merkle.VerifyMapInclusionProof(idx, leafHash, root.GetRootHash(), inc.GetInclusion(), m.hasher); err != nil {
+		return fmt.Errorf("inclusion proof failed: %v", err)
+	}
+	return nil
+}
+
+// run runs the command given by args.
+func (m *mapClient) run(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no command given, see --help")
+	}
+	cmd, args := args[0], args[1:]
+	if cmd == "load" {
+		opts := loadOpts{
+			duration:     *loadDuration,
+			workers:      *loadWorkers,
+			batchSize:    *loadBatchSize,
+			readFraction: *loadReadFraction,
+			keySpace:     *loadKeySpace,
+			distribution: *loadKeyDist,
+			valueSize:    *loadValueSize,
+		}
+		return runLoad(context.Background(), m.client, m.mapID, opts, m.out)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	switch cmd {
+	case "get":
+		return m.get(ctx, args)
+	case "set":
+		return m.set(ctx, args)
+	case "root":
+		return m.root(ctx)
+	case "history":
+		return m.history(ctx, args)
+	case "verify":
+		return m.verify(ctx, args)
+	}
+	return fmt.Errorf("unknown command %q, see --help", cmd)
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *mapID == 0 {
+		fmt.Fprintln(os.Stderr, "--map_id must be set")
+		os.Exit(1)
+	}
+
+	h, err := merkle.Factory(merkle.RFC6962SHA256Type)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create hasher: %v\n", err)
+		os.Exit(1)
+	}
+	m := &mapClient{mapID: *mapID, hasher: merkle.NewMapHasher(h), out: os.Stdout}
+	if *publicKeyPath != "" {
+		if m.pubKey, err = keys.NewFromPublicPEMFile(*publicKeyPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read public key: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	conn, err := grpc.Dial(*mapServerAddr, grpc.WithInsecure())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to dial map server: %v\n", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+	m.client = trillian.NewTrillianMapClient(conn)
+
+	if err := m.run(flag.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
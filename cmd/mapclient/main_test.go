@@ -0,0 +1,122 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto"
+	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/mockclient"
+	"github.com/google/trillian/testonly"
+)
+
+const testMapID = 3
+
+func newTestClient(ctrl *gomock.Controller) (*mapClient, *mockclient.MockTrillianMapClient, *bytes.Buffer) {
+	client := mockclient.NewMockTrillianMapClient(ctrl)
+	out := &bytes.Buffer{}
+	return &mapClient{client: client, mapID: testMapID, hasher: merkle.NewMapHasher(testonly.Hasher), out: out}, client, out
+}
+
+func TestGet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m, client, out := newTestClient(ctrl)
+	client.EXPECT().GetLeaves(gomock.Any(), &trillian.GetMapLeavesRequest{
+		MapId:    testMapID,
+		Index:    [][]byte{index("a"), index("b")},
+		Revision: -1,
+	}).Return(&trillian.GetMapLeavesResponse{
+		MapLeafInclusion: []*trillian.MapLeafInclusion{{Leaf: &trillian.MapLeaf{Index: index("a"), LeafValue: []byte("value")}}},
+	}, nil)
+
+	if err := m.run([]string{"get", "a", "b"}); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got, want := out.String(), "a: \"value\"\nb: not set\n"; got != want {
+		t.Errorf("get printed %q, want %q", got, want)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	key, err := keys.NewFromPrivatePEM(testonly.DemoPrivateKey, testonly.DemoPrivateKeyPass)
+	if err != nil {
+		t.Fatalf("NewFromPrivatePEM(): %v", err)
+	}
+	m, client, out := newTestClient(ctrl)
+	m.pubKey = key.Public()
+
+	// A map holding just one leaf has the leaf's hash at every level of the
+	// path, and empty siblings.
+	value := []byte("value")
+	hs2 := merkle.NewHStar2(testonly.Hasher)
+	rootHash, err := hs2.HStar2Root(m.hasher.Size()*8, []merkle.HStar2LeafHash{
+		{Index: new(big.Int).SetBytes(index("a")), LeafHash: m.hasher.HashLeaf(value)},
+	})
+	if err != nil {
+		t.Fatalf("HStar2Root(): %v", err)
+	}
+	root := trillian.SignedMapRoot{MapId: testMapID, MapRevision: 2, RootHash: rootHash}
+	sig, err := crypto.NewSigner(key).SignObject(root)
+	if err != nil {
+		t.Fatalf("SignObject(): %v", err)
+	}
+	root.Signature = sig
+
+	client.EXPECT().GetSignedMapRoot(gomock.Any(), &trillian.GetSignedMapRootRequest{MapId: testMapID}).Return(&trillian.GetSignedMapRootResponse{MapRoot: &root}, nil)
+	client.EXPECT().GetLeaves(gomock.Any(), gomock.Any()).Return(&trillian.GetMapLeavesResponse{
+		MapLeafInclusion: []*trillian.MapLeafInclusion{{
+			Leaf:      &trillian.MapLeaf{Index: index("a"), LeafValue: value},
+			Inclusion: make([][]byte, m.hasher.Size()*8),
+		}},
+	}, nil)
+	if err := m.verify(context.Background(), []string{"a"}); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if got, want := out.String(), "a: verified at revision 2\n"; got != want {
+		t.Errorf("verify printed %q, want %q", got, want)
+	}
+
+	// A root with a bad signature is rejected.
+	bad := root
+	bad.RootHash = []byte("other")
+	client.EXPECT().GetSignedMapRoot(gomock.Any(), gomock.Any()).Return(&trillian.GetSignedMapRootResponse{MapRoot: &bad}, nil)
+	if err := m.verify(context.Background(), []string{"a"}); err == nil {
+		t.Error("verify succeeded with a bad root signature, want error")
+	}
+}
+
+func TestRunUnknownCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m, _, _ := newTestClient(ctrl)
+	for _, args := range [][]string{nil, {"frobnicate"}, {"set", "key"}, {"history"}} {
+		if err := m.run(args); err == nil {
+			t.Errorf("run(%v) succeeded, want error", args)
+		}
+	}
+}
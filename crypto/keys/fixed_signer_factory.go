@@ -0,0 +1,34 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"context"
+	"crypto"
+
+	"github.com/google/trillian"
+)
+
+// FixedSignerFactory returns the same signer for every tree, e.g. for a
+// cosigner whose key is its own rather than the tree's.
+// It implements keys.SignerFactory.
+type FixedSignerFactory struct {
+	Signer crypto.Signer
+}
+
+// NewSigner returns f.Signer, whatever the tree.
+func (f FixedSignerFactory) NewSigner(ctx context.Context, tree *trillian.Tree) (crypto.Signer, error) {
+	return f.Signer, nil
+}
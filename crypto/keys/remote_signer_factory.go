@@ -0,0 +1,108 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto/signerpb"
+	"github.com/google/trillian/crypto/sigpb"
+)
+
+// LogRootSignerOpts are the crypto.SignerOpts used when signing a log root.
+// They make the root available to signers that need it, such as those returned
+// by RemoteSignerFactory.
+type LogRootSignerOpts struct {
+	crypto.Hash
+	Root *trillian.SignedLogRoot
+}
+
+// RemoteSignerFactory returns signers which send their signing requests to a
+// TrillianSigner service, so that the private keys of trees don't need to be
+// held by the caller.
+// It implements keys.SignerFactory.
+type RemoteSignerFactory struct {
+	Client signerpb.TrillianSignerClient
+	// Timeout bounds each request made by a signer, as crypto.Signer.Sign
+	// doesn't take a context. Zero means no timeout.
+	Timeout time.Duration
+}
+
+// NewSigner returns a crypto.Signer for the given tree.
+// The public key of the tree is fetched from the signing service.
+func (f RemoteSignerFactory) NewSigner(ctx context.Context, tree *trillian.Tree) (crypto.Signer, error) {
+	resp, err := f.Client.GetPublicKey(ctx, &signerpb.GetPublicKeyRequest{TreeId: tree.GetTreeId()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get public key for tree %d: %v", tree.GetTreeId(), err)
+	}
+	pubKey, err := NewFromPublicDER(resp.GetPublicKeyDer())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key for tree %d: %v", tree.GetTreeId(), err)
+	}
+	return &remoteSigner{
+		client:  f.Client,
+		treeID:  tree.GetTreeId(),
+		pubKey:  pubKey,
+		timeout: f.Timeout,
+	}, nil
+}
+
+// remoteSigner is a crypto.Signer for a single tree which signs by calling a
+// TrillianSigner service.
+type remoteSigner struct {
+	client  signerpb.TrillianSignerClient
+	treeID  int64
+	pubKey  crypto.PublicKey
+	timeout time.Duration
+}
+
+// Public returns the public key of the tree.
+func (r *remoteSigner) Public() crypto.PublicKey {
+	return r.pubKey
+}
+
+// Sign asks the signing service to sign digest. If opts are LogRootSignerOpts,
+// the log root is sent along with the digest so the service can apply its
+// policy to it.
+func (r *remoteSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	req := &signerpb.SignRequest{TreeId: r.treeID, Digest: digest}
+	switch opts.HashFunc() {
+	case crypto.SHA256:
+		req.HashAlgorithm = sigpb.DigitallySigned_SHA256
+	default:
+		return nil, fmt.Errorf("unsupported hash function: %v", opts.HashFunc())
+	}
+	if o, ok := opts.(LogRootSignerOpts); ok {
+		req.LogRoot = o.Root
+	}
+
+	ctx := context.Background()
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+	resp, err := r.client.Sign(ctx, req)
+	if err != nil {
+		// Return the error unchanged, so callers can see its gRPC code.
+		return nil, err
+	}
+	return resp.GetSignature(), nil
+}
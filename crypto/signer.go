@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 
 	"github.com/benlaurie/objecthash/go/objecthash"
+	"github.com/google/trillian"
 	"github.com/google/trillian/crypto/keys"
 	"github.com/google/trillian/crypto/sigpb"
 )
@@ -52,11 +53,21 @@ func (s *Signer) Public() crypto.PublicKey {
 
 // Sign obtains a signature after first hashing the input data.
 func (s *Signer) Sign(data []byte) (*sigpb.DigitallySigned, error) {
+	return s.sign(data, s.hash)
+}
+
+// SignLogRoot signs a log root. The root is passed to the underlying signer in
+// keys.LogRootSignerOpts, for signers which check what they're asked to sign.
+func (s *Signer) SignLogRoot(root trillian.SignedLogRoot) (*sigpb.DigitallySigned, error) {
+	return s.sign(HashLogRoot(root), keys.LogRootSignerOpts{Hash: s.hash, Root: &root})
+}
+
+func (s *Signer) sign(data []byte, opts crypto.SignerOpts) (*sigpb.DigitallySigned, error) {
 	h := s.hash.New()
 	h.Write(data)
 	digest := h.Sum(nil)
 
-	sig, err := s.signer.Sign(rand.Reader, digest, s.hash)
+	sig, err := s.signer.Sign(rand.Reader, digest, opts)
 	if err != nil {
 		return nil, err
 	}
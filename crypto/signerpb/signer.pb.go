@@ -0,0 +1,282 @@
+// Code generated by protoc-gen-go.
+// source: signer.proto
+// DO NOT EDIT!
+
+/*
+Package signerpb is a generated protocol buffer package.
+
+It is generated from these files:
+	signer.proto
+
+It has these top-level messages:
+	GetPublicKeyRequest
+	GetPublicKeyResponse
+	SignRequest
+	SignResponse
+*/
+package signerpb
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+import sigpb "github.com/google/trillian/crypto/sigpb"
+import trillian "github.com/google/trillian"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+type GetPublicKeyRequest struct {
+	TreeId int64 `protobuf:"varint,1,opt,name=tree_id,json=treeId" json:"tree_id,omitempty"`
+}
+
+func (m *GetPublicKeyRequest) Reset()                    { *m = GetPublicKeyRequest{} }
+func (m *GetPublicKeyRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetPublicKeyRequest) ProtoMessage()               {}
+func (*GetPublicKeyRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
+func (m *GetPublicKeyRequest) GetTreeId() int64 {
+	if m != nil {
+		return m.TreeId
+	}
+	return 0
+}
+
+type GetPublicKeyResponse struct {
+	// DER encoded SubjectPublicKeyInfo of the tree's public key.
+	PublicKeyDer []byte `protobuf:"bytes,1,opt,name=public_key_der,json=publicKeyDer,proto3" json:"public_key_der,omitempty"`
+}
+
+func (m *GetPublicKeyResponse) Reset()                    { *m = GetPublicKeyResponse{} }
+func (m *GetPublicKeyResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetPublicKeyResponse) ProtoMessage()               {}
+func (*GetPublicKeyResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+func (m *GetPublicKeyResponse) GetPublicKeyDer() []byte {
+	if m != nil {
+		return m.PublicKeyDer
+	}
+	return nil
+}
+
+type SignRequest struct {
+	TreeId int64 `protobuf:"varint,1,opt,name=tree_id,json=treeId" json:"tree_id,omitempty"`
+	// The digest to sign.
+	Digest []byte `protobuf:"bytes,2,opt,name=digest,proto3" json:"digest,omitempty"`
+	// The hash algorithm used to produce digest.
+	HashAlgorithm sigpb.DigitallySigned_HashAlgorithm `protobuf:"varint,3,opt,name=hash_algorithm,json=hashAlgorithm,enum=sigpb.DigitallySigned_HashAlgorithm" json:"hash_algorithm,omitempty"`
+	// The log root that digest is the hash of, which is required when signing
+	// for a log. It allows the service to check that the roots it signs for a log
+	// don't go backwards in size or time, and are for that log.
+	LogRoot *trillian.SignedLogRoot `protobuf:"bytes,4,opt,name=log_root,json=logRoot" json:"log_root,omitempty"`
+}
+
+func (m *SignRequest) Reset()                    { *m = SignRequest{} }
+func (m *SignRequest) String() string            { return proto.CompactTextString(m) }
+func (*SignRequest) ProtoMessage()               {}
+func (*SignRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+func (m *SignRequest) GetTreeId() int64 {
+	if m != nil {
+		return m.TreeId
+	}
+	return 0
+}
+
+func (m *SignRequest) GetDigest() []byte {
+	if m != nil {
+		return m.Digest
+	}
+	return nil
+}
+
+func (m *SignRequest) GetHashAlgorithm() sigpb.DigitallySigned_HashAlgorithm {
+	if m != nil {
+		return m.HashAlgorithm
+	}
+	return sigpb.DigitallySigned_NONE
+}
+
+func (m *SignRequest) GetLogRoot() *trillian.SignedLogRoot {
+	if m != nil {
+		return m.LogRoot
+	}
+	return nil
+}
+
+type SignResponse struct {
+	Signature []byte `protobuf:"bytes,1,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *SignResponse) Reset()                    { *m = SignResponse{} }
+func (m *SignResponse) String() string            { return proto.CompactTextString(m) }
+func (*SignResponse) ProtoMessage()               {}
+func (*SignResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+func (m *SignResponse) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*GetPublicKeyRequest)(nil), "signerpb.GetPublicKeyRequest")
+	proto.RegisterType((*GetPublicKeyResponse)(nil), "signerpb.GetPublicKeyResponse")
+	proto.RegisterType((*SignRequest)(nil), "signerpb.SignRequest")
+	proto.RegisterType((*SignResponse)(nil), "signerpb.SignResponse")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for TrillianSigner service
+
+type TrillianSignerClient interface {
+	// GetPublicKey returns the public key of a tree.
+	GetPublicKey(ctx context.Context, in *GetPublicKeyRequest, opts ...grpc.CallOption) (*GetPublicKeyResponse, error)
+	// Sign signs a digest with the private key of a tree.
+	// Requests are subject to the signing policy of the service, which may
+	// require the log root that the digest was produced from.
+	Sign(ctx context.Context, in *SignRequest, opts ...grpc.CallOption) (*SignResponse, error)
+}
+
+type trillianSignerClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewTrillianSignerClient(cc *grpc.ClientConn) TrillianSignerClient {
+	return &trillianSignerClient{cc}
+}
+
+func (c *trillianSignerClient) GetPublicKey(ctx context.Context, in *GetPublicKeyRequest, opts ...grpc.CallOption) (*GetPublicKeyResponse, error) {
+	out := new(GetPublicKeyResponse)
+	err := grpc.Invoke(ctx, "/signerpb.TrillianSigner/GetPublicKey", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianSignerClient) Sign(ctx context.Context, in *SignRequest, opts ...grpc.CallOption) (*SignResponse, error) {
+	out := new(SignResponse)
+	err := grpc.Invoke(ctx, "/signerpb.TrillianSigner/Sign", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for TrillianSigner service
+
+type TrillianSignerServer interface {
+	// GetPublicKey returns the public key of a tree.
+	GetPublicKey(context.Context, *GetPublicKeyRequest) (*GetPublicKeyResponse, error)
+	// Sign signs a digest with the private key of a tree.
+	// Requests are subject to the signing policy of the service, which may
+	// require the log root that the digest was produced from.
+	Sign(context.Context, *SignRequest) (*SignResponse, error)
+}
+
+func RegisterTrillianSignerServer(s *grpc.Server, srv TrillianSignerServer) {
+	s.RegisterService(&_TrillianSigner_serviceDesc, srv)
+}
+
+func _TrillianSigner_GetPublicKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPublicKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianSignerServer).GetPublicKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/signerpb.TrillianSigner/GetPublicKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianSignerServer).GetPublicKey(ctx, req.(*GetPublicKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianSigner_Sign_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SignRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianSignerServer).Sign(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/signerpb.TrillianSigner/Sign",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianSignerServer).Sign(ctx, req.(*SignRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _TrillianSigner_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "signerpb.TrillianSigner",
+	HandlerType: (*TrillianSignerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetPublicKey",
+			Handler:    _TrillianSigner_GetPublicKey_Handler,
+		},
+		{
+			MethodName: "Sign",
+			Handler:    _TrillianSigner_Sign_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "signer.proto",
+}
+
+func init() { proto.RegisterFile("signer.proto", fileDescriptor0) }
+
+var fileDescriptor0 = []byte{
+	// 364 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x52, 0x4d, 0x6b, 0xdb, 0x40,
+	0x10, 0xad, 0x6a, 0x63, 0xbb, 0x6b, 0x55, 0x87, 0x6d, 0x6b, 0x0b, 0xd1, 0x16, 0x23, 0x7c, 0x70,
+	0xa1, 0xac, 0x40, 0x3e, 0xf4, 0xd2, 0x4b, 0xc0, 0x90, 0x04, 0x07, 0x12, 0x94, 0xdc, 0x85, 0x64,
+	0x0d, 0xab, 0x25, 0x6b, 0xad, 0xb2, 0xbb, 0x3a, 0xe8, 0xaf, 0xe4, 0xcf, 0xe4, 0xaf, 0x05, 0x69,
+	0xa5, 0xd8, 0x09, 0xf9, 0xb8, 0x88, 0x79, 0xa3, 0xf7, 0xde, 0xf0, 0x66, 0x16, 0xd9, 0x8a, 0xd1,
+	0x02, 0x24, 0x29, 0xa5, 0xd0, 0x02, 0x4f, 0x0c, 0x2a, 0x53, 0x6f, 0x4d, 0x99, 0xce, 0xab, 0x94,
+	0xec, 0xc4, 0x3e, 0xa0, 0x42, 0x50, 0x0e, 0x81, 0x96, 0x8c, 0x73, 0x96, 0x14, 0xc1, 0x4e, 0xd6,
+	0xa5, 0x16, 0x81, 0x62, 0xb4, 0x4c, 0xcd, 0xd7, 0xc8, 0xbd, 0x3f, 0xef, 0x88, 0xfa, 0xc2, 0x50,
+	0x7d, 0x82, 0xbe, 0x9d, 0x82, 0xbe, 0xaa, 0x52, 0xce, 0x76, 0x5b, 0xa8, 0x23, 0xb8, 0xab, 0x40,
+	0x69, 0x3c, 0x47, 0x63, 0x2d, 0x01, 0x62, 0x96, 0xb9, 0xd6, 0xc2, 0x5a, 0x0d, 0xa2, 0x51, 0x03,
+	0xcf, 0x33, 0xff, 0x3f, 0xfa, 0xfe, 0x9c, 0xaf, 0x4a, 0x51, 0x28, 0xc0, 0x4b, 0xe4, 0x94, 0x6d,
+	0x33, 0xbe, 0x85, 0x3a, 0xce, 0x40, 0xb6, 0x3a, 0x3b, 0xb2, 0xcb, 0x9e, 0xba, 0x01, 0xe9, 0x3f,
+	0x58, 0x68, 0x7a, 0xcd, 0x68, 0xf1, 0xd1, 0x18, 0x3c, 0x43, 0xa3, 0x8c, 0x51, 0x50, 0xda, 0xfd,
+	0xdc, 0xda, 0x74, 0x08, 0x6f, 0x91, 0x93, 0x27, 0x2a, 0x8f, 0x13, 0x4e, 0x85, 0x64, 0x3a, 0xdf,
+	0xbb, 0x83, 0x85, 0xb5, 0x72, 0xc2, 0x25, 0x31, 0xf9, 0x37, 0x8c, 0x32, 0x9d, 0x70, 0x5e, 0x37,
+	0x53, 0x20, 0x23, 0x67, 0x89, 0xca, 0x4f, 0x7a, 0x6e, 0xf4, 0x35, 0x3f, 0x86, 0x38, 0x44, 0x13,
+	0x2e, 0x68, 0x2c, 0x85, 0xd0, 0xee, 0x70, 0x61, 0xad, 0xa6, 0xe1, 0x9c, 0x3c, 0xad, 0xc7, 0x18,
+	0x5c, 0x08, 0x1a, 0x09, 0xa1, 0xa3, 0x31, 0x37, 0x85, 0xff, 0x17, 0xd9, 0x26, 0x40, 0x97, 0xfb,
+	0x27, 0xfa, 0xd2, 0xdc, 0x2a, 0xd1, 0x95, 0x84, 0x2e, 0xf2, 0xa1, 0x11, 0xde, 0x5b, 0xc8, 0xb9,
+	0xe9, 0x1c, 0x5b, 0x43, 0x89, 0x2f, 0x91, 0x7d, 0xbc, 0x40, 0xfc, 0x8b, 0xf4, 0xb7, 0x26, 0xaf,
+	0x1c, 0xc2, 0xfb, 0xfd, 0xd6, 0x6f, 0x33, 0xdf, 0xff, 0x84, 0xff, 0xa1, 0x61, 0x63, 0x8d, 0x7f,
+	0x1c, 0x98, 0x47, 0x2b, 0xf6, 0x66, 0x2f, 0xdb, 0xbd, 0x30, 0x1d, 0xb5, 0x2f, 0x60, 0xfd, 0x18,
+	0x00, 0x00, 0xff, 0xff, 0x21, 0xaf, 0x4d, 0x61, 0x7b, 0x02, 0x00, 0x00,
+}
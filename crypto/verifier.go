@@ -18,6 +18,8 @@ import (
 	"crypto"
 	"crypto/ecdsa"
 	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
 	"encoding/asn1"
 	"encoding/json"
 	"errors"
@@ -25,6 +27,7 @@ import (
 	"math/big"
 
 	"github.com/benlaurie/objecthash/go/objecthash"
+	"github.com/google/trillian"
 	"github.com/google/trillian/crypto/keys"
 	"github.com/google/trillian/crypto/sigpb"
 )
@@ -37,6 +40,51 @@ var (
 	}
 )
 
+// KeyID returns the identifier of a public key used in trillian.LogRootSignature,
+// which is the SHA-256 hash of its DER encoding.
+func KeyID(pub crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	id := sha256.Sum256(der)
+	return id[:], nil
+}
+
+// VerifyLogRootSignatures checks that root.Signatures contains valid signatures
+// over the root from at least threshold of pubKeys. Invalid signatures and those
+// by other keys are ignored, and each key is only counted once.
+func VerifyLogRootSignatures(pubKeys []crypto.PublicKey, root trillian.SignedLogRoot, threshold int) error {
+	if threshold > len(pubKeys) {
+		return fmt.Errorf("threshold %d is more than the %d keys given", threshold, len(pubKeys))
+	}
+	sigs := make(map[string]*sigpb.DigitallySigned)
+	for _, s := range root.Signatures {
+		sigs[string(s.KeyId)] = s.Signature
+	}
+
+	hash := HashLogRoot(root)
+	valid := 0
+	for _, pub := range pubKeys {
+		id, err := KeyID(pub)
+		if err != nil {
+			return err
+		}
+		sig, ok := sigs[string(id)]
+		if !ok {
+			continue
+		}
+		delete(sigs, string(id))
+		if err := Verify(pub, hash, sig); err == nil {
+			valid++
+		}
+	}
+	if valid < threshold {
+		return fmt.Errorf("log root has %d valid signatures, need %d", valid, threshold)
+	}
+	return nil
+}
+
 // VerifyObject verifies the output of Signer.SignObject.
 func VerifyObject(pub crypto.PublicKey, obj interface{}, sig *sigpb.DigitallySigned) error {
 	j, err := json.Marshal(obj)
@@ -15,8 +15,13 @@
 package crypto
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"testing"
 
+	"github.com/google/trillian"
 	"github.com/google/trillian/crypto/keys"
 	"github.com/google/trillian/crypto/sigpb"
 	"github.com/google/trillian/testonly"
@@ -81,3 +86,57 @@ func TestSignVerify(t *testing.T) {
 		}
 	}
 }
+
+func TestVerifyLogRootSignatures(t *testing.T) {
+	var signers []*Signer
+	var pubKeys []crypto.PublicKey
+	for i := 0; i < 3; i++ {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("GenerateKey(): %v", err)
+		}
+		signers = append(signers, NewSigner(key))
+		pubKeys = append(pubKeys, key.Public())
+	}
+	root := trillian.SignedLogRoot{TreeSize: 5, TimestampNanos: 1000, RootHash: []byte("root")}
+	signatures := func(signedBy ...int) []*trillian.LogRootSignature {
+		var sigs []*trillian.LogRootSignature
+		for _, i := range signedBy {
+			sig, err := signers[i].SignLogRoot(root)
+			if err != nil {
+				t.Fatalf("SignLogRoot(): %v", err)
+			}
+			id, err := KeyID(pubKeys[i])
+			if err != nil {
+				t.Fatalf("KeyID(): %v", err)
+			}
+			sigs = append(sigs, &trillian.LogRootSignature{KeyId: id, Signature: sig})
+		}
+		return sigs
+	}
+	otherRootSig := signatures(2)[0]
+	otherRootSig.Signature, _ = signers[2].SignLogRoot(trillian.SignedLogRoot{TreeSize: 6})
+
+	for _, test := range []struct {
+		desc      string
+		sigs      []*trillian.LogRootSignature
+		keys      []crypto.PublicKey
+		threshold int
+		wantErr   bool
+	}{
+		{desc: "2 of 3", sigs: signatures(0, 2), keys: pubKeys, threshold: 2},
+		{desc: "3 of 3", sigs: signatures(0, 1, 2), keys: pubKeys, threshold: 3},
+		{desc: "1 of 3", sigs: signatures(1), keys: pubKeys, threshold: 2, wantErr: true},
+		{desc: "repeated key", sigs: signatures(1, 1), keys: pubKeys, threshold: 2, wantErr: true},
+		{desc: "unknown key", sigs: signatures(0, 2), keys: pubKeys[:2], threshold: 2, wantErr: true},
+		{desc: "wrong root", sigs: append(signatures(0), otherRootSig), keys: pubKeys, threshold: 2, wantErr: true},
+		{desc: "threshold above keys", sigs: signatures(0, 1), keys: pubKeys[:2], threshold: 3, wantErr: true},
+	} {
+		r := root
+		r.Signatures = test.sigs
+		err := VerifyLogRootSignatures(test.keys, r, test.threshold)
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("%v: VerifyLogRootSignatures()=%v, want err: %v", test.desc, err, test.wantErr)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// CertHash is the SHA-256 hash of a DER-encoded certificate.
+type CertHash [sha256.Size]byte
+
+// CertStore is a content-addressed store of DER-encoded certificates, keyed by
+// their SHA-256 hash. It allows the certificates that make up submitted chains,
+// which are mostly the same few intermediates and roots, to be stored once
+// rather than in the ExtraData of every log entry.
+type CertStore interface {
+	// PutCerts stores the given certificates. Storing a certificate that is
+	// already present is not an error.
+	PutCerts(ctx context.Context, certs [][]byte) error
+	// GetCerts returns the certificates with the given hashes, keyed by hash.
+	// Certificates which are not present are missing from the result.
+	GetCerts(ctx context.Context, hashes []CertHash) (map[CertHash][]byte, error)
+}
+
+// MemoryCertStore is a CertStore which holds certificates in memory.
+type MemoryCertStore struct {
+	mu    sync.RWMutex
+	certs map[CertHash][]byte
+}
+
+// NewMemoryCertStore creates an empty MemoryCertStore.
+func NewMemoryCertStore() *MemoryCertStore {
+	return &MemoryCertStore{certs: make(map[CertHash][]byte)}
+}
+
+// PutCerts stores the given certificates.
+func (m *MemoryCertStore) PutCerts(ctx context.Context, certs [][]byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, cert := range certs {
+		m.certs[sha256.Sum256(cert)] = append([]byte(nil), cert...)
+	}
+	return nil
+}
+
+// GetCerts returns the stored certificates with the given hashes.
+func (m *MemoryCertStore) GetCerts(ctx context.Context, hashes []CertHash) (map[CertHash][]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	certs := make(map[CertHash][]byte)
+	for _, hash := range hashes {
+		if cert, ok := m.certs[hash]; ok {
+			certs[hash] = cert
+		}
+	}
+	return certs, nil
+}
+
+// Len returns the number of distinct certificates in the store.
+func (m *MemoryCertStore) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.certs)
+}
+
+const (
+	insertCertSQL = "INSERT IGNORE INTO CertificateData(CertificateHash, Certificate) VALUES(?, ?)"
+	selectCertSQL = "SELECT CertificateHash, Certificate FROM CertificateData WHERE CertificateHash IN (%s)"
+)
+
+// MySQLCertStore is a CertStore which holds certificates in the
+// CertificateData table of a MySQL database, as created by cert_store.sql.
+// The table can be shared by many logs.
+type MySQLCertStore struct {
+	db *sql.DB
+}
+
+// NewMySQLCertStore creates a MySQLCertStore using the given database.
+func NewMySQLCertStore(db *sql.DB) *MySQLCertStore {
+	return &MySQLCertStore{db: db}
+}
+
+// PutCerts stores the given certificates, ignoring any already present.
+func (m *MySQLCertStore) PutCerts(ctx context.Context, certs [][]byte) error {
+	if len(certs) == 0 {
+		return nil
+	}
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, cert := range certs {
+		hash := sha256.Sum256(cert)
+		if _, err := tx.ExecContext(ctx, insertCertSQL, hash[:], cert); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to insert certificate %x: %v", hash, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetCerts returns the stored certificates with the given hashes.
+func (m *MySQLCertStore) GetCerts(ctx context.Context, hashes []CertHash) (map[CertHash][]byte, error) {
+	certs := make(map[CertHash][]byte)
+	if len(hashes) == 0 {
+		return certs, nil
+	}
+	args := make([]interface{}, 0, len(hashes))
+	for i := range hashes {
+		args = append(args, hashes[i][:])
+	}
+	query := fmt.Sprintf(selectCertSQL, strings.TrimSuffix(strings.Repeat("?,", len(hashes)), ","))
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var hashBytes, cert []byte
+		if err := rows.Scan(&hashBytes, &cert); err != nil {
+			return nil, err
+		}
+		var hash CertHash
+		if copy(hash[:], hashBytes) != len(hash) {
+			return nil, fmt.Errorf("certificate hash has wrong length %d", len(hashBytes))
+		}
+		certs[hash] = cert
+	}
+	return certs, rows.Err()
+}
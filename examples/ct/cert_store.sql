@@ -0,0 +1,11 @@
+# MySQL / MariaDB version of the CT personality's certificate store schema.
+
+-- Certificates from submitted chains, stored once and referred to by hash
+-- from the ExtraData of log entries. The table can be shared by many logs.
+CREATE TABLE IF NOT EXISTS CertificateData(
+  -- SHA-256 hash of the DER-encoded certificate.
+  CertificateHash      BINARY(32) NOT NULL,
+  -- The DER-encoded certificate.
+  Certificate          MEDIUMBLOB NOT NULL,
+  PRIMARY KEY(CertificateHash)
+);
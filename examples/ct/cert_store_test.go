@@ -0,0 +1,51 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestMemoryCertStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryCertStore()
+	certs := [][]byte{[]byte("cert1"), []byte("cert2"), []byte("cert1")}
+	if err := store.PutCerts(ctx, certs); err != nil {
+		t.Fatalf("PutCerts(): %v", err)
+	}
+	if got, want := store.Len(), 2; got != want {
+		t.Errorf("Len()=%d, want %d", got, want)
+	}
+
+	missing := sha256.Sum256([]byte("cert3"))
+	got, err := store.GetCerts(ctx, []CertHash{sha256.Sum256(certs[0]), sha256.Sum256(certs[1]), missing})
+	if err != nil {
+		t.Fatalf("GetCerts(): %v", err)
+	}
+	if len(got) != 2 {
+		t.Errorf("GetCerts() returned %d certificates, want 2", len(got))
+	}
+	for _, cert := range certs {
+		if c := got[sha256.Sum256(cert)]; !bytes.Equal(c, cert) {
+			t.Errorf("GetCerts()[%x]=%q, want %q", sha256.Sum256(cert), c, cert)
+		}
+	}
+	if _, ok := got[missing]; ok {
+		t.Error("GetCerts() returned a certificate that was never stored")
+	}
+}
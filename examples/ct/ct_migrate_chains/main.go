@@ -0,0 +1,128 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// ct_migrate_chains moves the chain certificates held in the ExtraData of
+// existing CT log entries into a certificate store, and replaces the ExtraData
+// with references to them. It works directly on the LeafData table of the
+// Trillian MySQL database, and can be safely re-run, as entries which already
+// hold references are skipped. The CT server must be run with the same
+// --cert_store_mysql_uri once any entry has been migrated.
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+
+	_ "github.com/go-sql-driver/mysql" // Load MySQL driver
+
+	"github.com/golang/glog"
+	"github.com/google/trillian/examples/ct"
+	"github.com/google/trillian/storage/mysql"
+)
+
+var (
+	mySQLURI          = flag.String("mysql_uri", "test:zaphod@tcp(127.0.0.1:3306)/test", "Connection URI for the Trillian MySQL database")
+	certStoreMySQLURI = flag.String("cert_store_mysql_uri", "test:zaphod@tcp(127.0.0.1:3306)/test", "Connection URI for the MySQL database holding the certificate store")
+	logID             = flag.Int64("log_id", 0, "Tree ID of the log to migrate")
+	batchSize         = flag.Int("batch_size", 100, "Number of entries to read at a time")
+	dryRun            = flag.Bool("dry_run", false, "If true, store certificates but don't rewrite any ExtraData")
+)
+
+const (
+	selectLeavesSQL = `SELECT LeafIdentityHash, LeafValue, ExtraData FROM LeafData
+			WHERE TreeId = ? AND LeafIdentityHash > ? ORDER BY LeafIdentityHash LIMIT ?`
+	updateExtraDataSQL = "UPDATE LeafData SET ExtraData = ? WHERE TreeId = ? AND LeafIdentityHash = ?"
+)
+
+type leafData struct {
+	identityHash, leafValue, extraData []byte
+}
+
+func main() {
+	flag.Parse()
+	if *logID == 0 {
+		glog.Exit("No log provided (via --log_id)")
+	}
+	if *batchSize <= 0 {
+		glog.Exitf("Invalid --batch_size %d", *batchSize)
+	}
+
+	db, err := mysql.OpenDB(*mySQLURI)
+	if err != nil {
+		glog.Exitf("Failed to open Trillian database: %v", err)
+	}
+	defer db.Close()
+	storeDB, err := mysql.OpenDB(*certStoreMySQLURI)
+	if err != nil {
+		glog.Exitf("Failed to open certificate store database: %v", err)
+	}
+	defer storeDB.Close()
+	store := ct.NewMySQLCertStore(storeDB)
+
+	ctx := context.Background()
+	var migrated, skipped, bytesBefore, bytesAfter int64
+	last := []byte{}
+	for {
+		leaves, err := readLeaves(ctx, db, last)
+		if err != nil {
+			glog.Exitf("Failed to read leaves: %v", err)
+		}
+		if len(leaves) == 0 {
+			break
+		}
+		for _, leaf := range leaves {
+			extraData, err := ct.MigrateExtraData(ctx, store, leaf.leafValue, leaf.extraData)
+			if err != nil {
+				glog.Exitf("Failed to migrate leaf %x: %v", leaf.identityHash, err)
+			}
+			if bytes.Equal(extraData, leaf.extraData) {
+				skipped++
+				continue
+			}
+			if !*dryRun {
+				if _, err := db.ExecContext(ctx, updateExtraDataSQL, extraData, *logID, leaf.identityHash); err != nil {
+					glog.Exitf("Failed to update leaf %x: %v", leaf.identityHash, err)
+				}
+			}
+			migrated++
+			bytesBefore += int64(len(leaf.extraData))
+			bytesAfter += int64(len(extraData))
+		}
+		last = leaves[len(leaves)-1].identityHash
+		glog.Infof("Migrated %d entries, skipped %d", migrated, skipped)
+	}
+	fmt.Printf("Migrated %d entries (ExtraData %d => %d bytes), skipped %d already migrated\n", migrated, bytesBefore, bytesAfter, skipped)
+}
+
+// readLeaves returns the next batch of the log's leaves, in order of identity
+// hash, following the one with identity hash after.
+func readLeaves(ctx context.Context, db *sql.DB, after []byte) ([]leafData, error) {
+	rows, err := db.QueryContext(ctx, selectLeavesSQL, *logID, after, *batchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var leaves []leafData
+	for rows.Next() {
+		var leaf leafData
+		if err := rows.Scan(&leaf.identityHash, &leaf.leafValue, &leaf.extraData); err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, leaf)
+	}
+	return leaves, rows.Err()
+}
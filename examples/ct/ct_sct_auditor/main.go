@@ -0,0 +1,123 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// ct_sct_auditor follows the submission log written by ct_server (with
+// --sct_log), and checks that every SCT issued is correctly signed and that
+// its entry is incorporated into the log within the MMD, using
+// get-proof-by-hash. Problems are reported as errors in the log, and counted
+// in the sct_audit_alerts_* metrics.
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/golang/glog"
+	ct "github.com/google/certificate-transparency/go"
+	"github.com/google/certificate-transparency/go/client"
+	"github.com/google/certificate-transparency/go/jsonclient"
+	ctfe "github.com/google/trillian/examples/ct"
+	"github.com/google/trillian/examples/ct/sctaudit"
+	"github.com/google/trillian/util"
+)
+
+var (
+	logConfigFlag     = flag.String("log_config", "", "File holding log config in JSON, as used by ct_server")
+	httpServerFlag    = flag.String("ct_http_server", "localhost:6962", "CT server to query, as address:port")
+	sctLogFlag        = flag.String("sct_log", "", "Submission log of issued SCTs, as written by ct_server --sct_log")
+	mmdFlag           = flag.Duration("mmd", 24*time.Hour, "MMD of the logs")
+	graceFlag         = flag.Duration("grace_period", time.Hour, "How long after an SCT's merge deadline to wait for the log to publish an STH before alerting")
+	checkIntervalFlag = flag.Duration("check_interval", time.Minute, "How often to read new SCTs and check pending ones")
+)
+
+func main() {
+	flag.Parse()
+	if *sctLogFlag == "" {
+		glog.Exit("No submission log provided (via --sct_log)")
+	}
+	cfg, err := ctfe.LogConfigFromFile(*logConfigFlag)
+	if err != nil {
+		glog.Exitf("Failed to read log config: %v", err)
+	}
+
+	auditors := make(map[string]*sctaudit.Auditor)
+	for _, c := range cfg {
+		pemData, err := ioutil.ReadFile(c.PubKeyPEMFile)
+		if err != nil {
+			glog.Exitf("%s: Failed to read public key: %v", c.Prefix, err)
+		}
+		pubKey, _, _, err := ct.PublicKeyFromPEM(pemData)
+		if err != nil {
+			glog.Exitf("%s: Failed to parse public key: %v", c.Prefix, err)
+		}
+		logClient, err := client.New("http://"+*httpServerFlag+"/"+c.Prefix, nil, jsonclient.Options{PublicKey: string(pemData)})
+		if err != nil {
+			glog.Exitf("%s: Failed to create log client: %v", c.Prefix, err)
+		}
+		auditor, err := sctaudit.NewAuditor(c.Prefix, logClient, pubKey, *mmdFlag, util.SystemTimeSource{}, sctaudit.LogAlerter{})
+		if err != nil {
+			glog.Exitf("%s: Failed to create auditor: %v", c.Prefix, err)
+		}
+		auditor.SetGracePeriod(*graceFlag)
+		auditors[c.Prefix] = auditor
+	}
+
+	f, err := os.Open(*sctLogFlag)
+	if err != nil {
+		glog.Exitf("Failed to open submission log: %v", err)
+	}
+	defer f.Close()
+	reader := ctfe.NewSCTLogReader(f)
+
+	ctx := context.Background()
+	ticker := time.NewTicker(*checkIntervalFlag)
+	defer ticker.Stop()
+	for {
+		readSCTs(reader, auditors)
+		for prefix, auditor := range auditors {
+			if err := auditor.Check(ctx); err != nil {
+				glog.Warningf("%s: Check failed: %v", prefix, err)
+			}
+			glog.V(1).Infof("%s: %d SCTs pending", prefix, auditor.Pending())
+		}
+		<-ticker.C
+	}
+}
+
+// readSCTs passes every SCT that has been added to the submission log since
+// the last call to the auditor for its log.
+func readSCTs(reader *ctfe.SCTLogReader, auditors map[string]*sctaudit.Auditor) {
+	for {
+		issued, err := reader.Next()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			glog.Errorf("Failed to read submission log: %v", err)
+			continue
+		}
+		auditor, ok := auditors[issued.Prefix]
+		if !ok {
+			glog.Warningf("Ignoring SCT from unknown log %q", issued.Prefix)
+			continue
+		}
+		if err := auditor.Add(issued); err != nil {
+			glog.Errorf("%s: Failed to audit SCT: %v", issued.Prefix, err)
+		}
+	}
+}
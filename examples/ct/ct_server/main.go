@@ -23,9 +23,12 @@ import (
 
 	"time"
 
+	_ "github.com/go-sql-driver/mysql" // Load MySQL driver
+
 	"github.com/golang/glog"
 	"github.com/google/trillian"
 	"github.com/google/trillian/examples/ct"
+	"github.com/google/trillian/storage/mysql"
 	"github.com/google/trillian/util"
 	"google.golang.org/grpc"
 )
@@ -36,6 +39,8 @@ var serverPortFlag = flag.Int("port", 6962, "Port to serve CT log requests on")
 var rpcBackendFlag = flag.String("log_rpc_server", "localhost:8090", "Backend Log RPC server to use")
 var rpcDeadlineFlag = flag.Duration("rpc_deadline", time.Second*10, "Deadline for backend RPC requests")
 var logConfigFlag = flag.String("log_config", "", "File holding log config in JSON")
+var sctLogFlag = flag.String("sct_log", "", "If set, file to append every issued SCT to, for auditing by ct_sct_auditor")
+var certStoreMySQLURIFlag = flag.String("cert_store_mysql_uri", "", "If set, connection URI for a MySQL database holding chain certificates (see cert_store.sql), which are then not stored with each entry")
 
 func main() {
 	flag.Parse()
@@ -58,8 +63,28 @@ func main() {
 	defer conn.Close()
 	client := trillian.NewTrillianLogClient(conn)
 
+	var certStore ct.CertStore
+	if *certStoreMySQLURIFlag != "" {
+		db, err := mysql.OpenDB(*certStoreMySQLURIFlag)
+		if err != nil {
+			glog.Exitf("Failed to open certificate store database: %v", err)
+		}
+		defer db.Close()
+		certStore = ct.NewMySQLCertStore(db)
+	}
+
+	var sctObserver ct.SCTObserver
+	if *sctLogFlag != "" {
+		f, err := os.OpenFile(*sctLogFlag, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			glog.Exitf("Failed to open SCT log: %v", err)
+		}
+		defer f.Close()
+		sctObserver = ct.NewSCTLogWriter(f)
+	}
+
 	for _, c := range cfg {
-		handlers, err := c.SetUpInstance(client, *rpcDeadlineFlag)
+		handlers, err := c.SetUpInstance(client, *rpcDeadlineFlag, certStore, sctObserver)
 		if err != nil {
 			glog.Exitf("Failed to set up log instance for %+v: %v", cfg, err)
 		}
@@ -0,0 +1,94 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// ct_submit_proxy accepts add-chain and add-pre-chain requests, submits each
+// chain to all the logs in its config, and returns the SCTs collected once
+// they meet the configured policy.
+//
+// Example config, requiring SCTs from at least two logs run by different
+// operators:
+//
+//	{
+//	  "Prefix": "submit",
+//	  "Logs": [
+//	    {"Name": "trillian-a", "URL": "http://localhost:6962/aramis", "Operator": "us", "PubKeyPEMFile": "aramis.pem"},
+//	    {"Name": "external", "URL": "https://ct.example.com", "Operator": "them", "Timeout": "5s"}
+//	  ],
+//	  "Policy": {"MinSCTs": 2, "MinOperators": 2}
+//	}
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/golang/glog"
+	"github.com/google/certificate-transparency/go/client"
+	"github.com/google/certificate-transparency/go/jsonclient"
+	"github.com/google/trillian/examples/ct/submitproxy"
+	"github.com/google/trillian/util"
+)
+
+var (
+	serverHostFlag  = flag.String("host", "localhost", "Address to serve proxy requests on")
+	serverPortFlag  = flag.Int("port", 6963, "Port to serve proxy requests on")
+	proxyConfigFlag = flag.String("proxy_config", "", "File holding proxy config in JSON")
+)
+
+func main() {
+	flag.Parse()
+	cfg, err := submitproxy.ProxyConfigFromFile(*proxyConfigFlag)
+	if err != nil {
+		glog.Exitf("Failed to read proxy config: %v", err)
+	}
+
+	var logs []*submitproxy.Log
+	for _, l := range cfg.Logs {
+		opts := jsonclient.Options{}
+		if l.PubKeyPEMFile != "" {
+			pubKey, err := ioutil.ReadFile(l.PubKeyPEMFile)
+			if err != nil {
+				glog.Exitf("%s: Failed to read public key: %v", l.Name, err)
+			}
+			opts.PublicKey = string(pubKey)
+		}
+		logClient, err := client.New(l.URL, nil, opts)
+		if err != nil {
+			glog.Exitf("%s: Failed to create log client: %v", l.Name, err)
+		}
+		timeout, err := l.TimeoutDuration()
+		if err != nil {
+			glog.Exit(err)
+		}
+		logs = append(logs, &submitproxy.Log{Name: l.Name, Operator: l.Operator, Client: logClient, Timeout: timeout})
+	}
+	proxy, err := submitproxy.NewProxy(logs, cfg.Policy)
+	if err != nil {
+		glog.Exitf("Failed to create proxy: %v", err)
+	}
+	for path, handler := range proxy.Handlers(cfg.Prefix) {
+		http.Handle(path, handler)
+	}
+
+	go util.AwaitSignal(func() {
+		os.Exit(1)
+	})
+	server := http.Server{Addr: fmt.Sprintf("%s:%d", *serverHostFlag, *serverPortFlag), Handler: nil}
+	err = server.ListenAndServe()
+	glog.Warningf("Server exited: %v", err)
+	glog.Flush()
+}
@@ -0,0 +1,204 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	ct "github.com/google/certificate-transparency/go"
+	"github.com/google/certificate-transparency/go/tls"
+	"github.com/google/certificate-transparency/go/x509"
+)
+
+// chainRefsPrefix starts every ExtraData that refers to chain certificates
+// held in a CertStore rather than containing them. RFC 6962 ExtraData can
+// only start with three zero bytes if it is exactly the encoding of an empty
+// certificate chain, so the two forms cannot be confused.
+var chainRefsPrefix = []byte{0x00, 0x00, 0x00, 0x01}
+
+// chainRefs is the TLS-encoded body of ExtraData that refers to chain
+// certificates in a CertStore.
+type chainRefs struct {
+	// PreCertificate is the submitted precertificate for precert entries,
+	// which is kept inline as it is unique to the entry. It is empty for
+	// X.509 entries.
+	PreCertificate []byte `tls:"minlen:0,maxlen:16777215"`
+	// Chain holds the hashes of the certificates following the leaf.
+	Chain []CertHash `tls:"minlen:0,maxlen:16777215"`
+}
+
+// isChainRefs reports whether extraData refers to certificates in a CertStore.
+func isChainRefs(extraData []byte) bool {
+	return len(extraData) > len(chainRefsPrefix) && bytes.HasPrefix(extraData, chainRefsPrefix)
+}
+
+// chainRefsForChain stores the certificates following the leaf of chain in
+// store, and returns ExtraData referring to them.
+func chainRefsForChain(ctx context.Context, store CertStore, chain []*x509.Certificate, isPrecert bool) ([]byte, error) {
+	var precert []byte
+	if isPrecert {
+		precert = chain[0].Raw
+	}
+	rest := make([][]byte, 0, len(chain)-1)
+	for _, cert := range chain[1:] {
+		rest = append(rest, cert.Raw)
+	}
+	return storeChain(ctx, store, precert, rest)
+}
+
+func storeChain(ctx context.Context, store CertStore, precert []byte, chain [][]byte) ([]byte, error) {
+	if err := store.PutCerts(ctx, chain); err != nil {
+		return nil, fmt.Errorf("failed to store chain certificates: %v", err)
+	}
+	refs := chainRefs{PreCertificate: precert, Chain: make([]CertHash, 0, len(chain))}
+	for _, cert := range chain {
+		refs.Chain = append(refs.Chain, sha256.Sum256(cert))
+	}
+	data, err := tls.Marshal(refs)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte(nil), chainRefsPrefix...), data...), nil
+}
+
+// resolveExtraData converts any entries of extraData which refer to
+// certificates in store back into RFC 6962 ExtraData. Other entries are
+// returned unchanged, so store may be nil if none of them are references.
+func resolveExtraData(ctx context.Context, store CertStore, extraData [][]byte) ([][]byte, error) {
+	refs := make([]*chainRefs, len(extraData))
+	found := false
+	var hashes []CertHash
+	for i, data := range extraData {
+		if !isChainRefs(data) {
+			continue
+		}
+		if store == nil {
+			return nil, errors.New("ExtraData refers to a certificate store, but none is configured")
+		}
+		var r chainRefs
+		if rest, err := tls.Unmarshal(data[len(chainRefsPrefix):], &r); err != nil {
+			return nil, fmt.Errorf("failed to parse chain references: %v", err)
+		} else if len(rest) > 0 {
+			return nil, fmt.Errorf("extra data (%d bytes) after chain references", len(rest))
+		}
+		refs[i] = &r
+		found = true
+		hashes = append(hashes, r.Chain...)
+	}
+	if !found {
+		return extraData, nil
+	}
+
+	certs, err := store.GetCerts(ctx, hashes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch chain certificates: %v", err)
+	}
+	resolved := make([][]byte, len(extraData))
+	for i, r := range refs {
+		if r == nil {
+			resolved[i] = extraData[i]
+			continue
+		}
+		chain := make([][]byte, 0, len(r.Chain))
+		for _, hash := range r.Chain {
+			cert, ok := certs[hash]
+			if !ok {
+				return nil, fmt.Errorf("chain certificate %x not found", hash)
+			}
+			if got := CertHash(sha256.Sum256(cert)); got != hash {
+				return nil, fmt.Errorf("chain certificate %x has hash %x", hash, got)
+			}
+			chain = append(chain, cert)
+		}
+		resolved[i], err = marshalExtraData(len(r.PreCertificate) > 0, r.PreCertificate, chain)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resolved, nil
+}
+
+// MigrateExtraData converts the RFC 6962 ExtraData of an existing log entry,
+// whose Merkle tree leaf is leafValue, into references to certificates in
+// store, adding the certificates to store. ExtraData which already holds
+// references is returned unchanged.
+func MigrateExtraData(ctx context.Context, store CertStore, leafValue, extraData []byte) ([]byte, error) {
+	if isChainRefs(extraData) {
+		return extraData, nil
+	}
+	var leaf ct.MerkleTreeLeaf
+	if rest, err := tls.Unmarshal(leafValue, &leaf); err != nil {
+		return nil, fmt.Errorf("failed to parse MerkleTreeLeaf: %v", err)
+	} else if len(rest) > 0 {
+		return nil, fmt.Errorf("extra data (%d bytes) after MerkleTreeLeaf", len(rest))
+	}
+	if leaf.TimestampedEntry == nil {
+		return nil, errors.New("MerkleTreeLeaf has no TimestampedEntry")
+	}
+
+	var precert []byte
+	var chain []ct.ASN1Cert
+	switch entryType := leaf.TimestampedEntry.EntryType; entryType {
+	case ct.X509LogEntryType:
+		var entry ct.CertificateChain
+		if rest, err := tls.Unmarshal(extraData, &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse certificate chain: %v", err)
+		} else if len(rest) > 0 {
+			return nil, fmt.Errorf("extra data (%d bytes) after certificate chain", len(rest))
+		}
+		chain = entry.Entries
+	case ct.PrecertLogEntryType:
+		var entry ct.PrecertChainEntry
+		if rest, err := tls.Unmarshal(extraData, &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse precert chain entry: %v", err)
+		} else if len(rest) > 0 {
+			return nil, fmt.Errorf("extra data (%d bytes) after precert chain entry", len(rest))
+		}
+		precert = entry.PreCertificate.Data
+		chain = entry.CertificateChain
+	default:
+		return nil, fmt.Errorf("unsupported entry type %v", entryType)
+	}
+
+	raw := make([][]byte, 0, len(chain))
+	for _, cert := range chain {
+		raw = append(raw, cert.Data)
+	}
+	return storeChain(ctx, store, precert, raw)
+}
+
+// marshalExtraData builds the RFC 6962 ExtraData for an entry, as described
+// in section 4.6.
+func marshalExtraData(isPrecert bool, precert []byte, chain [][]byte) ([]byte, error) {
+	entries := make([]ct.ASN1Cert, 0, len(chain))
+	for _, cert := range chain {
+		entries = append(entries, ct.ASN1Cert{Data: cert})
+	}
+	if isPrecert {
+		// For a pre-certificate, the extra data is a TLS-encoded PrecertChainEntry.
+		return tls.Marshal(ct.PrecertChainEntry{
+			PreCertificate:   ct.ASN1Cert{Data: precert},
+			CertificateChain: entries,
+		})
+	}
+	// For a certificate, the extra data is a TLS-encoded:
+	//   ASN.1Cert certificate_chain<0..2^24-1>;
+	// containing the chain after the leaf.
+	return tls.Marshal(ct.CertificateChain{Entries: entries})
+}
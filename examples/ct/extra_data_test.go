@@ -0,0 +1,270 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	ct "github.com/google/certificate-transparency/go"
+	"github.com/google/certificate-transparency/go/tls"
+	"github.com/google/certificate-transparency/go/x509"
+	"github.com/google/trillian"
+	cttestonly "github.com/google/trillian/examples/ct/testonly"
+	"google.golang.org/grpc"
+)
+
+func parseChainOrDie(t *testing.T, pems ...string) []*x509.Certificate {
+	return loadCertsIntoPoolOrDie(t, pems).RawCertificates()
+}
+
+func merkleLeafBytes(t *testing.T, chain []*x509.Certificate, isPrecert bool) []byte {
+	var leaf *ct.MerkleTreeLeaf
+	var err error
+	if isPrecert {
+		leaf, err = buildV1MerkleTreeLeafForPrecert(chain[0], chain[1], fakeTimeMillis)
+	} else {
+		leaf, err = buildV1MerkleTreeLeafForCert(chain[0], nil, fakeTimeMillis)
+	}
+	if err != nil {
+		t.Fatalf("failed to build MerkleTreeLeaf: %v", err)
+	}
+	data, err := tls.Marshal(*leaf)
+	if err != nil {
+		t.Fatalf("failed to serialize MerkleTreeLeaf: %v", err)
+	}
+	return data
+}
+
+func TestChainRefsRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryCertStore()
+	for _, test := range []struct {
+		desc      string
+		chain     []*x509.Certificate
+		isPrecert bool
+	}{
+		{
+			desc:  "cert",
+			chain: parseChainOrDie(t, cttestonly.LeafSignedByFakeIntermediateCertPEM, cttestonly.FakeIntermediateCertPEM, cttestonly.FakeCACertPEM),
+		},
+		{
+			desc:      "precert",
+			chain:     parseChainOrDie(t, cttestonly.PrecertPEMValid, cttestonly.FakeIntermediateCertPEM, cttestonly.FakeCACertPEM),
+			isPrecert: true,
+		},
+		{
+			desc:  "root only",
+			chain: parseChainOrDie(t, cttestonly.FakeCACertPEM),
+		},
+	} {
+		want, err := extraDataForChain(test.chain, test.isPrecert)
+		if err != nil {
+			t.Fatalf("%s: extraDataForChain(): %v", test.desc, err)
+		}
+		if isChainRefs(want) {
+			t.Errorf("%s: isChainRefs(%x)=true for RFC 6962 ExtraData", test.desc, want)
+		}
+		refs, err := chainRefsForChain(ctx, store, test.chain, test.isPrecert)
+		if err != nil {
+			t.Fatalf("%s: chainRefsForChain(): %v", test.desc, err)
+		}
+		if !isChainRefs(refs) {
+			t.Errorf("%s: isChainRefs(%x)=false, want true", test.desc, refs)
+		}
+		got, err := resolveExtraData(ctx, store, [][]byte{refs, want})
+		if err != nil {
+			t.Fatalf("%s: resolveExtraData(): %v", test.desc, err)
+		}
+		for i := range got {
+			if !bytes.Equal(got[i], want) {
+				t.Errorf("%s: resolveExtraData()[%d]=%x, want %x", test.desc, i, got[i], want)
+			}
+		}
+	}
+	// The intermediate and root are shared by all chains, so are stored once.
+	if got, want := store.Len(), 2; got != want {
+		t.Errorf("store.Len()=%d, want %d", got, want)
+	}
+}
+
+func TestResolveExtraDataErrors(t *testing.T) {
+	ctx := context.Background()
+	chain := parseChainOrDie(t, cttestonly.LeafSignedByFakeIntermediateCertPEM, cttestonly.FakeIntermediateCertPEM)
+	store := NewMemoryCertStore()
+	refs, err := chainRefsForChain(ctx, store, chain, false)
+	if err != nil {
+		t.Fatalf("chainRefsForChain(): %v", err)
+	}
+
+	if _, err := resolveExtraData(ctx, nil, [][]byte{refs}); err == nil {
+		t.Error("resolveExtraData() with no store succeeded, want error")
+	}
+	if _, err := resolveExtraData(ctx, NewMemoryCertStore(), [][]byte{refs}); err == nil {
+		t.Error("resolveExtraData() with missing certificate succeeded, want error")
+	}
+	corrupt := NewMemoryCertStore()
+	corrupt.certs[sha256.Sum256(chain[1].Raw)] = chain[0].Raw
+	if _, err := resolveExtraData(ctx, corrupt, [][]byte{refs}); err == nil {
+		t.Error("resolveExtraData() with corrupt certificate succeeded, want error")
+	}
+	if _, err := resolveExtraData(ctx, store, [][]byte{append(refs, 0)}); err == nil {
+		t.Error("resolveExtraData() with trailing data succeeded, want error")
+	}
+	legacy := [][]byte{[]byte("extra1")}
+	if got, err := resolveExtraData(ctx, nil, legacy); err != nil || !bytes.Equal(got[0], legacy[0]) {
+		t.Errorf("resolveExtraData(legacy)=%x, %v; want %x, nil", got, err, legacy)
+	}
+}
+
+func TestMigrateExtraData(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		desc      string
+		chain     []*x509.Certificate
+		isPrecert bool
+	}{
+		{
+			desc:  "cert",
+			chain: parseChainOrDie(t, cttestonly.LeafSignedByFakeIntermediateCertPEM, cttestonly.FakeIntermediateCertPEM, cttestonly.FakeCACertPEM),
+		},
+		{
+			desc:      "precert",
+			chain:     parseChainOrDie(t, cttestonly.PrecertPEMValid, cttestonly.FakeCACertPEM),
+			isPrecert: true,
+		},
+	} {
+		store := NewMemoryCertStore()
+		leafValue := merkleLeafBytes(t, test.chain, test.isPrecert)
+		legacy, err := extraDataForChain(test.chain, test.isPrecert)
+		if err != nil {
+			t.Fatalf("%s: extraDataForChain(): %v", test.desc, err)
+		}
+
+		migrated, err := MigrateExtraData(ctx, store, leafValue, legacy)
+		if err != nil {
+			t.Fatalf("%s: MigrateExtraData(): %v", test.desc, err)
+		}
+		if !isChainRefs(migrated) {
+			t.Errorf("%s: MigrateExtraData()=%x, want chain references", test.desc, migrated)
+		}
+		if got, want := store.Len(), len(test.chain)-1; got != want {
+			t.Errorf("%s: store.Len()=%d, want %d", test.desc, got, want)
+		}
+		again, err := MigrateExtraData(ctx, store, leafValue, migrated)
+		if err != nil || !bytes.Equal(again, migrated) {
+			t.Errorf("%s: MigrateExtraData(migrated)=%x, %v; want unchanged", test.desc, again, err)
+		}
+		resolved, err := resolveExtraData(ctx, store, [][]byte{migrated})
+		if err != nil {
+			t.Fatalf("%s: resolveExtraData(): %v", test.desc, err)
+		}
+		if !bytes.Equal(resolved[0], legacy) {
+			t.Errorf("%s: resolveExtraData()=%x, want %x", test.desc, resolved[0], legacy)
+		}
+
+		if _, err := MigrateExtraData(ctx, store, []byte("not a leaf"), legacy); err == nil {
+			t.Errorf("%s: MigrateExtraData() with bad leaf succeeded, want error", test.desc)
+		}
+		if _, err := MigrateExtraData(ctx, store, leafValue, append(legacy, 0)); err == nil {
+			t.Errorf("%s: MigrateExtraData() with trailing data succeeded, want error", test.desc)
+		}
+	}
+}
+
+func TestAddChainWithCertStore(t *testing.T) {
+	signer, err := setupSigner(fakeSignature)
+	if err != nil {
+		t.Fatalf("Failed to create test signer: %v", err)
+	}
+	info := setupTest(t, []string{cttestonly.FakeCACertPEM}, signer)
+	defer info.mockCtrl.Finish()
+	store := NewMemoryCertStore()
+	info.c.SetCertStore(store)
+
+	pool := loadCertsIntoPoolOrDie(t, []string{cttestonly.LeafSignedByFakeIntermediateCertPEM, cttestonly.FakeIntermediateCertPEM})
+	leafValue := merkleLeafBytes(t, pool.RawCertificates(), false)
+	var queued *trillian.LogLeaf
+	info.client.EXPECT().QueueLeaves(deadlineMatcher(), gomock.Any()).Do(func(_ context.Context, req *trillian.QueueLeavesRequest, _ ...grpc.CallOption) {
+		queued = req.Leaves[0]
+	}).Return(&trillian.QueueLeavesResponse{QueuedLeaves: []*trillian.QueuedLogLeaf{{Leaf: &trillian.LogLeaf{LeafValue: leafValue}}}}, nil)
+
+	recorder := makeAddChainRequest(t, info.c, createJSONChain(t, *pool))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("addChain()=%d (body:%v); want 200", recorder.Code, recorder.Body)
+	}
+	if !isChainRefs(queued.ExtraData) {
+		t.Errorf("queued ExtraData=%x, want chain references", queued.ExtraData)
+	}
+	// The chain is completed with the trusted root before being stored.
+	if got, want := store.Len(), 2; got != want {
+		t.Errorf("store.Len()=%d, want %d", got, want)
+	}
+}
+
+func TestGetEntriesWithCertStore(t *testing.T) {
+	ctx := context.Background()
+	chain := parseChainOrDie(t, cttestonly.LeafSignedByFakeIntermediateCertPEM, cttestonly.FakeIntermediateCertPEM, cttestonly.FakeCACertPEM)
+	leafValue := merkleLeafBytes(t, chain, false)
+	legacy, err := extraDataForChain(chain, false)
+	if err != nil {
+		t.Fatalf("extraDataForChain(): %v", err)
+	}
+	store := NewMemoryCertStore()
+	refs, err := chainRefsForChain(ctx, store, chain, false)
+	if err != nil {
+		t.Fatalf("chainRefsForChain(): %v", err)
+	}
+
+	info := setupTest(t, nil, nil)
+	defer info.mockCtrl.Finish()
+	info.c.SetCertStore(store)
+	info.client.EXPECT().GetLeavesByIndex(deadlineMatcher(), &trillian.GetLeavesByIndexRequest{LogId: 0x42, LeafIndex: []int64{1, 2}}).Return(
+		&trillian.GetLeavesByIndexResponse{
+			Leaves: []*trillian.LogLeaf{
+				{LeafIndex: 1, LeafValue: leafValue, ExtraData: legacy},
+				{LeafIndex: 2, LeafValue: leafValue, ExtraData: refs},
+			},
+		}, nil)
+
+	handler := AppHandler{Context: info.c, Handler: getEntries, Name: "GetEntries", Method: http.MethodGet}
+	req, err := http.NewRequest("GET", "/ct/v1/get-entries?start=1&end=2", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetEntries()=%d (body:%v); want 200", w.Code, w.Body)
+	}
+	var rsp ct.GetEntriesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &rsp); err != nil {
+		t.Fatalf("Failed to unmarshal json response %s: %v", w.Body.Bytes(), err)
+	}
+	if got, want := len(rsp.Entries), 2; got != want {
+		t.Fatalf("len(rsp.Entries)=%d; want %d", got, want)
+	}
+	for i, entry := range rsp.Entries {
+		if !bytes.Equal(entry.ExtraData, legacy) {
+			t.Errorf("rsp.Entries[%d].ExtraData=%x; want %x", i, entry.ExtraData, legacy)
+		}
+	}
+}
@@ -162,6 +162,11 @@ type LogContext struct {
 	signer *crypto.Signer
 	// rpcDeadline is the deadline that will be set on all backend RPC requests
 	rpcDeadline time.Duration
+	// certStore holds the chain certificates of new entries, if set, in which
+	// case ExtraData only refers to them
+	certStore CertStore
+	// sctObserver is told about every SCT issued, if set
+	sctObserver SCTObserver
 	// Various per-log statistics
 	exp struct {
 		vars             *expvar.Map // varname => expvar.Var, includes all below
@@ -215,6 +220,21 @@ func NewLogContext(logID int64, prefix string, trustedRoots *PEMCertPool, rpcCli
 	return ctx
 }
 
+// SetCertStore makes the log keep the certificates of submitted chains in
+// store, and only refer to them from the ExtraData of new entries. Entries
+// with full chains in their ExtraData are still served as they are. This must
+// be called before Handlers.
+func (c *LogContext) SetCertStore(store CertStore) {
+	c.certStore = store
+}
+
+// SetSCTObserver makes the log tell observer about every SCT it issues, for
+// example so that they can be audited against the maximum merge delay. This
+// must be called before Handlers.
+func (c *LogContext) SetSCTObserver(observer SCTObserver) {
+	c.sctObserver = observer
+}
+
 // Handlers returns a map from URL paths (with the given prefix) and AppHandler instances
 // to handle those entrypoints.
 func (c LogContext) Handlers(prefix string) PathHandlers {
@@ -296,7 +316,7 @@ func addChainInternal(ctx context.Context, c LogContext, w http.ResponseWriter,
 	if err != nil {
 		return http.StatusBadRequest, fmt.Errorf("failed to build MerkleTreeLeaf: %v", err)
 	}
-	leaf, err := buildLogLeafForAddChain(c, *merkleLeaf, chain)
+	leaf, err := buildLogLeafForAddChain(ctx, c, *merkleLeaf, chain)
 	if err != nil {
 		return http.StatusInternalServerError, fmt.Errorf("failed to build LogLeaf: %v", err)
 	}
@@ -340,10 +360,26 @@ func addChainInternal(ctx context.Context, c LogContext, w http.ResponseWriter,
 	}
 	glog.V(3).Infof("%s: %s <= SCT", c.LogPrefix, method)
 	c.exp.lastSCTTimestamp.Set(int64(sct.Timestamp))
+	if c.sctObserver != nil {
+		observeSCT(c, sct, &loggedLeaf)
+	}
 
 	return http.StatusOK, nil
 }
 
+// observeSCT tells the log's SCTObserver about sct. The SCT has already been
+// returned, so failures are only logged.
+func observeSCT(c LogContext, sct *ct.SignedCertificateTimestamp, leaf *ct.MerkleTreeLeaf) {
+	issued, err := NewIssuedSCT(c.logID, c.urlPrefix, sct, leaf)
+	if err != nil {
+		glog.Warningf("%s: failed to record issued SCT: %v", c.LogPrefix, err)
+		return
+	}
+	if err := c.sctObserver.SCTIssued(issued); err != nil {
+		glog.Warningf("%s: failed to record issued SCT: %v", c.LogPrefix, err)
+	}
+}
+
 func addChain(ctx context.Context, c LogContext, w http.ResponseWriter, r *http.Request) (int, error) {
 	return addChainInternal(ctx, c, w, r, false)
 }
@@ -551,7 +587,7 @@ func getEntries(ctx context.Context, c LogContext, w http.ResponseWriter, r *htt
 	// to serialize the leaves in JSON format for the HTTP response. Doing a
 	// round trip via the leaf deserializer gives us another chance to
 	// prevent bad / corrupt data from reaching the client.
-	jsonRsp, err := marshalGetEntriesResponse(c, rsp)
+	jsonRsp, err := marshalGetEntriesResponse(ctx, c, rsp)
 	if err != nil {
 		return http.StatusInternalServerError, fmt.Errorf("failed to process leaves returned from backend: %v", err)
 	}
@@ -610,10 +646,15 @@ func getEntryAndProof(ctx context.Context, c LogContext, w http.ResponseWriter,
 		return http.StatusInternalServerError, fmt.Errorf("got RPC bad response, possible extra info: %v", rsp)
 	}
 
+	extraData, err := resolveExtraData(ctx, c.certStore, [][]byte{rsp.Leaf.ExtraData})
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to resolve ExtraData: %v", err)
+	}
+
 	// Build and marshal the response to the client
 	jsonRsp := ct.GetEntryAndProofResponse{
 		LeafInput: rsp.Leaf.LeafValue,
-		ExtraData: rsp.Leaf.ExtraData,
+		ExtraData: extraData[0],
 		AuditPath: auditPathFromProto(rsp.Proof.ProofNode),
 	}
 
@@ -677,7 +718,7 @@ func verifyAddChain(c LogContext, req ct.AddChainRequest, w http.ResponseWriter,
 
 // buildLogLeafForAddChain is also used by add-pre-chain and does the hashing to build a
 // LogLeaf that will be sent to the backend
-func buildLogLeafForAddChain(c LogContext, merkleLeaf ct.MerkleTreeLeaf, chain []*x509.Certificate) (trillian.LogLeaf, error) {
+func buildLogLeafForAddChain(ctx context.Context, c LogContext, merkleLeaf ct.MerkleTreeLeaf, chain []*x509.Certificate) (trillian.LogLeaf, error) {
 	leafData, err := tls.Marshal(merkleLeaf)
 	if err != nil {
 		glog.Warningf("%s: Failed to serialize Merkle leaf: %v", c.LogPrefix, err)
@@ -690,7 +731,12 @@ func buildLogLeafForAddChain(c LogContext, merkleLeaf ct.MerkleTreeLeaf, chain [
 		return trillian.LogLeaf{}, err
 	}
 
-	extraData, err := extraDataForChain(chain, isPrecert)
+	var extraData []byte
+	if c.certStore != nil {
+		extraData, err = chainRefsForChain(ctx, c.certStore, chain, isPrecert)
+	} else {
+		extraData, err = extraDataForChain(chain, isPrecert)
+	}
 	if err != nil {
 		glog.Warningf("%s: Failed to serialize chain for ExtraData: %v", c.LogPrefix, err)
 		return trillian.LogLeaf{}, err
@@ -710,37 +756,11 @@ func buildLogLeafForAddChain(c LogContext, merkleLeaf ct.MerkleTreeLeaf, chain [
 // extraDataForChain creates the extra data associated with a log entry as described in
 // RFC6962 section 4.6.
 func extraDataForChain(chain []*x509.Certificate, isPrecert bool) ([]byte, error) {
-	var extraData []byte
-	var err error
-	if isPrecert {
-		// For a pre-certificate, the extra data is a TLS-encoded PrecertChainEntry.
-		extra := ct.PrecertChainEntry{
-			PreCertificate:   ct.ASN1Cert{Data: chain[0].Raw},
-			CertificateChain: make([]ct.ASN1Cert, len(chain)-1),
-		}
-		for i := 1; i < len(chain); i++ {
-			extra.CertificateChain[i-1] = ct.ASN1Cert{Data: chain[i].Raw}
-		}
-		extraData, err = tls.Marshal(extra)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		// For a certificate, the extra data is a TLS-encoded:
-		//   ASN.1Cert certificate_chain<0..2^24-1>;
-		// containing the chain after the leaf.
-		extra := ct.CertificateChain{
-			Entries: make([]ct.ASN1Cert, len(chain)-1),
-		}
-		for i := 1; i < len(chain); i++ {
-			extra.Entries[i-1] = ct.ASN1Cert{Data: chain[i].Raw}
-		}
-		extraData, err = tls.Marshal(extra)
-		if err != nil {
-			return nil, err
-		}
+	rest := make([][]byte, 0, len(chain)-1)
+	for _, cert := range chain[1:] {
+		rest = append(rest, cert.Raw)
 	}
-	return extraData, nil
+	return marshalExtraData(isPrecert, chain[0].Raw, rest)
 }
 
 // marshalAndWriteAddChainResponse is used by add-chain and add-pre-chain to create and write
@@ -890,10 +910,19 @@ func sortLeafRange(rsp *trillian.GetLeavesByIndexResponse, start, end int64) err
 
 // marshalGetEntriesResponse does the conversion from the backend response to the one we need for
 // an RFC compliant JSON response to the client.
-func marshalGetEntriesResponse(c LogContext, rsp *trillian.GetLeavesByIndexResponse) (ct.GetEntriesResponse, error) {
+func marshalGetEntriesResponse(ctx context.Context, c LogContext, rsp *trillian.GetLeavesByIndexResponse) (ct.GetEntriesResponse, error) {
 	jsonRsp := ct.GetEntriesResponse{}
 
+	extraData := make([][]byte, 0, len(rsp.Leaves))
 	for _, leaf := range rsp.Leaves {
+		extraData = append(extraData, leaf.ExtraData)
+	}
+	extraData, err := resolveExtraData(ctx, c.certStore, extraData)
+	if err != nil {
+		return jsonRsp, err
+	}
+
+	for i, leaf := range rsp.Leaves {
 		// We're only deserializing it to ensure it's valid, don't need the result. We still
 		// return the data if it fails to deserialize as otherwise the root hash could not
 		// be verified. However this indicates a potentially serious failure in log operation
@@ -906,13 +935,12 @@ func marshalGetEntriesResponse(c LogContext, rsp *trillian.GetLeavesByIndexRespo
 			glog.Warningf("%s: Trailing data after Merkle leaf from backend: %d", c.LogPrefix, leaf.LeafIndex)
 		}
 
-		extraData := leaf.ExtraData
-		if len(extraData) == 0 {
+		if len(extraData[i]) == 0 {
 			glog.Errorf("%s: Missing ExtraData for leaf %d", c.LogPrefix, leaf.LeafIndex)
 		}
 		jsonRsp.Entries = append(jsonRsp.Entries, ct.LeafEntry{
 			LeafInput: leaf.LeafValue,
-			ExtraData: extraData,
+			ExtraData: extraData[i],
 		})
 	}
 
@@ -80,8 +80,10 @@ func LogConfigFromFile(filename string) ([]LogConfig, error) {
 }
 
 // SetUpInstance sets up a log instance that uses the specified client to communicate
-// with the Trillian RPC back end.
-func (cfg LogConfig) SetUpInstance(client trillian.TrillianLogClient, deadline time.Duration) (*PathHandlers, error) {
+// with the Trillian RPC back end. If certStore is not nil, the certificates of
+// submitted chains are kept there rather than in the ExtraData of each entry.
+// If sctObserver is not nil, it is told about every SCT the log issues.
+func (cfg LogConfig) SetUpInstance(client trillian.TrillianLogClient, deadline time.Duration, certStore CertStore, sctObserver SCTObserver) (*PathHandlers, error) {
 	// Check config validity.
 	if len(cfg.RootsPEMFile) == 0 {
 		return nil, errors.New("need to specify RootsPEMFile")
@@ -109,6 +111,12 @@ func (cfg LogConfig) SetUpInstance(client trillian.TrillianLogClient, deadline t
 
 	// Create and register the handlers using the RPC client we just set up
 	ctx := NewLogContext(cfg.LogID, cfg.Prefix, roots, client, signer, deadline, new(util.SystemTimeSource))
+	if certStore != nil {
+		ctx.SetCertStore(certStore)
+	}
+	if sctObserver != nil {
+		ctx.SetSCTObserver(sctObserver)
+	}
 	logVars.Set(cfg.Prefix, ctx.exp.vars)
 
 	handlers := ctx.Handlers(cfg.Prefix)
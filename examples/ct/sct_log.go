@@ -0,0 +1,130 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	ct "github.com/google/certificate-transparency/go"
+	"github.com/google/certificate-transparency/go/tls"
+)
+
+// IssuedSCT records an SCT issued by a log, together with the Merkle tree
+// leaf it was issued for, so that the SCT can later be audited.
+type IssuedSCT struct {
+	// LogID is the tree ID of the log that issued the SCT.
+	LogID int64 `json:"log_id"`
+	// Prefix is the URL prefix of the log that issued the SCT.
+	Prefix string `json:"prefix"`
+	// SCT is the TLS-encoded SignedCertificateTimestamp.
+	SCT []byte `json:"sct"`
+	// LeafInput is the TLS-encoded MerkleTreeLeaf the SCT was issued for, as
+	// it will appear in the log.
+	LeafInput []byte `json:"leaf_input"`
+}
+
+// NewIssuedSCT builds the IssuedSCT for an SCT issued by the given log for
+// leaf.
+func NewIssuedSCT(logID int64, prefix string, sct *ct.SignedCertificateTimestamp, leaf *ct.MerkleTreeLeaf) (*IssuedSCT, error) {
+	sctData, err := tls.Marshal(*sct)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize SCT: %v", err)
+	}
+	leafData, err := tls.Marshal(*leaf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize MerkleTreeLeaf: %v", err)
+	}
+	return &IssuedSCT{LogID: logID, Prefix: prefix, SCT: sctData, LeafInput: leafData}, nil
+}
+
+// ParseSCT returns the parsed SCT and Merkle tree leaf held in s.
+func (s *IssuedSCT) ParseSCT() (*ct.SignedCertificateTimestamp, *ct.MerkleTreeLeaf, error) {
+	var sct ct.SignedCertificateTimestamp
+	if rest, err := tls.Unmarshal(s.SCT, &sct); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse SCT: %v", err)
+	} else if len(rest) > 0 {
+		return nil, nil, fmt.Errorf("extra data (%d bytes) after SCT", len(rest))
+	}
+	var leaf ct.MerkleTreeLeaf
+	if rest, err := tls.Unmarshal(s.LeafInput, &leaf); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse MerkleTreeLeaf: %v", err)
+	} else if len(rest) > 0 {
+		return nil, nil, fmt.Errorf("extra data (%d bytes) after MerkleTreeLeaf", len(rest))
+	}
+	if leaf.TimestampedEntry == nil {
+		return nil, nil, errors.New("MerkleTreeLeaf has no TimestampedEntry")
+	}
+	return &sct, &leaf, nil
+}
+
+// SCTObserver is told about every SCT a log issues.
+type SCTObserver interface {
+	// SCTIssued is called once the SCT has been returned to the submitter.
+	SCTIssued(sct *IssuedSCT) error
+}
+
+// SCTLogWriter is an SCTObserver which writes each issued SCT to a submission
+// log, as a line of JSON. It is safe for concurrent use, and can be shared by
+// many logs.
+type SCTLogWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewSCTLogWriter creates an SCTLogWriter which writes to w.
+func NewSCTLogWriter(w io.Writer) *SCTLogWriter {
+	return &SCTLogWriter{enc: json.NewEncoder(w)}
+}
+
+// SCTIssued writes sct to the submission log.
+func (s *SCTLogWriter) SCTIssued(sct *IssuedSCT) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(sct)
+}
+
+// SCTLogReader reads the SCTs in a submission log written by an SCTLogWriter.
+// It can follow a log which is still being written, as an incomplete final
+// line is held back until the rest of it can be read.
+type SCTLogReader struct {
+	r       *bufio.Reader
+	partial []byte
+}
+
+// NewSCTLogReader creates an SCTLogReader which reads from r.
+func NewSCTLogReader(r io.Reader) *SCTLogReader {
+	return &SCTLogReader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next SCT in the submission log, or io.EOF if no complete
+// entry is available yet.
+func (s *SCTLogReader) Next() (*IssuedSCT, error) {
+	line, err := s.r.ReadBytes('\n')
+	s.partial = append(s.partial, line...)
+	if err != nil {
+		return nil, err
+	}
+	line, s.partial = s.partial, nil
+	var sct IssuedSCT
+	if err := json.Unmarshal(line, &sct); err != nil {
+		return nil, fmt.Errorf("failed to parse submission log entry: %v", err)
+	}
+	return &sct, nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/trillian"
+	cttestonly "github.com/google/trillian/examples/ct/testonly"
+)
+
+type fakeSCTObserver struct {
+	issued []*IssuedSCT
+}
+
+func (f *fakeSCTObserver) SCTIssued(sct *IssuedSCT) error {
+	f.issued = append(f.issued, sct)
+	return nil
+}
+
+func TestSCTLogRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewSCTLogWriter(&buf)
+	want := []*IssuedSCT{
+		{LogID: 1, Prefix: "one", SCT: []byte("sct1"), LeafInput: []byte("leaf1")},
+		{LogID: 2, Prefix: "two", SCT: []byte("sct2"), LeafInput: []byte("leaf2")},
+	}
+	for _, sct := range want {
+		if err := writer.SCTIssued(sct); err != nil {
+			t.Fatalf("SCTIssued(): %v", err)
+		}
+	}
+	data := buf.Bytes()
+
+	// Feed the log to the reader in two parts, splitting the second entry, as
+	// happens when following a log that is being written.
+	split := len(data) - 5
+	var r bytes.Buffer
+	r.Write(data[:split])
+	reader := NewSCTLogReader(&r)
+	got, err := reader.Next()
+	if err != nil {
+		t.Fatalf("Next(): %v", err)
+	}
+	if !reflect.DeepEqual(got, want[0]) {
+		t.Errorf("Next()=%+v, want %+v", got, want[0])
+	}
+	if got, err := reader.Next(); err != io.EOF {
+		t.Fatalf("Next() on partial entry=%+v, %v; want io.EOF", got, err)
+	}
+	r.Write(data[split:])
+	if got, err = reader.Next(); err != nil {
+		t.Fatalf("Next(): %v", err)
+	}
+	if !reflect.DeepEqual(got, want[1]) {
+		t.Errorf("Next()=%+v, want %+v", got, want[1])
+	}
+	if got, err := reader.Next(); err != io.EOF {
+		t.Errorf("Next() at end=%+v, %v; want io.EOF", got, err)
+	}
+}
+
+func TestAddChainObservesSCT(t *testing.T) {
+	signer, err := setupSigner(fakeSignature)
+	if err != nil {
+		t.Fatalf("Failed to create test signer: %v", err)
+	}
+	info := setupTest(t, []string{cttestonly.FakeCACertPEM}, signer)
+	defer info.mockCtrl.Finish()
+	observer := &fakeSCTObserver{}
+	info.c.SetSCTObserver(observer)
+
+	pool := loadCertsIntoPoolOrDie(t, []string{cttestonly.LeafSignedByFakeIntermediateCertPEM, cttestonly.FakeIntermediateCertPEM})
+	leafValue := merkleLeafBytes(t, pool.RawCertificates(), false)
+	info.client.EXPECT().QueueLeaves(deadlineMatcher(), gomock.Any()).Return(&trillian.QueueLeavesResponse{QueuedLeaves: []*trillian.QueuedLogLeaf{{Leaf: &trillian.LogLeaf{LeafValue: leafValue}}}}, nil)
+
+	recorder := makeAddChainRequest(t, info.c, createJSONChain(t, *pool))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("addChain()=%d (body:%v); want 200", recorder.Code, recorder.Body)
+	}
+	if got, want := len(observer.issued), 1; got != want {
+		t.Fatalf("len(issued)=%d, want %d", got, want)
+	}
+	issued := observer.issued[0]
+	if got, want := issued.LogID, int64(0x42); got != want {
+		t.Errorf("issued.LogID=%d, want %d", got, want)
+	}
+	if !bytes.Equal(issued.LeafInput, leafValue) {
+		t.Errorf("issued.LeafInput=%x, want %x", issued.LeafInput, leafValue)
+	}
+	sct, _, err := issued.ParseSCT()
+	if err != nil {
+		t.Fatalf("ParseSCT(): %v", err)
+	}
+	if got, want := sct.Timestamp, fakeTimeMillis; got != want {
+		t.Errorf("sct.Timestamp=%d, want %d", got, want)
+	}
+}
@@ -0,0 +1,291 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sctaudit checks that the SCTs issued by a CT log are honoured: that
+// they are correctly signed, and that their entries are incorporated into the
+// log within its maximum merge delay (MMD).
+package sctaudit
+
+import (
+	"context"
+	gocrypto "crypto"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	ct "github.com/google/certificate-transparency/go"
+	"github.com/google/certificate-transparency/go/merkletree"
+	ctfe "github.com/google/trillian/examples/ct"
+	"github.com/google/trillian/monitoring/metric"
+	"github.com/google/trillian/util"
+)
+
+// AlertKind identifies the kind of problem an Alert reports.
+type AlertKind string
+
+const (
+	// BadSCTSignature means that an SCT's signature did not verify.
+	BadSCTSignature AlertKind = "bad-sct-signature"
+	// BadSTHSignature means that an STH's signature did not verify.
+	BadSTHSignature AlertKind = "bad-sth-signature"
+	// MissedMMD means that an SCT's entry was not incorporated into the log
+	// within the MMD.
+	MissedMMD AlertKind = "missed-mmd"
+)
+
+var (
+	sctsAudited = metric.NewCounter("sct_audit_scts_audited")
+	sctsPassed  = metric.NewCounter("sct_audit_scts_passed")
+	alertCounts = map[AlertKind]metric.Counter{
+		BadSCTSignature: metric.NewCounter("sct_audit_alerts_bad_sct_signature"),
+		BadSTHSignature: metric.NewCounter("sct_audit_alerts_bad_sth_signature"),
+		MissedMMD:       metric.NewCounter("sct_audit_alerts_missed_mmd"),
+	}
+)
+
+// Alert describes a way in which a log failed to honour an SCT.
+type Alert struct {
+	Kind AlertKind
+	// Prefix identifies the log.
+	Prefix string
+	// Timestamp is the timestamp of the SCT or STH concerned.
+	Timestamp time.Time
+	// LeafHash is the Merkle leaf hash of the SCT's entry, if any.
+	LeafHash []byte
+	// Details describes what went wrong.
+	Details string
+}
+
+func (a Alert) String() string {
+	return fmt.Sprintf("%s: %s for SCT/STH @ %v (leaf hash %x): %s", a.Prefix, a.Kind, a.Timestamp, a.LeafHash, a.Details)
+}
+
+// Alerter is told about every Alert raised.
+type Alerter interface {
+	Alert(a Alert)
+}
+
+// LogAlerter is an Alerter which logs each Alert as an error.
+type LogAlerter struct{}
+
+// Alert logs a.
+func (LogAlerter) Alert(a Alert) {
+	glog.Errorf("ALERT: %v", a)
+}
+
+// LogClient is the part of the CT log client API used by the Auditor.
+type LogClient interface {
+	GetSTH(ctx context.Context) (*ct.SignedTreeHead, error)
+	GetProofByHash(ctx context.Context, hash []byte, treeSize uint64) (*ct.GetProofByHashResponse, error)
+}
+
+var verifier = merkletree.NewMerkleVerifier(func(data []byte) []byte {
+	hash := sha256.Sum256(data)
+	return hash[:]
+})
+
+// pendingSCT is an SCT whose entry has not yet been checked for inclusion.
+type pendingSCT struct {
+	timestamp time.Time
+	leafData  []byte
+	leafHash  [sha256.Size]byte
+	// due is when the entry must be incorporated by.
+	due time.Time
+}
+
+// Auditor audits the SCTs issued by a single log.
+type Auditor struct {
+	prefix     string
+	client     LogClient
+	sigVerify  *ct.SignatureVerifier
+	mmd        time.Duration
+	grace      time.Duration
+	timeSource util.TimeSource
+	alerter    Alerter
+
+	mu      sync.Mutex
+	pending []*pendingSCT
+}
+
+// NewAuditor creates an Auditor for the log identified by prefix, which is
+// accessed through client, and whose public key is pubKey.
+func NewAuditor(prefix string, client LogClient, pubKey gocrypto.PublicKey, mmd time.Duration, timeSource util.TimeSource, alerter Alerter) (*Auditor, error) {
+	sigVerify, err := ct.NewSignatureVerifier(pubKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create signature verifier: %v", err)
+	}
+	return &Auditor{
+		prefix:     prefix,
+		client:     client,
+		sigVerify:  sigVerify,
+		mmd:        mmd,
+		grace:      mmd,
+		timeSource: timeSource,
+		alerter:    alerter,
+	}, nil
+}
+
+// SetGracePeriod sets how long after an SCT's merge deadline the Auditor waits
+// for the log to publish an STH from after the deadline, before raising a
+// MissedMMD alert. It defaults to the MMD.
+func (a *Auditor) SetGracePeriod(grace time.Duration) {
+	a.grace = grace
+}
+
+// Pending returns the number of SCTs which have not yet been checked for
+// inclusion.
+func (a *Auditor) Pending() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.pending)
+}
+
+// Add verifies the signature of an SCT issued by the log, raising an alert if
+// it is bad, and otherwise queues the SCT's entry to be checked for inclusion
+// once its merge deadline has passed.
+func (a *Auditor) Add(issued *ctfe.IssuedSCT) error {
+	sct, leaf, err := issued.ParseSCT()
+	if err != nil {
+		return err
+	}
+	sctsAudited.Add(1)
+	p := &pendingSCT{
+		timestamp: timeFromMS(sct.Timestamp),
+		leafData:  issued.LeafInput,
+		leafHash:  sha256.Sum256(append([]byte{merkletree.LeafPrefix}, issued.LeafInput...)),
+	}
+	p.due = p.timestamp.Add(a.mmd)
+	if err := a.sigVerify.VerifySCTSignature(*sct, ct.LogEntry{Leaf: *leaf}); err != nil {
+		a.alert(BadSCTSignature, p.timestamp, p.leafHash[:], err.Error())
+		return nil
+	}
+	if ts := leaf.TimestampedEntry.Timestamp; ts != sct.Timestamp {
+		a.alert(BadSCTSignature, p.timestamp, p.leafHash[:], fmt.Sprintf("SCT timestamp differs from entry timestamp %v", timeFromMS(ts)))
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.insertLocked(p)
+	return nil
+}
+
+// Check fetches the log's latest STH, and checks the inclusion of every
+// pending entry whose merge deadline has passed. An entry which can't be shown
+// to be included in an STH from at or after its deadline raises a MissedMMD
+// alert straight away. If the log hasn't published an STH since the deadline,
+// the entry is retried on each Check until the grace period after the deadline
+// has passed, and then raises a MissedMMD alert. SCTs may be added while Check
+// is waiting on the log.
+func (a *Auditor) Check(ctx context.Context) error {
+	due := a.takeDue(a.timeSource.Now())
+	if len(due) == 0 {
+		return nil
+	}
+
+	sth, err := a.client.GetSTH(ctx)
+	if err != nil {
+		a.requeue(due)
+		return fmt.Errorf("failed to get STH: %v", err)
+	}
+	sthTime := timeFromMS(sth.Timestamp)
+	if err := a.sigVerify.VerifySTHSignature(*sth); err != nil {
+		a.requeue(due)
+		a.alert(BadSTHSignature, sthTime, nil, err.Error())
+		return nil
+	}
+
+	now := a.timeSource.Now()
+	var retry []*pendingSCT
+	for _, p := range due {
+		if sthTime.Before(p.due) {
+			if now.Before(p.due.Add(a.grace)) {
+				glog.V(1).Infof("%s: SCT @ %v due by %v, but latest STH is @ %v", a.prefix, p.timestamp, p.due, sthTime)
+				retry = append(retry, p)
+				continue
+			}
+			a.alert(MissedMMD, p.timestamp, p.leafHash[:], fmt.Sprintf("no STH published since merge deadline %v, latest STH @ %v", p.due, sthTime))
+			continue
+		}
+		if err := a.checkInclusion(ctx, p, sth); err != nil {
+			a.alert(MissedMMD, p.timestamp, p.leafHash[:], err.Error())
+			continue
+		}
+		sctsPassed.Add(1)
+	}
+	a.requeue(retry)
+	return nil
+}
+
+// takeDue removes the pending SCTs whose merge deadline has passed by now, and
+// returns them.
+func (a *Auditor) takeDue(now time.Time) []*pendingSCT {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	n := 0
+	for n < len(a.pending) && !now.Before(a.pending[n].due) {
+		n++
+	}
+	due := append([]*pendingSCT(nil), a.pending[:n]...)
+	a.pending = append([]*pendingSCT(nil), a.pending[n:]...)
+	return due
+}
+
+// requeue returns SCTs taken by takeDue to the pending SCTs.
+func (a *Auditor) requeue(ps []*pendingSCT) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, p := range ps {
+		a.insertLocked(p)
+	}
+}
+
+// insertLocked adds p to the pending SCTs, which are kept sorted by due time.
+// a.mu must be held.
+func (a *Auditor) insertLocked(p *pendingSCT) {
+	// SCTs mostly arrive in timestamp order, so insert from the end.
+	i := len(a.pending)
+	for i > 0 && a.pending[i-1].due.After(p.due) {
+		i--
+	}
+	a.pending = append(a.pending, nil)
+	copy(a.pending[i+1:], a.pending[i:])
+	a.pending[i] = p
+}
+
+func (a *Auditor) checkInclusion(ctx context.Context, p *pendingSCT, sth *ct.SignedTreeHead) error {
+	rsp, err := a.client.GetProofByHash(ctx, p.leafHash[:], sth.TreeSize)
+	if err != nil {
+		return fmt.Errorf("get-proof-by-hash(size=%d) failed: %v", sth.TreeSize, err)
+	}
+	if err := verifier.VerifyInclusionProof(rsp.LeafIndex, int64(sth.TreeSize), rsp.AuditPath, sth.SHA256RootHash[:], p.leafData); err != nil {
+		return fmt.Errorf("inclusion proof for index %d in tree size %d failed to verify: %v", rsp.LeafIndex, sth.TreeSize, err)
+	}
+	glog.V(2).Infof("%s: SCT @ %v included at index %d", a.prefix, p.timestamp, rsp.LeafIndex)
+	return nil
+}
+
+func (a *Auditor) alert(kind AlertKind, ts time.Time, leafHash []byte, details string) {
+	alertCounts[kind].Add(1)
+	a.alerter.Alert(Alert{Kind: kind, Prefix: a.prefix, Timestamp: ts, LeafHash: leafHash, Details: details})
+}
+
+// timeFromMS converts a timestamp in milliseconds (as used in CT) to a time.Time.
+func timeFromMS(ts uint64) time.Time {
+	secs := int64(ts / 1000)
+	msecs := int64(ts % 1000)
+	return time.Unix(secs, msecs*1000000)
+}
@@ -0,0 +1,312 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sctaudit
+
+import (
+	"context"
+	gocrypto "crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	ct "github.com/google/certificate-transparency/go"
+	"github.com/google/certificate-transparency/go/tls"
+	"github.com/google/trillian/crypto/keys"
+	ctfe "github.com/google/trillian/examples/ct"
+	cttestonly "github.com/google/trillian/examples/ct/testonly"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/merkle/rfc6962"
+	"github.com/google/trillian/util"
+)
+
+const mmd = time.Hour
+
+var fakeTime = time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func msFromTime(t time.Time) uint64 {
+	return uint64(t.UnixNano() / int64(time.Millisecond))
+}
+
+func sign(t *testing.T, signer gocrypto.Signer, data []byte) ct.DigitallySigned {
+	digest := sha256.Sum256(data)
+	sig, err := signer.Sign(rand.Reader, digest[:], gocrypto.SHA256)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	return ct.DigitallySigned(tls.DigitallySigned{
+		Algorithm: tls.SignatureAndHashAlgorithm{Hash: tls.SHA256, Signature: tls.ECDSA},
+		Signature: sig,
+	})
+}
+
+// fakeLog is an in-memory CT log which signs its SCTs and STHs.
+type fakeLog struct {
+	t        *testing.T
+	signer   gocrypto.Signer
+	tree     *merkle.InMemoryMerkleTree
+	indices  map[[sha256.Size]byte]int64
+	sth      *ct.SignedTreeHead
+	proofErr error
+	// onProof, if set, is called by GetProofByHash.
+	onProof func()
+}
+
+func newFakeLog(t *testing.T) *fakeLog {
+	signer, err := keys.NewFromPrivatePEM(cttestonly.CTLogPrivateKeyPEM, cttestonly.CTLogKeyPassword)
+	if err != nil {
+		t.Fatalf("Failed to load log key: %v", err)
+	}
+	return &fakeLog{
+		t:       t,
+		signer:  signer,
+		tree:    merkle.NewInMemoryMerkleTree(rfc6962.TreeHasher{Hash: gocrypto.SHA256}),
+		indices: make(map[[sha256.Size]byte]int64),
+	}
+}
+
+// issue returns an SCT with the given timestamp for a new entry, adding the
+// entry to the tree if include is set.
+func (f *fakeLog) issue(ts time.Time, include bool) *ctfe.IssuedSCT {
+	leaf := ct.MerkleTreeLeaf{
+		Version:  ct.V1,
+		LeafType: ct.TimestampedEntryLeafType,
+		TimestampedEntry: &ct.TimestampedEntry{
+			Timestamp: msFromTime(ts),
+			EntryType: ct.X509LogEntryType,
+			X509Entry: &ct.ASN1Cert{Data: []byte(fmt.Sprintf("cert-%d", len(f.indices)))},
+		},
+	}
+	sct := ct.SignedCertificateTimestamp{SCTVersion: ct.V1, Timestamp: msFromTime(ts)}
+	input, err := ct.SerializeSCTSignatureInput(sct, ct.LogEntry{Leaf: leaf})
+	if err != nil {
+		f.t.Fatalf("SerializeSCTSignatureInput(): %v", err)
+	}
+	sct.Signature = sign(f.t, f.signer, input)
+	issued, err := ctfe.NewIssuedSCT(1, "test", &sct, &leaf)
+	if err != nil {
+		f.t.Fatalf("NewIssuedSCT(): %v", err)
+	}
+	if include {
+		index, _ := f.tree.AddLeaf(issued.LeafInput)
+		f.indices[sha256.Sum256(append([]byte{0}, issued.LeafInput...))] = index - 1
+	}
+	return issued
+}
+
+// publish signs an STH for the current tree with the given timestamp.
+func (f *fakeLog) publish(ts time.Time) {
+	sth := &ct.SignedTreeHead{Version: ct.V1, TreeSize: uint64(f.tree.LeafCount()), Timestamp: msFromTime(ts)}
+	copy(sth.SHA256RootHash[:], f.tree.CurrentRoot().Hash())
+	input, err := ct.SerializeSTHSignatureInput(*sth)
+	if err != nil {
+		f.t.Fatalf("SerializeSTHSignatureInput(): %v", err)
+	}
+	sth.TreeHeadSignature = sign(f.t, f.signer, input)
+	f.sth = sth
+}
+
+func (f *fakeLog) GetSTH(ctx context.Context) (*ct.SignedTreeHead, error) {
+	if f.sth == nil {
+		return nil, errors.New("no STH")
+	}
+	return f.sth, nil
+}
+
+func (f *fakeLog) GetProofByHash(ctx context.Context, hash []byte, treeSize uint64) (*ct.GetProofByHashResponse, error) {
+	if f.onProof != nil {
+		f.onProof()
+	}
+	if f.proofErr != nil {
+		return nil, f.proofErr
+	}
+	var key [sha256.Size]byte
+	copy(key[:], hash)
+	index, ok := f.indices[key]
+	if !ok || index >= int64(treeSize) {
+		return nil, errors.New("not found")
+	}
+	rsp := &ct.GetProofByHashResponse{LeafIndex: index}
+	for _, node := range f.tree.PathToRootAtSnapshot(index+1, int64(treeSize)) {
+		rsp.AuditPath = append(rsp.AuditPath, node.Value.Hash())
+	}
+	return rsp, nil
+}
+
+type fakeAlerter struct {
+	alerts []Alert
+}
+
+func (f *fakeAlerter) Alert(a Alert) {
+	f.alerts = append(f.alerts, a)
+}
+
+func (f *fakeAlerter) kinds() []AlertKind {
+	var kinds []AlertKind
+	for _, a := range f.alerts {
+		kinds = append(kinds, a.Kind)
+	}
+	return kinds
+}
+
+func newTestAuditor(t *testing.T, log *fakeLog, timeSource util.TimeSource) (*Auditor, *fakeAlerter) {
+	alerter := &fakeAlerter{}
+	a, err := NewAuditor("test", log, log.signer.Public(), mmd, timeSource, alerter)
+	if err != nil {
+		t.Fatalf("NewAuditor(): %v", err)
+	}
+	a.SetGracePeriod(10 * time.Minute)
+	return a, alerter
+}
+
+func TestAuditor(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		desc     string
+		include  bool
+		sthTime  time.Duration // after the SCT timestamp
+		now      time.Duration // after the SCT timestamp
+		proofErr error
+		want     []AlertKind
+		pending  int
+	}{
+		{desc: "included", include: true, sthTime: mmd, now: mmd},
+		{desc: "not due", include: false, sthTime: mmd, now: mmd - time.Second, pending: 1},
+		// There's an STH from after the deadline, so there's no need to wait.
+		{desc: "missing", include: false, sthTime: mmd, now: mmd, want: []AlertKind{MissedMMD}},
+		{desc: "proof error", include: true, sthTime: mmd, now: mmd, proofErr: errors.New("unavailable"), want: []AlertKind{MissedMMD}},
+		{desc: "stale STH within grace", include: true, sthTime: mmd - time.Second, now: mmd + time.Minute, pending: 1},
+		{desc: "stale STH", include: true, sthTime: mmd - time.Second, now: mmd + 10*time.Minute, want: []AlertKind{MissedMMD}},
+	} {
+		log := newFakeLog(t)
+		log.proofErr = test.proofErr
+		timeSource := &util.FakeTimeSource{FakeTime: fakeTime}
+		a, alerter := newTestAuditor(t, log, timeSource)
+
+		if err := a.Add(log.issue(fakeTime, test.include)); err != nil {
+			t.Fatalf("%s: Add(): %v", test.desc, err)
+		}
+		log.publish(fakeTime.Add(test.sthTime))
+		timeSource.FakeTime = fakeTime.Add(test.now)
+		if err := a.Check(ctx); err != nil {
+			t.Errorf("%s: Check(): %v", test.desc, err)
+		}
+		if got := alerter.kinds(); fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("%s: alerts=%v, want %v", test.desc, got, test.want)
+		}
+		if got := a.Pending(); got != test.pending {
+			t.Errorf("%s: Pending()=%d, want %d", test.desc, got, test.pending)
+		}
+	}
+}
+
+func TestAuditorOrdering(t *testing.T) {
+	ctx := context.Background()
+	log := newFakeLog(t)
+	timeSource := &util.FakeTimeSource{FakeTime: fakeTime}
+	a, alerter := newTestAuditor(t, log, timeSource)
+
+	// SCTs are checked once due, whatever order they are added in.
+	for _, offset := range []time.Duration{2 * time.Minute, 0, time.Minute, 3 * time.Minute} {
+		if err := a.Add(log.issue(fakeTime.Add(offset), true)); err != nil {
+			t.Fatalf("Add(): %v", err)
+		}
+	}
+	log.publish(fakeTime.Add(mmd + 3*time.Minute))
+	for i, want := range []int{3, 2, 1, 0} {
+		timeSource.FakeTime = fakeTime.Add(mmd + time.Duration(i)*time.Minute)
+		if err := a.Check(ctx); err != nil {
+			t.Fatalf("Check(): %v", err)
+		}
+		if got := a.Pending(); got != want {
+			t.Errorf("at %v: Pending()=%d, want %d", timeSource.FakeTime, got, want)
+		}
+	}
+	if len(alerter.alerts) != 0 {
+		t.Errorf("alerts=%v, want none", alerter.alerts)
+	}
+}
+
+func TestAuditorAddDuringCheck(t *testing.T) {
+	ctx := context.Background()
+	log := newFakeLog(t)
+	timeSource := &util.FakeTimeSource{FakeTime: fakeTime.Add(mmd)}
+	a, alerter := newTestAuditor(t, log, timeSource)
+
+	if err := a.Add(log.issue(fakeTime, true)); err != nil {
+		t.Fatalf("Add(): %v", err)
+	}
+	log.publish(fakeTime.Add(mmd))
+	// Adding an SCT while Check waits on the log mustn't block, and the SCT
+	// isn't lost.
+	late := log.issue(fakeTime.Add(time.Minute), true)
+	log.onProof = func() {
+		log.onProof = nil
+		if err := a.Add(late); err != nil {
+			t.Errorf("Add() during Check(): %v", err)
+		}
+	}
+	if err := a.Check(ctx); err != nil {
+		t.Fatalf("Check(): %v", err)
+	}
+	if got := a.Pending(); got != 1 {
+		t.Errorf("Pending()=%d, want 1", got)
+	}
+	if len(alerter.alerts) != 0 {
+		t.Errorf("alerts=%v, want none", alerter.alerts)
+	}
+}
+
+func TestAuditorBadSignatures(t *testing.T) {
+	ctx := context.Background()
+	log := newFakeLog(t)
+	timeSource := &util.FakeTimeSource{FakeTime: fakeTime.Add(mmd)}
+	a, alerter := newTestAuditor(t, log, timeSource)
+
+	issued := log.issue(fakeTime, true)
+	sct, leaf, err := issued.ParseSCT()
+	if err != nil {
+		t.Fatalf("ParseSCT(): %v", err)
+	}
+	sct.Timestamp++
+	leaf.TimestampedEntry.Timestamp++
+	forged, err := ctfe.NewIssuedSCT(issued.LogID, issued.Prefix, sct, leaf)
+	if err != nil {
+		t.Fatalf("NewIssuedSCT(): %v", err)
+	}
+	if err := a.Add(forged); err != nil {
+		t.Fatalf("Add(): %v", err)
+	}
+	if got := a.Pending(); got != 0 {
+		t.Errorf("Pending()=%d after bad SCT, want 0", got)
+	}
+	if err := a.Add(&ctfe.IssuedSCT{SCT: []byte("not an SCT")}); err == nil {
+		t.Error("Add(garbage) succeeded, want error")
+	}
+
+	if err := a.Add(issued); err != nil {
+		t.Fatalf("Add(): %v", err)
+	}
+	log.publish(fakeTime.Add(mmd))
+	log.sth.TreeSize++
+	if err := a.Check(ctx); err != nil {
+		t.Fatalf("Check(): %v", err)
+	}
+	if got, want := fmt.Sprint(alerter.kinds()), fmt.Sprint([]AlertKind{BadSCTSignature, BadSTHSignature}); got != want {
+		t.Errorf("alerts=%v, want %v", got, want)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package submitproxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+// LogEndpointConfig describes one of the logs a Proxy submits to.
+type LogEndpointConfig struct {
+	// Name identifies the log in diagnostics.
+	Name string
+	// URL is the base URL of the log, up to but not including "/ct/v1/".
+	URL string
+	// Operator identifies the organization running the log, for policy.
+	Operator string
+	// PubKeyPEMFile holds the log's public key, which is used to check the
+	// SCTs it returns. It may be empty, in which case they are not checked.
+	PubKeyPEMFile string
+	// Timeout is how long to wait for the log to return an SCT, in the form
+	// accepted by time.ParseDuration. It defaults to DefaultTimeout.
+	Timeout string
+}
+
+// ProxyConfig describes the configuration of a Proxy.
+type ProxyConfig struct {
+	// Prefix is the URL prefix the Proxy serves add-chain and add-pre-chain
+	// under.
+	Prefix string
+	// Logs are the logs to submit to.
+	Logs []LogEndpointConfig
+	// Policy is the requirement the collected SCTs must meet.
+	Policy Policy
+}
+
+// DefaultTimeout is the timeout for logs which do not specify one.
+const DefaultTimeout = 10 * time.Second
+
+// TimeoutDuration returns the parsed timeout of the log.
+func (l LogEndpointConfig) TimeoutDuration() (time.Duration, error) {
+	if len(l.Timeout) == 0 {
+		return DefaultTimeout, nil
+	}
+	timeout, err := time.ParseDuration(l.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid timeout: %v", l.Name, err)
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("%s: timeout %v must be positive", l.Name, timeout)
+	}
+	return timeout, nil
+}
+
+// ProxyConfigFromFile reads a ProxyConfig from the given filename, which
+// should contain JSON encoded configuration data.
+func ProxyConfigFromFile(filename string) (*ProxyConfig, error) {
+	if len(filename) == 0 {
+		return nil, errors.New("proxy config filename empty")
+	}
+	cfgData, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proxy config: %v", err)
+	}
+	var cfg ProxyConfig
+	if err := json.Unmarshal(cfgData, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config data: %v", err)
+	}
+	if len(cfg.Logs) == 0 {
+		return nil, errors.New("no logs configured")
+	}
+	for _, l := range cfg.Logs {
+		if len(l.Name) == 0 || len(l.URL) == 0 {
+			return nil, fmt.Errorf("log %+v needs Name and URL", l)
+		}
+		if _, err := l.TimeoutDuration(); err != nil {
+			return nil, err
+		}
+	}
+	return &cfg, nil
+}
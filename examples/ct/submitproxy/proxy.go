@@ -0,0 +1,249 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package submitproxy provides a proxy which accepts a single add-chain or
+// add-pre-chain request, submits the chain to a number of CT logs in
+// parallel, and returns all the SCTs collected, so that CAs do not each need
+// to implement submission to multiple logs. Any RFC 6962 log can be used,
+// whether it is served by the Trillian CT frontend in examples/ct or run
+// elsewhere.
+package submitproxy
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	ct "github.com/google/certificate-transparency/go"
+	"github.com/google/certificate-transparency/go/tls"
+	ctfe "github.com/google/trillian/examples/ct"
+)
+
+// LogClient is the part of the CT log client API used to submit to a log.
+type LogClient interface {
+	AddChain(ctx context.Context, chain []ct.ASN1Cert) (*ct.SignedCertificateTimestamp, error)
+	AddPreChain(ctx context.Context, chain []ct.ASN1Cert) (*ct.SignedCertificateTimestamp, error)
+}
+
+// Log is one of the logs a Proxy submits to.
+type Log struct {
+	// Name identifies the log in diagnostics.
+	Name string
+	// Operator identifies the organization running the log.
+	Operator string
+	// Client submits chains to the log. It should check the SCTs it returns.
+	Client LogClient
+	// Timeout is how long to wait for the log to return an SCT.
+	Timeout time.Duration
+}
+
+// Policy describes the SCTs that must be collected for a submission to
+// succeed.
+type Policy struct {
+	// MinSCTs is the minimum number of SCTs.
+	MinSCTs int
+	// MinOperators is the minimum number of distinct operators whose logs
+	// returned SCTs.
+	MinOperators int
+}
+
+// AddChainsResponse is the JSON response to the proxy's add-chain and
+// add-pre-chain methods.
+type AddChainsResponse struct {
+	SCTs []ct.AddChainResponse `json:"scts"`
+}
+
+// Result holds the outcome of submitting to one log.
+type Result struct {
+	Log *Log
+	SCT *ct.SignedCertificateTimestamp
+	Err error
+}
+
+// Proxy submits chains to a set of logs.
+type Proxy struct {
+	logs   []*Log
+	policy Policy
+}
+
+// NewProxy creates a Proxy submitting to the given logs, which checks the
+// SCTs collected against policy.
+func NewProxy(logs []*Log, policy Policy) (*Proxy, error) {
+	if len(logs) == 0 {
+		return nil, errors.New("no logs provided")
+	}
+	operators := make(map[string]bool)
+	for _, l := range logs {
+		if l.Timeout <= 0 {
+			return nil, fmt.Errorf("%s: timeout %v must be positive", l.Name, l.Timeout)
+		}
+		operators[l.Operator] = true
+	}
+	if policy.MinSCTs > len(logs) {
+		return nil, fmt.Errorf("policy requires %d SCTs but only %d logs are configured", policy.MinSCTs, len(logs))
+	}
+	if policy.MinOperators > len(operators) {
+		return nil, fmt.Errorf("policy requires %d operators but only %d are configured", policy.MinOperators, len(operators))
+	}
+	return &Proxy{logs: logs, policy: policy}, nil
+}
+
+// Submit submits chain to every log in parallel, waiting for each for at most
+// its timeout, and returns the outcome for each log in the order they were
+// configured. It returns an error if the SCTs collected do not meet the
+// Proxy's policy.
+func (p *Proxy) Submit(ctx context.Context, chain []ct.ASN1Cert, isPrecert bool) ([]Result, error) {
+	results := make([]Result, len(p.logs))
+	done := make(chan int, len(p.logs))
+	for i, l := range p.logs {
+		go func(i int, l *Log) {
+			ctx, cancel := context.WithTimeout(ctx, l.Timeout)
+			defer cancel()
+			var sct *ct.SignedCertificateTimestamp
+			var err error
+			if isPrecert {
+				sct, err = l.Client.AddPreChain(ctx, chain)
+			} else {
+				sct, err = l.Client.AddChain(ctx, chain)
+			}
+			results[i] = Result{Log: l, SCT: sct, Err: err}
+			done <- i
+		}(i, l)
+	}
+	for range p.logs {
+		<-done
+	}
+
+	operators := make(map[string]bool)
+	scts := 0
+	var errs []string
+	for _, r := range results {
+		if r.Err != nil {
+			glog.Warningf("%s: submission failed: %v", r.Log.Name, r.Err)
+			errs = append(errs, fmt.Sprintf("%s: %v", r.Log.Name, r.Err))
+			continue
+		}
+		scts++
+		operators[r.Log.Operator] = true
+	}
+	if scts < p.policy.MinSCTs || len(operators) < p.policy.MinOperators {
+		return results, fmt.Errorf("got %d SCTs from %d operators, policy requires %d from %d (failures: %s)",
+			scts, len(operators), p.policy.MinSCTs, p.policy.MinOperators, strings.Join(errs, "; "))
+	}
+	return results, nil
+}
+
+// Handlers returns a map from URL paths (with the given prefix) to handlers
+// for the proxy's add-chain and add-pre-chain entrypoints. Requests and
+// responses are as for RFC 6962, except that the response holds a list of
+// SCTs.
+func (p *Proxy) Handlers(prefix string) map[string]http.Handler {
+	if !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	prefix = strings.TrimRight(prefix, "/")
+	return map[string]http.Handler{
+		prefix + ct.AddChainPath:    addChainHandler{proxy: p, name: ctfe.AddChainName},
+		prefix + ct.AddPreChainPath: addChainHandler{proxy: p, name: ctfe.AddPreChainName, isPrecert: true},
+	}
+}
+
+type addChainHandler struct {
+	proxy     *Proxy
+	name      ctfe.EntrypointName
+	isPrecert bool
+}
+
+func (a addChainHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	glog.V(2).Infof("proxy: request %v %q => %s", r.Method, r.URL, a.name)
+	if r.Method != http.MethodPost {
+		sendHTTPError(w, http.StatusMethodNotAllowed, fmt.Errorf("method not allowed: %s", r.Method))
+		return
+	}
+	status, err := a.serve(r.Context(), w, r)
+	glog.V(2).Infof("proxy: %s <= status=%d", a.name, status)
+	if err != nil {
+		glog.Warningf("proxy: %s handler error: %v", a.name, err)
+		sendHTTPError(w, status, err)
+	}
+}
+
+func (a addChainHandler) serve(ctx context.Context, w http.ResponseWriter, r *http.Request) (int, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return http.StatusBadRequest, fmt.Errorf("failed to read request body: %v", err)
+	}
+	var req ct.AddChainRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("failed to parse add-chain body: %v", err)
+	}
+	if len(req.Chain) == 0 {
+		return http.StatusBadRequest, errors.New("cert chain was empty")
+	}
+	chain := make([]ct.ASN1Cert, 0, len(req.Chain))
+	for _, cert := range req.Chain {
+		chain = append(chain, ct.ASN1Cert{Data: cert})
+	}
+
+	results, err := a.proxy.Submit(ctx, chain, a.isPrecert)
+	if err != nil {
+		return http.StatusServiceUnavailable, err
+	}
+	var rsp AddChainsResponse
+	for _, r := range results {
+		if r.Err != nil {
+			continue
+		}
+		sctRsp, err := addChainResponse(r.SCT)
+		if err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("%s: %v", r.Log.Name, err)
+		}
+		rsp.SCTs = append(rsp.SCTs, *sctRsp)
+	}
+	jsonData, err := json.Marshal(&rsp)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to marshal response: %v", err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(jsonData); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to write response: %v", err)
+	}
+	return http.StatusOK, nil
+}
+
+// addChainResponse converts sct back into the form a log returns it in.
+func addChainResponse(sct *ct.SignedCertificateTimestamp) (*ct.AddChainResponse, error) {
+	sig, err := tls.Marshal(sct.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal signature: %v", err)
+	}
+	return &ct.AddChainResponse{
+		SCTVersion: sct.SCTVersion,
+		ID:         sct.LogID.KeyID[:],
+		Timestamp:  sct.Timestamp,
+		Extensions: base64.StdEncoding.EncodeToString(sct.Extensions),
+		Signature:  sig,
+	}, nil
+}
+
+func sendHTTPError(w http.ResponseWriter, statusCode int, err error) {
+	http.Error(w, fmt.Sprintf("%s\n%v", http.StatusText(statusCode), err), statusCode)
+}
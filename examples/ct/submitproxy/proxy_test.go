@@ -0,0 +1,256 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package submitproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	ct "github.com/google/certificate-transparency/go"
+	"github.com/google/certificate-transparency/go/tls"
+)
+
+// fakeLogClient returns an SCT with its timestamp, or its error. If hang is
+// set it waits for its context to expire instead.
+type fakeLogClient struct {
+	timestamp uint64
+	err       error
+	hang      bool
+	precerts  int
+}
+
+func (f *fakeLogClient) add(ctx context.Context) (*ct.SignedCertificateTimestamp, error) {
+	if f.hang {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &ct.SignedCertificateTimestamp{
+		SCTVersion: ct.V1,
+		Timestamp:  f.timestamp,
+		Signature:  ct.DigitallySigned(tls.DigitallySigned{Algorithm: tls.SignatureAndHashAlgorithm{Hash: tls.SHA256, Signature: tls.ECDSA}, Signature: []byte("sig")}),
+	}, nil
+}
+
+func (f *fakeLogClient) AddChain(ctx context.Context, chain []ct.ASN1Cert) (*ct.SignedCertificateTimestamp, error) {
+	return f.add(ctx)
+}
+
+func (f *fakeLogClient) AddPreChain(ctx context.Context, chain []ct.ASN1Cert) (*ct.SignedCertificateTimestamp, error) {
+	f.precerts++
+	return f.add(ctx)
+}
+
+func testLog(name, operator string, client *fakeLogClient) *Log {
+	return &Log{Name: name, Operator: operator, Client: client, Timeout: 50 * time.Millisecond}
+}
+
+var chain = []ct.ASN1Cert{{Data: []byte("leaf")}, {Data: []byte("issuer")}}
+
+func TestNewProxy(t *testing.T) {
+	logs := []*Log{testLog("a", "op1", &fakeLogClient{}), testLog("b", "op1", &fakeLogClient{})}
+	for _, test := range []struct {
+		desc    string
+		logs    []*Log
+		policy  Policy
+		wantErr bool
+	}{
+		{desc: "ok", logs: logs, policy: Policy{MinSCTs: 2, MinOperators: 1}},
+		{desc: "no logs", policy: Policy{}, wantErr: true},
+		{desc: "too many SCTs", logs: logs, policy: Policy{MinSCTs: 3}, wantErr: true},
+		{desc: "too many operators", logs: logs, policy: Policy{MinOperators: 2}, wantErr: true},
+		{desc: "no timeout", logs: []*Log{{Name: "c", Client: &fakeLogClient{}}}, wantErr: true},
+	} {
+		_, err := NewProxy(test.logs, test.policy)
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("%s: NewProxy()=%v, want error %v", test.desc, err, test.wantErr)
+		}
+	}
+}
+
+func TestSubmit(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		desc    string
+		clients map[string]*fakeLogClient // operator => client
+		policy  Policy
+		wantOK  int
+		wantErr bool
+	}{
+		{
+			desc:    "all succeed",
+			clients: map[string]*fakeLogClient{"op1": {timestamp: 1}, "op2": {timestamp: 2}},
+			policy:  Policy{MinSCTs: 2, MinOperators: 2},
+			wantOK:  2,
+		},
+		{
+			desc:    "one fails",
+			clients: map[string]*fakeLogClient{"op1": {timestamp: 1}, "op2": {err: errors.New("rejected")}},
+			policy:  Policy{MinSCTs: 1, MinOperators: 1},
+			wantOK:  1,
+		},
+		{
+			desc:    "one times out",
+			clients: map[string]*fakeLogClient{"op1": {timestamp: 1}, "op2": {hang: true}},
+			policy:  Policy{MinSCTs: 1},
+			wantOK:  1,
+		},
+		{
+			desc:    "too few operators",
+			clients: map[string]*fakeLogClient{"op1": {timestamp: 1}, "op2": {hang: true}},
+			policy:  Policy{MinOperators: 2},
+			wantOK:  1,
+			wantErr: true,
+		},
+	} {
+		var logs []*Log
+		for operator, client := range test.clients {
+			logs = append(logs, testLog(operator, operator, client))
+		}
+		proxy, err := NewProxy(logs, test.policy)
+		if err != nil {
+			t.Fatalf("%s: NewProxy(): %v", test.desc, err)
+		}
+		results, err := proxy.Submit(ctx, chain, true)
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("%s: Submit()=%v, want error %v", test.desc, err, test.wantErr)
+		}
+		ok := 0
+		for i, r := range results {
+			if r.Log != logs[i] {
+				t.Errorf("%s: results[%d].Log=%s, want %s", test.desc, i, r.Log.Name, logs[i].Name)
+			}
+			if r.Err == nil {
+				ok++
+			}
+		}
+		if ok != test.wantOK {
+			t.Errorf("%s: got %d SCTs, want %d", test.desc, ok, test.wantOK)
+		}
+		for operator, client := range test.clients {
+			if client.precerts != 1 {
+				t.Errorf("%s: %s got %d AddPreChain calls, want 1", test.desc, operator, client.precerts)
+			}
+		}
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	logs := []*Log{
+		testLog("a", "op1", &fakeLogClient{timestamp: 1}),
+		testLog("b", "op2", &fakeLogClient{err: errors.New("rejected")}),
+		testLog("c", "op3", &fakeLogClient{timestamp: 3}),
+	}
+	proxy, err := NewProxy(logs, Policy{MinSCTs: 2})
+	if err != nil {
+		t.Fatalf("NewProxy(): %v", err)
+	}
+	handlers := proxy.Handlers("submit")
+	handler, ok := handlers["/submit/ct/v1/add-chain"]
+	if !ok {
+		t.Fatalf("Handlers()=%v, missing add-chain", handlers)
+	}
+
+	for _, test := range []struct {
+		desc   string
+		method string
+		body   string
+		want   int
+	}{
+		{desc: "ok", method: http.MethodPost, body: `{"chain": ["bGVhZg==", "aXNzdWVy"]}`, want: http.StatusOK},
+		{desc: "wrong method", method: http.MethodGet, want: http.StatusMethodNotAllowed},
+		{desc: "bad json", method: http.MethodPost, body: `{"chain": [`, want: http.StatusBadRequest},
+		{desc: "empty chain", method: http.MethodPost, body: `{"chain": []}`, want: http.StatusBadRequest},
+	} {
+		req, err := http.NewRequest(test.method, "http://example.com/submit/ct/v1/add-chain", bytes.NewBufferString(test.body))
+		if err != nil {
+			t.Fatalf("%s: failed to create request: %v", test.desc, err)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != test.want {
+			t.Errorf("%s: status=%d (body:%v), want %d", test.desc, w.Code, w.Body, test.want)
+			continue
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+		var rsp AddChainsResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &rsp); err != nil {
+			t.Fatalf("%s: failed to unmarshal response %s: %v", test.desc, w.Body.Bytes(), err)
+		}
+		if got, want := len(rsp.SCTs), 2; got != want {
+			t.Fatalf("%s: len(SCTs)=%d, want %d", test.desc, got, want)
+		}
+		for i, want := range []uint64{1, 3} {
+			if got := rsp.SCTs[i].Timestamp; got != want {
+				t.Errorf("%s: SCTs[%d].Timestamp=%d, want %d", test.desc, i, got, want)
+			}
+		}
+	}
+}
+
+func TestProxyConfigFromFile(t *testing.T) {
+	for _, test := range []struct {
+		desc    string
+		config  string
+		wantErr bool
+	}{
+		{
+			desc:   "ok",
+			config: `{"Prefix": "p", "Logs": [{"Name": "a", "URL": "http://a", "Timeout": "5s"}, {"Name": "b", "URL": "http://b"}], "Policy": {"MinSCTs": 2}}`,
+		},
+		{desc: "no logs", config: `{"Prefix": "p"}`, wantErr: true},
+		{desc: "no URL", config: `{"Logs": [{"Name": "a"}]}`, wantErr: true},
+		{desc: "bad timeout", config: `{"Logs": [{"Name": "a", "URL": "http://a", "Timeout": "soon"}]}`, wantErr: true},
+		{desc: "bad json", config: `{"Logs": [`, wantErr: true},
+	} {
+		f, err := ioutil.TempFile("", "proxy_config")
+		if err != nil {
+			t.Fatalf("TempFile(): %v", err)
+		}
+		defer os.Remove(f.Name())
+		if _, err := f.WriteString(test.config); err != nil {
+			t.Fatalf("WriteString(): %v", err)
+		}
+		f.Close()
+
+		cfg, err := ProxyConfigFromFile(f.Name())
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("%s: ProxyConfigFromFile()=%v, want error %v", test.desc, err, test.wantErr)
+		}
+		if err != nil {
+			continue
+		}
+		if got, want := cfg.Policy.MinSCTs, 2; got != want {
+			t.Errorf("%s: MinSCTs=%d, want %d", test.desc, got, want)
+		}
+		for i, want := range []time.Duration{5 * time.Second, DefaultTimeout} {
+			if got, err := cfg.Logs[i].TimeoutDuration(); err != nil || got != want {
+				t.Errorf("%s: Logs[%d].TimeoutDuration()=%v, %v; want %v", test.desc, i, got, err, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,608 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian"
+	"github.com/google/trillian/merkle"
+	"google.golang.org/grpc/codes"
+)
+
+// How many log roots and integrated leaves the log hammer holds on to.
+const (
+	rootCount    = 10
+	knownCount   = 100
+	pendingCount = 100
+)
+
+// LogOp names an operation performed by the log hammer, which is the
+// TrillianLog RPC it exercises.
+type LogOp string
+
+// Operations performed by the log hammer.
+const (
+	QueueLeavesOp             = LogOp("QueueLeaves")
+	GetLatestSignedLogRootOp  = LogOp("GetLatestSignedLogRoot")
+	GetSequencedLeafCountOp   = LogOp("GetSequencedLeafCount")
+	GetLeavesByHashOp         = LogOp("GetLeavesByHash")
+	GetLeavesByIndexOp        = LogOp("GetLeavesByIndex")
+	GetInclusionProofOp       = LogOp("GetInclusionProof")
+	GetInclusionProofByHashOp = LogOp("GetInclusionProofByHash")
+	GetConsistencyProofOp     = LogOp("GetConsistencyProof")
+	GetEntryAndProofOp        = LogOp("GetEntryAndProof")
+)
+
+// LogOps holds all of the operations performed by the log hammer.
+var LogOps = []LogOp{
+	QueueLeavesOp,
+	GetLatestSignedLogRootOp,
+	GetSequencedLeafCountOp,
+	GetLeavesByHashOp,
+	GetLeavesByIndexOp,
+	GetInclusionProofOp,
+	GetInclusionProofByHashOp,
+	GetConsistencyProofOp,
+	GetEntryAndProofOp,
+}
+
+// LogHammerBias indicates the bias for selecting different log operations.
+type LogHammerBias struct {
+	Bias map[LogOp]int
+}
+
+// Choose randomly picks an operation to perform according to the biases.
+func (hb LogHammerBias) Choose() LogOp {
+	total := 0
+	for _, op := range LogOps {
+		total += hb.Bias[op]
+	}
+	which := rand.Intn(total)
+	for _, op := range LogOps {
+		which -= hb.Bias[op]
+		if which < 0 {
+			return op
+		}
+	}
+	panic("random choice out of range")
+}
+
+// LogHammerConfig provides configuration for a stress/load test of a log
+// through the TrillianLog API.
+type LogHammerConfig struct {
+	LogID  int64
+	Client trillian.TrillianLogClient
+	// Hasher used by the log, to check leaf hashes and proofs.
+	Hasher merkle.TreeHasher
+	// Bias values to favor particular log operations.
+	Bias LogHammerBias
+	// Number of operations to perform.
+	Operations uint64
+	// How long to run for, or zero to only stop after Operations.
+	Duration time.Duration
+	// Number of leaves queued or read by each request.
+	BatchSize int
+	// Maximum merge delay; leaves which have not been sequenced this long
+	// after being queued fail the test.
+	MMD time.Duration
+	// Deadline for each RPC.
+	RPCTimeout time.Duration
+}
+
+// OpStats holds the outcomes of one kind of log hammer operation.
+type OpStats struct {
+	OK      int
+	Skipped int
+	// Errors counts failed RPCs. Responses which fail verification stop
+	// the test instead.
+	Errors    int
+	Latencies []time.Duration
+}
+
+// LogHammerReport summarises a run of the log hammer.
+type LogHammerReport struct {
+	LogID    int64
+	Elapsed  time.Duration
+	TreeSize int64
+	Ops      map[LogOp]*OpStats
+	// MergeDelays holds the time from queueing a leaf to finding it in the
+	// log, for the leaves which were tracked.
+	MergeDelays []time.Duration
+}
+
+// String returns the report as a table, with latency percentiles.
+func (r *LogHammerReport) String() string {
+	var b bytes.Buffer
+	secs := r.Elapsed.Seconds()
+	fmt.Fprintf(&b, "Log %d: tree size %d after %v\n", r.LogID, r.TreeSize, r.Elapsed)
+	fmt.Fprintf(&b, "%-24s %8s %8s %8s %8s %12s %12s %12s\n", "operation", "ok", "skipped", "errors", "ops/s", "p50", "p90", "p99")
+	for _, op := range LogOps {
+		s := r.Ops[op]
+		if s == nil {
+			continue
+		}
+		p := percentiles(s.Latencies)
+		fmt.Fprintf(&b, "%-24s %8d %8d %8d %8.1f %12v %12v %12v\n", op, s.OK, s.Skipped, s.Errors, float64(s.OK)/secs, p[0], p[1], p[2])
+	}
+	p := percentiles(r.MergeDelays)
+	fmt.Fprintf(&b, "merge delay of %d leaves: p50 %v p90 %v p99 %v\n", len(r.MergeDelays), p[0], p[1], p[2])
+	return b.String()
+}
+
+// percentiles returns the 50th, 90th and 99th percentiles of durations.
+func percentiles(durations []time.Duration) [3]time.Duration {
+	var p [3]time.Duration
+	if len(durations) == 0 {
+		return p
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	for i, pc := range []int{50, 90, 99} {
+		p[i] = sorted[(len(sorted)*pc-1)/100]
+	}
+	return p
+}
+
+// errVerify indicates that a response from the log failed verification.
+type errVerify struct {
+	err error
+}
+
+func (e errVerify) Error() string {
+	return e.err.Error()
+}
+
+func verifyErrorf(format string, args ...interface{}) error {
+	return errVerify{fmt.Errorf(format, args...)}
+}
+
+// pendingLeaf is a leaf which has been queued but not yet found in the log.
+type pendingLeaf struct {
+	leafHash []byte
+	queued   time.Time
+}
+
+// knownLeaf is a leaf which has been found in the log.
+type knownLeaf struct {
+	index    int64
+	leafHash []byte
+}
+
+// logHammerState tracks the operations that have been performed on a log,
+// including earlier roots and queued leaves for later checking.
+type logHammerState struct {
+	cfg      *LogHammerConfig
+	verifier merkle.LogVerifier
+	report   *LogHammerReport
+	// Roots are arranged from later to earlier, so [0] is the most recent.
+	roots [rootCount]*trillian.SignedLogRoot
+	// Pending leaves are in the order they were queued.
+	pending []pendingLeaf
+	known   []knownLeaf
+	// Number of leaves queued so far, used to make leaf values unique.
+	queued int64
+	nonce  int64
+}
+
+func (s *logHammerState) rpcContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, s.cfg.RPCTimeout)
+}
+
+func (s *logHammerState) latestSize() int64 {
+	if s.roots[0] == nil {
+		return 0
+	}
+	return s.roots[0].TreeSize
+}
+
+func (s *logHammerState) addKnown(leaf knownLeaf) {
+	if len(s.known) < knownCount {
+		s.known = append(s.known, leaf)
+		return
+	}
+	s.known[rand.Intn(knownCount)] = leaf
+}
+
+// pickKnown returns a random known leaf which is within the latest root.
+func (s *logHammerState) pickKnown() (knownLeaf, error) {
+	var candidates []knownLeaf
+	for _, l := range s.known {
+		if l.index < s.latestSize() {
+			candidates = append(candidates, l)
+		}
+	}
+	if len(candidates) == 0 {
+		return knownLeaf{}, errSkip{}
+	}
+	return candidates[rand.Intn(len(candidates))], nil
+}
+
+// checkLeaf checks that a leaf returned by the log is self-consistent.
+func (s *logHammerState) checkLeaf(leaf *trillian.LogLeaf) error {
+	if leaf == nil {
+		return verifyErrorf("log returned no leaf")
+	}
+	if got, want := leaf.MerkleLeafHash, s.cfg.Hasher.HashLeaf(leaf.LeafValue); !bytes.Equal(got, want) {
+		return verifyErrorf("leaf %d has Merkle leaf hash %x, want %x", leaf.LeafIndex, got, want)
+	}
+	return nil
+}
+
+func (s *logHammerState) queueLeaves(ctx context.Context) error {
+	leaves := make([]*trillian.LogLeaf, 0, s.cfg.BatchSize)
+	for i := 0; i < s.cfg.BatchSize; i++ {
+		data := []byte(fmt.Sprintf("hammer leaf %x-%d", s.nonce, s.queued))
+		s.queued++
+		idHash := sha256.Sum256(data)
+		leaves = append(leaves, &trillian.LogLeaf{
+			LeafIdentityHash: idHash[:],
+			MerkleLeafHash:   s.cfg.Hasher.HashLeaf(data),
+			LeafValue:        data,
+		})
+	}
+	rctx, cancel := s.rpcContext(ctx)
+	defer cancel()
+	queued := time.Now()
+	rsp, err := s.cfg.Client.QueueLeaves(rctx, &trillian.QueueLeavesRequest{LogId: s.cfg.LogID, Leaves: leaves})
+	if err != nil {
+		return err
+	}
+	if got, want := len(rsp.QueuedLeaves), len(leaves); got != want {
+		return verifyErrorf("QueueLeaves() returned %d leaves, want %d", got, want)
+	}
+	for i, ql := range rsp.QueuedLeaves {
+		if c := codes.Code(ql.GetStatus().GetCode()); c != codes.OK {
+			return verifyErrorf("QueueLeaves() returned status %v for new leaf %d", c, i)
+		}
+		if !bytes.Equal(ql.GetLeaf().GetMerkleLeafHash(), leaves[i].MerkleLeafHash) {
+			return verifyErrorf("QueueLeaves() returned leaf %d with hash %x, want %x", i, ql.GetLeaf().GetMerkleLeafHash(), leaves[i].MerkleLeafHash)
+		}
+	}
+	if len(s.pending) < pendingCount {
+		// Only one leaf per batch is tracked, which is enough to follow the
+		// merge delay.
+		s.pending = append(s.pending, pendingLeaf{leafHash: leaves[0].MerkleLeafHash, queued: queued})
+	}
+	glog.V(2).Infof("%d: Queued %d leaves", s.cfg.LogID, len(leaves))
+	return nil
+}
+
+func (s *logHammerState) getLatestSignedLogRoot(ctx context.Context) error {
+	rctx, cancel := s.rpcContext(ctx)
+	defer cancel()
+	rsp, err := s.cfg.Client.GetLatestSignedLogRoot(rctx, &trillian.GetLatestSignedLogRootRequest{LogId: s.cfg.LogID})
+	if err != nil {
+		return err
+	}
+	root := rsp.SignedLogRoot
+	if root == nil {
+		return verifyErrorf("GetLatestSignedLogRoot() returned no root")
+	}
+	if prev := s.roots[0]; prev != nil {
+		if root.TreeSize < prev.TreeSize {
+			return verifyErrorf("tree size went backwards from %d to %d", prev.TreeSize, root.TreeSize)
+		}
+		if root.TreeSize == prev.TreeSize && !bytes.Equal(root.RootHash, prev.RootHash) {
+			return verifyErrorf("root hash for tree size %d changed from %x to %x", root.TreeSize, prev.RootHash, root.RootHash)
+		}
+		if root.TreeSize == prev.TreeSize {
+			// Nothing new to hold on to.
+			return nil
+		}
+	}
+	// Shuffle earlier roots along.
+	for i := rootCount - 1; i > 0; i-- {
+		s.roots[i] = s.roots[i-1]
+	}
+	s.roots[0] = root
+	glog.V(2).Infof("%d: Got root of tree size %d", s.cfg.LogID, root.TreeSize)
+	return nil
+}
+
+func (s *logHammerState) getSequencedLeafCount(ctx context.Context) error {
+	rctx, cancel := s.rpcContext(ctx)
+	defer cancel()
+	rsp, err := s.cfg.Client.GetSequencedLeafCount(rctx, &trillian.GetSequencedLeafCountRequest{LogId: s.cfg.LogID})
+	if err != nil {
+		return err
+	}
+	if rsp.LeafCount < s.latestSize() {
+		return verifyErrorf("GetSequencedLeafCount()=%d, but have seen a root of size %d", rsp.LeafCount, s.latestSize())
+	}
+	return nil
+}
+
+// getLeavesByHash looks for the oldest queued leaves in the log, which gives
+// the merge delay of those that are found.
+func (s *logHammerState) getLeavesByHash(ctx context.Context) error {
+	if len(s.pending) == 0 {
+		return errSkip{}
+	}
+	n := len(s.pending)
+	if n > s.cfg.BatchSize {
+		n = s.cfg.BatchSize
+	}
+	req := &trillian.GetLeavesByHashRequest{LogId: s.cfg.LogID}
+	for _, p := range s.pending[:n] {
+		req.LeafHash = append(req.LeafHash, p.leafHash)
+	}
+	rctx, cancel := s.rpcContext(ctx)
+	defer cancel()
+	rsp, err := s.cfg.Client.GetLeavesByHash(rctx, req)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	found := make(map[string]*trillian.LogLeaf)
+	for _, leaf := range rsp.Leaves {
+		if err := s.checkLeaf(leaf); err != nil {
+			return err
+		}
+		found[string(leaf.MerkleLeafHash)] = leaf
+	}
+	var stillPending []pendingLeaf
+	for _, p := range s.pending[:n] {
+		leaf, ok := found[string(p.leafHash)]
+		if !ok {
+			if s.cfg.MMD > 0 && now.Sub(p.queued) > s.cfg.MMD {
+				return verifyErrorf("leaf with hash %x not sequenced %v after being queued", p.leafHash, now.Sub(p.queued))
+			}
+			stillPending = append(stillPending, p)
+			continue
+		}
+		s.report.MergeDelays = append(s.report.MergeDelays, now.Sub(p.queued))
+		s.addKnown(knownLeaf{index: leaf.LeafIndex, leafHash: p.leafHash})
+	}
+	s.pending = append(stillPending, s.pending[n:]...)
+	return nil
+}
+
+func (s *logHammerState) getLeavesByIndex(ctx context.Context) error {
+	size := s.latestSize()
+	if size == 0 {
+		return errSkip{}
+	}
+	req := &trillian.GetLeavesByIndexRequest{LogId: s.cfg.LogID}
+	for i := 0; i < s.cfg.BatchSize; i++ {
+		req.LeafIndex = append(req.LeafIndex, rand.Int63n(size))
+	}
+	rctx, cancel := s.rpcContext(ctx)
+	defer cancel()
+	rsp, err := s.cfg.Client.GetLeavesByIndex(rctx, req)
+	if err != nil {
+		return err
+	}
+	wanted := make(map[int64]bool)
+	for _, idx := range req.LeafIndex {
+		wanted[idx] = true
+	}
+	for _, leaf := range rsp.Leaves {
+		if err := s.checkLeaf(leaf); err != nil {
+			return err
+		}
+		if !wanted[leaf.LeafIndex] {
+			return verifyErrorf("GetLeavesByIndex(%v) returned leaf %d", req.LeafIndex, leaf.LeafIndex)
+		}
+		s.addKnown(knownLeaf{index: leaf.LeafIndex, leafHash: leaf.MerkleLeafHash})
+	}
+	return nil
+}
+
+func (s *logHammerState) getInclusionProof(ctx context.Context) error {
+	leaf, err := s.pickKnown()
+	if err != nil {
+		return err
+	}
+	root := s.roots[0]
+	rctx, cancel := s.rpcContext(ctx)
+	defer cancel()
+	rsp, err := s.cfg.Client.GetInclusionProof(rctx, &trillian.GetInclusionProofRequest{
+		LogId:     s.cfg.LogID,
+		LeafIndex: leaf.index,
+		TreeSize:  root.TreeSize,
+	})
+	if err != nil {
+		return err
+	}
+	return s.verifyInclusion(leaf, rsp.Proof, root)
+}
+
+func (s *logHammerState) getInclusionProofByHash(ctx context.Context) error {
+	leaf, err := s.pickKnown()
+	if err != nil {
+		return err
+	}
+	root := s.roots[0]
+	rctx, cancel := s.rpcContext(ctx)
+	defer cancel()
+	rsp, err := s.cfg.Client.GetInclusionProofByHash(rctx, &trillian.GetInclusionProofByHashRequest{
+		LogId:    s.cfg.LogID,
+		LeafHash: leaf.leafHash,
+		TreeSize: root.TreeSize,
+	})
+	if err != nil {
+		return err
+	}
+	// Leaf values are unique, so there should be just the one proof.
+	if got := len(rsp.Proof); got != 1 {
+		return verifyErrorf("GetInclusionProofByHash(%x) returned %d proofs, want 1", leaf.leafHash, got)
+	}
+	return s.verifyInclusion(leaf, rsp.Proof[0], root)
+}
+
+func (s *logHammerState) getEntryAndProof(ctx context.Context) error {
+	root := s.roots[0]
+	if root == nil || root.TreeSize == 0 {
+		return errSkip{}
+	}
+	index := rand.Int63n(root.TreeSize)
+	rctx, cancel := s.rpcContext(ctx)
+	defer cancel()
+	rsp, err := s.cfg.Client.GetEntryAndProof(rctx, &trillian.GetEntryAndProofRequest{
+		LogId:     s.cfg.LogID,
+		LeafIndex: index,
+		TreeSize:  root.TreeSize,
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.checkLeaf(rsp.Leaf); err != nil {
+		return err
+	}
+	return s.verifyInclusion(knownLeaf{index: index, leafHash: rsp.Leaf.MerkleLeafHash}, rsp.Proof, root)
+}
+
+// verifyInclusion checks that proof shows leaf is included in root.
+func (s *logHammerState) verifyInclusion(leaf knownLeaf, proof *trillian.Proof, root *trillian.SignedLogRoot) error {
+	if got := proof.GetLeafIndex(); got != leaf.index {
+		return verifyErrorf("got inclusion proof for leaf %d, want %d", got, leaf.index)
+	}
+	if err := s.verifier.VerifyInclusionProof(leaf.index, root.TreeSize, proofHashes(proof), root.RootHash, leaf.leafHash); err != nil {
+		return verifyErrorf("inclusion proof for leaf %d at tree size %d failed: %v", leaf.index, root.TreeSize, err)
+	}
+	return nil
+}
+
+func (s *logHammerState) getConsistencyProof(ctx context.Context) error {
+	latest := s.roots[0]
+	which := 1 + rand.Intn(rootCount-1)
+	earlier := s.roots[which]
+	if earlier == nil || earlier.TreeSize == 0 {
+		glog.V(3).Infof("%d: skipping GetConsistencyProof as no earlier root", s.cfg.LogID)
+		return errSkip{}
+	}
+	rctx, cancel := s.rpcContext(ctx)
+	defer cancel()
+	rsp, err := s.cfg.Client.GetConsistencyProof(rctx, &trillian.GetConsistencyProofRequest{
+		LogId:          s.cfg.LogID,
+		FirstTreeSize:  earlier.TreeSize,
+		SecondTreeSize: latest.TreeSize,
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.verifier.VerifyConsistencyProof(earlier.TreeSize, latest.TreeSize, earlier.RootHash, latest.RootHash, proofHashes(rsp.Proof)); err != nil {
+		return verifyErrorf("consistency proof from tree size %d to %d failed: %v", earlier.TreeSize, latest.TreeSize, err)
+	}
+	return nil
+}
+
+func proofHashes(proof *trillian.Proof) [][]byte {
+	hashes := make([][]byte, 0, len(proof.GetProofNode()))
+	for _, node := range proof.GetProofNode() {
+		hashes = append(hashes, node.NodeHash)
+	}
+	return hashes
+}
+
+func (s *logHammerState) perform(ctx context.Context, op LogOp) error {
+	switch op {
+	case QueueLeavesOp:
+		return s.queueLeaves(ctx)
+	case GetLatestSignedLogRootOp:
+		return s.getLatestSignedLogRoot(ctx)
+	case GetSequencedLeafCountOp:
+		return s.getSequencedLeafCount(ctx)
+	case GetLeavesByHashOp:
+		return s.getLeavesByHash(ctx)
+	case GetLeavesByIndexOp:
+		return s.getLeavesByIndex(ctx)
+	case GetInclusionProofOp:
+		return s.getInclusionProof(ctx)
+	case GetInclusionProofByHashOp:
+		return s.getInclusionProofByHash(ctx)
+	case GetConsistencyProofOp:
+		return s.getConsistencyProof(ctx)
+	case GetEntryAndProofOp:
+		return s.getEntryAndProof(ctx)
+	}
+	return fmt.Errorf("internal error: unknown operation %s selected", op)
+}
+
+// HammerLog performs load/stress operations on a log according to the given
+// config, checking every response. It stops when a response fails
+// verification, or when the configured number of operations or duration is
+// reached, and returns a report of what was done.
+func HammerLog(ctx context.Context, cfg LogHammerConfig) (*LogHammerReport, error) {
+	s := &logHammerState{
+		cfg:      &cfg,
+		verifier: merkle.NewLogVerifier(cfg.Hasher),
+		report:   &LogHammerReport{LogID: cfg.LogID, Ops: make(map[LogOp]*OpStats)},
+		nonce:    rand.Int63(),
+	}
+	for _, op := range LogOps {
+		s.report.Ops[op] = &OpStats{}
+	}
+	if cfg.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Duration)
+		defer cancel()
+	}
+
+	start := time.Now()
+	defer func() {
+		s.report.Elapsed = time.Since(start)
+		s.report.TreeSize = s.latestSize()
+	}()
+	for count := uint64(1); count <= cfg.Operations && ctx.Err() == nil; count++ {
+		op := cfg.Bias.Choose()
+		glog.V(3).Infof("%d: perform %s operation", cfg.LogID, op)
+		stats := s.report.Ops[op]
+		opStart := time.Now()
+		err := s.perform(ctx, op)
+		switch err.(type) {
+		case nil:
+			stats.OK++
+			stats.Latencies = append(stats.Latencies, time.Since(opStart))
+		case errSkip:
+			stats.Skipped++
+		case errVerify:
+			return s.report, fmt.Errorf("%d: %s: %v", cfg.LogID, op, err)
+		default:
+			// Requests cut short by the end of the run aren't counted.
+			if ctx.Err() == nil {
+				stats.Errors++
+				glog.Warningf("%d: %s failed: %v", cfg.LogID, op, err)
+			}
+		}
+
+		if count%emitInterval == 0 {
+			s.emit(os.Stdout, count)
+		}
+	}
+	return s.report, nil
+}
+
+// emit prints a line of progress.
+func (s *logHammerState) emit(w io.Writer, count uint64) {
+	fmt.Fprintf(w, "%10d: last-root.size=%d operations: total=%d", s.cfg.LogID, s.latestSize(), count)
+	for _, op := range LogOps {
+		if s.cfg.Bias.Bias[op] > 0 {
+			st := s.report.Ops[op]
+			fmt.Fprintf(w, " %s=%d/%d", op, st.OK, st.OK+st.Skipped+st.Errors)
+		}
+	}
+	fmt.Fprintf(w, " pending=%d\n", len(s.pending))
+}
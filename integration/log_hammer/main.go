@@ -0,0 +1,140 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// log_hammer is a stress/load test for Trillian logs, which uses the
+// TrillianLog gRPC API directly.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian"
+	"github.com/google/trillian/integration"
+	"github.com/google/trillian/merkle"
+	"google.golang.org/grpc"
+)
+
+var (
+	serverFlag     = flag.String("log_rpc_server", "localhost:8090", "Address of the gRPC Trillian Log Server (host:port)")
+	logIDsFlag     = flag.String("log_ids", "", "Comma-separated list of IDs of the logs to hammer")
+	seed           = flag.Int64("seed", -1, "Seed for random number generation")
+	operationsFlag = flag.Uint64("operations", ^uint64(0), "Number of operations to perform on each log")
+	durationFlag   = flag.Duration("duration", 0, "How long to run for, or zero to run until --operations are done")
+	batchSizeFlag  = flag.Int("batch_size", 10, "Number of leaves queued or read by each request")
+	mmdFlag        = flag.Duration("mmd", 2*time.Minute, "MMD for logs")
+	rpcDeadline    = flag.Duration("rpc_deadline", 10*time.Second, "Deadline to use for all RPC requests")
+)
+var (
+	queueLeavesBias             = flag.Int("queue_leaves", 20, "Bias for QueueLeaves operations")
+	getLatestSignedLogRootBias  = flag.Int("get_latest_signed_log_root", 5, "Bias for GetLatestSignedLogRoot operations")
+	getSequencedLeafCountBias   = flag.Int("get_sequenced_leaf_count", 1, "Bias for GetSequencedLeafCount operations")
+	getLeavesByHashBias         = flag.Int("get_leaves_by_hash", 5, "Bias for GetLeavesByHash operations")
+	getLeavesByIndexBias        = flag.Int("get_leaves_by_index", 5, "Bias for GetLeavesByIndex operations")
+	getInclusionProofBias       = flag.Int("get_inclusion_proof", 5, "Bias for GetInclusionProof operations")
+	getInclusionProofByHashBias = flag.Int("get_inclusion_proof_by_hash", 5, "Bias for GetInclusionProofByHash operations")
+	getConsistencyProofBias     = flag.Int("get_consistency_proof", 5, "Bias for GetConsistencyProof operations")
+	getEntryAndProofBias        = flag.Int("get_entry_and_proof", 5, "Bias for GetEntryAndProof operations")
+)
+
+func main() {
+	flag.Parse()
+	if *logIDsFlag == "" {
+		glog.Exit("Test aborted as no logs provided (via --log_ids)")
+	}
+	var logIDs []int64
+	for _, s := range strings.Split(*logIDsFlag, ",") {
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			glog.Exitf("Invalid log ID %q: %v", s, err)
+		}
+		logIDs = append(logIDs, id)
+	}
+	if *seed == -1 {
+		*seed = time.Now().UTC().UnixNano() & 0xFFFFFFFF
+	}
+	fmt.Printf("Today's test has been brought to you by the letters L and T and the number %#x\n", *seed)
+	rand.Seed(*seed)
+
+	th, err := merkle.Factory(merkle.RFC6962SHA256Type)
+	if err != nil {
+		glog.Exitf("Failed to create hasher: %v", err)
+	}
+	conn, err := grpc.Dial(*serverFlag, grpc.WithInsecure())
+	if err != nil {
+		glog.Exitf("Failed to connect to log server: %v", err)
+	}
+	defer conn.Close()
+	client := trillian.NewTrillianLogClient(conn)
+
+	bias := integration.LogHammerBias{Bias: map[integration.LogOp]int{
+		integration.QueueLeavesOp:             *queueLeavesBias,
+		integration.GetLatestSignedLogRootOp:  *getLatestSignedLogRootBias,
+		integration.GetSequencedLeafCountOp:   *getSequencedLeafCountBias,
+		integration.GetLeavesByHashOp:         *getLeavesByHashBias,
+		integration.GetLeavesByIndexOp:        *getLeavesByIndexBias,
+		integration.GetInclusionProofOp:       *getInclusionProofBias,
+		integration.GetInclusionProofByHashOp: *getInclusionProofByHashBias,
+		integration.GetConsistencyProofOp:     *getConsistencyProofBias,
+		integration.GetEntryAndProofOp:        *getEntryAndProofBias,
+	}}
+
+	type result struct {
+		logID  int64
+		report *integration.LogHammerReport
+		err    error
+	}
+	results := make(chan result, len(logIDs))
+	var wg sync.WaitGroup
+	for _, logID := range logIDs {
+		wg.Add(1)
+		cfg := integration.LogHammerConfig{
+			LogID:      logID,
+			Client:     client,
+			Hasher:     th,
+			Bias:       bias,
+			Operations: *operationsFlag,
+			Duration:   *durationFlag,
+			BatchSize:  *batchSizeFlag,
+			MMD:        *mmdFlag,
+			RPCTimeout: *rpcDeadline,
+		}
+		go func(cfg integration.LogHammerConfig) {
+			defer wg.Done()
+			report, err := integration.HammerLog(context.Background(), cfg)
+			results <- result{logID: cfg.LogID, report: report, err: err}
+		}(cfg)
+	}
+	wg.Wait()
+	close(results)
+	errCount := 0
+	for r := range results {
+		fmt.Printf("\n%s", r.report)
+		if r.err != nil {
+			errCount++
+			glog.Errorf("%d: %v", r.logID, r.err)
+		}
+	}
+	if errCount > 0 {
+		os.Exit(1)
+	}
+}
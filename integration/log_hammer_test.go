@@ -0,0 +1,82 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/testonly"
+	"github.com/google/trillian/testonly/integration"
+)
+
+func TestLogHammerBiasChoose(t *testing.T) {
+	bias := LogHammerBias{Bias: map[LogOp]int{QueueLeavesOp: 1, GetEntryAndProofOp: 1}}
+	for i := 0; i < 100; i++ {
+		if op := bias.Choose(); op != QueueLeavesOp && op != GetEntryAndProofOp {
+			t.Fatalf("Choose()=%s, want an operation with non-zero bias", op)
+		}
+	}
+}
+
+func TestPercentiles(t *testing.T) {
+	var durations []time.Duration
+	for i := 100; i > 0; i-- {
+		durations = append(durations, time.Duration(i))
+	}
+	if got, want := percentiles(durations), [3]time.Duration{50, 90, 99}; got != want {
+		t.Errorf("percentiles()=%v, want %v", got, want)
+	}
+	if got, want := percentiles(nil), [3]time.Duration{}; got != want {
+		t.Errorf("percentiles(nil)=%v, want %v", got, want)
+	}
+}
+
+func TestInProcessLogHammer(t *testing.T) {
+	ctx := context.Background()
+	env, err := integration.NewLogEnv(ctx, 1, "TestInProcessLogHammer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.Close()
+
+	logID, err := env.CreateLog()
+	if err != nil {
+		t.Fatalf("Failed to create log: %v", err)
+	}
+
+	bias := LogHammerBias{Bias: make(map[LogOp]int)}
+	for _, op := range LogOps {
+		bias.Bias[op] = 1
+	}
+	report, err := HammerLog(ctx, LogHammerConfig{
+		LogID:      logID,
+		Client:     trillian.NewTrillianLogClient(env.ClientConn),
+		Hasher:     testonly.Hasher,
+		Bias:       bias,
+		Operations: 500,
+		BatchSize:  5,
+		MMD:        time.Minute,
+		RPCTimeout: 10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("HammerLog(): %v\n%s", err, report)
+	}
+	if got := report.Ops[QueueLeavesOp].OK; got == 0 {
+		t.Errorf("HammerLog() queued no leaves:\n%s", report)
+	}
+}
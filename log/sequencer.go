@@ -18,6 +18,7 @@ package log
 
 import (
 	"context"
+	"expvar"
 	"fmt"
 	"time"
 
@@ -25,7 +26,9 @@ import (
 	"github.com/google/trillian"
 	"github.com/google/trillian/crypto"
 	"github.com/google/trillian/crypto/sigpb"
+	te "github.com/google/trillian/errors"
 	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/monitoring/metric"
 	"github.com/google/trillian/storage"
 	"github.com/google/trillian/util"
 )
@@ -43,10 +46,47 @@ type Sequencer struct {
 	logStorage storage.LogStorage
 	signer     *crypto.Signer
 
+	// cosigners are additional signers for log roots, of which enough must
+	// succeed to give at least minSignatures signatures including the signer's.
+	cosigners     []*crypto.Signer
+	minSignatures int
+
 	// These parameters could theoretically be adjusted during operation
 	// sequencerGuardWindow is used to ensure entries newer than the guard window will not be
 	// sequenced until they fall outside it. By default there is no guard window.
 	sequencerGuardWindow time.Duration
+	// maxClockSkew is how far the clock may be behind the log's timestamps
+	// before the sequencer refuses to sign new roots. Zero means no limit.
+	maxClockSkew time.Duration
+	// minLaneShare is the fraction of each batch reserved for leaves from each
+	// priority lane below the highest.
+	minLaneShare float64
+}
+
+var (
+	adjustedRootTimestamps = metric.NewCounter("sequencer_adjusted_root_timestamps")
+	clockSkewRefusals      = metric.NewCounter("sequencer_clock_skew_refusals")
+	deadLetteredLeaves     = metric.NewCounter("sequencer_dead_lettered_leaves")
+
+	// Per-lane dequeue stats, keyed by priority class. The mean queue age of a lane's
+	// leaves is its total queue age divided by its dequeued leaves.
+	laneDequeuedLeaves = expvar.NewMap("sequencer-lane-dequeued-leaves")
+	laneQueueAgeMillis = expvar.NewMap("sequencer-lane-total-queue-age-ms")
+	laneMaxQueueAge    = expvar.NewMap("sequencer-lane-max-queue-age-ms")
+	// laneMaxQueueAges holds the entries of laneMaxQueueAge, which are set in place
+	// rather than replaced on every dequeue.
+	laneMaxQueueAges = newLaneInts(laneMaxQueueAge)
+)
+
+// newLaneInts adds an expvar.Int to m for each priority lane, and returns them.
+func newLaneInts(m *expvar.Map) map[trillian.LeafPriority]*expvar.Int {
+	ints := make(map[trillian.LeafPriority]*expvar.Int)
+	for _, priority := range storage.PriorityLanes {
+		v := new(expvar.Int)
+		m.Set(priority.String(), v)
+		ints[priority] = v
+	}
+	return ints
 }
 
 // maxTreeDepth sets an upper limit on the size of Log trees.
@@ -71,6 +111,33 @@ func (s *Sequencer) SetGuardWindow(sequencerGuardWindow time.Duration) {
 	s.sequencerGuardWindow = sequencerGuardWindow
 }
 
+// SetMaxClockSkew sets how far behind the timestamps of the previous root or of
+// the leaves still queued the clock may be. Root timestamps always increase, so
+// while the clock is behind the previous root the timestamps of new roots are
+// moved forward. Leaves queued by frontends whose clocks are ahead aren't
+// dequeued until the clock passes their queue timestamps. Beyond maxClockSkew
+// the clock can't be trusted and no roots are signed.
+// The default of zero means there is no limit.
+func (s *Sequencer) SetMaxClockSkew(maxClockSkew time.Duration) {
+	s.maxClockSkew = maxClockSkew
+}
+
+// SetMinLaneShare reserves a fraction of each batch for leaves from each priority lane
+// below the highest, so that a busy higher priority lane can't starve them. The rest
+// of the batch is filled from the lanes in priority order. The default of zero means
+// lanes are strictly dequeued in priority order.
+func (s *Sequencer) SetMinLaneShare(minLaneShare float64) {
+	s.minLaneShare = minLaneShare
+}
+
+// SetCosigners configures additional signers for the log's roots. Each root is
+// then signed by the log's own signer and by the cosigners, and is only stored
+// if it gets at least minSignatures signatures in total.
+func (s *Sequencer) SetCosigners(cosigners []*crypto.Signer, minSignatures int) {
+	s.cosigners = cosigners
+	s.minSignatures = minSignatures
+}
+
 // TODO: This currently doesn't use the batch api for fetching the required nodes. This
 // would be more efficient but requires refactoring.
 func (s Sequencer) buildMerkleTreeFromStorageAtRoot(ctx context.Context, root trillian.SignedLogRoot, tx storage.TreeTX) (*merkle.CompactMerkleTree, error) {
@@ -153,7 +220,7 @@ func (s Sequencer) initMerkleTreeFromStorage(ctx context.Context, currentRoot tr
 }
 
 func (s Sequencer) createRootSignature(ctx context.Context, root trillian.SignedLogRoot) (*sigpb.DigitallySigned, error) {
-	signature, err := s.signer.Sign(crypto.HashLogRoot(root))
+	signature, err := s.signer.SignLogRoot(root)
 	if err != nil {
 		glog.Warningf("%s: signer failed to sign root: %v", util.LogIDPrefix(ctx), err)
 		return nil, err
@@ -162,52 +229,143 @@ func (s Sequencer) createRootSignature(ctx context.Context, root trillian.Signed
 	return signature, nil
 }
 
-// SequenceBatch wraps up all the operations needed to take a batch of queued leaves
-// and integrate them into the tree.
-// TODO(Martin2112): Can possibly improve by deferring a function that attempts to rollback,
-// which will fail if the tx was committed. Should only do this if we can hide the details of
-// the underlying storage transactions and it doesn't create other problems.
-func (s Sequencer) SequenceBatch(ctx context.Context, logID int64, limit int) (int, error) {
-	tx, err := s.logStorage.BeginForTree(ctx, logID)
-	if err != nil {
-		glog.Warningf("%v: Sequencer failed to start tx: %v", logID, err)
+// rootTimestamp returns the timestamp for a new root following currentRoot.
+// This is the current time unless that's not later than the previous root, in
+// which case it's adjusted to the earliest time that is, if within maxClockSkew.
+func (s Sequencer) rootTimestamp(ctx context.Context, currentRoot trillian.SignedLogRoot) (int64, error) {
+	now := s.timeSource.Now().UnixNano()
+	if now > currentRoot.TimestampNanos {
+		return now, nil
+	}
+
+	ts := currentRoot.TimestampNanos + 1
+	if err := s.checkClockSkew(ctx, time.Duration(ts-now), "previous root"); err != nil {
 		return 0, err
 	}
-	defer tx.Close()
+	adjustedRootTimestamps.Add(1)
+	glog.Warningf("%s: clock is %v behind the previous root, adjusting root timestamp", util.LogIDPrefix(ctx), time.Duration(ts-now))
+	return ts, nil
+}
 
-	// Very recent leaves inside the guard window will not be available for sequencing
-	guardCutoffTime := s.timeSource.Now().Add(-s.sequencerGuardWindow)
-	leaves, err := tx.DequeueLeaves(limit, guardCutoffTime)
+// checkQueueSkew returns an error if the clock is more than maxClockSkew behind
+// the queue timestamp of a leaf still queued for the log. Queue timestamps are
+// set by the frontends' clocks, and leaves aren't dequeued until the clock
+// passes them, so otherwise a clock which is far behind would hold up leaves
+// without any error.
+func (s Sequencer) checkQueueSkew(ctx context.Context, tx storage.LogTreeTX) error {
+	if s.maxClockSkew <= 0 {
+		return nil
+	}
+	newest, err := tx.NewestQueueTimestamp()
 	if err != nil {
-		glog.Warningf("%v: Sequencer failed to dequeue leaves: %v", logID, err)
-		return 0, err
+		return err
+	}
+	if newest.IsZero() {
+		return nil
 	}
+	return s.checkClockSkew(ctx, newest.Sub(s.timeSource.Now()), "newest queued leaf")
+}
 
-	// Get the latest known root from storage
-	currentRoot, err := tx.LatestSignedLogRoot()
+// checkClockSkew returns an error if skew, by which the clock is behind what,
+// exceeds maxClockSkew.
+func (s Sequencer) checkClockSkew(ctx context.Context, skew time.Duration, what string) error {
+	if s.maxClockSkew <= 0 || skew <= s.maxClockSkew {
+		return nil
+	}
+	clockSkewRefusals.Add(1)
+	glog.Errorf("%s: clock is %v behind the %s, more than the allowed %v: refusing to sign root", util.LogIDPrefix(ctx), skew, what, s.maxClockSkew)
+	return fmt.Errorf("clock skew of %v behind the %s exceeds maximum of %v", skew, what, s.maxClockSkew)
+}
+
+// cosignRoot returns the signatures of root by the log's signer and each of its
+// cosigners, where root.Signature already holds the log signer's signature.
+// Only one signature is kept per key, as clients count each key once.
+// Returns nil if the log doesn't need more than one signature.
+func (s Sequencer) cosignRoot(ctx context.Context, root trillian.SignedLogRoot) ([]*trillian.LogRootSignature, error) {
+	if len(s.cosigners) == 0 && s.minSignatures <= 1 {
+		return nil, nil
+	}
+	keyID, err := crypto.KeyID(s.signer.Public())
 	if err != nil {
-		glog.Warningf("%v: Sequencer failed to get latest root: %v", logID, err)
-		return 0, err
+		return nil, err
+	}
+	sigs := []*trillian.LogRootSignature{{KeyId: keyID, Signature: root.Signature}}
+	signed := map[string]bool{string(keyID): true}
+	for _, cosigner := range s.cosigners {
+		keyID, err := crypto.KeyID(cosigner.Public())
+		if err != nil {
+			return nil, err
+		}
+		if signed[string(keyID)] {
+			glog.Warningf("%s: skipping cosigner with duplicate key %x", util.LogIDPrefix(ctx), keyID)
+			continue
+		}
+		sig, err := cosigner.SignLogRoot(root)
+		if err != nil {
+			// The root may still get enough signatures from the others.
+			glog.Warningf("%s: cosigner %x failed to sign root: %v", util.LogIDPrefix(ctx), keyID, err)
+			continue
+		}
+		sigs = append(sigs, &trillian.LogRootSignature{KeyId: keyID, Signature: sig})
+		signed[string(keyID)] = true
+	}
+	if len(sigs) < s.minSignatures {
+		return nil, fmt.Errorf("got %d root signatures, need %d", len(sigs), s.minSignatures)
 	}
+	return sigs, nil
+}
 
-	// TODO(al): Have a better detection mechanism for there being no stored root.
-	// TODO(mhs): Might be better to create empty root in provisioning API when it exists
-	if currentRoot.RootHash == nil {
-		glog.Warningf("%v: Fresh log - no previous TreeHeads exist.", logID)
-		return 0, s.SignRoot(ctx, logID)
+// dequeueLeaves dequeues up to limit leaves. First, up to minLaneShare of limit leaves
+// are taken from each lane below the highest priority one, then the rest of the batch is
+// filled from the lanes in priority order.
+func (s Sequencer) dequeueLeaves(tx storage.LogTreeTX, limit int, cutoffTime time.Time) ([]*trillian.LogLeaf, error) {
+	var reserved []*trillian.LogLeaf
+	if share := int(s.minLaneShare * float64(limit)); share > 0 {
+		for _, priority := range storage.PriorityLanes[1:] {
+			n := share
+			if remaining := limit - len(reserved); n > remaining {
+				n = remaining
+			}
+			leaves, err := tx.DequeuePriorityLeaves(priority, n, cutoffTime)
+			if err != nil {
+				return nil, err
+			}
+			reserved = append(reserved, leaves...)
+		}
 	}
 
-	// There might be no work to be done. But we possibly still need to create an STH if the
-	// current one is too old. If there's work to be done then we'll be creating a root anyway.
-	if len(leaves) == 0 {
-		// We have nothing to integrate into the tree
-		glog.V(1).Infof("No leaves sequenced in this signing operation.")
-		return 0, tx.Commit()
+	leaves, err := tx.DequeueLeaves(limit-len(reserved), cutoffTime)
+	if err != nil {
+		return nil, err
+	}
+	leaves = append(leaves, reserved...)
+
+	now := s.timeSource.Now()
+	maxAges := make(map[trillian.LeafPriority]int64)
+	for _, leaf := range leaves {
+		key := leaf.QueuePriority.String()
+		age := now.Sub(time.Unix(0, leaf.QueueTimestampNanos)).Nanoseconds() / int64(time.Millisecond)
+		laneDequeuedLeaves.Add(key, 1)
+		laneQueueAgeMillis.Add(key, age)
+		if maxAge, ok := maxAges[leaf.QueuePriority]; !ok || age > maxAge {
+			maxAges[leaf.QueuePriority] = age
+		}
+	}
+	for priority, age := range maxAges {
+		if v, ok := laneMaxQueueAges[priority]; ok {
+			v.Set(age)
+		}
 	}
+	return leaves, nil
+}
 
+// integrateLeaves assigns sequence numbers to the leaves and writes them and the
+// updated Merkle nodes to storage, at the tree revision after currentRoot's. It returns
+// the updated Merkle tree and its revision.
+func (s Sequencer) integrateLeaves(ctx context.Context, logID int64, tx storage.LogTreeTX, currentRoot trillian.SignedLogRoot, leaves []*trillian.LogLeaf) (*merkle.CompactMerkleTree, int64, error) {
 	merkleTree, err := s.initMerkleTreeFromStorage(ctx, currentRoot, tx)
 	if err != nil {
-		return 0, err
+		return nil, 0, err
 	}
 
 	// We've done all the reads, can now do the updates.
@@ -216,24 +374,24 @@ func (s Sequencer) SequenceBatch(ctx context.Context, logID int64, limit int) (i
 	// number so it should not be possible for colliding updates to commit.
 	newVersion := tx.WriteRevision()
 	if got, want := newVersion, currentRoot.TreeRevision+int64(1); got != want {
-		return 0, fmt.Errorf("%v: got writeRevision of %v, but expected %v", logID, got, want)
+		return nil, 0, fmt.Errorf("%v: got writeRevision of %v, but expected %v", logID, got, want)
 	}
 
 	// Assign leaf sequence numbers and collate node updates
 	nodeMap, sequencedLeaves, err := s.sequenceLeaves(merkleTree, leaves)
 	if err != nil {
-		return 0, err
+		return nil, 0, err
 	}
 
 	// We should still have the same number of leaves
 	if want, got := len(leaves), len(sequencedLeaves); want != got {
-		return 0, fmt.Errorf("%v: wanted: %v leaves after sequencing but we got: %v", logID, want, got)
+		return nil, 0, fmt.Errorf("%v: wanted: %v leaves after sequencing but we got: %v", logID, want, got)
 	}
 
 	// Write the new sequence numbers to the leaves in the DB
 	if err := tx.UpdateSequencedLeaves(sequencedLeaves); err != nil {
 		glog.Warningf("%v: Sequencer failed to update sequenced leaves: %v", logID, err)
-		return 0, err
+		return nil, 0, err
 	}
 
 	// Build objects for the nodes to be updated. Because we deduped via the map each
@@ -243,21 +401,82 @@ func (s Sequencer) SequenceBatch(ctx context.Context, logID int64, limit int) (i
 	if err != nil {
 		// probably an internal error with map building, unexpected
 		glog.Warningf("%v: Failed to build target nodes in sequencer: %v", logID, err)
-		return 0, err
+		return nil, 0, err
 	}
 
 	// Now insert or update the nodes affected by the above, at the new tree version
 	if err := tx.SetMerkleNodes(targetNodes); err != nil {
 		glog.Warningf("%v: Sequencer failed to set Merkle nodes: %v", logID, err)
+		return nil, 0, err
+	}
+
+	return merkleTree, newVersion, nil
+}
+
+// SequenceBatch wraps up all the operations needed to take a batch of queued leaves
+// and integrate them into the tree.
+// TODO(Martin2112): Can possibly improve by deferring a function that attempts to rollback,
+// which will fail if the tx was committed. Should only do this if we can hide the details of
+// the underlying storage transactions and it doesn't create other problems.
+func (s Sequencer) SequenceBatch(ctx context.Context, logID int64, limit int) (int, error) {
+	tx, err := s.logStorage.BeginForTree(ctx, logID)
+	if err != nil {
+		glog.Warningf("%v: Sequencer failed to start tx: %v", logID, err)
+		return 0, err
+	}
+	defer tx.Close()
+
+	if err := s.checkQueueSkew(ctx, tx); err != nil {
+		glog.Warningf("%v: Sequencer refused to dequeue leaves: %v", logID, err)
+		return 0, err
+	}
+
+	// Very recent leaves inside the guard window will not be available for sequencing
+	guardCutoffTime := s.timeSource.Now().Add(-s.sequencerGuardWindow)
+	leaves, err := s.dequeueLeaves(tx, limit, guardCutoffTime)
+	if err != nil {
+		glog.Warningf("%v: Sequencer failed to dequeue leaves: %v", logID, err)
+		return 0, err
+	}
+
+	// Get the latest known root from storage
+	currentRoot, err := tx.LatestSignedLogRoot()
+	if err != nil {
+		glog.Warningf("%v: Sequencer failed to get latest root: %v", logID, err)
+		return 0, err
+	}
+
+	// TODO(al): Have a better detection mechanism for there being no stored root.
+	// CreateTree signs an initial root for new logs, but older logs may still
+	// lack one.
+	if currentRoot.RootHash == nil {
+		glog.Warningf("%v: Fresh log - no previous TreeHeads exist.", logID)
+		return 0, s.SignRoot(ctx, logID)
+	}
+
+	// There might be no work to be done. But we possibly still need to create an STH if the
+	// current one is too old. If there's work to be done then we'll be creating a root anyway.
+	if len(leaves) == 0 {
+		// We have nothing to integrate into the tree
+		glog.V(1).Infof("No leaves sequenced in this signing operation.")
+		return 0, tx.Commit()
+	}
+
+	merkleTree, newVersion, err := s.integrateLeaves(ctx, logID, tx, currentRoot, leaves)
+	if err != nil {
 		return 0, err
 	}
 
 	// Create the log root ready for signing
+	timestamp, err := s.rootTimestamp(ctx, currentRoot)
+	if err != nil {
+		return 0, err
+	}
 	newLogRoot := trillian.SignedLogRoot{
 		RootHash:       merkleTree.CurrentRoot(),
-		TimestampNanos: s.timeSource.Now().UnixNano(),
+		TimestampNanos: timestamp,
 		TreeSize:       merkleTree.Size(),
-		LogId:          currentRoot.LogId,
+		LogId:          logID,
 		TreeRevision:   newVersion,
 	}
 
@@ -269,6 +488,10 @@ func (s Sequencer) SequenceBatch(ctx context.Context, logID int64, limit int) (i
 	}
 
 	newLogRoot.Signature = signature
+	if newLogRoot.Signatures, err = s.cosignRoot(ctx, newLogRoot); err != nil {
+		glog.Warningf("%v: failed to cosign root: %v", logID, err)
+		return 0, err
+	}
 
 	if err := tx.StoreSignedLogRoot(newLogRoot); err != nil {
 		glog.Warningf("%v: failed to write updated tree root: %v", logID, err)
@@ -284,6 +507,142 @@ func (s Sequencer) SequenceBatch(ctx context.Context, logID int64, limit int) (i
 	return len(leaves), nil
 }
 
+// IsolateFailingLeaves finds the leaves which stop the batch that SequenceBatch would
+// dequeue from being integrated, and moves them to the dead-letter queue so the rest of
+// the log's queue can make progress. It bisects the batch, trying to integrate each half
+// in a transaction which is then rolled back, until it's down to single leaves which
+// fail. It returns the number of leaves dead-lettered.
+// Only leaves which storage rejects as invalid or as breaking one of its constraints
+// are at fault; any other failure is returned as an error. If every leaf of the batch
+// is at fault, even a batch of one, the failure is unlikely to be down to the leaves,
+// so none of them are dead-lettered and an error is returned.
+func (s Sequencer) IsolateFailingLeaves(ctx context.Context, logID int64, limit int) (int, error) {
+	leaves, err := s.peekLeaves(ctx, logID, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	var failed []*trillian.DeadLetterLeaf
+	if err := s.bisectLeaves(ctx, logID, leaves, &failed); err != nil {
+		return 0, err
+	}
+	if len(failed) == 0 {
+		glog.Infof("%v: batch of %d leaves no longer fails, nothing to dead-letter", logID, len(leaves))
+		return 0, nil
+	}
+	if len(failed) == len(leaves) {
+		return 0, fmt.Errorf("%v: all %d leaves of batch fail to integrate: %s", logID, len(leaves), failed[0].Error)
+	}
+
+	tx, err := s.logStorage.BeginForTree(ctx, logID)
+	if err != nil {
+		glog.Warningf("%v: Sequencer failed to start tx: %v", logID, err)
+		return 0, err
+	}
+	defer tx.Close()
+
+	if err := tx.DeadLetterLeaves(failed); err != nil {
+		glog.Warningf("%v: Sequencer failed to dead-letter leaves: %v", logID, err)
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	for _, dl := range failed {
+		glog.Warningf("%v: dead-lettered leaf %x: %v", logID, dl.Leaf.LeafIdentityHash, dl.Error)
+	}
+	deadLetteredLeaves.Add(int64(len(failed)))
+	return len(failed), nil
+}
+
+// peekLeaves returns the batch of leaves SequenceBatch would dequeue, leaving them queued.
+func (s Sequencer) peekLeaves(ctx context.Context, logID int64, limit int) ([]*trillian.LogLeaf, error) {
+	tx, err := s.logStorage.BeginForTree(ctx, logID)
+	if err != nil {
+		glog.Warningf("%v: Sequencer failed to start tx: %v", logID, err)
+		return nil, err
+	}
+	defer tx.Close()
+
+	guardCutoffTime := s.timeSource.Now().Add(-s.sequencerGuardWindow)
+	leaves, err := s.dequeueLeaves(tx, limit, guardCutoffTime)
+	if err != nil {
+		glog.Warningf("%v: Sequencer failed to dequeue leaves: %v", logID, err)
+		return nil, err
+	}
+	return leaves, tx.Rollback()
+}
+
+// bisectLeaves appends the leaves which fail to integrate to failed, along with the
+// error from integrating each of them alone.
+func (s Sequencer) bisectLeaves(ctx context.Context, logID int64, leaves []*trillian.LogLeaf, failed *[]*trillian.DeadLetterLeaf) error {
+	if len(leaves) == 0 {
+		return nil
+	}
+	failure, err := s.tryIntegrateLeaves(ctx, logID, leaves)
+	if err != nil || failure == "" {
+		return err
+	}
+	if len(leaves) == 1 {
+		*failed = append(*failed, &trillian.DeadLetterLeaf{
+			Leaf:                     leaves[0],
+			Error:                    failure,
+			DeadLetterTimestampNanos: s.timeSource.Now().UnixNano(),
+		})
+		return nil
+	}
+
+	mid := len(leaves) / 2
+	if err := s.bisectLeaves(ctx, logID, leaves[:mid], failed); err != nil {
+		return err
+	}
+	return s.bisectLeaves(ctx, logID, leaves[mid:], failed)
+}
+
+// tryIntegrateLeaves integrates the leaves into the tree in a transaction which is then
+// rolled back. It returns a description of why the leaves were rejected, which is empty
+// if they were integrated, or an error if integrating them failed for any other reason.
+func (s Sequencer) tryIntegrateLeaves(ctx context.Context, logID int64, leaves []*trillian.LogLeaf) (string, error) {
+	tx, err := s.logStorage.BeginForTree(ctx, logID)
+	if err != nil {
+		glog.Warningf("%v: Sequencer failed to start tx: %v", logID, err)
+		return "", err
+	}
+	defer tx.Close()
+
+	currentRoot, err := tx.LatestSignedLogRoot()
+	if err != nil {
+		glog.Warningf("%v: Sequencer failed to get latest root: %v", logID, err)
+		return "", err
+	}
+
+	// The leaves are copied, as sequencing them assigns their indices.
+	copies := make([]*trillian.LogLeaf, 0, len(leaves))
+	for _, leaf := range leaves {
+		c := *leaf
+		copies = append(copies, &c)
+	}
+	var failure string
+	if _, _, err := s.integrateLeaves(ctx, logID, tx, currentRoot, copies); err != nil {
+		if !isLeafFault(err) {
+			return "", err
+		}
+		failure = err.Error()
+	}
+	return failure, tx.Rollback()
+}
+
+// isLeafFault returns whether err is storage rejecting leaves as invalid or as breaking
+// one of its constraints, as opposed to e.g. contention or an unavailable database.
+func isLeafFault(err error) bool {
+	switch te.ErrorCode(err) {
+	case te.InvalidArgument, te.AlreadyExists:
+		return true
+	}
+	return false
+}
+
 // SignRoot wraps up all the operations for creating a new log signed root.
 func (s Sequencer) SignRoot(ctx context.Context, logID int64) error {
 	tx, err := s.logStorage.BeginForTree(ctx, logID)
@@ -308,11 +667,15 @@ func (s Sequencer) SignRoot(ctx context.Context, logID int64) error {
 	}
 
 	// Build the updated root, ready for signing
+	timestamp, err := s.rootTimestamp(ctx, currentRoot)
+	if err != nil {
+		return err
+	}
 	newLogRoot := trillian.SignedLogRoot{
 		RootHash:       merkleTree.CurrentRoot(),
-		TimestampNanos: s.timeSource.Now().UnixNano(),
+		TimestampNanos: timestamp,
 		TreeSize:       merkleTree.Size(),
-		LogId:          currentRoot.LogId,
+		LogId:          logID,
 		TreeRevision:   currentRoot.TreeRevision + 1,
 	}
 
@@ -323,6 +686,10 @@ func (s Sequencer) SignRoot(ctx context.Context, logID int64) error {
 		return err
 	}
 	newLogRoot.Signature = signature
+	if newLogRoot.Signatures, err = s.cosignRoot(ctx, newLogRoot); err != nil {
+		glog.Warningf("%v: failed to cosign root: %v", logID, err)
+		return err
+	}
 
 	// Store the new root and we're done
 	if err := tx.StoreSignedLogRoot(newLogRoot); err != nil {
@@ -17,8 +17,13 @@ package log
 import (
 	"context"
 	gocrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"errors"
+	"expvar"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -27,6 +32,8 @@ import (
 	"github.com/google/trillian/crypto"
 	"github.com/google/trillian/crypto/keys"
 	"github.com/google/trillian/crypto/sigpb"
+	te "github.com/google/trillian/errors"
+	"github.com/google/trillian/monitoring/metric"
 	"github.com/google/trillian/storage"
 	"github.com/google/trillian/testonly"
 	"github.com/google/trillian/util"
@@ -61,7 +68,7 @@ var expectedSignedRoot = trillian.SignedLogRoot{
 	TimestampNanos: fakeTimeForTest.UnixNano(),
 	TreeRevision:   6,
 	TreeSize:       17,
-	LogId:          0,
+	LogId:          154035,
 	Signature: &sigpb.DigitallySigned{
 		SignatureAlgorithm: sigpb.DigitallySigned_ECDSA,
 		HashAlgorithm:      sigpb.DigitallySigned_SHA256,
@@ -75,7 +82,7 @@ var expectedSignedRoot16 = trillian.SignedLogRoot{
 	TreeRevision:   6,
 	TreeSize:       16,
 	RootHash:       testRoot16.RootHash,
-	LogId:          0,
+	LogId:          154035,
 	Signature: &sigpb.DigitallySigned{
 		SignatureAlgorithm: sigpb.DigitallySigned_ECDSA,
 		HashAlgorithm:      sigpb.DigitallySigned_SHA256,
@@ -89,7 +96,7 @@ var expectedSignedRoot0 = trillian.SignedLogRoot{
 	TimestampNanos: fakeTimeForTest.UnixNano(),
 	TreeRevision:   1,
 	TreeSize:       0,
-	LogId:          0,
+	LogId:          154035,
 	Signature: &sigpb.DigitallySigned{
 		SignatureAlgorithm: sigpb.DigitallySigned_ECDSA,
 		HashAlgorithm:      sigpb.DigitallySigned_SHA256,
@@ -708,3 +715,384 @@ func TestSignRootNoExistingRoot(t *testing.T) {
 		t.Fatalf("Expected signing to succeed, but got err: %v", err)
 	}
 }
+
+func TestSignRootWithCosigners(t *testing.T) {
+	signer, err := newSignerWithFixedSig(expectedSignedRoot16.Signature)
+	if err != nil {
+		t.Fatalf("Failed to create test signer (%v)", err)
+	}
+	cosignerKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate cosigner key (%v)", err)
+	}
+	cosigner := crypto.NewSigner(testonly.NewSignerWithFixedSig(cosignerKey.Public(), []byte("cosigned")))
+	failingCosigner := crypto.NewSigner(testonly.NewSignerWithErr(cosignerKey.Public(), errors.New("cosignerfailed")))
+	// sameKeyCosigner has the log signer's own key, so adds no signature.
+	sameKeyCosigner := crypto.NewSigner(testonly.NewSignerWithFixedSig(signer.Public(), []byte("cosigned")))
+
+	signerKeyID, err := crypto.KeyID(signer.Public())
+	if err != nil {
+		t.Fatalf("KeyID(): %v", err)
+	}
+	cosignerKeyID, err := crypto.KeyID(cosignerKey.Public())
+	if err != nil {
+		t.Fatalf("KeyID(): %v", err)
+	}
+	cosignedRoot := expectedSignedRoot16
+	cosignedRoot.Signatures = []*trillian.LogRootSignature{
+		{KeyId: signerKeyID, Signature: expectedSignedRoot16.Signature},
+		{
+			KeyId: cosignerKeyID,
+			Signature: &sigpb.DigitallySigned{
+				SignatureAlgorithm: sigpb.DigitallySigned_ECDSA,
+				HashAlgorithm:      sigpb.DigitallySigned_SHA256,
+				Signature:          []byte("cosigned"),
+			},
+		},
+	}
+	signerOnlyRoot := expectedSignedRoot16
+	signerOnlyRoot.Signatures = cosignedRoot.Signatures[:1]
+
+	for _, test := range []struct {
+		desc          string
+		cosigners     []*crypto.Signer
+		minSignatures int
+		wantRoot      *trillian.SignedLogRoot
+		wantErr       string
+	}{
+		{desc: "cosigned", cosigners: []*crypto.Signer{cosigner}, minSignatures: 2, wantRoot: &cosignedRoot},
+		{desc: "cosigner optional", cosigners: []*crypto.Signer{failingCosigner}, minSignatures: 1, wantRoot: &signerOnlyRoot},
+		{desc: "cosigner failed", cosigners: []*crypto.Signer{failingCosigner}, minSignatures: 2, wantErr: "need 2"},
+		{desc: "no cosigners", minSignatures: 2, wantErr: "need 2"},
+		{desc: "duplicate cosigners", cosigners: []*crypto.Signer{cosigner, cosigner}, minSignatures: 3, wantErr: "need 3"},
+		{desc: "cosigner with log's key", cosigners: []*crypto.Signer{sameKeyCosigner}, minSignatures: 2, wantErr: "need 2"},
+		{desc: "duplicate cosigner skipped", cosigners: []*crypto.Signer{cosigner, cosigner, sameKeyCosigner}, minSignatures: 2, wantRoot: &cosignedRoot},
+	} {
+		func() {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			params := testParameters{
+				logID:               154035,
+				writeRevision:       testRoot16.TreeRevision + 1,
+				latestSignedRoot:    &testRoot16,
+				storeSignedRoot:     test.wantRoot,
+				signer:              signer,
+				shouldCommit:        test.wantRoot != nil,
+				skipDequeue:         true,
+				skipStoreSignedRoot: test.wantRoot == nil,
+			}
+			c, ctx := createTestContext(ctrl, params)
+			c.sequencer.SetCosigners(test.cosigners, test.minSignatures)
+
+			err := c.sequencer.SignRoot(ctx, params.logID)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("%v: SignRoot()=%v, want err containing %q", test.desc, err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("%v: SignRoot()=%v, want no error", test.desc, err)
+			}
+		}()
+	}
+}
+
+func TestRootTimestamp(t *testing.T) {
+	now := fakeTimeForTest.UnixNano()
+	second := time.Second.Nanoseconds()
+	for _, test := range []struct {
+		desc         string
+		maxClockSkew time.Duration
+		prevRoot     int64
+		want         int64
+		wantErr      bool
+	}{
+		{desc: "clock ahead", maxClockSkew: time.Minute, prevRoot: now - second, want: now},
+		{desc: "same as previous root", maxClockSkew: time.Minute, prevRoot: now, want: now + 1},
+		{desc: "behind previous root", maxClockSkew: time.Minute, prevRoot: now + second, want: now + second + 1},
+		{desc: "too far behind previous root", maxClockSkew: time.Minute, prevRoot: now + 61*second, wantErr: true},
+		{desc: "no limit", prevRoot: now + 3600*second, want: now + 3600*second + 1},
+	} {
+		s := NewSequencer(testonly.Hasher, util.FakeTimeSource{FakeTime: fakeTimeForTest}, nil, nil)
+		s.SetMaxClockSkew(test.maxClockSkew)
+
+		got, err := s.rootTimestamp(context.Background(), trillian.SignedLogRoot{TimestampNanos: test.prevRoot})
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("%v: rootTimestamp()=%v, want err: %v", test.desc, err, test.wantErr)
+			continue
+		}
+		if err == nil && got != test.want {
+			t.Errorf("%v: rootTimestamp()=%d, want %d", test.desc, got, test.want)
+		}
+	}
+}
+
+func TestSequenceBatchQueueClockSkew(t *testing.T) {
+	for _, test := range []struct {
+		desc         string
+		maxClockSkew time.Duration
+		newestQueued time.Time
+		wantErr      bool
+	}{
+		{desc: "empty queue", maxClockSkew: time.Minute},
+		{desc: "leaf queued earlier", maxClockSkew: time.Minute, newestQueued: fakeTimeForTest.Add(-time.Second)},
+		{desc: "leaf queued within skew", maxClockSkew: time.Minute, newestQueued: fakeTimeForTest.Add(30 * time.Second)},
+		{desc: "leaf queued beyond skew", maxClockSkew: time.Minute, newestQueued: fakeTimeForTest.Add(2 * time.Minute), wantErr: true},
+		{desc: "no limit"},
+	} {
+		func() {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// Storage only dequeues leaves queued before the cutoff, so none of
+			// the leaves queued after the clock are dequeued.
+			params := testParameters{
+				logID:               154035,
+				dequeueLimit:        1,
+				skipDequeue:         test.wantErr,
+				shouldCommit:        !test.wantErr,
+				skipStoreSignedRoot: true,
+			}
+			if !test.wantErr {
+				params.latestSignedRoot = &testRoot16
+			}
+			c, ctx := createTestContext(ctrl, params)
+			c.sequencer.SetMaxClockSkew(test.maxClockSkew)
+			if test.maxClockSkew > 0 {
+				c.mockTx.EXPECT().NewestQueueTimestamp().Return(test.newestQueued, nil)
+			}
+
+			refusals := metric.Value("sequencer_clock_skew_refusals")
+			n, err := c.sequencer.SequenceBatch(ctx, params.logID, params.dequeueLimit)
+			if gotErr := err != nil; gotErr != test.wantErr || n != 0 {
+				t.Errorf("%v: SequenceBatch()=(%d, %v), want (0, err: %v)", test.desc, n, err, test.wantErr)
+			}
+			wantRefusals := refusals
+			if test.wantErr {
+				wantRefusals++
+			}
+			if got := metric.Value("sequencer_clock_skew_refusals"); got != wantRefusals {
+				t.Errorf("%v: clockSkewRefusals=%d, want %d", test.desc, got, wantRefusals)
+			}
+		}()
+	}
+}
+
+func TestDequeueLeavesReservesLaneShares(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	queuedAt := fakeTimeForTest.Add(-3 * time.Second).UnixNano()
+	high := []*trillian.LogLeaf{
+		{LeafIndex: 1, QueuePriority: trillian.LeafPriority_PRIORITY_HIGH, QueueTimestampNanos: queuedAt},
+		{LeafIndex: 2, QueuePriority: trillian.LeafPriority_PRIORITY_HIGH, QueueTimestampNanos: queuedAt},
+	}
+	normal := []*trillian.LogLeaf{{LeafIndex: 3, QueuePriority: trillian.LeafPriority_PRIORITY_NORMAL, QueueTimestampNanos: queuedAt}}
+	bulk := []*trillian.LogLeaf{
+		{LeafIndex: 4, QueuePriority: trillian.LeafPriority_PRIORITY_BULK, QueueTimestampNanos: queuedAt},
+		{LeafIndex: 5, QueuePriority: trillian.LeafPriority_PRIORITY_BULK, QueueTimestampNanos: queuedAt},
+	}
+
+	// A quarter of the batch of 10 is reserved for each lower lane, and the
+	// remaining 7 leaves are dequeued in priority order.
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	gomock.InOrder(
+		mockTx.EXPECT().DequeuePriorityLeaves(trillian.LeafPriority_PRIORITY_NORMAL, 2, fakeTimeForTest).Return(normal, nil),
+		mockTx.EXPECT().DequeuePriorityLeaves(trillian.LeafPriority_PRIORITY_BULK, 2, fakeTimeForTest).Return(bulk, nil),
+		mockTx.EXPECT().DequeueLeaves(7, fakeTimeForTest).Return(high, nil),
+	)
+
+	dequeuedBefore := expvarInt(laneDequeuedLeaves.Get("PRIORITY_BULK"))
+	s := NewSequencer(testonly.Hasher, util.FakeTimeSource{FakeTime: fakeTimeForTest}, nil, nil)
+	s.SetMinLaneShare(0.25)
+	leaves, err := s.dequeueLeaves(mockTx, 10, fakeTimeForTest)
+	if err != nil {
+		t.Fatalf("dequeueLeaves()=%v, want no error", err)
+	}
+
+	var got []int64
+	for _, leaf := range leaves {
+		got = append(got, leaf.LeafIndex)
+	}
+	if want := []int64{1, 2, 3, 4, 5}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("dequeueLeaves() returned leaves %v, want %v", got, want)
+	}
+	if got, want := expvarInt(laneDequeuedLeaves.Get("PRIORITY_BULK"))-dequeuedBefore, int64(2); got != want {
+		t.Errorf("dequeueLeaves() counted %d bulk leaves, want %d", got, want)
+	}
+	if got, want := expvarInt(laneMaxQueueAge.Get("PRIORITY_HIGH")), int64(3000); got != want {
+		t.Errorf("dequeueLeaves() set max high priority queue age %dms, want %dms", got, want)
+	}
+}
+
+func TestDequeueLeavesWithoutLaneShares(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	mockTx.EXPECT().DequeueLeaves(10, fakeTimeForTest).Return(nil, nil)
+
+	s := NewSequencer(testonly.Hasher, util.FakeTimeSource{FakeTime: fakeTimeForTest}, nil, nil)
+	if _, err := s.dequeueLeaves(mockTx, 10, fakeTimeForTest); err != nil {
+		t.Fatalf("dequeueLeaves()=%v, want no error", err)
+	}
+}
+
+// leavesContaining matches slices of leaves which include the leaf with the given
+// identity hash.
+type leavesContaining []byte
+
+func (m leavesContaining) Matches(x interface{}) bool {
+	leaves, ok := x.([]*trillian.LogLeaf)
+	if !ok {
+		return false
+	}
+	for _, leaf := range leaves {
+		if string(leaf.LeafIdentityHash) == string(m) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m leavesContaining) String() string {
+	return fmt.Sprintf("contains leaf %x", []byte(m))
+}
+
+// expectTrialIntegrations sets up mockTx for IsolateFailingLeaves to dequeue leaves,
+// and for its integration attempts to fail with badErr for batches including a bad leaf.
+func expectTrialIntegrations(mockStorage *storage.MockLogStorage, mockTx *storage.MockLogTreeTX, leaves []*trillian.LogLeaf, bad [][]byte, badErr error) {
+	emptyRoot := trillian.SignedLogRoot{RootHash: []byte{}}
+	mockStorage.EXPECT().BeginForTree(gomock.Any(), gomock.Any()).AnyTimes().Return(mockTx, nil)
+	mockTx.EXPECT().DequeueLeaves(len(leaves), fakeTimeForTest).Return(leaves, nil)
+	mockTx.EXPECT().LatestSignedLogRoot().AnyTimes().Return(emptyRoot, nil)
+	mockTx.EXPECT().WriteRevision().AnyTimes().Return(int64(1))
+	mockTx.EXPECT().Rollback().AnyTimes().Return(nil)
+	mockTx.EXPECT().Close().AnyTimes().Return(nil)
+
+	var good []gomock.Matcher
+	for _, hash := range bad {
+		mockTx.EXPECT().UpdateSequencedLeaves(leavesContaining(hash)).AnyTimes().Return(badErr)
+		good = append(good, gomock.Not(leavesContaining(hash)))
+	}
+	mockTx.EXPECT().UpdateSequencedLeaves(allOf(good)).AnyTimes().Return(nil)
+	mockTx.EXPECT().SetMerkleNodes(gomock.Any()).AnyTimes().Return(nil)
+}
+
+// allOf matches values which all of its matchers match.
+type allOf []gomock.Matcher
+
+func (m allOf) Matches(x interface{}) bool {
+	for _, matcher := range m {
+		if !matcher.Matches(x) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m allOf) String() string {
+	return fmt.Sprintf("all of %v", []gomock.Matcher(m))
+}
+
+func TestIsolateFailingLeaves(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var leaves []*trillian.LogLeaf
+	for i := 0; i < 5; i++ {
+		data := []byte(fmt.Sprintf("leaf %d", i))
+		leaves = append(leaves, &trillian.LogLeaf{
+			LeafIdentityHash: testonly.Hasher.HashLeaf(data),
+			MerkleLeafHash:   testonly.Hasher.HashLeaf(data),
+			LeafValue:        data,
+		})
+	}
+	bad := [][]byte{leaves[1].LeafIdentityHash, leaves[4].LeafIdentityHash}
+
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	expectTrialIntegrations(mockStorage, mockTx, leaves, bad, te.New(te.AlreadyExists, "duplicate entry"))
+
+	var deadLettered []*trillian.DeadLetterLeaf
+	mockTx.EXPECT().DeadLetterLeaves(gomock.Any()).Do(func(dl []*trillian.DeadLetterLeaf) {
+		deadLettered = dl
+	}).Return(nil)
+	mockTx.EXPECT().Commit().Return(nil)
+
+	s := NewSequencer(testonly.Hasher, util.FakeTimeSource{FakeTime: fakeTimeForTest}, mockStorage, nil)
+	n, err := s.IsolateFailingLeaves(context.Background(), 154035, len(leaves))
+	if err != nil {
+		t.Fatalf("IsolateFailingLeaves()=_,%v, want no error", err)
+	}
+	if got, want := n, len(bad); got != want {
+		t.Fatalf("IsolateFailingLeaves()=%d, want %d", got, want)
+	}
+	for i, dl := range deadLettered {
+		if got, want := dl.Leaf.LeafIdentityHash, bad[i]; string(got) != string(want) {
+			t.Errorf("dead-lettered leaf %d is %x, want %x", i, got, want)
+		}
+		if !strings.Contains(dl.Error, "duplicate entry") {
+			t.Errorf("dead-lettered leaf %d has error %q, want the integration error", i, dl.Error)
+		}
+		if got, want := dl.DeadLetterTimestampNanos, fakeTimeForTest.UnixNano(); got != want {
+			t.Errorf("dead-lettered leaf %d at %d, want %d", i, got, want)
+		}
+		if dl.Leaf.LeafIndex != 0 {
+			t.Errorf("dead-lettered leaf %d has index %d set by a trial integration", i, dl.Leaf.LeafIndex)
+		}
+	}
+}
+
+func TestIsolateFailingLeavesAllFail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	leaves := []*trillian.LogLeaf{
+		{LeafIdentityHash: []byte("leaf 0"), MerkleLeafHash: testonly.Hasher.HashLeaf([]byte("leaf 0"))},
+		{LeafIdentityHash: []byte("leaf 1"), MerkleLeafHash: testonly.Hasher.HashLeaf([]byte("leaf 1"))},
+	}
+	for _, n := range []int{1, len(leaves)} {
+		mockStorage := storage.NewMockLogStorage(ctrl)
+		mockTx := storage.NewMockLogTreeTX(ctrl)
+		var bad [][]byte
+		for _, leaf := range leaves[:n] {
+			bad = append(bad, leaf.LeafIdentityHash)
+		}
+		expectTrialIntegrations(mockStorage, mockTx, leaves[:n], bad, te.New(te.AlreadyExists, "duplicate entry"))
+
+		// No leaves are dead-lettered, as the failure isn't down to any of them.
+		s := NewSequencer(testonly.Hasher, util.FakeTimeSource{FakeTime: fakeTimeForTest}, mockStorage, nil)
+		if got, err := s.IsolateFailingLeaves(context.Background(), 154035, n); err == nil {
+			t.Errorf("IsolateFailingLeaves() of %d leaves=%d,nil, want error", n, got)
+		}
+	}
+}
+
+func TestIsolateFailingLeavesNotLeafFault(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	leaves := []*trillian.LogLeaf{
+		{LeafIdentityHash: []byte("leaf 0"), MerkleLeafHash: testonly.Hasher.HashLeaf([]byte("leaf 0"))},
+		{LeafIdentityHash: []byte("leaf 1"), MerkleLeafHash: testonly.Hasher.HashLeaf([]byte("leaf 1"))},
+	}
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	expectTrialIntegrations(mockStorage, mockTx, leaves, [][]byte{leaves[1].LeafIdentityHash}, errors.New("lock wait timeout exceeded"))
+
+	// Losing a lock isn't the leaf's fault, so it isn't dead-lettered.
+	s := NewSequencer(testonly.Hasher, util.FakeTimeSource{FakeTime: fakeTimeForTest}, mockStorage, nil)
+	if n, err := s.IsolateFailingLeaves(context.Background(), 154035, len(leaves)); err == nil {
+		t.Fatalf("IsolateFailingLeaves()=%d,nil, want error", n)
+	}
+}
+
+func expvarInt(v expvar.Var) int64 {
+	if i, ok := v.(*expvar.Int); ok {
+		return i.Value()
+	}
+	return 0
+}
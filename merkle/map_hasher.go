@@ -29,6 +29,11 @@ func NewMapHasher(th TreeHasher) MapHasher {
 	}
 }
 
+// HashEmptyMap returns the root hash of a SparseMerkleTree with no leaves.
+func (m MapHasher) HashEmptyMap() []byte {
+	return m.HashChildren(m.nullHashes[0], m.nullHashes[0])
+}
+
 func createNullHashes(th TreeHasher) [][]byte {
 	numEntries := th.Size() * 8
 	r := make([][]byte, numEntries, numEntries)
@@ -37,7 +37,7 @@ func emptyMapRoot() []byte {
 
 func TestNullHashes(t *testing.T) {
 	mh := NewMapHasher(testonly.Hasher)
-	emptyRoot := mh.HashChildren(mh.nullHashes[0], mh.nullHashes[0])
+	emptyRoot := mh.HashEmptyMap()
 	if got, want := emptyRoot, emptyMapRoot(); !bytes.Equal(got, want) {
 		t.Fatalf("Expected empty root of %v, got %v", want, got)
 	}
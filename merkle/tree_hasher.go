@@ -17,6 +17,7 @@ package merkle
 import (
 	"crypto"
 	"fmt"
+	"sort"
 
 	"github.com/google/trillian/merkle/rfc6962"
 )
@@ -47,3 +48,14 @@ func Factory(hashType string) (TreeHasher, error) {
 	}
 	return h, nil
 }
+
+// HashTypes returns the names of all hashers supported by Factory, in sorted
+// order.
+func HashTypes() []string {
+	types := make([]string, 0, len(hashTypes))
+	for t := range hashTypes {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
@@ -43,3 +43,15 @@ func TestRfc6962Hasher(t *testing.T) {
 	ensureHashMatches(testonly.MustHexDecode(rfc6962LeafL123456HashHex), hasher.HashLeaf([]byte("L123456")), "RFC6962 Leaf", t)
 	ensureHashMatches(testonly.MustHexDecode(rfc6962NodeN123N456HashHex), hasher.HashChildren([]byte("N123"), []byte("N456")), "RFC6962 Node", t)
 }
+
+func TestHashTypes(t *testing.T) {
+	types := HashTypes()
+	if len(types) == 0 {
+		t.Fatal("HashTypes() returned no hash types")
+	}
+	for _, hashType := range types {
+		if _, err := Factory(hashType); err != nil {
+			t.Errorf("Factory(%q): %v", hashType, err)
+		}
+	}
+}
@@ -31,6 +31,22 @@ func (_m *MockTrillianLogClient) EXPECT() *_MockTrillianLogClientRecorder {
 	return _m.recorder
 }
 
+func (_m *MockTrillianLogClient) DiscardDeadLetterLeaves(_param0 context.Context, _param1 *trillian.DiscardDeadLetterLeavesRequest, _param2 ...grpc.CallOption) (*trillian.DiscardDeadLetterLeavesResponse, error) {
+	_s := []interface{}{_param0, _param1}
+	for _, _x := range _param2 {
+		_s = append(_s, _x)
+	}
+	ret := _m.ctrl.Call(_m, "DiscardDeadLetterLeaves", _s...)
+	ret0, _ := ret[0].(*trillian.DiscardDeadLetterLeavesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockTrillianLogClientRecorder) DiscardDeadLetterLeaves(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	_s := append([]interface{}{arg0, arg1}, arg2...)
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "DiscardDeadLetterLeaves", _s...)
+}
+
 func (_m *MockTrillianLogClient) GetConsistencyProof(_param0 context.Context, _param1 *trillian.GetConsistencyProofRequest, _param2 ...grpc.CallOption) (*trillian.GetConsistencyProofResponse, error) {
 	_s := []interface{}{_param0, _param1}
 	for _, _x := range _param2 {
@@ -111,6 +127,22 @@ func (_mr *_MockTrillianLogClientRecorder) GetLatestSignedLogRoot(arg0, arg1 int
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetLatestSignedLogRoot", _s...)
 }
 
+func (_m *MockTrillianLogClient) GetLeafSubmitters(_param0 context.Context, _param1 *trillian.GetLeafSubmittersRequest, _param2 ...grpc.CallOption) (*trillian.GetLeafSubmittersResponse, error) {
+	_s := []interface{}{_param0, _param1}
+	for _, _x := range _param2 {
+		_s = append(_s, _x)
+	}
+	ret := _m.ctrl.Call(_m, "GetLeafSubmitters", _s...)
+	ret0, _ := ret[0].(*trillian.GetLeafSubmittersResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockTrillianLogClientRecorder) GetLeafSubmitters(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	_s := append([]interface{}{arg0, arg1}, arg2...)
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetLeafSubmitters", _s...)
+}
+
 func (_m *MockTrillianLogClient) GetLeavesByHash(_param0 context.Context, _param1 *trillian.GetLeavesByHashRequest, _param2 ...grpc.CallOption) (*trillian.GetLeavesByHashResponse, error) {
 	_s := []interface{}{_param0, _param1}
 	for _, _x := range _param2 {
@@ -159,6 +191,22 @@ func (_mr *_MockTrillianLogClientRecorder) GetSequencedLeafCount(arg0, arg1 inte
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetSequencedLeafCount", _s...)
 }
 
+func (_m *MockTrillianLogClient) ListDeadLetterLeaves(_param0 context.Context, _param1 *trillian.ListDeadLetterLeavesRequest, _param2 ...grpc.CallOption) (*trillian.ListDeadLetterLeavesResponse, error) {
+	_s := []interface{}{_param0, _param1}
+	for _, _x := range _param2 {
+		_s = append(_s, _x)
+	}
+	ret := _m.ctrl.Call(_m, "ListDeadLetterLeaves", _s...)
+	ret0, _ := ret[0].(*trillian.ListDeadLetterLeavesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockTrillianLogClientRecorder) ListDeadLetterLeaves(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	_s := append([]interface{}{arg0, arg1}, arg2...)
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListDeadLetterLeaves", _s...)
+}
+
 func (_m *MockTrillianLogClient) QueueLeaf(_param0 context.Context, _param1 *trillian.QueueLeafRequest, _param2 ...grpc.CallOption) (*trillian.QueueLeafResponse, error) {
 	_s := []interface{}{_param0, _param1}
 	for _, _x := range _param2 {
@@ -191,6 +239,38 @@ func (_mr *_MockTrillianLogClientRecorder) QueueLeaves(arg0, arg1 interface{}, a
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "QueueLeaves", _s...)
 }
 
+func (_m *MockTrillianLogClient) RequeueDeadLetterLeaves(_param0 context.Context, _param1 *trillian.RequeueDeadLetterLeavesRequest, _param2 ...grpc.CallOption) (*trillian.RequeueDeadLetterLeavesResponse, error) {
+	_s := []interface{}{_param0, _param1}
+	for _, _x := range _param2 {
+		_s = append(_s, _x)
+	}
+	ret := _m.ctrl.Call(_m, "RequeueDeadLetterLeaves", _s...)
+	ret0, _ := ret[0].(*trillian.RequeueDeadLetterLeavesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockTrillianLogClientRecorder) RequeueDeadLetterLeaves(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	_s := append([]interface{}{arg0, arg1}, arg2...)
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "RequeueDeadLetterLeaves", _s...)
+}
+
+func (_m *MockTrillianLogClient) WaitForInclusion(_param0 context.Context, _param1 *trillian.WaitForInclusionRequest, _param2 ...grpc.CallOption) (*trillian.WaitForInclusionResponse, error) {
+	_s := []interface{}{_param0, _param1}
+	for _, _x := range _param2 {
+		_s = append(_s, _x)
+	}
+	ret := _m.ctrl.Call(_m, "WaitForInclusion", _s...)
+	ret0, _ := ret[0].(*trillian.WaitForInclusionResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockTrillianLogClientRecorder) WaitForInclusion(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	_s := append([]interface{}{arg0, arg1}, arg2...)
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "WaitForInclusion", _s...)
+}
+
 // Mock of TrillianLogServer interface
 type MockTrillianLogServer struct {
 	ctrl     *gomock.Controller
@@ -212,6 +292,17 @@ func (_m *MockTrillianLogServer) EXPECT() *_MockTrillianLogServerRecorder {
 	return _m.recorder
 }
 
+func (_m *MockTrillianLogServer) DiscardDeadLetterLeaves(_param0 context.Context, _param1 *trillian.DiscardDeadLetterLeavesRequest) (*trillian.DiscardDeadLetterLeavesResponse, error) {
+	ret := _m.ctrl.Call(_m, "DiscardDeadLetterLeaves", _param0, _param1)
+	ret0, _ := ret[0].(*trillian.DiscardDeadLetterLeavesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockTrillianLogServerRecorder) DiscardDeadLetterLeaves(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "DiscardDeadLetterLeaves", arg0, arg1)
+}
+
 func (_m *MockTrillianLogServer) GetConsistencyProof(_param0 context.Context, _param1 *trillian.GetConsistencyProofRequest) (*trillian.GetConsistencyProofResponse, error) {
 	ret := _m.ctrl.Call(_m, "GetConsistencyProof", _param0, _param1)
 	ret0, _ := ret[0].(*trillian.GetConsistencyProofResponse)
@@ -267,6 +358,17 @@ func (_mr *_MockTrillianLogServerRecorder) GetLatestSignedLogRoot(arg0, arg1 int
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetLatestSignedLogRoot", arg0, arg1)
 }
 
+func (_m *MockTrillianLogServer) GetLeafSubmitters(_param0 context.Context, _param1 *trillian.GetLeafSubmittersRequest) (*trillian.GetLeafSubmittersResponse, error) {
+	ret := _m.ctrl.Call(_m, "GetLeafSubmitters", _param0, _param1)
+	ret0, _ := ret[0].(*trillian.GetLeafSubmittersResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockTrillianLogServerRecorder) GetLeafSubmitters(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetLeafSubmitters", arg0, arg1)
+}
+
 func (_m *MockTrillianLogServer) GetLeavesByHash(_param0 context.Context, _param1 *trillian.GetLeavesByHashRequest) (*trillian.GetLeavesByHashResponse, error) {
 	ret := _m.ctrl.Call(_m, "GetLeavesByHash", _param0, _param1)
 	ret0, _ := ret[0].(*trillian.GetLeavesByHashResponse)
@@ -300,6 +402,17 @@ func (_mr *_MockTrillianLogServerRecorder) GetSequencedLeafCount(arg0, arg1 inte
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetSequencedLeafCount", arg0, arg1)
 }
 
+func (_m *MockTrillianLogServer) ListDeadLetterLeaves(_param0 context.Context, _param1 *trillian.ListDeadLetterLeavesRequest) (*trillian.ListDeadLetterLeavesResponse, error) {
+	ret := _m.ctrl.Call(_m, "ListDeadLetterLeaves", _param0, _param1)
+	ret0, _ := ret[0].(*trillian.ListDeadLetterLeavesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockTrillianLogServerRecorder) ListDeadLetterLeaves(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListDeadLetterLeaves", arg0, arg1)
+}
+
 func (_m *MockTrillianLogServer) QueueLeaf(_param0 context.Context, _param1 *trillian.QueueLeafRequest) (*trillian.QueueLeafResponse, error) {
 	ret := _m.ctrl.Call(_m, "QueueLeaf", _param0, _param1)
 	ret0, _ := ret[0].(*trillian.QueueLeafResponse)
@@ -322,6 +435,28 @@ func (_mr *_MockTrillianLogServerRecorder) QueueLeaves(arg0, arg1 interface{}) *
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "QueueLeaves", arg0, arg1)
 }
 
+func (_m *MockTrillianLogServer) RequeueDeadLetterLeaves(_param0 context.Context, _param1 *trillian.RequeueDeadLetterLeavesRequest) (*trillian.RequeueDeadLetterLeavesResponse, error) {
+	ret := _m.ctrl.Call(_m, "RequeueDeadLetterLeaves", _param0, _param1)
+	ret0, _ := ret[0].(*trillian.RequeueDeadLetterLeavesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockTrillianLogServerRecorder) RequeueDeadLetterLeaves(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "RequeueDeadLetterLeaves", arg0, arg1)
+}
+
+func (_m *MockTrillianLogServer) WaitForInclusion(_param0 context.Context, _param1 *trillian.WaitForInclusionRequest) (*trillian.WaitForInclusionResponse, error) {
+	ret := _m.ctrl.Call(_m, "WaitForInclusion", _param0, _param1)
+	ret0, _ := ret[0].(*trillian.WaitForInclusionResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockTrillianLogServerRecorder) WaitForInclusion(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "WaitForInclusion", arg0, arg1)
+}
+
 // Mock of TrillianMapClient interface
 type MockTrillianMapClient struct {
 	ctrl     *gomock.Controller
@@ -359,6 +494,22 @@ func (_mr *_MockTrillianMapClientRecorder) GetLeaves(arg0, arg1 interface{}, arg
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetLeaves", _s...)
 }
 
+func (_m *MockTrillianMapClient) GetMapLeafHistory(_param0 context.Context, _param1 *trillian.GetMapLeafHistoryRequest, _param2 ...grpc.CallOption) (*trillian.GetMapLeafHistoryResponse, error) {
+	_s := []interface{}{_param0, _param1}
+	for _, _x := range _param2 {
+		_s = append(_s, _x)
+	}
+	ret := _m.ctrl.Call(_m, "GetMapLeafHistory", _s...)
+	ret0, _ := ret[0].(*trillian.GetMapLeafHistoryResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockTrillianMapClientRecorder) GetMapLeafHistory(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	_s := append([]interface{}{arg0, arg1}, arg2...)
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetMapLeafHistory", _s...)
+}
+
 func (_m *MockTrillianMapClient) GetSignedMapRoot(_param0 context.Context, _param1 *trillian.GetSignedMapRootRequest, _param2 ...grpc.CallOption) (*trillian.GetSignedMapRootResponse, error) {
 	_s := []interface{}{_param0, _param1}
 	for _, _x := range _param2 {
@@ -423,6 +574,17 @@ func (_mr *_MockTrillianMapServerRecorder) GetLeaves(arg0, arg1 interface{}) *go
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetLeaves", arg0, arg1)
 }
 
+func (_m *MockTrillianMapServer) GetMapLeafHistory(_param0 context.Context, _param1 *trillian.GetMapLeafHistoryRequest) (*trillian.GetMapLeafHistoryResponse, error) {
+	ret := _m.ctrl.Call(_m, "GetMapLeafHistory", _param0, _param1)
+	ret0, _ := ret[0].(*trillian.GetMapLeafHistoryResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockTrillianMapServerRecorder) GetMapLeafHistory(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetMapLeafHistory", arg0, arg1)
+}
+
 func (_m *MockTrillianMapServer) GetSignedMapRoot(_param0 context.Context, _param1 *trillian.GetSignedMapRootRequest) (*trillian.GetSignedMapRootResponse, error) {
 	ret := _m.ctrl.Call(_m, "GetSignedMapRoot", _param0, _param1)
 	ret0, _ := ret[0].(*trillian.GetSignedMapRootResponse)
@@ -18,6 +18,7 @@ import (
 	"context"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang/glog"
@@ -28,9 +29,12 @@ type Counter interface {
 	Add(n int64)
 }
 type counter struct {
+	// value is only accessed atomically, so that hot paths adding to the
+	// counter don't contend on mu. It's first to keep it 64-bit aligned.
+	value int64
+
 	mu              sync.Mutex
 	name            string
-	value           int64
 	lastDumped      time.Time
 	lastDumpedValue int64
 }
@@ -47,9 +51,7 @@ var (
 )
 
 func (m *counter) Add(n int64) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.value += n
+	atomic.AddInt64(&m.value, n)
 }
 
 // NewCounter defines a cumulative metric. The name should be unique
@@ -65,6 +67,18 @@ func NewCounter(name string) Counter {
 	return &c
 }
 
+// Value returns the current value of the named metric, or zero if no metric
+// with that name has been defined.
+func Value(name string) int64 {
+	metrics.mu.Lock()
+	m := metrics.m[name]
+	metrics.mu.Unlock()
+	if m == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&m.value)
+}
+
 func dump() {
 	metrics.mu.Lock()
 	defer metrics.mu.Unlock()
@@ -77,7 +91,7 @@ func dump() {
 	for _, key := range keys {
 		m := metrics.m[key]
 		m.mu.Lock()
-		current := m.value
+		current := atomic.LoadInt64(&m.value)
 		delta := current - m.lastDumpedValue
 		now := time.Now()
 		duration := now.Sub(m.lastDumped)
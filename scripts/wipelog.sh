@@ -10,6 +10,7 @@ TREE_ID=$1
 
 # Wipe all Log storage rows for the given tree ID.
 mysql ${TESTDBOPTS} -e "DELETE FROM Unsequenced WHERE TreeId = ${TREE_ID}"
+mysql ${TESTDBOPTS} -e "DELETE FROM DeadLetter WHERE TreeId = ${TREE_ID}"
 mysql ${TESTDBOPTS} -e "DELETE FROM TreeHead WHERE TreeId = ${TREE_ID}"
 mysql ${TESTDBOPTS} -e "DELETE FROM SequencedLeafData WHERE TreeId = ${TREE_ID}"
 mysql ${TESTDBOPTS} -e "DELETE FROM LeafData WHERE TreeId = ${TREE_ID}"
@@ -19,6 +19,7 @@ import (
 	"github.com/google/trillian"
 	"github.com/google/trillian/extension"
 	"github.com/google/trillian/server/errors"
+	"github.com/google/trillian/util"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -28,12 +29,16 @@ var errNotImplemented = grpc.Errorf(codes.Unimplemented, "not implemented")
 
 // Server is an implementation of trillian.TrillianAdminServer.
 type Server struct {
-	registry extension.Registry
+	registry   extension.Registry
+	timeSource util.TimeSource
 }
 
 // New returns a trillian.TrillianAdminServer implementation.
+// The registry must provide a SignerFactory, and LogStorage or MapStorage for
+// the types of trees created, as CreateTree signs the initial root of each
+// tree.
 func New(registry extension.Registry) *Server {
-	return &Server{registry}
+	return &Server{registry: registry, timeSource: util.SystemTimeSource{}}
 }
 
 // ListTrees implements trillian.TrillianAdminServer.ListTrees.
@@ -90,6 +95,10 @@ func (s *Server) createTreeImpl(ctx context.Context, request *trillian.CreateTre
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
+	// Clients need a signed root to start from, even for an empty tree.
+	if err := s.initTree(ctx, tree); err != nil {
+		return nil, grpc.Errorf(codes.Internal, "tree %d created but its initial root could not be signed: %v", tree.TreeId, err)
+	}
 	return redact(tree), nil
 }
 
@@ -20,9 +20,11 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/google/trillian"
+	"github.com/google/trillian/crypto/keys"
 	"github.com/google/trillian/extension"
 	"github.com/google/trillian/storage"
 	"github.com/google/trillian/storage/testonly"
+	"github.com/google/trillian/util"
 	"github.com/kylelemons/godebug/pretty"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
@@ -211,6 +213,18 @@ func TestAdminServer_CreateTree(t *testing.T) {
 		}
 		wantErr := test.createErr || test.commitErr
 
+		var storedRoot trillian.SignedLogRoot
+		if !wantErr {
+			ls := storage.NewMockLogStorage(ctrl)
+			logTX := storage.NewMockLogTreeTX(ctrl)
+			ls.EXPECT().BeginForTree(ctx, newTree.TreeId).Return(logTX, nil)
+			logTX.EXPECT().LatestSignedLogRoot().Return(trillian.SignedLogRoot{}, nil)
+			logTX.EXPECT().StoreSignedLogRoot(gomock.Any()).Do(func(root trillian.SignedLogRoot) { storedRoot = root }).Return(nil)
+			logTX.EXPECT().Commit().Return(nil)
+			logTX.EXPECT().Close().Return(nil)
+			s.registry.LogStorage = ls
+		}
+
 		tree, err := s.CreateTree(ctx, test.req)
 		if hasErr := err != nil; hasErr != wantErr {
 			t.Errorf("%v: CreateTree() = (_, %v), wantErr = %v", test.desc, err, wantErr)
@@ -224,6 +238,28 @@ func TestAdminServer_CreateTree(t *testing.T) {
 		if diff := pretty.Compare(tree, &wantTree); diff != "" {
 			t.Errorf("%v: post-CreateTree diff (-got +want):\n%v", test.desc, diff)
 		}
+		if storedRoot.TreeRevision != 0 || storedRoot.TreeSize != 0 || storedRoot.Signature == nil {
+			t.Errorf("%v: CreateTree() stored initial root %+v, want signed root of revision 0 and size 0", test.desc, storedRoot)
+		}
+	}
+}
+
+func TestAdminServer_CreateTreeInitFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	setup := setupAdminStorage(ctrl, false /* snapshot */, true /* shouldCommit */, false /* commitErr */)
+	newTree := *testonly.LogTree
+	newTree.TreeId = 12345
+	setup.tx.EXPECT().CreateTree(ctx, testonly.LogTree).Return(&newTree, nil)
+	ls := storage.NewMockLogStorage(ctrl)
+	ls.EXPECT().BeginForTree(ctx, newTree.TreeId).Return(nil, errors.New("begin error"))
+	setup.server.registry.LogStorage = ls
+
+	_, err := setup.server.CreateTree(ctx, &trillian.CreateTreeRequest{Tree: testonly.LogTree})
+	if got, want := grpc.Code(err), codes.Internal; got != want {
+		t.Errorf("CreateTree() = (_, %v), want code %v", err, want)
 	}
 }
 
@@ -271,10 +307,11 @@ func setupAdminStorage(ctrl *gomock.Controller, snapshot, shouldCommit, commitEr
 	}
 
 	registry := extension.Registry{
-		AdminStorage: as,
+		AdminStorage:  as,
+		SignerFactory: keys.PEMSignerFactory{},
 	}
 
-	s := &Server{registry}
+	s := &Server{registry: registry, timeSource: util.FakeTimeSource{FakeTime: fakeTime}}
 
 	return adminTestSetup{registry, as, tx, snapshotTX, s}
 }
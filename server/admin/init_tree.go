@@ -0,0 +1,133 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"fmt"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/util"
+	"golang.org/x/net/context"
+)
+
+// InitLog stores a signed root for the empty log logID, at revision zero, so
+// that clients have a signed root to start from. It does nothing if the log
+// already has a root, so may be retried.
+func InitLog(ctx context.Context, ls storage.LogStorage, signer *crypto.Signer, logID int64, timeSource util.TimeSource) error {
+	// TODO(al): Hasher must be selected based on log config.
+	th, err := merkle.Factory(merkle.RFC6962SHA256Type)
+	if err != nil {
+		return err
+	}
+
+	tx, err := ls.BeginForTree(ctx, logID)
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+
+	currentRoot, err := tx.LatestSignedLogRoot()
+	if err != nil {
+		return err
+	}
+	if currentRoot.RootHash != nil {
+		return nil
+	}
+
+	root := trillian.SignedLogRoot{
+		RootHash:       th.HashEmpty(),
+		TimestampNanos: timeSource.Now().UnixNano(),
+		TreeSize:       0,
+		LogId:          logID,
+		TreeRevision:   0,
+	}
+	if root.Signature, err = signer.SignLogRoot(root); err != nil {
+		return fmt.Errorf("failed to sign root: %v", err)
+	}
+	if err := tx.StoreSignedLogRoot(root); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// InitMap stores a signed root for the empty map mapID, at revision zero, so
+// that clients have a signed root to start from. It does nothing if the map
+// already has a root, so may be retried.
+func InitMap(ctx context.Context, ms storage.MapStorage, signer *crypto.Signer, mapID int64, timeSource util.TimeSource) error {
+	// TODO(al): Hasher must be selected based on map config.
+	th, err := merkle.Factory(merkle.RFC6962SHA256Type)
+	if err != nil {
+		return err
+	}
+
+	tx, err := ms.BeginForTree(ctx, mapID)
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+
+	currentRoot, err := tx.LatestSignedMapRoot()
+	if err != nil {
+		return err
+	}
+	if currentRoot.RootHash != nil {
+		return nil
+	}
+
+	root := trillian.SignedMapRoot{
+		RootHash:       merkle.NewMapHasher(th).HashEmptyMap(),
+		TimestampNanos: timeSource.Now().UnixNano(),
+		MapId:          mapID,
+		MapRevision:    0,
+	}
+	// The signature covers the root as it is before the signature is set.
+	if root.Signature, err = signer.SignObject(root); err != nil {
+		return fmt.Errorf("failed to sign root: %v", err)
+	}
+	if err := tx.StoreSignedMapRoot(root); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// initTree signs the initial root of a newly created tree.
+func (s *Server) initTree(ctx context.Context, tree *trillian.Tree) error {
+	if s.registry.SignerFactory == nil {
+		return fmt.Errorf("no SignerFactory provided by registry")
+	}
+	key, err := s.registry.SignerFactory.NewSigner(ctx, tree)
+	if err != nil {
+		return err
+	}
+	signer := crypto.NewSigner(key)
+
+	switch tree.TreeType {
+	case trillian.TreeType_LOG:
+		if s.registry.LogStorage == nil {
+			return fmt.Errorf("no LogStorage provided by registry")
+		}
+		return InitLog(ctx, s.registry.LogStorage, signer, tree.TreeId, s.timeSource)
+	case trillian.TreeType_MAP:
+		if s.registry.MapStorage == nil {
+			return fmt.Errorf("no MapStorage provided by registry")
+		}
+		return InitMap(ctx, s.registry.MapStorage, signer, tree.TreeId, s.timeSource)
+	default:
+		return fmt.Errorf("unknown tree type: %v", tree.TreeType)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto"
+	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/testonly"
+	"github.com/google/trillian/util"
+	"golang.org/x/net/context"
+)
+
+var fakeTime = time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestSigner(t *testing.T) *crypto.Signer {
+	key, err := keys.NewFromPrivatePEM(testonly.DemoPrivateKey, testonly.DemoPrivateKeyPass)
+	if err != nil {
+		t.Fatalf("NewFromPrivatePEM(): %v", err)
+	}
+	return crypto.NewSigner(key)
+}
+
+func TestInitLog(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	signer := newTestSigner(t)
+	for _, test := range []struct {
+		desc        string
+		currentRoot trillian.SignedLogRoot
+		wantStore   bool
+	}{
+		{desc: "empty log", wantStore: true},
+		// Initialisation may be retried, so this isn't an error.
+		{desc: "initialised log", currentRoot: trillian.SignedLogRoot{RootHash: testonly.Hasher.HashEmpty()}},
+	} {
+		ls := storage.NewMockLogStorage(ctrl)
+		tx := storage.NewMockLogTreeTX(ctrl)
+		ls.EXPECT().BeginForTree(ctx, int64(6962)).Return(tx, nil)
+		tx.EXPECT().LatestSignedLogRoot().Return(test.currentRoot, nil)
+		tx.EXPECT().Close().Return(nil)
+		var stored trillian.SignedLogRoot
+		if test.wantStore {
+			tx.EXPECT().StoreSignedLogRoot(gomock.Any()).Do(func(root trillian.SignedLogRoot) { stored = root }).Return(nil)
+			tx.EXPECT().Commit().Return(nil)
+		}
+
+		if err := InitLog(ctx, ls, signer, 6962, util.FakeTimeSource{FakeTime: fakeTime}); err != nil {
+			t.Errorf("%s: InitLog()=%v, want nil", test.desc, err)
+			continue
+		}
+		if !test.wantStore {
+			continue
+		}
+		if stored.LogId != 6962 || stored.TreeSize != 0 || stored.TreeRevision != 0 || stored.TimestampNanos != fakeTime.UnixNano() {
+			t.Errorf("%s: InitLog() stored root %+v, want empty root at revision 0", test.desc, stored)
+		}
+		if got, want := stored.RootHash, testonly.Hasher.HashEmpty(); !bytes.Equal(got, want) {
+			t.Errorf("%s: InitLog() stored root hash %x, want %x", test.desc, got, want)
+		}
+		if err := crypto.Verify(signer.Public(), crypto.HashLogRoot(stored), stored.Signature); err != nil {
+			t.Errorf("%s: Verify(): %v", test.desc, err)
+		}
+	}
+}
+
+func TestInitMap(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	signer := newTestSigner(t)
+	emptyRoot := merkle.NewMapHasher(testonly.Hasher).HashEmptyMap()
+	for _, test := range []struct {
+		desc        string
+		currentRoot trillian.SignedMapRoot
+		wantStore   bool
+	}{
+		{desc: "empty map", wantStore: true},
+		// Initialisation may be retried, so this isn't an error.
+		{desc: "initialised map", currentRoot: trillian.SignedMapRoot{RootHash: emptyRoot}},
+	} {
+		ms := storage.NewMockMapStorage(ctrl)
+		tx := storage.NewMockMapTreeTX(ctrl)
+		ms.EXPECT().BeginForTree(ctx, int64(6963)).Return(tx, nil)
+		tx.EXPECT().LatestSignedMapRoot().Return(test.currentRoot, nil)
+		tx.EXPECT().Close().Return(nil)
+		var stored trillian.SignedMapRoot
+		if test.wantStore {
+			tx.EXPECT().StoreSignedMapRoot(gomock.Any()).Do(func(root trillian.SignedMapRoot) { stored = root }).Return(nil)
+			tx.EXPECT().Commit().Return(nil)
+		}
+
+		if err := InitMap(ctx, ms, signer, 6963, util.FakeTimeSource{FakeTime: fakeTime}); err != nil {
+			t.Errorf("%s: InitMap()=%v, want nil", test.desc, err)
+			continue
+		}
+		if !test.wantStore {
+			continue
+		}
+		if stored.MapId != 6963 || stored.MapRevision != 0 || stored.TimestampNanos != fakeTime.UnixNano() {
+			t.Errorf("%s: InitMap() stored root %+v, want empty root at revision 0", test.desc, stored)
+		}
+		if !bytes.Equal(stored.RootHash, emptyRoot) {
+			t.Errorf("%s: InitMap() stored root hash %x, want %x", test.desc, stored.RootHash, emptyRoot)
+		}
+		sig := stored.Signature
+		stored.Signature = nil
+		if err := crypto.VerifyObject(signer.Public(), stored, sig); err != nil {
+			t.Errorf("%s: VerifyObject(): %v", test.desc, err)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package auth determines the identity of the clients calling Trillian RPC
+// servers, from their TLS client certificate or a bearer token, and whether
+// they may call admin-only RPCs.
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	authorizationKey = "authorization"
+	bearerPrefix     = "Bearer "
+)
+
+// Caller describes the client making an RPC.
+type Caller struct {
+	// Identity names the client, or is empty if it isn't authenticated.
+	Identity string
+	// Admin is set if the client may call admin-only RPCs.
+	Admin bool
+}
+
+type callerKey struct{}
+
+// NewContext returns a context carrying caller.
+func NewContext(ctx context.Context, caller Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// FromContext returns the caller carried by ctx. It returns an
+// unauthenticated Caller if there is none.
+func FromContext(ctx context.Context) Caller {
+	caller, _ := ctx.Value(callerKey{}).(Caller)
+	return caller
+}
+
+// RequireAdmin returns a PermissionDenied error unless the caller carried by
+// ctx may call admin-only RPCs.
+func RequireAdmin(ctx context.Context) error {
+	if !FromContext(ctx).Admin {
+		return grpc.Errorf(codes.PermissionDenied, "admin access required")
+	}
+	return nil
+}
+
+// Authenticator identifies callers. A caller presenting a verified TLS client
+// certificate is identified by the certificate's subject common name.
+// Otherwise, a caller presenting a bearer token in the "authorization"
+// metadata is identified by the name the token maps to. Callers presenting
+// neither are unauthenticated, but callers presenting an unknown token are
+// rejected.
+type Authenticator struct {
+	tokens map[string]string
+	admins map[string]bool
+}
+
+// NewAuthenticator creates an Authenticator which accepts the given tokens,
+// mapped to the identities they authenticate, and which allows the given
+// identities to call admin-only RPCs.
+func NewAuthenticator(tokens map[string]string, admins []string) *Authenticator {
+	a := &Authenticator{tokens: tokens, admins: make(map[string]bool)}
+	for _, admin := range admins {
+		a.admins[admin] = true
+	}
+	return a
+}
+
+// TokensFromFile reads bearer tokens from filename, which should hold a JSON
+// object mapping each token to the identity it authenticates.
+func TokensFromFile(filename string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tokens: %v", err)
+	}
+	var tokens map[string]string
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to parse tokens: %v", err)
+	}
+	for token, identity := range tokens {
+		if len(token) == 0 || len(identity) == 0 {
+			return nil, fmt.Errorf("empty token or identity in %s", filename)
+		}
+	}
+	return tokens, nil
+}
+
+// Authenticate identifies the caller of the RPC whose context is ctx.
+func (a *Authenticator) Authenticate(ctx context.Context) (Caller, error) {
+	identity := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			if chains := tlsInfo.State.VerifiedChains; len(chains) > 0 && len(chains[0]) > 0 {
+				identity = chains[0][0].Subject.CommonName
+			}
+		}
+	}
+	if len(identity) == 0 {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			for _, value := range md[authorizationKey] {
+				if !strings.HasPrefix(value, bearerPrefix) {
+					continue
+				}
+				var ok bool
+				identity, ok = a.tokens[strings.TrimPrefix(value, bearerPrefix)]
+				if !ok {
+					return Caller{}, grpc.Errorf(codes.Unauthenticated, "unknown bearer token")
+				}
+				break
+			}
+		}
+	}
+	return Caller{Identity: identity, Admin: len(identity) > 0 && a.admins[identity]}, nil
+}
+
+// Interceptor returns a UnaryServerInterceptor which identifies the caller of
+// each RPC, and makes the Caller available through FromContext.
+func (a *Authenticator) Interceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		caller, err := a.Authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(NewContext(ctx, caller), req)
+	}
+}
+
+// ChainInterceptors returns a UnaryServerInterceptor which calls each of the
+// given interceptors in turn, the first outermost.
+func ChainInterceptors(interceptors ...grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, next := interceptors[i], handler
+			handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return interceptor(ctx, req, info, next)
+			}
+		}
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func tlsContext(commonName string) context.Context {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+	info := credentials.TLSInfo{State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}}
+	return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: info})
+}
+
+func tokenContext(ctx context.Context, token string) context.Context {
+	return metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+token))
+}
+
+func TestAuthenticate(t *testing.T) {
+	a := NewAuthenticator(map[string]string{"t1": "alice", "t2": "root"}, []string{"root", "ops"})
+	for _, test := range []struct {
+		desc     string
+		ctx      context.Context
+		want     Caller
+		wantCode codes.Code
+	}{
+		{desc: "anonymous", ctx: context.Background()},
+		{desc: "token", ctx: tokenContext(context.Background(), "t1"), want: Caller{Identity: "alice"}},
+		{desc: "admin token", ctx: tokenContext(context.Background(), "t2"), want: Caller{Identity: "root", Admin: true}},
+		{desc: "unknown token", ctx: tokenContext(context.Background(), "t3"), wantCode: codes.Unauthenticated},
+		{desc: "cert", ctx: tlsContext("ops"), want: Caller{Identity: "ops", Admin: true}},
+		{desc: "cert wins", ctx: tokenContext(tlsContext("bob"), "t2"), want: Caller{Identity: "bob"}},
+		{desc: "unverified cert", ctx: peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{}})},
+	} {
+		got, err := a.Authenticate(test.ctx)
+		if code := grpc.Code(err); code != test.wantCode {
+			t.Errorf("%s: Authenticate()=_,%v; want code %v", test.desc, err, test.wantCode)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: Authenticate()=%+v,_; want %+v", test.desc, got, test.want)
+		}
+	}
+}
+
+func TestInterceptor(t *testing.T) {
+	a := NewAuthenticator(map[string]string{"t1": "alice"}, nil)
+	var got Caller
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		got = FromContext(ctx)
+		return nil, RequireAdmin(ctx)
+	}
+	var order []string
+	tracer := func(name string) grpc.UnaryServerInterceptor {
+		return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			order = append(order, name)
+			return handler(ctx, req)
+		}
+	}
+	interceptor := ChainInterceptors(tracer("first"), a.Interceptor(), tracer("last"))
+
+	_, err := interceptor(tokenContext(context.Background(), "t1"), nil, &grpc.UnaryServerInfo{}, handler)
+	if got, want := grpc.Code(err), codes.PermissionDenied; got != want {
+		t.Errorf("interceptor()=_,%v; want code %v", err, want)
+	}
+	if want := (Caller{Identity: "alice"}); got != want {
+		t.Errorf("FromContext()=%+v, want %+v", got, want)
+	}
+	if want := []string{"first", "last"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("interceptors called in order %v, want %v", order, want)
+	}
+
+	order = nil
+	_, err = interceptor(tokenContext(context.Background(), "t2"), nil, &grpc.UnaryServerInfo{}, handler)
+	if got, want := grpc.Code(err), codes.Unauthenticated; got != want {
+		t.Errorf("interceptor()=_,%v; want code %v", err, want)
+	}
+	if want := []string{"first"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("interceptors called in order %v, want %v", order, want)
+	}
+}
+
+func TestTokensFromFile(t *testing.T) {
+	for _, test := range []struct {
+		desc    string
+		data    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{desc: "ok", data: `{"t1": "alice", "t2": "bob"}`, want: map[string]string{"t1": "alice", "t2": "bob"}},
+		{desc: "empty identity", data: `{"t1": ""}`, wantErr: true},
+		{desc: "bad json", data: `{"t1": `, wantErr: true},
+	} {
+		f, err := ioutil.TempFile("", "tokens")
+		if err != nil {
+			t.Fatalf("TempFile(): %v", err)
+		}
+		defer os.Remove(f.Name())
+		if _, err := f.WriteString(test.data); err != nil {
+			t.Fatalf("WriteString(): %v", err)
+		}
+		f.Close()
+
+		got, err := TokensFromFile(f.Name())
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("%s: TokensFromFile()=_,%v; want error %v", test.desc, err, test.wantErr)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: TokensFromFile()=%v,nil; want %v", test.desc, got, test.want)
+		}
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"github.com/golang/glog"
+	"github.com/google/trillian"
 	"github.com/google/trillian/extension"
 	"github.com/google/trillian/util"
 )
@@ -58,6 +59,8 @@ type LogOperationManager struct {
 	context LogOperationManagerContext
 	// logOperation is the task that gets run across active logs in the scheduling loop
 	logOperation LogOperation
+	// treeType is the type of the trees that logOperation is run on.
+	treeType trillian.TreeType
 }
 
 // NewLogOperationManager creates a new LogOperationManager instance.
@@ -72,6 +75,25 @@ func NewLogOperationManager(ctx context.Context, registry extension.Registry, ba
 			numSequencers:    numSequencers,
 		},
 		logOperation: logOperation,
+		treeType:     trillian.TreeType_LOG,
+	}
+}
+
+// NewMapOperationManager creates a new LogOperationManager instance which runs
+// mapOperation across active maps, rather than logs. The maps are processed one
+// at a time.
+func NewMapOperationManager(ctx context.Context, registry extension.Registry, batchSize int, sleepBetweenRuns time.Duration, timeSource util.TimeSource, mapOperation LogOperation) *LogOperationManager {
+	return &LogOperationManager{
+		context: LogOperationManagerContext{
+			ctx:              ctx,
+			registry:         registry,
+			batchSize:        batchSize,
+			sleepBetweenRuns: sleepBetweenRuns,
+			timeSource:       timeSource,
+			numSequencers:    1,
+		},
+		logOperation: mapOperation,
+		treeType:     trillian.TreeType_MAP,
 	}
 }
 
@@ -88,14 +110,37 @@ func NewLogOperationManagerForTest(ctx context.Context, registry extension.Regis
 			numSequencers:    5,
 		},
 		logOperation: logOperation,
+		treeType:     trillian.TreeType_LOG,
 	}
 }
 
 func (l LogOperationManager) getLogsAndExecutePass(ctx context.Context) bool {
+	getIDs := l.getActiveLogIDs
+	if l.treeType == trillian.TreeType_MAP {
+		getIDs = l.getActiveMapIDs
+	}
+	logIDs, err := getIDs(ctx)
+	if err != nil {
+		return false
+	}
+
+	// Process each active log once.
+	l.logOperation.ExecutePass(logIDs, l.context)
+
+	// See if it's time to quit
+	select {
+	case <-ctx.Done():
+		return true
+	default:
+	}
+	return false
+}
+
+func (l LogOperationManager) getActiveLogIDs(ctx context.Context) ([]int64, error) {
 	tx, err := l.context.registry.LogStorage.Snapshot(ctx)
 	if err != nil {
 		glog.Warningf("Failed to get tx for run: %v", err)
-		return false
+		return nil, err
 	}
 	defer tx.Close()
 
@@ -103,24 +148,42 @@ func (l LogOperationManager) getLogsAndExecutePass(ctx context.Context) bool {
 	logIDs, err := tx.GetActiveLogIDs()
 	if err != nil {
 		glog.Warningf("Failed to get log list for run: %v", err)
-		return false
+		return nil, err
 	}
 
 	if err := tx.Commit(); err != nil {
 		glog.Warningf("Failed to commit getting logs: %v", err)
-		return false
+		return nil, err
 	}
+	return logIDs, nil
+}
 
-	// Process each active log once.
-	l.logOperation.ExecutePass(logIDs, l.context)
+func (l LogOperationManager) getActiveMapIDs(ctx context.Context) ([]int64, error) {
+	tx, err := l.context.registry.AdminStorage.Snapshot(ctx)
+	if err != nil {
+		glog.Warningf("Failed to get tx for run: %v", err)
+		return nil, err
+	}
+	defer tx.Close()
 
-	// See if it's time to quit
-	select {
-	case <-ctx.Done():
-		return true
-	default:
+	trees, err := tx.ListTrees(ctx)
+	if err != nil {
+		glog.Warningf("Failed to get map list for run: %v", err)
+		return nil, err
 	}
-	return false
+
+	if err := tx.Commit(); err != nil {
+		glog.Warningf("Failed to commit getting maps: %v", err)
+		return nil, err
+	}
+
+	var mapIDs []int64
+	for _, tree := range trees {
+		if tree.TreeType == trillian.TreeType_MAP && tree.TreeState == trillian.TreeState_ACTIVE {
+			mapIDs = append(mapIDs, tree.TreeId)
+		}
+	}
+	return mapIDs, nil
 }
 
 // OperationSingle performs a single pass of the manager.
@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/google/trillian"
 	"github.com/google/trillian/extension"
 	"github.com/google/trillian/storage"
 	"github.com/google/trillian/util"
@@ -133,3 +134,31 @@ func TestLogOperationManagerPassesIDs(t *testing.T) {
 
 	lom.OperationLoop()
 }
+
+func TestMapOperationManagerPassesActiveMapIDs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	trees := []*trillian.Tree{
+		{TreeId: 1, TreeType: trillian.TreeType_MAP, TreeState: trillian.TreeState_ACTIVE},
+		{TreeId: 2, TreeType: trillian.TreeType_LOG, TreeState: trillian.TreeState_ACTIVE},
+		{TreeId: 3, TreeType: trillian.TreeType_MAP, TreeState: trillian.TreeState_FROZEN},
+		{TreeId: 4, TreeType: trillian.TreeType_MAP, TreeState: trillian.TreeState_ACTIVE},
+	}
+	mockTx := storage.NewMockReadOnlyAdminTX(ctrl)
+	mockTx.EXPECT().ListTrees(gomock.Any()).Return(trees, nil)
+	mockTx.EXPECT().Commit().Return(nil)
+	mockTx.EXPECT().Close().Return(nil)
+	mockStorage := storage.NewMockAdminStorage(ctrl)
+	mockStorage.EXPECT().Snapshot(gomock.Any()).Return(mockTx, nil)
+
+	registry := extension.Registry{
+		AdminStorage: mockStorage,
+	}
+
+	mockMapOp := NewMockLogOperation(ctrl)
+	mockMapOp.EXPECT().ExecutePass([]int64{1, 4}, logOpMgrContextMatcher{50})
+
+	mom := NewMapOperationManager(context.Background(), registry, 50, time.Second, fakeTimeSource, mockMapOp)
+	mom.OperationSingle()
+}
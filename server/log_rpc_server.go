@@ -15,10 +15,14 @@
 package server
 
 import (
+	"expvar"
+	"time"
+
 	"github.com/golang/glog"
 	"github.com/google/trillian"
 	"github.com/google/trillian/extension"
 	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/server/auth"
 	"github.com/google/trillian/storage"
 	"github.com/google/trillian/util"
 	"golang.org/x/net/context"
@@ -34,20 +38,68 @@ import (
 // Pass this as a fixed value to proof calculations. It's used as the max depth of the tree
 const proofMaxBitLen = 64
 
+// defaultRootPollInterval is how often WaitForInclusion checks storage for a new root if
+// it isn't notified of one.
+const defaultRootPollInterval = 200 * time.Millisecond
+
+// defaultMaxInclusionWait is how long WaitForInclusion waits at most, whatever the
+// RPC's deadline, so that calls without one don't wait forever.
+const defaultMaxInclusionWait = time.Minute
+
+// defaultMaxDeadLetterLeaves is how many dead-lettered leaves ListDeadLetterLeaves returns
+// if the request doesn't say.
+const defaultMaxDeadLetterLeaves = 100
+
+// anonymousSubmitter is the key used in the per-submitter stats for leaves queued by
+// unauthenticated clients.
+const anonymousSubmitter = "anonymous"
+
+var (
+	// Per-submitter ingestion stats, keyed by submitter identity.
+	submitterQueuedLeaves    = expvar.NewMap("submitter-queued-leaves")
+	submitterDuplicateLeaves = expvar.NewMap("submitter-duplicate-leaves")
+	submitterQueuedBytes     = expvar.NewMap("submitter-queued-bytes")
+)
+
 // TrillianLogRPCServer implements the RPC API defined in the proto
 type TrillianLogRPCServer struct {
-	registry   extension.Registry
-	timeSource util.TimeSource
+	registry         extension.Registry
+	timeSource       util.TimeSource
+	rootWatcher      *rootWatcher
+	maxInclusionWait time.Duration
 }
 
 // NewTrillianLogRPCServer creates a new RPC server backed by a LogStorageProvider.
 func NewTrillianLogRPCServer(registry extension.Registry, timeSource util.TimeSource) *TrillianLogRPCServer {
 	return &TrillianLogRPCServer{
-		registry:   registry,
-		timeSource: timeSource,
+		registry:         registry,
+		timeSource:       timeSource,
+		rootWatcher:      newRootWatcher(registry.LogStorage, defaultRootPollInterval),
+		maxInclusionWait: defaultMaxInclusionWait,
 	}
 }
 
+// SetRootPollInterval sets how often WaitForInclusion checks storage for a new root
+// of a log that RPCs are waiting on. It must be called before the server is started.
+func (t *TrillianLogRPCServer) SetRootPollInterval(interval time.Duration) {
+	t.rootWatcher.pollInterval = interval
+}
+
+// SetMaxInclusionWait sets how long WaitForInclusion waits at most, even if the RPC's
+// deadline is later or it has none. It must be called before the server is started.
+func (t *TrillianLogRPCServer) SetMaxInclusionWait(maxWait time.Duration) {
+	t.maxInclusionWait = maxWait
+}
+
+// RootUpdated tells the server that a new root has been signed for logID, so that
+// WaitForInclusion RPCs waiting on it don't have to wait for the next poll. Only a
+// sequencer running in the same process can call it, as in tests; there's no
+// notification between processes, so a server whose log signer runs separately
+// finds new roots by polling, and waits up to the poll interval longer.
+func (t *TrillianLogRPCServer) RootUpdated(logID int64) {
+	t.rootWatcher.notify(logID)
+}
+
 // IsHealthy returns nil if the server is healthy, error otherwise.
 func (t *TrillianLogRPCServer) IsHealthy() error {
 	return t.registry.LogStorage.CheckDatabaseAccessible(context.Background())
@@ -56,8 +108,9 @@ func (t *TrillianLogRPCServer) IsHealthy() error {
 // QueueLeaf submits one leaf to the queue.
 func (t *TrillianLogRPCServer) QueueLeaf(ctx context.Context, req *trillian.QueueLeafRequest) (*trillian.QueueLeafResponse, error) {
 	queueReq := &trillian.QueueLeavesRequest{
-		LogId:  req.LogId,
-		Leaves: []*trillian.LogLeaf{req.Leaf},
+		LogId:    req.LogId,
+		Leaves:   []*trillian.LogLeaf{req.Leaf},
+		Priority: req.Priority,
 	}
 	queueRsp, err := t.QueueLeaves(ctx, queueReq)
 	if err != nil {
@@ -81,8 +134,13 @@ func (t *TrillianLogRPCServer) QueueLeaves(ctx context.Context, req *trillian.Qu
 
 	// TODO(al): Hasher must be selected based on log config.
 	th, _ := merkle.Factory(merkle.RFC6962SHA256Type)
+	submitter := auth.FromContext(ctx).Identity
 	for i := range req.Leaves {
 		req.Leaves[i].MerkleLeafHash = th.HashLeaf(req.Leaves[i].LeafValue)
+		// The submitter is always taken from the caller's credentials, and isn't
+		// covered by the leaf hash.
+		req.Leaves[i].Submitter = submitter
+		req.Leaves[i].QueuePriority = req.Priority
 	}
 
 	tx, err := t.prepareStorageTx(ctx, req.LogId)
@@ -100,7 +158,21 @@ func (t *TrillianLogRPCServer) QueueLeaves(ctx context.Context, req *trillian.Qu
 		return nil, err
 	}
 
+	statsKey := submitter
+	if len(statsKey) == 0 {
+		statsKey = anonymousSubmitter
+	}
 	var queuedLeaves []*trillian.QueuedLogLeaf
+	for i, existingLeaf := range existingLeaves {
+		if existingLeaf != nil {
+			submitterDuplicateLeaves.Add(statsKey, 1)
+		} else {
+			submitterQueuedLeaves.Add(statsKey, 1)
+			submitterQueuedBytes.Add(statsKey, int64(len(req.Leaves[i].LeafValue)+len(req.Leaves[i].ExtraData)))
+		}
+	}
+	redactSubmitters(req.Leaves)
+	redactSubmitters(existingLeaves)
 	for i, existingLeaf := range existingLeaves {
 		if existingLeaf != nil {
 			// Append the existing leaf to the response.
@@ -261,6 +333,11 @@ func (t *TrillianLogRPCServer) GetLatestSignedLogRoot(ctx context.Context, req *
 	if err := t.commitAndLog(ctx, tx, "GetLatestSignedLogRoot"); err != nil {
 		return nil, err
 	}
+	// Trees are given a signed root when they are created; don't hand out a
+	// zero-valued, unsigned root for one that has none.
+	if signedRoot.RootHash == nil {
+		return nil, grpc.Errorf(codes.FailedPrecondition, "log %d has not been initialised", req.LogId)
+	}
 
 	return &trillian.GetLatestSignedLogRootResponse{SignedLogRoot: &signedRoot}, nil
 }
@@ -312,6 +389,7 @@ func (t *TrillianLogRPCServer) GetLeavesByIndex(ctx context.Context, req *trilli
 		return nil, err
 	}
 
+	redactSubmitters(leaves)
 	return &trillian.GetLeavesByIndexResponse{
 		Leaves: leaves,
 	}, nil
@@ -367,12 +445,207 @@ func (t *TrillianLogRPCServer) GetEntryAndProof(ctx context.Context, req *trilli
 	}
 
 	// Work is complete, we have everything we need for the response
+	redactSubmitters(leaves)
 	return &trillian.GetEntryAndProofResponse{
 		Proof: &proof,
 		Leaf:  leaves[0],
 	}, nil
 }
 
+// GetLeafSubmitters returns sequenced leaves along with the identity of the client that
+// submitted each of them. Only admin clients may call it.
+func (t *TrillianLogRPCServer) GetLeafSubmitters(ctx context.Context, req *trillian.GetLeafSubmittersRequest) (*trillian.GetLeafSubmittersResponse, error) {
+	ctx = util.NewLogContext(ctx, req.LogId)
+	if err := auth.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if len(req.LeafIndex) == 0 || !validateLeafIndices(req.LeafIndex) {
+		return nil, grpc.Errorf(codes.InvalidArgument, "invalid leaf indices: %v", req.LeafIndex)
+	}
+
+	tx, err := t.prepareReadOnlyStorageTx(ctx, req.LogId)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+
+	leaves, err := tx.GetLeavesByIndex(req.LeafIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.commitAndLog(ctx, tx, "GetLeafSubmitters"); err != nil {
+		return nil, err
+	}
+
+	return &trillian.GetLeafSubmittersResponse{Leaves: leaves}, nil
+}
+
+// WaitForInclusion waits until the leaf with the given hash has been integrated and a
+// root covering it has been signed, then returns that root and an inclusion proof of the
+// leaf against it. It waits until the RPC's deadline, the request's maximum wait or the
+// server's maximum wait, whichever is soonest.
+// New roots are found by polling storage every root poll interval, unless a sequencer
+// in the same process calls RootUpdated.
+func (t *TrillianLogRPCServer) WaitForInclusion(ctx context.Context, req *trillian.WaitForInclusionRequest) (*trillian.WaitForInclusionResponse, error) {
+	ctx = util.NewLogContext(ctx, req.LogId)
+	if len(req.LeafHash) == 0 {
+		return nil, grpc.Errorf(codes.InvalidArgument, "WaitForInclusion() requires a leaf hash")
+	}
+	if req.MaxWaitMillis < 0 {
+		return nil, grpc.Errorf(codes.InvalidArgument, "invalid max_wait_millis: %d", req.MaxWaitMillis)
+	}
+	maxWait := t.maxInclusionWait
+	if reqWait := time.Duration(req.MaxWaitMillis) * time.Millisecond; reqWait > 0 && reqWait < maxWait {
+		maxWait = reqWait
+	}
+	ctx, cancel := context.WithTimeout(ctx, maxWait)
+	defer cancel()
+
+	for {
+		rsp, treeSize, err := t.getInclusionIfIntegrated(ctx, req)
+		if err != nil || rsp != nil {
+			return rsp, err
+		}
+		if err := t.rootWatcher.wait(ctx, req.LogId, treeSize); err != nil {
+			return nil, grpc.Errorf(codes.DeadlineExceeded, "leaf not integrated by a signed root: %v", err)
+		}
+	}
+}
+
+// getInclusionIfIntegrated returns the WaitForInclusion response if the leaf is covered by
+// the latest signed root. Otherwise it returns the size of the tree that root covers.
+func (t *TrillianLogRPCServer) getInclusionIfIntegrated(ctx context.Context, req *trillian.WaitForInclusionRequest) (*trillian.WaitForInclusionResponse, int64, error) {
+	tx, err := t.prepareReadOnlyStorageTx(ctx, req.LogId)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Close()
+
+	root, err := tx.LatestSignedLogRoot()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	leaves, err := tx.GetLeavesByHash([][]byte{req.LeafHash}, true)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Leaves are in sequence order, so the first is the one integrated earliest.
+	if len(leaves) == 0 || leaves[0].LeafIndex >= root.TreeSize {
+		if err := t.commitAndLog(ctx, tx, "WaitForInclusion"); err != nil {
+			return nil, 0, err
+		}
+		return nil, root.TreeSize, nil
+	}
+
+	leafIndex := leaves[0].LeafIndex
+	proof, err := getInclusionProofForLeafIndex(tx, root.TreeSize, leafIndex, root.TreeSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := t.commitAndLog(ctx, tx, "WaitForInclusion"); err != nil {
+		return nil, 0, err
+	}
+
+	return &trillian.WaitForInclusionResponse{
+		LeafIndex:     leafIndex,
+		SignedLogRoot: &root,
+		Proof:         &proof,
+	}, 0, nil
+}
+
+// ListDeadLetterLeaves returns the oldest queued leaves which the sequencer set aside
+// because they failed to be integrated. Only admin clients may call it.
+func (t *TrillianLogRPCServer) ListDeadLetterLeaves(ctx context.Context, req *trillian.ListDeadLetterLeavesRequest) (*trillian.ListDeadLetterLeavesResponse, error) {
+	ctx = util.NewLogContext(ctx, req.LogId)
+	if err := auth.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if req.MaxLeaves < 0 {
+		return nil, grpc.Errorf(codes.InvalidArgument, "invalid max_leaves: %d", req.MaxLeaves)
+	}
+	limit := int(req.MaxLeaves)
+	if limit == 0 {
+		limit = defaultMaxDeadLetterLeaves
+	}
+
+	tx, err := t.prepareStorageTx(ctx, req.LogId)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+
+	leaves, err := tx.GetDeadLetterLeaves(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.commitAndLog(ctx, tx, "ListDeadLetterLeaves"); err != nil {
+		return nil, err
+	}
+
+	return &trillian.ListDeadLetterLeavesResponse{Leaves: leaves}, nil
+}
+
+// RequeueDeadLetterLeaves moves dead-lettered leaves back to the queue, e.g. after the
+// cause of their failure has been fixed. Only admin clients may call it.
+func (t *TrillianLogRPCServer) RequeueDeadLetterLeaves(ctx context.Context, req *trillian.RequeueDeadLetterLeavesRequest) (*trillian.RequeueDeadLetterLeavesResponse, error) {
+	ctx = util.NewLogContext(ctx, req.LogId)
+	if err := auth.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if len(req.LeafIdentityHash) == 0 || !validateLeafHashes(req.LeafIdentityHash) {
+		return nil, grpc.Errorf(codes.InvalidArgument, "RequeueDeadLetterLeaves() requires leaf identity hashes")
+	}
+
+	tx, err := t.prepareStorageTx(ctx, req.LogId)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+
+	if err := tx.RequeueDeadLetterLeaves(req.LeafIdentityHash, t.timeSource.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := t.commitAndLog(ctx, tx, "RequeueDeadLetterLeaves"); err != nil {
+		return nil, err
+	}
+
+	return &trillian.RequeueDeadLetterLeavesResponse{}, nil
+}
+
+// DiscardDeadLetterLeaves deletes dead-lettered leaves so that they're never integrated.
+// Only admin clients may call it.
+func (t *TrillianLogRPCServer) DiscardDeadLetterLeaves(ctx context.Context, req *trillian.DiscardDeadLetterLeavesRequest) (*trillian.DiscardDeadLetterLeavesResponse, error) {
+	ctx = util.NewLogContext(ctx, req.LogId)
+	if err := auth.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if len(req.LeafIdentityHash) == 0 || !validateLeafHashes(req.LeafIdentityHash) {
+		return nil, grpc.Errorf(codes.InvalidArgument, "DiscardDeadLetterLeaves() requires leaf identity hashes")
+	}
+
+	tx, err := t.prepareStorageTx(ctx, req.LogId)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+
+	if err := tx.DiscardDeadLetterLeaves(req.LeafIdentityHash); err != nil {
+		return nil, err
+	}
+
+	if err := t.commitAndLog(ctx, tx, "DiscardDeadLetterLeaves"); err != nil {
+		return nil, err
+	}
+
+	return &trillian.DiscardDeadLetterLeavesResponse{}, nil
+}
+
 func (t *TrillianLogRPCServer) prepareStorageTx(ctx context.Context, treeID int64) (storage.LogTreeTX, error) {
 	tx, err := t.registry.LogStorage.BeginForTree(ctx, treeID)
 	if err != nil {
@@ -397,6 +670,16 @@ func (t *TrillianLogRPCServer) commitAndLog(ctx context.Context, tx storage.Read
 	return err
 }
 
+// redactSubmitters clears the submitter of the leaves, which is only returned by the
+// admin-only GetLeafSubmitters.
+func redactSubmitters(leaves []*trillian.LogLeaf) {
+	for _, leaf := range leaves {
+		if leaf != nil {
+			leaf.Submitter = ""
+		}
+	}
+}
+
 func validateLeafIndices(leafIndices []int64) bool {
 	for _, index := range leafIndices {
 		if index < 0 {
@@ -454,6 +737,7 @@ func (t *TrillianLogRPCServer) getLeavesByHashInternal(ctx context.Context, desc
 		return nil, err
 	}
 
+	redactSubmitters(leaves)
 	return &trillian.GetLeavesByHashResponse{
 		Leaves: leaves,
 	}, nil
@@ -20,11 +20,13 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/golang/protobuf/proto"
 	"github.com/google/trillian"
 	"github.com/google/trillian/extension"
+	"github.com/google/trillian/server/auth"
 	"github.com/google/trillian/storage"
 	"github.com/google/trillian/testonly"
 	"google.golang.org/genproto/googleapis/rpc/code"
@@ -1420,3 +1422,384 @@ func (p *parameterizedTest) executeBeginFailsTest(t *testing.T, logID int64) {
 		t.Fatalf("Returned wrong error response when begin failed: %v", err)
 	}
 }
+
+func TestQueueLeavesRecordsSubmitterAndPriority(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	// The stats are global, so clear any left by earlier runs of the test.
+	submitterQueuedLeaves.Init()
+	submitterDuplicateLeaves.Init()
+	submitterQueuedBytes.Init()
+
+	ctx := auth.NewContext(context.Background(), auth.Caller{Identity: "alice"})
+	leaf := &trillian.LogLeaf{LeafValue: leaf1Data, Submitter: "mallory"}
+	existing := &trillian.LogLeaf{LeafValue: leaf3Data, MerkleLeafHash: th.HashLeaf(leaf3Data), Submitter: "bob"}
+	req := &trillian.QueueLeavesRequest{LogId: logID1, Leaves: []*trillian.LogLeaf{leaf, {LeafValue: leaf3Data}}, Priority: trillian.LeafPriority_PRIORITY_HIGH}
+
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	mockStorage.EXPECT().BeginForTree(gomock.Any(), logID1).Return(mockTx, nil)
+	mockTx.EXPECT().QueueLeaves(gomock.Any(), fakeTime).Do(func(leaves []*trillian.LogLeaf, _ time.Time) {
+		for i, leaf := range leaves {
+			if got, want := leaf.Submitter, "alice"; got != want {
+				t.Errorf("QueueLeaves(): leaves[%d].Submitter=%q, want %q", i, got, want)
+			}
+			if got, want := leaf.QueuePriority, trillian.LeafPriority_PRIORITY_HIGH; got != want {
+				t.Errorf("QueueLeaves(): leaves[%d].QueuePriority=%v, want %v", i, got, want)
+			}
+		}
+	}).Return([]*trillian.LogLeaf{nil, existing}, nil)
+	mockTx.EXPECT().Commit().Return(nil)
+	mockTx.EXPECT().Close().Return(nil)
+
+	server := NewTrillianLogRPCServer(extension.Registry{LogStorage: mockStorage}, fakeTimeSource)
+	rsp, err := server.QueueLeaves(ctx, req)
+	if err != nil {
+		t.Fatalf("QueueLeaves()=nil,%v; want _,nil", err)
+	}
+	for i, queuedLeaf := range rsp.QueuedLeaves {
+		if got := queuedLeaf.Leaf.Submitter; got != "" {
+			t.Errorf("QueueLeaves(): QueuedLeaves[%d].Leaf.Submitter=%q, want redacted", i, got)
+		}
+	}
+	if got, want := submitterQueuedLeaves.Get("alice").String(), "1"; got != want {
+		t.Errorf("submitter-queued-leaves[alice]=%s, want %s", got, want)
+	}
+	if got, want := submitterDuplicateLeaves.Get("alice").String(), "1"; got != want {
+		t.Errorf("submitter-duplicate-leaves[alice]=%s, want %s", got, want)
+	}
+	if got, want := submitterQueuedBytes.Get("alice").String(), "5"; got != want {
+		t.Errorf("submitter-queued-bytes[alice]=%s, want %s", got, want)
+	}
+}
+
+func TestGetLeavesByIndexRedactsSubmitter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	mockStorage.EXPECT().SnapshotForTree(gomock.Any(), logID1).Return(mockTx, nil)
+	mockTx.EXPECT().GetLeavesByIndex([]int64{0}).Return([]*trillian.LogLeaf{{LeafValue: leaf1Data, Submitter: "alice"}}, nil)
+	mockTx.EXPECT().Commit().Return(nil)
+	mockTx.EXPECT().Close().Return(nil)
+
+	server := NewTrillianLogRPCServer(extension.Registry{LogStorage: mockStorage}, fakeTimeSource)
+	rsp, err := server.GetLeavesByIndex(context.Background(), &leaf0Request)
+	if err != nil {
+		t.Fatalf("GetLeavesByIndex()=nil,%v; want _,nil", err)
+	}
+	if got := rsp.Leaves[0].Submitter; got != "" {
+		t.Errorf("GetLeavesByIndex(): Submitter=%q, want redacted", got)
+	}
+}
+
+func TestGetLeafSubmitters(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	req := &trillian.GetLeafSubmittersRequest{LogId: logID1, LeafIndex: []int64{0}}
+	for _, test := range []struct {
+		desc     string
+		caller   auth.Caller
+		req      *trillian.GetLeafSubmittersRequest
+		wantCode codes.Code
+	}{
+		{desc: "anonymous", req: req, wantCode: codes.PermissionDenied},
+		{desc: "not admin", caller: auth.Caller{Identity: "alice"}, req: req, wantCode: codes.PermissionDenied},
+		{desc: "no indices", caller: auth.Caller{Identity: "root", Admin: true}, req: &trillian.GetLeafSubmittersRequest{LogId: logID1}, wantCode: codes.InvalidArgument},
+		{desc: "negative index", caller: auth.Caller{Identity: "root", Admin: true}, req: &trillian.GetLeafSubmittersRequest{LogId: logID1, LeafIndex: []int64{-1}}, wantCode: codes.InvalidArgument},
+		{desc: "admin", caller: auth.Caller{Identity: "root", Admin: true}, req: req, wantCode: codes.OK},
+	} {
+		mockStorage := storage.NewMockLogStorage(ctrl)
+		if test.wantCode == codes.OK {
+			mockTx := storage.NewMockLogTreeTX(ctrl)
+			mockStorage.EXPECT().SnapshotForTree(gomock.Any(), logID1).Return(mockTx, nil)
+			mockTx.EXPECT().GetLeavesByIndex([]int64{0}).Return([]*trillian.LogLeaf{{LeafValue: leaf1Data, Submitter: "alice"}}, nil)
+			mockTx.EXPECT().Commit().Return(nil)
+			mockTx.EXPECT().Close().Return(nil)
+		}
+
+		server := NewTrillianLogRPCServer(extension.Registry{LogStorage: mockStorage}, fakeTimeSource)
+		rsp, err := server.GetLeafSubmitters(auth.NewContext(context.Background(), test.caller), test.req)
+		if got := grpc.Code(err); got != test.wantCode {
+			t.Errorf("%s: GetLeafSubmitters()=_,%v; want code %v", test.desc, err, test.wantCode)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if len(rsp.Leaves) != 1 || rsp.Leaves[0].Submitter != "alice" {
+			t.Errorf("%s: GetLeafSubmitters()=%v,nil; want leaf submitted by alice", test.desc, rsp)
+		}
+	}
+}
+
+func TestListDeadLetterLeaves(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	admin := auth.Caller{Identity: "root", Admin: true}
+	deadLetter := &trillian.DeadLetterLeaf{Leaf: &trillian.LogLeaf{LeafValue: leaf1Data, Submitter: "alice"}, Error: "duplicate entry"}
+	for _, test := range []struct {
+		desc      string
+		caller    auth.Caller
+		req       *trillian.ListDeadLetterLeavesRequest
+		wantLimit int
+		wantCode  codes.Code
+	}{
+		{desc: "anonymous", req: &trillian.ListDeadLetterLeavesRequest{LogId: logID1}, wantCode: codes.PermissionDenied},
+		{desc: "not admin", caller: auth.Caller{Identity: "alice"}, req: &trillian.ListDeadLetterLeavesRequest{LogId: logID1}, wantCode: codes.PermissionDenied},
+		{desc: "negative max", caller: admin, req: &trillian.ListDeadLetterLeavesRequest{LogId: logID1, MaxLeaves: -1}, wantCode: codes.InvalidArgument},
+		{desc: "default max", caller: admin, req: &trillian.ListDeadLetterLeavesRequest{LogId: logID1}, wantLimit: defaultMaxDeadLetterLeaves, wantCode: codes.OK},
+		{desc: "max", caller: admin, req: &trillian.ListDeadLetterLeavesRequest{LogId: logID1, MaxLeaves: 5}, wantLimit: 5, wantCode: codes.OK},
+	} {
+		mockStorage := storage.NewMockLogStorage(ctrl)
+		if test.wantCode == codes.OK {
+			mockTx := storage.NewMockLogTreeTX(ctrl)
+			mockStorage.EXPECT().BeginForTree(gomock.Any(), logID1).Return(mockTx, nil)
+			mockTx.EXPECT().GetDeadLetterLeaves(test.wantLimit).Return([]*trillian.DeadLetterLeaf{deadLetter}, nil)
+			mockTx.EXPECT().Commit().Return(nil)
+			mockTx.EXPECT().Close().Return(nil)
+		}
+
+		server := NewTrillianLogRPCServer(extension.Registry{LogStorage: mockStorage}, fakeTimeSource)
+		rsp, err := server.ListDeadLetterLeaves(auth.NewContext(context.Background(), test.caller), test.req)
+		if got := grpc.Code(err); got != test.wantCode {
+			t.Errorf("%s: ListDeadLetterLeaves()=_,%v; want code %v", test.desc, err, test.wantCode)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if len(rsp.Leaves) != 1 || !proto.Equal(rsp.Leaves[0], deadLetter) {
+			t.Errorf("%s: ListDeadLetterLeaves()=%v,nil; want %v", test.desc, rsp, deadLetter)
+		}
+	}
+}
+
+func TestRequeueDeadLetterLeaves(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hashes := [][]byte{[]byte("leafhash")}
+	for _, test := range []struct {
+		desc     string
+		caller   auth.Caller
+		req      *trillian.RequeueDeadLetterLeavesRequest
+		wantCode codes.Code
+	}{
+		{desc: "not admin", caller: auth.Caller{Identity: "alice"}, req: &trillian.RequeueDeadLetterLeavesRequest{LogId: logID1, LeafIdentityHash: hashes}, wantCode: codes.PermissionDenied},
+		{desc: "no hashes", caller: auth.Caller{Identity: "root", Admin: true}, req: &trillian.RequeueDeadLetterLeavesRequest{LogId: logID1}, wantCode: codes.InvalidArgument},
+		{desc: "empty hash", caller: auth.Caller{Identity: "root", Admin: true}, req: &trillian.RequeueDeadLetterLeavesRequest{LogId: logID1, LeafIdentityHash: [][]byte{{}}}, wantCode: codes.InvalidArgument},
+		{desc: "admin", caller: auth.Caller{Identity: "root", Admin: true}, req: &trillian.RequeueDeadLetterLeavesRequest{LogId: logID1, LeafIdentityHash: hashes}, wantCode: codes.OK},
+	} {
+		mockStorage := storage.NewMockLogStorage(ctrl)
+		if test.wantCode == codes.OK {
+			mockTx := storage.NewMockLogTreeTX(ctrl)
+			mockStorage.EXPECT().BeginForTree(gomock.Any(), logID1).Return(mockTx, nil)
+			mockTx.EXPECT().RequeueDeadLetterLeaves(hashes, fakeTime).Return(nil)
+			mockTx.EXPECT().Commit().Return(nil)
+			mockTx.EXPECT().Close().Return(nil)
+		}
+
+		server := NewTrillianLogRPCServer(extension.Registry{LogStorage: mockStorage}, fakeTimeSource)
+		_, err := server.RequeueDeadLetterLeaves(auth.NewContext(context.Background(), test.caller), test.req)
+		if got := grpc.Code(err); got != test.wantCode {
+			t.Errorf("%s: RequeueDeadLetterLeaves()=_,%v; want code %v", test.desc, err, test.wantCode)
+		}
+	}
+}
+
+func TestDiscardDeadLetterLeaves(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hashes := [][]byte{[]byte("leafhash")}
+	for _, test := range []struct {
+		desc     string
+		caller   auth.Caller
+		req      *trillian.DiscardDeadLetterLeavesRequest
+		wantCode codes.Code
+	}{
+		{desc: "not admin", caller: auth.Caller{Identity: "alice"}, req: &trillian.DiscardDeadLetterLeavesRequest{LogId: logID1, LeafIdentityHash: hashes}, wantCode: codes.PermissionDenied},
+		{desc: "no hashes", caller: auth.Caller{Identity: "root", Admin: true}, req: &trillian.DiscardDeadLetterLeavesRequest{LogId: logID1}, wantCode: codes.InvalidArgument},
+		{desc: "admin", caller: auth.Caller{Identity: "root", Admin: true}, req: &trillian.DiscardDeadLetterLeavesRequest{LogId: logID1, LeafIdentityHash: hashes}, wantCode: codes.OK},
+	} {
+		mockStorage := storage.NewMockLogStorage(ctrl)
+		if test.wantCode == codes.OK {
+			mockTx := storage.NewMockLogTreeTX(ctrl)
+			mockStorage.EXPECT().BeginForTree(gomock.Any(), logID1).Return(mockTx, nil)
+			mockTx.EXPECT().DiscardDeadLetterLeaves(hashes).Return(nil)
+			mockTx.EXPECT().Commit().Return(nil)
+			mockTx.EXPECT().Close().Return(nil)
+		}
+
+		server := NewTrillianLogRPCServer(extension.Registry{LogStorage: mockStorage}, fakeTimeSource)
+		_, err := server.DiscardDeadLetterLeaves(auth.NewContext(context.Background(), test.caller), test.req)
+		if got := grpc.Code(err); got != test.wantCode {
+			t.Errorf("%s: DiscardDeadLetterLeaves()=_,%v; want code %v", test.desc, err, test.wantCode)
+		}
+	}
+}
+
+func TestGetLatestSignedLogRootUninitialised(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	mockStorage.EXPECT().SnapshotForTree(gomock.Any(), getLogRootRequest1.LogId).Return(mockTx, nil)
+	mockTx.EXPECT().LatestSignedLogRoot().Return(trillian.SignedLogRoot{}, nil)
+	mockTx.EXPECT().Commit().Return(nil)
+	mockTx.EXPECT().Close().Return(nil)
+
+	server := NewTrillianLogRPCServer(extension.Registry{LogStorage: mockStorage}, fakeTimeSource)
+	_, err := server.GetLatestSignedLogRoot(context.Background(), &getLogRootRequest1)
+	if got, want := grpc.Code(err), codes.FailedPrecondition; got != want {
+		t.Errorf("GetLatestSignedLogRoot()=_,%v; want code %v", err, want)
+	}
+}
+
+func TestWaitForInclusionNoHash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	registry := extension.Registry{LogStorage: storage.NewMockLogStorage(ctrl)}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+	_, err := server.WaitForInclusion(context.Background(), &trillian.WaitForInclusionRequest{LogId: logID1})
+	if got, want := grpc.Code(err), codes.InvalidArgument; got != want {
+		t.Errorf("WaitForInclusion()=%v, want code %v", err, want)
+	}
+}
+
+func TestWaitForInclusionNegativeMaxWait(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	registry := extension.Registry{LogStorage: storage.NewMockLogStorage(ctrl)}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+	_, err := server.WaitForInclusion(context.Background(), &trillian.WaitForInclusionRequest{LogId: logID1, LeafHash: []byte("ahash"), MaxWaitMillis: -1})
+	if got, want := grpc.Code(err), codes.InvalidArgument; got != want {
+		t.Errorf("WaitForInclusion()=%v, want code %v", err, want)
+	}
+}
+
+func TestWaitForInclusion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// The leaf isn't in the log at first, and is integrated by the next root.
+	signedRoot0 := trillian.SignedLogRoot{TimestampNanos: 987654320, RootHash: []byte("AN OLD HASH"), TreeSize: 2, TreeRevision: revision1 - 1}
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	mockStorage.EXPECT().SnapshotForTree(gomock.Any(), logID1).AnyTimes().Return(mockTx, nil)
+	mockTx.EXPECT().LatestSignedLogRoot().Return(signedRoot0, nil)
+	mockTx.EXPECT().LatestSignedLogRoot().AnyTimes().Return(signedRoot1, nil)
+	mockTx.EXPECT().GetLeavesByHash([][]byte{[]byte("ahash")}, true).Return(nil, nil)
+	mockTx.EXPECT().GetLeavesByHash([][]byte{[]byte("ahash")}, true).Return([]*trillian.LogLeaf{{LeafIndex: 2}}, nil)
+	mockTx.EXPECT().ReadRevision().Return(signedRoot1.TreeRevision)
+	mockTx.EXPECT().GetMerkleNodes(revision1, nodeIdsInclusionSize7Index2).Return([]storage.Node{
+		{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3, Hash: []byte("nodehash0")},
+		{NodeID: nodeIdsInclusionSize7Index2[1], NodeRevision: 2, Hash: []byte("nodehash1")},
+		{NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3, Hash: []byte("nodehash2")}}, nil)
+	mockTx.EXPECT().Commit().AnyTimes().Return(nil)
+	mockTx.EXPECT().Close().AnyTimes().Return(nil)
+
+	registry := extension.Registry{LogStorage: mockStorage}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+	server.SetRootPollInterval(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	rsp, err := server.WaitForInclusion(ctx, &trillian.WaitForInclusionRequest{LogId: logID1, LeafHash: []byte("ahash")})
+	if err != nil {
+		t.Fatalf("WaitForInclusion()=%v, want no error", err)
+	}
+	if got, want := rsp.LeafIndex, int64(2); got != want {
+		t.Errorf("WaitForInclusion().LeafIndex=%d, want %d", got, want)
+	}
+	if !proto.Equal(rsp.SignedLogRoot, &signedRoot1) {
+		t.Errorf("WaitForInclusion().SignedLogRoot=%v, want %v", rsp.SignedLogRoot, signedRoot1)
+	}
+	if got, want := len(rsp.Proof.ProofNode), len(nodeIdsInclusionSize7Index2); got != want {
+		t.Errorf("WaitForInclusion() returned proof with %d nodes, want %d", got, want)
+	}
+}
+
+func TestWaitForInclusionDeadlineExceeded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	mockStorage.EXPECT().SnapshotForTree(gomock.Any(), logID1).AnyTimes().Return(mockTx, nil)
+	mockTx.EXPECT().LatestSignedLogRoot().AnyTimes().Return(signedRoot1, nil)
+	mockTx.EXPECT().GetLeavesByHash([][]byte{[]byte("ahash")}, true).Return(nil, nil)
+	mockTx.EXPECT().Commit().AnyTimes().Return(nil)
+	mockTx.EXPECT().Close().AnyTimes().Return(nil)
+
+	registry := extension.Registry{LogStorage: mockStorage}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+	server.SetRootPollInterval(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := server.WaitForInclusion(ctx, &trillian.WaitForInclusionRequest{LogId: logID1, LeafHash: []byte("ahash")})
+	if got, want := grpc.Code(err), codes.DeadlineExceeded; got != want {
+		t.Errorf("WaitForInclusion()=%v, want code %v", err, want)
+	}
+}
+
+func TestWaitForInclusionMaxWait(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	mockStorage.EXPECT().SnapshotForTree(gomock.Any(), logID1).AnyTimes().Return(mockTx, nil)
+	mockTx.EXPECT().LatestSignedLogRoot().AnyTimes().Return(signedRoot1, nil)
+	mockTx.EXPECT().GetLeavesByHash([][]byte{[]byte("ahash")}, true).Return(nil, nil)
+	mockTx.EXPECT().Commit().AnyTimes().Return(nil)
+	mockTx.EXPECT().Close().AnyTimes().Return(nil)
+
+	registry := extension.Registry{LogStorage: mockStorage}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+	server.SetRootPollInterval(10 * time.Millisecond)
+	server.SetMaxInclusionWait(50 * time.Millisecond)
+
+	// The RPC has no deadline, so only the server's maximum stops it waiting.
+	_, err := server.WaitForInclusion(context.Background(), &trillian.WaitForInclusionRequest{LogId: logID1, LeafHash: []byte("ahash")})
+	if got, want := grpc.Code(err), codes.DeadlineExceeded; got != want {
+		t.Errorf("WaitForInclusion()=%v, want code %v", err, want)
+	}
+}
+
+func TestWaitForInclusionRequestMaxWait(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	mockStorage.EXPECT().SnapshotForTree(gomock.Any(), logID1).AnyTimes().Return(mockTx, nil)
+	mockTx.EXPECT().LatestSignedLogRoot().AnyTimes().Return(signedRoot1, nil)
+	mockTx.EXPECT().GetLeavesByHash([][]byte{[]byte("ahash")}, true).Return(nil, nil)
+	mockTx.EXPECT().Commit().AnyTimes().Return(nil)
+	mockTx.EXPECT().Close().AnyTimes().Return(nil)
+
+	registry := extension.Registry{LogStorage: mockStorage}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+	server.SetRootPollInterval(10 * time.Millisecond)
+
+	// Neither the RPC nor the server's maximum would stop it waiting for a minute.
+	start := time.Now()
+	_, err := server.WaitForInclusion(context.Background(), &trillian.WaitForInclusionRequest{LogId: logID1, LeafHash: []byte("ahash"), MaxWaitMillis: 50})
+	if got, want := grpc.Code(err), codes.DeadlineExceeded; got != want {
+		t.Errorf("WaitForInclusion()=%v, want code %v", err, want)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("WaitForInclusion() waited %v, want about 50ms", elapsed)
+	}
+}
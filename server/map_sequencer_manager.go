@@ -0,0 +1,98 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/server/vmap"
+	"github.com/google/trillian/util"
+)
+
+// MapSequencerManager writes the leaves queued by asynchronous map writes to a
+// collection of Maps. It should be run by a LogOperationManager created with
+// NewMapOperationManager, in one process only, as trillian_map_sequencer does:
+// concurrent sequencers for a map race, and all but one fail to commit.
+type MapSequencerManager struct {
+	registry          extension.Registry
+	mutationRetention time.Duration
+}
+
+// NewMapSequencerManager creates a new MapSequencerManager instance.
+func NewMapSequencerManager(registry extension.Registry) *MapSequencerManager {
+	return &MapSequencerManager{
+		registry:          registry,
+		mutationRetention: vmap.DefaultMutationRetention,
+	}
+}
+
+// SetMutationRetention sets how long mutations are kept after they have been
+// written, see vmap.MapSequencer.SetMutationRetention.
+func (m *MapSequencerManager) SetMutationRetention(retention time.Duration) {
+	m.mutationRetention = retention
+}
+
+// Name returns the name of the object.
+func (m MapSequencerManager) Name() string {
+	return "MapSequencer"
+}
+
+// ExecutePass writes a batch of queued mutations to each of the specified Maps.
+func (m MapSequencerManager) ExecutePass(mapIDs []int64, logctx LogOperationManagerContext) {
+	glog.V(1).Infof("Beginning map sequencing run for %v active map(s)", len(mapIDs))
+
+	startBatch := time.Now()
+	successCount := 0
+	mutationsApplied := 0
+
+	for _, mapID := range mapIDs {
+		ctx := util.NewMapContext(logctx.ctx, mapID)
+
+		// TODO(Martin2112): Allow for different tree hashers to be used by different maps
+		hasher, err := merkle.Factory(merkle.RFC6962SHA256Type)
+		if err != nil {
+			glog.Errorf("Unknown hash strategy for map %d: %v", mapID, err)
+			continue
+		}
+
+		signer, _, err := newSigners(ctx, m.registry, mapID, nil)
+		if err != nil {
+			glog.Errorf("Could not get signer for map %d: %v", mapID, err)
+			continue
+		}
+
+		sequencer := vmap.NewMapSequencer(merkle.NewMapHasher(hasher), logctx.timeSource, m.registry.MapStorage, signer)
+		sequencer.SetMutationRetention(m.mutationRetention)
+
+		mutations, err := sequencer.SequenceBatch(ctx, mapID, logctx.batchSize)
+		if err != nil {
+			glog.Warningf("%v: Error trying to sequence map mutations: %v", mapID, err)
+			continue
+		}
+		if mutations > 0 {
+			glog.Infof("%v: wrote %d queued mutations", mapID, mutations)
+		} else {
+			glog.V(1).Infof("%v: no mutations to write", mapID)
+		}
+		successCount++
+		mutationsApplied += mutations
+	}
+
+	d := time.Now().Sub(startBatch).Seconds()
+	glog.V(1).Infof("Map sequencing run completed in %.2f seconds: %v succeeded, %v failed, %v mutations written", d, successCount, len(mapIDs)-successCount, mutationsApplied)
+}
@@ -0,0 +1,158 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian/storage"
+	"golang.org/x/net/context"
+)
+
+// RootNotifier is told when a new root has been signed for a log by a sequencer
+// in the same process, so that it doesn't have to wait to find out from storage.
+type RootNotifier interface {
+	RootUpdated(logID int64)
+}
+
+// watchedLog is the state of a log which has RPCs waiting for its root to grow.
+type watchedLog struct {
+	waiters  int
+	treeSize int64
+	// changed is closed, and replaced, whenever treeSize grows.
+	changed chan struct{}
+	// wake makes the poller read the root without waiting for its next tick.
+	wake chan struct{}
+}
+
+// rootWatcher lets RPCs wait for a log's signed root to grow. However many RPCs
+// are waiting on a log, a single goroutine polls its root from storage, and is
+// woken early when a sequencer in the same process reports a new root through
+// notify.
+type rootWatcher struct {
+	ls           storage.LogStorage
+	pollInterval time.Duration
+
+	mu   sync.Mutex
+	logs map[int64]*watchedLog
+}
+
+func newRootWatcher(ls storage.LogStorage, pollInterval time.Duration) *rootWatcher {
+	return &rootWatcher{
+		ls:           ls,
+		pollInterval: pollInterval,
+		logs:         make(map[int64]*watchedLog),
+	}
+}
+
+// wait blocks until logID has a signed root for a tree bigger than treeSize,
+// or until ctx is done.
+func (w *rootWatcher) wait(ctx context.Context, logID, treeSize int64) error {
+	w.mu.Lock()
+	l, ok := w.logs[logID]
+	if !ok {
+		l = &watchedLog{
+			treeSize: treeSize,
+			changed:  make(chan struct{}),
+			wake:     make(chan struct{}, 1),
+		}
+		w.logs[logID] = l
+		go w.poll(logID, l)
+	}
+	l.waiters++
+	defer func() {
+		w.mu.Lock()
+		l.waiters--
+		w.mu.Unlock()
+	}()
+
+	for l.treeSize <= treeSize {
+		changed := l.changed
+		w.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		w.mu.Lock()
+	}
+	w.mu.Unlock()
+	return nil
+}
+
+// notify makes the poller of logID, if there is one, read its root now.
+func (w *rootWatcher) notify(logID int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if l, ok := w.logs[logID]; ok {
+		select {
+		case l.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// poll reads the root of logID until nothing is waiting on it any more.
+func (w *rootWatcher) poll(logID int64, l *watchedLog) {
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-l.wake:
+		}
+
+		w.mu.Lock()
+		if l.waiters == 0 {
+			delete(w.logs, logID)
+			w.mu.Unlock()
+			return
+		}
+		w.mu.Unlock()
+
+		treeSize, err := w.latestTreeSize(logID)
+		if err != nil {
+			glog.Warningf("%v: failed to read root for waiters: %v", logID, err)
+			continue
+		}
+
+		w.mu.Lock()
+		if treeSize > l.treeSize {
+			l.treeSize = treeSize
+			close(l.changed)
+			l.changed = make(chan struct{})
+		}
+		w.mu.Unlock()
+	}
+}
+
+func (w *rootWatcher) latestTreeSize(logID int64) (int64, error) {
+	tx, err := w.ls.SnapshotForTree(context.Background(), logID)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Close()
+
+	root, err := tx.LatestSignedLogRoot()
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return root.TreeSize, nil
+}
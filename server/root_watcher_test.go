@@ -0,0 +1,74 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/trillian"
+	"github.com/google/trillian/storage"
+)
+
+func TestRootWatcherNotify(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	mockStorage.EXPECT().SnapshotForTree(gomock.Any(), logID1).AnyTimes().Return(mockTx, nil)
+	mockTx.EXPECT().LatestSignedLogRoot().AnyTimes().Return(trillian.SignedLogRoot{TreeSize: 8}, nil)
+	mockTx.EXPECT().Commit().AnyTimes().Return(nil)
+	mockTx.EXPECT().Close().AnyTimes().Return(nil)
+
+	// The watcher never polls on its own during the test, so it only finds
+	// the new root when notified.
+	w := newRootWatcher(mockStorage, time.Hour)
+	done := make(chan error)
+	go func() {
+		done <- w.wait(context.Background(), logID1, 7)
+	}()
+
+	timeout := time.After(10 * time.Second)
+	for {
+		// The waiter may not be registered yet, in which case the
+		// notification is dropped, so keep notifying.
+		w.notify(logID1)
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("wait()=%v, want no error", err)
+			}
+			return
+		case <-timeout:
+			t.Fatal("wait() didn't return after notify()")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestRootWatcherContextDone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	w := newRootWatcher(storage.NewMockLogStorage(ctrl), time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := w.wait(ctx, logID1, 7); err != context.Canceled {
+		t.Errorf("wait()=%v, want %v", err, context.Canceled)
+	}
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/golang/glog"
 	"github.com/google/trillian/crypto"
+	"github.com/google/trillian/crypto/keys"
 	"github.com/google/trillian/extension"
 	"github.com/google/trillian/log"
 	"github.com/google/trillian/merkle"
@@ -32,6 +33,36 @@ import (
 type SequencerManager struct {
 	guardWindow time.Duration
 	registry    extension.Registry
+	// cosignerFactories provide additional signers for log roots, see
+	// log.Sequencer.SetCosigners.
+	cosignerFactories []keys.SignerFactory
+	minSignatures     int
+	maxClockSkew      time.Duration
+	minLaneShare      float64
+	rootNotifier      RootNotifier
+	// deadLetterAfter is how many consecutive passes a log's batch must fail
+	// before its failing leaves are dead-lettered, or zero to never do so.
+	deadLetterAfter int
+	failures        *batchFailures
+}
+
+// batchFailures counts the consecutive failed sequencing passes of each log.
+type batchFailures struct {
+	mu     sync.Mutex
+	counts map[int64]int
+}
+
+// record updates the count of consecutive failed passes for the log after a pass,
+// and returns it.
+func (b *batchFailures) record(logID int64, failed bool) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !failed {
+		delete(b.counts, logID)
+		return 0
+	}
+	b.counts[logID]++
+	return b.counts[logID]
 }
 
 // NewSequencerManager creates a new SequencerManager instance based on the provided KeyManager instance
@@ -40,9 +71,44 @@ func NewSequencerManager(registry extension.Registry, gw time.Duration) *Sequenc
 	return &SequencerManager{
 		guardWindow: gw,
 		registry:    registry,
+		failures:    &batchFailures{counts: make(map[int64]int)},
 	}
 }
 
+// SetCosignerFactories configures additional signers for log roots. Each log's
+// roots are signed by its signer from the registry and by a signer from each of
+// cosignerFactories, and must get at least minSignatures signatures in total.
+func (s *SequencerManager) SetCosignerFactories(cosignerFactories []keys.SignerFactory, minSignatures int) {
+	s.cosignerFactories = cosignerFactories
+	s.minSignatures = minSignatures
+}
+
+// SetMaxClockSkew sets how far the clock may be behind a log's timestamps before
+// its sequencer refuses to sign roots, see log.Sequencer.SetMaxClockSkew.
+func (s *SequencerManager) SetMaxClockSkew(maxClockSkew time.Duration) {
+	s.maxClockSkew = maxClockSkew
+}
+
+// SetMinLaneShare sets the fraction of each batch reserved for leaves from each lower
+// priority lane, see log.Sequencer.SetMinLaneShare.
+func (s *SequencerManager) SetMinLaneShare(minLaneShare float64) {
+	s.minLaneShare = minLaneShare
+}
+
+// SetDeadLetterAfter sets how many consecutive passes a log's batch must fail to be
+// sequenced before the leaves causing the failures are dead-lettered, see
+// log.Sequencer.IsolateFailingLeaves. The default of zero means they never are.
+func (s *SequencerManager) SetDeadLetterAfter(failedPasses int) {
+	s.deadLetterAfter = failedPasses
+}
+
+// SetRootNotifier sets a RootNotifier to be told whenever leaves are integrated into a
+// log. It must be in the same process, e.g. a TrillianLogRPCServer in an integration
+// test; servers in other processes aren't notified.
+func (s *SequencerManager) SetRootNotifier(rootNotifier RootNotifier) {
+	s.rootNotifier = rootNotifier
+}
+
 // Name returns the name of the object.
 func (s SequencerManager) Name() string {
 	return "Sequencer"
@@ -93,7 +159,7 @@ func (s SequencerManager) ExecutePass(logIDs []int64, logctx LogOperationManager
 					continue
 				}
 
-				signer, err := newSigner(ctx, s.registry, logID)
+				signer, cosigners, err := newSigners(ctx, s.registry, logID, s.cosignerFactories)
 				if err != nil {
 					glog.Errorf("Could not get signer for log %d: %v", logID, err)
 					continue
@@ -101,15 +167,25 @@ func (s SequencerManager) ExecutePass(logIDs []int64, logctx LogOperationManager
 
 				sequencer := log.NewSequencer(hasher, logctx.timeSource, s.registry.LogStorage, signer)
 				sequencer.SetGuardWindow(s.guardWindow)
+				sequencer.SetMaxClockSkew(s.maxClockSkew)
+				sequencer.SetMinLaneShare(s.minLaneShare)
+				sequencer.SetCosigners(cosigners, s.minSignatures)
 
 				leaves, err := sequencer.SequenceBatch(ctx, logID, logctx.batchSize)
+				failures := s.failures.record(logID, err != nil)
 				if err != nil {
 					glog.Warningf("%v: Error trying to sequence batch for: %v", logID, err)
+					if s.deadLetterAfter > 0 && failures >= s.deadLetterAfter {
+						s.isolateFailingLeaves(ctx, sequencer, logID, logctx.batchSize)
+					}
 					continue
 				}
 				if leaves > 0 {
 					d := time.Now().Sub(start).Seconds()
 					glog.Infof("%v: sequenced %d leaves in %.2f seconds (%.2f qps)", logID, leaves, d, float64(leaves)/d)
+					if s.rootNotifier != nil {
+						s.rootNotifier.RootUpdated(logID)
+					}
 				} else {
 					glog.V(1).Infof("%v: no leaves to sequence", logID)
 				}
@@ -130,33 +206,59 @@ func (s SequencerManager) ExecutePass(logIDs []int64, logctx LogOperationManager
 	glog.V(1).Infof("Sequencing group run completed in %.2f seconds: %v succeeded, %v failed, %v leaves integrated", d, successCount, len(logIDs)-successCount, leavesAdded)
 }
 
-func newSigner(ctx context.Context, registry extension.Registry, logID int64) (*crypto.Signer, error) {
+// isolateFailingLeaves dead-letters the leaves which keep a log's batch from being
+// sequenced, so that the next pass can make progress.
+func (s SequencerManager) isolateFailingLeaves(ctx context.Context, sequencer *log.Sequencer, logID int64, batchSize int) {
+	n, err := sequencer.IsolateFailingLeaves(ctx, logID, batchSize)
+	if err != nil {
+		glog.Warningf("%v: Error trying to isolate failing leaves: %v", logID, err)
+		return
+	}
+	if n > 0 {
+		glog.Warningf("%v: dead-lettered %d leaves which failed sequencing", logID, n)
+		s.failures.record(logID, false)
+	}
+}
+
+// newSigners returns the signer for a log from the registry, and the signers
+// from those of cosignerFactories which can provide one.
+func newSigners(ctx context.Context, registry extension.Registry, logID int64, cosignerFactories []keys.SignerFactory) (*crypto.Signer, []*crypto.Signer, error) {
 	if registry.AdminStorage == nil {
-		return nil, fmt.Errorf("no AdminStorage provided by registry")
+		return nil, nil, fmt.Errorf("no AdminStorage provided by registry")
 	}
 	if registry.SignerFactory == nil {
-		return nil, fmt.Errorf("no SignerFactory provided by registry")
+		return nil, nil, fmt.Errorf("no SignerFactory provided by registry")
 	}
 
 	snapshot, err := registry.AdminStorage.Snapshot(ctx)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer snapshot.Close()
 
 	tree, err := snapshot.GetTree(ctx, logID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if err := snapshot.Commit(); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	signer, err := registry.SignerFactory.NewSigner(ctx, tree)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return crypto.NewSigner(signer), nil
+	var cosigners []*crypto.Signer
+	for _, f := range cosignerFactories {
+		cosigner, err := f.NewSigner(ctx, tree)
+		if err != nil {
+			// The sequencer checks whether there are still enough signers.
+			glog.Warningf("%v: could not get cosigner: %v", logID, err)
+			continue
+		}
+		cosigners = append(cosigners, crypto.NewSigner(cosigner))
+	}
+	return crypto.NewSigner(signer), cosigners, nil
 }
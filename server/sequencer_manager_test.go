@@ -62,7 +62,7 @@ var testRoot0 = trillian.SignedLogRoot{
 }
 var updatedNodes0 = []storage.Node{{NodeID: storage.NodeID{Path: []uint8{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, PrefixLenBits: 64, PathLenBits: 64}, Hash: testonly.MustDecodeBase64("bjQLnP+zepicpUTmu3gKLHiQHT+zNzh2hRGjBhevoB0="), NodeRevision: 1}}
 var updatedRoot = trillian.SignedLogRoot{
-	LogId:          testLogID1,
+	LogId:          stestonly.LogTree.GetTreeId(),
 	TimestampNanos: fakeTime.UnixNano(),
 	RootHash:       []byte{110, 52, 11, 156, 255, 179, 122, 152, 156, 165, 68, 230, 187, 120, 10, 44, 120, 144, 29, 63, 179, 55, 56, 118, 133, 17, 163, 6, 23, 175, 160, 29},
 	TreeSize:       1,
@@ -255,3 +255,23 @@ func createTestContext(registry extension.Registry) LogOperationManagerContext {
 		timeSource:       fakeTimeSource,
 	}
 }
+
+func TestBatchFailuresRecord(t *testing.T) {
+	b := &batchFailures{counts: make(map[int64]int)}
+	for i, test := range []struct {
+		logID  int64
+		failed bool
+		want   int
+	}{
+		{logID: 1, failed: true, want: 1},
+		{logID: 1, failed: true, want: 2},
+		{logID: 2, failed: true, want: 1},
+		{logID: 1, failed: false, want: 0},
+		{logID: 1, failed: true, want: 1},
+		{logID: 2, failed: true, want: 2},
+	} {
+		if got := b.record(test.logID, test.failed); got != test.want {
+			t.Errorf("%d: record(%d, %v)=%d, want %d", i, test.logID, test.failed, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"bytes"
+	gocrypto "crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto"
+	"github.com/google/trillian/crypto/signerpb"
+	"github.com/google/trillian/crypto/sigpb"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/server/errors"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// Options control the signing policy of a Server.
+type Options struct {
+	// AllowRawDigests permits Sign requests which don't include a log root for
+	// trees which aren't logs, e.g. for signing map roots. The policy checks
+	// can't be applied to these. Requests for logs must always include a root.
+	AllowRawDigests bool
+}
+
+// treeState holds the signer and policy state of a single tree.
+type treeState struct {
+	mu       sync.Mutex
+	treeType trillian.TreeType
+	signer   gocrypto.Signer
+	// lastRoot is the most recent log root signed for the tree, or nil if none
+	// has been signed or read from storage yet.
+	lastRoot *trillian.SignedLogRoot
+}
+
+// Server is an implementation of signerpb.TrillianSignerServer.
+// It signs roots only for active trees, and log roots only if they're for the
+// log being signed for and don't go backwards in tree size or time compared to
+// the last root it signed for the same log. Logs are only signed for with a
+// root, so that the checks can't be skipped. The last root is kept in memory,
+// and is read from log storage (if available) when a log is first seen. Note
+// that a signed root which is never stored, e.g. because the sequencer failed
+// to commit it, still moves the policy on.
+type Server struct {
+	registry extension.Registry
+	opts     Options
+
+	mu    sync.Mutex
+	trees map[int64]*treeState
+}
+
+// New returns a signerpb.TrillianSignerServer implementation.
+// The registry must provide AdminStorage and a SignerFactory; LogStorage is
+// optional.
+func New(registry extension.Registry, opts Options) *Server {
+	return &Server{
+		registry: registry,
+		opts:     opts,
+		trees:    make(map[int64]*treeState),
+	}
+}
+
+// GetPublicKey implements signerpb.TrillianSignerServer.GetPublicKey.
+func (s *Server) GetPublicKey(ctx context.Context, req *signerpb.GetPublicKeyRequest) (*signerpb.GetPublicKeyResponse, error) {
+	ts, err := s.treeState(ctx, req.GetTreeId())
+	if err != nil {
+		return nil, errors.WrapError(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(ts.signer.Public())
+	if err != nil {
+		return nil, grpc.Errorf(codes.Internal, "failed to marshal public key of tree %d: %v", req.GetTreeId(), err)
+	}
+	return &signerpb.GetPublicKeyResponse{PublicKeyDer: der}, nil
+}
+
+// Sign implements signerpb.TrillianSignerServer.Sign.
+func (s *Server) Sign(ctx context.Context, req *signerpb.SignRequest) (*signerpb.SignResponse, error) {
+	if req.GetHashAlgorithm() != sigpb.DigitallySigned_SHA256 {
+		return nil, grpc.Errorf(codes.InvalidArgument, "unsupported hash algorithm: %v", req.GetHashAlgorithm())
+	}
+	if got, want := len(req.GetDigest()), sha256.Size; got != want {
+		return nil, grpc.Errorf(codes.InvalidArgument, "digest is %d bytes, want %d", got, want)
+	}
+
+	ts, err := s.treeState(ctx, req.GetTreeId())
+	if err != nil {
+		return nil, errors.WrapError(err)
+	}
+	if req.GetLogRoot() == nil && (ts.treeType == trillian.TreeType_LOG || !s.opts.AllowRawDigests) {
+		return nil, grpc.Errorf(codes.InvalidArgument, "signing requests for tree %d must include a log root", req.GetTreeId())
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	root := req.GetLogRoot()
+	if root != nil {
+		if err := checkLogRoot(req.GetTreeId(), req.GetDigest(), root, ts.lastRoot); err != nil {
+			glog.Warningf("%v: refused to sign log root: %v", req.GetTreeId(), err)
+			return nil, err
+		}
+	}
+
+	sig, err := ts.signer.Sign(rand.Reader, req.GetDigest(), gocrypto.SHA256)
+	if err != nil {
+		return nil, grpc.Errorf(codes.Internal, "failed to sign for tree %d: %v", req.GetTreeId(), err)
+	}
+	if root != nil {
+		ts.lastRoot = root
+	}
+	return &signerpb.SignResponse{Signature: sig}, nil
+}
+
+// checkLogRoot returns an error if digest isn't the digest of root, or if root
+// goes backwards from the previously signed lastRoot, which may be nil.
+func checkLogRoot(treeID int64, digest []byte, root, lastRoot *trillian.SignedLogRoot) error {
+	if root.LogId != treeID {
+		return grpc.Errorf(codes.InvalidArgument, "log root is for log %d, not %d", root.LogId, treeID)
+	}
+	if want := sha256.Sum256(crypto.HashLogRoot(*root)); !bytes.Equal(digest, want[:]) {
+		return grpc.Errorf(codes.InvalidArgument, "digest doesn't match log root")
+	}
+	if lastRoot == nil {
+		return nil
+	}
+	if root.TreeSize < lastRoot.TreeSize {
+		return grpc.Errorf(codes.FailedPrecondition, "tree size %d is smaller than previously signed size %d", root.TreeSize, lastRoot.TreeSize)
+	}
+	switch {
+	case root.TimestampNanos < lastRoot.TimestampNanos:
+		return grpc.Errorf(codes.FailedPrecondition, "timestamp %d is before previously signed timestamp %d", root.TimestampNanos, lastRoot.TimestampNanos)
+	case root.TimestampNanos == lastRoot.TimestampNanos:
+		// Only allow this for a repeated request for the same root.
+		if root.TreeSize != lastRoot.TreeSize || !bytes.Equal(root.RootHash, lastRoot.RootHash) {
+			return grpc.Errorf(codes.FailedPrecondition, "timestamp %d was already signed for a different root", root.TimestampNanos)
+		}
+	}
+	return nil
+}
+
+// treeState returns the state for a tree, creating its signer the first time
+// the tree is seen. Only active logs may be signed for. The tree is read on
+// every request, so that one which is frozen or deleted is no longer signed
+// for as soon as it changes.
+func (s *Server) treeState(ctx context.Context, treeID int64) (*treeState, error) {
+	tree, err := s.getTree(ctx, treeID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case tree.TreeType != trillian.TreeType_LOG && !s.opts.AllowRawDigests:
+		return nil, grpc.Errorf(codes.FailedPrecondition, "tree %d is not a log", treeID)
+	case tree.TreeState != trillian.TreeState_ACTIVE:
+		return nil, grpc.Errorf(codes.FailedPrecondition, "tree %d is not active", treeID)
+	}
+
+	s.mu.Lock()
+	ts, ok := s.trees[treeID]
+	s.mu.Unlock()
+	if ok {
+		return ts, nil
+	}
+
+	signer, err := s.registry.SignerFactory.NewSigner(ctx, tree)
+	if err != nil {
+		return nil, grpc.Errorf(codes.Internal, "failed to create signer for tree %d: %v", treeID, err)
+	}
+	ts = &treeState{treeType: tree.TreeType, signer: signer}
+	if tree.TreeType == trillian.TreeType_LOG && s.registry.LogStorage != nil {
+		if ts.lastRoot, err = s.latestRoot(ctx, treeID); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Another request may have got here first, in which case use its state.
+	if existing, ok := s.trees[treeID]; ok {
+		return existing, nil
+	}
+	s.trees[treeID] = ts
+	return ts, nil
+}
+
+func (s *Server) getTree(ctx context.Context, treeID int64) (*trillian.Tree, error) {
+	tx, err := s.registry.AdminStorage.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+	tree, err := tx.GetTree(ctx, treeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// latestRoot returns the latest root held in storage for a log, or nil if
+// there isn't one.
+func (s *Server) latestRoot(ctx context.Context, treeID int64) (*trillian.SignedLogRoot, error) {
+	tx, err := s.registry.LogStorage.SnapshotForTree(ctx, treeID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+	root, err := tx.LatestSignedLogRoot()
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	// A fresh log has no stored root yet.
+	if root.RootHash == nil {
+		return nil, nil
+	}
+	return &root, nil
+}
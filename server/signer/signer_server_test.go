@@ -0,0 +1,243 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"net"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto"
+	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/crypto/signerpb"
+	"github.com/google/trillian/crypto/sigpb"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/testonly"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	logID = 42
+	mapID = 43
+)
+
+// newTestServer returns a Server for a log and a map using the test key.
+func newTestServer(ctrl *gomock.Controller, opts Options) *Server {
+	logTree := *testonly.LogTree
+	logTree.TreeId = logID
+	mapTree := *testonly.MapTree
+	mapTree.TreeId = mapID
+	mapTree.PrivateKey = logTree.PrivateKey
+
+	as := storage.NewMockAdminStorage(ctrl)
+	tx := storage.NewMockReadOnlyAdminTX(ctrl)
+	as.EXPECT().Snapshot(gomock.Any()).AnyTimes().Return(tx, nil)
+	tx.EXPECT().GetTree(gomock.Any(), int64(logID)).AnyTimes().Return(&logTree, nil)
+	tx.EXPECT().GetTree(gomock.Any(), int64(mapID)).AnyTimes().Return(&mapTree, nil)
+	tx.EXPECT().Commit().AnyTimes().Return(nil)
+	tx.EXPECT().Close().AnyTimes().Return(nil)
+
+	return New(extension.Registry{AdminStorage: as, SignerFactory: keys.PEMSignerFactory{}}, opts)
+}
+
+// startServer serves s on a local port, and returns a client signer for the
+// log using RemoteSignerFactory.
+func startServer(t *testing.T, s *Server) (*crypto.Signer, func()) {
+	grpcServer := grpc.NewServer()
+	signerpb.RegisterTrillianSignerServer(grpcServer, s)
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Listen(): %v", err)
+	}
+	go grpcServer.Serve(lis)
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("Dial(): %v", err)
+	}
+	stop := func() {
+		conn.Close()
+		grpcServer.Stop()
+		lis.Close()
+	}
+	f := keys.RemoteSignerFactory{Client: signerpb.NewTrillianSignerClient(conn)}
+	signer, err := f.NewSigner(context.Background(), &trillian.Tree{TreeId: logID})
+	if err != nil {
+		stop()
+		t.Fatalf("NewSigner(): %v", err)
+	}
+	return crypto.NewSigner(signer), stop
+}
+
+func TestSignLogRoots(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	signer, stop := startServer(t, newTestServer(ctrl, Options{}))
+	defer stop()
+
+	for _, test := range []struct {
+		desc string
+		root trillian.SignedLogRoot
+		want codes.Code
+	}{
+		{desc: "first", root: trillian.SignedLogRoot{TreeSize: 10, TimestampNanos: 100, RootHash: []byte("root10"), LogId: logID}, want: codes.OK},
+		{desc: "retry", root: trillian.SignedLogRoot{TreeSize: 10, TimestampNanos: 100, RootHash: []byte("root10"), LogId: logID}, want: codes.OK},
+		{desc: "same time, different hash", root: trillian.SignedLogRoot{TreeSize: 10, TimestampNanos: 100, RootHash: []byte("fork"), LogId: logID}, want: codes.FailedPrecondition},
+		{desc: "same time, bigger tree", root: trillian.SignedLogRoot{TreeSize: 11, TimestampNanos: 100, RootHash: []byte("root11"), LogId: logID}, want: codes.FailedPrecondition},
+		{desc: "smaller tree", root: trillian.SignedLogRoot{TreeSize: 9, TimestampNanos: 200, RootHash: []byte("root9"), LogId: logID}, want: codes.FailedPrecondition},
+		{desc: "earlier time", root: trillian.SignedLogRoot{TreeSize: 12, TimestampNanos: 90, RootHash: []byte("root12"), LogId: logID}, want: codes.FailedPrecondition},
+		{desc: "same size, later time", root: trillian.SignedLogRoot{TreeSize: 10, TimestampNanos: 150, RootHash: []byte("root10"), LogId: logID}, want: codes.OK},
+		{desc: "wrong log", root: trillian.SignedLogRoot{TreeSize: 12, TimestampNanos: 200, RootHash: []byte("root12"), LogId: logID + 1}, want: codes.InvalidArgument},
+		{desc: "no log", root: trillian.SignedLogRoot{TreeSize: 12, TimestampNanos: 200, RootHash: []byte("root12")}, want: codes.InvalidArgument},
+		{desc: "next", root: trillian.SignedLogRoot{TreeSize: 12, TimestampNanos: 200, RootHash: []byte("root12"), LogId: logID}, want: codes.OK},
+	} {
+		sig, err := signer.SignLogRoot(test.root)
+		if got := grpc.Code(err); got != test.want {
+			t.Errorf("%v: SignLogRoot()=%v, want code %v", test.desc, err, test.want)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if err := crypto.Verify(signer.Public(), crypto.HashLogRoot(test.root), sig); err != nil {
+			t.Errorf("%v: Verify(): %v", test.desc, err)
+		}
+	}
+}
+
+func TestSignChecksRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	root := trillian.SignedLogRoot{TreeSize: 10, TimestampNanos: 100, RootHash: []byte("root10"), LogId: logID}
+	rootDigest := sha256.Sum256(crypto.HashLogRoot(root))
+	otherDigest := sha256.Sum256([]byte("other"))
+
+	for _, test := range []struct {
+		desc string
+		opts Options
+		req  *signerpb.SignRequest
+		want codes.Code
+	}{
+		{
+			desc: "ok",
+			req:  &signerpb.SignRequest{TreeId: logID, Digest: rootDigest[:], HashAlgorithm: sigpb.DigitallySigned_SHA256, LogRoot: &root},
+			want: codes.OK,
+		},
+		{
+			desc: "no hash algorithm",
+			req:  &signerpb.SignRequest{TreeId: logID, Digest: rootDigest[:], LogRoot: &root},
+			want: codes.InvalidArgument,
+		},
+		{
+			desc: "short digest",
+			req:  &signerpb.SignRequest{TreeId: logID, Digest: rootDigest[:20], HashAlgorithm: sigpb.DigitallySigned_SHA256, LogRoot: &root},
+			want: codes.InvalidArgument,
+		},
+		{
+			desc: "digest not of root",
+			req:  &signerpb.SignRequest{TreeId: logID, Digest: otherDigest[:], HashAlgorithm: sigpb.DigitallySigned_SHA256, LogRoot: &root},
+			want: codes.InvalidArgument,
+		},
+		{
+			desc: "raw digest",
+			req:  &signerpb.SignRequest{TreeId: logID, Digest: otherDigest[:], HashAlgorithm: sigpb.DigitallySigned_SHA256},
+			want: codes.InvalidArgument,
+		},
+		{
+			desc: "raw digest allowed, but not for logs",
+			opts: Options{AllowRawDigests: true},
+			req:  &signerpb.SignRequest{TreeId: logID, Digest: otherDigest[:], HashAlgorithm: sigpb.DigitallySigned_SHA256},
+			want: codes.InvalidArgument,
+		},
+		{
+			desc: "map",
+			req:  &signerpb.SignRequest{TreeId: mapID, Digest: rootDigest[:], HashAlgorithm: sigpb.DigitallySigned_SHA256, LogRoot: &root},
+			want: codes.FailedPrecondition,
+		},
+		{
+			desc: "map raw digest allowed",
+			opts: Options{AllowRawDigests: true},
+			req:  &signerpb.SignRequest{TreeId: mapID, Digest: otherDigest[:], HashAlgorithm: sigpb.DigitallySigned_SHA256},
+			want: codes.OK,
+		},
+	} {
+		s := newTestServer(ctrl, test.opts)
+		_, err := s.Sign(context.Background(), test.req)
+		if got := grpc.Code(err); got != test.want {
+			t.Errorf("%v: Sign()=%v, want code %v", test.desc, err, test.want)
+		}
+	}
+}
+
+func TestSignChecksTreeState(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	active := *testonly.LogTree
+	active.TreeId = logID
+	frozen := active
+	frozen.TreeState = trillian.TreeState_FROZEN
+	as := storage.NewMockAdminStorage(ctrl)
+	tx := storage.NewMockReadOnlyAdminTX(ctrl)
+	as.EXPECT().Snapshot(gomock.Any()).AnyTimes().Return(tx, nil)
+	gomock.InOrder(
+		tx.EXPECT().GetTree(gomock.Any(), int64(logID)).Return(&active, nil),
+		tx.EXPECT().GetTree(gomock.Any(), int64(logID)).Return(&frozen, nil),
+	)
+	tx.EXPECT().Commit().AnyTimes().Return(nil)
+	tx.EXPECT().Close().AnyTimes().Return(nil)
+	s := New(extension.Registry{AdminStorage: as, SignerFactory: keys.PEMSignerFactory{}}, Options{})
+
+	// Once the log is frozen it's no longer signed for, though its signer is
+	// cached.
+	for _, want := range []codes.Code{codes.OK, codes.FailedPrecondition} {
+		root := trillian.SignedLogRoot{TreeSize: 10, TimestampNanos: 100, RootHash: []byte("root10"), LogId: logID}
+		digest := sha256.Sum256(crypto.HashLogRoot(root))
+		_, err := s.Sign(context.Background(), &signerpb.SignRequest{TreeId: logID, Digest: digest[:], HashAlgorithm: sigpb.DigitallySigned_SHA256, LogRoot: &root})
+		if got := grpc.Code(err); got != want {
+			t.Errorf("Sign()=%v, want code %v", err, want)
+		}
+	}
+}
+
+func TestGetPublicKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := newTestServer(ctrl, Options{})
+
+	resp, err := s.GetPublicKey(context.Background(), &signerpb.GetPublicKeyRequest{TreeId: logID})
+	if err != nil {
+		t.Fatalf("GetPublicKey(): %v", err)
+	}
+	pubKey, err := keys.NewFromPublicPEMFile("../../testdata/log-rpc-server.pubkey.pem")
+	if err != nil {
+		t.Fatalf("NewFromPublicPEMFile(): %v", err)
+	}
+	want, err := x509.MarshalPKIXPublicKey(pubKey)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey(): %v", err)
+	}
+	if got := resp.GetPublicKeyDer(); !bytes.Equal(got, want) {
+		t.Errorf("GetPublicKey()=%x, want %x", got, want)
+	}
+}
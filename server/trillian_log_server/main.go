@@ -16,8 +16,13 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"flag"
 	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
 
 	_ "net/http/pprof"
 
@@ -29,10 +34,14 @@ import (
 	"github.com/google/trillian/extension"
 	"github.com/google/trillian/monitoring"
 	"github.com/google/trillian/server"
+	"github.com/google/trillian/server/auth"
+	"github.com/google/trillian/storage/archive"
+	"github.com/google/trillian/storage/blob"
 	"github.com/google/trillian/storage/mysql"
 	"github.com/google/trillian/util"
 	"github.com/grpc-ecosystem/grpc-gateway/runtime"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 var (
@@ -40,8 +49,62 @@ var (
 	serverPortFlag      = flag.Int("port", 8090, "Port to serve log RPC requests on")
 	httpPortFlag        = flag.Int("http_port", 8091, "Port to serve HTTP metrics and REST requests on (negative means disabled)")
 	dumpMetricsInterval = flag.Duration("dump_metrics_interval", 0, "If greater than 0, how often to dump metrics to the logs.")
+	rootPollInterval    = flag.Duration("root_poll_interval", 200*time.Millisecond, "How often WaitForInclusion checks storage for a new root of a log it's waiting on")
+	maxInclusionWait    = flag.Duration("max_inclusion_wait", time.Minute, "How long WaitForInclusion waits at most, even if the RPC's deadline is later or it has none")
+	tlsCertFile         = flag.String("tls_cert_file", "", "Path to the server's TLS certificate; if empty the server doesn't use TLS")
+	tlsKeyFile          = flag.String("tls_key_file", "", "Path to the server's TLS private key")
+	clientCAFile        = flag.String("client_ca_file", "", "Path to the CA certificates used to verify client certificates, whose common names identify their submitters")
+	authTokensFile      = flag.String("auth_tokens_file", "", "Path to a JSON object mapping bearer tokens to the submitter identities they authenticate")
+	adminIdentities     = flag.String("admin_identities", "", "Comma-separated list of client identities allowed to call admin-only RPCs")
+	queueBuckets        = flag.Int("queue_buckets", 1, "Number of buckets each log's queue of unsequenced leaves is split into, which should match the log signer's")
+	archiveDir          = flag.String("archive_dir", "", "If set, the directory of the cold archive tier, from which logs archived by archive_log are read")
+	blobDir             = flag.String("blob_dir", "", "If set, the directory of the blob store that leaf values larger than --blob_threshold are offloaded to, shared by every server using the database")
+	blobThreshold       = flag.Int("blob_threshold", 16*1024, "Size in bytes above which leaf values are offloaded to the blob store, if --blob_dir is set")
+	mySQLShards         = flag.String("mysql_shards", "", "If set, a comma-separated list of name=uri MySQL databases to shard trees across, with the database at --mysql_uri holding only the directory of which shard each tree is in")
 )
 
+// serverOptions returns the gRPC options needed to identify clients from
+// their TLS certificates, if TLS is configured.
+func serverOptions() ([]grpc.ServerOption, error) {
+	if len(*tlsCertFile) == 0 {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(*tlsCertFile, *tlsKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS key pair: %v", err)
+	}
+	cfg := &tls.Config{Certificates: []tls.Certificate{cert}}
+	if len(*clientCAFile) > 0 {
+		pem, err := ioutil.ReadFile(*clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CAs: %v", err)
+		}
+		cfg.ClientCAs = x509.NewCertPool()
+		if !cfg.ClientCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", *clientCAFile)
+		}
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(cfg))}, nil
+}
+
+// newAuthenticator returns an Authenticator using the tokens and admins
+// given by flags.
+func newAuthenticator() (*auth.Authenticator, error) {
+	var tokens map[string]string
+	if len(*authTokensFile) > 0 {
+		var err error
+		if tokens, err = auth.TokensFromFile(*authTokensFile); err != nil {
+			return nil, err
+		}
+	}
+	var admins []string
+	if len(*adminIdentities) > 0 {
+		admins = strings.Split(*adminIdentities, ",")
+	}
+	return auth.NewAuthenticator(tokens, admins), nil
+}
+
 func main() {
 	flag.Parse()
 
@@ -52,16 +115,47 @@ func main() {
 	}
 	// No defer: database ownership is delegated to server.Main
 
+	var blobs *blob.Offloader
+	if len(*blobDir) > 0 {
+		if *blobThreshold > mysql.MaxInlineLeafValueSize {
+			glog.Exitf("--blob_threshold=%d but values larger than %d bytes don't fit in MySQL", *blobThreshold, mysql.MaxInlineLeafValueSize)
+		}
+		blobs = &blob.Offloader{Store: blob.NewFileStore(*blobDir), Threshold: *blobThreshold}
+	}
+
 	registry := extension.Registry{
 		AdminStorage:  mysql.NewAdminStorage(db),
 		SignerFactory: keys.PEMSignerFactory{},
-		LogStorage:    mysql.NewLogStorage(db),
+		LogStorage:    mysql.NewLogStorageWithBlobs(db, *queueBuckets, blobs),
+		// MapStorage lets the admin server sign initial roots for new maps.
+		MapStorage: mysql.NewMapStorageWithBlobs(db, blobs),
+	}
+	if len(*mySQLShards) > 0 {
+		shards, err := mysql.NewShardedStorage(db, *mySQLShards, *queueBuckets, blobs)
+		if err != nil {
+			glog.Exitf("Failed to open database shards: %v", err)
+		}
+		registry.AdminStorage = shards.AdminStorage()
+		registry.LogStorage = shards.LogStorage()
+		registry.MapStorage = shards.MapStorage()
+	}
+	if len(*archiveDir) > 0 {
+		registry.LogStorage = archive.NewTieredLogStorage(registry.LogStorage, archive.NewLogStorage(*archiveDir))
 	}
 
 	ts := util.SystemTimeSource{}
 	stats := monitoring.NewRPCStatsInterceptor(ts, "ct", "example")
 	stats.Publish()
-	s := grpc.NewServer(grpc.UnaryInterceptor(stats.Interceptor()))
+	authenticator, err := newAuthenticator()
+	if err != nil {
+		glog.Exitf("Failed to set up authentication: %v", err)
+	}
+	opts, err := serverOptions()
+	if err != nil {
+		glog.Exitf("Failed to set up TLS: %v", err)
+	}
+	opts = append(opts, grpc.UnaryInterceptor(auth.ChainInterceptors(stats.Interceptor(), authenticator.Interceptor())))
+	s := grpc.NewServer(opts...)
 	// No defer: server ownership is delegated to server.Main
 
 	httpEndpoint := ""
@@ -80,6 +174,8 @@ func main() {
 		},
 		RegisterServerFn: func(s *grpc.Server, registry extension.Registry) error {
 			logServer := server.NewTrillianLogRPCServer(registry, ts)
+			logServer.SetRootPollInterval(*rootPollInterval)
+			logServer.SetMaxInclusionWait(*maxInclusionWait)
 			if err := logServer.IsHealthy(); err != nil {
 				return err
 			}
@@ -16,18 +16,22 @@ package main
 
 import (
 	"flag"
+	"strings"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql" // Load MySQL driver
 
 	"github.com/golang/glog"
 	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/crypto/signerpb"
 	"github.com/google/trillian/extension"
 	"github.com/google/trillian/monitoring/metric"
 	"github.com/google/trillian/server"
+	"github.com/google/trillian/storage/archive"
 	"github.com/google/trillian/storage/mysql"
 	"github.com/google/trillian/util"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc"
 )
 
 var (
@@ -38,7 +42,17 @@ var (
 	batchSizeFlag                 = flag.Int("batch_size", 50, "Max number of leaves to process per batch")
 	numSeqFlag                    = flag.Int("num_sequencers", 10, "Number of sequencers to run in parallel")
 	sequencerGuardWindowFlag      = flag.Duration("sequencer_guard_window", 0, "If set, the time elapsed before submitted leaves are eligible for sequencing")
+	maxClockSkewFlag              = flag.Duration("max_clock_skew", time.Minute, "How far the clock may be behind the timestamps of a log's previous root or queued leaves before roots are no longer signed, 0 for no limit")
+	minLaneShareFlag              = flag.Float64("min_lane_share", 0, "Fraction of each batch reserved for leaves from each lower priority lane, so they aren't starved by higher priority ones, 0 to dequeue lanes strictly in priority order")
+	queueBucketsFlag              = flag.Int("queue_buckets", 1, "Number of buckets each log's queue of unsequenced leaves is split into, which should match the log server's")
+	deadLetterAfterFlag           = flag.Int("dead_letter_after", 0, "Number of consecutive passes a log's batch must fail to be sequenced before the leaves causing the failures are dead-lettered, 0 to never dead-letter leaves")
 	dumpMetricsInterval           = flag.Duration("dump_metrics_interval", 0, "If greater than 0, how often to dump metrics to the logs.")
+	signerEndpointFlag            = flag.String("signer_endpoint", "", "If set, the address of a trillian_signer to sign with instead of loading private keys locally")
+	signerTimeoutFlag             = flag.Duration("signer_timeout", 5*time.Second, "Timeout for requests to the trillian_signer")
+	cosignerEndpointsFlag         = flag.String("cosigner_endpoints", "", "Comma-separated addresses of trillian_signers which also sign each log root, each with its own --private_key_file")
+	archiveDirFlag                = flag.String("archive_dir", "", "If set, the directory of the cold archive tier, from which logs archived by archive_log are read")
+	mySQLShardsFlag               = flag.String("mysql_shards", "", "If set, a comma-separated list of name=uri MySQL databases to shard trees across, with the database at --mysql_uri holding only the directory of which shard each tree is in")
+	minRootSignaturesFlag         = flag.Int("min_root_signatures", 1, "Number of signatures by distinct keys, including the log's own, that each log root must get before it's stored")
 )
 
 func main() {
@@ -59,10 +73,53 @@ func main() {
 	}
 	defer db.Close()
 
+	var signerFactory keys.SignerFactory = keys.PEMSignerFactory{}
+	if *signerEndpointFlag != "" {
+		conn, err := grpc.Dial(*signerEndpointFlag, grpc.WithInsecure())
+		if err != nil {
+			glog.Exitf("Failed to connect to signer at %v: %v", *signerEndpointFlag, err)
+		}
+		defer conn.Close()
+		signerFactory = keys.RemoteSignerFactory{
+			Client:  signerpb.NewTrillianSignerClient(conn),
+			Timeout: *signerTimeoutFlag,
+		}
+	}
+
+	var cosignerFactories []keys.SignerFactory
+	if *cosignerEndpointsFlag != "" {
+		for _, endpoint := range strings.Split(*cosignerEndpointsFlag, ",") {
+			conn, err := grpc.Dial(endpoint, grpc.WithInsecure())
+			if err != nil {
+				glog.Exitf("Failed to connect to cosigner at %v: %v", endpoint, err)
+			}
+			defer conn.Close()
+			cosignerFactories = append(cosignerFactories, keys.RemoteSignerFactory{
+				Client:  signerpb.NewTrillianSignerClient(conn),
+				Timeout: *signerTimeoutFlag,
+			})
+		}
+	}
+	if *minRootSignaturesFlag > len(cosignerFactories)+1 {
+		glog.Exitf("--min_root_signatures=%d but there are only %d signers", *minRootSignaturesFlag, len(cosignerFactories)+1)
+	}
+
 	registry := extension.Registry{
 		AdminStorage:  mysql.NewAdminStorage(db),
-		SignerFactory: keys.PEMSignerFactory{},
-		LogStorage:    mysql.NewLogStorage(db),
+		SignerFactory: signerFactory,
+		LogStorage:    mysql.NewLogStorageWithQueueBuckets(db, *queueBucketsFlag),
+	}
+	if len(*mySQLShardsFlag) > 0 {
+		// The signer never reads or writes leaf values, so needs no blob store.
+		shards, err := mysql.NewShardedStorage(db, *mySQLShardsFlag, *queueBucketsFlag, nil)
+		if err != nil {
+			glog.Exitf("Failed to open database shards: %v", err)
+		}
+		registry.AdminStorage = shards.AdminStorage()
+		registry.LogStorage = shards.LogStorage()
+	}
+	if len(*archiveDirFlag) > 0 {
+		registry.LogStorage = archive.NewTieredLogStorage(registry.LogStorage, archive.NewLogStorage(*archiveDirFlag))
 	}
 
 	// Start HTTP server (optional)
@@ -80,6 +137,10 @@ func main() {
 	go util.AwaitSignal(cancel)
 
 	sequencerManager := server.NewSequencerManager(registry, *sequencerGuardWindowFlag)
+	sequencerManager.SetCosignerFactories(cosignerFactories, *minRootSignaturesFlag)
+	sequencerManager.SetMaxClockSkew(*maxClockSkewFlag)
+	sequencerManager.SetMinLaneShare(*minLaneShareFlag)
+	sequencerManager.SetDeadLetterAfter(*deadLetterAfterFlag)
 	sequencerTask := server.NewLogOperationManager(ctx, registry, *batchSizeFlag, *numSeqFlag, *sequencerSleepBetweenRunsFlag, util.SystemTimeSource{}, sequencerManager)
 	sequencerTask.OperationLoop()
 
@@ -0,0 +1,88 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The trillian_signer binary runs a TrillianSigner service, which holds the
+// private keys of trees so that log signers can run without them (see the
+// --signer_endpoint flag of trillian_log_signer).
+//
+// To cosign log roots instead (see the --cosigner_endpoints flag of
+// trillian_log_signer), each trillian_signer must be given a key of its own
+// with --private_key_file, as signatures by the tree's key only count once.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+
+	_ "github.com/go-sql-driver/mysql" // Load MySQL driver
+
+	"github.com/golang/glog"
+	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/crypto/signerpb"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/server/signer"
+	"github.com/google/trillian/storage/mysql"
+	"github.com/google/trillian/util"
+	"google.golang.org/grpc"
+)
+
+var (
+	mySQLURI        = flag.String("mysql_uri", "test:zaphod@tcp(127.0.0.1:3306)/test", "Connection URI for MySQL database")
+	serverPortFlag  = flag.Int("port", 8094, "Port to serve signing requests on")
+	allowRawDigests = flag.Bool("allow_raw_digests", false, "If true, sign digests which aren't accompanied by a log root for trees which aren't logs, e.g. maps, without any policy checks")
+	privateKeyFile  = flag.String("private_key_file", "", "If set, the PEM file of the key to sign every tree with, instead of the tree's own private key, e.g. for a cosigner")
+	privateKeyPass  = flag.String("private_key_password", "", "Password for --private_key_file")
+)
+
+func main() {
+	flag.Parse()
+	glog.CopyStandardLogTo("WARNING")
+
+	db, err := mysql.OpenDB(*mySQLURI)
+	if err != nil {
+		glog.Exitf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	var signerFactory keys.SignerFactory = keys.PEMSignerFactory{}
+	if *privateKeyFile != "" {
+		key, err := keys.NewFromPrivatePEMFile(*privateKeyFile, *privateKeyPass)
+		if err != nil {
+			glog.Exitf("Failed to load private key: %v", err)
+		}
+		signerFactory = keys.FixedSignerFactory{Signer: key}
+	}
+
+	registry := extension.Registry{
+		AdminStorage:  mysql.NewAdminStorage(db),
+		SignerFactory: signerFactory,
+		LogStorage:    mysql.NewLogStorage(db),
+	}
+
+	s := grpc.NewServer()
+	signerpb.RegisterTrillianSignerServer(s, signer.New(registry, signer.Options{AllowRawDigests: *allowRawDigests}))
+
+	endpoint := fmt.Sprintf("localhost:%v", *serverPortFlag)
+	lis, err := net.Listen("tcp", endpoint)
+	if err != nil {
+		glog.Exitf("Failed to listen on %v: %v", endpoint, err)
+	}
+	go util.AwaitSignal(s.GracefulStop)
+
+	glog.Infof("Signer server starting on %v", endpoint)
+	if err := s.Serve(lis); err != nil {
+		glog.Exitf("Server exited with error: %v", err)
+	}
+}
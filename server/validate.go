@@ -74,5 +74,8 @@ func validateQueueLeavesRequest(req *trillian.QueueLeavesRequest) error {
 	if len(req.Leaves) == 0 {
 		return grpc.Errorf(codes.InvalidArgument, "len(leaves)=0, want > 0")
 	}
+	if _, ok := trillian.LeafPriority_name[int32(req.Priority)]; !ok {
+		return grpc.Errorf(codes.InvalidArgument, "unknown priority: %v", req.Priority)
+	}
 	return nil
 }
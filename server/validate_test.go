@@ -136,3 +136,20 @@ func TestGetProofByHashInvalidRequests(t *testing.T) {
 		}
 	}
 }
+
+func TestQueueLeavesRequest(t *testing.T) {
+	leaves := []*trillian.LogLeaf{{LeafValue: []byte("data")}}
+	for _, test := range []struct {
+		req     *trillian.QueueLeavesRequest
+		wantErr bool
+	}{
+		{req: &trillian.QueueLeavesRequest{LogId: logID1, Leaves: leaves}},
+		{req: &trillian.QueueLeavesRequest{LogId: logID1, Leaves: leaves, Priority: trillian.LeafPriority_PRIORITY_BULK}},
+		{req: &trillian.QueueLeavesRequest{LogId: logID1}, wantErr: true},
+		{req: &trillian.QueueLeavesRequest{LogId: logID1, Leaves: leaves, Priority: trillian.LeafPriority(42)}, wantErr: true},
+	} {
+		if err := validateQueueLeavesRequest(test.req); (err != nil) != test.wantErr {
+			t.Errorf("validateQueueLeavesRequest(%v): %v, want error: %v", test.req, err, test.wantErr)
+		}
+	}
+}
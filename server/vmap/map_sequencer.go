@@ -0,0 +1,188 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vmap
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/util"
+)
+
+// DefaultMutationRetention is how long applied mutations are kept, so that
+// SetLeaves calls waiting on them can find the revision they were written at.
+const DefaultMutationRetention = time.Hour
+
+// MapSequencer writes the mutations queued by asynchronous SetLeaves calls to
+// a map, in batches. Each batch produces a single new revision of the map with
+// a signed root.
+type MapSequencer struct {
+	hasher            merkle.MapHasher
+	timeSource        util.TimeSource
+	mapStorage        storage.MapStorage
+	signer            *crypto.Signer
+	mutationRetention time.Duration
+}
+
+// NewMapSequencer creates a new MapSequencer instance for the specified inputs.
+func NewMapSequencer(hasher merkle.MapHasher, timeSource util.TimeSource, mapStorage storage.MapStorage, signer *crypto.Signer) *MapSequencer {
+	return &MapSequencer{
+		hasher:            hasher,
+		timeSource:        timeSource,
+		mapStorage:        mapStorage,
+		signer:            signer,
+		mutationRetention: DefaultMutationRetention,
+	}
+}
+
+// SetMutationRetention sets how long mutations are kept after they have been
+// applied. It should be longer than SetLeaves calls wait for their leaves to
+// be written.
+func (s *MapSequencer) SetMutationRetention(retention time.Duration) {
+	s.mutationRetention = retention
+}
+
+// SequenceBatch writes up to limit queued mutations to the map as a new
+// revision, and returns the number of mutations written.
+func (s *MapSequencer) SequenceBatch(ctx context.Context, mapID int64, limit int) (int, error) {
+	tx, err := s.mapStorage.BeginForTree(ctx, mapID)
+	if err != nil {
+		glog.Warningf("%v: Sequencer failed to start tx: %v", mapID, err)
+		return 0, err
+	}
+	defer tx.Close()
+
+	mutations, err := tx.DequeueMutations(limit)
+	if err != nil {
+		glog.Warningf("%v: Sequencer failed to dequeue mutations: %v", mapID, err)
+		return 0, err
+	}
+
+	if len(mutations) > 0 {
+		if err := s.applyMutations(ctx, tx, mapID, mutations); err != nil {
+			return 0, err
+		}
+	}
+
+	cutoff := s.timeSource.Now().Add(-s.mutationRetention)
+	if pruned, err := tx.PruneAppliedMutations(cutoff); err != nil {
+		glog.Warningf("%v: Sequencer failed to prune applied mutations: %v", mapID, err)
+		return 0, err
+	} else if pruned > 0 {
+		glog.V(1).Infof("%v: Pruned %d applied mutations", mapID, pruned)
+	}
+
+	if err := tx.Commit(); err != nil {
+		glog.Warningf("%v: Sequencer failed to commit: %v", mapID, err)
+		return 0, err
+	}
+	return len(mutations), nil
+}
+
+// applyMutations writes mutations to the map at the write revision of tx, and
+// stores a signed root for the revision.
+func (s *MapSequencer) applyMutations(ctx context.Context, tx storage.MapTreeTX, mapID int64, mutations []*storage.QueuedMapMutation) error {
+	currentRoot, err := tx.LatestSignedMapRoot()
+	if err != nil {
+		glog.Warningf("%v: Sequencer failed to get latest root: %v", mapID, err)
+		return err
+	}
+
+	leaves, mapperData := mergeMutations(mutations)
+	if mapperData == nil {
+		mapperData = currentRoot.Metadata
+	}
+	rootHash, err := writeLeaves(ctx, s.mapStorage, tx, mapID, s.hasher, leaves)
+	if err != nil {
+		glog.Warningf("%v: Sequencer failed to write leaves: %v", mapID, err)
+		return err
+	}
+
+	newRoot, err := signMapRoot(s.signer, s.timeSource.Now(), mapID, currentRoot, rootHash, tx.WriteRevision(), mapperData)
+	if err != nil {
+		glog.Warningf("%v: Sequencer failed to sign root: %v", mapID, err)
+		return err
+	}
+
+	if err := tx.StoreSignedMapRoot(newRoot); err != nil {
+		glog.Warningf("%v: Sequencer failed to store root: %v", mapID, err)
+		return err
+	}
+
+	ids := make([]int64, 0, len(mutations))
+	for _, m := range mutations {
+		ids = append(ids, m.ID)
+	}
+	if err := tx.MarkMutationsApplied(ids); err != nil {
+		glog.Warningf("%v: Sequencer failed to mark mutations applied: %v", mapID, err)
+		return err
+	}
+
+	glog.Infof("%v: Wrote %d mutations (%d leaves) at revision %d", mapID, len(mutations), len(leaves), newRoot.MapRevision)
+	return nil
+}
+
+// mergeMutations combines mutations into a single set of leaves, in which a
+// leaf from a later mutation replaces any earlier leaf with the same index, as
+// if the mutations had been written one after the other. It also returns the
+// mapper metadata of the last mutation which has any.
+func mergeMutations(mutations []*storage.QueuedMapMutation) ([]*trillian.MapLeaf, *trillian.MapperMetadata) {
+	var leaves []*trillian.MapLeaf
+	var mapperData *trillian.MapperMetadata
+	pos := make(map[string]int)
+	for _, m := range mutations {
+		for _, l := range m.Leaves {
+			if i, ok := pos[string(l.Index)]; ok {
+				leaves[i] = l
+				continue
+			}
+			pos[string(l.Index)] = len(leaves)
+			leaves = append(leaves, l)
+		}
+		if m.MapperData != nil {
+			mapperData = m.MapperData
+		}
+	}
+	return leaves, mapperData
+}
+
+// signMapRoot returns the root of a new revision of the map, signed by signer.
+// Roots are ordered by timestamp in storage, so its timestamp is kept after that
+// of currentRoot.
+func signMapRoot(signer *crypto.Signer, now time.Time, mapID int64, currentRoot trillian.SignedMapRoot, rootHash []byte, revision int64, mapperData *trillian.MapperMetadata) (trillian.SignedMapRoot, error) {
+	timestamp := now.UnixNano()
+	if timestamp <= currentRoot.TimestampNanos {
+		timestamp = currentRoot.TimestampNanos + 1
+	}
+	root := trillian.SignedMapRoot{
+		TimestampNanos: timestamp,
+		RootHash:       rootHash,
+		MapId:          mapID,
+		MapRevision:    revision,
+		Metadata:       mapperData,
+	}
+	// The signature covers the root as it is before the signature is set.
+	signature, err := signer.SignObject(root)
+	if err != nil {
+		return trillian.SignedMapRoot{}, err
+	}
+	root.Signature = signature
+	return root, nil
+}
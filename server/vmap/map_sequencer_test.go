@@ -0,0 +1,153 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vmap
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto"
+	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/testonly"
+	"github.com/google/trillian/util"
+)
+
+const testMapID = 7
+
+var fakeTime = time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestSequencer(t *testing.T, ms storage.MapStorage) *MapSequencer {
+	key, err := keys.NewFromPrivatePEM(testonly.DemoPrivateKey, testonly.DemoPrivateKeyPass)
+	if err != nil {
+		t.Fatalf("NewFromPrivatePEM(): %v", err)
+	}
+	return NewMapSequencer(merkle.NewMapHasher(testonly.Hasher), util.FakeTimeSource{FakeTime: fakeTime}, ms, crypto.NewSigner(key))
+}
+
+// expectSubtreeTXs sets up ms to return tx for the first transaction, and
+// transactions which behave like an empty tree for the sparse Merkle tree
+// writer after that.
+func expectSubtreeTXs(ctrl *gomock.Controller, ms *storage.MockMapStorage, tx storage.MapTreeTX) {
+	ms.EXPECT().BeginForTree(gomock.Any(), int64(testMapID)).Return(tx, nil)
+	subTX := storage.NewMockMapTreeTX(ctrl)
+	subTX.EXPECT().GetMerkleNodes(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	subTX.EXPECT().SetMerkleNodes(gomock.Any()).AnyTimes().Return(nil)
+	subTX.EXPECT().Commit().AnyTimes().Return(nil)
+	subTX.EXPECT().Close().AnyTimes().Return(nil)
+	ms.EXPECT().BeginForTree(gomock.Any(), int64(testMapID)).AnyTimes().Return(subTX, nil)
+}
+
+func TestSequenceBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	keyA, keyB := testonly.HashKey("a"), testonly.HashKey("b")
+	mutations := []*storage.QueuedMapMutation{
+		{ID: 1, Leaves: []*trillian.MapLeaf{{Index: keyA, LeafValue: []byte("a1")}}, MapperData: &trillian.MapperMetadata{HighestFullyCompletedSeq: 1}},
+		{ID: 2, Leaves: []*trillian.MapLeaf{{Index: keyB, LeafValue: []byte("b1")}}},
+		{ID: 3, Leaves: []*trillian.MapLeaf{{Index: keyA, LeafValue: []byte("a2")}}, MapperData: &trillian.MapperMetadata{HighestFullyCompletedSeq: 3}},
+		{ID: 4, Leaves: []*trillian.MapLeaf{{Index: keyB, LeafValue: []byte("b2")}}},
+	}
+	currentRoot := trillian.SignedMapRoot{MapId: testMapID, MapRevision: 4, TimestampNanos: fakeTime.UnixNano() + 10}
+
+	ms := storage.NewMockMapStorage(ctrl)
+	tx := storage.NewMockMapTreeTX(ctrl)
+	expectSubtreeTXs(ctrl, ms, tx)
+	tx.EXPECT().DequeueMutations(10).Return(mutations, nil)
+	tx.EXPECT().LatestSignedMapRoot().Return(currentRoot, nil)
+	tx.EXPECT().WriteRevision().AnyTimes().Return(int64(5))
+	written := make(map[string][]byte)
+	tx.EXPECT().Set(gomock.Any(), gomock.Any()).Times(2).Do(func(index []byte, leaf trillian.MapLeaf) {
+		written[string(index)] = leaf.LeafValue
+	}).Return(nil)
+	var stored trillian.SignedMapRoot
+	tx.EXPECT().StoreSignedMapRoot(gomock.Any()).Do(func(root trillian.SignedMapRoot) { stored = root }).Return(nil)
+	tx.EXPECT().MarkMutationsApplied([]int64{1, 2, 3, 4}).Return(nil)
+	tx.EXPECT().PruneAppliedMutations(fakeTime.Add(-DefaultMutationRetention)).Return(int64(0), nil)
+	tx.EXPECT().Commit().Return(nil)
+	tx.EXPECT().Close().Return(nil)
+
+	s := newTestSequencer(t, ms)
+	n, err := s.SequenceBatch(context.Background(), testMapID, 10)
+	if err != nil {
+		t.Fatalf("SequenceBatch(): %v", err)
+	}
+	if n != len(mutations) {
+		t.Errorf("SequenceBatch()=%d, want %d", n, len(mutations))
+	}
+
+	if got, want := string(written[string(keyA)]), "a2"; got != want {
+		t.Errorf("wrote %q for key a, want %q", got, want)
+	}
+	if got, want := string(written[string(keyB)]), "b2"; got != want {
+		t.Errorf("wrote %q for key b, want %q", got, want)
+	}
+
+	// The root should be that of a map holding only the final values.
+	hasher := merkle.NewMapHasher(testonly.Hasher)
+	hs2 := merkle.NewHStar2(testonly.Hasher)
+	wantRoot, err := hs2.HStar2Root(hasher.Size()*8, []merkle.HStar2LeafHash{
+		{Index: new(big.Int).SetBytes(keyA), LeafHash: hasher.HashLeaf([]byte("a2"))},
+		{Index: new(big.Int).SetBytes(keyB), LeafHash: hasher.HashLeaf([]byte("b2"))},
+	})
+	if err != nil {
+		t.Fatalf("HStar2Root(): %v", err)
+	}
+	if !bytes.Equal(stored.RootHash, wantRoot) {
+		t.Errorf("stored root hash %x, want %x", stored.RootHash, wantRoot)
+	}
+	if got, want := stored.MapRevision, int64(5); got != want {
+		t.Errorf("stored root revision %d, want %d", got, want)
+	}
+	if got, want := stored.TimestampNanos, currentRoot.TimestampNanos+1; got != want {
+		t.Errorf("stored root timestamp %d, want %d", got, want)
+	}
+	if got, want := stored.Metadata.GetHighestFullyCompletedSeq(), int64(3); got != want {
+		t.Errorf("stored root has metadata from mutation %d, want %d", got, want)
+	}
+
+	sig := stored.Signature
+	stored.Signature = nil
+	if err := crypto.VerifyObject(s.signer.Public(), stored, sig); err != nil {
+		t.Errorf("VerifyObject(): %v", err)
+	}
+}
+
+func TestSequenceBatchNoMutations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ms := storage.NewMockMapStorage(ctrl)
+	tx := storage.NewMockMapTreeTX(ctrl)
+	ms.EXPECT().BeginForTree(gomock.Any(), int64(testMapID)).Return(tx, nil)
+	tx.EXPECT().DequeueMutations(10).Return(nil, nil)
+	tx.EXPECT().PruneAppliedMutations(fakeTime.Add(-time.Minute)).Return(int64(3), nil)
+	tx.EXPECT().Commit().Return(nil)
+	tx.EXPECT().Close().Return(nil)
+
+	s := newTestSequencer(t, ms)
+	s.SetMutationRetention(time.Minute)
+	n, err := s.SequenceBatch(context.Background(), testMapID, 10)
+	if err != nil || n != 0 {
+		t.Errorf("SequenceBatch()=%v, %v, want 0, nil", n, err)
+	}
+}
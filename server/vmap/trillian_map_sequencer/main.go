@@ -0,0 +1,102 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The trillian_map_sequencer binary writes the leaves queued by
+// trillian_map_servers running with --async_writes to their maps, in batches.
+// Like trillian_log_signer, exactly one must run for the database: the writes
+// of several sequencers would race, and all but one fail to commit.
+package main
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql" // Load MySQL driver
+
+	"github.com/golang/glog"
+	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/server"
+	"github.com/google/trillian/storage/blob"
+	"github.com/google/trillian/storage/mysql"
+	"github.com/google/trillian/util"
+)
+
+var (
+	mySQLURI              = flag.String("mysql_uri", "test:zaphod@tcp(127.0.0.1:3306)/test", "Connection URI for MySQL database")
+	exportRPCMetrics      = flag.Bool("export_metrics", true, "If true starts HTTP server and exports stats")
+	httpPortFlag          = flag.Int("http_port", 8092, "Port to serve HTTP metrics on")
+	blobDir               = flag.String("blob_dir", "", "If set, the directory of the blob store that leaf values larger than --blob_threshold are offloaded to, which must match the map servers'")
+	blobThreshold         = flag.Int("blob_threshold", 16*1024, "Size in bytes above which serialized map leaves are offloaded to the blob store, if --blob_dir is set")
+	mySQLShards           = flag.String("mysql_shards", "", "If set, a comma-separated list of name=uri MySQL databases to shard trees across, with the database at --mysql_uri holding only the directory of which shard each tree is in")
+	sequencerIntervalFlag = flag.Duration("sequencer_interval", 100*time.Millisecond, "Time between map sequencer runs")
+	batchSizeFlag         = flag.Int("batch_size", 1000, "Max number of queued SetLeaves calls to write as one map revision")
+	mutationRetentionFlag = flag.Duration("mutation_retention", time.Hour, "How long written mutations are kept for SetLeaves calls waiting on them")
+)
+
+func main() {
+	flag.Parse()
+	glog.CopyStandardLogTo("WARNING")
+	glog.Info("**** Map Sequencer Starting ****")
+
+	// First make sure we can access the database, quit if not
+	db, err := mysql.OpenDB(*mySQLURI)
+	if err != nil {
+		glog.Exitf("Failed to open MySQL database: %v", err)
+	}
+	defer db.Close()
+
+	var blobs *blob.Offloader
+	if len(*blobDir) > 0 {
+		if *blobThreshold > mysql.MaxInlineLeafValueSize {
+			glog.Exitf("--blob_threshold=%d but values larger than %d bytes don't fit in MySQL", *blobThreshold, mysql.MaxInlineLeafValueSize)
+		}
+		blobs = &blob.Offloader{Store: blob.NewFileStore(*blobDir), Threshold: *blobThreshold}
+	}
+
+	registry := extension.Registry{
+		AdminStorage:  mysql.NewAdminStorage(db),
+		SignerFactory: keys.PEMSignerFactory{},
+		MapStorage:    mysql.NewMapStorageWithBlobs(db, blobs),
+	}
+	if len(*mySQLShards) > 0 {
+		shards, err := mysql.NewShardedStorage(db, *mySQLShards, 1, blobs)
+		if err != nil {
+			glog.Exitf("Failed to open database shards: %v", err)
+		}
+		registry.AdminStorage = shards.AdminStorage()
+		registry.MapStorage = shards.MapStorage()
+	}
+
+	// Start HTTP server (optional)
+	if *exportRPCMetrics {
+		glog.Infof("Creating HTTP server starting on port: %d", *httpPortFlag)
+		if err := util.StartHTTPServer(*httpPortFlag); err != nil {
+			glog.Exitf("Failed to start http server on port %d: %v", *httpPortFlag, err)
+		}
+	}
+
+	// Run the sequencing loop until we terminate the process.
+	ctx, cancel := context.WithCancel(context.Background())
+	go util.AwaitSignal(cancel)
+
+	sequencerManager := server.NewMapSequencerManager(registry)
+	sequencerManager.SetMutationRetention(*mutationRetentionFlag)
+	sequencerTask := server.NewMapOperationManager(ctx, registry, *batchSizeFlag, *sequencerIntervalFlag, util.SystemTimeSource{}, sequencerManager)
+	sequencerTask.OperationLoop()
+
+	glog.Infof("Stopping map sequencer")
+	glog.Flush()
+}
@@ -19,25 +19,69 @@ import (
 
 	"github.com/golang/glog"
 	"github.com/google/trillian"
-	spb "github.com/google/trillian/crypto/sigpb"
+	"github.com/google/trillian/crypto"
 	"github.com/google/trillian/extension"
 	"github.com/google/trillian/merkle"
 	"github.com/google/trillian/storage"
 	"github.com/google/trillian/util"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 )
 
+// defaultPollInterval is how often SetLeaves checks whether queued leaves
+// have been written, when writing asynchronously.
+const defaultPollInterval = 100 * time.Millisecond
+
 // TODO: There is no access control in the server yet and clients could easily modify
 // any tree.
 
 // TrillianMapServer implements the RPC API defined in the proto
 type TrillianMapServer struct {
 	registry extension.Registry
+	// asyncWrites makes SetLeaves queue leaves for the map sequencer.
+	asyncWrites  bool
+	maxWait      time.Duration
+	pollInterval time.Duration
 }
 
 // NewTrillianMapServer creates a new RPC server backed by registry
 func NewTrillianMapServer(registry extension.Registry) *TrillianMapServer {
-	return &TrillianMapServer{registry}
+	return &TrillianMapServer{registry: registry, pollInterval: defaultPollInterval}
+}
+
+// SetAsyncWrites makes SetLeaves queue its leaves rather than writing a new
+// revision of the map itself. The queued leaves are written in batches by a
+// MapSequencer, which must be running for the map. SetLeaves waits for up to
+// maxWait for its leaves to be written, and then returns the root of the
+// revision they were written at.
+func (t *TrillianMapServer) SetAsyncWrites(maxWait time.Duration) {
+	t.asyncWrites = true
+	t.maxWait = maxWait
+}
+
+// signer returns the signer for the roots of the given map.
+func (t *TrillianMapServer) signer(ctx context.Context, mapID int64) (*crypto.Signer, error) {
+	if t.registry.AdminStorage == nil || t.registry.SignerFactory == nil {
+		return nil, grpc.Errorf(codes.FailedPrecondition, "map roots can't be signed without AdminStorage and a SignerFactory")
+	}
+	snapshot, err := t.registry.AdminStorage.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer snapshot.Close()
+	tree, err := snapshot.GetTree(ctx, mapID)
+	if err != nil {
+		return nil, err
+	}
+	if err := snapshot.Commit(); err != nil {
+		return nil, err
+	}
+	key, err := t.registry.SignerFactory.NewSigner(ctx, tree)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.NewSigner(key), nil
 }
 
 // IsHealthy returns nil if the server is healthy, error otherwise.
@@ -111,9 +155,80 @@ func (t *TrillianMapServer) GetLeaves(ctx context.Context, req *trillian.GetMapL
 	return resp, nil
 }
 
+// GetMapLeafHistory implements the GetMapLeafHistory RPC method.
+func (t *TrillianMapServer) GetMapLeafHistory(ctx context.Context, req *trillian.GetMapLeafHistoryRequest) (*trillian.GetMapLeafHistoryResponse, error) {
+	ctx = util.NewMapContext(ctx, req.MapId)
+	kh, err := t.getHasherForMap(req.MapId)
+	if err != nil {
+		return nil, err
+	}
+	if got, want := len(req.Index), kh.Size(); got != want {
+		return nil, grpc.Errorf(codes.InvalidArgument, "index is %d bytes, want %d", got, want)
+	}
+	if req.FromRevision < 0 {
+		return nil, grpc.Errorf(codes.InvalidArgument, "from_revision %d is negative", req.FromRevision)
+	}
+
+	tx, err := t.registry.MapStorage.SnapshotForTree(ctx, req.MapId)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+
+	toRevision := req.ToRevision
+	if toRevision < 0 {
+		root, err := tx.LatestSignedMapRoot()
+		if err != nil {
+			return nil, err
+		}
+		toRevision = root.MapRevision
+	}
+	if toRevision < req.FromRevision {
+		return nil, grpc.Errorf(codes.InvalidArgument, "to_revision %d is before from_revision %d", toRevision, req.FromRevision)
+	}
+
+	history, err := tx.GetHistory(req.Index, req.FromRevision, toRevision)
+	if err != nil {
+		return nil, err
+	}
+	glog.V(1).Infof("%s: found %d values for key between revisions %d and %d", util.MapIDPrefix(ctx), len(history), req.FromRevision, toRevision)
+
+	resp := &trillian.GetMapLeafHistoryResponse{
+		Entries: make([]*trillian.MapLeafHistoryEntry, 0, len(history)),
+	}
+	for _, h := range history {
+		root, err := tx.GetSignedMapRoot(h.Revision)
+		if err != nil {
+			return nil, err
+		}
+		smtReader := merkle.NewSparseMerkleTreeReader(h.Revision, kh, tx)
+		proof, err := smtReader.InclusionProof(h.Revision, req.Index)
+		if err != nil {
+			return nil, err
+		}
+		leaf := h.Leaf
+		resp.Entries = append(resp.Entries, &trillian.MapLeafHistoryEntry{
+			MapLeafInclusion: &trillian.MapLeafInclusion{
+				Leaf:      &leaf,
+				Inclusion: proof,
+			},
+			MapRoot: &root,
+		})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
 // SetLeaves implements the SetLeaves RPC method.
 func (t *TrillianMapServer) SetLeaves(ctx context.Context, req *trillian.SetMapLeavesRequest) (*trillian.SetMapLeavesResponse, error) {
 	ctx = util.NewMapContext(ctx, req.MapId)
+	if t.asyncWrites {
+		return t.queueLeaves(ctx, req)
+	}
 	tx, err := t.registry.MapStorage.BeginForTree(ctx, req.MapId)
 	if err != nil {
 		return nil, err
@@ -127,14 +242,51 @@ func (t *TrillianMapServer) SetLeaves(ctx context.Context, req *trillian.SetMapL
 
 	glog.Infof("%s: Writing at revision %d", util.MapIDPrefix(ctx), tx.WriteRevision())
 
+	signer, err := t.signer(ctx, req.MapId)
+	if err != nil {
+		return nil, err
+	}
+	currentRoot, err := tx.LatestSignedMapRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	rootHash, err := writeLeaves(ctx, t.registry.MapStorage, tx, req.MapId, hasher, req.Leaves)
+	if err != nil {
+		return nil, err
+	}
+	// Roots are signed as the map sequencer signs them for asynchronous writes.
+	newRoot, err := signMapRoot(signer, time.Now(), req.MapId, currentRoot, rootHash, tx.WriteRevision(), req.MapperData)
+	if err != nil {
+		return nil, err
+	}
+
+	// TODO(al): need an smtWriter.Rollback() or similar I think.
+	if err = tx.StoreSignedMapRoot(newRoot); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		glog.Warningf("%s: Commit failed for SetLeaves: %v", util.MapIDPrefix(ctx), err)
+		return nil, err
+	}
+
+	return &trillian.SetMapLeavesResponse{
+		MapRoot: &newRoot,
+	}, nil
+}
+
+// writeLeaves sets leaves in the map at the write revision of tx, and returns
+// the resulting root hash. The hash of each leaf is filled in.
+func writeLeaves(ctx context.Context, ms storage.MapStorage, tx storage.MapTreeTX, mapID int64, hasher merkle.MapHasher, leaves []*trillian.MapLeaf) ([]byte, error) {
 	smtWriter, err := merkle.NewSparseMerkleTreeWriter(tx.WriteRevision(), hasher, func() (storage.TreeTX, error) {
-		return t.registry.MapStorage.BeginForTree(ctx, req.MapId)
+		return ms.BeginForTree(ctx, mapID)
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	for _, l := range req.Leaves {
+	for _, l := range leaves {
 		// TODO(gbelvin) Verify that Index is of the proper length.
 		// TODO(gbelvin) use LeafHash rather than computing here.
 		l.LeafHash = hasher.HashLeaf(l.LeafValue)
@@ -152,30 +304,74 @@ func (t *TrillianMapServer) SetLeaves(ctx context.Context, req *trillian.SetMapL
 		}
 	}
 
-	rootHash, err := smtWriter.CalculateRoot()
-	newRoot := trillian.SignedMapRoot{
-		TimestampNanos: time.Now().UnixNano(),
-		RootHash:       rootHash,
-		MapId:          req.MapId,
-		MapRevision:    tx.WriteRevision(),
-		Metadata:       req.MapperData,
-		// TODO(al): Actually sign stuff, etc!
-		Signature: &spb.DigitallySigned{},
-	}
+	return smtWriter.CalculateRoot()
+}
 
-	// TODO(al): need an smtWriter.Rollback() or similar I think.
-	if err = tx.StoreSignedMapRoot(newRoot); err != nil {
+// queueLeaves queues the leaves of req for the map sequencer, and waits for
+// them to be written.
+func (t *TrillianMapServer) queueLeaves(ctx context.Context, req *trillian.SetMapLeavesRequest) (*trillian.SetMapLeavesResponse, error) {
+	tx, err := t.registry.MapStorage.BeginForTree(ctx, req.MapId)
+	if err != nil {
 		return nil, err
 	}
+	defer tx.Close()
 
+	id, err := tx.QueueMutation(req.Leaves, req.MapperData, time.Now())
+	if err != nil {
+		return nil, err
+	}
 	if err := tx.Commit(); err != nil {
 		glog.Warningf("%s: Commit failed for SetLeaves: %v", util.MapIDPrefix(ctx), err)
 		return nil, err
 	}
+	glog.V(1).Infof("%s: Queued %d leaves as mutation %d", util.MapIDPrefix(ctx), len(req.Leaves), id)
 
-	return &trillian.SetMapLeavesResponse{
-		MapRoot: &newRoot,
-	}, nil
+	deadline := time.Now().Add(t.maxWait)
+	for {
+		root, err := t.appliedRoot(ctx, req.MapId, id)
+		if err != nil {
+			return nil, err
+		}
+		if root != nil {
+			return &trillian.SetMapLeavesResponse{MapRoot: root}, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, grpc.Errorf(codes.DeadlineExceeded, "leaves were queued as mutation %d but have not been written yet", id)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(t.pollInterval):
+		}
+	}
+}
+
+// appliedRoot returns the root of the revision of the map that the mutation
+// with the given ID was written at, or nil if it is still queued.
+func (t *TrillianMapServer) appliedRoot(ctx context.Context, mapID, mutationID int64) (*trillian.SignedMapRoot, error) {
+	tx, err := t.registry.MapStorage.SnapshotForTree(ctx, mapID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+
+	var root *trillian.SignedMapRoot
+	rev, applied, err := tx.MutationRevision(mutationID)
+	if err != nil {
+		return nil, err
+	}
+	if applied {
+		r, err := tx.GetSignedMapRoot(rev)
+		if err != nil {
+			return nil, err
+		}
+		root = &r
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return root, nil
 }
 
 // GetSignedMapRoot implements the GetSignedMapRoot RPC method.
@@ -196,6 +392,11 @@ func (t *TrillianMapServer) GetSignedMapRoot(ctx context.Context, req *trillian.
 		glog.Warningf("%s: Commit failed for GetSignedMapRoot: %v", util.MapIDPrefix(ctx), err)
 		return nil, err
 	}
+	// Trees are given a signed root when they are created; don't hand out a
+	// zero-valued, unsigned root for one that has none.
+	if r.RootHash == nil {
+		return nil, grpc.Errorf(codes.FailedPrecondition, "map %d has not been initialised", req.MapId)
+	}
 
 	return &trillian.GetSignedMapRootResponse{
 		MapRoot: &r,
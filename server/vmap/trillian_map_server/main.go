@@ -18,6 +18,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"time"
 
 	_ "net/http/pprof"
 
@@ -29,6 +30,7 @@ import (
 	"github.com/google/trillian/extension"
 	"github.com/google/trillian/server"
 	"github.com/google/trillian/server/vmap"
+	"github.com/google/trillian/storage/blob"
 	"github.com/google/trillian/storage/mysql"
 	"github.com/grpc-ecosystem/grpc-gateway/runtime"
 	"google.golang.org/grpc"
@@ -38,6 +40,12 @@ var (
 	mySQLURI       = flag.String("mysql_uri", "test:zaphod@tcp(127.0.0.1:3306)/test", "Connection URI for MySQL database")
 	serverPortFlag = flag.Int("port", 8090, "Port to serve log RPC requests on")
 	httpPortFlag   = flag.Int("http_port", 8091, "Port to serve HTTP metrics and REST requests on (negative means disabled)")
+	blobDir        = flag.String("blob_dir", "", "If set, the directory of the blob store that leaf values larger than --blob_threshold are offloaded to, shared by every server using the database")
+	blobThreshold  = flag.Int("blob_threshold", 16*1024, "Size in bytes above which serialized map leaves are offloaded to the blob store, if --blob_dir is set")
+	mySQLShards    = flag.String("mysql_shards", "", "If set, a comma-separated list of name=uri MySQL databases to shard trees across, with the database at --mysql_uri holding only the directory of which shard each tree is in")
+
+	asyncWritesFlag    = flag.Bool("async_writes", false, "If true, SetLeaves queues leaves which are written to maps in batches by trillian_map_sequencer, which must be running")
+	asyncWriteWaitFlag = flag.Duration("async_write_wait", 30*time.Second, "How long SetLeaves waits for queued leaves to be written")
 )
 
 func main() {
@@ -49,10 +57,29 @@ func main() {
 	}
 	// No defer: database ownership is delegated to server.Main
 
+	var blobs *blob.Offloader
+	if len(*blobDir) > 0 {
+		if *blobThreshold > mysql.MaxInlineLeafValueSize {
+			glog.Exitf("--blob_threshold=%d but values larger than %d bytes don't fit in MySQL", *blobThreshold, mysql.MaxInlineLeafValueSize)
+		}
+		blobs = &blob.Offloader{Store: blob.NewFileStore(*blobDir), Threshold: *blobThreshold}
+	}
+
 	registry := extension.Registry{
 		AdminStorage:  mysql.NewAdminStorage(db),
 		SignerFactory: keys.PEMSignerFactory{},
-		MapStorage:    mysql.NewMapStorage(db),
+		MapStorage:    mysql.NewMapStorageWithBlobs(db, blobs),
+		// LogStorage lets the admin server sign initial roots for new logs.
+		LogStorage: mysql.NewLogStorageWithBlobs(db, 1, blobs),
+	}
+	if len(*mySQLShards) > 0 {
+		shards, err := mysql.NewShardedStorage(db, *mySQLShards, 1, blobs)
+		if err != nil {
+			glog.Exitf("Failed to open database shards: %v", err)
+		}
+		registry.AdminStorage = shards.AdminStorage()
+		registry.MapStorage = shards.MapStorage()
+		registry.LogStorage = shards.LogStorage()
 	}
 
 	s := grpc.NewServer()
@@ -74,6 +101,9 @@ func main() {
 		},
 		RegisterServerFn: func(s *grpc.Server, registry extension.Registry) error {
 			mapServer := vmap.NewTrillianMapServer(registry)
+			if *asyncWritesFlag {
+				mapServer.SetAsyncWrites(*asyncWriteWaitFlag)
+			}
 			if err := mapServer.IsHealthy(); err != nil {
 				return err
 			}
@@ -82,8 +112,7 @@ func main() {
 		},
 	}
 
-	ctx := context.Background()
-	if err := m.Run(ctx); err != nil {
+	if err := m.Run(context.Background()); err != nil {
 		glog.Exitf("Server exited with error: %v", err)
 	}
 }
@@ -0,0 +1,274 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vmap
+
+import (
+	"context"
+	gocrypto "crypto"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto"
+	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/testonly"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+const testMutationID = 12
+
+// expectQueue sets up ms to expect leaves to be queued once.
+func expectQueue(ctrl *gomock.Controller, ms *storage.MockMapStorage, req *trillian.SetMapLeavesRequest) {
+	tx := storage.NewMockMapTreeTX(ctrl)
+	ms.EXPECT().BeginForTree(gomock.Any(), req.MapId).Return(tx, nil)
+	tx.EXPECT().QueueMutation(req.Leaves, req.MapperData, gomock.Any()).Return(int64(testMutationID), nil)
+	tx.EXPECT().Commit().Return(nil)
+	tx.EXPECT().Close().Return(nil)
+}
+
+func TestSetLeavesAsync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	req := &trillian.SetMapLeavesRequest{
+		MapId:      testMapID,
+		Leaves:     []*trillian.MapLeaf{{Index: []byte("index"), LeafValue: []byte("value")}},
+		MapperData: &trillian.MapperMetadata{HighestFullyCompletedSeq: 1},
+	}
+	root := trillian.SignedMapRoot{MapId: testMapID, MapRevision: 6, RootHash: []byte("root")}
+
+	ms := storage.NewMockMapStorage(ctrl)
+	expectQueue(ctrl, ms, req)
+	// The mutation is still queued the first time it's checked.
+	for _, applied := range []bool{false, true} {
+		tx := storage.NewMockMapTreeTX(ctrl)
+		ms.EXPECT().SnapshotForTree(gomock.Any(), req.MapId).Return(tx, nil)
+		tx.EXPECT().MutationRevision(int64(testMutationID)).Return(int64(6), applied, nil)
+		if applied {
+			// The root of the revision written at is returned, even if the map
+			// has moved on since.
+			tx.EXPECT().GetSignedMapRoot(int64(6)).Return(root, nil)
+		}
+		tx.EXPECT().Commit().Return(nil)
+		tx.EXPECT().Close().Return(nil)
+	}
+
+	s := NewTrillianMapServer(extension.Registry{MapStorage: ms})
+	s.SetAsyncWrites(time.Minute)
+	s.pollInterval = time.Millisecond
+	resp, err := s.SetLeaves(context.Background(), req)
+	if err != nil {
+		t.Fatalf("SetLeaves(): %v", err)
+	}
+	if !proto.Equal(resp.MapRoot, &root) {
+		t.Errorf("SetLeaves() returned root %v, want %v", resp.MapRoot, root)
+	}
+}
+
+func TestSetLeavesSignsRoot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ms := storage.NewMockMapStorage(ctrl)
+	registry, key := signingRegistry(t, ctrl, ms)
+	tx := storage.NewMockMapTreeTX(ctrl)
+	expectSubtreeTXs(ctrl, ms, tx)
+	tx.EXPECT().LatestSignedMapRoot().Return(trillian.SignedMapRoot{MapId: testMapID, MapRevision: 4}, nil)
+	tx.EXPECT().WriteRevision().AnyTimes().Return(int64(5))
+	tx.EXPECT().Set(gomock.Any(), gomock.Any()).Return(nil)
+	var stored trillian.SignedMapRoot
+	tx.EXPECT().StoreSignedMapRoot(gomock.Any()).Do(func(root trillian.SignedMapRoot) { stored = root }).Return(nil)
+	tx.EXPECT().Commit().Return(nil)
+	tx.EXPECT().Close().Return(nil)
+
+	s := NewTrillianMapServer(registry)
+	req := &trillian.SetMapLeavesRequest{
+		MapId:  testMapID,
+		Leaves: []*trillian.MapLeaf{{Index: testonly.HashKey("a"), LeafValue: []byte("value")}},
+	}
+	resp, err := s.SetLeaves(context.Background(), req)
+	if err != nil {
+		t.Fatalf("SetLeaves(): %v", err)
+	}
+	if !proto.Equal(resp.MapRoot, &stored) {
+		t.Errorf("SetLeaves() returned root %v, want stored root %v", resp.MapRoot, stored)
+	}
+	sig := stored.Signature
+	stored.Signature = nil
+	if err := crypto.VerifyObject(key.Public(), stored, sig); err != nil {
+		t.Errorf("VerifyObject(): %v", err)
+	}
+}
+
+// fixedSignerFactory returns the same key for every tree.
+type fixedSignerFactory struct {
+	key gocrypto.Signer
+}
+
+func (f fixedSignerFactory) NewSigner(context.Context, *trillian.Tree) (gocrypto.Signer, error) {
+	return f.key, nil
+}
+
+// signingRegistry returns a registry with which the map server can sign the
+// roots of the test map once, and the key they are signed with.
+func signingRegistry(t *testing.T, ctrl *gomock.Controller, ms storage.MapStorage) (extension.Registry, gocrypto.Signer) {
+	key, err := keys.NewFromPrivatePEM(testonly.DemoPrivateKey, testonly.DemoPrivateKeyPass)
+	if err != nil {
+		t.Fatalf("NewFromPrivatePEM(): %v", err)
+	}
+	tree := &trillian.Tree{TreeId: testMapID, TreeType: trillian.TreeType_MAP}
+	as := storage.NewMockAdminStorage(ctrl)
+	adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+	as.EXPECT().Snapshot(gomock.Any()).Return(adminTX, nil)
+	adminTX.EXPECT().GetTree(gomock.Any(), int64(testMapID)).Return(tree, nil)
+	adminTX.EXPECT().Commit().Return(nil)
+	adminTX.EXPECT().Close().Return(nil)
+	return extension.Registry{
+		AdminStorage:  as,
+		MapStorage:    ms,
+		SignerFactory: fixedSignerFactory{key},
+	}, key
+}
+
+func TestSetLeavesAsyncTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	req := &trillian.SetMapLeavesRequest{
+		MapId:  testMapID,
+		Leaves: []*trillian.MapLeaf{{Index: []byte("index"), LeafValue: []byte("value")}},
+	}
+
+	ms := storage.NewMockMapStorage(ctrl)
+	expectQueue(ctrl, ms, req)
+	tx := storage.NewMockMapTreeTX(ctrl)
+	ms.EXPECT().SnapshotForTree(gomock.Any(), req.MapId).MinTimes(1).Return(tx, nil)
+	tx.EXPECT().MutationRevision(int64(testMutationID)).MinTimes(1).Return(int64(0), false, nil)
+	tx.EXPECT().Commit().MinTimes(1).Return(nil)
+	tx.EXPECT().Close().MinTimes(1).Return(nil)
+
+	s := NewTrillianMapServer(extension.Registry{MapStorage: ms})
+	s.SetAsyncWrites(5 * time.Millisecond)
+	s.pollInterval = time.Millisecond
+	if _, err := s.SetLeaves(context.Background(), req); grpc.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("SetLeaves()=%v, want code %v", err, codes.DeadlineExceeded)
+	}
+}
+
+func TestGetMapLeafHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	index := testonly.HashKey("key")
+	history := []storage.MapLeafRevision{
+		{Revision: 2, Leaf: trillian.MapLeaf{Index: index, LeafValue: []byte("value2")}},
+		{Revision: 5, Leaf: trillian.MapLeaf{Index: index, LeafValue: []byte("value5")}},
+	}
+
+	ms := storage.NewMockMapStorage(ctrl)
+	tx := storage.NewMockMapTreeTX(ctrl)
+	ms.EXPECT().SnapshotForTree(gomock.Any(), int64(testMapID)).Return(tx, nil)
+	tx.EXPECT().LatestSignedMapRoot().Return(trillian.SignedMapRoot{MapRevision: 6}, nil)
+	tx.EXPECT().GetHistory(index, int64(1), int64(6)).Return(history, nil)
+	for _, h := range history {
+		tx.EXPECT().GetSignedMapRoot(h.Revision).Return(trillian.SignedMapRoot{MapId: testMapID, MapRevision: h.Revision}, nil)
+		tx.EXPECT().GetMerkleNodes(h.Revision, gomock.Any()).Return(nil, nil)
+	}
+	tx.EXPECT().Commit().Return(nil)
+	tx.EXPECT().Close().Return(nil)
+
+	s := NewTrillianMapServer(extension.Registry{MapStorage: ms})
+	resp, err := s.GetMapLeafHistory(context.Background(), &trillian.GetMapLeafHistoryRequest{
+		MapId:        testMapID,
+		Index:        index,
+		FromRevision: 1,
+		ToRevision:   -1,
+	})
+	if err != nil {
+		t.Fatalf("GetMapLeafHistory(): %v", err)
+	}
+	if got, want := len(resp.Entries), len(history); got != want {
+		t.Fatalf("GetMapLeafHistory() returned %d entries, want %d", got, want)
+	}
+	for i, e := range resp.Entries {
+		if got, want := e.MapRoot.GetMapRevision(), history[i].Revision; got != want {
+			t.Errorf("entry %d has root for revision %d, want %d", i, got, want)
+		}
+		if got, want := e.MapLeafInclusion.GetLeaf(), &history[i].Leaf; !proto.Equal(got, want) {
+			t.Errorf("entry %d has leaf %v, want %v", i, got, want)
+		}
+		if got, want := len(e.MapLeafInclusion.GetInclusion()), 256; got != want {
+			t.Errorf("entry %d has proof of length %d, want %d", i, got, want)
+		}
+	}
+}
+
+func TestGetMapLeafHistoryInvalidRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ms := storage.NewMockMapStorage(ctrl)
+	tx := storage.NewMockMapTreeTX(ctrl)
+	ms.EXPECT().SnapshotForTree(gomock.Any(), int64(testMapID)).AnyTimes().Return(tx, nil)
+	tx.EXPECT().Close().AnyTimes().Return(nil)
+
+	s := NewTrillianMapServer(extension.Registry{MapStorage: ms})
+	index := testonly.HashKey("key")
+	for _, req := range []*trillian.GetMapLeafHistoryRequest{
+		{MapId: testMapID, Index: []byte("short"), FromRevision: 1, ToRevision: 2},
+		{MapId: testMapID, Index: index, FromRevision: -1, ToRevision: 2},
+		{MapId: testMapID, Index: index, FromRevision: 3, ToRevision: 2},
+	} {
+		if _, err := s.GetMapLeafHistory(context.Background(), req); grpc.Code(err) != codes.InvalidArgument {
+			t.Errorf("GetMapLeafHistory(%v)=%v, want code %v", req, err, codes.InvalidArgument)
+		}
+	}
+}
+
+func TestGetSignedMapRoot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	for _, test := range []struct {
+		desc     string
+		root     trillian.SignedMapRoot
+		wantCode codes.Code
+	}{
+		{desc: "initialised", root: trillian.SignedMapRoot{MapId: testMapID, RootHash: []byte("root")}},
+		{desc: "uninitialised", wantCode: codes.FailedPrecondition},
+	} {
+		ms := storage.NewMockMapStorage(ctrl)
+		tx := storage.NewMockMapTreeTX(ctrl)
+		ms.EXPECT().SnapshotForTree(gomock.Any(), int64(testMapID)).Return(tx, nil)
+		tx.EXPECT().LatestSignedMapRoot().Return(test.root, nil)
+		tx.EXPECT().Commit().Return(nil)
+		tx.EXPECT().Close().Return(nil)
+
+		s := NewTrillianMapServer(extension.Registry{MapStorage: ms})
+		resp, err := s.GetSignedMapRoot(context.Background(), &trillian.GetSignedMapRootRequest{MapId: testMapID})
+		if got := grpc.Code(err); got != test.wantCode {
+			t.Errorf("%s: GetSignedMapRoot()=_,%v; want code %v", test.desc, err, test.wantCode)
+			continue
+		}
+		if err == nil && !proto.Equal(resp.MapRoot, &test.root) {
+			t.Errorf("%s: GetSignedMapRoot()=%v,nil; want root %v", test.desc, resp.MapRoot, test.root)
+		}
+	}
+}
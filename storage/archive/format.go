@@ -0,0 +1,447 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/storage/storagepb"
+)
+
+// An archive file holds one log as a sequence of records, each a uvarint
+// length followed by a serialized proto, and then an index:
+//
+//	magic
+//	leaf records, in sequence number order (trillian.LogLeaf)
+//	subtree records (storagepb.SubtreeProto)
+//	root record (trillian.SignedLogRoot)
+//	leaf offsets: a uint64 per leaf, the position of its record
+//	hash index: a (MerkleLeafHash, uint64 sequence number) pair per leaf,
+//	    sorted by hash
+//	subtree index: a (uvarint prefix length, prefix, uint64 position) entry
+//	    per subtree
+//	footer
+//
+// All fixed size integers are big endian. Only the latest revision of each
+// subtree is kept, as log nodes never change once written.
+const (
+	magic = "TRLARCH1"
+	// footerSize is the size of the footer: the leaf count, hash size, and
+	// the positions of the leaf offsets, hash index, subtree index and root,
+	// then magic again.
+	footerSize = 6*8 + len(magic)
+)
+
+// footer locates the parts of an archive file.
+type footer struct {
+	leafCount       int64
+	hashSize        int64
+	leafOffsetsPos  int64
+	hashIndexPos    int64
+	subtreeIndexPos int64
+	rootPos         int64
+}
+
+func (f *footer) marshal() []byte {
+	b := make([]byte, footerSize)
+	for i, v := range []int64{f.leafCount, f.hashSize, f.leafOffsetsPos, f.hashIndexPos, f.subtreeIndexPos, f.rootPos} {
+		binary.BigEndian.PutUint64(b[i*8:], uint64(v))
+	}
+	copy(b[6*8:], magic)
+	return b
+}
+
+func (f *footer) unmarshal(b []byte) error {
+	if len(b) != footerSize || string(b[6*8:]) != magic {
+		return errors.New("not an archive file")
+	}
+	for i, v := range []*int64{&f.leafCount, &f.hashSize, &f.leafOffsetsPos, &f.hashIndexPos, &f.subtreeIndexPos, &f.rootPos} {
+		*v = int64(binary.BigEndian.Uint64(b[i*8:]))
+	}
+	return nil
+}
+
+type hashEntry struct {
+	hash  []byte
+	index int64
+}
+
+// byHash sorts hash index entries by hash, then sequence number.
+type byHash []hashEntry
+
+func (b byHash) Len() int      { return len(b) }
+func (b byHash) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byHash) Less(i, j int) bool {
+	if c := bytes.Compare(b[i].hash, b[j].hash); c != 0 {
+		return c < 0
+	}
+	return b[i].index < b[j].index
+}
+
+type subtreeEntry struct {
+	prefix []byte
+	pos    int64
+}
+
+// Writer creates an archive file. Leaves must be added in sequence number
+// order, starting from zero. The file only appears at its path once Close
+// succeeds, so an archive is never seen partly written.
+type Writer struct {
+	path string
+	f    *os.File
+	w    *bufio.Writer
+	pos  int64
+
+	hashSize    int
+	leafOffsets []int64
+	hashes      []hashEntry
+	subtrees    []subtreeEntry
+	root        *trillian.SignedLogRoot
+}
+
+// NewWriter starts writing an archive file at path.
+func NewWriter(path string) (*Writer, error) {
+	f, err := os.OpenFile(path+".tmp", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+	w := &Writer{path: path, f: f, w: bufio.NewWriter(f)}
+	if err := w.write([]byte(magic)); err != nil {
+		w.Abort()
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Writer) write(b []byte) error {
+	n, err := w.w.Write(b)
+	w.pos += int64(n)
+	return err
+}
+
+// writeRecord writes pb as a record, returning its position.
+func (w *Writer) writeRecord(pb proto.Message) (int64, error) {
+	data, err := proto.Marshal(pb)
+	if err != nil {
+		return 0, err
+	}
+	pos := w.pos
+	var lenBuf [binary.MaxVarintLen64]byte
+	if err := w.write(lenBuf[:binary.PutUvarint(lenBuf[:], uint64(len(data)))]); err != nil {
+		return 0, err
+	}
+	return pos, w.write(data)
+}
+
+// AddLeaf adds the next sequenced leaf of the log.
+func (w *Writer) AddLeaf(leaf *trillian.LogLeaf) error {
+	if want := int64(len(w.leafOffsets)); leaf.LeafIndex != want {
+		return fmt.Errorf("got leaf %d, want leaf %d", leaf.LeafIndex, want)
+	}
+	if len(w.subtrees) > 0 || w.root != nil {
+		return errors.New("leaves must be added before subtrees and the root")
+	}
+	if w.hashSize == 0 {
+		w.hashSize = len(leaf.MerkleLeafHash)
+	} else if len(leaf.MerkleLeafHash) != w.hashSize {
+		return fmt.Errorf("leaf %d has hash size %d, want %d", leaf.LeafIndex, len(leaf.MerkleLeafHash), w.hashSize)
+	}
+	pos, err := w.writeRecord(leaf)
+	if err != nil {
+		return err
+	}
+	w.leafOffsets = append(w.leafOffsets, pos)
+	w.hashes = append(w.hashes, hashEntry{hash: leaf.MerkleLeafHash, index: leaf.LeafIndex})
+	return nil
+}
+
+// AddSubtree adds the latest revision of one of the log's subtrees.
+func (w *Writer) AddSubtree(subtree *storagepb.SubtreeProto) error {
+	if w.root != nil {
+		return errors.New("subtrees must be added before the root")
+	}
+	pos, err := w.writeRecord(subtree)
+	if err != nil {
+		return err
+	}
+	w.subtrees = append(w.subtrees, subtreeEntry{prefix: subtree.Prefix, pos: pos})
+	return nil
+}
+
+// SetRoot writes the log's latest root, which must cover every leaf added.
+func (w *Writer) SetRoot(root trillian.SignedLogRoot) error {
+	if w.root != nil {
+		return errors.New("root already set")
+	}
+	if root.TreeSize != int64(len(w.leafOffsets)) {
+		return fmt.Errorf("root has tree size %d, but %d leaves were added", root.TreeSize, len(w.leafOffsets))
+	}
+	w.root = &root
+	return nil
+}
+
+// Close writes the index and moves the archive file into place.
+func (w *Writer) Close() error {
+	if w.root == nil {
+		w.Abort()
+		return errors.New("root not set")
+	}
+	if err := w.finish(); err != nil {
+		w.Abort()
+		return err
+	}
+	if err := w.f.Close(); err != nil {
+		os.Remove(w.f.Name())
+		return err
+	}
+	return os.Rename(w.f.Name(), w.path)
+}
+
+func (w *Writer) finish() error {
+	ftr := footer{leafCount: int64(len(w.leafOffsets)), hashSize: int64(w.hashSize)}
+	var err error
+	if ftr.rootPos, err = w.writeRecord(w.root); err != nil {
+		return err
+	}
+
+	var buf [binary.MaxVarintLen64]byte
+	ftr.leafOffsetsPos = w.pos
+	for _, pos := range w.leafOffsets {
+		binary.BigEndian.PutUint64(buf[:], uint64(pos))
+		if err := w.write(buf[:8]); err != nil {
+			return err
+		}
+	}
+
+	sort.Sort(byHash(w.hashes))
+	ftr.hashIndexPos = w.pos
+	for _, e := range w.hashes {
+		binary.BigEndian.PutUint64(buf[:], uint64(e.index))
+		if err := w.write(e.hash); err != nil {
+			return err
+		}
+		if err := w.write(buf[:8]); err != nil {
+			return err
+		}
+	}
+
+	ftr.subtreeIndexPos = w.pos
+	for _, e := range w.subtrees {
+		if err := w.write(buf[:binary.PutUvarint(buf[:], uint64(len(e.prefix)))]); err != nil {
+			return err
+		}
+		if err := w.write(e.prefix); err != nil {
+			return err
+		}
+		binary.BigEndian.PutUint64(buf[:], uint64(e.pos))
+		if err := w.write(buf[:8]); err != nil {
+			return err
+		}
+	}
+
+	if err := w.write(ftr.marshal()); err != nil {
+		return err
+	}
+	if err := w.w.Flush(); err != nil {
+		return err
+	}
+	return w.f.Sync()
+}
+
+// Abort discards the archive file.
+func (w *Writer) Abort() error {
+	w.f.Close()
+	return os.Remove(w.f.Name())
+}
+
+// Reader reads an archive file. It's safe for concurrent use.
+type Reader struct {
+	f        *os.File
+	footer   footer
+	root     trillian.SignedLogRoot
+	subtrees map[string]int64
+}
+
+// OpenReader opens the archive file at path. The subtree index is read into
+// memory, while leaves are read from the file as needed.
+func OpenReader(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &Reader{f: f, subtrees: make(map[string]int64)}
+	if err := r.readIndex(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return r, nil
+}
+
+func (r *Reader) readIndex() error {
+	info, err := r.f.Stat()
+	if err != nil {
+		return err
+	}
+	footerPos := info.Size() - int64(footerSize)
+	if footerPos < int64(len(magic)) {
+		return errors.New("not an archive file")
+	}
+	b := make([]byte, footerSize)
+	if _, err := r.f.ReadAt(b, footerPos); err != nil {
+		return err
+	}
+	if err := r.footer.unmarshal(b); err != nil {
+		return err
+	}
+	if err := r.readRecord(r.footer.rootPos, &r.root); err != nil {
+		return fmt.Errorf("failed to read root: %v", err)
+	}
+
+	b = make([]byte, footerPos-r.footer.subtreeIndexPos)
+	if _, err := r.f.ReadAt(b, r.footer.subtreeIndexPos); err != nil {
+		return err
+	}
+	for len(b) > 0 {
+		n, l := binary.Uvarint(b)
+		if l <= 0 || uint64(len(b)-l) < n+8 {
+			return errors.New("corrupt subtree index")
+		}
+		prefix := string(b[l : l+int(n)])
+		r.subtrees[prefix] = int64(binary.BigEndian.Uint64(b[l+int(n):]))
+		b = b[l+int(n)+8:]
+	}
+	return nil
+}
+
+// readRecord reads the record at pos into pb.
+func (r *Reader) readRecord(pos int64, pb proto.Message) error {
+	var lenBuf [binary.MaxVarintLen64]byte
+	n, err := r.f.ReadAt(lenBuf[:], pos)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	size, l := binary.Uvarint(lenBuf[:n])
+	if l <= 0 {
+		return fmt.Errorf("corrupt record at %d", pos)
+	}
+	data := make([]byte, size)
+	if _, err := r.f.ReadAt(data, pos+int64(l)); err != nil {
+		return err
+	}
+	return proto.Unmarshal(data, pb)
+}
+
+// Root returns the latest root of the archived log.
+func (r *Reader) Root() trillian.SignedLogRoot {
+	return r.root
+}
+
+// LeafCount returns the number of leaves in the archived log.
+func (r *Reader) LeafCount() int64 {
+	return r.footer.leafCount
+}
+
+// Leaf returns the leaf with the given sequence number.
+func (r *Reader) Leaf(index int64) (*trillian.LogLeaf, error) {
+	if index < 0 || index >= r.footer.leafCount {
+		return nil, fmt.Errorf("leaf %d out of range [0, %d)", index, r.footer.leafCount)
+	}
+	var buf [8]byte
+	if _, err := r.f.ReadAt(buf[:], r.footer.leafOffsetsPos+index*8); err != nil {
+		return nil, err
+	}
+	leaf := &trillian.LogLeaf{}
+	if err := r.readRecord(int64(binary.BigEndian.Uint64(buf[:])), leaf); err != nil {
+		return nil, err
+	}
+	return leaf, nil
+}
+
+// hashEntry returns the i'th entry of the hash index.
+func (r *Reader) hashEntry(i int64) (hashEntry, error) {
+	size := r.footer.hashSize + 8
+	b := make([]byte, size)
+	if _, err := r.f.ReadAt(b, r.footer.hashIndexPos+i*size); err != nil {
+		return hashEntry{}, err
+	}
+	return hashEntry{hash: b[:r.footer.hashSize], index: int64(binary.BigEndian.Uint64(b[r.footer.hashSize:]))}, nil
+}
+
+// LeavesByHash returns the leaves with the given Merkle leaf hash, in
+// sequence number order.
+func (r *Reader) LeavesByHash(hash []byte) ([]*trillian.LogLeaf, error) {
+	if int64(len(hash)) != r.footer.hashSize {
+		return nil, nil
+	}
+	var searchErr error
+	first := sort.Search(int(r.footer.leafCount), func(i int) bool {
+		e, err := r.hashEntry(int64(i))
+		if err != nil {
+			searchErr = err
+			return true
+		}
+		return bytes.Compare(e.hash, hash) >= 0
+	})
+	if searchErr != nil {
+		return nil, searchErr
+	}
+
+	var leaves []*trillian.LogLeaf
+	for i := int64(first); i < r.footer.leafCount; i++ {
+		e, err := r.hashEntry(i)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(e.hash, hash) {
+			break
+		}
+		leaf, err := r.Leaf(e.index)
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, leaf)
+	}
+	return leaves, nil
+}
+
+// Subtree returns the subtree with the given prefix, or nil if there's none.
+func (r *Reader) Subtree(prefix []byte) (*storagepb.SubtreeProto, error) {
+	pos, ok := r.subtrees[string(prefix)]
+	if !ok {
+		return nil, nil
+	}
+	subtree := &storagepb.SubtreeProto{}
+	if err := r.readRecord(pos, subtree); err != nil {
+		return nil, err
+	}
+	if subtree.Prefix == nil {
+		subtree.Prefix = []byte{}
+	}
+	return subtree, nil
+}
+
+// Close closes the archive file.
+func (r *Reader) Close() error {
+	return r.f.Close()
+}
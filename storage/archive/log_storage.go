@@ -0,0 +1,253 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package archive provides a cold storage tier for frozen logs, which only
+// ever serve reads. Each archived log is kept in one immutable file holding
+// its leaves, subtrees and latest root, with an index.
+package archive
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/google/trillian"
+	te "github.com/google/trillian/errors"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/cache"
+	"github.com/google/trillian/storage/storagepb"
+)
+
+// logStrata must match the strata the log's subtrees were written with.
+var logStrata = []int{8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8}
+
+// LogPath returns the path of the archive file for treeID in dir.
+func LogPath(dir string, treeID int64) string {
+	return filepath.Join(dir, fmt.Sprintf("%d.log", treeID))
+}
+
+// LogStorage is a read-only storage.LogStorage for logs archived in a
+// directory. Archive files are opened on first use and kept open.
+type LogStorage struct {
+	dir string
+
+	mu      sync.Mutex
+	readers map[int64]*Reader
+}
+
+// NewLogStorage returns a LogStorage for the logs archived in dir.
+func NewLogStorage(dir string) *LogStorage {
+	return &LogStorage{dir: dir, readers: make(map[int64]*Reader)}
+}
+
+// IsArchived returns whether treeID has been archived.
+func (s *LogStorage) IsArchived(treeID int64) (bool, error) {
+	s.mu.Lock()
+	_, ok := s.readers[treeID]
+	s.mu.Unlock()
+	if ok {
+		return true, nil
+	}
+	switch _, err := os.Stat(LogPath(s.dir, treeID)); {
+	case err == nil:
+		return true, nil
+	case os.IsNotExist(err):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// reader returns the Reader for treeID's archive file.
+func (s *LogStorage) reader(treeID int64) (*Reader, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, ok := s.readers[treeID]; ok {
+		return r, nil
+	}
+	r, err := OpenReader(LogPath(s.dir, treeID))
+	if os.IsNotExist(err) {
+		return nil, te.Errorf(te.NotFound, "log %d is not archived", treeID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.readers[treeID] = r
+	return r, nil
+}
+
+// Close closes all open archive files.
+func (s *LogStorage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for treeID, r := range s.readers {
+		r.Close()
+		delete(s.readers, treeID)
+	}
+	return nil
+}
+
+// CheckDatabaseAccessible checks the archive directory can be read.
+func (s *LogStorage) CheckDatabaseAccessible(ctx context.Context) error {
+	f, err := os.Open(s.dir)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// Snapshot returns a transaction which lists no logs, as archived logs are
+// frozen so never active.
+func (s *LogStorage) Snapshot(ctx context.Context) (storage.ReadOnlyLogTX, error) {
+	return &readOnlyLogTX{}, nil
+}
+
+// SnapshotForTree returns a transaction reading treeID's archive file.
+func (s *LogStorage) SnapshotForTree(ctx context.Context, treeID int64) (storage.ReadOnlyLogTreeTX, error) {
+	r, err := s.reader(treeID)
+	if err != nil {
+		return nil, err
+	}
+	// TODO: read hash algorithm from storage.
+	hasher, err := merkle.Factory(merkle.RFC6962SHA256Type)
+	if err != nil {
+		return nil, err
+	}
+	return &logTreeTX{
+		r:            r,
+		subtreeCache: cache.NewSubtreeCache(logStrata, cache.PopulateLogSubtreeNodes(hasher), cache.PrepareLogSubtreeWrite()),
+	}, nil
+}
+
+// BeginForTree always fails, as archived logs are read-only.
+func (s *LogStorage) BeginForTree(ctx context.Context, treeID int64) (storage.LogTreeTX, error) {
+	return nil, te.Errorf(te.FailedPrecondition, "log %d is archived and read-only", treeID)
+}
+
+type readOnlyLogTX struct{}
+
+func (t *readOnlyLogTX) Commit() error   { return nil }
+func (t *readOnlyLogTX) Rollback() error { return nil }
+func (t *readOnlyLogTX) Close() error    { return nil }
+
+func (t *readOnlyLogTX) GetActiveLogIDs() ([]int64, error) {
+	return []int64{}, nil
+}
+
+func (t *readOnlyLogTX) GetActiveLogIDsWithPendingWork() ([]int64, error) {
+	return []int64{}, nil
+}
+
+// logTreeTX reads an archived log. Archive files are immutable, so there's
+// nothing to commit or roll back.
+type logTreeTX struct {
+	r            *Reader
+	subtreeCache cache.SubtreeCache
+	closed       bool
+}
+
+func (t *logTreeTX) ReadRevision() int64 {
+	return t.r.Root().TreeRevision
+}
+
+func (t *logTreeTX) Commit() error {
+	t.closed = true
+	return nil
+}
+
+func (t *logTreeTX) Rollback() error {
+	t.closed = true
+	return nil
+}
+
+func (t *logTreeTX) Close() error {
+	t.closed = true
+	return nil
+}
+
+func (t *logTreeTX) IsOpen() bool {
+	return !t.closed
+}
+
+// getSubtrees reads the subtrees with the given IDs, skipping any that don't
+// exist.
+func (t *logTreeTX) getSubtrees(ids []storage.NodeID) ([]*storagepb.SubtreeProto, error) {
+	ret := make([]*storagepb.SubtreeProto, 0, len(ids))
+	for _, id := range ids {
+		if id.PrefixLenBits%8 != 0 {
+			return nil, fmt.Errorf("invalid subtree ID - not multiple of 8: %d", id.PrefixLenBits)
+		}
+		subtree, err := t.r.Subtree(id.Path[:id.PrefixLenBits/8])
+		if err != nil {
+			return nil, err
+		}
+		if subtree != nil {
+			ret = append(ret, subtree)
+		}
+	}
+	return ret, nil
+}
+
+// GetMerkleNodes returns the requested nodes. Only the latest revision of each
+// subtree is archived, which holds every node of earlier revisions too, so
+// treeRevision is ignored.
+func (t *logTreeTX) GetMerkleNodes(treeRevision int64, ids []storage.NodeID) ([]storage.Node, error) {
+	return t.subtreeCache.GetNodes(ids, t.getSubtrees)
+}
+
+func (t *logTreeTX) GetSequencedLeafCount() (int64, error) {
+	return t.r.LeafCount(), nil
+}
+
+func (t *logTreeTX) GetLeavesByIndex(leaves []int64) ([]*trillian.LogLeaf, error) {
+	ret := make([]*trillian.LogLeaf, 0, len(leaves))
+	for _, index := range leaves {
+		leaf, err := t.r.Leaf(index)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, leaf)
+	}
+	return ret, nil
+}
+
+func (t *logTreeTX) GetLeavesByHash(leafHashes [][]byte, orderBySequence bool) ([]*trillian.LogLeaf, error) {
+	var ret []*trillian.LogLeaf
+	for _, hash := range leafHashes {
+		leaves, err := t.r.LeavesByHash(hash)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, leaves...)
+	}
+	if orderBySequence {
+		sort.Sort(byLeafIndex(ret))
+	}
+	return ret, nil
+}
+
+func (t *logTreeTX) LatestSignedLogRoot() (trillian.SignedLogRoot, error) {
+	return t.r.Root(), nil
+}
+
+// byLeafIndex sorts leaves by sequence number.
+type byLeafIndex []*trillian.LogLeaf
+
+func (b byLeafIndex) Len() int           { return len(b) }
+func (b byLeafIndex) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byLeafIndex) Less(i, j int) bool { return b[i].LeafIndex < b[j].LeafIndex }
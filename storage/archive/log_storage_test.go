@@ -0,0 +1,262 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	te "github.com/google/trillian/errors"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/cache"
+	"github.com/google/trillian/storage/storagepb"
+)
+
+const maxTreeDepth = 64
+
+// leafValue returns the value of leaf i of test logs. Leaves 2 and 5 are
+// duplicates.
+func leafValue(i int64) []byte {
+	if i == 5 {
+		i = 2
+	}
+	return []byte(fmt.Sprintf("leaf %d", i))
+}
+
+// writeTestLog archives a log of size leaves in dir, returning its root.
+func writeTestLog(t *testing.T, dir string, treeID, size int64) trillian.SignedLogRoot {
+	hasher, err := merkle.Factory(merkle.RFC6962SHA256Type)
+	if err != nil {
+		t.Fatalf("merkle.Factory() = (_, %v), want = (_, nil)", err)
+	}
+	w, err := NewWriter(LogPath(dir, treeID))
+	if err != nil {
+		t.Fatalf("NewWriter() = (_, %v), want = (_, nil)", err)
+	}
+
+	cmt := merkle.NewCompactMerkleTree(hasher)
+	sc := cache.NewSubtreeCache(logStrata, cache.PopulateLogSubtreeNodes(hasher), cache.PrepareLogSubtreeWrite())
+	noSubtree := func(storage.NodeID) (*storagepb.SubtreeProto, error) { return nil, nil }
+	setNode := func(depth int, index int64, hash []byte) error {
+		nodeID, err := storage.NewNodeIDForTreeCoords(int64(depth), index, maxTreeDepth)
+		if err != nil {
+			return err
+		}
+		return sc.SetNodeHash(nodeID, hash, noSubtree)
+	}
+	for i := int64(0); i < size; i++ {
+		leaf := &trillian.LogLeaf{
+			LeafIndex:        i,
+			LeafValue:        leafValue(i),
+			LeafIdentityHash: []byte(fmt.Sprintf("identity %d", i)),
+			MerkleLeafHash:   hasher.HashLeaf(leafValue(i)),
+		}
+		if _, err := cmt.AddLeafHash(leaf.MerkleLeafHash, setNode); err != nil {
+			t.Fatalf("AddLeafHash() = (_, %v), want = (_, nil)", err)
+		}
+		if err := w.AddLeaf(leaf); err != nil {
+			t.Fatalf("AddLeaf(%d) = %v, want = nil", i, err)
+		}
+	}
+	if err := sc.Flush(func(subtrees []*storagepb.SubtreeProto) error {
+		for _, s := range subtrees {
+			if err := w.AddSubtree(s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("Flush() = %v, want = nil", err)
+	}
+
+	root := trillian.SignedLogRoot{LogId: treeID, TreeSize: size, RootHash: cmt.CurrentRoot(), TreeRevision: 3}
+	if err := w.SetRoot(root); err != nil {
+		t.Fatalf("SetRoot() = %v, want = nil", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() = %v, want = nil", err)
+	}
+	return root
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "archive")
+	if err != nil {
+		t.Fatalf("TempDir() = (_, %v), want = (_, nil)", err)
+	}
+	return dir
+}
+
+func TestWriterRejectsInvalidArchives(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := LogPath(dir, 1)
+
+	w, err := NewWriter(path)
+	if err != nil {
+		t.Fatalf("NewWriter() = (_, %v), want = (_, nil)", err)
+	}
+	if err := w.AddLeaf(&trillian.LogLeaf{LeafIndex: 1, MerkleLeafHash: []byte("hash")}); err == nil {
+		t.Error("AddLeaf() of leaf 1 first = nil, want error")
+	}
+	if err := w.SetRoot(trillian.SignedLogRoot{TreeSize: 1}); err == nil {
+		t.Error("SetRoot() with too large tree size = nil, want error")
+	}
+	if err := w.Close(); err == nil {
+		t.Error("Close() without root = nil, want error")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Stat() of aborted archive = %v, want not found", err)
+	}
+}
+
+func TestLogStorageReads(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	ctx := context.Background()
+	const treeID, size = 10, 300
+	wantRoot := writeTestLog(t, dir, treeID, size)
+
+	s := NewLogStorage(dir)
+	defer s.Close()
+	tx, err := s.SnapshotForTree(ctx, treeID)
+	if err != nil {
+		t.Fatalf("SnapshotForTree() = (_, %v), want = (_, nil)", err)
+	}
+	defer tx.Close()
+
+	root, err := tx.LatestSignedLogRoot()
+	if err != nil || !proto.Equal(&root, &wantRoot) {
+		t.Errorf("LatestSignedLogRoot() = (%v, %v), want = (%v, nil)", root, err, wantRoot)
+	}
+	if got, want := tx.ReadRevision(), wantRoot.TreeRevision; got != want {
+		t.Errorf("ReadRevision() = %v, want = %v", got, want)
+	}
+	if count, err := tx.GetSequencedLeafCount(); err != nil || count != size {
+		t.Errorf("GetSequencedLeafCount() = (%v, %v), want = (%v, nil)", count, err, size)
+	}
+
+	leaves, err := tx.GetLeavesByIndex([]int64{299, 0})
+	if err != nil {
+		t.Fatalf("GetLeavesByIndex() = (_, %v), want = (_, nil)", err)
+	}
+	for i, index := range []int64{299, 0} {
+		if got, want := leaves[i].LeafValue, leafValue(index); leaves[i].LeafIndex != index || !reflect.DeepEqual(got, want) {
+			t.Errorf("GetLeavesByIndex()[%d] = leaf %d with value %q, want leaf %d with value %q", i, leaves[i].LeafIndex, got, index, want)
+		}
+	}
+	if _, err := tx.GetLeavesByIndex([]int64{size}); err == nil {
+		t.Error("GetLeavesByIndex() beyond tree size = (_, nil), want error")
+	}
+
+	hasher, _ := merkle.Factory(merkle.RFC6962SHA256Type)
+	leaves, err = tx.GetLeavesByHash([][]byte{hasher.HashLeaf(leafValue(7)), hasher.HashLeaf(leafValue(2))}, true)
+	if err != nil {
+		t.Fatalf("GetLeavesByHash() = (_, %v), want = (_, nil)", err)
+	}
+	var indexes []int64
+	for _, leaf := range leaves {
+		indexes = append(indexes, leaf.LeafIndex)
+	}
+	if want := []int64{2, 5, 7}; !reflect.DeepEqual(indexes, want) {
+		t.Errorf("GetLeavesByHash() returned leaves %v, want %v", indexes, want)
+	}
+
+	// Inclusion proofs at a size spanning both bottom subtrees must verify
+	// against the root of the full log's first 256 leaves.
+	const snapshot = 256
+	snapshotRoot := merkle.NewCompactMerkleTree(hasher)
+	for i := int64(0); i < snapshot; i++ {
+		snapshotRoot.AddLeafHash(hasher.HashLeaf(leafValue(i)), func(int, int64, []byte) error { return nil })
+	}
+	verifier := merkle.NewLogVerifier(hasher)
+	for _, index := range []int64{0, 100, 255} {
+		fetches, err := merkle.CalcInclusionProofNodeAddresses(snapshot, index, size, maxTreeDepth)
+		if err != nil {
+			t.Fatalf("CalcInclusionProofNodeAddresses() = (_, %v), want = (_, nil)", err)
+		}
+		var ids []storage.NodeID
+		for _, f := range fetches {
+			ids = append(ids, f.NodeID)
+		}
+		nodes, err := tx.GetMerkleNodes(tx.ReadRevision(), ids)
+		if err != nil || len(nodes) != len(ids) {
+			t.Fatalf("GetMerkleNodes() = (%d nodes, %v), want = (%d nodes, nil)", len(nodes), err, len(ids))
+		}
+		var proof [][]byte
+		for _, n := range nodes {
+			proof = append(proof, n.Hash)
+		}
+		if err := verifier.VerifyInclusionProof(index, snapshot, proof, snapshotRoot.CurrentRoot(), hasher.HashLeaf(leafValue(index))); err != nil {
+			t.Errorf("VerifyInclusionProof(%d) = %v, want = nil", index, err)
+		}
+	}
+
+	if _, err := s.BeginForTree(ctx, treeID); te.ErrorCode(err) != te.FailedPrecondition {
+		t.Errorf("BeginForTree() = (_, %v), want FailedPrecondition error", err)
+	}
+	if _, err := s.SnapshotForTree(ctx, treeID+1); te.ErrorCode(err) != te.NotFound {
+		t.Errorf("SnapshotForTree() of unarchived log = (_, %v), want NotFound error", err)
+	}
+}
+
+func TestTieredLogStorage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	ctx := context.Background()
+	const archivedID, primaryID = 1, 2
+	writeTestLog(t, dir, archivedID, 10)
+
+	primary := storage.NewMockLogStorage(ctrl)
+	s := NewTieredLogStorage(primary, NewLogStorage(dir))
+
+	primaryTX := storage.NewMockLogTreeTX(ctrl)
+	primary.EXPECT().BeginForTree(gomock.Any(), int64(primaryID)).Return(primaryTX, nil)
+	if tx, err := s.BeginForTree(ctx, primaryID); err != nil || tx != primaryTX {
+		t.Errorf("BeginForTree(%d) = (%v, %v), want = (%v, nil)", primaryID, tx, err, primaryTX)
+	}
+	if _, err := s.BeginForTree(ctx, archivedID); err == nil {
+		t.Errorf("BeginForTree(%d) = (_, nil), want error for archived log", archivedID)
+	}
+
+	tx, err := s.SnapshotForTree(ctx, archivedID)
+	if err != nil {
+		t.Fatalf("SnapshotForTree(%d) = (_, %v), want = (_, nil)", archivedID, err)
+	}
+	if count, err := tx.GetSequencedLeafCount(); err != nil || count != 10 {
+		t.Errorf("GetSequencedLeafCount() = (%v, %v), want = (10, nil)", count, err)
+	}
+
+	primaryLogTX := storage.NewMockReadOnlyLogTX(ctrl)
+	primary.EXPECT().Snapshot(gomock.Any()).Return(primaryLogTX, nil)
+	primaryLogTX.EXPECT().GetActiveLogIDs().Return([]int64{archivedID, primaryID}, nil)
+	logTX, err := s.Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("Snapshot() = (_, %v), want = (_, nil)", err)
+	}
+	if ids, err := logTX.GetActiveLogIDs(); err != nil || !reflect.DeepEqual(ids, []int64{primaryID}) {
+		t.Errorf("GetActiveLogIDs() = (%v, %v), want = (%v, nil)", ids, err, []int64{primaryID})
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"context"
+
+	"github.com/google/trillian/storage"
+)
+
+// NewTieredLogStorage returns a storage.LogStorage which reads archived logs
+// from archive and every other log from primary. A log's archive file is
+// written before its rows are deleted from primary, so reads never miss it.
+func NewTieredLogStorage(primary storage.LogStorage, archive *LogStorage) storage.LogStorage {
+	return &tieredLogStorage{primary: primary, archive: archive}
+}
+
+type tieredLogStorage struct {
+	primary storage.LogStorage
+	archive *LogStorage
+}
+
+func (s *tieredLogStorage) CheckDatabaseAccessible(ctx context.Context) error {
+	if err := s.primary.CheckDatabaseAccessible(ctx); err != nil {
+		return err
+	}
+	return s.archive.CheckDatabaseAccessible(ctx)
+}
+
+// Snapshot returns primary's transaction, leaving archived logs out of the
+// active logs, so they're never sequenced.
+func (s *tieredLogStorage) Snapshot(ctx context.Context) (storage.ReadOnlyLogTX, error) {
+	tx, err := s.primary.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &tieredLogTX{ReadOnlyLogTX: tx, archive: s.archive}, nil
+}
+
+func (s *tieredLogStorage) SnapshotForTree(ctx context.Context, treeID int64) (storage.ReadOnlyLogTreeTX, error) {
+	archived, err := s.archive.IsArchived(treeID)
+	if err != nil {
+		return nil, err
+	}
+	if archived {
+		return s.archive.SnapshotForTree(ctx, treeID)
+	}
+	return s.primary.SnapshotForTree(ctx, treeID)
+}
+
+func (s *tieredLogStorage) BeginForTree(ctx context.Context, treeID int64) (storage.LogTreeTX, error) {
+	archived, err := s.archive.IsArchived(treeID)
+	if err != nil {
+		return nil, err
+	}
+	if archived {
+		return s.archive.BeginForTree(ctx, treeID)
+	}
+	return s.primary.BeginForTree(ctx, treeID)
+}
+
+type tieredLogTX struct {
+	storage.ReadOnlyLogTX
+	archive *LogStorage
+}
+
+func (t *tieredLogTX) GetActiveLogIDs() ([]int64, error) {
+	logIDs, err := t.ReadOnlyLogTX.GetActiveLogIDs()
+	if err != nil {
+		return nil, err
+	}
+	return t.withoutArchived(logIDs)
+}
+
+func (t *tieredLogTX) GetActiveLogIDsWithPendingWork() ([]int64, error) {
+	logIDs, err := t.ReadOnlyLogTX.GetActiveLogIDsWithPendingWork()
+	if err != nil {
+		return nil, err
+	}
+	return t.withoutArchived(logIDs)
+}
+
+func (t *tieredLogTX) withoutArchived(logIDs []int64) ([]int64, error) {
+	ret := make([]int64, 0, len(logIDs))
+	for _, id := range logIDs {
+		archived, err := t.archive.IsArchived(id)
+		if err != nil {
+			return nil, err
+		}
+		if !archived {
+			ret = append(ret, id)
+		}
+	}
+	return ret, nil
+}
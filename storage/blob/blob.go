@@ -0,0 +1,106 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package blob provides content-addressed stores for large leaf values, which
+// storage implementations keep out of their own tables, referencing them by
+// key instead.
+package blob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	te "github.com/google/trillian/errors"
+)
+
+// Store is a content-addressed store of blobs. Implementations must be safe
+// for concurrent use.
+type Store interface {
+	// Put stores value under key, which must be Key(value). Putting a blob
+	// which is already stored updates its modification time, so it's not
+	// collected while a new reference to it is being written.
+	Put(ctx context.Context, key string, value []byte) error
+	// Get returns the blob stored under key, or a NotFound error.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete deletes the blob stored under key if it was last put before
+	// cutoff, returning whether it was deleted.
+	Delete(ctx context.Context, key string, cutoff time.Time) (bool, error)
+	// List returns every stored blob.
+	List(ctx context.Context) ([]Info, error)
+}
+
+// Info describes a stored blob.
+type Info struct {
+	Key      string
+	Modified time.Time
+}
+
+// Key returns the key a blob holding value is stored under, the hex encoded
+// SHA-256 hash of value.
+func Key(value []byte) string {
+	h := sha256.Sum256(value)
+	return hex.EncodeToString(h[:])
+}
+
+// validKey returns an error if key could not have been returned by Key.
+func validKey(key string) error {
+	if b, err := hex.DecodeString(key); err != nil || len(b) != sha256.Size {
+		return te.Errorf(te.InvalidArgument, "invalid blob key %q", key)
+	}
+	return nil
+}
+
+// Offloader moves values larger than a threshold into a Store. A nil
+// *Offloader never offloads values.
+type Offloader struct {
+	// Store holds offloaded values.
+	Store Store
+	// Threshold is the size in bytes above which values are offloaded.
+	Threshold int
+}
+
+// Offload returns the value to store inline in place of value, and the key
+// of the blob holding value if it was offloaded, or "" if it wasn't.
+func (o *Offloader) Offload(ctx context.Context, value []byte) ([]byte, string, error) {
+	if o == nil || len(value) <= o.Threshold {
+		return value, "", nil
+	}
+	key := Key(value)
+	if err := o.Store.Put(ctx, key, value); err != nil {
+		return nil, "", fmt.Errorf("failed to offload value to blob %s: %v", key, err)
+	}
+	return []byte{}, key, nil
+}
+
+// Resolve returns the value stored inline, or, if key isn't empty, the value
+// offloaded to the blob with key.
+func (o *Offloader) Resolve(ctx context.Context, inline []byte, key string) ([]byte, error) {
+	if len(key) == 0 {
+		return inline, nil
+	}
+	if o == nil {
+		return nil, te.Errorf(te.FailedPrecondition, "value is offloaded to blob %s, but no blob store is configured", key)
+	}
+	value, err := o.Store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if got := Key(value); got != key {
+		return nil, te.Errorf(te.DataLoss, "blob %s is corrupt: content hashes to %s", key, got)
+	}
+	return value, nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	te "github.com/google/trillian/errors"
+)
+
+func testStores(t *testing.T) (map[string]Store, func()) {
+	dir, err := ioutil.TempDir("", "blob")
+	if err != nil {
+		t.Fatalf("TempDir() = (_, %v), want = (_, nil)", err)
+	}
+	stores := map[string]Store{
+		"memory": NewMemoryStore(),
+		"file":   NewFileStore(dir),
+	}
+	return stores, func() { os.RemoveAll(dir) }
+}
+
+func TestStores(t *testing.T) {
+	stores, cleanup := testStores(t)
+	defer cleanup()
+	ctx := context.Background()
+	value := []byte("a large value")
+	key := Key(value)
+
+	for name, s := range stores {
+		if _, err := s.Get(ctx, key); te.ErrorCode(err) != te.NotFound {
+			t.Errorf("%s: Get() before Put() = (_, %v), want NotFound error", name, err)
+		}
+		if err := s.Put(ctx, "../escape", value); te.ErrorCode(err) != te.InvalidArgument {
+			t.Errorf("%s: Put() with invalid key = %v, want InvalidArgument error", name, err)
+		}
+		for i := 0; i < 2; i++ {
+			if err := s.Put(ctx, key, value); err != nil {
+				t.Fatalf("%s: Put() = %v, want = nil", name, err)
+			}
+		}
+		if got, err := s.Get(ctx, key); err != nil || !bytes.Equal(got, value) {
+			t.Errorf("%s: Get() = (%q, %v), want = (%q, nil)", name, got, err, value)
+		}
+		infos, err := s.List(ctx)
+		if err != nil || len(infos) != 1 || infos[0].Key != key {
+			t.Errorf("%s: List() = (%v, %v), want one blob %s", name, infos, err, key)
+		}
+
+		if ok, err := s.Delete(ctx, key, time.Now().Add(-time.Hour)); err != nil || ok {
+			t.Errorf("%s: Delete() before blob was put = (%v, %v), want = (false, nil)", name, ok, err)
+		}
+		if ok, err := s.Delete(ctx, key, time.Now().Add(time.Hour)); err != nil || !ok {
+			t.Errorf("%s: Delete() = (%v, %v), want = (true, nil)", name, ok, err)
+		}
+		if _, err := s.Get(ctx, key); te.ErrorCode(err) != te.NotFound {
+			t.Errorf("%s: Get() after Delete() = (_, %v), want NotFound error", name, err)
+		}
+	}
+}
+
+func TestOffloader(t *testing.T) {
+	ctx := context.Background()
+	o := &Offloader{Store: NewMemoryStore(), Threshold: 4}
+	for _, value := range [][]byte{[]byte("tiny"), []byte("large value")} {
+		inline, key, err := o.Offload(ctx, value)
+		if err != nil {
+			t.Fatalf("Offload(%q) = (_, _, %v), want = (_, _, nil)", value, err)
+		}
+		if offloaded := len(value) > o.Threshold; offloaded != (len(key) > 0) || offloaded != (len(inline) == 0) {
+			t.Errorf("Offload(%q) = (%q, %q, nil), want offloaded = %v", value, inline, key, offloaded)
+		}
+		if got, err := o.Resolve(ctx, inline, key); err != nil || !bytes.Equal(got, value) {
+			t.Errorf("Resolve() = (%q, %v), want = (%q, nil)", got, err, value)
+		}
+	}
+
+	var none *Offloader
+	if inline, key, err := none.Offload(ctx, []byte("large value")); err != nil || len(key) > 0 || string(inline) != "large value" {
+		t.Errorf("nil Offload() = (%q, %q, %v), want value inline", inline, key, err)
+	}
+	if _, err := none.Resolve(ctx, nil, Key([]byte("x"))); te.ErrorCode(err) != te.FailedPrecondition {
+		t.Errorf("nil Resolve() of offloaded value = (_, %v), want FailedPrecondition error", err)
+	}
+
+	corrupt := Key([]byte("original"))
+	o.Store.Put(ctx, corrupt, []byte("tampered"))
+	if _, err := o.Resolve(ctx, nil, corrupt); te.ErrorCode(err) != te.DataLoss {
+		t.Errorf("Resolve() of corrupt blob = (_, %v), want DataLoss error", err)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	stores, cleanup := testStores(t)
+	defer cleanup()
+	ctx := context.Background()
+	referenced, unreferenced := []byte("referenced"), []byte("unreferenced")
+	refs := func(context.Context) (map[string]bool, error) {
+		return map[string]bool{Key(referenced): true}, nil
+	}
+
+	for name, s := range stores {
+		for _, value := range [][]byte{referenced, unreferenced} {
+			if err := s.Put(ctx, Key(value), value); err != nil {
+				t.Fatalf("%s: Put() = %v, want = nil", name, err)
+			}
+		}
+		if n, err := Collect(ctx, s, refs, time.Now().Add(-time.Hour)); err != nil || n != 0 {
+			t.Errorf("%s: Collect() of new blobs = (%v, %v), want = (0, nil)", name, n, err)
+		}
+		if n, err := Collect(ctx, s, refs, time.Now().Add(time.Hour)); err != nil || n != 1 {
+			t.Errorf("%s: Collect() = (%v, %v), want = (1, nil)", name, n, err)
+		}
+		if _, err := s.Get(ctx, Key(referenced)); err != nil {
+			t.Errorf("%s: Get() of referenced blob after Collect() = (_, %v), want = (_, nil)", name, err)
+		}
+		if _, err := s.Get(ctx, Key(unreferenced)); te.ErrorCode(err) != te.NotFound {
+			t.Errorf("%s: Get() of unreferenced blob after Collect() = (_, %v), want NotFound error", name, err)
+		}
+	}
+}
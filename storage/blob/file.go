@@ -0,0 +1,141 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	te "github.com/google/trillian/errors"
+)
+
+// FileStore is a Store keeping each blob in a file under a directory, which
+// may be shared by several servers, e.g. over NFS. Blobs are spread across
+// subdirectories named after the first two characters of their keys.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore returns a FileStore keeping blobs under dir, which must exist.
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+func (s *FileStore) path(key string) string {
+	return filepath.Join(s.dir, key[:2], key)
+}
+
+// Put writes value to a temporary file, which is renamed into place so that
+// readers never see a partial blob.
+func (s *FileStore) Put(ctx context.Context, key string, value []byte) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+	path := s.path(key)
+	now := time.Now()
+	switch err := os.Chtimes(path, now, now); {
+	case err == nil:
+		return nil
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), ".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(value); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// Get reads the blob stored under key.
+func (s *FileStore) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+	value, err := ioutil.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, te.Errorf(te.NotFound, "blob %s not found", key)
+	}
+	return value, err
+}
+
+// Delete deletes the blob stored under key if it was last put before cutoff.
+// A Put racing with Delete may still lose its blob, so collection relies on
+// cutoff being well before any Put whose reference isn't yet committed.
+func (s *FileStore) Delete(ctx context.Context, key string, cutoff time.Time) (bool, error) {
+	if err := validKey(key); err != nil {
+		return false, err
+	}
+	path := s.path(key)
+	fi, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !fi.ModTime().Before(cutoff) {
+		return false, nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	return true, nil
+}
+
+// List returns every stored blob, skipping any files being written.
+func (s *FileStore) List(ctx context.Context) ([]Info, error) {
+	dirs, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var ret []Info
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		files, err := ioutil.ReadDir(filepath.Join(s.dir, d.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if f.IsDir() || strings.HasPrefix(f.Name(), ".") || validKey(f.Name()) != nil {
+				continue
+			}
+			ret = append(ret, Info{Key: f.Name(), Modified: f.ModTime()})
+		}
+	}
+	return ret, nil
+}
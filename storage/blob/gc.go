@@ -0,0 +1,61 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// ReferencesFunc returns the keys of every blob referenced by storage.
+type ReferencesFunc func(ctx context.Context) (map[string]bool, error)
+
+// Collect deletes the blobs in s which aren't referenced and were last put
+// before cutoff, returning how many were deleted.
+//
+// Values are offloaded before the rows referencing them are committed, so a
+// blob put after cutoff may be about to be referenced. Cutoff must be earlier
+// than the start of any transaction still in progress. Blobs are listed
+// before references are read, so references committed in between are seen,
+// and Delete rechecks cutoff, so blobs put again since they were listed are
+// kept.
+func Collect(ctx context.Context, s Store, references ReferencesFunc, cutoff time.Time) (int, error) {
+	blobs, err := s.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	refs, err := references(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, b := range blobs {
+		if refs[b.Key] || !b.Modified.Before(cutoff) {
+			continue
+		}
+		ok, err := s.Delete(ctx, b.Key, cutoff)
+		if err != nil {
+			return deleted, err
+		}
+		if ok {
+			glog.V(1).Infof("Deleted unreferenced blob %s", b.Key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	te "github.com/google/trillian/errors"
+)
+
+type memoryBlob struct {
+	value    []byte
+	modified time.Time
+}
+
+// MemoryStore is a Store holding blobs in memory, for tests and
+// single-process deployments whose storage is also in memory.
+type MemoryStore struct {
+	mu    sync.Mutex
+	blobs map[string]memoryBlob
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{blobs: make(map[string]memoryBlob)}
+}
+
+// Put stores a copy of value under key.
+func (s *MemoryStore) Put(ctx context.Context, key string, value []byte) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.blobs[key]
+	if !ok {
+		b.value = append([]byte{}, value...)
+	}
+	b.modified = time.Now()
+	s.blobs[key] = b
+	return nil
+}
+
+// Get returns a copy of the blob stored under key.
+func (s *MemoryStore) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.blobs[key]
+	if !ok {
+		return nil, te.Errorf(te.NotFound, "blob %s not found", key)
+	}
+	return append([]byte{}, b.value...), nil
+}
+
+// Delete deletes the blob stored under key if it was last put before cutoff.
+func (s *MemoryStore) Delete(ctx context.Context, key string, cutoff time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.blobs[key]
+	if !ok || !b.modified.Before(cutoff) {
+		return false, nil
+	}
+	delete(s.blobs, key)
+	return true, nil
+}
+
+// List returns every stored blob.
+func (s *MemoryStore) List(ctx context.Context) ([]Info, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ret := make([]Info, 0, len(s.blobs))
+	for key, b := range s.blobs {
+		ret = append(ret, Info{Key: key, Modified: b.modified})
+	}
+	return ret, nil
+}
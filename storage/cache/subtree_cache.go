@@ -23,6 +23,7 @@ import (
 
 	"github.com/golang/glog"
 	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/monitoring/metric"
 	"github.com/google/trillian/storage"
 	"github.com/google/trillian/storage/storagepb"
 )
@@ -36,6 +37,25 @@ type GetSubtreesFunc func(ids []storage.NodeID) ([]*storagepb.SubtreeProto, erro
 // SetSubtreesFunc describes a function which can store a collection of Subtrees into storage.
 type SetSubtreesFunc func(s []*storagepb.SubtreeProto) error
 
+// Names of the metrics which describe the behaviour of all the SubtreeCaches
+// in a process.
+const (
+	// NodeLookupsMetric counts node hashes read or written through a cache.
+	NodeLookupsMetric = "subtree_cache_node_lookups"
+	// SubtreeReadsMetric counts subtrees read from storage on a cache miss.
+	SubtreeReadsMetric = "subtree_cache_subtree_reads"
+	// SubtreesFlushedMetric counts subtrees written back to storage.
+	SubtreesFlushedMetric = "subtree_cache_subtrees_flushed"
+)
+
+// Adding to a counter is atomic, so nodeLookups can be counted on every lookup
+// without the caches contending.
+var (
+	nodeLookups     = metric.NewCounter(NodeLookupsMetric)
+	subtreeReads    = metric.NewCounter(SubtreeReadsMetric)
+	subtreesFlushed = metric.NewCounter(SubtreesFlushedMetric)
+)
+
 // stratumInfo represents a single stratum across the tree.
 // It it used inside the SubtreeCache to determine which Subtree prefix should
 // be used for a given NodeID.
@@ -189,6 +209,7 @@ func (s *SubtreeCache) preload(ids []storage.NodeID, getSubtrees GetSubtreesFunc
 	for _, v := range want {
 		list = append(list, *v)
 	}
+	subtreeReads.Add(int64(len(list)))
 	subtrees, err := getSubtrees(list)
 	if err != nil {
 		return err
@@ -242,6 +263,7 @@ func (s *SubtreeCache) GetNodes(ids []storage.NodeID, getSubtrees GetSubtreesFun
 func (s *SubtreeCache) GetNodeHash(id storage.NodeID, getSubtree GetSubtreeFunc) ([]byte, error) {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
+	nodeLookups.Add(1)
 	return s.getNodeHashUnderLock(id, getSubtree)
 }
 
@@ -255,6 +277,7 @@ func (s *SubtreeCache) getNodeHashUnderLock(id storage.NodeID, getSubtree GetSub
 		subID := id
 		subID.PrefixLenBits = len(px) * depthQuantum // this won't work if depthQuantum changes
 		var err error
+		subtreeReads.Add(1)
 		c, err = getSubtree(subID)
 		if err != nil {
 			return nil, err
@@ -308,6 +331,7 @@ func (s *SubtreeCache) getNodeHashUnderLock(id storage.NodeID, getSubtree GetSub
 func (s *SubtreeCache) SetNodeHash(id storage.NodeID, h []byte, getSubtree GetSubtreeFunc) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
+	nodeLookups.Add(1)
 	px, sx := s.splitNodeID(id)
 	prefixKey := string(px)
 	c := s.subtrees[prefixKey]
@@ -369,6 +393,7 @@ func (s *SubtreeCache) Flush(setSubtrees SetSubtreesFunc) error {
 	if len(treesToWrite) == 0 {
 		return nil
 	}
+	subtreesFlushed.Add(int64(len(treesToWrite)))
 	return setSubtrees(treesToWrite)
 }
 
@@ -23,6 +23,7 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/monitoring/metric"
 	"github.com/google/trillian/storage"
 	"github.com/google/trillian/storage/storagepb"
 	"github.com/google/trillian/testonly"
@@ -95,6 +96,8 @@ func TestCacheFillOnlyReadsSubtrees(t *testing.T) {
 		si++
 	}
 
+	readsBefore, lookupsBefore := metric.Value(SubtreeReadsMetric), metric.Value(NodeLookupsMetric)
+	lookups := nodeID.PrefixLenBits
 	for nodeID.PrefixLenBits > 0 {
 		_, err := c.GetNodeHash(nodeID, m.GetSubtree)
 		if err != nil {
@@ -102,6 +105,13 @@ func TestCacheFillOnlyReadsSubtrees(t *testing.T) {
 		}
 		nodeID.PrefixLenBits--
 	}
+
+	if got, want := metric.Value(SubtreeReadsMetric)-readsBefore, int64(si); got != want {
+		t.Errorf("%s increased by %d, want %d", SubtreeReadsMetric, got, want)
+	}
+	if got, want := metric.Value(NodeLookupsMetric)-lookupsBefore, int64(lookups); got != want {
+		t.Errorf("%s increased by %d, want %d", NodeLookupsMetric, got, want)
+	}
 }
 
 func TestCacheGetNodesReadsSubtrees(t *testing.T) {
@@ -16,6 +16,7 @@ package storage
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/google/trillian"
@@ -57,6 +58,7 @@ type LogTreeTX interface {
 	LeafReader
 	LeafQueuer
 	LeafDequeuer
+	DeadLetterQueue
 	LogMetadata
 }
 
@@ -97,16 +99,64 @@ type LeafQueuer interface {
 	QueueLeaves(leaves []*trillian.LogLeaf, queueTimestamp time.Time) ([]*trillian.LogLeaf, error)
 }
 
+// PriorityLanes lists the priority classes of queued leaves, each of which is queued in
+// its own lane, from highest priority to lowest.
+var PriorityLanes = []trillian.LeafPriority{
+	trillian.LeafPriority_PRIORITY_HIGH,
+	trillian.LeafPriority_PRIORITY_NORMAL,
+	trillian.LeafPriority_PRIORITY_BULK,
+}
+
+// PriorityLane returns the index in PriorityLanes of the lane for leaves queued with
+// priority.
+func PriorityLane(priority trillian.LeafPriority) (int, error) {
+	for i, p := range PriorityLanes {
+		if p == priority {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown leaf priority: %v", priority)
+}
+
 // LeafDequeuer provides an interface for reading previously queued leaves for integration into the tree.
 type LeafDequeuer interface {
 	// DequeueLeaves will return between [0, limit] leaves from the queue.
 	// Leaves which have been dequeued within a Rolled-back Tx will become available for dequeing again.
 	// Leaves queued more recently than the cutoff time will not be returned. This allows for
 	// guard intervals to be configured.
+	// Leaves are dequeued from the lanes of PriorityLanes in order, and in order of queue
+	// time within each lane.
 	DequeueLeaves(limit int, cutoffTime time.Time) ([]*trillian.LogLeaf, error)
+	// DequeuePriorityLeaves is like DequeueLeaves, but only returns leaves queued with
+	// the given priority.
+	DequeuePriorityLeaves(priority trillian.LeafPriority, limit int, cutoffTime time.Time) ([]*trillian.LogLeaf, error)
+	// NewestQueueTimestamp returns the queue time of the most recently queued leaf
+	// which is still queued, whatever the cutoff time, or the zero time if there is
+	// none.
+	NewestQueueTimestamp() (time.Time, error)
+	// UpdateSequencedLeaves stores the sequence numbers assigned to leaves. Leaves
+	// which are invalid or break a constraint of the storage, e.g. by duplicating a
+	// leaf already in the log, are reported by an error with the InvalidArgument or
+	// AlreadyExists code of the trillian errors package.
 	UpdateSequencedLeaves(leaves []*trillian.LogLeaf) error
 }
 
+// DeadLetterQueue provides an interface for setting aside queued leaves which the
+// sequencer fails to integrate, so that they don't stall the rest of the queue.
+type DeadLetterQueue interface {
+	// DeadLetterLeaves removes the leaves from the queue and stores them, along with the
+	// error that prevented their integration, until they're requeued or discarded.
+	DeadLetterLeaves(leaves []*trillian.DeadLetterLeaf) error
+	// GetDeadLetterLeaves returns up to limit dead-lettered leaves, oldest first.
+	GetDeadLetterLeaves(limit int) ([]*trillian.DeadLetterLeaf, error)
+	// RequeueDeadLetterLeaves moves the dead-lettered leaves with the given identity hashes
+	// back to the queue, with the given queue time. Unknown hashes are ignored.
+	RequeueDeadLetterLeaves(leafIdentityHashes [][]byte, queueTimestamp time.Time) error
+	// DiscardDeadLetterLeaves deletes the dead-lettered leaves with the given identity
+	// hashes, so they're never integrated. Unknown hashes are ignored.
+	DiscardDeadLetterLeaves(leafIdentityHashes [][]byte) error
+}
+
 // LeafReader provides a read only interface to stored tree leaves
 type LeafReader interface {
 	// GetSequencedLeafCount returns the total number of leaves that have been integrated into the
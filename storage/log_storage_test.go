@@ -0,0 +1,42 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/google/trillian"
+)
+
+func TestPriorityLane(t *testing.T) {
+	for name, value := range trillian.LeafPriority_value {
+		priority := trillian.LeafPriority(value)
+		lane, err := PriorityLane(priority)
+		if err != nil {
+			t.Errorf("PriorityLane(%s)=%v, want no error", name, err)
+			continue
+		}
+		if got := PriorityLanes[lane]; got != priority {
+			t.Errorf("PriorityLanes[PriorityLane(%s)]=%v", name, got)
+		}
+	}
+	if _, err := PriorityLane(trillian.LeafPriority(42)); err == nil {
+		t.Error("PriorityLane(42) succeeded, want error")
+	}
+	// Leaves queued before priorities existed are in the default lane.
+	if lane, _ := PriorityLane(trillian.LeafPriority_PRIORITY_NORMAL); lane != 1 {
+		t.Errorf("PriorityLane(PRIORITY_NORMAL)=%d, want 1 to match the storage default", lane)
+	}
+}
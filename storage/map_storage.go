@@ -16,6 +16,7 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/trillian"
 )
@@ -40,6 +41,8 @@ type ReadOnlyMapTreeTX interface {
 	ReadOnlyTreeTX
 	MapRootReader
 	Getter
+	HistoryGetter
+	MutationReader
 }
 
 // MapTreeTX is the transactional interface for reading/modifying a Map.
@@ -52,7 +55,10 @@ type MapTreeTX interface {
 	MapRootReader
 	MapRootWriter
 	Getter
+	HistoryGetter
 	Setter
+	MutationReader
+	MutationQueuer
 }
 
 // ReadOnlyMapStorage provides a narrow read-only view into a MapStorage.
@@ -96,10 +102,28 @@ type Getter interface {
 	Get(revision int64, keyHashes [][]byte) ([]trillian.MapLeaf, error)
 }
 
+// MapLeafRevision is a value of a leaf, and the map revision at which it was set.
+type MapLeafRevision struct {
+	Revision int64
+	Leaf     trillian.MapLeaf
+}
+
+// HistoryGetter allows access to the values a key has had over time.
+type HistoryGetter interface {
+	// GetHistory returns the values set for keyHash at revisions from
+	// fromRevision to toRevision inclusive, oldest first. Revisions at which
+	// the key wasn't set are not included.
+	GetHistory(keyHash []byte, fromRevision, toRevision int64) ([]MapLeafRevision, error)
+}
+
 // MapRootReader provides access to the map roots.
 type MapRootReader interface {
 	// LatestSignedMapRoot returns the most recently created SignedMapRoot.
 	LatestSignedMapRoot() (trillian.SignedMapRoot, error)
+
+	// GetSignedMapRoot returns the SignedMapRoot of the given revision, or
+	// an error if there is no such root.
+	GetSignedMapRoot(revision int64) (trillian.SignedMapRoot, error)
 }
 
 // MapRootWriter allows the storage of new SignedMapRoots
@@ -107,3 +131,41 @@ type MapRootWriter interface {
 	// StoreSignedMapRoot stores root.
 	StoreSignedMapRoot(root trillian.SignedMapRoot) error
 }
+
+// QueuedMapMutation is a set of leaves written by an asynchronous SetLeaves
+// call, which is waiting to be applied to the map by the map sequencer.
+type QueuedMapMutation struct {
+	// ID identifies the mutation in its map.
+	ID             int64
+	Leaves         []*trillian.MapLeaf
+	MapperData     *trillian.MapperMetadata
+	QueueTimestamp time.Time
+}
+
+// MutationQueuer allows map writes to be queued, and applied in batches.
+type MutationQueuer interface {
+	// QueueMutation queues leaves to be written to the map along with
+	// mapperData, and returns the ID of the queued mutation.
+	QueueMutation(leaves []*trillian.MapLeaf, mapperData *trillian.MapperMetadata, queueTimestamp time.Time) (int64, error)
+
+	// DequeueMutations returns up to limit mutations which have not been
+	// applied yet, in the order they were queued.
+	DequeueMutations(limit int) ([]*QueuedMapMutation, error)
+
+	// MarkMutationsApplied records that the mutations with the given IDs have
+	// been written to the map at the write revision of the transaction.
+	MarkMutationsApplied(ids []int64) error
+
+	// PruneAppliedMutations deletes applied mutations which were queued
+	// before cutoff, and returns the number deleted.
+	PruneAppliedMutations(cutoff time.Time) (int64, error)
+}
+
+// MutationReader allows the progress of queued mutations to be followed.
+type MutationReader interface {
+	// MutationRevision returns the map revision at which the mutation with
+	// the given ID was applied, and whether it has been applied yet.
+	// An error is returned if the mutation doesn't exist, e.g. because it has
+	// been pruned.
+	MutationRevision(id int64) (int64, bool, error)
+}
@@ -274,6 +274,16 @@ func (_mr *_MockLogTreeTXRecorder) Commit() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "Commit")
 }
 
+func (_m *MockLogTreeTX) DeadLetterLeaves(_param0 []*trillian.DeadLetterLeaf) error {
+	ret := _m.ctrl.Call(_m, "DeadLetterLeaves", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockLogTreeTXRecorder) DeadLetterLeaves(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "DeadLetterLeaves", arg0)
+}
+
 func (_m *MockLogTreeTX) DequeueLeaves(_param0 int, _param1 time.Time) ([]*trillian.LogLeaf, error) {
 	ret := _m.ctrl.Call(_m, "DequeueLeaves", _param0, _param1)
 	ret0, _ := ret[0].([]*trillian.LogLeaf)
@@ -285,6 +295,27 @@ func (_mr *_MockLogTreeTXRecorder) DequeueLeaves(arg0, arg1 interface{}) *gomock
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "DequeueLeaves", arg0, arg1)
 }
 
+func (_m *MockLogTreeTX) DequeuePriorityLeaves(_param0 trillian.LeafPriority, _param1 int, _param2 time.Time) ([]*trillian.LogLeaf, error) {
+	ret := _m.ctrl.Call(_m, "DequeuePriorityLeaves", _param0, _param1, _param2)
+	ret0, _ := ret[0].([]*trillian.LogLeaf)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockLogTreeTXRecorder) DequeuePriorityLeaves(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "DequeuePriorityLeaves", arg0, arg1, arg2)
+}
+
+func (_m *MockLogTreeTX) DiscardDeadLetterLeaves(_param0 [][]byte) error {
+	ret := _m.ctrl.Call(_m, "DiscardDeadLetterLeaves", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockLogTreeTXRecorder) DiscardDeadLetterLeaves(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "DiscardDeadLetterLeaves", arg0)
+}
+
 func (_m *MockLogTreeTX) GetActiveLogIDs() ([]int64, error) {
 	ret := _m.ctrl.Call(_m, "GetActiveLogIDs")
 	ret0, _ := ret[0].([]int64)
@@ -307,6 +338,17 @@ func (_mr *_MockLogTreeTXRecorder) GetActiveLogIDsWithPendingWork() *gomock.Call
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetActiveLogIDsWithPendingWork")
 }
 
+func (_m *MockLogTreeTX) GetDeadLetterLeaves(_param0 int) ([]*trillian.DeadLetterLeaf, error) {
+	ret := _m.ctrl.Call(_m, "GetDeadLetterLeaves", _param0)
+	ret0, _ := ret[0].([]*trillian.DeadLetterLeaf)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockLogTreeTXRecorder) GetDeadLetterLeaves(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetDeadLetterLeaves", arg0)
+}
+
 func (_m *MockLogTreeTX) GetLeavesByHash(_param0 [][]byte, _param1 bool) ([]*trillian.LogLeaf, error) {
 	ret := _m.ctrl.Call(_m, "GetLeavesByHash", _param0, _param1)
 	ret0, _ := ret[0].([]*trillian.LogLeaf)
@@ -372,6 +414,17 @@ func (_mr *_MockLogTreeTXRecorder) LatestSignedLogRoot() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "LatestSignedLogRoot")
 }
 
+func (_m *MockLogTreeTX) NewestQueueTimestamp() (time.Time, error) {
+	ret := _m.ctrl.Call(_m, "NewestQueueTimestamp")
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockLogTreeTXRecorder) NewestQueueTimestamp() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "NewestQueueTimestamp")
+}
+
 func (_m *MockLogTreeTX) QueueLeaves(_param0 []*trillian.LogLeaf, _param1 time.Time) ([]*trillian.LogLeaf, error) {
 	ret := _m.ctrl.Call(_m, "QueueLeaves", _param0, _param1)
 	ret0, _ := ret[0].([]*trillian.LogLeaf)
@@ -393,6 +446,16 @@ func (_mr *_MockLogTreeTXRecorder) ReadRevision() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReadRevision")
 }
 
+func (_m *MockLogTreeTX) RequeueDeadLetterLeaves(_param0 [][]byte, _param1 time.Time) error {
+	ret := _m.ctrl.Call(_m, "RequeueDeadLetterLeaves", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockLogTreeTXRecorder) RequeueDeadLetterLeaves(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "RequeueDeadLetterLeaves", arg0, arg1)
+}
+
 func (_m *MockLogTreeTX) Rollback() error {
 	ret := _m.ctrl.Call(_m, "Rollback")
 	ret0, _ := ret[0].(error)
@@ -524,6 +587,17 @@ func NewMockMapTreeTX(ctrl *gomock.Controller) *MockMapTreeTX {
 	return mock
 }
 
+func (_m *MockMapTreeTX) DequeueMutations(_param0 int) ([]*QueuedMapMutation, error) {
+	ret := _m.ctrl.Call(_m, "DequeueMutations", _param0)
+	ret0, _ := ret[0].([]*QueuedMapMutation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockMapTreeTXRecorder) DequeueMutations(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "DequeueMutations", arg0)
+}
+
 func (_m *MockMapTreeTX) EXPECT() *_MockMapTreeTXRecorder {
 	return _m.recorder
 }
@@ -559,6 +633,17 @@ func (_mr *_MockMapTreeTXRecorder) Get(arg0, arg1 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "Get", arg0, arg1)
 }
 
+func (_m *MockMapTreeTX) GetHistory(_param0 []byte, _param1 int64, _param2 int64) ([]MapLeafRevision, error) {
+	ret := _m.ctrl.Call(_m, "GetHistory", _param0, _param1, _param2)
+	ret0, _ := ret[0].([]MapLeafRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockMapTreeTXRecorder) GetHistory(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetHistory", arg0, arg1, arg2)
+}
+
 func (_m *MockMapTreeTX) GetMerkleNodes(_param0 int64, _param1 []NodeID) ([]Node, error) {
 	ret := _m.ctrl.Call(_m, "GetMerkleNodes", _param0, _param1)
 	ret0, _ := ret[0].([]Node)
@@ -570,6 +655,17 @@ func (_mr *_MockMapTreeTXRecorder) GetMerkleNodes(arg0, arg1 interface{}) *gomoc
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetMerkleNodes", arg0, arg1)
 }
 
+func (_m *MockMapTreeTX) GetSignedMapRoot(_param0 int64) (trillian.SignedMapRoot, error) {
+	ret := _m.ctrl.Call(_m, "GetSignedMapRoot", _param0)
+	ret0, _ := ret[0].(trillian.SignedMapRoot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockMapTreeTXRecorder) GetSignedMapRoot(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetSignedMapRoot", arg0)
+}
+
 func (_m *MockMapTreeTX) IsOpen() bool {
 	ret := _m.ctrl.Call(_m, "IsOpen")
 	ret0, _ := ret[0].(bool)
@@ -591,6 +687,50 @@ func (_mr *_MockMapTreeTXRecorder) LatestSignedMapRoot() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "LatestSignedMapRoot")
 }
 
+func (_m *MockMapTreeTX) MarkMutationsApplied(_param0 []int64) error {
+	ret := _m.ctrl.Call(_m, "MarkMutationsApplied", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockMapTreeTXRecorder) MarkMutationsApplied(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "MarkMutationsApplied", arg0)
+}
+
+func (_m *MockMapTreeTX) MutationRevision(_param0 int64) (int64, bool, error) {
+	ret := _m.ctrl.Call(_m, "MutationRevision", _param0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+func (_mr *_MockMapTreeTXRecorder) MutationRevision(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "MutationRevision", arg0)
+}
+
+func (_m *MockMapTreeTX) PruneAppliedMutations(_param0 time.Time) (int64, error) {
+	ret := _m.ctrl.Call(_m, "PruneAppliedMutations", _param0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockMapTreeTXRecorder) PruneAppliedMutations(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "PruneAppliedMutations", arg0)
+}
+
+func (_m *MockMapTreeTX) QueueMutation(_param0 []*trillian.MapLeaf, _param1 *trillian.MapperMetadata, _param2 time.Time) (int64, error) {
+	ret := _m.ctrl.Call(_m, "QueueMutation", _param0, _param1, _param2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockMapTreeTXRecorder) QueueMutation(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "QueueMutation", arg0, arg1, arg2)
+}
+
 func (_m *MockMapTreeTX) ReadRevision() int64 {
 	ret := _m.ctrl.Call(_m, "ReadRevision")
 	ret0, _ := ret[0].(int64)
@@ -996,6 +1136,17 @@ func (_mr *_MockReadOnlyMapTreeTXRecorder) Get(arg0, arg1 interface{}) *gomock.C
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "Get", arg0, arg1)
 }
 
+func (_m *MockReadOnlyMapTreeTX) GetHistory(_param0 []byte, _param1 int64, _param2 int64) ([]MapLeafRevision, error) {
+	ret := _m.ctrl.Call(_m, "GetHistory", _param0, _param1, _param2)
+	ret0, _ := ret[0].([]MapLeafRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockReadOnlyMapTreeTXRecorder) GetHistory(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetHistory", arg0, arg1, arg2)
+}
+
 func (_m *MockReadOnlyMapTreeTX) GetMerkleNodes(_param0 int64, _param1 []NodeID) ([]Node, error) {
 	ret := _m.ctrl.Call(_m, "GetMerkleNodes", _param0, _param1)
 	ret0, _ := ret[0].([]Node)
@@ -1007,6 +1158,17 @@ func (_mr *_MockReadOnlyMapTreeTXRecorder) GetMerkleNodes(arg0, arg1 interface{}
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetMerkleNodes", arg0, arg1)
 }
 
+func (_m *MockReadOnlyMapTreeTX) GetSignedMapRoot(_param0 int64) (trillian.SignedMapRoot, error) {
+	ret := _m.ctrl.Call(_m, "GetSignedMapRoot", _param0)
+	ret0, _ := ret[0].(trillian.SignedMapRoot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockReadOnlyMapTreeTXRecorder) GetSignedMapRoot(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetSignedMapRoot", arg0)
+}
+
 func (_m *MockReadOnlyMapTreeTX) IsOpen() bool {
 	ret := _m.ctrl.Call(_m, "IsOpen")
 	ret0, _ := ret[0].(bool)
@@ -1028,6 +1190,18 @@ func (_mr *_MockReadOnlyMapTreeTXRecorder) LatestSignedMapRoot() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "LatestSignedMapRoot")
 }
 
+func (_m *MockReadOnlyMapTreeTX) MutationRevision(_param0 int64) (int64, bool, error) {
+	ret := _m.ctrl.Call(_m, "MutationRevision", _param0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+func (_mr *_MockReadOnlyMapTreeTXRecorder) MutationRevision(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "MutationRevision", arg0)
+}
+
 func (_m *MockReadOnlyMapTreeTX) ReadRevision() int64 {
 	ret := _m.ctrl.Call(_m, "ReadRevision")
 	ret0, _ := ret[0].(int64)
@@ -0,0 +1,105 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/trillian"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/testonly"
+)
+
+func BenchmarkMySQLStorage(b *testing.B) {
+	cleanTestDB(DB)
+	sb := &testonly.StorageBenchmarker{
+		NewAdminStorage: func() storage.AdminStorage { return NewAdminStorage(DB) },
+		NewLogStorage:   func() storage.LogStorage { return NewLogStorage(DB) },
+		NewMapStorage:   func() storage.MapStorage { return NewMapStorage(DB) },
+		TreeSizes:       []int64{100, 1000},
+	}
+	sb.RunAllBenchmarks(b)
+}
+
+// BenchmarkQueueBuckets compares concurrently queueing and dequeueing leaves with the
+// queue split into buckets against the single bucket of the original design.
+func BenchmarkQueueBuckets(b *testing.B) {
+	for _, buckets := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("buckets-%d", buckets), func(b *testing.B) {
+			cleanTestDB(DB)
+			logID := createLogForTests(DB)
+			s := NewLogStorageWithQueueBuckets(DB, buckets)
+
+			var next, contended int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					start := atomic.AddInt64(&next, 10) - 10
+					err := queueAndDequeue(s, logID, createTestLeaves(10, start))
+					switch {
+					case err == nil:
+					case isContention(err):
+						atomic.AddInt64(&contended, 1)
+					default:
+						b.Error(err)
+					}
+				}
+			})
+			b.Logf("%d of %d dequeues lost to contention", contended, b.N)
+		})
+	}
+}
+
+// queueAndDequeue queues the leaves and then dequeues as many, in separate transactions.
+func queueAndDequeue(s storage.LogStorage, logID int64, leaves []*trillian.LogLeaf) error {
+	tx, err := s.BeginForTree(context.Background(), logID)
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+	if _, err := tx.QueueLeaves(leaves, time.Now()); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	tx2, err := s.BeginForTree(context.Background(), logID)
+	if err != nil {
+		return err
+	}
+	defer tx2.Close()
+	if _, err := tx2.DequeueLeaves(len(leaves), time.Now()); err != nil {
+		return err
+	}
+	return tx2.Commit()
+}
+
+// isContention returns whether err is because concurrent dequeuers contended for the
+// same leaves, so that all but one failed.
+func isContention(err error) bool {
+	if _, ok := err.(rowCountError); ok {
+		return true
+	}
+	if mysqlErr, ok := err.(*mysql.MySQLError); ok {
+		return mysqlErr.Number == errNumDeadlock || mysqlErr.Number == errNumLockWaitTimeout
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+)
+
+// MaxInlineLeafValueSize is the size in bytes of the largest leaf value which
+// fits in the LeafValue columns. Larger values must be offloaded to a blob
+// store.
+const MaxInlineLeafValueSize = 1<<16 - 1
+
+const selectReferencedBlobsSQL = `SELECT LeafValueBlob FROM LeafData WHERE LeafValueBlob IS NOT NULL
+		UNION SELECT LeafValueBlob FROM MapLeaf WHERE LeafValueBlob IS NOT NULL`
+
+// nullableBlobKey returns the value to write to a LeafValueBlob column for
+// blobKey, which is NULL for values kept inline.
+func nullableBlobKey(blobKey string) interface{} {
+	if len(blobKey) == 0 {
+		return nil
+	}
+	return blobKey
+}
+
+// ReferencedBlobs returns the keys of the blobs holding offloaded leaf values
+// of any log or map in db. It's a blob.ReferencesFunc when bound to db.
+func ReferencedBlobs(ctx context.Context, db *sql.DB) (map[string]bool, error) {
+	rows, err := db.Query(selectReferencedBlobsSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	refs := make(map[string]bool)
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		refs[key] = true
+	}
+	return refs, rows.Err()
+}
@@ -0,0 +1,115 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	te "github.com/google/trillian/errors"
+	"github.com/google/trillian/storage/blob"
+)
+
+func TestLogLeafValueOffload(t *testing.T) {
+	cleanTestDB(DB)
+	logID := createLogForTests(DB)
+	blobs := &blob.Offloader{Store: blob.NewMemoryStore(), Threshold: 7}
+	s := NewLogStorageWithBlobs(DB, 1, blobs)
+
+	// "Leaf 20" stays inline, "Leaf 21 is large" is offloaded.
+	leaves := createTestLeaves(2, 20)
+	leaves[1].LeafValue = []byte("Leaf 21 is large")
+	tx := beginLogTx(s, logID, t)
+	defer tx.Close()
+	if _, err := tx.QueueLeaves(leaves, fakeQueueTime); err != nil {
+		t.Fatalf("Failed to queue leaves: %v", err)
+	}
+	commit(tx, t)
+
+	for i, leaf := range leaves {
+		var inline []byte
+		var blobKey *string
+		if err := DB.QueryRow("SELECT LeafValue,LeafValueBlob FROM LeafData WHERE TreeId=? AND LeafIdentityHash=?", logID, leaf.LeafIdentityHash).Scan(&inline, &blobKey); err != nil {
+			t.Fatalf("Could not query leaf %d: %v", i, err)
+		}
+		if offloaded := i == 1; offloaded != (blobKey != nil) || offloaded != (len(inline) == 0) {
+			t.Errorf("leaf %d stored as (%q, %v), want offloaded = %v", i, inline, blobKey, offloaded)
+		}
+	}
+
+	tx2 := beginLogTx(s, logID, t)
+	defer tx2.Close()
+	for _, leaf := range leaves {
+		got, err := tx2.(*logTreeTX).getLeafDataByIdentityHash([][]byte{leaf.LeafIdentityHash})
+		if err != nil {
+			t.Fatalf("getLeafDataByIdentityHash(_) = (_,%v); want (_,nil)", err)
+		}
+		if len(got) != 1 || !bytes.Equal(got[0].LeafValue, leaf.LeafValue) {
+			t.Errorf("getLeafDataByIdentityHash(_) = (%+v,nil); want leaf with value %q", got, leaf.LeafValue)
+		}
+	}
+	commit(tx2, t)
+
+	// Storage without the blob store can't read offloaded values.
+	tx3 := beginLogTx(NewLogStorage(DB), logID, t)
+	defer tx3.Close()
+	if _, err := tx3.(*logTreeTX).getLeafDataByIdentityHash([][]byte{leaves[1].LeafIdentityHash}); te.ErrorCode(err) != te.FailedPrecondition {
+		t.Errorf("getLeafDataByIdentityHash(_) without blob store = (_,%v); want FailedPrecondition error", err)
+	}
+
+	refs, err := ReferencedBlobs(context.Background(), DB)
+	if want := blob.Key(leaves[1].LeafValue); err != nil || len(refs) != 1 || !refs[want] {
+		t.Errorf("ReferencedBlobs() = (%v, %v), want = (map[%s:true], nil)", refs, err, want)
+	}
+}
+
+func TestMapLeafValueOffload(t *testing.T) {
+	cleanTestDB(DB)
+	mapID := createMapForTests(DB)
+	blobs := &blob.Offloader{Store: blob.NewMemoryStore(), Threshold: 10}
+	s := NewMapStorageWithBlobs(DB, blobs)
+	ctx := context.Background()
+
+	leaf := trillian.MapLeaf{
+		Index:     keyHash,
+		LeafValue: []byte("a map leaf value too large to keep inline"),
+	}
+	tx := beginMapTx(ctx, s, mapID, t)
+	defer tx.Close()
+	if err := tx.Set(keyHash, leaf); err != nil {
+		t.Fatalf("Failed to set %v to %v: %v", keyHash, leaf, err)
+	}
+	commit(tx, t)
+
+	tx2 := beginMapTx(ctx, s, mapID, t)
+	defer tx2.Close()
+	readValues, err := tx2.Get(1, [][]byte{keyHash})
+	if err != nil || len(readValues) != 1 || !proto.Equal(&readValues[0], &leaf) {
+		t.Errorf("Get() = (%v, %v), want = ([%v], nil)", readValues, err, leaf)
+	}
+	history, err := tx2.GetHistory(keyHash, 0, 10)
+	if err != nil || len(history) != 1 || !proto.Equal(&history[0].Leaf, &leaf) {
+		t.Errorf("GetHistory() = (%v, %v), want one revision of %v", history, err, leaf)
+	}
+	commit(tx2, t)
+
+	refs, err := ReferencedBlobs(ctx, DB)
+	if err != nil || len(refs) != 1 {
+		t.Errorf("ReferencedBlobs() = (%v, %v), want one key", refs, err)
+	}
+}
@@ -23,7 +23,9 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/go-sql-driver/mysql"
@@ -31,39 +33,77 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/google/trillian"
 	spb "github.com/google/trillian/crypto/sigpb"
+	te "github.com/google/trillian/errors"
 	"github.com/google/trillian/merkle"
 	"github.com/google/trillian/monitoring/metric"
 	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/blob"
 	"github.com/google/trillian/storage/cache"
+	"github.com/google/trillian/storage/storagepb"
 )
 
 const (
 	getTreePropertiesSQL  = "SELECT DuplicatePolicy FROM Trees WHERE TreeId=?"
-	selectQueuedLeavesSQL = `SELECT LeafIdentityHash,MerkleLeafHash
+	selectQueuedLeavesSQL = `SELECT LeafIdentityHash,MerkleLeafHash,QueueTimestampNanos,QueueLane
 			FROM Unsequenced
 			WHERE TreeID=?
+			AND QueueBucket=?
+			AND QueueTimestampNanos<=?
+			ORDER BY QueueLane,QueueTimestampNanos,LeafIdentityHash ASC LIMIT ?`
+	selectQueuedLaneLeavesSQL = `SELECT LeafIdentityHash,MerkleLeafHash,QueueTimestampNanos,QueueLane
+			FROM Unsequenced
+			WHERE TreeID=?
+			AND QueueBucket=?
+			AND QueueLane=?
 			AND QueueTimestampNanos<=?
 			ORDER BY QueueTimestampNanos,LeafIdentityHash ASC LIMIT ?`
-	insertUnsequencedLeafSQL = `INSERT INTO LeafData(TreeId,LeafIdentityHash,LeafValue,ExtraData)
-			VALUES(?,?,?,?) ON DUPLICATE KEY UPDATE LeafIdentityHash=LeafIdentityHash`
-	insertUnsequencedLeafSQLNoDuplicates = `INSERT INTO LeafData(TreeId,LeafIdentityHash,LeafValue,ExtraData)
-			VALUES(?,?,?,?)`
-	insertUnsequencedEntrySQL = `INSERT INTO Unsequenced(TreeId,LeafIdentityHash,MerkleLeafHash,MessageId,QueueTimestampNanos)
-			VALUES(?,?,?,?,?)`
+	// The buckets are read from QueueBucketIdx, so that leaves in buckets beyond
+	// the configured number are still dequeued.
+	selectQueueBucketsSQL = `SELECT DISTINCT QueueBucket
+			FROM Unsequenced
+			WHERE TreeID=?`
+	// Grouping by bucket and lane lets the newest timestamp of each be read from
+	// the end of its range of QueueBucketIdx.
+	selectNewestQueueTimestampsSQL = `SELECT MAX(QueueTimestampNanos)
+			FROM Unsequenced
+			WHERE TreeID=?
+			GROUP BY QueueBucket,QueueLane`
+	insertUnsequencedLeafSQL = `INSERT INTO LeafData(TreeId,LeafIdentityHash,LeafValue,LeafValueBlob,ExtraData,Submitter)
+			VALUES(?,?,?,?,?,?) ON DUPLICATE KEY UPDATE LeafIdentityHash=LeafIdentityHash`
+	insertUnsequencedLeafSQLNoDuplicates = `INSERT INTO LeafData(TreeId,LeafIdentityHash,LeafValue,LeafValueBlob,ExtraData,Submitter)
+			VALUES(?,?,?,?,?,?)`
+	insertUnsequencedEntrySQL = `INSERT INTO Unsequenced(TreeId,LeafIdentityHash,MerkleLeafHash,MessageId,QueueTimestampNanos,QueueLane,QueueBucket)
+			VALUES(?,?,?,?,?,?,?)`
 	insertSequencedLeafSQL = `INSERT INTO SequencedLeafData(TreeId,LeafIdentityHash,MerkleLeafHash,SequenceNumber)
 			VALUES(?,?,?,?)`
 	selectSequencedLeafCountSQL  = "SELECT COUNT(*) FROM SequencedLeafData WHERE TreeId=?"
-	selectLatestSignedLogRootSQL = `SELECT TreeHeadTimestamp,TreeSize,RootHash,TreeRevision,RootSignature
+	selectLatestSignedLogRootSQL = `SELECT TreeHeadTimestamp,TreeSize,RootHash,TreeRevision,RootSignature,RootSignatures
 			FROM TreeHead WHERE TreeId=?
 			ORDER BY TreeHeadTimestamp DESC LIMIT 1`
 
+	// Statements for leaves set aside by the sequencer.
+	insertDeadLetterSQL = `INSERT INTO DeadLetter(TreeId,LeafIdentityHash,MerkleLeafHash,QueueTimestampNanos,QueueLane,Error,DeadLetterTimestampNanos)
+			VALUES(?,?,?,?,?,?,?)
+			ON DUPLICATE KEY UPDATE Error=VALUES(Error),DeadLetterTimestampNanos=VALUES(DeadLetterTimestampNanos)`
+	selectDeadLettersSQL = `SELECT d.LeafIdentityHash,d.MerkleLeafHash,d.QueueTimestampNanos,d.QueueLane,d.Error,d.DeadLetterTimestampNanos,
+			l.LeafValue,l.LeafValueBlob,l.ExtraData,l.Submitter
+			FROM DeadLetter d,LeafData l
+			WHERE d.TreeId=? AND l.TreeId=d.TreeId AND l.LeafIdentityHash=d.LeafIdentityHash
+			ORDER BY d.DeadLetterTimestampNanos,d.LeafIdentityHash ASC LIMIT ?`
+	selectDeadLetterSQL           = "SELECT MerkleLeafHash,QueueLane FROM DeadLetter WHERE TreeId=? AND LeafIdentityHash=?"
+	deleteDeadLetterSQL           = "DELETE FROM DeadLetter WHERE TreeId=? AND LeafIdentityHash=?"
+	deleteUnsequencedLeafSQL      = "DELETE FROM Unsequenced WHERE TreeId=? AND LeafIdentityHash=?"
+	deleteUnreferencedLeafDataSQL = `DELETE FROM LeafData WHERE TreeId=? AND LeafIdentityHash=?
+			AND NOT EXISTS (SELECT * FROM SequencedLeafData s WHERE s.TreeId=LeafData.TreeId AND s.LeafIdentityHash=LeafData.LeafIdentityHash)
+			AND NOT EXISTS (SELECT * FROM Unsequenced u WHERE u.TreeId=LeafData.TreeId AND u.LeafIdentityHash=LeafData.LeafIdentityHash)`
+
 	// These statements need to be expanded to provide the correct number of parameter placeholders.
 	deleteUnsequencedSQL   = "DELETE FROM Unsequenced WHERE LeafIdentityHash IN (<placeholder>) AND TreeId = ?"
-	selectLeavesByIndexSQL = `SELECT s.MerkleLeafHash,l.LeafIdentityHash,l.LeafValue,s.SequenceNumber,l.ExtraData
+	selectLeavesByIndexSQL = `SELECT s.MerkleLeafHash,l.LeafIdentityHash,l.LeafValue,l.LeafValueBlob,s.SequenceNumber,l.ExtraData,l.Submitter
 			FROM LeafData l,SequencedLeafData s
 			WHERE l.LeafIdentityHash = s.LeafIdentityHash
 			AND s.SequenceNumber IN (` + placeholderSQL + `) AND l.TreeId = ? AND s.TreeId = l.TreeId`
-	selectLeavesByMerkleHashSQL = `SELECT s.MerkleLeafHash,l.LeafIdentityHash,l.LeafValue,s.SequenceNumber,l.ExtraData
+	selectLeavesByMerkleHashSQL = `SELECT s.MerkleLeafHash,l.LeafIdentityHash,l.LeafValue,l.LeafValueBlob,s.SequenceNumber,l.ExtraData,l.Submitter
 			FROM LeafData l,SequencedLeafData s
 			WHERE l.LeafIdentityHash = s.LeafIdentityHash
 			AND s.MerkleLeafHash IN (` + placeholderSQL + `) AND l.TreeId = ? AND s.TreeId = l.TreeId`
@@ -72,7 +112,7 @@ const (
 	// This statement returns a dummy Merkle leaf hash value (which must be
 	// of the right size) so that its signature matches that of the other
 	// leaf-selection statements.
-	selectLeavesByLeafIdentityHashSQL = `SELECT '` + dummyMerkleLeafHash + `',l.LeafIdentityHash,l.LeafValue,-1,l.ExtraData
+	selectLeavesByLeafIdentityHashSQL = `SELECT '` + dummyMerkleLeafHash + `',l.LeafIdentityHash,l.LeafValue,l.LeafValueBlob,-1,l.ExtraData,l.Submitter
 			FROM LeafData l
 			WHERE l.LeafIdentityHash IN (` + placeholderSQL + `) AND l.TreeId = ?`
 
@@ -82,6 +122,14 @@ const (
 
 	// Error code returned by driver when inserting a duplicate row
 	errNumDuplicate = 1062
+	// Error codes returned by driver when a transaction loses a lock to another
+	errNumLockWaitTimeout = 1205
+	errNumDeadlock        = 1213
+	// Error codes returned by driver when a value written breaks the schema
+	errNumBadNull      = 1048
+	errNumOutOfRange   = 1264
+	errNumDataTooLong  = 1406
+	errNumNoReferenced = 1452
 )
 
 var (
@@ -93,13 +141,53 @@ var (
 
 type mySQLLogStorage struct {
 	*mySQLTreeStorage
+	// queueBuckets is how many buckets each log's queue of unsequenced leaves is
+	// split into.
+	queueBuckets int
+	// blobs offloads large leaf values, or is nil to keep all values inline.
+	blobs *blob.Offloader
 }
 
 // NewLogStorage creates a mySQLLogStorage instance for the specified MySQL URL.
 func NewLogStorage(db *sql.DB) storage.LogStorage {
+	return NewLogStorageWithQueueBuckets(db, 1)
+}
+
+// NewLogStorageWithQueueBuckets creates a mySQLLogStorage instance which splits each
+// log's queue of unsequenced leaves into queueBuckets buckets, chosen by leaf identity
+// hash, so that queueing and dequeueing leaves contend less at high rates. Leaves are
+// dequeued in parallel from every bucket which holds any, whatever the number.
+// Every server using the database should use the same number of buckets, so that
+// leaves are spread evenly, but the number can be changed at any time.
+func NewLogStorageWithQueueBuckets(db *sql.DB, queueBuckets int) storage.LogStorage {
+	return NewLogStorageWithBlobs(db, queueBuckets, nil)
+}
+
+// NewLogStorageWithBlobs creates a mySQLLogStorage instance as for
+// NewLogStorageWithQueueBuckets, which offloads leaf values larger than the
+// threshold of blobs to its blob store, fetching them back transparently when
+// leaves are read. Values offloaded by any server are only readable by servers
+// using the same blob store.
+func NewLogStorageWithBlobs(db *sql.DB, queueBuckets int, blobs *blob.Offloader) storage.LogStorage {
+	if queueBuckets < 1 {
+		queueBuckets = 1
+	}
 	return &mySQLLogStorage{
 		mySQLTreeStorage: newTreeStorage(db),
+		queueBuckets:     queueBuckets,
+		blobs:            blobs,
+	}
+}
+
+// queueBucket returns the bucket of the queue that the leaf with the given identity
+// hash is queued in.
+func (m *mySQLLogStorage) queueBucket(leafIdentityHash []byte) int {
+	if m.queueBuckets <= 1 {
+		return 0
 	}
+	h := fnv.New32a()
+	h.Write(leafIdentityHash)
+	return int(h.Sum32() % uint32(m.queueBuckets))
 }
 
 func (m *mySQLLogStorage) CheckDatabaseAccessible(ctx context.Context) error {
@@ -223,6 +311,7 @@ func (m *mySQLLogStorage) beginInternal(ctx context.Context, treeID int64) (stor
 
 	ltx := &logTreeTX{
 		treeTX:          ttx,
+		ctx:             ctx,
 		ls:              m,
 		duplicatePolicy: policy,
 	}
@@ -251,9 +340,14 @@ func (m *mySQLLogStorage) SnapshotForTree(ctx context.Context, treeID int64) (st
 
 type logTreeTX struct {
 	treeTX
+	// ctx is used to reach the blob store holding offloaded leaf values.
+	ctx             context.Context
 	ls              *mySQLLogStorage
 	root            trillian.SignedLogRoot
 	duplicatePolicy trillian.DuplicatePolicy
+	// dequeued holds the identity hashes of the leaves already dequeued by this
+	// transaction, which reads outside it still see queued.
+	dequeued map[string]bool
 }
 
 func (t *logTreeTX) ReadRevision() int64 {
@@ -265,15 +359,135 @@ func (t *logTreeTX) WriteRevision() int64 {
 }
 
 func (t *logTreeTX) DequeueLeaves(limit int, cutoffTime time.Time) ([]*trillian.LogLeaf, error) {
-	stx, err := t.tx.Prepare(selectQueuedLeavesSQL)
+	return t.dequeueLeaves(limit, selectQueuedLeavesSQL, cutoffTime.UnixNano())
+}
+
+func (t *logTreeTX) DequeuePriorityLeaves(priority trillian.LeafPriority, limit int, cutoffTime time.Time) ([]*trillian.LogLeaf, error) {
+	lane, err := storage.PriorityLane(priority)
+	if err != nil {
+		return nil, err
+	}
+	return t.dequeueLeaves(limit, selectQueuedLaneLeavesSQL, lane, cutoffTime.UnixNano())
+}
+
+// dequeueLeaves dequeues up to limit leaves selected from each bucket of the queue by
+// query, whose arguments are the tree ID, the bucket, args and the limit.
+func (t *logTreeTX) dequeueLeaves(limit int, query string, args ...interface{}) ([]*trillian.LogLeaf, error) {
+	buckets, err := t.selectQueueBuckets()
+	if err != nil {
+		return nil, err
+	}
+
+	var leaves []*trillian.LogLeaf
+	switch len(buckets) {
+	case 0:
+	case 1:
+		queryArgs := append([]interface{}{t.treeID, buckets[0]}, args...)
+		leaves, err = t.selectQueuedLeaves(t.tx, limit, query, append(queryArgs, limit)...)
+	default:
+		leaves, err = t.selectBucketedLeaves(buckets, limit, query, args...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The convention is that if leaf processing succeeds (by committing this tx)
+	// then the unsequenced entries for them are removed
+	if len(leaves) > 0 {
+		err = t.removeSequencedLeaves(leaves)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if t.dequeued == nil {
+		t.dequeued = make(map[string]bool)
+	}
+	for _, leaf := range leaves {
+		t.dequeued[string(leaf.LeafIdentityHash)] = true
+	}
+	dequeuedCounter.Add(int64(len(leaves)))
+
+	return leaves, nil
+}
+
+// selectQueueBuckets returns the buckets of the queue which hold any leaves. These
+// are usually those below the configured number of buckets, but may include others
+// if the number was reduced while leaves were queued.
+func (t *logTreeTX) selectQueueBuckets() ([]int, error) {
+	rows, err := t.tx.Query(selectQueueBucketsSQL, t.treeID)
+	if err != nil {
+		glog.Warningf("Failed to select queue buckets: %s", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var buckets []int
+	for rows.Next() {
+		var bucket int
+		if err := rows.Scan(&bucket); err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, bucket)
+	}
+	return buckets, rows.Err()
+}
+
+// selectBucketedLeaves selects up to limit leaves from each of buckets in
+// parallel, and returns the first limit of them in queue order. The buckets are read
+// outside the transaction, so the order is only approximate while leaves are being
+// queued, but they're removed from the queue inside it, so a leaf can't be dequeued
+// by two transactions which both commit. Leaves this transaction has already
+// dequeued are still seen outside it, so they're skipped.
+func (t *logTreeTX) selectBucketedLeaves(buckets []int, limit int, query string, args ...interface{}) ([]*trillian.LogLeaf, error) {
+	// Select enough leaves from each bucket to make up for skipped ones.
+	bucketLimit := limit + len(t.dequeued)
+	results := make([][]*trillian.LogLeaf, len(buckets))
+	errs := make([]error, len(buckets))
+	var wg sync.WaitGroup
+	for i, bucket := range buckets {
+		wg.Add(1)
+		go func(i, bucket int) {
+			defer wg.Done()
+			bucketArgs := append([]interface{}{t.treeID, bucket}, args...)
+			bucketArgs = append(bucketArgs, bucketLimit)
+			results[i], errs[i] = t.selectQueuedLeaves(t.ls.db, bucketLimit, query, bucketArgs...)
+		}(i, bucket)
+	}
+	wg.Wait()
+
+	var leaves []*trillian.LogLeaf
+	for i, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+		for _, leaf := range results[i] {
+			if !t.dequeued[string(leaf.LeafIdentityHash)] {
+				leaves = append(leaves, leaf)
+			}
+		}
+	}
+
+	sort.Sort(byQueueOrder(leaves))
+	if len(leaves) > limit {
+		leaves = leaves[:limit]
+	}
+	return leaves, nil
+}
+
+// selectQueuedLeaves returns up to limit queued leaves selected by query with args.
+func (t *logTreeTX) selectQueuedLeaves(db preparer, limit int, query string, args ...interface{}) ([]*trillian.LogLeaf, error) {
+	stx, err := db.Prepare(query)
 
 	if err != nil {
 		glog.Warningf("Failed to prepare dequeue select: %s", err)
 		return nil, err
 	}
+	defer stx.Close()
 
 	leaves := make([]*trillian.LogLeaf, 0, limit)
-	rows, err := stx.Query(t.treeID, cutoffTime.UnixNano(), limit)
+	rows, err := stx.Query(args...)
 
 	if err != nil {
 		glog.Warningf("Failed to select rows for work: %s", err)
@@ -285,8 +499,10 @@ func (t *logTreeTX) DequeueLeaves(limit int, cutoffTime time.Time) ([]*trillian.
 	for rows.Next() {
 		var leafIDHash []byte
 		var merkleHash []byte
+		var queueTimestamp int64
+		var queueLane int
 
-		err := rows.Scan(&leafIDHash, &merkleHash)
+		err := rows.Scan(&leafIDHash, &merkleHash, &queueTimestamp, &queueLane)
 
 		if err != nil {
 			glog.Warningf("Error scanning work rows: %s", err)
@@ -296,13 +512,18 @@ func (t *logTreeTX) DequeueLeaves(limit int, cutoffTime time.Time) ([]*trillian.
 		if len(leafIDHash) != t.hashSizeBytes {
 			return nil, errors.New("Dequeued a leaf with incorrect hash size")
 		}
+		if queueLane < 0 || queueLane >= len(storage.PriorityLanes) {
+			return nil, fmt.Errorf("Dequeued a leaf with unknown queue lane %d", queueLane)
+		}
 
 		// Note: the LeafData and ExtraData being nil here is OK as this is only used by the
 		// sequencer. The sequencer only writes to the SequencedLeafData table and the client
 		// supplied data was already written to LeafData as part of queueing the leaf.
 		leaf := &trillian.LogLeaf{
-			LeafIdentityHash: leafIDHash,
-			MerkleLeafHash:   merkleHash,
+			LeafIdentityHash:    leafIDHash,
+			MerkleLeafHash:      merkleHash,
+			QueueTimestampNanos: queueTimestamp,
+			QueuePriority:       storage.PriorityLanes[queueLane],
 		}
 		leaves = append(leaves, leaf)
 	}
@@ -311,18 +532,6 @@ func (t *logTreeTX) DequeueLeaves(limit int, cutoffTime time.Time) ([]*trillian.
 		return nil, rows.Err()
 	}
 
-	// The convention is that if leaf processing succeeds (by committing this tx)
-	// then the unsequenced entries for them are removed
-	if len(leaves) > 0 {
-		err = t.removeSequencedLeaves(leaves)
-	}
-
-	if err != nil {
-		return nil, err
-	}
-
-	dequeuedCounter.Add(int64(len(leaves)))
-
 	return leaves, nil
 }
 
@@ -332,6 +541,9 @@ func (t *logTreeTX) QueueLeaves(leaves []*trillian.LogLeaf, queueTimestamp time.
 		if len(leaf.LeafIdentityHash) != t.hashSizeBytes {
 			return nil, fmt.Errorf("queued leaf must have a leaf ID hash of length %d", t.hashSizeBytes)
 		}
+		if _, err := storage.PriorityLane(leaf.QueuePriority); err != nil {
+			return nil, err
+		}
 	}
 
 	// If the log does not allow duplicates we prevent the insert of such a leaf from
@@ -360,7 +572,12 @@ func (t *logTreeTX) QueueLeaves(leaves []*trillian.LogLeaf, queueTimestamp time.
 		// can suppress errors unrelated to key collisions. We don't use REPLACE because
 		// if there's ever a hash collision it will do the wrong thing and it also
 		// causes a DELETE / INSERT, which is undesirable.
-		_, err := t.tx.Exec(insertSQL, t.treeID, leaf.LeafIdentityHash, leaf.LeafValue, leaf.ExtraData)
+		value, blobKey, err := t.ls.blobs.Offload(t.ctx, leaf.LeafValue)
+		if err != nil {
+			glog.Warningf("Error offloading %d: %s", i, err)
+			return nil, err
+		}
+		_, err = t.tx.Exec(insertSQL, t.treeID, leaf.LeafIdentityHash, value, nullableBlobKey(blobKey), leaf.ExtraData, leaf.Submitter)
 		if isDuplicateErr(err) {
 			// Remember the duplicate leaf, using the requested leaf for now.
 			existingLeaves[leafPos.idx] = leaf
@@ -373,31 +590,14 @@ func (t *logTreeTX) QueueLeaves(leaves []*trillian.LogLeaf, queueTimestamp time.
 		}
 
 		// Create the work queue entry
-		// Message ids only need to guard against duplicates for the time that entries are
-		// in the unsequenced queue, which should be short, but we'll still use a strong hash.
-		// TODO(alcutter): get this from somewhere else
-		hasher := sha256.New()
-
-		// We use a fixed zero message id if the log disallows duplicates otherwise a random one.
-		// the fixed id will collide if dups submitted when not allowed so the insert won't succeed
-		// and everything will get rolled back
-		messageIDBytes := make([]byte, 8)
-
-		if t.duplicatePolicy == trillian.DuplicatePolicy_DUPLICATES_ALLOWED {
-			_, err := rand.Read(messageIDBytes)
-			if err != nil {
-				glog.Warningf("Failed to get a random message id: %s", err)
-				return nil, err
-			}
+		messageID, err := t.messageID(leaf.LeafIdentityHash)
+		if err != nil {
+			return nil, err
 		}
 
-		hasher.Write(messageIDBytes)
-		binary.Write(hasher, binary.LittleEndian, t.treeID)
-		hasher.Write(leaf.LeafIdentityHash)
-		messageID := hasher.Sum(nil)
-
+		lane, _ := storage.PriorityLane(leaf.QueuePriority)
 		_, err = t.tx.Exec(insertUnsequencedEntrySQL,
-			t.treeID, leaf.LeafIdentityHash, leaf.MerkleLeafHash, messageID, queueTimestamp.UnixNano())
+			t.treeID, leaf.LeafIdentityHash, leaf.MerkleLeafHash, messageID, queueTimestamp.UnixNano(), lane, t.ls.queueBucket(leaf.LeafIdentityHash))
 		if err != nil {
 			glog.Warningf("Error inserting into Unsequenced: %s", err)
 			return nil, fmt.Errorf("Unsequenced: %v", err)
@@ -445,6 +645,182 @@ func (t *logTreeTX) QueueLeaves(leaves []*trillian.LogLeaf, queueTimestamp time.
 	return existingLeaves, nil
 }
 
+// messageID returns the message id for a work queue entry for the leaf with the given
+// identity hash.
+func (t *logTreeTX) messageID(leafIdentityHash []byte) ([]byte, error) {
+	// Message ids only need to guard against duplicates for the time that entries are
+	// in the unsequenced queue, which should be short, but we'll still use a strong hash.
+	// TODO(alcutter): get this from somewhere else
+	hasher := sha256.New()
+
+	// We use a fixed zero message id if the log disallows duplicates otherwise a random one.
+	// the fixed id will collide if dups submitted when not allowed so the insert won't succeed
+	// and everything will get rolled back
+	messageIDBytes := make([]byte, 8)
+
+	if t.duplicatePolicy == trillian.DuplicatePolicy_DUPLICATES_ALLOWED {
+		_, err := rand.Read(messageIDBytes)
+		if err != nil {
+			glog.Warningf("Failed to get a random message id: %s", err)
+			return nil, err
+		}
+	}
+
+	hasher.Write(messageIDBytes)
+	binary.Write(hasher, binary.LittleEndian, t.treeID)
+	hasher.Write(leafIdentityHash)
+	return hasher.Sum(nil), nil
+}
+
+func (t *logTreeTX) DeadLetterLeaves(leaves []*trillian.DeadLetterLeaf) error {
+	for _, dl := range leaves {
+		leaf := dl.GetLeaf()
+		if len(leaf.GetLeafIdentityHash()) != t.hashSizeBytes {
+			return errors.New("Dead-lettered leaf has incorrect hash size")
+		}
+		lane, err := storage.PriorityLane(leaf.QueuePriority)
+		if err != nil {
+			return err
+		}
+
+		if _, err := t.tx.Exec(insertDeadLetterSQL, t.treeID, leaf.LeafIdentityHash, leaf.MerkleLeafHash,
+			leaf.QueueTimestampNanos, lane, dl.Error, dl.DeadLetterTimestampNanos); err != nil {
+			glog.Warningf("Error inserting into DeadLetter: %s", err)
+			return fmt.Errorf("DeadLetter: %v", err)
+		}
+		if _, err := t.tx.Exec(deleteUnsequencedLeafSQL, t.treeID, leaf.LeafIdentityHash); err != nil {
+			glog.Warningf("Failed to delete dead-lettered work: %s", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *logTreeTX) GetDeadLetterLeaves(limit int) ([]*trillian.DeadLetterLeaf, error) {
+	rows, err := t.tx.Query(selectDeadLettersSQL, t.treeID, limit)
+	if err != nil {
+		glog.Warningf("Failed to select dead-lettered leaves: %s", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ret []*trillian.DeadLetterLeaf
+	for rows.Next() {
+		leaf := &trillian.LogLeaf{}
+		dl := &trillian.DeadLetterLeaf{Leaf: leaf}
+		var queueLane int
+		var blobKey []byte
+		if err := rows.Scan(
+			&leaf.LeafIdentityHash,
+			&leaf.MerkleLeafHash,
+			&leaf.QueueTimestampNanos,
+			&queueLane,
+			&dl.Error,
+			&dl.DeadLetterTimestampNanos,
+			&leaf.LeafValue,
+			&blobKey,
+			&leaf.ExtraData,
+			&leaf.Submitter); err != nil {
+			glog.Warningf("Failed to scan dead-lettered leaves: %s", err)
+			return nil, err
+		}
+		if err := t.resolveLeafValue(leaf, blobKey); err != nil {
+			return nil, err
+		}
+		if queueLane < 0 || queueLane >= len(storage.PriorityLanes) {
+			return nil, fmt.Errorf("Dead-lettered leaf has unknown queue lane %d", queueLane)
+		}
+		leaf.QueuePriority = storage.PriorityLanes[queueLane]
+		ret = append(ret, dl)
+	}
+
+	return ret, rows.Err()
+}
+
+func (t *logTreeTX) RequeueDeadLetterLeaves(leafIdentityHashes [][]byte, queueTimestamp time.Time) error {
+	for _, hash := range leafIdentityHashes {
+		var merkleHash []byte
+		var queueLane int
+		err := t.tx.QueryRow(selectDeadLetterSQL, t.treeID, hash).Scan(&merkleHash, &queueLane)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			glog.Warningf("Failed to select dead-lettered leaf: %s", err)
+			return err
+		}
+
+		messageID, err := t.messageID(hash)
+		if err != nil {
+			return err
+		}
+		if _, err := t.tx.Exec(insertUnsequencedEntrySQL,
+			t.treeID, hash, merkleHash, messageID, queueTimestamp.UnixNano(), queueLane, t.ls.queueBucket(hash)); err != nil {
+			glog.Warningf("Error inserting into Unsequenced: %s", err)
+			return fmt.Errorf("Unsequenced: %v", err)
+		}
+		if _, err := t.tx.Exec(deleteDeadLetterSQL, t.treeID, hash); err != nil {
+			glog.Warningf("Failed to delete requeued dead letter: %s", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *logTreeTX) DiscardDeadLetterLeaves(leafIdentityHashes [][]byte) error {
+	for _, hash := range leafIdentityHashes {
+		res, err := t.tx.Exec(deleteDeadLetterSQL, t.treeID, hash)
+		if err != nil {
+			glog.Warningf("Failed to delete discarded dead letter: %s", err)
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			// Nothing was discarded, so there's no leaf data to tidy up.
+			continue
+		}
+
+		// The leaf data can be shared with a sequenced or queued duplicate of the leaf,
+		// in which case it's kept.
+		if _, err := t.tx.Exec(deleteUnreferencedLeafDataSQL, t.treeID, hash); err != nil {
+			glog.Warningf("Failed to delete discarded leaf data: %s", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *logTreeTX) NewestQueueTimestamp() (time.Time, error) {
+	rows, err := t.tx.Query(selectNewestQueueTimestampsSQL, t.treeID)
+	if err != nil {
+		glog.Warningf("Failed to select newest queue timestamps: %s", err)
+		return time.Time{}, err
+	}
+	defer rows.Close()
+
+	var newest int64
+	found := false
+	for rows.Next() {
+		var ts int64
+		if err := rows.Scan(&ts); err != nil {
+			glog.Warningf("Failed to scan newest queue timestamp: %s", err)
+			return time.Time{}, err
+		}
+		if !found || ts > newest {
+			newest, found = ts, true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return time.Time{}, err
+	}
+	if !found {
+		return time.Time{}, nil
+	}
+	return time.Unix(0, newest), nil
+}
+
 func (t *logTreeTX) GetSequencedLeafCount() (int64, error) {
 	var sequencedLeafCount int64
 
@@ -478,15 +854,21 @@ func (t *logTreeTX) GetLeavesByIndex(leaves []int64) ([]*trillian.LogLeaf, error
 	defer rows.Close()
 	for rows.Next() {
 		leaf := &trillian.LogLeaf{}
+		var blobKey []byte
 		if err := rows.Scan(
 			&leaf.MerkleLeafHash,
 			&leaf.LeafIdentityHash,
 			&leaf.LeafValue,
+			&blobKey,
 			&leaf.LeafIndex,
-			&leaf.ExtraData); err != nil {
+			&leaf.ExtraData,
+			&leaf.Submitter); err != nil {
 			glog.Warningf("Failed to scan merkle leaves: %s", err)
 			return nil, err
 		}
+		if err := t.resolveLeafValue(leaf, blobKey); err != nil {
+			return nil, err
+		}
 		ret = append(ret, leaf)
 	}
 
@@ -523,12 +905,12 @@ func (t *logTreeTX) LatestSignedLogRoot() (trillian.SignedLogRoot, error) {
 // fetchLatestRoot reads the latest SignedLogRoot from the DB and returns it.
 func (t *logTreeTX) fetchLatestRoot() (trillian.SignedLogRoot, error) {
 	var timestamp, treeSize, treeRevision int64
-	var rootHash, rootSignatureBytes []byte
+	var rootHash, rootSignatureBytes, rootSignaturesBytes []byte
 	var rootSignature spb.DigitallySigned
 
 	err := t.tx.QueryRow(
 		selectLatestSignedLogRootSQL, t.treeID).Scan(
-		&timestamp, &treeSize, &rootHash, &treeRevision, &rootSignatureBytes)
+		&timestamp, &treeSize, &rootHash, &treeRevision, &rootSignatureBytes, &rootSignaturesBytes)
 
 	// It's possible there are no roots for this tree yet
 	if err == sql.ErrNoRows {
@@ -542,6 +924,14 @@ func (t *logTreeTX) fetchLatestRoot() (trillian.SignedLogRoot, error) {
 		return trillian.SignedLogRoot{}, err
 	}
 
+	var rootSignatures storagepb.LogRootSignatures
+	if len(rootSignaturesBytes) != 0 {
+		if err := proto.Unmarshal(rootSignaturesBytes, &rootSignatures); err != nil {
+			glog.Warningf("Failed to unmarshal root signatures: %v", err)
+			return trillian.SignedLogRoot{}, err
+		}
+	}
+
 	return trillian.SignedLogRoot{
 		RootHash:       rootHash,
 		TimestampNanos: timestamp,
@@ -549,6 +939,7 @@ func (t *logTreeTX) fetchLatestRoot() (trillian.SignedLogRoot, error) {
 		Signature:      &rootSignature,
 		LogId:          t.treeID,
 		TreeSize:       treeSize,
+		Signatures:     rootSignatures.Signatures,
 	}, nil
 }
 
@@ -560,8 +951,17 @@ func (t *logTreeTX) StoreSignedLogRoot(root trillian.SignedLogRoot) error {
 		return err
 	}
 
+	var signaturesBytes []byte
+	if len(root.Signatures) != 0 {
+		signaturesBytes, err = proto.Marshal(&storagepb.LogRootSignatures{Signatures: root.Signatures})
+		if err != nil {
+			glog.Warningf("Failed to marshal root signatures: %v", err)
+			return err
+		}
+	}
+
 	res, err := t.tx.Exec(insertTreeHeadSQL, t.treeID, root.TimestampNanos, root.TreeSize,
-		root.RootHash, root.TreeRevision, signatureBytes)
+		root.RootHash, root.TreeRevision, signatureBytes, signaturesBytes)
 
 	if err != nil {
 		glog.Warningf("Failed to store signed root: %s", err)
@@ -576,7 +976,7 @@ func (t *logTreeTX) UpdateSequencedLeaves(leaves []*trillian.LogLeaf) error {
 	for _, leaf := range leaves {
 		// This should fail on insert but catch it early
 		if len(leaf.LeafIdentityHash) != t.hashSizeBytes {
-			return errors.New("Sequenced leaf has incorrect hash size")
+			return te.New(te.InvalidArgument, "Sequenced leaf has incorrect hash size")
 		}
 
 		_, err := t.tx.Exec(insertSequencedLeafSQL, t.treeID, leaf.LeafIdentityHash, leaf.MerkleLeafHash,
@@ -584,13 +984,30 @@ func (t *logTreeTX) UpdateSequencedLeaves(leaves []*trillian.LogLeaf) error {
 
 		if err != nil {
 			glog.Warningf("Failed to update sequenced leaves: %s", err)
-			return err
+			return leafError(err)
 		}
 	}
 
 	return nil
 }
 
+// leafError returns err with an AlreadyExists or InvalidArgument code if MySQL
+// rejected the leaves written because they break the schema, rather than
+// because of the state of the database or the connection to it.
+func leafError(err error) error {
+	mysqlErr, ok := err.(*mysql.MySQLError)
+	if !ok {
+		return err
+	}
+	switch mysqlErr.Number {
+	case errNumDuplicate:
+		return te.Errorf(te.AlreadyExists, "%v", err)
+	case errNumBadNull, errNumOutOfRange, errNumDataTooLong, errNumNoReferenced:
+		return te.Errorf(te.InvalidArgument, "%v", err)
+	}
+	return err
+}
+
 // removeSequencedLeaves removes the passed in leaves slice (which may be
 // modified as part of the operation).
 func (t *logTreeTX) removeSequencedLeaves(leaves []*trillian.LogLeaf) error {
@@ -643,11 +1060,15 @@ func (t *logTreeTX) getLeavesByHashInternal(leafHashes [][]byte, tmpl *sql.Stmt,
 	defer rows.Close()
 	for rows.Next() {
 		leaf := &trillian.LogLeaf{}
+		var blobKey []byte
 
-		if err := rows.Scan(&leaf.MerkleLeafHash, &leaf.LeafIdentityHash, &leaf.LeafValue, &leaf.LeafIndex, &leaf.ExtraData); err != nil {
+		if err := rows.Scan(&leaf.MerkleLeafHash, &leaf.LeafIdentityHash, &leaf.LeafValue, &blobKey, &leaf.LeafIndex, &leaf.ExtraData, &leaf.Submitter); err != nil {
 			glog.Warningf("LogID: %d Scan() %s = %s", t.treeID, desc, err)
 			return nil, err
 		}
+		if err := t.resolveLeafValue(leaf, blobKey); err != nil {
+			return nil, err
+		}
 
 		if got, want := len(leaf.MerkleLeafHash), t.hashSizeBytes; got != want {
 			return nil, fmt.Errorf("LogID: %d Scanned leaf %s does not have hash length %d, got %d", t.treeID, desc, want, got)
@@ -659,6 +1080,18 @@ func (t *logTreeTX) getLeavesByHashInternal(leafHashes [][]byte, tmpl *sql.Stmt,
 	return ret, nil
 }
 
+// resolveLeafValue replaces the value of leaf with the blob it was offloaded
+// to, if blobKey is set.
+func (t *logTreeTX) resolveLeafValue(leaf *trillian.LogLeaf, blobKey []byte) error {
+	value, err := t.ls.blobs.Resolve(t.ctx, leaf.LeafValue, string(blobKey))
+	if err != nil {
+		glog.Warningf("LogID: %d failed to fetch offloaded leaf value: %s", t.treeID, err)
+		return err
+	}
+	leaf.LeafValue = value
+	return nil
+}
+
 // GetActiveLogIDs returns a list of the IDs of all configured logs
 func (t *logTreeTX) GetActiveLogIDs() ([]int64, error) {
 	return getActiveLogIDs(t.tx)
@@ -684,6 +1117,33 @@ func (l byLeafIdentityHash) Less(i, j int) bool {
 	return bytes.Compare(l[i].LeafIdentityHash, l[j].LeafIdentityHash) == -1
 }
 
+// byQueueOrder allows sorting of queued leaves into the order they're dequeued in: by
+// priority lane, then by queue time.
+type byQueueOrder []*trillian.LogLeaf
+
+func (l byQueueOrder) Len() int {
+	return len(l)
+}
+func (l byQueueOrder) Swap(i, j int) {
+	l[i], l[j] = l[j], l[i]
+}
+func (l byQueueOrder) Less(i, j int) bool {
+	laneI, _ := storage.PriorityLane(l[i].QueuePriority)
+	laneJ, _ := storage.PriorityLane(l[j].QueuePriority)
+	if laneI != laneJ {
+		return laneI < laneJ
+	}
+	if l[i].QueueTimestampNanos != l[j].QueueTimestampNanos {
+		return l[i].QueueTimestampNanos < l[j].QueueTimestampNanos
+	}
+	return bytes.Compare(l[i].LeafIdentityHash, l[j].LeafIdentityHash) == -1
+}
+
+// preparer prepares SQL statements, and is implemented by both sql.DB and sql.Tx.
+type preparer interface {
+	Prepare(query string) (*sql.Stmt, error)
+}
+
 // leafAndPosition records original position before sort.
 type leafAndPosition struct {
 	leaf *trillian.LogLeaf
@@ -33,7 +33,7 @@ import (
 	"github.com/google/trillian/storage"
 )
 
-var allTables = []string{"Unsequenced", "TreeHead", "SequencedLeafData", "LeafData", "Subtree", "TreeControl", "Trees", "MapLeaf", "MapHead"}
+var allTables = []string{"Unsequenced", "DeadLetter", "TreeHead", "SequencedLeafData", "LeafData", "Subtree", "TreeControl", "Trees", "MapLeaf", "MapHead", "MapMutationQueue", "TreeShards"}
 
 // Must be 32 bytes to match sha256 length if it was a real hash
 var dummyHash = []byte("hashxxxxhashxxxxhashxxxxhashxxxx")
@@ -381,6 +381,11 @@ func TestDequeueLeaves(t *testing.T) {
 			t.Fatalf("Dequeued %d leaves but expected to get %d", len(leaves2), leavesToInsert)
 		}
 		ensureAllLeavesDistinct(leaves2, t)
+		for _, leaf := range leaves2 {
+			if got, want := leaf.QueueTimestampNanos, fakeDequeueCutoffTime.UnixNano(); got != want {
+				t.Errorf("Dequeued leaf with QueueTimestampNanos %d, want %d", got, want)
+			}
+		}
 		commit(tx2, t)
 	}
 
@@ -507,6 +512,36 @@ func TestDequeueLeavesGuardInterval(t *testing.T) {
 	}
 }
 
+func TestNewestQueueTimestamp(t *testing.T) {
+	cleanTestDB(DB)
+	logID := createLogForTests(DB)
+	s := NewLogStorage(DB)
+	later := fakeQueueTime.Add(time.Minute)
+
+	tx := beginLogTx(s, logID, t)
+	defer tx.Close()
+	if got, err := tx.NewestQueueTimestamp(); err != nil || !got.IsZero() {
+		t.Errorf("NewestQueueTimestamp() of empty queue = (%v, %v), want zero time", got, err)
+	}
+	if _, err := tx.QueueLeaves(createTestLeaves(leavesToInsert, 20), fakeQueueTime); err != nil {
+		t.Fatalf("Failed to queue leaves: %v", err)
+	}
+	if _, err := tx.QueueLeaves(createTestLeaves(leavesToInsert, 40), later); err != nil {
+		t.Fatalf("Failed to queue leaves: %v", err)
+	}
+
+	// The newest leaves are reported even though they're within the guard
+	// interval of the cutoff, so aren't dequeued.
+	leaves, err := tx.DequeueLeaves(99, fakeQueueTime.Add(time.Second))
+	if err != nil || len(leaves) != leavesToInsert {
+		t.Fatalf("DequeueLeaves() = (%d leaves, %v), want %d leaves", len(leaves), err, leavesToInsert)
+	}
+	if got, err := tx.NewestQueueTimestamp(); err != nil || !got.Equal(later) {
+		t.Errorf("NewestQueueTimestamp() = (%v, %v), want %v", got, err, later)
+	}
+	commit(tx, t)
+}
+
 func TestDequeueLeavesTimeOrdering(t *testing.T) {
 	// Queue two small batches of leaves at different timestamps. Do two separate dequeue
 	// transactions and make sure the returned leaves are respecting the time ordering of the
@@ -572,6 +607,125 @@ func TestDequeueLeavesTimeOrdering(t *testing.T) {
 	}
 }
 
+func TestDequeueLeavesBuckets(t *testing.T) {
+	cleanTestDB(DB)
+	logID := createLogForTests(DB)
+	s := NewLogStorageWithQueueBuckets(DB, 4)
+
+	// Each leaf is queued a second earlier than the one before, so with enough of
+	// them they're spread across all the buckets out of order.
+	leaves := createTestLeaves(20, 0)
+	tx := beginLogTx(s, logID, t)
+	defer tx.Close()
+	for i, leaf := range leaves {
+		if _, err := tx.QueueLeaves([]*trillian.LogLeaf{leaf}, fakeQueueTime.Add(-time.Duration(i)*time.Second)); err != nil {
+			t.Fatalf("QueueLeaves(%d) = %v", i, err)
+		}
+	}
+	commit(tx, t)
+
+	var buckets []int
+	if err := func() error {
+		rows, err := DB.Query("SELECT DISTINCT QueueBucket FROM Unsequenced WHERE TreeId=?", logID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var bucket int
+			if err := rows.Scan(&bucket); err != nil {
+				return err
+			}
+			buckets = append(buckets, bucket)
+		}
+		return rows.Err()
+	}(); err != nil {
+		t.Fatalf("Could not query buckets: %v", err)
+	}
+	if len(buckets) < 2 {
+		t.Errorf("Leaves were queued in buckets %v; want them spread across several", buckets)
+	}
+
+	// The oldest leaves should be dequeued first, whichever bucket they're in.
+	tx2 := beginLogTx(s, logID, t)
+	defer tx2.Close()
+	dequeued, err := tx2.DequeueLeaves(5, fakeDequeueCutoffTime)
+	if err != nil {
+		t.Fatalf("DequeueLeaves() = %v", err)
+	}
+	if got, want := len(dequeued), 5; got != want {
+		t.Fatalf("Dequeue count mismatch got: %d, want: %d", got, want)
+	}
+	ensureAllLeavesDistinct(dequeued, t)
+	for _, leaf := range dequeued {
+		if !leafInBatch(leaf, leaves[15:]) {
+			t.Errorf("Dequeued leaf queued at %d, want one of the oldest", leaf.QueueTimestampNanos)
+		}
+	}
+	commit(tx2, t)
+	ensureUnsequencedCount(t, logID, 15)
+}
+
+func TestDequeueLeavesFewerBuckets(t *testing.T) {
+	cleanTestDB(DB)
+	logID := createLogForTests(DB)
+
+	leaves := createTestLeaves(20, 0)
+	tx := beginLogTx(NewLogStorageWithQueueBuckets(DB, 4), logID, t)
+	defer tx.Close()
+	if _, err := tx.QueueLeaves(leaves, fakeQueueTime); err != nil {
+		t.Fatalf("QueueLeaves() = %v", err)
+	}
+	commit(tx, t)
+
+	// Leaves queued in buckets beyond a reduced number should still be dequeued.
+	for _, queueBuckets := range []int{2, 1} {
+		tx2 := beginLogTx(NewLogStorageWithQueueBuckets(DB, queueBuckets), logID, t)
+		defer tx2.Close()
+		dequeued, err := tx2.DequeueLeaves(5, fakeDequeueCutoffTime)
+		if err != nil {
+			t.Fatalf("%d buckets: DequeueLeaves() = %v", queueBuckets, err)
+		}
+		if got, want := len(dequeued), 5; got != want {
+			t.Fatalf("%d buckets: Dequeue count mismatch got: %d, want: %d", queueBuckets, got, want)
+		}
+		commit(tx2, t)
+	}
+
+	tx3 := beginLogTx(NewLogStorage(DB), logID, t)
+	defer tx3.Close()
+	dequeued, err := tx3.DequeueLeaves(20, fakeDequeueCutoffTime)
+	if err != nil {
+		t.Fatalf("DequeueLeaves() = %v", err)
+	}
+	if got, want := len(dequeued), 10; got != want {
+		t.Fatalf("Dequeue count mismatch got: %d, want: %d", got, want)
+	}
+	commit(tx3, t)
+	ensureUnsequencedCount(t, logID, 0)
+}
+
+func TestQueueBucket(t *testing.T) {
+	s := NewLogStorageWithQueueBuckets(nil, 8).(*mySQLLogStorage)
+	counts := make([]int, 8)
+	for _, leaf := range createTestLeaves(800, 0) {
+		bucket := s.queueBucket(leaf.LeafIdentityHash)
+		if bucket != s.queueBucket(leaf.LeafIdentityHash) {
+			t.Fatalf("queueBucket(%x) isn't deterministic", leaf.LeafIdentityHash)
+		}
+		counts[bucket]++
+	}
+	for bucket, count := range counts {
+		if count < 50 {
+			t.Errorf("queueBucket() put %d of 800 leaves in bucket %d, want them spread evenly", count, bucket)
+		}
+	}
+
+	if got := NewLogStorage(nil).(*mySQLLogStorage).queueBucket([]byte("hash")); got != 0 {
+		t.Errorf("queueBucket() with one bucket = %d, want 0", got)
+	}
+}
+
 func TestGetLeavesByHashNotPresent(t *testing.T) {
 	cleanTestDB(DB)
 	logID := createLogForTests(DB)
@@ -629,6 +783,157 @@ func TestGetLeavesByHash(t *testing.T) {
 	commit(tx, t)
 }
 
+func TestQueueLeavesSubmitter(t *testing.T) {
+	cleanTestDB(DB)
+	logID := createLogForTests(DB)
+	s := NewLogStorage(DB)
+
+	tx := beginLogTx(s, logID, t)
+	defer tx.Close()
+
+	leaves := createTestLeaves(2, 20)
+	leaves[0].Submitter = "alice"
+	if _, err := tx.QueueLeaves(leaves, fakeQueueTime); err != nil {
+		t.Fatalf("Failed to queue leaves: %v", err)
+	}
+	commit(tx, t)
+
+	tx2 := beginLogTx(s, logID, t)
+	defer tx2.Close()
+	for _, leaf := range leaves {
+		got, err := tx2.(*logTreeTX).getLeafDataByIdentityHash([][]byte{leaf.LeafIdentityHash})
+		if err != nil {
+			t.Fatalf("getLeafDataByIdentityHash(_) = (_,%v); want (_,nil)", err)
+		}
+		if len(got) != 1 || got[0].Submitter != leaf.Submitter {
+			t.Errorf("getLeafDataByIdentityHash(_) = (%+v,nil); want leaf with Submitter %q", got, leaf.Submitter)
+		}
+	}
+	commit(tx2, t)
+}
+
+func TestDequeueLeavesPriority(t *testing.T) {
+	// With several buckets, the leaves already dequeued from a lane must not be
+	// dequeued again by DequeueLeaves, although they're read outside the transaction.
+	for _, queueBuckets := range []int{1, 4} {
+		cleanTestDB(DB)
+		logID := createLogForTests(DB)
+		s := NewLogStorageWithQueueBuckets(DB, queueBuckets)
+
+		tx := beginLogTx(s, logID, t)
+		defer tx.Close()
+
+		leaves := createTestLeaves(4, 20)
+		leaves[0].QueuePriority = trillian.LeafPriority_PRIORITY_BULK
+		leaves[1].QueuePriority = trillian.LeafPriority_PRIORITY_NORMAL
+		leaves[2].QueuePriority = trillian.LeafPriority_PRIORITY_HIGH
+		leaves[3].QueuePriority = trillian.LeafPriority_PRIORITY_BULK
+		if _, err := tx.QueueLeaves(leaves, fakeQueueTime); err != nil {
+			t.Fatalf("Failed to queue leaves: %v", err)
+		}
+		commit(tx, t)
+
+		tx2 := beginLogTx(s, logID, t)
+		defer tx2.Close()
+		bulk, err := tx2.DequeuePriorityLeaves(trillian.LeafPriority_PRIORITY_BULK, 1, fakeDequeueCutoffTime)
+		if err != nil {
+			t.Fatalf("%d buckets: DequeuePriorityLeaves() = (_,%v); want (_,nil)", queueBuckets, err)
+		}
+		if len(bulk) != 1 || bulk[0].QueuePriority != trillian.LeafPriority_PRIORITY_BULK {
+			t.Fatalf("%d buckets: DequeuePriorityLeaves() = (%+v,nil); want one bulk leaf", queueBuckets, bulk)
+		}
+		rest, err := tx2.DequeueLeaves(10, fakeDequeueCutoffTime)
+		if err != nil {
+			t.Fatalf("%d buckets: DequeueLeaves() = (_,%v); want (_,nil)", queueBuckets, err)
+		}
+		var got []trillian.LeafPriority
+		for _, leaf := range rest {
+			got = append(got, leaf.QueuePriority)
+			if bytes.Equal(leaf.LeafIdentityHash, bulk[0].LeafIdentityHash) {
+				t.Errorf("%d buckets: DequeueLeaves() returned leaf %x again", queueBuckets, leaf.LeafIdentityHash)
+			}
+		}
+		want := []trillian.LeafPriority{trillian.LeafPriority_PRIORITY_HIGH, trillian.LeafPriority_PRIORITY_NORMAL, trillian.LeafPriority_PRIORITY_BULK}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%d buckets: DequeueLeaves() returned leaves with priorities %v; want %v", queueBuckets, got, want)
+		}
+		commit(tx2, t)
+		ensureUnsequencedCount(t, logID, 0)
+	}
+}
+
+func TestDeadLetterLeaves(t *testing.T) {
+	cleanTestDB(DB)
+	logID := createLogForTests(DB)
+	s := NewLogStorage(DB)
+
+	tx := beginLogTx(s, logID, t)
+	defer tx.Close()
+	leaves := createTestLeaves(3, 20)
+	leaves[0].Submitter = "alice"
+	leaves[0].QueuePriority = trillian.LeafPriority_PRIORITY_HIGH
+	if _, err := tx.QueueLeaves(leaves, fakeQueueTime); err != nil {
+		t.Fatalf("Failed to queue leaves: %v", err)
+	}
+	commit(tx, t)
+
+	// Dead-letter the first two leaves, leaving only the third queued.
+	tx2 := beginLogTx(s, logID, t)
+	defer tx2.Close()
+	var deadLetters []*trillian.DeadLetterLeaf
+	for i, leaf := range leaves[:2] {
+		deadLetters = append(deadLetters, &trillian.DeadLetterLeaf{
+			Leaf:                     &trillian.LogLeaf{LeafIdentityHash: leaf.LeafIdentityHash, MerkleLeafHash: leaf.MerkleLeafHash, QueuePriority: leaf.QueuePriority},
+			Error:                    fmt.Sprintf("error %d", i),
+			DeadLetterTimestampNanos: fakeQueueTime.Add(time.Duration(i) * time.Second).UnixNano(),
+		})
+	}
+	if err := tx2.DeadLetterLeaves(deadLetters); err != nil {
+		t.Fatalf("DeadLetterLeaves() = %v; want nil", err)
+	}
+	commit(tx2, t)
+	ensureUnsequencedCount(t, logID, 1)
+
+	tx3 := beginLogTx(s, logID, t)
+	defer tx3.Close()
+	got, err := tx3.GetDeadLetterLeaves(10)
+	if err != nil {
+		t.Fatalf("GetDeadLetterLeaves() = (_,%v); want (_,nil)", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("GetDeadLetterLeaves() returned %d leaves; want 2", len(got))
+	}
+	if got, want := got[0].Leaf.LeafValue, leaves[0].LeafValue; !bytes.Equal(got, want) {
+		t.Errorf("GetDeadLetterLeaves()[0].Leaf.LeafValue = %s; want %s", got, want)
+	}
+	if got, want := got[0].Leaf.Submitter, "alice"; got != want {
+		t.Errorf("GetDeadLetterLeaves()[0].Leaf.Submitter = %q; want %q", got, want)
+	}
+	if got, want := got[0].Leaf.QueuePriority, trillian.LeafPriority_PRIORITY_HIGH; got != want {
+		t.Errorf("GetDeadLetterLeaves()[0].Leaf.QueuePriority = %v; want %v", got, want)
+	}
+	if got, want := got[1].Error, "error 1"; got != want {
+		t.Errorf("GetDeadLetterLeaves()[1].Error = %q; want %q", got, want)
+	}
+
+	// Requeue the first leaf and discard the second.
+	if err := tx3.RequeueDeadLetterLeaves([][]byte{leaves[0].LeafIdentityHash}, fakeQueueTime); err != nil {
+		t.Fatalf("RequeueDeadLetterLeaves() = %v; want nil", err)
+	}
+	if err := tx3.DiscardDeadLetterLeaves([][]byte{leaves[1].LeafIdentityHash, []byte("unknown")}); err != nil {
+		t.Fatalf("DiscardDeadLetterLeaves() = %v; want nil", err)
+	}
+	if got, err := tx3.GetDeadLetterLeaves(10); err != nil || len(got) != 0 {
+		t.Errorf("GetDeadLetterLeaves() = (%v,%v); want (empty,nil)", got, err)
+	}
+	discarded, err := tx3.(*logTreeTX).getLeafDataByIdentityHash([][]byte{leaves[1].LeafIdentityHash})
+	if err != nil || len(discarded) != 0 {
+		t.Errorf("getLeafDataByIdentityHash(discarded) = (%v,%v); want (empty,nil)", discarded, err)
+	}
+	commit(tx3, t)
+	ensureUnsequencedCount(t, logID, 2)
+}
+
 func TestGetLeafDataByIdentityHash(t *testing.T) {
 	// Create fake leaf as if it had been sequenced
 	cleanTestDB(DB)
@@ -1091,6 +1396,17 @@ func TestSortByLeafIdentityHash(t *testing.T) {
 
 }
 
+// ensureUnsequencedCount checks how many leaves are queued for the log.
+func ensureUnsequencedCount(t *testing.T, logID int64, want int) {
+	var count int
+	if err := DB.QueryRow("SELECT COUNT(*) FROM Unsequenced WHERE TreeID=?", logID).Scan(&count); err != nil {
+		t.Fatalf("Could not query row count: %v", err)
+	}
+	if count != want {
+		t.Errorf("Expected %d unsequenced rows but got: %d", want, count)
+	}
+}
+
 func ensureAllLeavesDistinct(leaves []*trillian.LogLeaf, t *testing.T) {
 	// All the leaf value hashes should be distinct because the leaves were created with distinct
 	// leaf data. If only we had maps with slices as keys or sets or pretty much any kind of usable
@@ -17,6 +17,8 @@ package mysql
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"time"
 
 	"github.com/golang/glog"
 	"github.com/golang/protobuf/proto"
@@ -24,6 +26,7 @@ import (
 	spb "github.com/google/trillian/crypto/sigpb"
 	"github.com/google/trillian/merkle"
 	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/blob"
 	"github.com/google/trillian/storage/cache"
 )
 
@@ -33,9 +36,11 @@ const (
 	selectLatestSignedMapRootSQL = `SELECT MapHeadTimestamp, RootHash, MapRevision, RootSignature, MapperData
 		 FROM MapHead WHERE TreeId=?
 		 ORDER BY MapHeadTimestamp DESC LIMIT 1`
-	insertMapLeafSQL = `INSERT INTO MapLeaf(TreeId, KeyHash, MapRevision, LeafValue) VALUES (?, ?, ?, ?)`
+	selectSignedMapRootSQL = `SELECT MapHeadTimestamp, RootHash, MapRevision, RootSignature, MapperData
+		 FROM MapHead WHERE TreeId=? AND MapRevision=?`
+	insertMapLeafSQL = `INSERT INTO MapLeaf(TreeId, KeyHash, MapRevision, LeafValue, LeafValueBlob) VALUES (?, ?, ?, ?, ?)`
 	selectMapLeafSQL = `
- SELECT t1.KeyHash, t1.MapRevision, t1.LeafValue
+ SELECT t1.KeyHash, t1.MapRevision, t1.LeafValue, t1.LeafValueBlob
  FROM MapLeaf t1
  INNER JOIN
  (
@@ -48,18 +53,39 @@ const (
  ON t1.TreeId=t2.TreeId
  AND t1.KeyHash=t2.KeyHash
  AND t1.MapRevision=t2.maxrev`
+	selectMapLeafHistorySQL = `SELECT MapRevision, LeafValue, LeafValueBlob FROM MapLeaf
+		 WHERE TreeId=? AND KeyHash=? AND MapRevision>=? AND MapRevision<=?
+		 ORDER BY MapRevision`
+	insertMapMutationSQL  = `INSERT INTO MapMutationQueue(TreeId, QueueTimestampNanos, Mutation) VALUES(?, ?, ?)`
+	selectMapMutationsSQL = `SELECT MutationId, QueueTimestampNanos, Mutation
+		 FROM MapMutationQueue WHERE TreeId=? AND MapRevision IS NULL
+		 ORDER BY MutationId LIMIT ? FOR UPDATE`
+	updateMapMutationsSQL     = `UPDATE MapMutationQueue SET MapRevision=? WHERE TreeId=? AND MutationId IN (` + placeholderSQL + `)`
+	selectMapMutationRevSQL   = `SELECT MapRevision FROM MapMutationQueue WHERE TreeId=? AND MutationId=?`
+	deleteAppliedMutationsSQL = `DELETE FROM MapMutationQueue
+		 WHERE TreeId=? AND MapRevision IS NOT NULL AND QueueTimestampNanos<?`
 )
 
 var defaultMapStrata = []int{8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 176}
 
 type mySQLMapStorage struct {
 	*mySQLTreeStorage
+	// blobs offloads large leaf values, or is nil to keep all values inline.
+	blobs *blob.Offloader
 }
 
 // NewMapStorage creates a mySQLMapStorage instance for the specified MySQL URL.
 func NewMapStorage(db *sql.DB) storage.MapStorage {
+	return NewMapStorageWithBlobs(db, nil)
+}
+
+// NewMapStorageWithBlobs creates a mySQLMapStorage instance which offloads map
+// leaves whose serialized form is larger than the threshold of blobs to its blob
+// store, fetching them back transparently when leaves are read.
+func NewMapStorageWithBlobs(db *sql.DB, blobs *blob.Offloader) storage.MapStorage {
 	return &mySQLMapStorage{
 		mySQLTreeStorage: newTreeStorage(db),
+		blobs:            blobs,
 	}
 }
 
@@ -114,6 +140,7 @@ func (m *mySQLMapStorage) BeginForTree(ctx context.Context, treeID int64) (stora
 
 	mtx := &mapTreeTX{
 		treeTX: ttx,
+		ctx:    ctx,
 		ms:     m,
 	}
 
@@ -136,6 +163,8 @@ func (m *mySQLMapStorage) SnapshotForTree(ctx context.Context, treeID int64) (st
 
 type mapTreeTX struct {
 	treeTX
+	// ctx is used to reach the blob store holding offloaded leaf values.
+	ctx  context.Context
 	ms   *mySQLMapStorage
 	root trillian.SignedMapRoot
 }
@@ -157,6 +186,10 @@ func (m *mapTreeTX) Set(keyHash []byte, value trillian.MapLeaf) error {
 	if err != nil {
 		return nil
 	}
+	flatValue, blobKey, err := m.ms.blobs.Offload(m.ctx, flatValue)
+	if err != nil {
+		return err
+	}
 
 	stmt, err := m.tx.Prepare(insertMapLeafSQL)
 	if err != nil {
@@ -164,7 +197,7 @@ func (m *mapTreeTX) Set(keyHash []byte, value trillian.MapLeaf) error {
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(m.treeID, keyHash, m.writeRevision, flatValue)
+	_, err = stmt.Exec(m.treeID, keyHash, m.writeRevision, flatValue, nullableBlobKey(blobKey))
 	return err
 }
 
@@ -201,8 +234,12 @@ func (m *mapTreeTX) Get(revision int64, indexes [][]byte) ([]trillian.MapLeaf, e
 	for rows.Next() {
 		var mapKeyHash []byte
 		var mapRevision int64
-		var flatData []byte
-		err = rows.Scan(&mapKeyHash, &mapRevision, &flatData)
+		var flatData, blobKey []byte
+		err = rows.Scan(&mapKeyHash, &mapRevision, &flatData, &blobKey)
+		if err != nil {
+			return nil, err
+		}
+		flatData, err = m.ms.blobs.Resolve(m.ctx, flatData, string(blobKey))
 		if err != nil {
 			return nil, err
 		}
@@ -223,25 +260,70 @@ func (m *mapTreeTX) Get(revision int64, indexes [][]byte) ([]trillian.MapLeaf, e
 	return ret, nil
 }
 
+func (m *mapTreeTX) GetHistory(keyHash []byte, fromRevision, toRevision int64) ([]storage.MapLeafRevision, error) {
+	rows, err := m.tx.Query(selectMapLeafHistorySQL, m.treeID, keyHash, fromRevision, toRevision)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ret []storage.MapLeafRevision
+	for rows.Next() {
+		var rev int64
+		var flatData, blobKey []byte
+		if err := rows.Scan(&rev, &flatData, &blobKey); err != nil {
+			return nil, err
+		}
+		flatData, err := m.ms.blobs.Resolve(m.ctx, flatData, string(blobKey))
+		if err != nil {
+			return nil, err
+		}
+		var mapLeaf trillian.MapLeaf
+		if err := proto.Unmarshal(flatData, &mapLeaf); err != nil {
+			return nil, err
+		}
+		mapLeaf.Index = keyHash
+		ret = append(ret, storage.MapLeafRevision{Revision: rev, Leaf: mapLeaf})
+	}
+	return ret, rows.Err()
+}
+
 func (m *mapTreeTX) LatestSignedMapRoot() (trillian.SignedMapRoot, error) {
+	root, err := m.signedMapRoot(selectLatestSignedMapRootSQL, m.treeID)
+	// It's possible there are no roots for this tree yet
+	if err == sql.ErrNoRows {
+		return trillian.SignedMapRoot{}, nil
+	}
+	return root, err
+}
+
+func (m *mapTreeTX) GetSignedMapRoot(revision int64) (trillian.SignedMapRoot, error) {
+	root, err := m.signedMapRoot(selectSignedMapRootSQL, m.treeID, revision)
+	if err == sql.ErrNoRows {
+		return trillian.SignedMapRoot{}, fmt.Errorf("no root for revision %d of map %d", revision, m.treeID)
+	}
+	return root, err
+}
+
+// signedMapRoot reads a single map root with query. It returns sql.ErrNoRows
+// if there is no matching root.
+func (m *mapTreeTX) signedMapRoot(query string, args ...interface{}) (trillian.SignedMapRoot, error) {
 	var timestamp, mapRevision int64
 	var rootHash, rootSignatureBytes []byte
 	var rootSignature spb.DigitallySigned
 	var mapperMetaBytes []byte
 	var mapperMeta *trillian.MapperMetadata
 
-	stmt, err := m.tx.Prepare(selectLatestSignedMapRootSQL)
+	stmt, err := m.tx.Prepare(query)
 	if err != nil {
 		return trillian.SignedMapRoot{}, err
 	}
 	defer stmt.Close()
 
-	err = stmt.QueryRow(m.treeID).Scan(
+	err = stmt.QueryRow(args...).Scan(
 		&timestamp, &rootHash, &mapRevision, &rootSignatureBytes, &mapperMetaBytes)
-
-	// It's possible there are no roots for this tree yet
-	if err == sql.ErrNoRows {
-		return trillian.SignedMapRoot{}, nil
+	if err != nil {
+		return trillian.SignedMapRoot{}, err
 	}
 
 	err = proto.Unmarshal(rootSignatureBytes, &rootSignature)
@@ -302,3 +384,90 @@ func (m *mapTreeTX) StoreSignedMapRoot(root trillian.SignedMapRoot) error {
 
 	return checkResultOkAndRowCountIs(res, err, 1)
 }
+
+// QueueMutation stores the mutation as a serialized SetMapLeavesRequest, which
+// conveniently holds both the leaves and the mapper metadata.
+func (m *mapTreeTX) QueueMutation(leaves []*trillian.MapLeaf, mapperData *trillian.MapperMetadata, queueTimestamp time.Time) (int64, error) {
+	mutation, err := proto.Marshal(&trillian.SetMapLeavesRequest{
+		MapId:      m.treeID,
+		Leaves:     leaves,
+		MapperData: mapperData,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := m.tx.Exec(insertMapMutationSQL, m.treeID, queueTimestamp.UnixNano(), mutation)
+	if err := checkResultOkAndRowCountIs(res, err, 1); err != nil {
+		glog.Warningf("Failed to queue map mutation: %v", err)
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (m *mapTreeTX) DequeueMutations(limit int) ([]*storage.QueuedMapMutation, error) {
+	rows, err := m.tx.Query(selectMapMutationsSQL, m.treeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ret []*storage.QueuedMapMutation
+	for rows.Next() {
+		var id, queueTimestamp int64
+		var mutation []byte
+		if err := rows.Scan(&id, &queueTimestamp, &mutation); err != nil {
+			return nil, err
+		}
+		var req trillian.SetMapLeavesRequest
+		if err := proto.Unmarshal(mutation, &req); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal map mutation %d: %v", id, err)
+		}
+		ret = append(ret, &storage.QueuedMapMutation{
+			ID:             id,
+			Leaves:         req.Leaves,
+			MapperData:     req.MapperData,
+			QueueTimestamp: time.Unix(0, queueTimestamp),
+		})
+	}
+	return ret, rows.Err()
+}
+
+func (m *mapTreeTX) MarkMutationsApplied(ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	stmt, err := m.ms.getStmt(updateMapMutationsSQL, len(ids), "?", "?")
+	if err != nil {
+		return err
+	}
+	stx := m.tx.Stmt(stmt)
+	defer stx.Close()
+
+	args := make([]interface{}, 0, len(ids)+2)
+	args = append(args, m.writeRevision, m.treeID)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	res, err := stx.Exec(args...)
+	return checkResultOkAndRowCountIs(res, err, int64(len(ids)))
+}
+
+func (m *mapTreeTX) MutationRevision(id int64) (int64, bool, error) {
+	var rev sql.NullInt64
+	err := m.tx.QueryRow(selectMapMutationRevSQL, m.treeID, id).Scan(&rev)
+	if err == sql.ErrNoRows {
+		return 0, false, fmt.Errorf("no map mutation %d in tree %d", id, m.treeID)
+	} else if err != nil {
+		return 0, false, err
+	}
+	return rev.Int64, rev.Valid, nil
+}
+
+func (m *mapTreeTX) PruneAppliedMutations(cutoff time.Time) (int64, error) {
+	res, err := m.tx.Exec(deleteAppliedMutationsSQL, m.treeID, cutoff.UnixNano())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
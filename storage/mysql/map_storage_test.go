@@ -15,8 +15,11 @@
 package mysql
 
 import (
+	"bytes"
 	"context"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/google/trillian"
@@ -279,6 +282,97 @@ func TestMapSetGetMultipleRevisions(t *testing.T) {
 	}
 }
 
+func TestMapGetHistory(t *testing.T) {
+	cleanTestDB(DB)
+	mapID := createMapForTests(DB)
+	s := NewMapStorage(DB)
+	otherKeyHash := []byte("A Different Key Hash")
+
+	ctx := context.Background()
+	for _, rev := range []int64{1, 3, 4, 7} {
+		tx := beginMapTx(ctx, s, mapID, t)
+		defer tx.Close()
+		tx.(*mapTreeTX).treeTX.writeRevision = rev
+		if err := tx.Set(keyHash, trillian.MapLeaf{Index: keyHash, LeafValue: []byte{byte(rev)}}); err != nil {
+			t.Fatalf("Set(): %v", err)
+		}
+		if err := tx.Set(otherKeyHash, trillian.MapLeaf{Index: otherKeyHash, LeafValue: []byte("other")}); err != nil {
+			t.Fatalf("Set(): %v", err)
+		}
+		commit(tx, t)
+	}
+
+	for _, test := range []struct {
+		from, to int64
+		want     []int64
+	}{
+		{from: 0, to: 10, want: []int64{1, 3, 4, 7}},
+		{from: 3, to: 4, want: []int64{3, 4}},
+		{from: 2, to: 6, want: []int64{3, 4}},
+		{from: 5, to: 6},
+		{from: 7, to: 7, want: []int64{7}},
+	} {
+		tx := beginMapTx(ctx, s, mapID, t)
+		defer tx.Close()
+		history, err := tx.GetHistory(keyHash, test.from, test.to)
+		if err != nil {
+			t.Fatalf("GetHistory(%d, %d): %v", test.from, test.to, err)
+		}
+		var got []int64
+		for _, h := range history {
+			got = append(got, h.Revision)
+			if want := []byte{byte(h.Revision)}; !bytes.Equal(h.Leaf.LeafValue, want) || !bytes.Equal(h.Leaf.Index, keyHash) {
+				t.Errorf("GetHistory(%d, %d) returned %v at revision %d, want value %x", test.from, test.to, h.Leaf, h.Revision, want)
+			}
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("GetHistory(%d, %d) returned revisions %v, want %v", test.from, test.to, got, test.want)
+		}
+		commit(tx, t)
+	}
+}
+
+func TestGetSignedMapRoot(t *testing.T) {
+	cleanTestDB(DB)
+	mapID := createMapForTests(DB)
+	s := NewMapStorage(DB)
+
+	ctx := context.Background()
+	tx := beginMapTx(ctx, s, mapID, t)
+	defer tx.Close()
+	var roots []trillian.SignedMapRoot
+	for rev := int64(1); rev <= 3; rev++ {
+		root := trillian.SignedMapRoot{
+			MapId:          mapID,
+			TimestampNanos: 98765 + rev,
+			MapRevision:    rev,
+			RootHash:       []byte(dummyHash),
+			Signature:      &spb.DigitallySigned{Signature: []byte("notempty")},
+		}
+		if err := tx.StoreSignedMapRoot(root); err != nil {
+			t.Fatalf("Failed to store signed map root: %v", err)
+		}
+		roots = append(roots, root)
+	}
+	commit(tx, t)
+
+	tx2 := beginMapTx(ctx, s, mapID, t)
+	defer tx2.Close()
+	for _, want := range roots {
+		got, err := tx2.GetSignedMapRoot(want.MapRevision)
+		if err != nil {
+			t.Fatalf("GetSignedMapRoot(%d): %v", want.MapRevision, err)
+		}
+		if !proto.Equal(&got, &want) {
+			t.Errorf("GetSignedMapRoot(%d)=%v, want %v", want.MapRevision, got, want)
+		}
+	}
+	if _, err := tx2.GetSignedMapRoot(4); err == nil {
+		t.Error("GetSignedMapRoot(4) for missing revision: got nil err, want error")
+	}
+	commit(tx2, t)
+}
+
 func TestLatestSignedMapRootNoneWritten(t *testing.T) {
 	cleanTestDB(DB)
 	mapID := createMapForTests(DB)
@@ -361,6 +455,82 @@ func TestDuplicateSignedMapRoot(t *testing.T) {
 	commit(tx, t)
 }
 
+func TestMapMutationQueue(t *testing.T) {
+	cleanTestDB(DB)
+	mapID := createMapForTests(DB)
+	s := NewMapStorage(DB)
+	ctx := context.Background()
+
+	queueTime := time.Unix(10, 0)
+	mutations := [][]*trillian.MapLeaf{
+		{{Index: []byte("key1"), LeafValue: []byte("value1")}},
+		{{Index: []byte("key2"), LeafValue: []byte("value2")}, {Index: []byte("key3"), LeafValue: []byte("value3")}},
+		{{Index: []byte("key1"), LeafValue: []byte("value4")}},
+	}
+	var ids []int64
+	{
+		tx := beginMapTx(ctx, s, mapID, t)
+		defer tx.Close()
+		for i, leaves := range mutations {
+			id, err := tx.QueueMutation(leaves, &trillian.MapperMetadata{HighestFullyCompletedSeq: int64(i)}, queueTime)
+			if err != nil {
+				t.Fatalf("QueueMutation(): %v", err)
+			}
+			ids = append(ids, id)
+		}
+		commit(tx, t)
+	}
+
+	{
+		tx := beginMapTx(ctx, s, mapID, t)
+		defer tx.Close()
+		got, err := tx.DequeueMutations(2)
+		if err != nil {
+			t.Fatalf("DequeueMutations(): %v", err)
+		}
+		if len(got) != 2 {
+			t.Fatalf("DequeueMutations(2) returned %d mutations, want 2", len(got))
+		}
+		for i, m := range got {
+			if m.ID != ids[i] || !m.QueueTimestamp.Equal(queueTime) || m.MapperData.GetHighestFullyCompletedSeq() != int64(i) {
+				t.Errorf("DequeueMutations()[%d]=%+v, want mutation %d", i, m, ids[i])
+			}
+			if len(m.Leaves) != len(mutations[i]) || !proto.Equal(m.Leaves[0], mutations[i][0]) {
+				t.Errorf("DequeueMutations()[%d].Leaves=%v, want %v", i, m.Leaves, mutations[i])
+			}
+		}
+		if err := tx.MarkMutationsApplied(ids[:2]); err != nil {
+			t.Fatalf("MarkMutationsApplied(): %v", err)
+		}
+		if rev, applied, err := tx.MutationRevision(ids[0]); err != nil || !applied || rev != tx.WriteRevision() {
+			t.Errorf("MutationRevision()=%v, %v, %v, want %v, true, nil", rev, applied, err, tx.WriteRevision())
+		}
+		if _, applied, err := tx.MutationRevision(ids[2]); err != nil || applied {
+			t.Errorf("MutationRevision()=_, %v, %v, want _, false, nil", applied, err)
+		}
+		commit(tx, t)
+	}
+
+	{
+		tx := beginMapTx(ctx, s, mapID, t)
+		defer tx.Close()
+		got, err := tx.DequeueMutations(10)
+		if err != nil {
+			t.Fatalf("DequeueMutations(): %v", err)
+		}
+		if len(got) != 1 || got[0].ID != ids[2] {
+			t.Errorf("DequeueMutations() after applying=%v, want only mutation %d", got, ids[2])
+		}
+		if n, err := tx.PruneAppliedMutations(queueTime.Add(time.Second)); err != nil || n != 2 {
+			t.Errorf("PruneAppliedMutations()=%v, %v, want 2, nil", n, err)
+		}
+		if _, _, err := tx.MutationRevision(ids[0]); err == nil {
+			t.Error("MutationRevision() of pruned mutation: got nil err, want error")
+		}
+		commit(tx, t)
+	}
+}
+
 func TestReadOnlyMapTX_Rollback(t *testing.T) {
 	cleanTestDB(DB)
 	s := NewMapStorage(DB)
@@ -0,0 +1,110 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/trillian/storage/blob"
+	"github.com/google/trillian/storage/sharded"
+)
+
+const (
+	selectTreeShardSQL  = "SELECT ShardName FROM TreeShards WHERE TreeId=?"
+	insertTreeShardSQL  = "INSERT INTO TreeShards(TreeId, ShardName) VALUES(?, ?)"
+	selectShardSizesSQL = "SELECT ShardName, COUNT(*) FROM TreeShards GROUP BY ShardName"
+)
+
+// NewShardDirectory returns a sharded.Directory kept in the TreeShards table
+// of db. ShardForTree returns sql.ErrNoRows for trees which haven't been
+// placed.
+func NewShardDirectory(db *sql.DB) sharded.Directory {
+	return &shardDirectory{db: db}
+}
+
+type shardDirectory struct {
+	db *sql.DB
+}
+
+func (d *shardDirectory) ShardForTree(ctx context.Context, treeID int64) (string, error) {
+	var shard string
+	if err := d.db.QueryRow(selectTreeShardSQL, treeID).Scan(&shard); err != nil {
+		return "", err
+	}
+	return shard, nil
+}
+
+func (d *shardDirectory) PlaceTree(ctx context.Context, treeID int64, shard string) error {
+	_, err := d.db.Exec(insertTreeShardSQL, treeID, shard)
+	return err
+}
+
+func (d *shardDirectory) TreeCounts(ctx context.Context) (map[string]int64, error) {
+	rows, err := d.db.Query(selectShardSizesSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var shard string
+		var count int64
+		if err := rows.Scan(&shard, &count); err != nil {
+			return nil, err
+		}
+		counts[shard] = count
+	}
+	return counts, rows.Err()
+}
+
+// OpenShards opens the MySQL databases given in spec, a comma-separated list
+// of name=uri pairs, returning a shard for each. Large leaf values are offloaded
+// by blobs, which may be nil. The databases stay open for the lifetime of the
+// process.
+func OpenShards(spec string, queueBuckets int, blobs *blob.Offloader) ([]*sharded.Shard, error) {
+	var shards []*sharded.Shard
+	for _, pair := range strings.Split(spec, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || len(kv[0]) == 0 {
+			return nil, fmt.Errorf("invalid shard %q: want name=uri", pair)
+		}
+		db, err := OpenDB(kv[1])
+		if err != nil {
+			return nil, fmt.Errorf("shard %q: %v", kv[0], err)
+		}
+		shards = append(shards, &sharded.Shard{
+			Name:         kv[0],
+			AdminStorage: NewAdminStorage(db),
+			LogStorage:   NewLogStorageWithBlobs(db, queueBuckets, blobs),
+			MapStorage:   NewMapStorageWithBlobs(db, blobs),
+		})
+	}
+	return shards, nil
+}
+
+// NewShardedStorage returns storage spreading trees across the shards given
+// in spec, as for OpenShards, with the directory kept in directoryDB. New
+// trees are placed in the shard holding the fewest trees.
+func NewShardedStorage(directoryDB *sql.DB, spec string, queueBuckets int, blobs *blob.Offloader) (*sharded.Storage, error) {
+	shards, err := OpenShards(spec, queueBuckets, blobs)
+	if err != nil {
+		return nil, err
+	}
+	dir := NewShardDirectory(directoryDB)
+	return sharded.New(dir, shards, sharded.FewestTreesPlacement{Directory: dir})
+}
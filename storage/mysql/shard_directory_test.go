@@ -0,0 +1,57 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestShardDirectory(t *testing.T) {
+	cleanTestDB(DB)
+	ctx := context.Background()
+	dir := NewShardDirectory(DB)
+
+	if _, err := dir.ShardForTree(ctx, 1); err != sql.ErrNoRows {
+		t.Errorf("ShardForTree() of unplaced tree = (_, %v), want = (_, %v)", err, sql.ErrNoRows)
+	}
+
+	placements := map[int64]string{1: "a", 2: "b", 3: "a"}
+	for treeID, shard := range placements {
+		if err := dir.PlaceTree(ctx, treeID, shard); err != nil {
+			t.Fatalf("PlaceTree(%v, %q) = %v, want = nil", treeID, shard, err)
+		}
+	}
+	if err := dir.PlaceTree(ctx, 1, "b"); err == nil {
+		t.Error("PlaceTree() of placed tree = nil, want error")
+	}
+
+	for treeID, want := range placements {
+		got, err := dir.ShardForTree(ctx, treeID)
+		if err != nil || got != want {
+			t.Errorf("ShardForTree(%v) = (%q, %v), want = (%q, nil)", treeID, got, err, want)
+		}
+	}
+
+	counts, err := dir.TreeCounts(ctx)
+	if err != nil {
+		t.Fatalf("TreeCounts() = (_, %v), want = (_, nil)", err)
+	}
+	if want := map[string]int64{"a": 2, "b": 1}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("TreeCounts() = %v, want = %v", counts, want)
+	}
+}
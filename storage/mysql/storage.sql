@@ -56,6 +56,7 @@ CREATE TABLE IF NOT EXISTS TreeHead(
   RootHash             VARBINARY(255) NOT NULL,
   RootSignature        VARBINARY(255) NOT NULL,
   TreeRevision         BIGINT,
+  RootSignatures       BLOB,
   PRIMARY KEY(TreeId, TreeHeadTimestamp),
   UNIQUE INDEX TreeRevisionIdx(TreeId, TreeRevision),
   FOREIGN KEY(TreeId) REFERENCES Trees(TreeId) ON DELETE CASCADE
@@ -81,10 +82,17 @@ CREATE TABLE IF NOT EXISTS LeafData(
   -- This is the data stored in the leaf for example in CT it contains a DER encoded
   -- X.509 certificate but is application dependent
   LeafValue            BLOB NOT NULL,
+  -- If set, the key of the blob in the blob store holding the leaf value, which
+  -- was too large to keep in LeafValue, which is then empty.
+  LeafValueBlob        VARCHAR(64),
   -- This is extra data that the application can associate with the leaf should it wish to.
   -- This data is not included in signing and hashing.
   ExtraData            BLOB,
+  -- The identity of the authenticated client which first queued the leaf, or empty
+  -- if it was not authenticated. This is not included in signing and hashing.
+  Submitter            VARCHAR(255) NOT NULL DEFAULT '',
   PRIMARY KEY(TreeId, LeafIdentityHash),
+  INDEX LeafValueBlobIdx(LeafValueBlob),
   FOREIGN KEY(TreeId) REFERENCES Trees(TreeId) ON DELETE CASCADE
 );
 
@@ -123,7 +131,33 @@ CREATE TABLE IF NOT EXISTS Unsequenced(
   -- we can try to stomp dupe submissions.
   MessageId            BINARY(32) NOT NULL,
   QueueTimestampNanos  BIGINT NOT NULL,
-  PRIMARY KEY (TreeId, LeafIdentityHash, MessageId)
+  -- The index in storage.PriorityLanes of the priority class the leaf was
+  -- queued with. Lower numbered lanes are dequeued first.
+  QueueLane            TINYINT NOT NULL DEFAULT 1,
+  -- The bucket of the log's queue the leaf is in, chosen by its
+  -- LeafIdentityHash. Splitting the queue into buckets spreads out the
+  -- insertion points in the dequeue index, which otherwise all land at
+  -- the end of a single range of it.
+  QueueBucket          INT NOT NULL DEFAULT 0,
+  PRIMARY KEY (TreeId, LeafIdentityHash, MessageId),
+  INDEX QueueBucketIdx(TreeId, QueueBucket, QueueLane, QueueTimestampNanos)
+);
+
+-- Queued leaves which the sequencer failed to integrate, set aside until they're
+-- requeued or discarded by an admin.
+CREATE TABLE IF NOT EXISTS DeadLetter(
+  TreeId                    BIGINT NOT NULL,
+  LeafIdentityHash          VARBINARY(255) NOT NULL,
+  MerkleLeafHash            VARBINARY(255) NOT NULL,
+  QueueTimestampNanos       BIGINT NOT NULL,
+  QueueLane                 TINYINT NOT NULL DEFAULT 1,
+  -- The error which prevented the leaf from being integrated.
+  Error                     TEXT NOT NULL,
+  DeadLetterTimestampNanos  BIGINT NOT NULL,
+  PRIMARY KEY (TreeId, LeafIdentityHash),
+  INDEX DeadLetterTimestampIdx(TreeId, DeadLetterTimestampNanos),
+  FOREIGN KEY(TreeId) REFERENCES Trees(TreeId) ON DELETE CASCADE,
+  FOREIGN KEY(TreeId, LeafIdentityHash) REFERENCES LeafData(TreeId, LeafIdentityHash) ON DELETE CASCADE
 );
 
 
@@ -138,7 +172,11 @@ CREATE TABLE IF NOT EXISTS MapLeaf(
   -- st. more recent revisions come first.
   MapRevision           BIGINT NOT NULL,
   LeafValue             BLOB NOT NULL,
+  -- If set, the key of the blob in the blob store holding the leaf value, as
+  -- for LeafData.
+  LeafValueBlob         VARCHAR(64),
   PRIMARY KEY(TreeId, KeyHash, MapRevision),
+  INDEX LeafValueBlobIdx(LeafValueBlob),
   FOREIGN KEY(TreeId) REFERENCES Trees(TreeId) ON DELETE CASCADE
 );
 
@@ -155,3 +193,31 @@ CREATE TABLE IF NOT EXISTS MapHead(
   FOREIGN KEY(TreeId) REFERENCES Trees(TreeId) ON DELETE CASCADE
 );
 
+-- Map writes queued by SetLeaves when the map server writes asynchronously.
+-- MapRevision is NULL until the map sequencer has applied the mutation.
+CREATE TABLE IF NOT EXISTS MapMutationQueue(
+  MutationId           BIGINT NOT NULL AUTO_INCREMENT,
+  TreeId               BIGINT NOT NULL,
+  QueueTimestampNanos  BIGINT NOT NULL,
+  -- Mutation is a serialized SetMapLeavesRequest.
+  Mutation             MEDIUMBLOB NOT NULL,
+  MapRevision          BIGINT,
+  PRIMARY KEY(MutationId),
+  INDEX TreeRevisionIdx(TreeId, MapRevision, MutationId),
+  FOREIGN KEY(TreeId) REFERENCES Trees(TreeId) ON DELETE CASCADE
+);
+
+
+-- ---------------------------------------------
+-- Storage sharding
+-- ---------------------------------------------
+
+-- When trees are sharded across several databases, this table records the
+-- shard each tree was placed in. It lives only in the directory database,
+-- which needn't hold any trees itself, so TreeId doesn't reference Trees.
+CREATE TABLE IF NOT EXISTS TreeShards(
+  TreeId               BIGINT NOT NULL,
+  ShardName            VARCHAR(255) NOT NULL,
+  PRIMARY KEY(TreeId),
+  INDEX ShardNameIdx(ShardName)
+);
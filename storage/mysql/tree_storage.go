@@ -31,8 +31,8 @@ import (
 // These statements are fixed
 const (
 	insertSubtreeMultiSQL = `INSERT INTO Subtree(TreeId, SubtreeId, Nodes, SubtreeRevision) ` + placeholderSQL
-	insertTreeHeadSQL     = `INSERT INTO TreeHead(TreeId,TreeHeadTimestamp,TreeSize,RootHash,TreeRevision,RootSignature)
-		 VALUES(?,?,?,?,?,?)`
+	insertTreeHeadSQL     = `INSERT INTO TreeHead(TreeId,TreeHeadTimestamp,TreeSize,RootHash,TreeRevision,RootSignature,RootSignatures)
+		 VALUES(?,?,?,?,?,?,?)`
 	selectTreeRevisionAtSizeOrLargerSQL = "SELECT TreeRevision,TreeSize FROM TreeHead WHERE TreeId=? AND TreeSize>=? ORDER BY TreeRevision LIMIT 1"
 	selectActiveLogsSQL                 = "SELECT TreeId from Trees where TreeType='LOG'"
 	selectActiveLogsWithUnsequencedSQL  = "SELECT DISTINCT t.TreeId from Trees t INNER JOIN Unsequenced u WHERE TreeType='LOG' AND t.TreeId=u.TreeId"
@@ -306,13 +306,23 @@ func checkResultOkAndRowCountIs(res sql.Result, err error, count int64) error {
 	}
 
 	if rowsAffected != count {
-		return fmt.Errorf("Expected %d row(s) to be affected but saw: %d", count,
-			rowsAffected)
+		return rowCountError{want: count, got: rowsAffected}
 	}
 
 	return nil
 }
 
+// rowCountError is returned by checkResultOkAndRowCountIs when a statement
+// affected the wrong number of rows, e.g. because a concurrent transaction
+// deleted some of them first.
+type rowCountError struct {
+	want, got int64
+}
+
+func (e rowCountError) Error() string {
+	return fmt.Sprintf("Expected %d row(s) to be affected but saw: %d", e.want, e.got)
+}
+
 // GetTreeRevisionAtSize returns the max node version for a tree at a particular size.
 // It is an error to request tree sizes larger than the currently published tree size.
 // For an inexact tree size this implementation always returns the next largest revision if an
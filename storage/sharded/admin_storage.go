@@ -0,0 +1,222 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sharded
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian"
+	"github.com/google/trillian/storage"
+)
+
+// adminStorage implements storage.AdminStorage by delegating to the
+// AdminStorage of each shard.
+type adminStorage struct {
+	r      *router
+	policy PlacementPolicy
+}
+
+func (s *adminStorage) Snapshot(ctx context.Context) (storage.ReadOnlyAdminTX, error) {
+	return &adminTX{
+		r:      s.r,
+		policy: s.policy,
+		begin: func(sh *Shard) (storage.ReadOnlyAdminTX, error) {
+			return sh.AdminStorage.Snapshot(ctx)
+		},
+		txs: make(map[string]storage.ReadOnlyAdminTX),
+	}, nil
+}
+
+func (s *adminStorage) Begin(ctx context.Context) (storage.AdminTX, error) {
+	return &adminTX{
+		r:      s.r,
+		policy: s.policy,
+		begin: func(sh *Shard) (storage.ReadOnlyAdminTX, error) {
+			return sh.AdminStorage.Begin(ctx)
+		},
+		txs: make(map[string]storage.ReadOnlyAdminTX),
+	}, nil
+}
+
+// adminTX starts a transaction on each shard the first time the shard is
+// needed. Writes only ever touch one shard, but commits of transactions
+// which wrote to several shards aren't atomic.
+type adminTX struct {
+	r      *router
+	policy PlacementPolicy
+	begin  func(*Shard) (storage.ReadOnlyAdminTX, error)
+
+	// mu guards txs and closed.
+	mu     sync.Mutex
+	txs    map[string]storage.ReadOnlyAdminTX
+	closed bool
+}
+
+// txForShard returns the transaction open on sh, starting one if needed.
+func (t *adminTX) txForShard(sh *Shard) (storage.ReadOnlyAdminTX, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil, errors.New("transaction is closed")
+	}
+	if tx, ok := t.txs[sh.Name]; ok {
+		return tx, nil
+	}
+	tx, err := t.begin(sh)
+	if err != nil {
+		return nil, err
+	}
+	t.txs[sh.Name] = tx
+	return tx, nil
+}
+
+// writeTXForShard is txForShard for transactions started with Begin.
+func (t *adminTX) writeTXForShard(sh *Shard) (storage.AdminTX, error) {
+	tx, err := t.txForShard(sh)
+	if err != nil {
+		return nil, err
+	}
+	wtx, ok := tx.(storage.AdminTX)
+	if !ok {
+		return nil, errors.New("write attempted in read-only transaction")
+	}
+	return wtx, nil
+}
+
+// end closes the transaction, then applies fn to each shard's transaction.
+func (t *adminTX) end(fn func(storage.ReadOnlyAdminTX) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	var firstErr error
+	for _, tx := range t.txs {
+		if err := fn(tx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (t *adminTX) Commit() error {
+	return t.end(storage.ReadOnlyAdminTX.Commit)
+}
+
+func (t *adminTX) Rollback() error {
+	return t.end(storage.ReadOnlyAdminTX.Rollback)
+}
+
+func (t *adminTX) IsClosed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.closed
+}
+
+func (t *adminTX) Close() error {
+	if t.IsClosed() {
+		return nil
+	}
+	err := t.Rollback()
+	if err != nil {
+		glog.Warningf("Rollback error on Close(): %v", err)
+	}
+	return err
+}
+
+func (t *adminTX) GetTree(ctx context.Context, treeID int64) (*trillian.Tree, error) {
+	sh, err := t.r.shardForTree(ctx, treeID)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := t.txForShard(sh)
+	if err != nil {
+		return nil, err
+	}
+	return tx.GetTree(ctx, treeID)
+}
+
+func (t *adminTX) ListTreeIDs(ctx context.Context) ([]int64, error) {
+	treeIDs := []int64{}
+	for _, sh := range t.r.shards {
+		tx, err := t.txForShard(sh)
+		if err != nil {
+			return nil, err
+		}
+		ids, err := tx.ListTreeIDs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		treeIDs = append(treeIDs, ids...)
+	}
+	return treeIDs, nil
+}
+
+func (t *adminTX) ListTrees(ctx context.Context) ([]*trillian.Tree, error) {
+	trees := []*trillian.Tree{}
+	for _, sh := range t.r.shards {
+		tx, err := t.txForShard(sh)
+		if err != nil {
+			return nil, err
+		}
+		shardTrees, err := tx.ListTrees(ctx)
+		if err != nil {
+			return nil, err
+		}
+		trees = append(trees, shardTrees...)
+	}
+	return trees, nil
+}
+
+// CreateTree creates tree in the shard chosen by the placement policy, and
+// records it in the directory. The directory is written straight away, so a
+// tree whose creation is rolled back stays placed but is never found.
+func (t *adminTX) CreateTree(ctx context.Context, tree *trillian.Tree) (*trillian.Tree, error) {
+	name, err := t.policy.PlaceTree(ctx, tree, t.r.shardNames())
+	if err != nil {
+		return nil, err
+	}
+	sh, err := t.r.shard(name)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := t.writeTXForShard(sh)
+	if err != nil {
+		return nil, err
+	}
+	newTree, err := tx.CreateTree(ctx, tree)
+	if err != nil {
+		return nil, err
+	}
+	// Tree IDs are random, so may collide with a tree in another shard, in
+	// which case placing the tree fails.
+	if err := t.r.placeTree(ctx, newTree.TreeId, sh); err != nil {
+		return nil, err
+	}
+	return newTree, nil
+}
+
+func (t *adminTX) UpdateTree(ctx context.Context, treeID int64, updateFunc func(*trillian.Tree)) (*trillian.Tree, error) {
+	sh, err := t.r.shardForTree(ctx, treeID)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := t.writeTXForShard(sh)
+	if err != nil {
+		return nil, err
+	}
+	return tx.UpdateTree(ctx, treeID, updateFunc)
+}
@@ -0,0 +1,92 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sharded
+
+import (
+	"context"
+
+	"github.com/google/trillian/storage"
+)
+
+// logStorage implements storage.LogStorage by delegating to the LogStorage
+// of each shard.
+type logStorage struct {
+	r *router
+}
+
+func (s *logStorage) CheckDatabaseAccessible(ctx context.Context) error {
+	return s.r.checkDatabasesAccessible(ctx, func(sh *Shard) storage.DatabaseChecker {
+		return sh.LogStorage
+	})
+}
+
+// Snapshot starts a read-only transaction on every shard, so active logs are
+// listed across all of them.
+func (s *logStorage) Snapshot(ctx context.Context) (storage.ReadOnlyLogTX, error) {
+	tx := &logMetadataTX{}
+	for _, sh := range s.r.shards {
+		shardTX, err := sh.LogStorage.Snapshot(ctx)
+		if err != nil {
+			tx.Close()
+			return nil, err
+		}
+		tx.shardTXs = append(tx.shardTXs, shardTX)
+		tx.txs = append(tx.txs, shardTX)
+	}
+	return tx, nil
+}
+
+func (s *logStorage) SnapshotForTree(ctx context.Context, treeID int64) (storage.ReadOnlyLogTreeTX, error) {
+	sh, err := s.r.shardForTree(ctx, treeID)
+	if err != nil {
+		return nil, err
+	}
+	return sh.LogStorage.SnapshotForTree(ctx, treeID)
+}
+
+func (s *logStorage) BeginForTree(ctx context.Context, treeID int64) (storage.LogTreeTX, error) {
+	sh, err := s.r.shardForTree(ctx, treeID)
+	if err != nil {
+		return nil, err
+	}
+	return sh.LogStorage.BeginForTree(ctx, treeID)
+}
+
+// logMetadataTX aggregates the logs of every shard.
+type logMetadataTX struct {
+	multiTX
+	shardTXs []storage.ReadOnlyLogTX
+}
+
+func (t *logMetadataTX) GetActiveLogIDs() ([]int64, error) {
+	return t.collect(storage.ReadOnlyLogTX.GetActiveLogIDs)
+}
+
+func (t *logMetadataTX) GetActiveLogIDsWithPendingWork() ([]int64, error) {
+	return t.collect(storage.ReadOnlyLogTX.GetActiveLogIDsWithPendingWork)
+}
+
+// collect concatenates the log IDs returned by list for each shard.
+func (t *logMetadataTX) collect(list func(storage.ReadOnlyLogTX) ([]int64, error)) ([]int64, error) {
+	logIDs := []int64{}
+	for _, tx := range t.shardTXs {
+		ids, err := list(tx)
+		if err != nil {
+			return nil, err
+		}
+		logIDs = append(logIDs, ids...)
+	}
+	return logIDs, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sharded
+
+import (
+	"context"
+
+	"github.com/google/trillian/storage"
+)
+
+// mapStorage implements storage.MapStorage by delegating to the MapStorage
+// of each shard.
+type mapStorage struct {
+	r *router
+}
+
+func (s *mapStorage) CheckDatabaseAccessible(ctx context.Context) error {
+	return s.r.checkDatabasesAccessible(ctx, func(sh *Shard) storage.DatabaseChecker {
+		return sh.MapStorage
+	})
+}
+
+func (s *mapStorage) Snapshot(ctx context.Context) (storage.ReadOnlyMapTX, error) {
+	tx := &multiTX{}
+	for _, sh := range s.r.shards {
+		shardTX, err := sh.MapStorage.Snapshot(ctx)
+		if err != nil {
+			tx.Close()
+			return nil, err
+		}
+		tx.txs = append(tx.txs, shardTX)
+	}
+	return tx, nil
+}
+
+func (s *mapStorage) SnapshotForTree(ctx context.Context, treeID int64) (storage.ReadOnlyMapTreeTX, error) {
+	sh, err := s.r.shardForTree(ctx, treeID)
+	if err != nil {
+		return nil, err
+	}
+	return sh.MapStorage.SnapshotForTree(ctx, treeID)
+}
+
+func (s *mapStorage) BeginForTree(ctx context.Context, treeID int64) (storage.MapTreeTX, error) {
+	sh, err := s.r.shardForTree(ctx, treeID)
+	if err != nil {
+		return nil, err
+	}
+	return sh.MapStorage.BeginForTree(ctx, treeID)
+}
@@ -0,0 +1,65 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sharded
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/trillian"
+)
+
+// PlacementPolicy chooses the shard a new tree is created in.
+type PlacementPolicy interface {
+	// PlaceTree returns the name of the shard, out of shards, tree should be
+	// created in.
+	PlaceTree(ctx context.Context, tree *trillian.Tree, shards []string) (string, error)
+}
+
+// RoundRobinPlacement places new trees in each shard in turn.
+type RoundRobinPlacement struct {
+	mu   sync.Mutex
+	next int
+}
+
+// PlaceTree implements PlacementPolicy.
+func (p *RoundRobinPlacement) PlaceTree(ctx context.Context, tree *trillian.Tree, shards []string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	shard := shards[p.next%len(shards)]
+	p.next = (p.next + 1) % len(shards)
+	return shard, nil
+}
+
+// FewestTreesPlacement places new trees in the shard holding the fewest trees
+// according to Directory, preferring earlier shards on ties.
+type FewestTreesPlacement struct {
+	Directory Directory
+}
+
+// PlaceTree implements PlacementPolicy.
+func (p FewestTreesPlacement) PlaceTree(ctx context.Context, tree *trillian.Tree, shards []string) (string, error) {
+	counts, err := p.Directory.TreeCounts(ctx)
+	if err != nil {
+		return "", err
+	}
+	best := shards[0]
+	for _, s := range shards[1:] {
+		if counts[s] < counts[best] {
+			best = s
+		}
+	}
+	return best, nil
+}
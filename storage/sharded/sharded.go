@@ -0,0 +1,224 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sharded spreads trees across several storage backends, or shards.
+// Each tree lives entirely within one shard, recorded in a Directory when the
+// tree is created; per-tree operations are delegated to that shard, and
+// operations spanning all trees are aggregated across every shard.
+package sharded
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/trillian/storage"
+)
+
+// Shard is one of the storage backends trees are spread across.
+type Shard struct {
+	// Name identifies the shard in the Directory. It must not change once
+	// trees have been placed in the shard.
+	Name         string
+	AdminStorage storage.AdminStorage
+	LogStorage   storage.LogStorage
+	MapStorage   storage.MapStorage
+}
+
+// Directory records which shard each tree is stored in.
+type Directory interface {
+	// ShardForTree returns the name of the shard treeID was placed in, or an
+	// error if it hasn't been placed.
+	ShardForTree(ctx context.Context, treeID int64) (string, error)
+
+	// PlaceTree records that treeID is stored in the named shard. It returns
+	// an error if treeID has already been placed.
+	PlaceTree(ctx context.Context, treeID int64, shard string) error
+
+	// TreeCounts returns the number of trees placed in each shard. Shards
+	// without trees may be omitted.
+	TreeCounts(ctx context.Context) (map[string]int64, error)
+}
+
+// Storage spreads trees across shards. The storage implementations it
+// returns share a cache of the directory, so should be used together.
+type Storage struct {
+	r      *router
+	policy PlacementPolicy
+}
+
+// New returns a Storage routing trees to shards using dir, with new trees
+// placed in shards by policy.
+func New(dir Directory, shards []*Shard, policy PlacementPolicy) (*Storage, error) {
+	r, err := newRouter(dir, shards)
+	if err != nil {
+		return nil, err
+	}
+	return &Storage{r: r, policy: policy}, nil
+}
+
+// AdminStorage returns a storage.AdminStorage implementation which places
+// new trees in shards and aggregates tree listings across them.
+func (s *Storage) AdminStorage() storage.AdminStorage {
+	return &adminStorage{r: s.r, policy: s.policy}
+}
+
+// LogStorage returns a storage.LogStorage implementation which routes each
+// log to its shard.
+func (s *Storage) LogStorage() storage.LogStorage {
+	return &logStorage{r: s.r}
+}
+
+// MapStorage returns a storage.MapStorage implementation which routes each
+// map to its shard.
+func (s *Storage) MapStorage() storage.MapStorage {
+	return &mapStorage{r: s.r}
+}
+
+// router maps tree IDs to shards. Placements never change, so they're
+// cached once read from the directory.
+type router struct {
+	dir    Directory
+	shards []*Shard
+	byName map[string]*Shard
+
+	mu    sync.RWMutex
+	cache map[int64]*Shard
+}
+
+func newRouter(dir Directory, shards []*Shard) (*router, error) {
+	if len(shards) == 0 {
+		return nil, errors.New("no shards given")
+	}
+	byName := make(map[string]*Shard)
+	for _, s := range shards {
+		if _, ok := byName[s.Name]; ok {
+			return nil, fmt.Errorf("duplicate shard name: %q", s.Name)
+		}
+		byName[s.Name] = s
+	}
+	return &router{
+		dir:    dir,
+		shards: shards,
+		byName: byName,
+		cache:  make(map[int64]*Shard),
+	}, nil
+}
+
+// shardNames returns the names of all shards, in the order they were given.
+func (r *router) shardNames() []string {
+	names := make([]string, 0, len(r.shards))
+	for _, s := range r.shards {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+// shard returns the named shard.
+func (r *router) shard(name string) (*Shard, error) {
+	s, ok := r.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown shard: %q", name)
+	}
+	return s, nil
+}
+
+// shardForTree returns the shard treeID is stored in.
+func (r *router) shardForTree(ctx context.Context, treeID int64) (*Shard, error) {
+	r.mu.RLock()
+	s, ok := r.cache[treeID]
+	r.mu.RUnlock()
+	if ok {
+		return s, nil
+	}
+
+	name, err := r.dir.ShardForTree(ctx, treeID)
+	if err != nil {
+		return nil, err
+	}
+	if s, err = r.shard(name); err != nil {
+		return nil, fmt.Errorf("tree %v: %v", treeID, err)
+	}
+
+	r.mu.Lock()
+	r.cache[treeID] = s
+	r.mu.Unlock()
+	return s, nil
+}
+
+// placeTree records treeID as stored in s.
+func (r *router) placeTree(ctx context.Context, treeID int64, s *Shard) error {
+	if err := r.dir.PlaceTree(ctx, treeID, s.Name); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.cache[treeID] = s
+	r.mu.Unlock()
+	return nil
+}
+
+// checkDatabasesAccessible checks every shard using check, returning the
+// first error found.
+func (r *router) checkDatabasesAccessible(ctx context.Context, check func(*Shard) storage.DatabaseChecker) error {
+	for _, s := range r.shards {
+		if err := check(s).CheckDatabaseAccessible(ctx); err != nil {
+			return fmt.Errorf("shard %q: %v", s.Name, err)
+		}
+	}
+	return nil
+}
+
+// closer is the part of every transaction type needed to end it.
+type closer interface {
+	Commit() error
+	Rollback() error
+	Close() error
+}
+
+// multiTX ends transactions open on several shards together. Since each
+// shard commits separately, a commit isn't atomic across shards.
+type multiTX struct {
+	txs []closer
+}
+
+func (m *multiTX) Commit() error {
+	var firstErr error
+	for _, tx := range m.txs {
+		if err := tx.Commit(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (m *multiTX) Rollback() error {
+	var firstErr error
+	for _, tx := range m.txs {
+		if err := tx.Rollback(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (m *multiTX) Close() error {
+	var firstErr error
+	for _, tx := range m.txs {
+		if err := tx.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,291 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sharded
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/trillian"
+	"github.com/google/trillian/storage"
+	stestonly "github.com/google/trillian/storage/testonly"
+)
+
+// fakeDirectory is an in-memory Directory.
+type fakeDirectory struct {
+	mu     sync.Mutex
+	shards map[int64]string
+	// lookups counts calls to ShardForTree.
+	lookups int
+}
+
+func newFakeDirectory() *fakeDirectory {
+	return &fakeDirectory{shards: make(map[int64]string)}
+}
+
+func (d *fakeDirectory) ShardForTree(ctx context.Context, treeID int64) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lookups++
+	shard, ok := d.shards[treeID]
+	if !ok {
+		return "", fmt.Errorf("tree %v not placed", treeID)
+	}
+	return shard, nil
+}
+
+func (d *fakeDirectory) PlaceTree(ctx context.Context, treeID int64, shard string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.shards[treeID]; ok {
+		return fmt.Errorf("tree %v already placed", treeID)
+	}
+	d.shards[treeID] = shard
+	return nil
+}
+
+func (d *fakeDirectory) TreeCounts(ctx context.Context) (map[string]int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	counts := make(map[string]int64)
+	for _, shard := range d.shards {
+		counts[shard]++
+	}
+	return counts, nil
+}
+
+func TestNew(t *testing.T) {
+	dir := newFakeDirectory()
+	tests := []struct {
+		shards  []*Shard
+		wantErr bool
+	}{
+		{shards: nil, wantErr: true},
+		{shards: []*Shard{{Name: "a"}, {Name: "b"}}},
+		{shards: []*Shard{{Name: "a"}, {Name: "a"}}, wantErr: true},
+	}
+	for i, test := range tests {
+		_, err := New(dir, test.shards, &RoundRobinPlacement{})
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("%v: New() = (_, %v), wantErr = %v", i, err, test.wantErr)
+		}
+	}
+}
+
+func TestCreateTree(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	dir := newFakeDirectory()
+	shards := []*Shard{
+		{Name: "a", AdminStorage: storage.NewMockAdminStorage(ctrl)},
+		{Name: "b", AdminStorage: storage.NewMockAdminStorage(ctrl)},
+	}
+	s, err := New(dir, shards, &RoundRobinPlacement{})
+	if err != nil {
+		t.Fatalf("New() = (_, %v), want = (_, nil)", err)
+	}
+
+	for i, sh := range shards {
+		treeID := int64(i + 1)
+		tree := *stestonly.LogTree
+		tree.TreeId = treeID
+		mockTX := storage.NewMockAdminTX(ctrl)
+		sh.AdminStorage.(*storage.MockAdminStorage).EXPECT().Begin(gomock.Any()).Return(mockTX, nil)
+		mockTX.EXPECT().CreateTree(gomock.Any(), stestonly.LogTree).Return(&tree, nil)
+		mockTX.EXPECT().GetTree(gomock.Any(), treeID).Return(&tree, nil)
+		mockTX.EXPECT().Commit().Return(nil)
+
+		tx, err := s.AdminStorage().Begin(ctx)
+		if err != nil {
+			t.Fatalf("%v: Begin() = (_, %v), want = (_, nil)", i, err)
+		}
+		created, err := tx.CreateTree(ctx, stestonly.LogTree)
+		if err != nil {
+			t.Fatalf("%v: CreateTree() = (_, %v), want = (_, nil)", i, err)
+		}
+		if got, want := dir.shards[created.TreeId], sh.Name; got != want {
+			t.Errorf("%v: tree placed in shard %q, want %q", i, got, want)
+		}
+		// The placement is cached, so the directory isn't consulted.
+		if _, err := tx.GetTree(ctx, treeID); err != nil {
+			t.Errorf("%v: GetTree() = (_, %v), want = (_, nil)", i, err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Errorf("%v: Commit() = %v, want = nil", i, err)
+		}
+	}
+	if dir.lookups != 0 {
+		t.Errorf("directory looked up %v times, want 0", dir.lookups)
+	}
+}
+
+func TestListTreeIDs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	var shards []*Shard
+	for i, name := range []string{"a", "b"} {
+		mockAdmin := storage.NewMockAdminStorage(ctrl)
+		mockTX := storage.NewMockReadOnlyAdminTX(ctrl)
+		mockAdmin.EXPECT().Snapshot(gomock.Any()).Return(mockTX, nil)
+		mockTX.EXPECT().ListTreeIDs(gomock.Any()).Return([]int64{int64(i*10 + 1), int64(i*10 + 2)}, nil)
+		mockTX.EXPECT().Commit().Return(nil)
+		shards = append(shards, &Shard{Name: name, AdminStorage: mockAdmin})
+	}
+	s, err := New(newFakeDirectory(), shards, &RoundRobinPlacement{})
+	if err != nil {
+		t.Fatalf("New() = (_, %v), want = (_, nil)", err)
+	}
+
+	tx, err := s.AdminStorage().Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("Snapshot() = (_, %v), want = (_, nil)", err)
+	}
+	ids, err := tx.ListTreeIDs(ctx)
+	if err != nil {
+		t.Fatalf("ListTreeIDs() = (_, %v), want = (_, nil)", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Errorf("Commit() = %v, want = nil", err)
+	}
+	if want := []int64{1, 2, 11, 12}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ListTreeIDs() = %v, want = %v", ids, want)
+	}
+}
+
+func TestLogStorageRoutesTrees(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	dir := newFakeDirectory()
+	dir.PlaceTree(ctx, 1, "a")
+	dir.PlaceTree(ctx, 2, "b")
+	mockA := storage.NewMockLogStorage(ctrl)
+	mockB := storage.NewMockLogStorage(ctrl)
+	shards := []*Shard{{Name: "a", LogStorage: mockA}, {Name: "b", LogStorage: mockB}}
+	s, err := New(dir, shards, &RoundRobinPlacement{})
+	if err != nil {
+		t.Fatalf("New() = (_, %v), want = (_, nil)", err)
+	}
+	ls := s.LogStorage()
+
+	txA := storage.NewMockLogTreeTX(ctrl)
+	txB := storage.NewMockReadOnlyLogTreeTX(ctrl)
+	mockA.EXPECT().BeginForTree(gomock.Any(), int64(1)).Times(2).Return(txA, nil)
+	mockB.EXPECT().SnapshotForTree(gomock.Any(), int64(2)).Return(txB, nil)
+
+	for i := 0; i < 2; i++ {
+		if tx, err := ls.BeginForTree(ctx, 1); err != nil || tx != txA {
+			t.Errorf("BeginForTree(1) = (%v, %v), want = (%v, nil)", tx, err, txA)
+		}
+	}
+	if tx, err := ls.SnapshotForTree(ctx, 2); err != nil || tx != txB {
+		t.Errorf("SnapshotForTree(2) = (%v, %v), want = (%v, nil)", tx, err, txB)
+	}
+	if _, err := ls.BeginForTree(ctx, 3); err == nil {
+		t.Error("BeginForTree(3) = (_, nil), want error for unplaced tree")
+	}
+	// Tree 1's placement was cached after the first lookup.
+	if got, want := dir.lookups, 3; got != want {
+		t.Errorf("directory looked up %v times, want %v", got, want)
+	}
+}
+
+func TestGetActiveLogIDs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	var shards []*Shard
+	for i, name := range []string{"a", "b", "c"} {
+		mockLog := storage.NewMockLogStorage(ctrl)
+		mockTX := storage.NewMockReadOnlyLogTX(ctrl)
+		mockLog.EXPECT().Snapshot(gomock.Any()).Return(mockTX, nil)
+		mockTX.EXPECT().GetActiveLogIDs().Return([]int64{int64(i + 1)}, nil)
+		mockTX.EXPECT().GetActiveLogIDsWithPendingWork().Return([]int64{int64(i + 100)}, nil)
+		mockTX.EXPECT().Commit().Return(nil)
+		shards = append(shards, &Shard{Name: name, LogStorage: mockLog})
+	}
+	s, err := New(newFakeDirectory(), shards, &RoundRobinPlacement{})
+	if err != nil {
+		t.Fatalf("New() = (_, %v), want = (_, nil)", err)
+	}
+
+	tx, err := s.LogStorage().Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("Snapshot() = (_, %v), want = (_, nil)", err)
+	}
+	active, err := tx.GetActiveLogIDs()
+	if err != nil {
+		t.Fatalf("GetActiveLogIDs() = (_, %v), want = (_, nil)", err)
+	}
+	pending, err := tx.GetActiveLogIDsWithPendingWork()
+	if err != nil {
+		t.Fatalf("GetActiveLogIDsWithPendingWork() = (_, %v), want = (_, nil)", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Errorf("Commit() = %v, want = nil", err)
+	}
+	if want := []int64{1, 2, 3}; !reflect.DeepEqual(active, want) {
+		t.Errorf("GetActiveLogIDs() = %v, want = %v", active, want)
+	}
+	if want := []int64{100, 101, 102}; !reflect.DeepEqual(pending, want) {
+		t.Errorf("GetActiveLogIDsWithPendingWork() = %v, want = %v", pending, want)
+	}
+}
+
+func TestPlacementPolicies(t *testing.T) {
+	ctx := context.Background()
+	shards := []string{"a", "b", "c"}
+
+	rr := &RoundRobinPlacement{}
+	var got []string
+	for i := 0; i < 4; i++ {
+		shard, err := rr.PlaceTree(ctx, &trillian.Tree{}, shards)
+		if err != nil {
+			t.Fatalf("RoundRobinPlacement.PlaceTree() = (_, %v), want = (_, nil)", err)
+		}
+		got = append(got, shard)
+	}
+	if want := []string{"a", "b", "c", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RoundRobinPlacement placed trees in %v, want %v", got, want)
+	}
+
+	dir := newFakeDirectory()
+	dir.PlaceTree(ctx, 1, "a")
+	dir.PlaceTree(ctx, 2, "a")
+	dir.PlaceTree(ctx, 3, "c")
+	fewest := FewestTreesPlacement{Directory: dir}
+	got = nil
+	for i := int64(4); i < 8; i++ {
+		shard, err := fewest.PlaceTree(ctx, &trillian.Tree{}, shards)
+		if err != nil {
+			t.Fatalf("FewestTreesPlacement.PlaceTree() = (_, %v), want = (_, nil)", err)
+		}
+		dir.PlaceTree(ctx, i, shard)
+		got = append(got, shard)
+	}
+	if want := []string{"b", "b", "c", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FewestTreesPlacement placed trees in %v, want %v", got, want)
+	}
+}
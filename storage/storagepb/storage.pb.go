@@ -11,12 +11,14 @@ It is generated from these files:
 It has these top-level messages:
 	NodeIDProto
 	SubtreeProto
+	LogRootSignatures
 */
 package storagepb
 
 import proto "github.com/golang/protobuf/proto"
 import fmt "fmt"
 import math "math"
+import trillian "github.com/google/trillian"
 
 // Reference imports to suppress errors if they are not otherwise used.
 var _ = proto.Marshal
@@ -121,33 +123,56 @@ func (m *SubtreeProto) GetInternalNodeCount() uint32 {
 	return 0
 }
 
+// LogRootSignatures holds the signatures of a log root by multiple keys, for
+// storage alongside the root.
+type LogRootSignatures struct {
+	Signatures []*trillian.LogRootSignature `protobuf:"bytes,1,rep,name=signatures" json:"signatures,omitempty"`
+}
+
+func (m *LogRootSignatures) Reset()                    { *m = LogRootSignatures{} }
+func (m *LogRootSignatures) String() string            { return proto.CompactTextString(m) }
+func (*LogRootSignatures) ProtoMessage()               {}
+func (*LogRootSignatures) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+func (m *LogRootSignatures) GetSignatures() []*trillian.LogRootSignature {
+	if m != nil {
+		return m.Signatures
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*NodeIDProto)(nil), "storagepb.NodeIDProto")
 	proto.RegisterType((*SubtreeProto)(nil), "storagepb.SubtreeProto")
+	proto.RegisterType((*LogRootSignatures)(nil), "storagepb.LogRootSignatures")
 }
 
 func init() { proto.RegisterFile("storage.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 308 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x91, 0x4d, 0x4b, 0xc3, 0x40,
-	0x10, 0x86, 0x49, 0xd3, 0x06, 0x3b, 0x6d, 0xfc, 0x58, 0x45, 0x96, 0x7a, 0x09, 0x15, 0x24, 0x78,
-	0xc8, 0x41, 0x2f, 0x7e, 0x5c, 0xc4, 0x0f, 0xb0, 0x50, 0x44, 0xd7, 0x1f, 0x10, 0x36, 0x66, 0x34,
-	0xc1, 0xb0, 0x1b, 0x76, 0x37, 0xc5, 0xfe, 0x11, 0x7f, 0xaf, 0x64, 0xb3, 0x48, 0x44, 0x3c, 0x78,
-	0x9b, 0x77, 0xe6, 0x9d, 0x27, 0x93, 0x77, 0x21, 0xd4, 0x46, 0x2a, 0xfe, 0x86, 0x49, 0xad, 0xa4,
-	0x91, 0x64, 0xec, 0x64, 0x9d, 0xcd, 0x17, 0x30, 0x79, 0x90, 0x39, 0x2e, 0x6e, 0x1f, 0xed, 0x84,
-	0xc0, 0xb0, 0xe6, 0xa6, 0xa0, 0x5e, 0xe4, 0xc5, 0x53, 0x66, 0x6b, 0x72, 0x04, 0x5b, 0xb5, 0xc2,
-	0xd7, 0xf2, 0x23, 0xad, 0x50, 0xa4, 0x59, 0x69, 0x34, 0x1d, 0x44, 0x5e, 0x3c, 0x62, 0x61, 0xd7,
-	0x5e, 0xa2, 0xb8, 0x2e, 0x8d, 0x9e, 0x7f, 0xfa, 0x30, 0x7d, 0x6e, 0x32, 0xa3, 0x10, 0x3b, 0xd8,
-	0x3e, 0x04, 0x9d, 0xc3, 0xe1, 0x9c, 0x22, 0x7b, 0x30, 0xca, 0xb1, 0x36, 0x85, 0xc3, 0x74, 0x82,
-	0x1c, 0xc0, 0x58, 0x49, 0x69, 0xd2, 0x82, 0xeb, 0x82, 0xfa, 0x76, 0x61, 0xa3, 0x6d, 0xdc, 0x73,
-	0x5d, 0x90, 0x4b, 0x08, 0x2a, 0xe4, 0x2b, 0xd4, 0x74, 0x18, 0xf9, 0xf1, 0xe4, 0xe4, 0x30, 0xf9,
-	0xfe, 0x85, 0xa4, 0xff, 0xcd, 0x64, 0x69, 0x5d, 0x77, 0xc2, 0xa8, 0x35, 0x73, 0x2b, 0xe4, 0x09,
-	0x36, 0x4b, 0x61, 0x50, 0x09, 0x5e, 0xa5, 0x42, 0xe6, 0xa8, 0xe9, 0xc8, 0x42, 0x8e, 0xff, 0x82,
-	0x2c, 0x9c, 0xbb, 0x4d, 0xc6, 0xb1, 0xc2, 0xb2, 0xdf, 0x23, 0x09, 0xec, 0xfe, 0x40, 0xa6, 0x2f,
-	0xb2, 0x11, 0x86, 0x06, 0x91, 0x17, 0x87, 0x6c, 0xa7, 0xef, 0xbd, 0x69, 0x07, 0xb3, 0x73, 0x98,
-	0xf4, 0x2e, 0x23, 0xdb, 0xe0, 0xbf, 0xe3, 0xda, 0xc6, 0x32, 0x66, 0x6d, 0xd9, 0x66, 0xb2, 0xe2,
-	0x55, 0x83, 0x36, 0x93, 0x29, 0xeb, 0xc4, 0xc5, 0xe0, 0xcc, 0x9b, 0x5d, 0x01, 0xf9, 0x7d, 0xcf,
-	0x7f, 0x08, 0x59, 0x60, 0x5f, 0xfd, 0xf4, 0x2b, 0x00, 0x00, 0xff, 0xff, 0xf4, 0xae, 0x38, 0x20,
-	0x06, 0x02, 0x00, 0x00,
+	// 370 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x92, 0x5f, 0x6b, 0xdb, 0x30,
+	0x14, 0xc5, 0x71, 0x9c, 0x98, 0xe5, 0x26, 0xde, 0x16, 0x6d, 0x0c, 0xe3, 0xbd, 0x98, 0x0c, 0x86,
+	0xb7, 0x07, 0x07, 0xb6, 0x97, 0x2d, 0x7b, 0x19, 0xfb, 0x03, 0x0b, 0x84, 0xb5, 0x55, 0x3e, 0x80,
+	0x91, 0x93, 0x5b, 0x5b, 0xd4, 0x95, 0x8c, 0x24, 0x87, 0xe6, 0x8b, 0xf4, 0xf3, 0x16, 0xcb, 0xc6,
+	0xb8, 0x2d, 0x7d, 0xe8, 0xdb, 0x3d, 0xd7, 0xe7, 0xfc, 0x2c, 0x1d, 0x04, 0xbe, 0x36, 0x52, 0xb1,
+	0x1c, 0x93, 0x4a, 0x49, 0x23, 0xc9, 0xb4, 0x93, 0x55, 0x16, 0x7e, 0xca, 0xb9, 0x29, 0xea, 0x2c,
+	0xd9, 0xcb, 0xeb, 0x55, 0x2e, 0x65, 0x5e, 0xe2, 0xca, 0x28, 0x5e, 0x96, 0x9c, 0x89, 0x7e, 0x68,
+	0x53, 0xcb, 0x0d, 0xcc, 0xfe, 0xcb, 0x03, 0x6e, 0xfe, 0x9c, 0x5b, 0x08, 0x81, 0x71, 0xc5, 0x4c,
+	0x11, 0x38, 0x91, 0x13, 0xcf, 0xa9, 0x9d, 0xc9, 0x47, 0x78, 0x55, 0x29, 0xbc, 0xe4, 0x37, 0x69,
+	0x89, 0x22, 0xcd, 0xb8, 0xd1, 0xc1, 0x28, 0x72, 0xe2, 0x09, 0xf5, 0xdb, 0xf5, 0x16, 0xc5, 0x2f,
+	0x6e, 0xf4, 0xf2, 0xd6, 0x85, 0xf9, 0xae, 0xce, 0x8c, 0x42, 0x6c, 0x61, 0xef, 0xc0, 0x6b, 0x1d,
+	0x1d, 0xae, 0x53, 0xe4, 0x2d, 0x4c, 0x0e, 0x58, 0x99, 0xa2, 0xc3, 0xb4, 0x82, 0xbc, 0x87, 0xa9,
+	0x92, 0xd2, 0xa4, 0x05, 0xd3, 0x45, 0xe0, 0xda, 0xc0, 0x8b, 0x66, 0xf1, 0x8f, 0xe9, 0x82, 0xfc,
+	0x00, 0xaf, 0x44, 0x76, 0x44, 0x1d, 0x8c, 0x23, 0x37, 0x9e, 0x7d, 0xf9, 0x90, 0xf4, 0xb7, 0x4d,
+	0x86, 0xff, 0x4c, 0xb6, 0xd6, 0xf5, 0x57, 0x18, 0x75, 0xa2, 0x5d, 0x84, 0x5c, 0xc0, 0x4b, 0x2e,
+	0x0c, 0x2a, 0xc1, 0xca, 0x54, 0xc8, 0x03, 0xea, 0x60, 0x62, 0x21, 0x9f, 0x9f, 0x82, 0x6c, 0x3a,
+	0x77, 0xd3, 0x4c, 0xc7, 0xf2, 0xf9, 0x70, 0x47, 0x12, 0x78, 0x73, 0x0f, 0x99, 0xee, 0x65, 0x2d,
+	0x4c, 0xe0, 0x45, 0x4e, 0xec, 0xd3, 0xc5, 0xd0, 0xfb, 0xbb, 0xf9, 0x10, 0x7e, 0x87, 0xd9, 0xe0,
+	0x64, 0xe4, 0x35, 0xb8, 0x57, 0x78, 0xb2, 0xb5, 0x4c, 0x69, 0x33, 0x36, 0x9d, 0x1c, 0x59, 0x59,
+	0xa3, 0xed, 0x64, 0x4e, 0x5b, 0xb1, 0x1e, 0x7d, 0x73, 0xc2, 0x9f, 0x40, 0x1e, 0x9f, 0xe7, 0x39,
+	0x84, 0xe5, 0x19, 0x2c, 0xb6, 0x32, 0xa7, 0x52, 0x9a, 0x1d, 0xcf, 0x05, 0x33, 0xb5, 0x42, 0x4d,
+	0xd6, 0x00, 0xba, 0x57, 0x81, 0x63, 0x0b, 0x09, 0x93, 0xfe, 0x75, 0x3c, 0x0c, 0xd0, 0x81, 0x3b,
+	0xf3, 0xec, 0xdb, 0xf9, 0x7a, 0x17, 0x00, 0x00, 0xff, 0xff, 0x87, 0x8c, 0x62, 0xf2, 0x82, 0x02,
+	0x00, 0x00,
 }
@@ -0,0 +1,457 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testonly
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto"
+	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/log"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/monitoring/metric"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/cache"
+	ttestonly "github.com/google/trillian/testonly"
+	"github.com/google/trillian/util"
+)
+
+// proofMaxBitLen is the maximum depth of the log trees used by the benchmarks.
+const proofMaxBitLen = 64
+
+var (
+	// DefaultBenchmarkTreeSizes are the log sizes sequencing and proofs are
+	// benchmarked at if StorageBenchmarker.TreeSizes is not set.
+	DefaultBenchmarkTreeSizes = []int64{1000, 10000}
+	// DefaultBenchmarkBatchSizes are the batch sizes queueing, sequencing and
+	// map writes are benchmarked with if StorageBenchmarker.BatchSizes is not
+	// set.
+	DefaultBenchmarkBatchSizes = []int{1, 10, 100}
+)
+
+// BenchmarkResult is the outcome of a single storage benchmark. It's written
+// as a line of JSON by StorageBenchmarker.Run, so that runs against different
+// implementations or schemas can be compared.
+type BenchmarkResult struct {
+	Name       string `json:"name"`
+	Iterations int    `json:"iterations"`
+	NsPerOp    int64  `json:"ns_per_op"`
+	// Metrics holds other measurements made by the benchmark, averaged per
+	// operation (with names ending in _per_op) or per second of benchmark
+	// time (ending in _per_sec).
+	Metrics map[string]float64 `json:"metrics,omitempty"`
+}
+
+// StorageBenchmarker runs a suite of benchmarks against LogStorage and
+// MapStorage implementations.
+type StorageBenchmarker struct {
+	// NewAdminStorage returns an AdminStorage instance, used to create the
+	// trees which are benchmarked.
+	NewAdminStorage func() storage.AdminStorage
+	// NewLogStorage returns the LogStorage to benchmark, or is nil to skip
+	// the log benchmarks.
+	NewLogStorage func() storage.LogStorage
+	// NewMapStorage returns the MapStorage to benchmark, or is nil to skip
+	// the map benchmarks.
+	NewMapStorage func() storage.MapStorage
+	// TreeSizes are the log sizes to benchmark sequencing and proofs at.
+	TreeSizes []int64
+	// BatchSizes are the batch sizes to benchmark queueing, sequencing and
+	// map writes with.
+	BatchSizes []int
+}
+
+// storageBenchmark is a benchmark which returns any measurements it makes, as
+// totals over all b.N operations. It calls resetTimer, rather than
+// b.ResetTimer, once it has finished any setup.
+type storageBenchmark struct {
+	name string
+	run  func(b *testing.B, resetTimer func()) (map[string]float64, error)
+}
+
+// RunAllBenchmarks runs all of the benchmarks as sub-benchmarks of b.
+func (sb *StorageBenchmarker) RunAllBenchmarks(b *testing.B) {
+	for _, bm := range sb.benchmarks() {
+		bm := bm
+		b.Run(bm.name, func(b *testing.B) {
+			metrics, err := sb.measure(b, bm)
+			if err != nil {
+				b.Fatal(err)
+			}
+			keys := make([]string, 0, len(metrics))
+			for k := range metrics {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				b.Logf("%s_per_op: %.2f", k, metrics[k]/float64(b.N))
+			}
+		})
+	}
+}
+
+// Run runs all of the benchmarks, writes each result to w as a line of JSON,
+// and returns the results.
+func (sb *StorageBenchmarker) Run(w io.Writer) ([]BenchmarkResult, error) {
+	var results []BenchmarkResult
+	enc := json.NewEncoder(w)
+	for _, bm := range sb.benchmarks() {
+		var metrics map[string]float64
+		var err error
+		r := testing.Benchmark(func(b *testing.B) {
+			metrics, err = sb.measure(b, bm)
+		})
+		if err != nil {
+			return results, fmt.Errorf("%s: %v", bm.name, err)
+		}
+		result := BenchmarkResult{
+			Name:       bm.name,
+			Iterations: r.N,
+			NsPerOp:    r.NsPerOp(),
+			Metrics:    make(map[string]float64),
+		}
+		for k, v := range metrics {
+			perOp := v / float64(r.N)
+			result.Metrics[k+"_per_op"] = perOp
+			if r.NsPerOp() > 0 {
+				result.Metrics[k+"_per_sec"] = perOp * 1e9 / float64(r.NsPerOp())
+			}
+		}
+		if err := enc.Encode(result); err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// measure runs bm, and adds the changes in the subtree cache metrics while it
+// was timed to its measurements.
+func (sb *StorageBenchmarker) measure(b *testing.B, bm storageBenchmark) (map[string]float64, error) {
+	cacheMetrics := map[string]string{
+		"node_lookups":     cache.NodeLookupsMetric,
+		"subtree_reads":    cache.SubtreeReadsMetric,
+		"subtrees_flushed": cache.SubtreesFlushedMetric,
+	}
+	before := make(map[string]int64)
+	metrics, err := bm.run(b, func() {
+		for k, name := range cacheMetrics {
+			before[k] = metric.Value(name)
+		}
+		b.ResetTimer()
+	})
+	if err != nil {
+		return nil, err
+	}
+	if metrics == nil {
+		metrics = make(map[string]float64)
+	}
+	for k, name := range cacheMetrics {
+		metrics[k] = float64(metric.Value(name) - before[k])
+	}
+	return metrics, nil
+}
+
+func (sb *StorageBenchmarker) benchmarks() []storageBenchmark {
+	treeSizes, batchSizes := sb.TreeSizes, sb.BatchSizes
+	if len(treeSizes) == 0 {
+		treeSizes = DefaultBenchmarkTreeSizes
+	}
+	if len(batchSizes) == 0 {
+		batchSizes = DefaultBenchmarkBatchSizes
+	}
+
+	var bms []storageBenchmark
+	if sb.NewLogStorage != nil {
+		for _, batch := range batchSizes {
+			bms = append(bms, storageBenchmark{fmt.Sprintf("QueueLeaves/batch=%d", batch), sb.queueLeaves(batch)})
+		}
+		for _, size := range treeSizes {
+			for _, batch := range batchSizes {
+				bms = append(bms, storageBenchmark{fmt.Sprintf("SequenceBatch/size=%d/batch=%d", size, batch), sb.sequenceBatch(size, batch)})
+			}
+		}
+		for _, size := range treeSizes {
+			bms = append(bms, storageBenchmark{fmt.Sprintf("InclusionProof/size=%d", size), sb.inclusionProof(size)})
+		}
+	}
+	if sb.NewMapStorage != nil {
+		for _, batch := range batchSizes {
+			bms = append(bms, storageBenchmark{fmt.Sprintf("MapSetLeaves/batch=%d", batch), sb.mapSetLeaves(batch)})
+		}
+	}
+	return bms
+}
+
+func (sb *StorageBenchmarker) createTree(tree *trillian.Tree) (int64, error) {
+	ctx := context.Background()
+	tx, err := sb.NewAdminStorage().Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Close()
+	created, err := tx.CreateTree(ctx, tree)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return created.TreeId, nil
+}
+
+// benchmarkLeaves returns n distinct leaves.
+func benchmarkLeaves(n int) []*trillian.LogLeaf {
+	nonce := rand.Int63()
+	leaves := make([]*trillian.LogLeaf, 0, n)
+	for i := 0; i < n; i++ {
+		data := []byte(fmt.Sprintf("benchmark leaf %x-%d", nonce, i))
+		idHash := sha256.Sum256(data)
+		leaves = append(leaves, &trillian.LogLeaf{
+			LeafIdentityHash: idHash[:],
+			MerkleLeafHash:   ttestonly.Hasher.HashLeaf(data),
+			LeafValue:        data,
+		})
+	}
+	return leaves
+}
+
+func (sb *StorageBenchmarker) queue(logID int64, leaves []*trillian.LogLeaf) error {
+	tx, err := sb.NewLogStorage().BeginForTree(context.Background(), logID)
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+	if _, err := tx.QueueLeaves(leaves, time.Now()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func newBenchmarkSequencer(ls storage.LogStorage) (*log.Sequencer, error) {
+	key, err := keys.NewFromPrivatePEM(ttestonly.DemoPrivateKey, ttestonly.DemoPrivateKeyPass)
+	if err != nil {
+		return nil, err
+	}
+	return log.NewSequencer(ttestonly.Hasher, util.SystemTimeSource{}, ls, crypto.NewSigner(key)), nil
+}
+
+// growLog queues and sequences leaves until the log has at least size leaves.
+func (sb *StorageBenchmarker) growLog(seq *log.Sequencer, logID int64, from, size int64) error {
+	const batch = 1000
+	for from < size {
+		n := size - from
+		if n > batch {
+			n = batch
+		}
+		if err := sb.queue(logID, benchmarkLeaves(int(n))); err != nil {
+			return err
+		}
+		done, err := seq.SequenceBatch(context.Background(), logID, int(n))
+		if err != nil {
+			return err
+		}
+		from += int64(done)
+	}
+	return nil
+}
+
+// queueLeaves measures queueing batches of leaves, each in its own
+// transaction.
+func (sb *StorageBenchmarker) queueLeaves(batch int) func(b *testing.B, resetTimer func()) (map[string]float64, error) {
+	return func(b *testing.B, resetTimer func()) (map[string]float64, error) {
+		logID, err := sb.createTree(LogTree)
+		if err != nil {
+			return nil, err
+		}
+		resetTimer()
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			leaves := benchmarkLeaves(batch)
+			b.StartTimer()
+			if err := sb.queue(logID, leaves); err != nil {
+				return nil, err
+			}
+		}
+		return map[string]float64{"leaves": float64(b.N * batch)}, nil
+	}
+}
+
+// sequenceBatch measures sequencing batches of leaves into a log which starts
+// with size leaves. Queueing the leaves isn't timed.
+func (sb *StorageBenchmarker) sequenceBatch(size int64, batch int) func(b *testing.B, resetTimer func()) (map[string]float64, error) {
+	return func(b *testing.B, resetTimer func()) (map[string]float64, error) {
+		ls := sb.NewLogStorage()
+		seq, err := newBenchmarkSequencer(ls)
+		if err != nil {
+			return nil, err
+		}
+		logID, err := sb.createTree(LogTree)
+		if err != nil {
+			return nil, err
+		}
+		if err := sb.growLog(seq, logID, 0, size); err != nil {
+			return nil, err
+		}
+		resetTimer()
+		sequenced := 0
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			if err := sb.queue(logID, benchmarkLeaves(batch)); err != nil {
+				return nil, err
+			}
+			b.StartTimer()
+			n, err := seq.SequenceBatch(context.Background(), logID, batch)
+			if err != nil {
+				return nil, err
+			}
+			sequenced += n
+		}
+		return map[string]float64{"leaves": float64(sequenced)}, nil
+	}
+}
+
+// inclusionProof measures reading the nodes for an inclusion proof of a random
+// leaf in a log of size leaves.
+func (sb *StorageBenchmarker) inclusionProof(size int64) func(b *testing.B, resetTimer func()) (map[string]float64, error) {
+	return func(b *testing.B, resetTimer func()) (map[string]float64, error) {
+		ls := sb.NewLogStorage()
+		seq, err := newBenchmarkSequencer(ls)
+		if err != nil {
+			return nil, err
+		}
+		logID, err := sb.createTree(LogTree)
+		if err != nil {
+			return nil, err
+		}
+		if err := sb.growLog(seq, logID, 0, size); err != nil {
+			return nil, err
+		}
+		resetTimer()
+		nodes := 0
+		for i := 0; i < b.N; i++ {
+			n, err := readInclusionProof(ls, logID, rand.Int63n(size))
+			if err != nil {
+				return nil, err
+			}
+			nodes += n
+		}
+		return map[string]float64{"proof_nodes": float64(nodes)}, nil
+	}
+}
+
+// readInclusionProof reads the nodes of an inclusion proof for index at the
+// latest size of the log, and returns how many there were.
+func readInclusionProof(ls storage.LogStorage, logID, index int64) (int, error) {
+	tx, err := ls.SnapshotForTree(context.Background(), logID)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Close()
+	root, err := tx.LatestSignedLogRoot()
+	if err != nil {
+		return 0, err
+	}
+	fetches, err := merkle.CalcInclusionProofNodeAddresses(root.TreeSize, index, root.TreeSize, proofMaxBitLen)
+	if err != nil {
+		return 0, err
+	}
+	ids := make([]storage.NodeID, 0, len(fetches))
+	for _, f := range fetches {
+		ids = append(ids, f.NodeID)
+	}
+	nodes, err := tx.GetMerkleNodes(tx.ReadRevision(), ids)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(nodes), nil
+}
+
+// mapSetLeaves measures writing batches of random leaves to a map, each as a
+// new revision.
+func (sb *StorageBenchmarker) mapSetLeaves(batch int) func(b *testing.B, resetTimer func()) (map[string]float64, error) {
+	return func(b *testing.B, resetTimer func()) (map[string]float64, error) {
+		mapID, err := sb.createTree(MapTree)
+		if err != nil {
+			return nil, err
+		}
+		ms := sb.NewMapStorage()
+		hasher := merkle.NewMapHasher(ttestonly.Hasher)
+		resetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := writeMapBatch(ms, hasher, mapID, batch); err != nil {
+				return nil, err
+			}
+		}
+		return map[string]float64{"leaves": float64(b.N * batch)}, nil
+	}
+}
+
+func writeMapBatch(ms storage.MapStorage, hasher merkle.MapHasher, mapID int64, batch int) error {
+	ctx := context.Background()
+	tx, err := ms.BeginForTree(ctx, mapID)
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+	w, err := merkle.NewSparseMerkleTreeWriter(tx.WriteRevision(), hasher, func() (storage.TreeTX, error) {
+		return ms.BeginForTree(ctx, mapID)
+	})
+	if err != nil {
+		return err
+	}
+	kvs := make([]merkle.HashKeyValue, 0, batch)
+	for i := 0; i < batch; i++ {
+		value := []byte(fmt.Sprintf("benchmark value %d", rand.Int63()))
+		leaf := trillian.MapLeaf{
+			Index:     ttestonly.HashKey(fmt.Sprintf("benchmark key %d", rand.Int63())),
+			LeafHash:  hasher.HashLeaf(value),
+			LeafValue: value,
+		}
+		if err := tx.Set(leaf.Index, leaf); err != nil {
+			return err
+		}
+		kvs = append(kvs, merkle.HashKeyValue{HashedKey: leaf.Index, HashedValue: leaf.LeafHash})
+	}
+	if err := w.SetLeaves(kvs); err != nil {
+		return err
+	}
+	rootHash, err := w.CalculateRoot()
+	if err != nil {
+		return err
+	}
+	// The root isn't signed, as only its revision matters here.
+	if err := tx.StoreSignedMapRoot(trillian.SignedMapRoot{
+		MapId:          mapID,
+		MapRevision:    tx.WriteRevision(),
+		RootHash:       rootHash,
+		TimestampNanos: time.Now().UnixNano(),
+	}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
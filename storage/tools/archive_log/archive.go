@@ -0,0 +1,152 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/storage/archive"
+	"github.com/google/trillian/storage/storagepb"
+)
+
+// logSource reads a log from primary storage, and deletes it once archived.
+type logSource interface {
+	// tree returns the log's tree.
+	tree(ctx context.Context) (*trillian.Tree, error)
+	// root returns the log's latest root.
+	root(ctx context.Context) (trillian.SignedLogRoot, error)
+	// leaves returns the sequenced leaves in [start, end), in order.
+	leaves(ctx context.Context, start, end int64) ([]*trillian.LogLeaf, error)
+	// latestSubtrees calls fn with the latest revision of each subtree.
+	latestSubtrees(fn func(*storagepb.SubtreeProto) error) error
+	// deleteLog deletes the log's leaves, subtrees and roots.
+	deleteLog() error
+}
+
+// run archives the log and deletes it from primary storage.
+func run(ctx context.Context, src logSource, opts *archiveOpts) error {
+	tree, err := src.tree(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read tree %d: %v", opts.treeID, err)
+	}
+	if tree.TreeType != trillian.TreeType_LOG || tree.TreeState != trillian.TreeState_FROZEN {
+		return fmt.Errorf("tree %d is a %v %v, only FROZEN logs can be archived", opts.treeID, tree.TreeState, tree.TreeType)
+	}
+	root, err := src.root(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read root of log %d: %v", opts.treeID, err)
+	}
+
+	logs := archive.NewLogStorage(opts.archiveDir)
+	defer logs.Close()
+	archived, err := logs.IsArchived(opts.treeID)
+	if err != nil {
+		return err
+	}
+	if archived {
+		glog.Infof("Log %d is already archived, verifying the archive", opts.treeID)
+	} else if err := writeArchive(ctx, src, archive.LogPath(opts.archiveDir, opts.treeID), root, opts.batchSize); err != nil {
+		return fmt.Errorf("failed to archive log %d: %v", opts.treeID, err)
+	}
+
+	if err := verifyArchive(ctx, logs, opts.treeID, root); err != nil {
+		return fmt.Errorf("archive of log %d is invalid, nothing was deleted: %v", opts.treeID, err)
+	}
+	if err := src.deleteLog(); err != nil {
+		return fmt.Errorf("log %d was archived but deleting it failed: %v", opts.treeID, err)
+	}
+	fmt.Printf("Archived log %d with %d leaves\n", opts.treeID, root.TreeSize)
+	return nil
+}
+
+// writeArchive copies the log, up to root, from src to an archive file at
+// path.
+func writeArchive(ctx context.Context, src logSource, path string, root trillian.SignedLogRoot, batchSize int64) error {
+	w, err := archive.NewWriter(path)
+	if err != nil {
+		return err
+	}
+	for start := int64(0); start < root.TreeSize; start += batchSize {
+		end := start + batchSize
+		if end > root.TreeSize {
+			end = root.TreeSize
+		}
+		leaves, err := src.leaves(ctx, start, end)
+		if err != nil {
+			w.Abort()
+			return err
+		}
+		for _, leaf := range leaves {
+			if err := w.AddLeaf(leaf); err != nil {
+				w.Abort()
+				return err
+			}
+		}
+	}
+	if err := src.latestSubtrees(w.AddSubtree); err != nil {
+		w.Abort()
+		return err
+	}
+	if err := w.SetRoot(root); err != nil {
+		w.Abort()
+		return err
+	}
+	return w.Close()
+}
+
+// verifyArchive checks the archived log has the expected root, and that its
+// leaves hash to it.
+func verifyArchive(ctx context.Context, logs *archive.LogStorage, treeID int64, want trillian.SignedLogRoot) error {
+	tx, err := logs.SnapshotForTree(ctx, treeID)
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+
+	root, err := tx.LatestSignedLogRoot()
+	if err != nil {
+		return err
+	}
+	if !proto.Equal(&root, &want) {
+		return fmt.Errorf("archived root %v, want %v", root, want)
+	}
+
+	// TODO: read the hash strategy from the tree once storage supports more than one.
+	hasher, err := merkle.Factory(merkle.RFC6962SHA256Type)
+	if err != nil {
+		return err
+	}
+	cmt := merkle.NewCompactMerkleTree(hasher)
+	noop := func(int, int64, []byte) error { return nil }
+	for i := int64(0); i < root.TreeSize; i++ {
+		leaves, err := tx.GetLeavesByIndex([]int64{i})
+		if err != nil {
+			return err
+		}
+		if _, err := cmt.AddLeafHash(leaves[0].MerkleLeafHash, noop); err != nil {
+			return err
+		}
+	}
+	if got := cmt.CurrentRoot(); root.TreeSize > 0 && !bytes.Equal(got, root.RootHash) {
+		return fmt.Errorf("archived leaves have root hash %x, want %x", got, root.RootHash)
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,163 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/storage/archive"
+	"github.com/google/trillian/storage/storagepb"
+)
+
+// fakeLog is an in-memory logSource.
+type fakeLog struct {
+	state    trillian.TreeState
+	leafs    []*trillian.LogLeaf
+	logRoot  trillian.SignedLogRoot
+	subtrees []*storagepb.SubtreeProto
+	deleted  bool
+}
+
+// newFakeLog creates a fakeLog of size leaves, whose root hash matches them.
+func newFakeLog(state trillian.TreeState, size int64) *fakeLog {
+	hasher, err := merkle.Factory(merkle.RFC6962SHA256Type)
+	if err != nil {
+		panic(err)
+	}
+	f := &fakeLog{state: state}
+	cmt := merkle.NewCompactMerkleTree(hasher)
+	for i := int64(0); i < size; i++ {
+		value := []byte(fmt.Sprintf("leaf %d", i))
+		leaf := &trillian.LogLeaf{LeafIndex: i, LeafValue: value, MerkleLeafHash: hasher.HashLeaf(value)}
+		cmt.AddLeafHash(leaf.MerkleLeafHash, func(int, int64, []byte) error { return nil })
+		f.leafs = append(f.leafs, leaf)
+	}
+	f.logRoot = trillian.SignedLogRoot{TreeSize: size, RootHash: cmt.CurrentRoot(), TreeRevision: 1}
+	f.subtrees = []*storagepb.SubtreeProto{{Prefix: []byte{}, Depth: 8}}
+	return f
+}
+
+func (f *fakeLog) tree(ctx context.Context) (*trillian.Tree, error) {
+	return &trillian.Tree{TreeType: trillian.TreeType_LOG, TreeState: f.state}, nil
+}
+
+func (f *fakeLog) root(ctx context.Context) (trillian.SignedLogRoot, error) {
+	return f.logRoot, nil
+}
+
+func (f *fakeLog) leaves(ctx context.Context, start, end int64) ([]*trillian.LogLeaf, error) {
+	return f.leafs[start:end], nil
+}
+
+func (f *fakeLog) latestSubtrees(fn func(*storagepb.SubtreeProto) error) error {
+	for _, st := range f.subtrees {
+		if err := fn(st); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeLog) deleteLog() error {
+	f.deleted = true
+	return nil
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "archive_log")
+	if err != nil {
+		t.Fatalf("TempDir() = (_, %v), want = (_, nil)", err)
+	}
+	return dir
+}
+
+func TestRunArchivesFrozenLog(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	ctx := context.Background()
+	opts := &archiveOpts{archiveDir: dir, treeID: 1, confirmTreeID: 1, batchSize: 3}
+
+	f := newFakeLog(trillian.TreeState_FROZEN, 10)
+	if err := run(ctx, f, opts); err != nil {
+		t.Fatalf("run() = %v, want = nil", err)
+	}
+	if !f.deleted {
+		t.Error("run() didn't delete the log from primary storage")
+	}
+
+	logs := archive.NewLogStorage(dir)
+	defer logs.Close()
+	tx, err := logs.SnapshotForTree(ctx, opts.treeID)
+	if err != nil {
+		t.Fatalf("SnapshotForTree() = (_, %v), want = (_, nil)", err)
+	}
+	if count, err := tx.GetSequencedLeafCount(); err != nil || count != 10 {
+		t.Errorf("GetSequencedLeafCount() = (%v, %v), want = (10, nil)", count, err)
+	}
+
+	// Rerunning reuses the archive.
+	f.deleted = false
+	if err := run(ctx, f, opts); err != nil || !f.deleted {
+		t.Errorf("run() again = %v with deleted = %v, want = nil with deleted = true", err, f.deleted)
+	}
+}
+
+func TestRunRefusesToDelete(t *testing.T) {
+	ctx := context.Background()
+	corrupt := newFakeLog(trillian.TreeState_FROZEN, 10)
+	corrupt.leafs[4].MerkleLeafHash = corrupt.leafs[3].MerkleLeafHash
+
+	for _, test := range []struct {
+		desc string
+		log  *fakeLog
+	}{
+		{desc: "active", log: newFakeLog(trillian.TreeState_ACTIVE, 10)},
+		{desc: "corrupt", log: corrupt},
+	} {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		opts := &archiveOpts{archiveDir: dir, treeID: 1, confirmTreeID: 1, batchSize: 100}
+		if err := run(ctx, test.log, opts); err == nil {
+			t.Errorf("%v: run() = nil, want error", test.desc)
+		}
+		if test.log.deleted {
+			t.Errorf("%v: run() deleted the log from primary storage", test.desc)
+		}
+	}
+}
+
+func TestValidateOpts(t *testing.T) {
+	for _, test := range []struct {
+		desc    string
+		opts    archiveOpts
+		wantErr bool
+	}{
+		{desc: "valid", opts: archiveOpts{archiveDir: "/a", treeID: 1, confirmTreeID: 1, batchSize: 1}},
+		{desc: "no dir", opts: archiveOpts{treeID: 1, confirmTreeID: 1, batchSize: 1}, wantErr: true},
+		{desc: "no tree", opts: archiveOpts{archiveDir: "/a", batchSize: 1}, wantErr: true},
+		{desc: "unconfirmed", opts: archiveOpts{archiveDir: "/a", treeID: 1, confirmTreeID: 2, batchSize: 1}, wantErr: true},
+		{desc: "no batch", opts: archiveOpts{archiveDir: "/a", treeID: 1, confirmTreeID: 1}, wantErr: true},
+	} {
+		if err := validateOpts(&test.opts); (err != nil) != test.wantErr {
+			t.Errorf("%v: validateOpts() = %v, wantErr = %v", test.desc, err, test.wantErr)
+		}
+	}
+}
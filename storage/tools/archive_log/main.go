@@ -0,0 +1,102 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package main contains the archive_log command, which migrates a FROZEN log
+// from MySQL to the cold archive tier, then deletes its leaves, subtrees and
+// roots from MySQL. The tree itself stays in MySQL, so the log keeps being
+// served, with reads coming from the archive:
+// $ ./archive_log --mysql_uri=... --archive_dir=... --treeid=1234 --confirm_treeid=1234
+//
+// The archive is verified against the log's latest root before anything is
+// deleted. If the command fails after writing the archive it can be rerun, and
+// will reuse the archive. Servers must have the same --archive_dir set.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+
+	_ "github.com/go-sql-driver/mysql" // Load MySQL driver
+
+	"github.com/golang/glog"
+	"github.com/google/trillian/storage/blob"
+	"github.com/google/trillian/storage/mysql"
+)
+
+var (
+	mySQLURI          = flag.String("mysql_uri", "test:zaphod@tcp(127.0.0.1:3306)/test", "Connection URI for MySQL database")
+	archiveDirFlag    = flag.String("archive_dir", "", "Directory of the archive tier to migrate the log to")
+	treeIDFlag        = flag.Int64("treeid", 0, "The ID of the log to archive")
+	confirmTreeIDFlag = flag.Int64("confirm_treeid", 0, "Must be set to the value of --treeid, as the log's rows are deleted from MySQL")
+	leafBatchSize     = flag.Int64("leaf_batch_size", 1000, "Number of leaves to read from MySQL at a time")
+	blobDirFlag       = flag.String("blob_dir", "", "Directory of the blob store holding the log's offloaded leaf values, if any, which are copied into the archive")
+)
+
+// archiveOpts contains all user-supplied options required to run the program.
+type archiveOpts struct {
+	archiveDir            string
+	treeID, confirmTreeID int64
+	batchSize             int64
+}
+
+func validateOpts(opts *archiveOpts) error {
+	if opts.archiveDir == "" {
+		return errors.New("--archive_dir must be set")
+	}
+	if opts.treeID <= 0 {
+		return errors.New("--treeid must be set")
+	}
+	if opts.confirmTreeID != opts.treeID {
+		return errors.New("archiving deletes the log from MySQL, set --confirm_treeid to the value of --treeid to allow it")
+	}
+	if opts.batchSize <= 0 {
+		return errors.New("--leaf_batch_size must be positive")
+	}
+	return nil
+}
+
+func main() {
+	flag.Parse()
+
+	opts := &archiveOpts{
+		archiveDir:    *archiveDirFlag,
+		treeID:        *treeIDFlag,
+		confirmTreeID: *confirmTreeIDFlag,
+		batchSize:     *leafBatchSize,
+	}
+	if err := validateOpts(opts); err != nil {
+		glog.Exit(err)
+	}
+
+	db, err := mysql.OpenDB(*mySQLURI)
+	if err != nil {
+		glog.Exitf("Failed to open MySQL database: %v", err)
+	}
+	defer db.Close()
+
+	var blobs *blob.Offloader
+	if len(*blobDirFlag) > 0 {
+		blobs = &blob.Offloader{Store: blob.NewFileStore(*blobDirFlag)}
+	}
+	l := &mySQLLog{
+		db:     db,
+		treeID: opts.treeID,
+		admin:  mysql.NewAdminStorage(db),
+		logs:   mysql.NewLogStorageWithBlobs(db, 1, blobs),
+	}
+	if err := run(context.Background(), l, opts); err != nil {
+		glog.Exit(err)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/storagepb"
+)
+
+// These statements read and delete the MySQL storage tables directly, as the
+// storage API doesn't list subtrees or delete logs.
+const (
+	selectLatestSubtreesSQL = `SELECT s.Nodes FROM Subtree s
+			INNER JOIN (
+				SELECT SubtreeId, MAX(SubtreeRevision) AS MaxRevision
+				FROM Subtree WHERE TreeId=? GROUP BY SubtreeId
+			) AS x
+			ON s.SubtreeId=x.SubtreeId AND s.SubtreeRevision=x.MaxRevision
+			WHERE s.TreeId=?`
+)
+
+// deleteLogSQL deletes all of a log's rows but its tree, in an order which
+// respects foreign keys.
+var deleteLogSQL = []string{
+	"DELETE FROM Unsequenced WHERE TreeId=?",
+	"DELETE FROM DeadLetter WHERE TreeId=?",
+	"DELETE FROM SequencedLeafData WHERE TreeId=?",
+	"DELETE FROM LeafData WHERE TreeId=?",
+	"DELETE FROM Subtree WHERE TreeId=?",
+	"DELETE FROM TreeHead WHERE TreeId=?",
+}
+
+// mySQLLog implements logSource for a log stored in MySQL.
+type mySQLLog struct {
+	db     *sql.DB
+	treeID int64
+	admin  storage.AdminStorage
+	logs   storage.LogStorage
+}
+
+func (m *mySQLLog) tree(ctx context.Context) (*trillian.Tree, error) {
+	tx, err := m.admin.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+	tree, err := tx.GetTree(ctx, m.treeID)
+	if err != nil {
+		return nil, err
+	}
+	return tree, tx.Commit()
+}
+
+func (m *mySQLLog) root(ctx context.Context) (trillian.SignedLogRoot, error) {
+	tx, err := m.logs.SnapshotForTree(ctx, m.treeID)
+	if err != nil {
+		return trillian.SignedLogRoot{}, err
+	}
+	defer tx.Close()
+	root, err := tx.LatestSignedLogRoot()
+	if err != nil {
+		return trillian.SignedLogRoot{}, err
+	}
+	return root, tx.Commit()
+}
+
+func (m *mySQLLog) leaves(ctx context.Context, start, end int64) ([]*trillian.LogLeaf, error) {
+	tx, err := m.logs.SnapshotForTree(ctx, m.treeID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+	indexes := make([]int64, 0, end-start)
+	for i := start; i < end; i++ {
+		indexes = append(indexes, i)
+	}
+	leaves, err := tx.GetLeavesByIndex(indexes)
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(byLeafIndex(leaves))
+	return leaves, tx.Commit()
+}
+
+func (m *mySQLLog) latestSubtrees(fn func(*storagepb.SubtreeProto) error) error {
+	rows, err := m.db.Query(selectLatestSubtreesSQL, m.treeID, m.treeID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var nodes []byte
+		if err := rows.Scan(&nodes); err != nil {
+			return err
+		}
+		var st storagepb.SubtreeProto
+		if err := proto.Unmarshal(nodes, &st); err != nil {
+			return fmt.Errorf("failed to unmarshal subtree: %v", err)
+		}
+		if st.Prefix == nil {
+			st.Prefix = []byte{}
+		}
+		if err := fn(&st); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (m *mySQLLog) deleteLog() error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, stmt := range deleteLogSQL {
+		if _, err := tx.Exec(stmt, m.treeID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// byLeafIndex sorts leaves by sequence number.
+type byLeafIndex []*trillian.LogLeaf
+
+func (b byLeafIndex) Len() int           { return len(b) }
+func (b byLeafIndex) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byLeafIndex) Less(i, j int) bool { return b[i].LeafIndex < b[j].LeafIndex }
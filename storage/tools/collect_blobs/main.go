@@ -0,0 +1,126 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package main contains the collect_blobs command, which deletes blobs holding
+// offloaded leaf values that are no longer referenced by any log or map, e.g.
+// because the leaf was a rejected duplicate or its log was archived:
+// $ ./collect_blobs --mysql_uri=... --blob_dir=...
+//
+// If trees are sharded, every shard must be listed in --mysql_shards, as for
+// the servers, or blobs referenced from the missing shards are deleted too.
+// It's safe to run while servers are writing, as long as no transaction lasts
+// longer than --grace_period.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql" // Load MySQL driver
+
+	"github.com/golang/glog"
+	"github.com/google/trillian/storage/blob"
+	"github.com/google/trillian/storage/mysql"
+)
+
+var (
+	mySQLURI        = flag.String("mysql_uri", "test:zaphod@tcp(127.0.0.1:3306)/test", "Connection URI for MySQL database")
+	mySQLShardsFlag = flag.String("mysql_shards", "", "If set, the comma-separated list of name=uri MySQL databases trees are sharded across, as for the servers")
+	blobDirFlag     = flag.String("blob_dir", "", "Directory of the blob store to collect")
+	gracePeriodFlag = flag.Duration("grace_period", 24*time.Hour, "How long unreferenced blobs are kept for, which must be longer than any transaction")
+)
+
+// collectOpts contains all user-supplied options required to run the program.
+type collectOpts struct {
+	blobDir     string
+	gracePeriod time.Duration
+}
+
+func validateOpts(opts *collectOpts) error {
+	if opts.blobDir == "" {
+		return errors.New("--blob_dir must be set")
+	}
+	if opts.gracePeriod <= 0 {
+		return errors.New("--grace_period must be positive")
+	}
+	return nil
+}
+
+// openDBs opens the database at uri and those of the shards in spec.
+func openDBs(uri, spec string) ([]*sql.DB, error) {
+	uris := []string{uri}
+	if len(spec) > 0 {
+		for _, pair := range strings.Split(spec, ",") {
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) != 2 || len(kv[0]) == 0 {
+				return nil, fmt.Errorf("invalid shard %q: want name=uri", pair)
+			}
+			uris = append(uris, kv[1])
+		}
+	}
+	var dbs []*sql.DB
+	for _, u := range uris {
+		db, err := mysql.OpenDB(u)
+		if err != nil {
+			return nil, err
+		}
+		dbs = append(dbs, db)
+	}
+	return dbs, nil
+}
+
+func main() {
+	flag.Parse()
+
+	opts := &collectOpts{
+		blobDir:     *blobDirFlag,
+		gracePeriod: *gracePeriodFlag,
+	}
+	if err := validateOpts(opts); err != nil {
+		glog.Exit(err)
+	}
+
+	dbs, err := openDBs(*mySQLURI, *mySQLShardsFlag)
+	if err != nil {
+		glog.Exitf("Failed to open MySQL database: %v", err)
+	}
+	for _, db := range dbs {
+		defer db.Close()
+	}
+
+	refs := func(ctx context.Context) (map[string]bool, error) {
+		all := make(map[string]bool)
+		for _, db := range dbs {
+			refs, err := mysql.ReferencedBlobs(ctx, db)
+			if err != nil {
+				return nil, err
+			}
+			for key := range refs {
+				all[key] = true
+			}
+		}
+		return all, nil
+	}
+	cutoff := time.Now().Add(-opts.gracePeriod)
+	n, err := blob.Collect(context.Background(), blob.NewFileStore(opts.blobDir), refs, cutoff)
+	if err != nil {
+		glog.Exitf("Failed to collect blobs: %v", err)
+	}
+	glog.Infof("Deleted %d unreferenced blobs", n)
+}
@@ -0,0 +1,327 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/cache"
+	"github.com/google/trillian/storage/storagepb"
+)
+
+// logTreeDepth must match the value used by the log sequencer when it creates node IDs.
+const logTreeDepth = 64
+
+// logStrata and mapStrata must match the strata used by MySQL storage.
+var (
+	logStrata = []int{8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8}
+	mapStrata = []int{8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 176}
+)
+
+// treeHead is the root of a tree at a revision.
+type treeHead struct {
+	Revision int64
+	// Size is only set for logs.
+	Size     int64
+	RootHash []byte
+}
+
+// storedSubtree is a subtree as read from storage.
+type storedSubtree struct {
+	// Revision is the revision the subtree was written at, which may be earlier
+	// than the inspected revision.
+	Revision int64
+	Subtree  *storagepb.SubtreeProto
+}
+
+// subtreeReader reads the subtrees of a tree as they were at a revision.
+type subtreeReader interface {
+	// subtrees returns the most recent version at or before rev of every subtree
+	// whose ID starts with prefix, ordered by ID.
+	subtrees(rev int64, prefix []byte) ([]storedSubtree, error)
+	// subtree returns the most recent version at or before rev of the subtree with
+	// the given ID, or nil if there isn't one.
+	subtree(rev int64, id []byte) (*storedSubtree, error)
+}
+
+// treeLayout describes how the nodes of a tree are stored in subtrees.
+type treeLayout struct {
+	treeType trillian.TreeType
+	// pathBits is the length of node ID paths.
+	pathBits int
+	strata   []int
+	populate storage.PopulateSubtreeFunc
+	prepare  storage.PrepareSubtreeWriteFunc
+}
+
+// newTreeLayout returns the layout used by storage for trees of the given type.
+func newTreeLayout(treeType trillian.TreeType, hasher merkle.TreeHasher) (*treeLayout, error) {
+	switch treeType {
+	case trillian.TreeType_LOG:
+		return &treeLayout{
+			treeType: treeType,
+			pathBits: logTreeDepth,
+			strata:   logStrata,
+			populate: cache.PopulateLogSubtreeNodes(hasher),
+			prepare:  cache.PrepareLogSubtreeWrite(),
+		}, nil
+	case trillian.TreeType_MAP:
+		return &treeLayout{
+			treeType: treeType,
+			pathBits: hasher.Size() * 8,
+			strata:   mapStrata,
+			populate: cache.PopulateMapSubtreeNodes(hasher),
+			prepare:  cache.PrepareMapSubtreeWrite(),
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported tree type %v", treeType)
+}
+
+// subtreePrefixLen returns the length in bytes of the ID of the subtree which
+// holds a node with the given prefix length.
+func (l *treeLayout) subtreePrefixLen(prefixLenBits int) int {
+	base := 0
+	for _, depth := range l.strata {
+		if prefixLenBits <= base+depth {
+			break
+		}
+		base += depth
+	}
+	return base / 8
+}
+
+// nodeIDFromSuffix rebuilds the NodeID of a node from its subtree prefix and the
+// serialized suffix used as a key inside the subtree.
+func (l *treeLayout) nodeIDFromSuffix(prefix []byte, key string) (storage.NodeID, error) {
+	sfx, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return storage.NodeID{}, err
+	}
+	if len(sfx) < 2 || len(prefix)+len(sfx)-1 > l.pathBits/8 {
+		return storage.NodeID{}, fmt.Errorf("invalid suffix %x for prefix %x", sfx, prefix)
+	}
+	id := storage.NewEmptyNodeID(l.pathBits)
+	copy(id.Path, prefix)
+	copy(id.Path[len(prefix):], sfx[1:])
+	id.PrefixLenBits = len(prefix)*8 + int(sfx[0])
+	return id, nil
+}
+
+// nodeLabel returns a human readable description of a node ID.
+func (l *treeLayout) nodeLabel(id storage.NodeID) string {
+	if l.treeType == trillian.TreeType_LOG {
+		return id.CoordString()
+	}
+	return fmt.Sprintf("%x/%d", id.Path[:(id.PrefixLenBits+7)/8], id.PrefixLenBits)
+}
+
+// inspector examines the subtrees of a tree at a single revision.
+type inspector struct {
+	layout *treeLayout
+	reader subtreeReader
+	head   treeHead
+}
+
+// rootCheck is the result of repopulating a subtree and checking its root hash.
+type rootCheck struct {
+	// Populated is the repopulated copy of the subtree, nil if repopulation failed.
+	Populated *storagepb.SubtreeProto
+	// Problem is set if the subtree couldn't be repopulated or its root hash
+	// doesn't match.
+	Problem bool
+	Status  string
+}
+
+// checkSubtree repopulates the internal nodes of st and compares the resulting
+// root hash with the hash of the same node stored in the parent subtree, or with
+// the tree root for the top subtree of a map.
+func (i *inspector) checkSubtree(st *storagepb.SubtreeProto) (*rootCheck, error) {
+	populated := proto.Clone(st).(*storagepb.SubtreeProto)
+	if err := i.layout.populate(populated); err != nil {
+		return &rootCheck{Problem: true, Status: fmt.Sprintf("failed to repopulate: %v", err)}, nil
+	}
+	ret := &rootCheck{Populated: populated}
+
+	// The hash of a partial log subtree isn't kept up to date in its parent, the
+	// sequencer writes the right hand edge of the tree at whichever depths the tree
+	// size requires.
+	if i.layout.treeType == trillian.TreeType_LOG && len(st.Leaves) < 1<<uint(st.Depth) {
+		ret.Status = fmt.Sprintf("partial subtree with root %x, not checked", populated.RootHash)
+		return ret, nil
+	}
+
+	var want []byte
+	var from string
+	if len(st.Prefix) == 0 {
+		if i.layout.treeType != trillian.TreeType_MAP {
+			ret.Status = fmt.Sprintf("top subtree with root %x, not checked", populated.RootHash)
+			return ret, nil
+		}
+		want, from = i.head.RootHash, fmt.Sprintf("tree root at revision %d", i.head.Revision)
+	} else {
+		parentLen := i.layout.subtreePrefixLen(len(st.Prefix) * 8)
+		parent, err := i.reader.subtree(i.head.Revision, st.Prefix[:parentLen])
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil {
+			ret.Problem = true
+			ret.Status = fmt.Sprintf("parent subtree %x is missing", st.Prefix[:parentLen])
+			return ret, nil
+		}
+		key := base64.StdEncoding.EncodeToString(append([]byte{byte(8 * (len(st.Prefix) - parentLen))}, st.Prefix[parentLen:]...))
+		want, from = parent.Subtree.Leaves[key], fmt.Sprintf("parent subtree %x", st.Prefix[:parentLen])
+		if want == nil {
+			ret.Problem = true
+			ret.Status = fmt.Sprintf("missing from %s", from)
+			return ret, nil
+		}
+	}
+	if !bytes.Equal(populated.RootHash, want) {
+		ret.Problem = true
+		ret.Status = fmt.Sprintf("mismatch, computed %x but %s has %x", populated.RootHash, from, want)
+		return ret, nil
+	}
+	ret.Status = fmt.Sprintf("%x matches %s", want, from)
+	return ret, nil
+}
+
+// listSubtrees writes a description of every subtree whose ID starts with prefix
+// to w, including all of their nodes if showNodes is set. It returns the number
+// of subtrees whose root hash didn't check out.
+func (i *inspector) listSubtrees(w io.Writer, prefix []byte, showNodes bool) (int, error) {
+	sts, err := i.reader.subtrees(i.head.Revision, prefix)
+	if err != nil {
+		return 0, err
+	}
+	bad := 0
+	for _, s := range sts {
+		st := s.Subtree
+		fmt.Fprintf(w, "Subtree %x (revision %d, depth %d): %d leaves, %d internal nodes stored, internal node count %d\n",
+			st.Prefix, s.Revision, st.Depth, len(st.Leaves), len(st.InternalNodes), st.InternalNodeCount)
+		check, err := i.checkSubtree(st)
+		if err != nil {
+			return bad, err
+		}
+		if check.Problem {
+			bad++
+			fmt.Fprintf(w, "  root: ERROR: %s\n", check.Status)
+		} else {
+			fmt.Fprintf(w, "  root: %s\n", check.Status)
+		}
+		if !showNodes {
+			continue
+		}
+		i.printNodes(w, "leaf", st.Prefix, st.Leaves)
+		if check.Populated != nil {
+			i.printNodes(w, "internal", st.Prefix, check.Populated.InternalNodes)
+		}
+	}
+	return bad, nil
+}
+
+// printNodes writes the nodes in a subtree map to w, ordered by suffix.
+func (i *inspector) printNodes(w io.Writer, kind string, prefix []byte, nodes map[string][]byte) {
+	keys := make([]string, 0, len(nodes))
+	for k := range nodes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		// Map internal nodes are keyed by their position inside the subtree rather
+		// than by their path, so they can't be turned back into node IDs.
+		label := "suffix " + k
+		if kind == "leaf" || i.layout.treeType == trillian.TreeType_LOG {
+			id, err := i.layout.nodeIDFromSuffix(prefix, k)
+			if err != nil {
+				label = fmt.Sprintf("suffix %s (%v)", k, err)
+			} else {
+				label = i.layout.nodeLabel(id)
+			}
+		}
+		fmt.Fprintf(w, "  %-8s %-24s %x\n", kind, label, nodes[k])
+	}
+}
+
+// logPath returns the IDs of the nodes from a log leaf up to the root.
+func (i *inspector) logPath(leafIndex int64) ([]storage.NodeID, error) {
+	if leafIndex < 0 || leafIndex >= i.head.Size {
+		return nil, fmt.Errorf("leaf index %d is outside the tree of size %d at revision %d", leafIndex, i.head.Size, i.head.Revision)
+	}
+	var ids []storage.NodeID
+	for depth := 0; depth == 0 || i.head.Size-1 >= int64(1)<<uint(depth-1); depth++ {
+		id, err := storage.NewNodeIDForTreeCoords(int64(depth), leafIndex>>uint(depth), logTreeDepth)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// mapPath returns the IDs of the nodes stored for a map index, from the leaf up to
+// the top subtree. Only nodes on the boundaries between strata are stored, the
+// rest are recalculated when needed.
+func (i *inspector) mapPath(index []byte) ([]storage.NodeID, error) {
+	if got, want := len(index), i.layout.pathBits/8; got != want {
+		return nil, fmt.Errorf("map index %x has %d bytes, want %d", index, got, want)
+	}
+	var bounds []int
+	b := 0
+	for _, depth := range i.layout.strata {
+		b += depth
+		bounds = append(bounds, b)
+	}
+	var ids []storage.NodeID
+	for j := len(bounds) - 1; j >= 0; j-- {
+		id := storage.NewNodeIDFromHash(index)
+		id.PrefixLenBits = bounds[j]
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// printPath writes the subtree, suffix and hash of each node in ids to w.
+func (i *inspector) printPath(w io.Writer, ids []storage.NodeID) error {
+	sc := cache.NewSubtreeCache(i.layout.strata, i.layout.populate, i.layout.prepare)
+	getSubtree := func(id storage.NodeID) (*storagepb.SubtreeProto, error) {
+		s, err := i.reader.subtree(i.head.Revision, id.Path[:id.PrefixLenBits/8])
+		if err != nil || s == nil {
+			return nil, err
+		}
+		return s.Subtree, nil
+	}
+	fmt.Fprintf(w, "Root at revision %d: %x\n", i.head.Revision, i.head.RootHash)
+	for _, id := range ids {
+		h, err := sc.GetNodeHash(id, getSubtree)
+		if err != nil {
+			return fmt.Errorf("failed to read node %s: %v", i.layout.nodeLabel(id), err)
+		}
+		hash := "(not stored)"
+		if h != nil {
+			hash = fmt.Sprintf("%x", h)
+		}
+		fmt.Fprintf(w, "  %-24s subtree %x  %s\n", i.layout.nodeLabel(id), id.Path[:i.layout.subtreePrefixLen(id.PrefixLenBits)], hash)
+	}
+	return nil
+}
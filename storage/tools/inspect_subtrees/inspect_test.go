@@ -0,0 +1,213 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/cache"
+	"github.com/google/trillian/storage/storagepb"
+	"github.com/google/trillian/testonly"
+)
+
+// fakeTree is an in-memory subtreeReader holding a single revision.
+type fakeTree map[string]*storagepb.SubtreeProto
+
+func (f fakeTree) subtrees(rev int64, prefix []byte) ([]storedSubtree, error) {
+	var ids []string
+	for id := range f {
+		if strings.HasPrefix(id, string(prefix)) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	var ret []storedSubtree
+	for _, id := range ids {
+		ret = append(ret, storedSubtree{Revision: 1, Subtree: proto.Clone(f[id]).(*storagepb.SubtreeProto)})
+	}
+	return ret, nil
+}
+
+func (f fakeTree) subtree(rev int64, id []byte) (*storedSubtree, error) {
+	st, ok := f[string(id)]
+	if !ok {
+		return nil, nil
+	}
+	return &storedSubtree{Revision: 1, Subtree: proto.Clone(st).(*storagepb.SubtreeProto)}, nil
+}
+
+// newLogInspector creates an inspector for a log of the given size, with its
+// subtrees written in a single revision the same way as the sequencer does.
+func newLogInspector(t *testing.T, size int) (*inspector, fakeTree) {
+	layout, err := newTreeLayout(trillian.TreeType_LOG, testonly.Hasher)
+	if err != nil {
+		t.Fatalf("newTreeLayout(): %v", err)
+	}
+	f := make(fakeTree)
+	sc := cache.NewSubtreeCache(logStrata, layout.populate, layout.prepare)
+	getSubtree := func(storage.NodeID) (*storagepb.SubtreeProto, error) { return nil, nil }
+	cmt := merkle.NewCompactMerkleTree(testonly.Hasher)
+	for j := 0; j < size; j++ {
+		if _, err := cmt.AddLeafHash(testonly.Hasher.HashLeaf([]byte(fmt.Sprintf("leaf %d", j))), func(depth int, index int64, h []byte) error {
+			id, err := storage.NewNodeIDForTreeCoords(int64(depth), index, logTreeDepth)
+			if err != nil {
+				return err
+			}
+			return sc.SetNodeHash(id, h, getSubtree)
+		}); err != nil {
+			t.Fatalf("AddLeafHash(): %v", err)
+		}
+	}
+	if err := sc.Flush(func(sts []*storagepb.SubtreeProto) error {
+		for _, st := range sts {
+			f[string(st.Prefix)] = proto.Clone(st).(*storagepb.SubtreeProto)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("Flush(): %v", err)
+	}
+	return &inspector{layout: layout, reader: f, head: treeHead{Revision: 1, Size: int64(size), RootHash: cmt.CurrentRoot()}}, f
+}
+
+func TestListLogSubtrees(t *testing.T) {
+	i, f := newLogInspector(t, 600)
+	var buf bytes.Buffer
+	bad, err := i.listSubtrees(&buf, nil, true)
+	if err != nil {
+		t.Fatalf("listSubtrees(): %v", err)
+	}
+	if bad != 0 {
+		t.Errorf("listSubtrees() found %d bad subtrees in a consistent log:\n%s", bad, buf.String())
+	}
+	// The subtrees holding leaves [0, 256) and [256, 512) are full so can be checked.
+	if got, want := strings.Count(buf.String(), "matches parent subtree"), 2; got != want {
+		t.Errorf("listSubtrees() checked %d subtrees, want %d:\n%s", got, want, buf.String())
+	}
+	if !strings.Contains(buf.String(), "[d:0, i:599]") {
+		t.Errorf("listSubtrees() output doesn't include the last leaf:\n%s", buf.String())
+	}
+
+	// Corrupt a leaf in the first subtree.
+	firstSubtree := string(make([]byte, logTreeDepth/8-1))
+	for k := range f[firstSubtree].Leaves {
+		f[firstSubtree].Leaves[k] = []byte("bad hash")
+		break
+	}
+	buf.Reset()
+	bad, err = i.listSubtrees(&buf, []byte(firstSubtree), false)
+	if err != nil {
+		t.Fatalf("listSubtrees(): %v", err)
+	}
+	if bad != 1 || !strings.Contains(buf.String(), "mismatch") {
+		t.Errorf("listSubtrees()=%d, want 1 mismatched subtree:\n%s", bad, buf.String())
+	}
+}
+
+func TestLogPath(t *testing.T) {
+	i, _ := newLogInspector(t, 300)
+	if _, err := i.logPath(300); err == nil {
+		t.Error("logPath(300)=nil err, want error for index outside the tree")
+	}
+	ids, err := i.logPath(5)
+	if err != nil {
+		t.Fatalf("logPath(5): %v", err)
+	}
+	// A tree of 300 leaves has a height of 9.
+	if got, want := len(ids), 10; got != want {
+		t.Fatalf("logPath(5) returned %d nodes, want %d", got, want)
+	}
+	if got, want := ids[9].CoordString(), "[d:9, i:0]"; got != want {
+		t.Errorf("logPath(5) ends at %s, want %s", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := i.printPath(&buf, ids); err != nil {
+		t.Fatalf("printPath(): %v", err)
+	}
+	for _, want := range []string{
+		fmt.Sprintf("%x", testonly.Hasher.HashLeaf([]byte("leaf 5"))),
+		fmt.Sprintf("subtree 000000000000  %x", i.head.RootHash),
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("printPath() output doesn't contain %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestMapPath(t *testing.T) {
+	layout, err := newTreeLayout(trillian.TreeType_MAP, testonly.Hasher)
+	if err != nil {
+		t.Fatalf("newTreeLayout(): %v", err)
+	}
+	i := &inspector{layout: layout, reader: make(fakeTree)}
+	if _, err := i.mapPath([]byte("short")); err == nil {
+		t.Error("mapPath() with a short index=nil err, want error")
+	}
+	index := testonly.HashKey("key")
+	ids, err := i.mapPath(index)
+	if err != nil {
+		t.Fatalf("mapPath(): %v", err)
+	}
+	var bits, prefixLens []int
+	for _, id := range ids {
+		bits = append(bits, id.PrefixLenBits)
+		prefixLens = append(prefixLens, layout.subtreePrefixLen(id.PrefixLenBits))
+	}
+	if got, want := fmt.Sprint(bits), "[256 80 72 64 56 48 40 32 24 16 8]"; got != want {
+		t.Errorf("mapPath() prefix lengths=%s, want %s", got, want)
+	}
+	if got, want := fmt.Sprint(prefixLens), "[10 9 8 7 6 5 4 3 2 1 0]"; got != want {
+		t.Errorf("mapPath() subtree prefix lengths=%s, want %s", got, want)
+	}
+}
+
+func TestNodeIDFromSuffix(t *testing.T) {
+	layout, err := newTreeLayout(trillian.TreeType_LOG, testonly.Hasher)
+	if err != nil {
+		t.Fatalf("newTreeLayout(): %v", err)
+	}
+	for _, test := range []struct {
+		prefix  []byte
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{prefix: make([]byte, 7), key: "CAA=", want: "[d:0, i:0]"},
+		{prefix: append(make([]byte, 6), 1), key: "CAU=", want: "[d:0, i:261]"},
+		{prefix: make([]byte, 7), key: "BwI=", want: "[d:1, i:1]"},
+		{prefix: make([]byte, 7), key: "not base64", wantErr: true},
+		{prefix: make([]byte, 8), key: "CAA=", wantErr: true},
+	} {
+		id, err := layout.nodeIDFromSuffix(test.prefix, test.key)
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("nodeIDFromSuffix(%x, %s)=%v, want err: %v", test.prefix, test.key, err, test.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got := id.CoordString(); got != test.want {
+			t.Errorf("nodeIDFromSuffix(%x, %s)=%s, want %s", test.prefix, test.key, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package main contains the inspect_subtrees command, a debugging aid which shows
+// how the Merkle tree nodes of a log or map are held in storage.
+//
+// It lists the subtrees of a tree as they were at a revision, optionally only
+// those whose ID starts with a prefix. Each subtree is repopulated in the same way
+// as when it's read by the storage layer, and its root hash checked against the
+// copy held in its parent:
+// $ ./inspect_subtrees --mysql_uri=... --treeid=1234 --revision=10 --prefix=000000 --nodes
+//
+// It can also show the stored nodes on the path from a log leaf or map leaf up to
+// the root:
+// $ ./inspect_subtrees --mysql_uri=... --treeid=1234 --leaf_index=77
+// $ ./inspect_subtrees --mysql_uri=... --treeid=5678 --map_key=somekey
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+
+	_ "github.com/go-sql-driver/mysql" // Load MySQL driver
+
+	"github.com/golang/glog"
+	"github.com/google/trillian"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/mysql"
+)
+
+var (
+	mySQLURI     = flag.String("mysql_uri", "test:zaphod@tcp(127.0.0.1:3306)/test", "Connection URI for MySQL database")
+	treeIDFlag   = flag.Int64("treeid", 0, "The ID of the tree to inspect")
+	revisionFlag = flag.Int64("revision", -1, "The revision to inspect, -1 for the latest")
+	prefixFlag   = flag.String("prefix", "", "Only list subtrees whose ID starts with this prefix, in hex")
+	nodesFlag    = flag.Bool("nodes", false, "List every node in each subtree")
+	leafIndex    = flag.Int64("leaf_index", -1, "Show the path from this log leaf to the root instead of listing subtrees")
+	mapKey       = flag.String("map_key", "", "Show the path from the leaf for this map key to the root instead of listing subtrees")
+	mapIndexHex  = flag.String("map_index", "", "As --map_key, but with the hashed key given in hex")
+)
+
+// inspectOpts contains all user-supplied options required to run the program.
+type inspectOpts struct {
+	treeID    int64
+	revision  int64
+	prefix    []byte
+	nodes     bool
+	leafIndex int64
+	mapIndex  []byte
+}
+
+func parseOpts() (*inspectOpts, error) {
+	opts := &inspectOpts{
+		treeID:    *treeIDFlag,
+		revision:  *revisionFlag,
+		nodes:     *nodesFlag,
+		leafIndex: *leafIndex,
+	}
+	if opts.treeID <= 0 {
+		return nil, errors.New("--treeid must be set")
+	}
+	if opts.revision < 0 {
+		opts.revision = math.MaxInt64
+	}
+	var err error
+	if opts.prefix, err = hex.DecodeString(*prefixFlag); err != nil {
+		return nil, fmt.Errorf("invalid --prefix: %v", err)
+	}
+	switch {
+	case len(*mapKey) > 0 && len(*mapIndexHex) > 0:
+		return nil, errors.New("only one of --map_key and --map_index can be set")
+	case len(*mapKey) > 0:
+		h := sha256.Sum256([]byte(*mapKey))
+		opts.mapIndex = h[:]
+	case len(*mapIndexHex) > 0:
+		if opts.mapIndex, err = hex.DecodeString(*mapIndexHex); err != nil {
+			return nil, fmt.Errorf("invalid --map_index: %v", err)
+		}
+	}
+	if opts.leafIndex >= 0 && opts.mapIndex != nil {
+		return nil, errors.New("--leaf_index can't be used with --map_key or --map_index")
+	}
+	return opts, nil
+}
+
+// run inspects the tree as requested by opts.
+func run(i *inspector, opts *inspectOpts) error {
+	var ids []storage.NodeID
+	var err error
+	switch {
+	case opts.leafIndex >= 0:
+		if i.layout.treeType != trillian.TreeType_LOG {
+			return errors.New("--leaf_index can only be used with logs")
+		}
+		ids, err = i.logPath(opts.leafIndex)
+	case opts.mapIndex != nil:
+		if i.layout.treeType != trillian.TreeType_MAP {
+			return errors.New("--map_key and --map_index can only be used with maps")
+		}
+		ids, err = i.mapPath(opts.mapIndex)
+	default:
+		bad, err := i.listSubtrees(os.Stdout, opts.prefix, opts.nodes)
+		if err != nil {
+			return err
+		}
+		if bad > 0 {
+			return fmt.Errorf("%d subtrees failed their root hash check", bad)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return i.printPath(os.Stdout, ids)
+}
+
+func main() {
+	flag.Parse()
+
+	opts, err := parseOpts()
+	if err != nil {
+		glog.Exit(err)
+	}
+
+	db, err := mysql.OpenDB(*mySQLURI)
+	if err != nil {
+		glog.Exitf("Failed to open MySQL database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	tx, err := mysql.NewAdminStorage(db).Snapshot(ctx)
+	if err != nil {
+		glog.Exitf("Failed to start admin transaction: %v", err)
+	}
+	tree, err := tx.GetTree(ctx, opts.treeID)
+	if err != nil {
+		glog.Exitf("Failed to read tree %d: %v", opts.treeID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		glog.Exitf("Failed to commit admin transaction: %v", err)
+	}
+
+	// TODO: read the hash strategy from the tree once storage supports more than one.
+	hasher, err := merkle.Factory(merkle.RFC6962SHA256Type)
+	if err != nil {
+		glog.Exitf("Failed to create hasher: %v", err)
+	}
+	layout, err := newTreeLayout(tree.TreeType, hasher)
+	if err != nil {
+		glog.Exit(err)
+	}
+
+	t := &mySQLTree{db: db, treeID: opts.treeID, treeType: tree.TreeType}
+	head, err := t.head(opts.revision)
+	if err != nil {
+		glog.Exitf("Failed to read root: %v", err)
+	}
+	i := &inspector{layout: layout, reader: t, head: head}
+	if err := run(i, opts); err != nil {
+		glog.Exit(err)
+	}
+}
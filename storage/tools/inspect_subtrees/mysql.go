@@ -0,0 +1,117 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/storage/storagepb"
+)
+
+// These statements read the MySQL storage tables directly, as the storage API
+// doesn't support listing subtrees.
+const (
+	selectLogHeadSQL = `SELECT TreeRevision,TreeSize,RootHash
+			FROM TreeHead WHERE TreeId=? AND TreeRevision<=?
+			ORDER BY TreeRevision DESC LIMIT 1`
+	selectMapHeadSQL = `SELECT MapRevision,RootHash
+			FROM MapHead WHERE TreeId=? AND MapRevision<=?
+			ORDER BY MapRevision DESC LIMIT 1`
+	selectSubtreesWithPrefixSQL = `SELECT x.SubtreeId,x.MaxRevision,s.Nodes
+			FROM (SELECT SubtreeId,MAX(SubtreeRevision) AS MaxRevision
+				FROM Subtree WHERE TreeId=? AND SubtreeRevision<=? AND LEFT(SubtreeId,?)=?
+				GROUP BY SubtreeId) AS x
+			INNER JOIN Subtree s
+			ON s.SubtreeId=x.SubtreeId AND s.SubtreeRevision=x.MaxRevision AND s.TreeId=?
+			ORDER BY x.SubtreeId`
+	selectSubtreeSQL = `SELECT SubtreeRevision,Nodes
+			FROM Subtree WHERE TreeId=? AND SubtreeId=? AND SubtreeRevision<=?
+			ORDER BY SubtreeRevision DESC LIMIT 1`
+)
+
+// mySQLTree implements subtreeReader for a tree stored in MySQL.
+type mySQLTree struct {
+	db       *sql.DB
+	treeID   int64
+	treeType trillian.TreeType
+}
+
+// head returns the most recent root of the tree at or before rev.
+func (m *mySQLTree) head(rev int64) (treeHead, error) {
+	var h treeHead
+	var err error
+	if m.treeType == trillian.TreeType_MAP {
+		err = m.db.QueryRow(selectMapHeadSQL, m.treeID, rev).Scan(&h.Revision, &h.RootHash)
+	} else {
+		err = m.db.QueryRow(selectLogHeadSQL, m.treeID, rev).Scan(&h.Revision, &h.Size, &h.RootHash)
+	}
+	if err == sql.ErrNoRows {
+		return h, fmt.Errorf("tree %d has no root at or before revision %d", m.treeID, rev)
+	}
+	return h, err
+}
+
+func (m *mySQLTree) subtrees(rev int64, prefix []byte) ([]storedSubtree, error) {
+	rows, err := m.db.Query(selectSubtreesWithPrefixSQL, m.treeID, rev, len(prefix), prefix, m.treeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ret []storedSubtree
+	for rows.Next() {
+		var id, nodes []byte
+		var s storedSubtree
+		if err := rows.Scan(&id, &s.Revision, &nodes); err != nil {
+			return nil, err
+		}
+		s.Subtree = unmarshalSubtree(id, s.Revision, nodes)
+		ret = append(ret, s)
+	}
+	return ret, rows.Err()
+}
+
+func (m *mySQLTree) subtree(rev int64, id []byte) (*storedSubtree, error) {
+	var nodes []byte
+	var s storedSubtree
+	err := m.db.QueryRow(selectSubtreeSQL, m.treeID, id, rev).Scan(&s.Revision, &nodes)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	s.Subtree = unmarshalSubtree(id, s.Revision, nodes)
+	return &s, nil
+}
+
+// unmarshalSubtree decodes a stored subtree. An undecodable subtree is returned
+// with just its prefix set, so that it shows up as broken rather than stopping
+// the inspection.
+func unmarshalSubtree(id []byte, rev int64, nodes []byte) *storagepb.SubtreeProto {
+	var st storagepb.SubtreeProto
+	if err := proto.Unmarshal(nodes, &st); err != nil {
+		glog.Warningf("Failed to unmarshal subtree %x at revision %d: %v", id, rev, err)
+		st = storagepb.SubtreeProto{Prefix: id}
+	}
+	if st.Prefix == nil {
+		st.Prefix = []byte{}
+	}
+	return &st
+}
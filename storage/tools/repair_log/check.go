@@ -0,0 +1,367 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/cache"
+	"github.com/google/trillian/storage/storagepb"
+)
+
+// maxTreeDepth must match the value used by the log sequencer when it creates node IDs.
+const maxTreeDepth = 64
+
+// logStrata must match the strata used by log storage.
+var logStrata = []int{8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8}
+
+// treeHead holds the fields of a stored log root that are needed to check the tree.
+type treeHead struct {
+	Revision int64
+	Size     int64
+	RootHash []byte
+}
+
+// logReader provides raw read access to the stored state of a single log.
+type logReader interface {
+	// treeHeads returns all the stored roots of the log, in ascending revision order.
+	treeHeads() ([]treeHead, error)
+	// leaves returns the sequenced leaves with indices in [start, end), in index order.
+	// Leaves which are missing from storage are not returned.
+	leaves(start, end int64) ([]*trillian.LogLeaf, error)
+	// subtrees returns the subtrees stored at exactly revision rev, keyed by prefix.
+	subtrees(rev int64) (map[string]*storagepb.SubtreeProto, error)
+}
+
+// subtreeWriter rewrites stored subtrees in place.
+type subtreeWriter interface {
+	// rewriteSubtrees replaces (or creates) each subtree in diffs with its expected value.
+	rewriteSubtrees(diffs []subtreeDiff) error
+}
+
+// rootMismatch describes a stored root that doesn't match the root recomputed from the leaves.
+type rootMismatch struct {
+	Revision int64
+	Size     int64
+	Stored   []byte
+	Computed []byte
+}
+
+// nodeDiff describes a node whose stored hash differs from the recomputed one. A nil
+// hash means that the node is absent.
+type nodeDiff struct {
+	ID       storage.NodeID
+	Stored   []byte
+	Expected []byte
+}
+
+// subtreeDiff describes a stored subtree that differs from the recomputed one.
+type subtreeDiff struct {
+	Revision int64
+	Prefix   []byte
+	// Stored is nil if the subtree was expected at Revision but isn't in storage.
+	Stored *storagepb.SubtreeProto
+	// Expected is nil if the subtree is in storage but nothing should have been written
+	// to it at or before Revision.
+	Expected *storagepb.SubtreeProto
+	Nodes    []nodeDiff
+}
+
+// report holds the result of checking a log.
+type report struct {
+	// LeafErrors describes problems with the sequenced leaf data. If there are any then
+	// the recomputed tree can't be trusted.
+	LeafErrors     []string
+	RootMismatches []rootMismatch
+	SubtreeDiffs   []subtreeDiff
+	// Complete is false if the check stopped before reaching the latest root.
+	Complete bool
+}
+
+// firstBadRevision returns the lowest revision with an inconsistent subtree, or -1.
+func (r *report) firstBadRevision() int64 {
+	if len(r.SubtreeDiffs) == 0 {
+		return -1
+	}
+	return r.SubtreeDiffs[0].Revision
+}
+
+// repairable returns an error if the subtrees of the checked log must not be rewritten
+// using the recomputed values.
+func (r *report) repairable() error {
+	switch {
+	case !r.Complete || len(r.LeafErrors) > 0:
+		return errors.New("sequenced leaf data is not intact")
+	case len(r.RootMismatches) > 0:
+		return fmt.Errorf("%d stored roots don't match the leaf data", len(r.RootMismatches))
+	}
+	for _, d := range r.SubtreeDiffs {
+		if d.Expected == nil {
+			return fmt.Errorf("unexpected subtree %x at revision %d", d.Prefix, d.Revision)
+		}
+	}
+	return nil
+}
+
+// checker recomputes the Merkle tree of a log from its sequenced leaves and compares
+// the result against the stored roots and subtrees.
+type checker struct {
+	hasher    merkle.TreeHasher
+	reader    logReader
+	batchSize int64
+}
+
+// check replays sequencing of the stored leaves, one stored root at a time, in the same
+// way as the log sequencer does. The subtrees which would have been written at each
+// revision are compared with the ones in storage.
+func (c *checker) check() (*report, error) {
+	heads, err := c.reader.treeHeads()
+	if err != nil {
+		return nil, err
+	}
+
+	rep := &report{}
+	cmt := merkle.NewCompactMerkleTree(c.hasher)
+	// latest holds the recomputed value of each subtree as of the last replayed revision.
+	latest := make(map[string]*storagepb.SubtreeProto)
+	getSubtree := func(id storage.NodeID) (*storagepb.SubtreeProto, error) {
+		st, ok := latest[string(id.Path[:id.PrefixLenBits/8])]
+		if !ok {
+			return nil, nil
+		}
+		return proto.Clone(st).(*storagepb.SubtreeProto), nil
+	}
+
+	for _, head := range heads {
+		if head.Size < cmt.Size() {
+			rep.LeafErrors = append(rep.LeafErrors, fmt.Sprintf("root at revision %d has size %d, smaller than previous size %d", head.Revision, head.Size, cmt.Size()))
+			return rep, nil
+		}
+
+		// Each revision is written through a fresh cache, like a sequencer transaction.
+		sc := cache.NewSubtreeCache(logStrata, cache.PopulateLogSubtreeNodes(c.hasher), cache.PrepareLogSubtreeWrite())
+		for start := cmt.Size(); start < head.Size; start += c.batchSize {
+			end := start + c.batchSize
+			if end > head.Size {
+				end = head.Size
+			}
+			leaves, err := c.reader.leaves(start, end)
+			if err != nil {
+				return nil, err
+			}
+			if errs := c.checkLeaves(start, end, leaves); len(errs) > 0 {
+				rep.LeafErrors = append(rep.LeafErrors, errs...)
+				return rep, nil
+			}
+			for _, leaf := range leaves {
+				if _, err := cmt.AddLeafHash(leaf.MerkleLeafHash, func(depth int, index int64, h []byte) error {
+					id, err := storage.NewNodeIDForTreeCoords(int64(depth), index, maxTreeDepth)
+					if err != nil {
+						return err
+					}
+					return sc.SetNodeHash(id, h, getSubtree)
+				}); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if got := cmt.CurrentRoot(); !bytes.Equal(got, head.RootHash) {
+			rep.RootMismatches = append(rep.RootMismatches, rootMismatch{
+				Revision: head.Revision,
+				Size:     head.Size,
+				Stored:   head.RootHash,
+				Computed: got,
+			})
+		}
+
+		expected := make(map[string]*storagepb.SubtreeProto)
+		if err := sc.Flush(func(sts []*storagepb.SubtreeProto) error {
+			for _, st := range sts {
+				expected[string(st.Prefix)] = st
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		stored, err := c.reader.subtrees(head.Revision)
+		if err != nil {
+			return nil, err
+		}
+
+		// Subtrees which weren't written at this revision should still match the
+		// most recent value, if they're stored anyway.
+		prefixes := make(map[string]*storagepb.SubtreeProto)
+		for px := range stored {
+			prefixes[px] = latest[px]
+		}
+		for px, st := range expected {
+			prefixes[px] = st
+		}
+		keys := make([]string, 0, len(prefixes))
+		for px := range prefixes {
+			keys = append(keys, px)
+		}
+		sort.Strings(keys)
+		for _, px := range keys {
+			if d, ok := diffSubtree(head.Revision, []byte(px), stored[px], prefixes[px]); ok {
+				rep.SubtreeDiffs = append(rep.SubtreeDiffs, d)
+			}
+		}
+		for px, st := range expected {
+			latest[px] = proto.Clone(st).(*storagepb.SubtreeProto)
+		}
+	}
+
+	// Leaves beyond the latest root shouldn't exist, as they're written in the same
+	// transaction as the root which includes them.
+	extra, err := c.reader.leaves(cmt.Size(), cmt.Size()+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(extra) > 0 {
+		rep.LeafErrors = append(rep.LeafErrors, fmt.Sprintf("found sequenced leaf %d beyond latest tree size", extra[0].LeafIndex))
+	}
+	rep.Complete = true
+	return rep, nil
+}
+
+// checkLeaves verifies that leaves holds exactly the leaves [start, end) and that the
+// Merkle leaf hash of each matches its value.
+func (c *checker) checkLeaves(start, end int64, leaves []*trillian.LogLeaf) []string {
+	var errs []string
+	want := start
+	for _, leaf := range leaves {
+		if leaf.LeafIndex != want {
+			errs = append(errs, fmt.Sprintf("leaf %d is missing (next leaf is %d)", want, leaf.LeafIndex))
+			return errs
+		}
+		if h := c.hasher.HashLeaf(leaf.LeafValue); !bytes.Equal(h, leaf.MerkleLeafHash) {
+			errs = append(errs, fmt.Sprintf("leaf %d has Merkle leaf hash %x, but its value hashes to %x", leaf.LeafIndex, leaf.MerkleLeafHash, h))
+		}
+		want++
+	}
+	if want != end {
+		errs = append(errs, fmt.Sprintf("leaves [%d, %d) are missing", want, end))
+	}
+	return errs
+}
+
+// diffSubtree compares a stored subtree with its expected value, returning a
+// subtreeDiff and true if they differ.
+func diffSubtree(rev int64, prefix []byte, stored, expected *storagepb.SubtreeProto) (subtreeDiff, bool) {
+	if stored != nil && expected != nil && proto.Equal(stored, expected) {
+		return subtreeDiff{}, false
+	}
+	d := subtreeDiff{
+		Revision: rev,
+		Prefix:   prefix,
+		Stored:   stored,
+		Expected: expected,
+	}
+	var storedLeaves, expectedLeaves, storedInternal, expectedInternal map[string][]byte
+	if stored != nil {
+		storedLeaves, storedInternal = stored.Leaves, stored.InternalNodes
+	}
+	if expected != nil {
+		expectedLeaves, expectedInternal = expected.Leaves, expected.InternalNodes
+	}
+	d.Nodes = append(diffNodes(prefix, storedLeaves, expectedLeaves), diffNodes(prefix, storedInternal, expectedInternal)...)
+	return d, true
+}
+
+// diffNodes compares two maps of suffix to node hash, returning the nodes that differ.
+func diffNodes(prefix []byte, stored, expected map[string][]byte) []nodeDiff {
+	var keys []string
+	for k := range stored {
+		keys = append(keys, k)
+	}
+	for k := range expected {
+		if _, ok := stored[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var diffs []nodeDiff
+	for _, k := range keys {
+		if bytes.Equal(stored[k], expected[k]) {
+			continue
+		}
+		id, err := nodeIDFromSuffix(prefix, k)
+		if err != nil {
+			// The suffix is garbage, so report it as an unknown node.
+			id = storage.NewNodeIDFromHash(prefix)
+		}
+		diffs = append(diffs, nodeDiff{ID: id, Stored: stored[k], Expected: expected[k]})
+	}
+	return diffs
+}
+
+// nodeIDFromSuffix rebuilds the NodeID of a node from its subtree prefix and the
+// serialized suffix used as a key inside the subtree.
+func nodeIDFromSuffix(prefix []byte, key string) (storage.NodeID, error) {
+	sfx, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return storage.NodeID{}, err
+	}
+	if len(sfx) < 2 || len(prefix)+len(sfx)-1 > maxTreeDepth/8 {
+		return storage.NodeID{}, fmt.Errorf("invalid suffix %x for prefix %x", sfx, prefix)
+	}
+	id := storage.NewEmptyNodeID(maxTreeDepth)
+	copy(id.Path, prefix)
+	copy(id.Path[len(prefix):], sfx[1:])
+	id.PrefixLenBits = len(prefix)*8 + int(sfx[0])
+	return id, nil
+}
+
+// printReport writes a human readable version of rep to w.
+func printReport(w io.Writer, rep *report) {
+	for _, e := range rep.LeafErrors {
+		fmt.Fprintf(w, "Leaf data: %s\n", e)
+	}
+	if !rep.Complete {
+		fmt.Fprintln(w, "Check stopped early, later revisions were not checked")
+	}
+	for _, m := range rep.RootMismatches {
+		fmt.Fprintf(w, "Root at revision %d (size %d): stored %x, computed %x\n", m.Revision, m.Size, m.Stored, m.Computed)
+	}
+	for _, d := range rep.SubtreeDiffs {
+		switch {
+		case d.Stored == nil:
+			fmt.Fprintf(w, "Subtree %x at revision %d: missing\n", d.Prefix, d.Revision)
+		case d.Expected == nil:
+			fmt.Fprintf(w, "Subtree %x at revision %d: unexpected\n", d.Prefix, d.Revision)
+		default:
+			fmt.Fprintf(w, "Subtree %x at revision %d: differs\n", d.Prefix, d.Revision)
+		}
+		for _, n := range d.Nodes {
+			fmt.Fprintf(w, "  node %s: stored %x, expected %x\n", n.ID.CoordString(), n.Stored, n.Expected)
+		}
+	}
+	if rev := rep.firstBadRevision(); rev >= 0 {
+		fmt.Fprintf(w, "First inconsistent subtree revision: %d\n", rev)
+	} else if rep.Complete && len(rep.LeafErrors) == 0 && len(rep.RootMismatches) == 0 {
+		fmt.Fprintln(w, "No inconsistencies found")
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/storage/storagepb"
+	"github.com/google/trillian/testonly"
+)
+
+// firstSubtree is the prefix of the bottom subtree holding leaves [0, 256).
+var firstSubtree = string(make([]byte, maxTreeDepth/8-1))
+
+// leafZeroSuffix is the key of leaf 0 within firstSubtree.
+const leafZeroSuffix = "CAA="
+
+type subtreeKey struct {
+	rev    int64
+	prefix string
+}
+
+// fakeLog is an in-memory logReader and subtreeWriter.
+type fakeLog struct {
+	heads   []treeHead
+	leafs   []*trillian.LogLeaf
+	stored  map[subtreeKey]*storagepb.SubtreeProto
+	written int
+}
+
+func (f *fakeLog) treeHeads() ([]treeHead, error) {
+	return f.heads, nil
+}
+
+func (f *fakeLog) leaves(start, end int64) ([]*trillian.LogLeaf, error) {
+	var ret []*trillian.LogLeaf
+	for _, l := range f.leafs {
+		if l.LeafIndex >= start && l.LeafIndex < end {
+			ret = append(ret, l)
+		}
+	}
+	return ret, nil
+}
+
+func (f *fakeLog) subtrees(rev int64) (map[string]*storagepb.SubtreeProto, error) {
+	ret := make(map[string]*storagepb.SubtreeProto)
+	for k, st := range f.stored {
+		if k.rev == rev {
+			ret[k.prefix] = proto.Clone(st).(*storagepb.SubtreeProto)
+		}
+	}
+	return ret, nil
+}
+
+func (f *fakeLog) rewriteSubtrees(diffs []subtreeDiff) error {
+	for _, d := range diffs {
+		f.stored[subtreeKey{d.Revision, string(d.Prefix)}] = proto.Clone(d.Expected).(*storagepb.SubtreeProto)
+		f.written++
+	}
+	return nil
+}
+
+// newFakeLog creates a fakeLog holding a consistent tree, sequenced in batches of the
+// given sizes.
+func newFakeLog(t *testing.T, batches ...int) *fakeLog {
+	f := &fakeLog{stored: make(map[subtreeKey]*storagepb.SubtreeProto)}
+	cmt := merkle.NewCompactMerkleTree(testonly.Hasher)
+	// An initial empty root, as created for a new log.
+	f.heads = append(f.heads, treeHead{Revision: 1, Size: 0, RootHash: cmt.CurrentRoot()})
+	for i, n := range batches {
+		for j := 0; j < n; j++ {
+			value := []byte(fmt.Sprintf("leaf %d", cmt.Size()))
+			leaf := &trillian.LogLeaf{
+				LeafIndex:      cmt.Size(),
+				LeafValue:      value,
+				MerkleLeafHash: testonly.Hasher.HashLeaf(value),
+			}
+			if _, err := cmt.AddLeafHash(leaf.MerkleLeafHash, func(int, int64, []byte) error { return nil }); err != nil {
+				t.Fatalf("AddLeafHash(): %v", err)
+			}
+			f.leafs = append(f.leafs, leaf)
+		}
+		f.heads = append(f.heads, treeHead{Revision: int64(i + 2), Size: cmt.Size(), RootHash: cmt.CurrentRoot()})
+	}
+
+	// Nothing is stored yet, so the checker reports every expected subtree as missing,
+	// and "repairing" the log stores them.
+	c := &checker{hasher: testonly.Hasher, reader: f, batchSize: 7}
+	rep, err := c.check()
+	if err != nil {
+		t.Fatalf("check(): %v", err)
+	}
+	if err := f.rewriteSubtrees(rep.SubtreeDiffs); err != nil {
+		t.Fatalf("rewriteSubtrees(): %v", err)
+	}
+	return f
+}
+
+func TestCheckConsistentLog(t *testing.T) {
+	for _, batches := range [][]int{{1}, {3, 5}, {256}, {255, 1, 300}, {1, 1, 1, 1, 1, 1, 1, 1, 1, 700}} {
+		f := newFakeLog(t, batches...)
+		if f.written == 0 {
+			t.Errorf("%v: no subtrees were stored", batches)
+		}
+		c := &checker{hasher: testonly.Hasher, reader: f, batchSize: 100}
+		rep, err := c.check()
+		if err != nil {
+			t.Fatalf("%v: check(): %v", batches, err)
+		}
+		if !rep.Complete || len(rep.LeafErrors) > 0 || len(rep.RootMismatches) > 0 || len(rep.SubtreeDiffs) > 0 {
+			t.Errorf("%v: check() found inconsistencies in a consistent log: %+v", batches, rep)
+		}
+	}
+}
+
+func TestCheckAndRepairCorruptSubtree(t *testing.T) {
+	f := newFakeLog(t, 10, 300, 20)
+
+	// Corrupt the hash of leaf 0 in the subtree written at revision 2, it is carried
+	// forward into the copy of the subtree written at revision 3.
+	for _, rev := range []int64{2, 3} {
+		st := f.stored[subtreeKey{rev, firstSubtree}]
+		if st == nil {
+			t.Fatalf("no subtree for leaf 0 at revision %d", rev)
+		}
+		st.Leaves[leafZeroSuffix] = []byte("bad hash")
+	}
+
+	c := &checker{hasher: testonly.Hasher, reader: f, batchSize: 100}
+	rep, err := c.check()
+	if err != nil {
+		t.Fatalf("check(): %v", err)
+	}
+	if got, want := rep.firstBadRevision(), int64(2); got != want {
+		t.Errorf("firstBadRevision()=%d, want %d", got, want)
+	}
+	if got, want := len(rep.SubtreeDiffs), 2; got != want {
+		t.Fatalf("got %d subtree diffs, want %d: %+v", got, want, rep.SubtreeDiffs)
+	}
+	for _, d := range rep.SubtreeDiffs {
+		if len(d.Nodes) != 1 || string(d.Nodes[0].Stored) != "bad hash" {
+			t.Errorf("subtree diff at revision %d has nodes %+v, want the corrupted node", d.Revision, d.Nodes)
+		}
+	}
+	if err := rep.repairable(); err != nil {
+		t.Fatalf("repairable()=%v, want nil", err)
+	}
+
+	if err := run(c, f, &repairOpts{treeID: 1, confirmTreeID: 1, repair: true}); err != nil {
+		t.Fatalf("run(): %v", err)
+	}
+	rep, err = c.check()
+	if err != nil {
+		t.Fatalf("check() after repair: %v", err)
+	}
+	if len(rep.SubtreeDiffs) > 0 {
+		t.Errorf("check() after repair found diffs: %+v", rep.SubtreeDiffs)
+	}
+}
+
+func TestCheckMissingSubtree(t *testing.T) {
+	f := newFakeLog(t, 4, 4)
+	delete(f.stored, subtreeKey{3, firstSubtree})
+
+	c := &checker{hasher: testonly.Hasher, reader: f, batchSize: 100}
+	rep, err := c.check()
+	if err != nil {
+		t.Fatalf("check(): %v", err)
+	}
+	if got, want := len(rep.SubtreeDiffs), 1; got != want {
+		t.Fatalf("got %d subtree diffs, want %d", got, want)
+	}
+	if d := rep.SubtreeDiffs[0]; d.Stored != nil || d.Revision != 3 {
+		t.Errorf("got diff %+v, want missing subtree at revision 3", d)
+	}
+}
+
+func TestCheckRefusesRepairWithBadLeafData(t *testing.T) {
+	for _, test := range []struct {
+		desc    string
+		corrupt func(f *fakeLog)
+	}{
+		{
+			desc:    "leaf value changed",
+			corrupt: func(f *fakeLog) { f.leafs[5].LeafValue = []byte("something else") },
+		},
+		{
+			desc:    "leaf missing",
+			corrupt: func(f *fakeLog) { f.leafs = append(f.leafs[:5], f.leafs[6:]...) },
+		},
+		{
+			desc:    "root mismatch",
+			corrupt: func(f *fakeLog) { f.heads[2].RootHash = []byte("not the root") },
+		},
+	} {
+		f := newFakeLog(t, 10, 10)
+		test.corrupt(f)
+		// Also corrupt a subtree so that there is something to repair.
+		for _, st := range f.stored {
+			for k := range st.Leaves {
+				st.Leaves[k] = []byte("bad hash")
+				break
+			}
+			break
+		}
+
+		c := &checker{hasher: testonly.Hasher, reader: f, batchSize: 100}
+		rep, err := c.check()
+		if err != nil {
+			t.Fatalf("%s: check(): %v", test.desc, err)
+		}
+		if err := rep.repairable(); err == nil {
+			t.Errorf("%s: repairable()=nil, want error", test.desc)
+		}
+		written := f.written
+		if err := run(c, f, &repairOpts{treeID: 1, confirmTreeID: 1, repair: true}); err == nil {
+			t.Errorf("%s: run()=nil, want error", test.desc)
+		}
+		if f.written != written {
+			t.Errorf("%s: run() rewrote subtrees of a log with bad leaf data", test.desc)
+		}
+	}
+}
+
+func TestValidateOpts(t *testing.T) {
+	for _, test := range []struct {
+		opts    repairOpts
+		wantErr bool
+	}{
+		{opts: repairOpts{treeID: 1, batchSize: 1}},
+		{opts: repairOpts{treeID: 1, batchSize: 1, repair: true, confirmTreeID: 1}},
+		{opts: repairOpts{batchSize: 1}, wantErr: true},
+		{opts: repairOpts{treeID: 1, batchSize: 1, repair: true}, wantErr: true},
+		{opts: repairOpts{treeID: 1, batchSize: 1, repair: true, confirmTreeID: 2}, wantErr: true},
+		{opts: repairOpts{treeID: 1}, wantErr: true},
+		{opts: repairOpts{treeID: 1, batchSize: -1}, wantErr: true},
+	} {
+		if err := validateOpts(&test.opts); (err != nil) != test.wantErr {
+			t.Errorf("validateOpts(%+v)=%v, want err: %v", test.opts, err, test.wantErr)
+		}
+	}
+}
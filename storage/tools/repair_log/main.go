@@ -0,0 +1,130 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package main contains the repair_log command, which diagnoses and optionally
+// repairs a log whose stored Merkle tree nodes are inconsistent with its leaves,
+// for example when the sequencer keeps failing with a merkle.RootHashMismatchError.
+//
+// The whole tree is recomputed from the sequenced leaf data, replaying each stored
+// root in turn, and compared with the stored subtrees at every revision:
+// $ ./repair_log --mysql_uri=... --treeid=1234
+//
+// If the leaf data is intact and every stored root matches the recomputed tree, the
+// inconsistent subtrees can be rewritten in place. This must be explicitly confirmed:
+// $ ./repair_log --mysql_uri=... --treeid=1234 --repair --confirm_treeid=1234
+//
+// The log's signer should be stopped while the command runs.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	_ "github.com/go-sql-driver/mysql" // Load MySQL driver
+
+	"github.com/golang/glog"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/storage/blob"
+	"github.com/google/trillian/storage/mysql"
+)
+
+var (
+	mySQLURI          = flag.String("mysql_uri", "test:zaphod@tcp(127.0.0.1:3306)/test", "Connection URI for MySQL database")
+	treeIDFlag        = flag.Int64("treeid", 0, "The ID of the log to check")
+	blobDirFlag       = flag.String("blob_dir", "", "Directory of the blob store holding the log's offloaded leaf values, if any")
+	leafBatchSize     = flag.Int64("leaf_batch_size", 1000, "Number of leaves to read from storage at a time")
+	repairFlag        = flag.Bool("repair", false, "Rewrite inconsistent subtrees with recomputed values, requires --confirm_treeid")
+	confirmTreeIDFlag = flag.Int64("confirm_treeid", 0, "Must be set to the value of --treeid for --repair to modify storage")
+)
+
+// repairOpts contains all user-supplied options required to run the program.
+type repairOpts struct {
+	treeID, confirmTreeID int64
+	batchSize             int64
+	repair                bool
+}
+
+func validateOpts(opts *repairOpts) error {
+	if opts.treeID <= 0 {
+		return errors.New("--treeid must be set")
+	}
+	if opts.repair && opts.confirmTreeID != opts.treeID {
+		return errors.New("--repair modifies storage, set --confirm_treeid to the value of --treeid to allow it")
+	}
+	if opts.batchSize <= 0 {
+		return errors.New("--leaf_batch_size must be positive")
+	}
+	return nil
+}
+
+// run checks the log and repairs it if requested and safe to do so.
+func run(c *checker, w subtreeWriter, opts *repairOpts) error {
+	rep, err := c.check()
+	if err != nil {
+		return fmt.Errorf("failed to check tree %d: %v", opts.treeID, err)
+	}
+	printReport(os.Stdout, rep)
+
+	if !opts.repair {
+		return nil
+	}
+	if err := rep.repairable(); err != nil {
+		return fmt.Errorf("refusing to repair tree %d: %v", opts.treeID, err)
+	}
+	if len(rep.SubtreeDiffs) == 0 {
+		return nil
+	}
+	if err := w.rewriteSubtrees(rep.SubtreeDiffs); err != nil {
+		return fmt.Errorf("failed to repair tree %d: %v", opts.treeID, err)
+	}
+	fmt.Printf("Rewrote %d subtrees\n", len(rep.SubtreeDiffs))
+	return nil
+}
+
+func main() {
+	flag.Parse()
+
+	opts := &repairOpts{
+		treeID:        *treeIDFlag,
+		confirmTreeID: *confirmTreeIDFlag,
+		batchSize:     *leafBatchSize,
+		repair:        *repairFlag,
+	}
+	if err := validateOpts(opts); err != nil {
+		glog.Exit(err)
+	}
+
+	db, err := mysql.OpenDB(*mySQLURI)
+	if err != nil {
+		glog.Exitf("Failed to open MySQL database: %v", err)
+	}
+	defer db.Close()
+
+	// TODO: read the hash strategy from the tree once storage supports more than one.
+	hasher, err := merkle.Factory(merkle.RFC6962SHA256Type)
+	if err != nil {
+		glog.Exitf("Failed to create hasher: %v", err)
+	}
+
+	l := &mySQLLog{db: db, treeID: opts.treeID}
+	if len(*blobDirFlag) > 0 {
+		l.blobs = &blob.Offloader{Store: blob.NewFileStore(*blobDirFlag)}
+	}
+	c := &checker{hasher: hasher, reader: l, batchSize: opts.batchSize}
+	if err := run(c, l, opts); err != nil {
+		glog.Exit(err)
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/storage/blob"
+	"github.com/google/trillian/storage/storagepb"
+)
+
+// These statements read and write the MySQL storage tables directly, as the storage
+// API doesn't expose historical subtree revisions.
+const (
+	selectTreeHeadsSQL = `SELECT TreeRevision,TreeSize,RootHash
+			FROM TreeHead WHERE TreeId=?
+			ORDER BY TreeRevision`
+	selectLeavesInRangeSQL = `SELECT s.SequenceNumber,s.MerkleLeafHash,l.LeafValue,l.LeafValueBlob
+			FROM SequencedLeafData s,LeafData l
+			WHERE s.TreeId=? AND l.TreeId=s.TreeId AND l.LeafIdentityHash=s.LeafIdentityHash
+			AND s.SequenceNumber>=? AND s.SequenceNumber<?
+			ORDER BY s.SequenceNumber`
+	selectSubtreesAtRevisionSQL = "SELECT SubtreeId,Nodes FROM Subtree WHERE TreeId=? AND SubtreeRevision=?"
+	updateSubtreeSQL            = "UPDATE Subtree SET Nodes=? WHERE TreeId=? AND SubtreeId=? AND SubtreeRevision=?"
+	insertSubtreeSQL            = "INSERT INTO Subtree(TreeId,SubtreeId,Nodes,SubtreeRevision) VALUES(?,?,?,?)"
+)
+
+// mySQLLog implements logReader and subtreeWriter for a log stored in MySQL.
+type mySQLLog struct {
+	db     *sql.DB
+	treeID int64
+	// blobs fetches offloaded leaf values, or is nil if there are none.
+	blobs *blob.Offloader
+}
+
+func (m *mySQLLog) treeHeads() ([]treeHead, error) {
+	rows, err := m.db.Query(selectTreeHeadsSQL, m.treeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var heads []treeHead
+	for rows.Next() {
+		var h treeHead
+		if err := rows.Scan(&h.Revision, &h.Size, &h.RootHash); err != nil {
+			return nil, err
+		}
+		heads = append(heads, h)
+	}
+	return heads, rows.Err()
+}
+
+func (m *mySQLLog) leaves(start, end int64) ([]*trillian.LogLeaf, error) {
+	rows, err := m.db.Query(selectLeavesInRangeSQL, m.treeID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var leaves []*trillian.LogLeaf
+	for rows.Next() {
+		leaf := &trillian.LogLeaf{}
+		var blobKey []byte
+		if err := rows.Scan(&leaf.LeafIndex, &leaf.MerkleLeafHash, &leaf.LeafValue, &blobKey); err != nil {
+			return nil, err
+		}
+		if leaf.LeafValue, err = m.blobs.Resolve(context.Background(), leaf.LeafValue, string(blobKey)); err != nil {
+			return nil, fmt.Errorf("leaf %d: %v", leaf.LeafIndex, err)
+		}
+		leaves = append(leaves, leaf)
+	}
+	return leaves, rows.Err()
+}
+
+func (m *mySQLLog) subtrees(rev int64) (map[string]*storagepb.SubtreeProto, error) {
+	rows, err := m.db.Query(selectSubtreesAtRevisionSQL, m.treeID, rev)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ret := make(map[string]*storagepb.SubtreeProto)
+	for rows.Next() {
+		var id, nodes []byte
+		if err := rows.Scan(&id, &nodes); err != nil {
+			return nil, err
+		}
+		var st storagepb.SubtreeProto
+		if err := proto.Unmarshal(nodes, &st); err != nil {
+			// Keep going, an undecodable subtree is reported as empty so that it
+			// shows up as corrupt.
+			glog.Warningf("Failed to unmarshal subtree %x at revision %d: %v", id, rev, err)
+		}
+		if st.Prefix == nil {
+			st.Prefix = []byte{}
+		}
+		ret[string(id)] = &st
+	}
+	return ret, rows.Err()
+}
+
+func (m *mySQLLog) rewriteSubtrees(diffs []subtreeDiff) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, d := range diffs {
+		nodes, err := proto.Marshal(d.Expected)
+		if err != nil {
+			return err
+		}
+		var res sql.Result
+		if d.Stored == nil {
+			res, err = tx.Exec(insertSubtreeSQL, m.treeID, d.Prefix, nodes, d.Revision)
+		} else {
+			res, err = tx.Exec(updateSubtreeSQL, nodes, m.treeID, d.Prefix, d.Revision)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write subtree %x at revision %d: %v", d.Prefix, d.Revision, err)
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n != 1 {
+			return fmt.Errorf("writing subtree %x at revision %d affected %d rows, want 1", d.Prefix, d.Revision, n)
+		}
+		glog.Infof("Rewrote subtree %x at revision %d", d.Prefix, d.Revision)
+	}
+	return tx.Commit()
+}
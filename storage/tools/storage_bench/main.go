@@ -0,0 +1,100 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// storage_bench runs the storage benchmark suite against a MySQL database, and
+// writes the results as lines of JSON so that they can be compared between
+// schema or implementation changes.
+//
+// The benchmarks create new trees in the database, so it should not be one
+// which is in use.
+package main
+
+import (
+	"flag"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/mysql"
+	"github.com/google/trillian/storage/testonly"
+)
+
+var (
+	mySQLURI       = flag.String("mysql_uri", "test:zaphod@tcp(127.0.0.1:3306)/test", "Connection URI for MySQL database")
+	treeSizesFlag  = flag.String("tree_sizes", "1000,10000", "Comma-separated list of log sizes to benchmark sequencing and proofs at")
+	batchSizesFlag = flag.String("batch_sizes", "1,10,100", "Comma-separated list of batch sizes to benchmark queueing, sequencing and map writes with")
+	logFlag        = flag.Bool("log", true, "Whether to run the log benchmarks")
+	mapFlag        = flag.Bool("map", true, "Whether to run the map benchmarks")
+	outputFlag     = flag.String("output", "", "File to write results to, instead of stdout")
+)
+
+func parseInts(s string) ([]int64, error) {
+	var ret []int64
+	for _, f := range strings.Split(s, ",") {
+		i, err := strconv.ParseInt(f, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, i)
+	}
+	return ret, nil
+}
+
+func main() {
+	flag.Parse()
+
+	treeSizes, err := parseInts(*treeSizesFlag)
+	if err != nil {
+		glog.Exitf("Invalid --tree_sizes: %v", err)
+	}
+	batchSizes64, err := parseInts(*batchSizesFlag)
+	if err != nil {
+		glog.Exitf("Invalid --batch_sizes: %v", err)
+	}
+	batchSizes := make([]int, 0, len(batchSizes64))
+	for _, b := range batchSizes64 {
+		batchSizes = append(batchSizes, int(b))
+	}
+
+	db, err := mysql.OpenDB(*mySQLURI)
+	if err != nil {
+		glog.Exitf("Failed to open MySQL database: %v", err)
+	}
+	defer db.Close()
+
+	sb := &testonly.StorageBenchmarker{
+		NewAdminStorage: func() storage.AdminStorage { return mysql.NewAdminStorage(db) },
+		TreeSizes:       treeSizes,
+		BatchSizes:      batchSizes,
+	}
+	if *logFlag {
+		sb.NewLogStorage = func() storage.LogStorage { return mysql.NewLogStorage(db) }
+	}
+	if *mapFlag {
+		sb.NewMapStorage = func() storage.MapStorage { return mysql.NewMapStorage(db) }
+	}
+
+	out := os.Stdout
+	if *outputFlag != "" {
+		if out, err = os.Create(*outputFlag); err != nil {
+			glog.Exitf("Failed to create output file: %v", err)
+		}
+		defer out.Close()
+	}
+	if _, err := sb.Run(out); err != nil {
+		glog.Exitf("Benchmark failed: %v", err)
+	}
+}
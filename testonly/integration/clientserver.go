@@ -26,9 +26,11 @@ import (
 
 	"github.com/golang/protobuf/ptypes"
 	"github.com/google/trillian"
+	tcrypto "github.com/google/trillian/crypto"
 	"github.com/google/trillian/crypto/keys"
 	"github.com/google/trillian/extension"
 	"github.com/google/trillian/server"
+	"github.com/google/trillian/server/admin"
 	"github.com/google/trillian/storage/mysql"
 	stestonly "github.com/google/trillian/storage/testonly"
 	"github.com/google/trillian/testonly"
@@ -100,6 +102,7 @@ func NewLogEnv(ctx context.Context, numSequencers int, testID string) (*LogEnv,
 
 	// Create Sequencer.
 	sequencerManager := server.NewSequencerManager(registry, sequencerWindow)
+	sequencerManager.SetRootNotifier(logServer)
 	var wg sync.WaitGroup
 	var sequencerTask *server.LogOperationManager
 	var cancel context.CancelFunc
@@ -201,7 +204,13 @@ func (env *LogEnv) CreateLog() (int64, error) {
 	if err := tx.Commit(); err != nil {
 		return 0, err
 	}
-	// Sign the first empty tree head.
-	env.Sequencer.OperationSingle()
+	// Sign the first empty tree head, as the admin server does.
+	signer, err := env.registry.SignerFactory.NewSigner(ctx, tree)
+	if err != nil {
+		return 0, err
+	}
+	if err := admin.InitLog(ctx, env.registry.LogStorage, tcrypto.NewSigner(signer), tree.TreeId, timeSource); err != nil {
+		return 0, err
+	}
 	return tree.TreeId, nil
 }
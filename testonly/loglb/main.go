@@ -140,6 +140,36 @@ func (lb *randomLoadBalancer) GetEntryAndProof(ctx context.Context, req *trillia
 	return bc.client.GetEntryAndProof(ctx, req)
 }
 
+func (lb *randomLoadBalancer) GetLeafSubmitters(ctx context.Context, req *trillian.GetLeafSubmittersRequest) (*trillian.GetLeafSubmittersResponse, error) {
+	bc := lb.pick()
+	glog.V(3).Infof("forward GetLeafSubmitters request to backend %s", bc.server)
+	return bc.client.GetLeafSubmitters(ctx, req)
+}
+
+func (lb *randomLoadBalancer) WaitForInclusion(ctx context.Context, req *trillian.WaitForInclusionRequest) (*trillian.WaitForInclusionResponse, error) {
+	bc := lb.pick()
+	glog.V(3).Infof("forward WaitForInclusion request to backend %s", bc.server)
+	return bc.client.WaitForInclusion(ctx, req)
+}
+
+func (lb *randomLoadBalancer) ListDeadLetterLeaves(ctx context.Context, req *trillian.ListDeadLetterLeavesRequest) (*trillian.ListDeadLetterLeavesResponse, error) {
+	bc := lb.pick()
+	glog.V(3).Infof("forward ListDeadLetterLeaves request to backend %s", bc.server)
+	return bc.client.ListDeadLetterLeaves(ctx, req)
+}
+
+func (lb *randomLoadBalancer) RequeueDeadLetterLeaves(ctx context.Context, req *trillian.RequeueDeadLetterLeavesRequest) (*trillian.RequeueDeadLetterLeavesResponse, error) {
+	bc := lb.pick()
+	glog.V(3).Infof("forward RequeueDeadLetterLeaves request to backend %s", bc.server)
+	return bc.client.RequeueDeadLetterLeaves(ctx, req)
+}
+
+func (lb *randomLoadBalancer) DiscardDeadLetterLeaves(ctx context.Context, req *trillian.DiscardDeadLetterLeavesRequest) (*trillian.DiscardDeadLetterLeavesResponse, error) {
+	bc := lb.pick()
+	glog.V(3).Infof("forward DiscardDeadLetterLeaves request to backend %s", bc.server)
+	return bc.client.DiscardDeadLetterLeaves(ctx, req)
+}
+
 func (lb *randomLoadBalancer) startRPCServer(listener net.Listener, port int) *grpc.Server {
 	// Create and publish the RPC stats objects
 	statsInterceptor := monitoring.NewRPCStatsInterceptor(util.SystemTimeSource{}, "ct", "example")
@@ -0,0 +1,562 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vectors generates a corpus of test vectors for implementations of
+// Trillian proof and signature verification in other languages. Every vector
+// is produced by Trillian's own code, and Verify checks the corpus against
+// Trillian's verifiers.
+package vectors
+
+import (
+	gocrypto "crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto"
+	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/crypto/sigpb"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/testonly"
+)
+
+// Version is the version of the corpus format produced by Generate. It must
+// be incremented whenever the format changes incompatibly.
+const Version = 1
+
+// Root types for SignedRootVector.
+const (
+	LogRootType = "log"
+	MapRootType = "map"
+)
+
+var (
+	// treeSizes are the log tree sizes that vectors are generated for.
+	treeSizes = []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 15, 16, 17, 31, 32, 33, 64}
+	// negativeConsistencySize2 is the larger tree size of the consistency
+	// proofs that negative cases are derived from.
+	negativeConsistencySize2 = int64(17)
+	// mapKeyCount is the number of keys set in the sparse map.
+	mapKeyCount = 16
+	// rootTimestamp is the timestamp used for all signed roots.
+	rootTimestamp = int64(1500000000000000000)
+)
+
+// Corpus is the top level of the test vector corpus. Byte strings are
+// encoded as standard base64 in the JSON form of the corpus.
+type Corpus struct {
+	Version     int                 `json:"version"`
+	Hashers     []*HasherVectors    `json:"hashers"`
+	SignedRoots []*SignedRootVector `json:"signed_roots"`
+}
+
+// HasherVectors holds the vectors for one of the hashers in merkle.Factory.
+type HasherVectors struct {
+	HashType           string               `json:"hash_type"`
+	EmptyRoot          []byte               `json:"empty_root"`
+	Trees              []*Tree              `json:"trees"`
+	InclusionProofs    []*InclusionProof    `json:"inclusion_proofs"`
+	ConsistencyProofs  []*ConsistencyProof  `json:"consistency_proofs"`
+	MapInclusionProofs []*MapInclusionProof `json:"map_inclusion_proofs"`
+}
+
+// Tree is a log Merkle tree and the leaves it was built from.
+type Tree struct {
+	TreeSize   int64    `json:"tree_size"`
+	Leaves     [][]byte `json:"leaves"`
+	LeafHashes [][]byte `json:"leaf_hashes"`
+	Root       []byte   `json:"root"`
+}
+
+// InclusionProof is a log inclusion proof. The proof is ordered from the
+// sibling of the leaf up to the child of the root.
+type InclusionProof struct {
+	Description string   `json:"description"`
+	LeafIndex   int64    `json:"leaf_index"`
+	TreeSize    int64    `json:"tree_size"`
+	LeafHash    []byte   `json:"leaf_hash"`
+	Root        []byte   `json:"root"`
+	Proof       [][]byte `json:"proof"`
+	Valid       bool     `json:"valid"`
+}
+
+// ConsistencyProof is a log consistency proof between two tree sizes.
+type ConsistencyProof struct {
+	Description string   `json:"description"`
+	Size1       int64    `json:"size1"`
+	Size2       int64    `json:"size2"`
+	Root1       []byte   `json:"root1"`
+	Root2       []byte   `json:"root2"`
+	Proof       [][]byte `json:"proof"`
+	Valid       bool     `json:"valid"`
+}
+
+// MapInclusionProof is a sparse Merkle map inclusion proof. The index is the
+// SHA-256 hash of the key. The proof has one element per level, ordered from
+// the sibling of the leaf up to the child of the root, and an empty element
+// stands for the hash of an empty subtree at that level. Keys which are not
+// in the map have an empty leaf value.
+type MapInclusionProof struct {
+	Description string   `json:"description"`
+	Key         string   `json:"key"`
+	Index       []byte   `json:"index"`
+	LeafValue   []byte   `json:"leaf_value"`
+	LeafHash    []byte   `json:"leaf_hash"`
+	Root        []byte   `json:"root"`
+	Proof       [][]byte `json:"proof"`
+	Valid       bool     `json:"valid"`
+}
+
+// SignedRootVector is a signed log or map root. SignedData holds the
+// ObjectHash of the root, which is hashed again with HashAlgorithm and then
+// signed. Log roots are hashed from their RootHash, TimestampNanos and
+// TreeSize fields, see crypto.HashLogRoot, and map roots from MapRootJSON.
+type SignedRootVector struct {
+	Description        string `json:"description"`
+	RootType           string `json:"root_type"`
+	PublicKey          string `json:"public_key"`
+	SignatureAlgorithm string `json:"signature_algorithm"`
+	HashAlgorithm      string `json:"hash_algorithm"`
+	TreeSize           int64  `json:"tree_size,omitempty"`
+	TimestampNanos     int64  `json:"timestamp_nanos,omitempty,string"`
+	RootHash           []byte `json:"root_hash,omitempty"`
+	MapRootJSON        string `json:"map_root_json,omitempty"`
+	SignedData         []byte `json:"signed_data"`
+	Signature          []byte `json:"signature"`
+	Valid              bool   `json:"valid"`
+}
+
+// Generate builds a new corpus. The keys are fixed, but ECDSA signatures are
+// not deterministic, so the corpus differs between runs.
+func Generate() (*Corpus, error) {
+	c := &Corpus{Version: Version}
+	for _, hashType := range merkle.HashTypes() {
+		th, err := merkle.Factory(hashType)
+		if err != nil {
+			return nil, err
+		}
+		hv, err := hasherVectors(hashType, th)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", hashType, err)
+		}
+		c.Hashers = append(c.Hashers, hv)
+	}
+	roots, err := signedRootVectors()
+	if err != nil {
+		return nil, err
+	}
+	c.SignedRoots = roots
+	return c, nil
+}
+
+func hasherVectors(hashType string, th merkle.TreeHasher) (*HasherVectors, error) {
+	mt := merkle.NewInMemoryMerkleTree(th)
+	maxSize := treeSizes[len(treeSizes)-1]
+	var leaves, leafHashes [][]byte
+	for i := int64(0); i < maxSize; i++ {
+		leaf := []byte(fmt.Sprintf("leaf-%d", i))
+		mt.AddLeaf(leaf)
+		leaves = append(leaves, leaf)
+		leafHashes = append(leafHashes, th.HashLeaf(leaf))
+	}
+	root := func(size int64) []byte { return mt.RootAtSnapshot(size).Hash() }
+
+	hv := &HasherVectors{HashType: hashType, EmptyRoot: th.HashEmpty()}
+	for _, size := range treeSizes {
+		hv.Trees = append(hv.Trees, &Tree{
+			TreeSize:   size,
+			Leaves:     leaves[:size],
+			LeafHashes: leafHashes[:size],
+			Root:       root(size),
+		})
+
+		for index := int64(0); index < size; index++ {
+			p := &InclusionProof{
+				Description: "valid",
+				LeafIndex:   index,
+				TreeSize:    size,
+				LeafHash:    leafHashes[index],
+				Root:        root(size),
+				Proof:       proofHashes(mt.PathToRootAtSnapshot(index+1, size)),
+				Valid:       true,
+			}
+			hv.InclusionProofs = append(hv.InclusionProofs, p)
+			if index == 0 && size > 1 {
+				hv.InclusionProofs = append(hv.InclusionProofs, badInclusionProofs(p, leafHashes[1])...)
+			}
+		}
+	}
+
+	for i, size1 := range treeSizes {
+		for _, size2 := range treeSizes[i:] {
+			p := &ConsistencyProof{
+				Description: "valid",
+				Size1:       size1,
+				Size2:       size2,
+				Root1:       root(size1),
+				Root2:       root(size2),
+				Proof:       proofHashes(mt.SnapshotConsistency(size1, size2)),
+				Valid:       true,
+			}
+			hv.ConsistencyProofs = append(hv.ConsistencyProofs, p)
+			if size2 == negativeConsistencySize2 && size1 < size2 {
+				hv.ConsistencyProofs = append(hv.ConsistencyProofs, badConsistencyProofs(p)...)
+			}
+		}
+	}
+
+	mapProofs, err := mapInclusionProofs(th)
+	if err != nil {
+		return nil, err
+	}
+	hv.MapInclusionProofs = mapProofs
+	return hv, nil
+}
+
+// proofHashes converts a path from merkle.InMemoryMerkleTree to proof hashes.
+func proofHashes(path []merkle.TreeEntryDescriptor) [][]byte {
+	proof := make([][]byte, 0, len(path))
+	for _, node := range path {
+		proof = append(proof, node.Value.Hash())
+	}
+	return proof
+}
+
+func badInclusionProofs(p *InclusionProof, otherLeafHash []byte) []*InclusionProof {
+	var bad []*InclusionProof
+	add := func(desc string, modify func(b *InclusionProof)) {
+		b := *p
+		b.Description = desc
+		b.Proof = copyProof(p.Proof)
+		b.Valid = false
+		modify(&b)
+		bad = append(bad, &b)
+	}
+	add("wrong leaf index", func(b *InclusionProof) { b.LeafIndex = p.LeafIndex ^ 1 })
+	add("wrong leaf hash", func(b *InclusionProof) { b.LeafHash = otherLeafHash })
+	add("wrong root", func(b *InclusionProof) { b.Root = flipBit(p.Root) })
+	add("tampered proof node", func(b *InclusionProof) { b.Proof[0] = flipBit(p.Proof[0]) })
+	add("truncated proof", func(b *InclusionProof) { b.Proof = b.Proof[:len(b.Proof)-1] })
+	add("extra proof node", func(b *InclusionProof) { b.Proof = append(b.Proof, p.Root) })
+	return bad
+}
+
+func badConsistencyProofs(p *ConsistencyProof) []*ConsistencyProof {
+	var bad []*ConsistencyProof
+	add := func(desc string, modify func(b *ConsistencyProof)) {
+		b := *p
+		b.Description = desc
+		b.Proof = copyProof(p.Proof)
+		b.Valid = false
+		modify(&b)
+		bad = append(bad, &b)
+	}
+	add("wrong root1", func(b *ConsistencyProof) { b.Root1 = flipBit(p.Root1) })
+	add("wrong root2", func(b *ConsistencyProof) { b.Root2 = flipBit(p.Root2) })
+	add("tampered proof node", func(b *ConsistencyProof) {
+		last := len(b.Proof) - 1
+		b.Proof[last] = flipBit(p.Proof[last])
+	})
+	add("truncated proof", func(b *ConsistencyProof) { b.Proof = b.Proof[:len(b.Proof)-1] })
+	add("extra proof node", func(b *ConsistencyProof) { b.Proof = append(b.Proof, p.Root1) })
+	return bad
+}
+
+func mapInclusionProofs(th merkle.TreeHasher) ([]*MapInclusionProof, error) {
+	depth := th.Size() * 8
+	type entry struct {
+		key   string
+		index []byte
+		value []byte
+	}
+	var entries []entry
+	var leaves []merkle.HStar2LeafHash
+	for i := 0; i < mapKeyCount; i++ {
+		e := entry{
+			key:   fmt.Sprintf("key-%d", i),
+			value: []byte(fmt.Sprintf("value-%d", i)),
+		}
+		e.index = testonly.HashKey(e.key)
+		if got := len(e.index) * 8; got != depth {
+			return nil, fmt.Errorf("map index has %d bits, but the hasher needs %d", got, depth)
+		}
+		entries = append(entries, e)
+		leaves = append(leaves, merkle.HStar2LeafHash{
+			Index:    new(big.Int).SetBytes(e.index),
+			LeafHash: th.HashLeaf(e.value),
+		})
+	}
+	absentKey := "absent-key"
+	entries = append(entries, entry{key: absentKey, index: testonly.HashKey(absentKey), value: []byte{}})
+
+	hs := merkle.NewHStar2(th)
+	root, err := hs.HStar2Root(depth, append([]merkle.HStar2LeafHash(nil), leaves...))
+	if err != nil {
+		return nil, err
+	}
+
+	var proofs []*MapInclusionProof
+	for _, e := range entries {
+		proof, err := mapProof(th, leaves, new(big.Int).SetBytes(e.index))
+		if err != nil {
+			return nil, err
+		}
+		desc := "valid"
+		if e.key == absentKey {
+			desc = "valid, key not in map"
+		}
+		proofs = append(proofs, &MapInclusionProof{
+			Description: desc,
+			Key:         e.key,
+			Index:       e.index,
+			LeafValue:   e.value,
+			LeafHash:    th.HashLeaf(e.value),
+			Root:        root,
+			Proof:       proof,
+			Valid:       true,
+		})
+	}
+
+	p := proofs[0]
+	add := func(desc string, modify func(b *MapInclusionProof)) {
+		b := *p
+		b.Description = desc
+		b.Proof = copyProof(p.Proof)
+		b.Valid = false
+		modify(&b)
+		proofs = append(proofs, &b)
+	}
+	add("wrong leaf value", func(b *MapInclusionProof) {
+		b.LeafValue = entries[1].value
+		b.LeafHash = th.HashLeaf(b.LeafValue)
+	})
+	add("wrong index", func(b *MapInclusionProof) {
+		b.Key = entries[1].key
+		b.Index = entries[1].index
+	})
+	add("wrong root", func(b *MapInclusionProof) { b.Root = flipBit(p.Root) })
+	add("tampered proof node", func(b *MapInclusionProof) {
+		for i := len(b.Proof) - 1; i >= 0; i-- {
+			if len(b.Proof[i]) > 0 {
+				b.Proof[i] = flipBit(p.Proof[i])
+				break
+			}
+		}
+	})
+	add("non-empty proof node replaced by empty", func(b *MapInclusionProof) {
+		for i := len(b.Proof) - 1; i >= 0; i-- {
+			if len(b.Proof[i]) > 0 {
+				b.Proof[i] = []byte{}
+				break
+			}
+		}
+	})
+	add("truncated proof", func(b *MapInclusionProof) { b.Proof = b.Proof[:len(b.Proof)-1] })
+	return proofs, nil
+}
+
+// mapProof builds the inclusion proof for index in the sparse Merkle tree
+// containing leaves. At each height the proof holds the root of the sibling
+// subtree, or an empty element if that subtree is empty.
+func mapProof(th merkle.TreeHasher, leaves []merkle.HStar2LeafHash, index *big.Int) ([][]byte, error) {
+	hs := merkle.NewHStar2(th)
+	depth := th.Size() * 8
+	proof := make([][]byte, depth)
+	for height := 0; height < depth; height++ {
+		prefix := new(big.Int).Rsh(index, uint(height+1))
+		mask := new(big.Int).Lsh(big.NewInt(1), uint(height))
+		mask.Sub(mask, big.NewInt(1))
+
+		var sibling []merkle.HStar2LeafHash
+		for _, l := range leaves {
+			if l.Index.Bit(height) == index.Bit(height) || new(big.Int).Rsh(l.Index, uint(height+1)).Cmp(prefix) != 0 {
+				continue
+			}
+			sibling = append(sibling, merkle.HStar2LeafHash{
+				Index:    new(big.Int).And(l.Index, mask),
+				LeafHash: l.LeafHash,
+			})
+		}
+		if len(sibling) == 0 {
+			proof[height] = []byte{}
+			continue
+		}
+		h, err := hs.HStar2Nodes(height, 0, sibling,
+			func(int, *big.Int) ([]byte, error) { return nil, nil },
+			func(int, *big.Int, []byte) error { return nil })
+		if err != nil {
+			return nil, err
+		}
+		proof[height] = h
+	}
+	return proof, nil
+}
+
+func signedRootVectors() ([]*SignedRootVector, error) {
+	demoKey, err := keys.NewFromPrivatePEM(testonly.DemoPrivateKey, testonly.DemoPrivateKeyPass)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load demo key: %v", err)
+	}
+	rsaKey, err := keys.NewFromPrivatePEM(rsaPrivateKey, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load RSA key: %v", err)
+	}
+	otherKey, err := keys.NewFromPrivatePEM(otherPrivateKey, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load other key: %v", err)
+	}
+
+	rootHash := sha256.Sum256([]byte("test vector root"))
+	logRoot := trillian.SignedLogRoot{
+		TimestampNanos: rootTimestamp,
+		RootHash:       rootHash[:],
+		TreeSize:       64,
+	}
+	mapRoot := trillian.SignedMapRoot{
+		TimestampNanos: rootTimestamp,
+		RootHash:       rootHash[:],
+		MapId:          1,
+		MapRevision:    7,
+	}
+
+	var vectors []*SignedRootVector
+	for _, k := range []struct {
+		name   string
+		signer gocrypto.Signer
+	}{
+		{name: "ECDSA", signer: demoKey},
+		{name: "RSA", signer: rsaKey},
+	} {
+		signer := crypto.NewSigner(k.signer)
+		logSig, err := signer.SignLogRoot(logRoot)
+		if err != nil {
+			return nil, err
+		}
+		mapSig, err := signer.SignObject(mapRoot)
+		if err != nil {
+			return nil, err
+		}
+		pub := k.signer.Public()
+
+		add := func(v *SignedRootVector, err error) error {
+			if err != nil {
+				return err
+			}
+			vectors = append(vectors, v)
+			return nil
+		}
+		if err := add(logRootVector(k.name+" log root", pub, logRoot, logSig, true)); err != nil {
+			return nil, err
+		}
+		if err := add(mapRootVector(k.name+" map root", pub, mapRoot, mapSig, true)); err != nil {
+			return nil, err
+		}
+		if k.name != "ECDSA" {
+			continue
+		}
+
+		tampered := logRoot
+		tampered.TreeSize++
+		if err := add(logRootVector("log root with tampered tree size", pub, tampered, logSig, false)); err != nil {
+			return nil, err
+		}
+		tampered = logRoot
+		tampered.TimestampNanos++
+		if err := add(logRootVector("log root with tampered timestamp", pub, tampered, logSig, false)); err != nil {
+			return nil, err
+		}
+		tampered = logRoot
+		tampered.RootHash = flipBit(logRoot.RootHash)
+		if err := add(logRootVector("log root with tampered root hash", pub, tampered, logSig, false)); err != nil {
+			return nil, err
+		}
+		badSig := *logSig
+		badSig.Signature = flipBit(logSig.Signature)
+		if err := add(logRootVector("log root with tampered signature", pub, logRoot, &badSig, false)); err != nil {
+			return nil, err
+		}
+		if err := add(logRootVector("log root with wrong public key", otherKey.Public(), logRoot, logSig, false)); err != nil {
+			return nil, err
+		}
+		tamperedMap := mapRoot
+		tamperedMap.MapRevision++
+		if err := add(mapRootVector("map root with tampered revision", pub, tamperedMap, mapSig, false)); err != nil {
+			return nil, err
+		}
+		if err := add(mapRootVector("map root with wrong public key", otherKey.Public(), mapRoot, mapSig, false)); err != nil {
+			return nil, err
+		}
+	}
+	return vectors, nil
+}
+
+func logRootVector(desc string, pub gocrypto.PublicKey, root trillian.SignedLogRoot, sig *sigpb.DigitallySigned, valid bool) (*SignedRootVector, error) {
+	v, err := newSignedRootVector(desc, LogRootType, pub, sig, valid)
+	if err != nil {
+		return nil, err
+	}
+	v.TreeSize = root.TreeSize
+	v.TimestampNanos = root.TimestampNanos
+	v.RootHash = root.RootHash
+	v.SignedData = crypto.HashLogRoot(root)
+	return v, nil
+}
+
+func mapRootVector(desc string, pub gocrypto.PublicKey, root trillian.SignedMapRoot, sig *sigpb.DigitallySigned, valid bool) (*SignedRootVector, error) {
+	v, err := newSignedRootVector(desc, MapRootType, pub, sig, valid)
+	if err != nil {
+		return nil, err
+	}
+	root.Signature = nil
+	j, err := json.Marshal(root)
+	if err != nil {
+		return nil, err
+	}
+	v.MapRootJSON = string(j)
+	v.SignedData = objectHash(v.MapRootJSON)
+	return v, nil
+}
+
+func newSignedRootVector(desc, rootType string, pub gocrypto.PublicKey, sig *sigpb.DigitallySigned, valid bool) (*SignedRootVector, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public key: %v", err)
+	}
+	return &SignedRootVector{
+		Description:        desc,
+		RootType:           rootType,
+		PublicKey:          string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+		SignatureAlgorithm: sig.SignatureAlgorithm.String(),
+		HashAlgorithm:      sig.HashAlgorithm.String(),
+		Signature:          sig.Signature,
+		Valid:              valid,
+	}, nil
+}
+
+func copyProof(proof [][]byte) [][]byte {
+	c := make([][]byte, len(proof))
+	copy(c, proof)
+	return c
+}
+
+// flipBit returns a copy of b with its lowest bit inverted.
+func flipBit(b []byte) []byte {
+	c := append([]byte(nil), b...)
+	c[len(c)-1] ^= 1
+	return c
+}
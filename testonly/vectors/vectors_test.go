@@ -0,0 +1,106 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vectors
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/trillian/merkle"
+)
+
+func TestGenerate(t *testing.T) {
+	c, err := Generate()
+	if err != nil {
+		t.Fatalf("Generate(): %v", err)
+	}
+	if err := Verify(c); err != nil {
+		t.Fatalf("Verify(): %v", err)
+	}
+	if got, want := len(c.Hashers), len(merkle.HashTypes()); got != want {
+		t.Errorf("Generate() produced vectors for %d hashers, want %d", got, want)
+	}
+
+	// The corpus is consumed as JSON, so it must survive a round trip.
+	j, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("json.Marshal(): %v", err)
+	}
+	var decoded Corpus
+	if err := json.Unmarshal(j, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal(): %v", err)
+	}
+	if err := Verify(&decoded); err != nil {
+		t.Fatalf("Verify() after JSON round trip: %v", err)
+	}
+
+	for _, hv := range c.Hashers {
+		var valid, invalid int
+		for _, p := range hv.InclusionProofs {
+			count(p.Valid, &valid, &invalid)
+		}
+		for _, p := range hv.ConsistencyProofs {
+			count(p.Valid, &valid, &invalid)
+		}
+		for _, p := range hv.MapInclusionProofs {
+			count(p.Valid, &valid, &invalid)
+		}
+		if valid == 0 || invalid == 0 {
+			t.Errorf("%s: got %d valid and %d invalid proofs, want some of each", hv.HashType, valid, invalid)
+		}
+	}
+	var valid, invalid int
+	for _, v := range c.SignedRoots {
+		count(v.Valid, &valid, &invalid)
+	}
+	if valid == 0 || invalid == 0 {
+		t.Errorf("got %d valid and %d invalid signed roots, want some of each", valid, invalid)
+	}
+	// The keys are fixed, so the public keys must not change between runs.
+	again, err := Generate()
+	if err != nil {
+		t.Fatalf("Generate(): %v", err)
+	}
+	for i, v := range c.SignedRoots {
+		if got, want := again.SignedRoots[i].PublicKey, v.PublicKey; got != want {
+			t.Errorf("%s: public key changed between runs from %q to %q", v.Description, want, got)
+		}
+	}
+}
+
+func TestVerifyDetectsWrongExpectation(t *testing.T) {
+	c, err := Generate()
+	if err != nil {
+		t.Fatalf("Generate(): %v", err)
+	}
+	c.Hashers[0].InclusionProofs[0].Valid = false
+	if err := Verify(c); err == nil {
+		t.Error("Verify() succeeded with a valid proof marked invalid, want error")
+	}
+	c.Hashers[0].InclusionProofs[0].Valid = true
+
+	c.SignedRoots[0].Signature = flipBit(c.SignedRoots[0].Signature)
+	if err := Verify(c); err == nil {
+		t.Error("Verify() succeeded with a tampered signature marked valid, want error")
+	}
+}
+
+func count(valid bool, nValid, nInvalid *int) {
+	if valid {
+		*nValid++
+	} else {
+		*nInvalid++
+	}
+}
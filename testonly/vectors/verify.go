@@ -0,0 +1,149 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vectors
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/benlaurie/objecthash/go/objecthash"
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto"
+	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/crypto/sigpb"
+	"github.com/google/trillian/merkle"
+)
+
+// Verify checks every vector in c with Trillian's verifiers, and returns an
+// error if any valid vector fails verification or any invalid one passes.
+func Verify(c *Corpus) error {
+	if c.Version != Version {
+		return fmt.Errorf("corpus version %d, want %d", c.Version, Version)
+	}
+	for _, hv := range c.Hashers {
+		if err := verifyHasher(hv); err != nil {
+			return fmt.Errorf("%s: %v", hv.HashType, err)
+		}
+	}
+	for _, v := range c.SignedRoots {
+		if err := checkResult(v.Valid, verifySignedRoot(v)); err != nil {
+			return fmt.Errorf("signed root %q: %v", v.Description, err)
+		}
+	}
+	return nil
+}
+
+func verifyHasher(hv *HasherVectors) error {
+	th, err := merkle.Factory(hv.HashType)
+	if err != nil {
+		return err
+	}
+	if got := th.HashEmpty(); !bytes.Equal(got, hv.EmptyRoot) {
+		return fmt.Errorf("empty root %x, want %x", hv.EmptyRoot, got)
+	}
+	for _, tree := range hv.Trees {
+		mt := merkle.NewInMemoryMerkleTree(th)
+		for i, leaf := range tree.Leaves {
+			if got := th.HashLeaf(leaf); !bytes.Equal(got, tree.LeafHashes[i]) {
+				return fmt.Errorf("tree size %d: leaf hash %d is %x, want %x", tree.TreeSize, i, tree.LeafHashes[i], got)
+			}
+			mt.AddLeaf(leaf)
+		}
+		if got := mt.CurrentRoot().Hash(); mt.LeafCount() != tree.TreeSize || !bytes.Equal(got, tree.Root) {
+			return fmt.Errorf("tree size %d: root %x, want %x", tree.TreeSize, tree.Root, got)
+		}
+	}
+
+	v := merkle.NewLogVerifier(th)
+	for _, p := range hv.InclusionProofs {
+		err := v.VerifyInclusionProof(p.LeafIndex, p.TreeSize, p.Proof, p.Root, p.LeafHash)
+		if err := checkResult(p.Valid, err); err != nil {
+			return fmt.Errorf("inclusion proof %q for leaf %d in tree size %d: %v", p.Description, p.LeafIndex, p.TreeSize, err)
+		}
+	}
+	for _, p := range hv.ConsistencyProofs {
+		err := v.VerifyConsistencyProof(p.Size1, p.Size2, p.Root1, p.Root2, p.Proof)
+		if err := checkResult(p.Valid, err); err != nil {
+			return fmt.Errorf("consistency proof %q from %d to %d: %v", p.Description, p.Size1, p.Size2, err)
+		}
+	}
+
+	mh := merkle.NewMapHasher(th)
+	for _, p := range hv.MapInclusionProofs {
+		err := merkle.VerifyMapInclusionProof(p.Index, p.LeafHash, p.Root, p.Proof, mh)
+		if err == nil && !bytes.Equal(th.HashLeaf(p.LeafValue), p.LeafHash) {
+			err = errors.New("leaf hash does not match leaf value")
+		}
+		if err := checkResult(p.Valid, err); err != nil {
+			return fmt.Errorf("map inclusion proof %q for key %q: %v", p.Description, p.Key, err)
+		}
+	}
+	return nil
+}
+
+func verifySignedRoot(v *SignedRootVector) error {
+	pub, err := keys.NewFromPublicPEM(v.PublicKey)
+	if err != nil {
+		return err
+	}
+	sig := &sigpb.DigitallySigned{
+		SignatureAlgorithm: sigpb.DigitallySigned_SignatureAlgorithm(sigpb.DigitallySigned_SignatureAlgorithm_value[v.SignatureAlgorithm]),
+		HashAlgorithm:      sigpb.DigitallySigned_HashAlgorithm(sigpb.DigitallySigned_HashAlgorithm_value[v.HashAlgorithm]),
+		Signature:          v.Signature,
+	}
+
+	switch v.RootType {
+	case LogRootType:
+		root := trillian.SignedLogRoot{
+			TimestampNanos: v.TimestampNanos,
+			RootHash:       v.RootHash,
+			TreeSize:       v.TreeSize,
+		}
+		if got := crypto.HashLogRoot(root); !bytes.Equal(got, v.SignedData) {
+			return fmt.Errorf("signed data %x, want %x", v.SignedData, got)
+		}
+		return crypto.Verify(pub, v.SignedData, sig)
+	case MapRootType:
+		var root trillian.SignedMapRoot
+		if err := json.Unmarshal([]byte(v.MapRootJSON), &root); err != nil {
+			return err
+		}
+		if got := objectHash(v.MapRootJSON); !bytes.Equal(got, v.SignedData) {
+			return fmt.Errorf("signed data %x, want %x", v.SignedData, got)
+		}
+		return crypto.VerifyObject(pub, root, sig)
+	default:
+		return fmt.Errorf("unknown root type %q", v.RootType)
+	}
+}
+
+// checkResult compares the outcome of verifying a vector with its expected
+// validity.
+func checkResult(valid bool, err error) error {
+	switch {
+	case valid && err != nil:
+		return fmt.Errorf("verification failed for valid vector: %v", err)
+	case !valid && err == nil:
+		return errors.New("verification succeeded for invalid vector")
+	}
+	return nil
+}
+
+func objectHash(j string) []byte {
+	hash := objecthash.CommonJSONHash(j)
+	return hash[:]
+}
@@ -327,6 +327,9 @@ type SignedLogRoot struct {
 	Signature    *sigpb.DigitallySigned `protobuf:"bytes,4,opt,name=signature" json:"signature,omitempty"`
 	LogId        int64                  `protobuf:"varint,5,opt,name=log_id,json=logId" json:"log_id,omitempty"`
 	TreeRevision int64                  `protobuf:"varint,6,opt,name=tree_revision,json=treeRevision" json:"tree_revision,omitempty"`
+	// Signatures over the root by each of the keys which sign the log's roots,
+	// for logs which require more than one. This includes the signature above.
+	Signatures []*LogRootSignature `protobuf:"bytes,7,rep,name=signatures" json:"signatures,omitempty"`
 }
 
 func (m *SignedLogRoot) Reset()                    { *m = SignedLogRoot{} }
@@ -376,6 +379,39 @@ func (m *SignedLogRoot) GetTreeRevision() int64 {
 	return 0
 }
 
+func (m *SignedLogRoot) GetSignatures() []*LogRootSignature {
+	if m != nil {
+		return m.Signatures
+	}
+	return nil
+}
+
+// LogRootSignature is a signature over a log root by one of several keys.
+type LogRootSignature struct {
+	// Identifies the key, as the SHA-256 hash of its DER encoded public key.
+	KeyId     []byte                 `protobuf:"bytes,1,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"`
+	Signature *sigpb.DigitallySigned `protobuf:"bytes,2,opt,name=signature" json:"signature,omitempty"`
+}
+
+func (m *LogRootSignature) Reset()                    { *m = LogRootSignature{} }
+func (m *LogRootSignature) String() string            { return proto.CompactTextString(m) }
+func (*LogRootSignature) ProtoMessage()               {}
+func (*LogRootSignature) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{3} }
+
+func (m *LogRootSignature) GetKeyId() []byte {
+	if m != nil {
+		return m.KeyId
+	}
+	return nil
+}
+
+func (m *LogRootSignature) GetSignature() *sigpb.DigitallySigned {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
 type MapperMetadata struct {
 	SourceLogId                  []byte `protobuf:"bytes,1,opt,name=source_log_id,json=sourceLogId,proto3" json:"source_log_id,omitempty"`
 	HighestFullyCompletedSeq     int64  `protobuf:"varint,2,opt,name=highest_fully_completed_seq,json=highestFullyCompletedSeq" json:"highest_fully_completed_seq,omitempty"`
@@ -385,7 +421,7 @@ type MapperMetadata struct {
 func (m *MapperMetadata) Reset()                    { *m = MapperMetadata{} }
 func (m *MapperMetadata) String() string            { return proto.CompactTextString(m) }
 func (*MapperMetadata) ProtoMessage()               {}
-func (*MapperMetadata) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{3} }
+func (*MapperMetadata) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{4} }
 
 func (m *MapperMetadata) GetSourceLogId() []byte {
 	if m != nil {
@@ -422,7 +458,7 @@ type SignedMapRoot struct {
 func (m *SignedMapRoot) Reset()                    { *m = SignedMapRoot{} }
 func (m *SignedMapRoot) String() string            { return proto.CompactTextString(m) }
 func (*SignedMapRoot) ProtoMessage()               {}
-func (*SignedMapRoot) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{4} }
+func (*SignedMapRoot) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{5} }
 
 func (m *SignedMapRoot) GetTimestampNanos() int64 {
 	if m != nil {
@@ -478,7 +514,7 @@ type PEMKeyFile struct {
 func (m *PEMKeyFile) Reset()                    { *m = PEMKeyFile{} }
 func (m *PEMKeyFile) String() string            { return proto.CompactTextString(m) }
 func (*PEMKeyFile) ProtoMessage()               {}
-func (*PEMKeyFile) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{5} }
+func (*PEMKeyFile) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{6} }
 
 func (m *PEMKeyFile) GetPath() string {
 	if m != nil {
@@ -498,6 +534,7 @@ func init() {
 	proto.RegisterType((*Tree)(nil), "trillian.Tree")
 	proto.RegisterType((*SignedEntryTimestamp)(nil), "trillian.SignedEntryTimestamp")
 	proto.RegisterType((*SignedLogRoot)(nil), "trillian.SignedLogRoot")
+	proto.RegisterType((*LogRootSignature)(nil), "trillian.LogRootSignature")
 	proto.RegisterType((*MapperMetadata)(nil), "trillian.MapperMetadata")
 	proto.RegisterType((*SignedMapRoot)(nil), "trillian.SignedMapRoot")
 	proto.RegisterType((*PEMKeyFile)(nil), "trillian.PEMKeyFile")
@@ -510,66 +547,69 @@ func init() {
 func init() { proto.RegisterFile("trillian.proto", fileDescriptor3) }
 
 var fileDescriptor3 = []byte{
-	// 963 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x55, 0xcb, 0x6e, 0xdb, 0x46,
-	0x17, 0x0e, 0x7d, 0x91, 0xa5, 0x23, 0xd9, 0xe6, 0x3f, 0x89, 0xfd, 0xd3, 0x4e, 0xd0, 0xba, 0x6a,
-	0x81, 0xba, 0x5e, 0x48, 0x80, 0x9d, 0xa6, 0x28, 0xda, 0x2e, 0x54, 0x8b, 0x8e, 0x05, 0xeb, 0x06,
-	0x92, 0x69, 0x90, 0x6c, 0x06, 0x63, 0x72, 0x42, 0x0d, 0x4a, 0x72, 0x26, 0xe4, 0x28, 0x05, 0xf3,
-	0x0c, 0x7d, 0x9c, 0xae, 0xfa, 0x3c, 0x7d, 0x8b, 0x6e, 0x8a, 0x19, 0x5e, 0x24, 0x27, 0x69, 0x11,
-	0x14, 0xdd, 0x08, 0x73, 0xbe, 0xf3, 0x9d, 0x8f, 0xe7, 0x36, 0x23, 0xd8, 0x93, 0x29, 0x8b, 0x22,
-	0x46, 0x92, 0x9e, 0x48, 0xb9, 0xe4, 0xa8, 0x59, 0xd9, 0xc7, 0x17, 0x21, 0x93, 0x8b, 0xe5, 0x6d,
-	0xcf, 0xe7, 0x71, 0x3f, 0xe4, 0x3c, 0x8c, 0x68, 0xbf, 0xf2, 0xf5, 0xfd, 0x34, 0x17, 0x92, 0xf7,
-	0x33, 0x16, 0x8a, 0xdb, 0xe2, 0xb7, 0x08, 0x3f, 0x3e, 0x2a, 0x99, 0xda, 0xba, 0x5d, 0xbe, 0xea,
-	0x93, 0x24, 0x2f, 0x5c, 0xdd, 0xdf, 0xb6, 0x61, 0xcb, 0x4b, 0x29, 0x45, 0xff, 0x87, 0x1d, 0x99,
-	0x52, 0x8a, 0x59, 0x60, 0x19, 0x27, 0xc6, 0xe9, 0xa6, 0xd3, 0x50, 0xe6, 0x28, 0x40, 0xe7, 0x00,
-	0xda, 0x91, 0x49, 0x22, 0xa9, 0xb5, 0x71, 0x62, 0x9c, 0xee, 0x9d, 0xdf, 0xef, 0xd5, 0x09, 0xaa,
-	0x60, 0x57, 0xb9, 0x9c, 0x96, 0xac, 0x8e, 0xa8, 0x0f, 0xda, 0xc0, 0x32, 0x17, 0xd4, 0xda, 0xd4,
-	0x21, 0xe8, 0x6e, 0x88, 0x97, 0x0b, 0xea, 0x34, 0x65, 0x79, 0x42, 0xdf, 0xc1, 0xee, 0x82, 0x64,
-	0x0b, 0x9c, 0xc9, 0x94, 0x48, 0x1a, 0xe6, 0xd6, 0x96, 0x0e, 0x3a, 0x5c, 0x05, 0x5d, 0x93, 0x6c,
-	0xe1, 0x96, 0x5e, 0xa7, 0xb3, 0x58, 0xb3, 0xd0, 0x0d, 0xec, 0xe9, 0x60, 0x12, 0x85, 0x3c, 0x65,
-	0x72, 0x11, 0x5b, 0xdb, 0x3a, 0xfa, 0x8b, 0x5e, 0xd1, 0x84, 0x21, 0x0b, 0x99, 0x24, 0x51, 0x94,
-	0xbb, 0x2c, 0x4c, 0x68, 0xa0, 0xa5, 0x06, 0x15, 0xd7, 0xd1, 0x1f, 0xae, 0x4d, 0xf4, 0x12, 0xee,
-	0x67, 0x2c, 0x4c, 0x88, 0x5c, 0xa6, 0x74, 0x4d, 0xb1, 0xa1, 0x15, 0xbf, 0xfa, 0x1b, 0x45, 0xb7,
-	0x8a, 0x58, 0xc9, 0xa2, 0xec, 0x3d, 0x0c, 0x0d, 0xc1, 0x0c, 0x96, 0x22, 0x62, 0x3e, 0x91, 0x14,
-	0x0b, 0x1e, 0x31, 0x3f, 0xb7, 0x76, 0xb4, 0xf0, 0xd1, 0xaa, 0xd0, 0x61, 0xc5, 0x98, 0x6b, 0x82,
-	0xb3, 0x1f, 0xdc, 0x05, 0xd0, 0x67, 0xd0, 0x09, 0x58, 0x26, 0x22, 0x92, 0xe3, 0x84, 0xc4, 0xd4,
-	0x6a, 0x9e, 0x18, 0xa7, 0x2d, 0xa7, 0x5d, 0x62, 0x53, 0x12, 0x53, 0x74, 0x02, 0xed, 0x80, 0x66,
-	0x7e, 0xca, 0x84, 0x64, 0x3c, 0xb1, 0x5a, 0x25, 0x63, 0x05, 0xa1, 0x1f, 0xe1, 0x13, 0x3f, 0xa5,
-	0x2a, 0x0f, 0xc9, 0x62, 0x8a, 0x63, 0xf5, 0xf1, 0x0c, 0x67, 0x2c, 0xf1, 0x29, 0xa6, 0x82, 0xfb,
-	0x0b, 0x0b, 0xf4, 0x16, 0x1c, 0x17, 0x2c, 0x8f, 0xc5, 0x74, 0xa2, 0x39, 0xae, 0xa2, 0xd8, 0x8a,
-	0xa1, 0x34, 0x96, 0x22, 0xf8, 0x27, 0x8d, 0x76, 0xa1, 0x51, 0xb0, 0x3e, 0xa8, 0xf1, 0x35, 0xb4,
-	0x45, 0xca, 0xde, 0x28, 0x91, 0x9f, 0x69, 0x6e, 0x75, 0x4e, 0x8c, 0xd3, 0xf6, 0xf9, 0x83, 0x5e,
-	0xb1, 0xb0, 0xbd, 0x6a, 0x61, 0x7b, 0x83, 0x24, 0x77, 0xa0, 0x24, 0xde, 0xd0, 0xbc, 0xfb, 0xab,
-	0x01, 0x0f, 0x8a, 0xde, 0xdb, 0x89, 0x4c, 0x73, 0x25, 0x9d, 0x49, 0x12, 0x0b, 0xf4, 0x25, 0xec,
-	0xcb, 0xca, 0xc0, 0x09, 0x49, 0x78, 0x56, 0xae, 0xf3, 0x5e, 0x0d, 0x4f, 0x15, 0x8a, 0x0e, 0xa0,
-	0x11, 0xf1, 0x50, 0xad, 0xfb, 0x86, 0xf6, 0x6f, 0x47, 0x3c, 0x1c, 0x05, 0xe8, 0x31, 0xb4, 0xea,
-	0xc1, 0xe9, 0xcd, 0x6d, 0x9f, 0x1f, 0x7e, 0x78, 0xe8, 0xce, 0x8a, 0xd8, 0xfd, 0xc3, 0x80, 0xdd,
-	0x02, 0x1d, 0xf3, 0xd0, 0xe1, 0x5c, 0x7e, 0x7c, 0x1e, 0x0f, 0xa1, 0x95, 0x72, 0x2e, 0xb1, 0xda,
-	0x42, 0x9d, 0x4a, 0xc7, 0x69, 0x2a, 0x40, 0x2d, 0xa9, 0x72, 0x16, 0x77, 0x8f, 0xbd, 0x2d, 0xb2,
-	0xd9, 0x2c, 0xee, 0x8c, 0xcb, 0xde, 0xd2, 0xbb, 0xa9, 0x6e, 0x7d, 0x64, 0xaa, 0x6b, 0x75, 0x6f,
-	0xaf, 0xd7, 0xfd, 0x39, 0xec, 0xea, 0x2f, 0xa5, 0xf4, 0x0d, 0xcb, 0xd4, 0xce, 0x34, 0xb4, 0xb7,
-	0xa3, 0x40, 0xa7, 0xc4, 0xba, 0xbf, 0x1b, 0xb0, 0x37, 0x21, 0x42, 0xd0, 0x74, 0x42, 0x25, 0x09,
-	0x88, 0x24, 0xa8, 0x0b, 0xbb, 0x19, 0x5f, 0xa6, 0x3e, 0xc5, 0xa5, 0xaa, 0xa1, 0x4b, 0x68, 0x17,
-	0xe0, 0x58, 0x6b, 0xff, 0x00, 0x0f, 0x17, 0x2c, 0x5c, 0xd0, 0x4c, 0xe2, 0x57, 0xcb, 0x28, 0xca,
-	0xb1, 0xcf, 0x63, 0x11, 0x51, 0x49, 0x03, 0x9c, 0xd1, 0xd7, 0x65, 0xff, 0xad, 0x92, 0x72, 0xa5,
-	0x18, 0x97, 0x15, 0xc1, 0xa5, 0xaf, 0x91, 0x0d, 0x9f, 0x56, 0xe1, 0x82, 0xa4, 0x92, 0x91, 0xf7,
-	0x25, 0x8a, 0xd6, 0x3c, 0x2a, 0x69, 0xf3, 0x8a, 0xb5, 0x2e, 0xd3, 0xfd, 0xb3, 0x9e, 0xd1, 0x84,
-	0x88, 0xff, 0x70, 0x46, 0x8f, 0xa1, 0x19, 0x97, 0xdd, 0x28, 0x17, 0xc6, 0x5a, 0x5d, 0xe6, 0xbb,
-	0xdd, 0x72, 0x6a, 0xe6, 0xbf, 0x1f, 0x5e, 0x4c, 0xc4, 0xda, 0xf0, 0x62, 0x22, 0x46, 0x81, 0x7a,
-	0x11, 0x14, 0xfc, 0xce, 0xec, 0xda, 0x31, 0x11, 0xf5, 0xe8, 0xbe, 0x07, 0x98, 0xdb, 0x93, 0x1b,
-	0x9a, 0x5f, 0xb1, 0x88, 0x22, 0x04, 0x5b, 0x82, 0xc8, 0x85, 0x2e, 0xb7, 0xe5, 0xe8, 0x33, 0x3a,
-	0x86, 0xa6, 0x20, 0x59, 0xf6, 0x0b, 0x4f, 0x8b, 0x2b, 0xd1, 0x72, 0x6a, 0xfb, 0xec, 0x1b, 0xe8,
-	0xac, 0xbf, 0xbf, 0xe8, 0x08, 0x0e, 0x9e, 0x4d, 0x6f, 0xa6, 0xb3, 0xe7, 0x53, 0x7c, 0x3d, 0x70,
-	0xaf, 0xb1, 0xeb, 0x39, 0x03, 0xcf, 0x7e, 0xfa, 0xc2, 0xbc, 0x87, 0x3a, 0xd0, 0x74, 0xae, 0x2e,
-	0xf1, 0x93, 0x6f, 0x9f, 0x9c, 0x9b, 0xc6, 0x19, 0x86, 0x56, 0xfd, 0x07, 0x81, 0x0e, 0x01, 0x55,
-	0x51, 0x9e, 0x63, 0xdb, 0xd8, 0xf5, 0x06, 0x9e, 0x6d, 0xde, 0x43, 0x00, 0x8d, 0xc1, 0xa5, 0x37,
-	0xfa, 0xc9, 0x36, 0x0d, 0x75, 0xbe, 0x72, 0x66, 0x2f, 0xed, 0xa9, 0xb9, 0x81, 0x4c, 0xe8, 0xb8,
-	0xb3, 0x2b, 0x0f, 0x0f, 0xed, 0xb1, 0xed, 0xd9, 0x43, 0x73, 0x53, 0x21, 0xd7, 0x03, 0x67, 0x58,
-	0x23, 0x5b, 0x67, 0x17, 0xd0, 0xac, 0xfe, 0x4e, 0xd0, 0x01, 0xfc, 0xef, 0x8e, 0xbe, 0xf7, 0x62,
-	0xae, 0xe4, 0x77, 0x60, 0x73, 0x3c, 0x7b, 0x6a, 0x1a, 0xea, 0x30, 0x19, 0xcc, 0xcd, 0x8d, 0x33,
-	0x1f, 0xf6, 0xdf, 0x79, 0x65, 0xd1, 0x23, 0xb0, 0xaa, 0xd8, 0xe1, 0xb3, 0xf9, 0x78, 0x74, 0x39,
-	0xf0, 0x6c, 0x3c, 0x9f, 0x8d, 0x47, 0x97, 0xaa, 0xa8, 0x63, 0x38, 0xac, 0x51, 0x17, 0x4f, 0x67,
-	0x1e, 0x1e, 0x8c, 0xc7, 0xb3, 0xe7, 0xf6, 0xd0, 0x34, 0x54, 0x55, 0x6b, 0xbe, 0x0a, 0xdf, 0xb8,
-	0x6d, 0xe8, 0xc7, 0xeb, 0xe2, 0xaf, 0x00, 0x00, 0x00, 0xff, 0xff, 0x4b, 0x08, 0x07, 0x62, 0xcc,
-	0x07, 0x00, 0x00,
+	// 1010 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x55, 0xdf, 0x6e, 0xdb, 0xb6,
+	0x17, 0xae, 0xec, 0xc4, 0xb1, 0x8f, 0x9d, 0x54, 0x3f, 0xb6, 0xcd, 0x4f, 0x71, 0x8b, 0xcd, 0xf3,
+	0x06, 0x2c, 0xcb, 0x85, 0x0d, 0x38, 0x5d, 0x87, 0xfd, 0xbb, 0xf0, 0x62, 0xa5, 0x31, 0xe2, 0x7f,
+	0xa0, 0xd4, 0x15, 0xed, 0x0d, 0xc1, 0x58, 0xac, 0x4c, 0x44, 0xb2, 0x58, 0x89, 0xee, 0xa0, 0x3e,
+	0xc3, 0x1e, 0x62, 0x0f, 0xb1, 0xab, 0xbd, 0xda, 0x6e, 0x06, 0x52, 0x92, 0xed, 0xa4, 0xdd, 0x10,
+	0x0c, 0xbb, 0x31, 0x78, 0xbe, 0xf3, 0x9d, 0xcf, 0xe7, 0x90, 0x1f, 0x45, 0x38, 0x90, 0x31, 0x0f,
+	0x02, 0x4e, 0x97, 0x1d, 0x11, 0x47, 0x32, 0x42, 0xd5, 0x22, 0x6e, 0x9e, 0xfa, 0x5c, 0x2e, 0x56,
+	0x57, 0x9d, 0x79, 0x14, 0x76, 0xfd, 0x28, 0xf2, 0x03, 0xd6, 0x2d, 0x72, 0xdd, 0x79, 0x9c, 0x0a,
+	0x19, 0x75, 0x13, 0xee, 0x8b, 0xab, 0xec, 0x37, 0x2b, 0x6f, 0x1e, 0xe5, 0x4c, 0x1d, 0x5d, 0xad,
+	0xde, 0x74, 0xe9, 0x32, 0xcd, 0x52, 0xed, 0xdf, 0x77, 0x61, 0xc7, 0x8d, 0x19, 0x43, 0xff, 0x87,
+	0x3d, 0x19, 0x33, 0x46, 0xb8, 0x67, 0x19, 0x2d, 0xe3, 0xb8, 0x8c, 0x2b, 0x2a, 0x1c, 0x7a, 0xa8,
+	0x07, 0xa0, 0x13, 0x89, 0xa4, 0x92, 0x59, 0xa5, 0x96, 0x71, 0x7c, 0xd0, 0x7b, 0xd0, 0x59, 0x37,
+	0xa8, 0x8a, 0x1d, 0x95, 0xc2, 0x35, 0x59, 0x2c, 0x51, 0x17, 0x74, 0x40, 0x64, 0x2a, 0x98, 0x55,
+	0xd6, 0x25, 0xe8, 0x66, 0x89, 0x9b, 0x0a, 0x86, 0xab, 0x32, 0x5f, 0xa1, 0xef, 0x61, 0x7f, 0x41,
+	0x93, 0x05, 0x49, 0x64, 0x4c, 0x25, 0xf3, 0x53, 0x6b, 0x47, 0x17, 0x1d, 0x6e, 0x8a, 0x2e, 0x68,
+	0xb2, 0x70, 0xf2, 0x2c, 0x6e, 0x2c, 0xb6, 0x22, 0x74, 0x09, 0x07, 0xba, 0x98, 0x06, 0x7e, 0x14,
+	0x73, 0xb9, 0x08, 0xad, 0x5d, 0x5d, 0xfd, 0x45, 0x27, 0xdb, 0x84, 0x01, 0xf7, 0xb9, 0xa4, 0x41,
+	0x90, 0x3a, 0xdc, 0x5f, 0x32, 0x4f, 0x4b, 0xf5, 0x0b, 0x2e, 0xde, 0x5f, 0x6c, 0x87, 0xe8, 0x35,
+	0x3c, 0x48, 0xb8, 0xbf, 0xa4, 0x72, 0x15, 0xb3, 0x2d, 0xc5, 0x8a, 0x56, 0xfc, 0xea, 0x6f, 0x14,
+	0x9d, 0xa2, 0x62, 0x23, 0x8b, 0x92, 0x0f, 0x30, 0x34, 0x00, 0xd3, 0x5b, 0x89, 0x80, 0xcf, 0xa9,
+	0x64, 0x44, 0x44, 0x01, 0x9f, 0xa7, 0xd6, 0x9e, 0x16, 0x3e, 0xda, 0x0c, 0x3a, 0x28, 0x18, 0x33,
+	0x4d, 0xc0, 0xf7, 0xbd, 0x9b, 0x00, 0xfa, 0x0c, 0x1a, 0x1e, 0x4f, 0x44, 0x40, 0x53, 0xb2, 0xa4,
+	0x21, 0xb3, 0xaa, 0x2d, 0xe3, 0xb8, 0x86, 0xeb, 0x39, 0x36, 0xa1, 0x21, 0x43, 0x2d, 0xa8, 0x7b,
+	0x2c, 0x99, 0xc7, 0x5c, 0x48, 0x1e, 0x2d, 0xad, 0x5a, 0xce, 0xd8, 0x40, 0xe8, 0x27, 0xf8, 0x64,
+	0x1e, 0x33, 0xd5, 0x87, 0xe4, 0x21, 0x23, 0xa1, 0xfa, 0xf3, 0x84, 0x24, 0x7c, 0x39, 0x67, 0x84,
+	0x89, 0x68, 0xbe, 0xb0, 0x40, 0xbb, 0xa0, 0x99, 0xb1, 0x5c, 0x1e, 0xb2, 0xb1, 0xe6, 0x38, 0x8a,
+	0x62, 0x2b, 0x86, 0xd2, 0x58, 0x09, 0xef, 0x9f, 0x34, 0xea, 0x99, 0x46, 0xc6, 0xfa, 0xa8, 0xc6,
+	0xd7, 0x50, 0x17, 0x31, 0x7f, 0xa7, 0x44, 0xae, 0x59, 0x6a, 0x35, 0x5a, 0xc6, 0x71, 0xbd, 0xf7,
+	0xb0, 0x93, 0x19, 0xb6, 0x53, 0x18, 0xb6, 0xd3, 0x5f, 0xa6, 0x18, 0x72, 0xe2, 0x25, 0x4b, 0xdb,
+	0xbf, 0x1a, 0xf0, 0x30, 0xdb, 0x7b, 0x7b, 0x29, 0xe3, 0x54, 0x49, 0x27, 0x92, 0x86, 0x02, 0x7d,
+	0x09, 0xf7, 0x65, 0x11, 0x90, 0x25, 0x5d, 0x46, 0x49, 0x6e, 0xe7, 0x83, 0x35, 0x3c, 0x51, 0x28,
+	0x7a, 0x04, 0x95, 0x20, 0xf2, 0x95, 0xdd, 0x4b, 0x3a, 0xbf, 0x1b, 0x44, 0xfe, 0xd0, 0x43, 0x4f,
+	0xa1, 0xb6, 0x3e, 0x38, 0xed, 0xdc, 0x7a, 0xef, 0xf0, 0xe3, 0x87, 0x8e, 0x37, 0xc4, 0xf6, 0x6f,
+	0x25, 0xd8, 0xcf, 0xd0, 0x51, 0xe4, 0xe3, 0x28, 0x92, 0x77, 0xef, 0xe3, 0x31, 0xd4, 0xe2, 0x28,
+	0x92, 0x44, 0xb9, 0x50, 0xb7, 0xd2, 0xc0, 0x55, 0x05, 0x28, 0x93, 0xaa, 0x64, 0x76, 0xf7, 0xf8,
+	0xfb, 0xac, 0x9b, 0x72, 0x76, 0x67, 0x1c, 0xfe, 0x9e, 0xdd, 0x6c, 0x75, 0xe7, 0x8e, 0xad, 0x6e,
+	0xcd, 0xbd, 0xbb, 0x3d, 0xf7, 0xe7, 0xb0, 0xaf, 0xff, 0x29, 0x66, 0xef, 0x78, 0xa2, 0x3c, 0x53,
+	0xd1, 0xd9, 0x86, 0x02, 0x71, 0x8e, 0xa1, 0xef, 0x00, 0xd6, 0x42, 0x89, 0xb5, 0xd7, 0x2a, 0x1f,
+	0xd7, 0x7b, 0xcd, 0x8d, 0x73, 0xf3, 0xd9, 0xd7, 0x97, 0x01, 0x6f, 0xb1, 0xdb, 0x04, 0xcc, 0xdb,
+	0x79, 0xd5, 0xcb, 0x35, 0x4b, 0x8b, 0x4f, 0x4e, 0x03, 0xef, 0x5e, 0xb3, 0xf4, 0xf6, 0x19, 0x94,
+	0xee, 0x7a, 0x06, 0x7f, 0x18, 0x70, 0x30, 0xa6, 0x42, 0xb0, 0x78, 0xcc, 0x24, 0xf5, 0xa8, 0xa4,
+	0xa8, 0x0d, 0xfb, 0x49, 0xb4, 0x8a, 0xe7, 0x8c, 0xe4, 0x23, 0x67, 0x7f, 0x53, 0xcf, 0xc0, 0x91,
+	0x1e, 0xfc, 0x47, 0x78, 0xbc, 0xe0, 0xfe, 0x82, 0x25, 0x92, 0xbc, 0x59, 0x05, 0x41, 0x4a, 0xe6,
+	0x51, 0x28, 0x02, 0x26, 0x99, 0x47, 0x12, 0xf6, 0x36, 0x37, 0x87, 0x95, 0x53, 0xce, 0x15, 0xe3,
+	0xac, 0x20, 0x38, 0xec, 0x2d, 0xb2, 0xe1, 0xd3, 0xa2, 0x5c, 0xd0, 0x58, 0x72, 0xfa, 0xa1, 0x44,
+	0x76, 0x6e, 0x4f, 0x72, 0xda, 0xac, 0x60, 0x6d, 0xcb, 0xb4, 0xff, 0x34, 0x0a, 0x03, 0x8d, 0xa9,
+	0xf8, 0x0f, 0x0d, 0xf4, 0x14, 0xaa, 0x61, 0xbe, 0x1b, 0xb9, 0x9b, 0xad, 0xcd, 0x79, 0xdd, 0xdc,
+	0x2d, 0xbc, 0x66, 0xfe, 0x7b, 0x67, 0x85, 0x54, 0x6c, 0x39, 0x2b, 0xa4, 0x62, 0xe8, 0xa9, 0xcf,
+	0x95, 0x82, 0x6f, 0x19, 0xab, 0x1e, 0x52, 0x51, 0xf8, 0xaa, 0xfd, 0x03, 0xc0, 0xcc, 0x1e, 0x5f,
+	0xb2, 0xf4, 0x9c, 0x07, 0x0c, 0x21, 0xd8, 0x11, 0x54, 0x2e, 0xf4, 0xb8, 0x35, 0xac, 0xd7, 0xa8,
+	0x09, 0x55, 0x41, 0x93, 0xe4, 0x97, 0x28, 0xce, 0xee, 0x6b, 0x0d, 0xaf, 0xe3, 0x93, 0x6f, 0xa0,
+	0xb1, 0xfd, 0x38, 0xa0, 0x23, 0x78, 0xf4, 0x62, 0x72, 0x39, 0x99, 0xbe, 0x9c, 0x90, 0x8b, 0xbe,
+	0x73, 0x41, 0x1c, 0x17, 0xf7, 0x5d, 0xfb, 0xf9, 0x2b, 0xf3, 0x1e, 0x6a, 0x40, 0x15, 0x9f, 0x9f,
+	0x91, 0x67, 0xdf, 0x3e, 0xeb, 0x99, 0xc6, 0x09, 0x81, 0xda, 0xfa, 0xf5, 0x42, 0x87, 0x80, 0x8a,
+	0x2a, 0x17, 0xdb, 0x36, 0x71, 0xdc, 0xbe, 0x6b, 0x9b, 0xf7, 0x10, 0x40, 0xa5, 0x7f, 0xe6, 0x0e,
+	0x7f, 0xb6, 0x4d, 0x43, 0xad, 0xcf, 0xf1, 0xf4, 0xb5, 0x3d, 0x31, 0x4b, 0xc8, 0x84, 0x86, 0x33,
+	0x3d, 0x77, 0xc9, 0xc0, 0x1e, 0xd9, 0xae, 0x3d, 0x30, 0xcb, 0x0a, 0xb9, 0xe8, 0xe3, 0xc1, 0x1a,
+	0xd9, 0x39, 0x39, 0x85, 0x6a, 0xf1, 0xd6, 0xa1, 0x47, 0xf0, 0xbf, 0x1b, 0xfa, 0xee, 0xab, 0x99,
+	0x92, 0xdf, 0x83, 0xf2, 0x68, 0xfa, 0xdc, 0x34, 0xd4, 0x62, 0xdc, 0x9f, 0x99, 0xa5, 0x93, 0x39,
+	0xdc, 0xbf, 0xf5, 0x04, 0xa0, 0x27, 0x60, 0x15, 0xb5, 0x83, 0x17, 0xb3, 0xd1, 0xf0, 0xac, 0xef,
+	0xda, 0x64, 0x36, 0x1d, 0x0d, 0xcf, 0xd4, 0x50, 0x4d, 0x38, 0x5c, 0xa3, 0x0e, 0x99, 0x4c, 0x5d,
+	0xd2, 0x1f, 0x8d, 0xa6, 0x2f, 0xed, 0x81, 0x69, 0xa8, 0xa9, 0xb6, 0x72, 0x05, 0x5e, 0xba, 0xaa,
+	0xe8, 0x2f, 0xeb, 0xe9, 0x5f, 0x01, 0x00, 0x00, 0xff, 0xff, 0x66, 0x25, 0x62, 0x50, 0x69, 0x08,
+	0x00, 0x00,
 }
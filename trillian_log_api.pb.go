@@ -30,18 +30,32 @@ It has these top-level messages:
 	GetLeavesByHashResponse
 	GetLeavesByIndexRequest
 	GetLeavesByIndexResponse
+	GetLeafSubmittersRequest
+	GetLeafSubmittersResponse
 	GetSequencedLeafCountRequest
 	GetSequencedLeafCountResponse
 	GetLatestSignedLogRootRequest
 	GetLatestSignedLogRootResponse
 	GetEntryAndProofRequest
 	GetEntryAndProofResponse
+	DeadLetterLeaf
+	ListDeadLetterLeavesRequest
+	ListDeadLetterLeavesResponse
+	RequeueDeadLetterLeavesRequest
+	RequeueDeadLetterLeavesResponse
+	DiscardDeadLetterLeavesRequest
+	DiscardDeadLetterLeavesResponse
+	WaitForInclusionRequest
+	WaitForInclusionResponse
 	MapLeaf
 	MapLeafInclusion
 	GetMapLeavesRequest
 	GetMapLeavesResponse
 	SetMapLeavesRequest
 	SetMapLeavesResponse
+	GetMapLeafHistoryRequest
+	MapLeafHistoryEntry
+	GetMapLeafHistoryResponse
 	GetSignedMapRootRequest
 	GetSignedMapRootResponse
 	ListTreesRequest
@@ -53,6 +67,7 @@ It has these top-level messages:
 	Tree
 	SignedEntryTimestamp
 	SignedLogRoot
+	LogRootSignature
 	MapperMetadata
 	SignedMapRoot
 	PEMKeyFile
@@ -80,6 +95,36 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
+// LeafPriority is the priority class of queued leaves. Each class has its own
+// lane in the queue, and the sequencer integrates leaves from the lanes of
+// higher priority classes first.
+type LeafPriority int32
+
+const (
+	// PRIORITY_NORMAL is the default priority class.
+	LeafPriority_PRIORITY_NORMAL LeafPriority = 0
+	// PRIORITY_HIGH leaves are integrated before all others.
+	LeafPriority_PRIORITY_HIGH LeafPriority = 1
+	// PRIORITY_BULK leaves are integrated after all others, e.g. for backfills.
+	LeafPriority_PRIORITY_BULK LeafPriority = 2
+)
+
+var LeafPriority_name = map[int32]string{
+	0: "PRIORITY_NORMAL",
+	1: "PRIORITY_HIGH",
+	2: "PRIORITY_BULK",
+}
+var LeafPriority_value = map[string]int32{
+	"PRIORITY_NORMAL": 0,
+	"PRIORITY_HIGH":   1,
+	"PRIORITY_BULK":   2,
+}
+
+func (x LeafPriority) String() string {
+	return proto.EnumName(LeafPriority_name, int32(x))
+}
+func (LeafPriority) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
 type LogLeaf struct {
 	// merkle_leaf_hash is over leaf data and optional extra_data.
 	MerkleLeafHash []byte `protobuf:"bytes,1,opt,name=merkle_leaf_hash,json=merkleLeafHash,proto3" json:"merkle_leaf_hash,omitempty"`
@@ -107,6 +152,17 @@ type LogLeaf struct {
 	// personality which fetches and submits the entries might set
 	// leaf_identity_hash to H(seq||certdata).
 	LeafIdentityHash []byte `protobuf:"bytes,5,opt,name=leaf_identity_hash,json=leafIdentityHash,proto3" json:"leaf_identity_hash,omitempty"`
+	// queue_timestamp_nanos is the time at which the leaf was queued, in
+	// nanoseconds since the epoch. It's set by storage on dequeued leaves.
+	QueueTimestampNanos int64 `protobuf:"varint,6,opt,name=queue_timestamp_nanos,json=queueTimestampNanos" json:"queue_timestamp_nanos,omitempty"`
+	// submitter identifies the authenticated client which queued the leaf.
+	// It's set by the log server, is not covered by merkle_leaf_hash, and is
+	// only returned by the GetLeafSubmitters admin RPC.
+	Submitter string `protobuf:"bytes,7,opt,name=submitter" json:"submitter,omitempty"`
+	// queue_priority is the priority class the leaf was queued with. It's set
+	// by the log server from the queueing request, and by storage on dequeued
+	// leaves.
+	QueuePriority LeafPriority `protobuf:"varint,8,opt,name=queue_priority,json=queuePriority,enum=trillian.LeafPriority" json:"queue_priority,omitempty"`
 }
 
 func (m *LogLeaf) Reset()                    { *m = LogLeaf{} }
@@ -149,6 +205,27 @@ func (m *LogLeaf) GetLeafIdentityHash() []byte {
 	return nil
 }
 
+func (m *LogLeaf) GetQueueTimestampNanos() int64 {
+	if m != nil {
+		return m.QueueTimestampNanos
+	}
+	return 0
+}
+
+func (m *LogLeaf) GetSubmitter() string {
+	if m != nil {
+		return m.Submitter
+	}
+	return ""
+}
+
+func (m *LogLeaf) GetQueuePriority() LeafPriority {
+	if m != nil {
+		return m.QueuePriority
+	}
+	return LeafPriority_PRIORITY_NORMAL
+}
+
 type Node struct {
 	// TODO(Martin2112): remove node_id and node_revision
 	NodeId       []byte `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
@@ -238,6 +315,8 @@ func (m *QueuedLogLeaf) GetStatus() *google_rpc.Status {
 type QueueLeavesRequest struct {
 	LogId  int64      `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
 	Leaves []*LogLeaf `protobuf:"bytes,2,rep,name=leaves" json:"leaves,omitempty"`
+	// priority is the priority class the leaves are queued with.
+	Priority LeafPriority `protobuf:"varint,3,opt,name=priority,enum=trillian.LeafPriority" json:"priority,omitempty"`
 }
 
 func (m *QueueLeavesRequest) Reset()                    { *m = QueueLeavesRequest{} }
@@ -259,9 +338,18 @@ func (m *QueueLeavesRequest) GetLeaves() []*LogLeaf {
 	return nil
 }
 
+func (m *QueueLeavesRequest) GetPriority() LeafPriority {
+	if m != nil {
+		return m.Priority
+	}
+	return LeafPriority_PRIORITY_NORMAL
+}
+
 type QueueLeafRequest struct {
 	LogId int64    `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
 	Leaf  *LogLeaf `protobuf:"bytes,2,opt,name=leaf" json:"leaf,omitempty"`
+	// priority is the priority class the leaf is queued with.
+	Priority LeafPriority `protobuf:"varint,3,opt,name=priority,enum=trillian.LeafPriority" json:"priority,omitempty"`
 }
 
 func (m *QueueLeafRequest) Reset()                    { *m = QueueLeafRequest{} }
@@ -283,6 +371,13 @@ func (m *QueueLeafRequest) GetLeaf() *LogLeaf {
 	return nil
 }
 
+func (m *QueueLeafRequest) GetPriority() LeafPriority {
+	if m != nil {
+		return m.Priority
+	}
+	return LeafPriority_PRIORITY_NORMAL
+}
+
 type QueueLeafResponse struct {
 	QueuedLeaf *QueuedLogLeaf `protobuf:"bytes,2,opt,name=queued_leaf,json=queuedLeaf" json:"queued_leaf,omitempty"`
 }
@@ -563,6 +658,47 @@ func (m *GetLeavesByIndexResponse) GetLeaves() []*LogLeaf {
 	return nil
 }
 
+type GetLeafSubmittersRequest struct {
+	LogId     int64   `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
+	LeafIndex []int64 `protobuf:"varint,2,rep,packed,name=leaf_index,json=leafIndex" json:"leaf_index,omitempty"`
+}
+
+func (m *GetLeafSubmittersRequest) Reset()                    { *m = GetLeafSubmittersRequest{} }
+func (m *GetLeafSubmittersRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetLeafSubmittersRequest) ProtoMessage()               {}
+func (*GetLeafSubmittersRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *GetLeafSubmittersRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *GetLeafSubmittersRequest) GetLeafIndex() []int64 {
+	if m != nil {
+		return m.LeafIndex
+	}
+	return nil
+}
+
+type GetLeafSubmittersResponse struct {
+	// leaves holds the requested leaves, with their submitter set.
+	Leaves []*LogLeaf `protobuf:"bytes,1,rep,name=leaves" json:"leaves,omitempty"`
+}
+
+func (m *GetLeafSubmittersResponse) Reset()                    { *m = GetLeafSubmittersResponse{} }
+func (m *GetLeafSubmittersResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetLeafSubmittersResponse) ProtoMessage()               {}
+func (*GetLeafSubmittersResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+func (m *GetLeafSubmittersResponse) GetLeaves() []*LogLeaf {
+	if m != nil {
+		return m.Leaves
+	}
+	return nil
+}
+
 type GetSequencedLeafCountRequest struct {
 	LogId int64 `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
 }
@@ -570,7 +706,7 @@ type GetSequencedLeafCountRequest struct {
 func (m *GetSequencedLeafCountRequest) Reset()                    { *m = GetSequencedLeafCountRequest{} }
 func (m *GetSequencedLeafCountRequest) String() string            { return proto.CompactTextString(m) }
 func (*GetSequencedLeafCountRequest) ProtoMessage()               {}
-func (*GetSequencedLeafCountRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+func (*GetSequencedLeafCountRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
 
 func (m *GetSequencedLeafCountRequest) GetLogId() int64 {
 	if m != nil {
@@ -586,7 +722,7 @@ type GetSequencedLeafCountResponse struct {
 func (m *GetSequencedLeafCountResponse) Reset()                    { *m = GetSequencedLeafCountResponse{} }
 func (m *GetSequencedLeafCountResponse) String() string            { return proto.CompactTextString(m) }
 func (*GetSequencedLeafCountResponse) ProtoMessage()               {}
-func (*GetSequencedLeafCountResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+func (*GetSequencedLeafCountResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
 
 func (m *GetSequencedLeafCountResponse) GetLeafCount() int64 {
 	if m != nil {
@@ -602,7 +738,7 @@ type GetLatestSignedLogRootRequest struct {
 func (m *GetLatestSignedLogRootRequest) Reset()                    { *m = GetLatestSignedLogRootRequest{} }
 func (m *GetLatestSignedLogRootRequest) String() string            { return proto.CompactTextString(m) }
 func (*GetLatestSignedLogRootRequest) ProtoMessage()               {}
-func (*GetLatestSignedLogRootRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+func (*GetLatestSignedLogRootRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
 
 func (m *GetLatestSignedLogRootRequest) GetLogId() int64 {
 	if m != nil {
@@ -618,7 +754,7 @@ type GetLatestSignedLogRootResponse struct {
 func (m *GetLatestSignedLogRootResponse) Reset()                    { *m = GetLatestSignedLogRootResponse{} }
 func (m *GetLatestSignedLogRootResponse) String() string            { return proto.CompactTextString(m) }
 func (*GetLatestSignedLogRootResponse) ProtoMessage()               {}
-func (*GetLatestSignedLogRootResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+func (*GetLatestSignedLogRootResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
 
 func (m *GetLatestSignedLogRootResponse) GetSignedLogRoot() *SignedLogRoot {
 	if m != nil {
@@ -636,7 +772,7 @@ type GetEntryAndProofRequest struct {
 func (m *GetEntryAndProofRequest) Reset()                    { *m = GetEntryAndProofRequest{} }
 func (m *GetEntryAndProofRequest) String() string            { return proto.CompactTextString(m) }
 func (*GetEntryAndProofRequest) ProtoMessage()               {}
-func (*GetEntryAndProofRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+func (*GetEntryAndProofRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
 
 func (m *GetEntryAndProofRequest) GetLogId() int64 {
 	if m != nil {
@@ -667,7 +803,7 @@ type GetEntryAndProofResponse struct {
 func (m *GetEntryAndProofResponse) Reset()                    { *m = GetEntryAndProofResponse{} }
 func (m *GetEntryAndProofResponse) String() string            { return proto.CompactTextString(m) }
 func (*GetEntryAndProofResponse) ProtoMessage()               {}
-func (*GetEntryAndProofResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
+func (*GetEntryAndProofResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{25} }
 
 func (m *GetEntryAndProofResponse) GetProof() *Proof {
 	if m != nil {
@@ -683,6 +819,225 @@ func (m *GetEntryAndProofResponse) GetLeaf() *LogLeaf {
 	return nil
 }
 
+// DeadLetterLeaf is a queued leaf which the sequencer failed to integrate, and
+// set aside so that it doesn't stall the log.
+type DeadLetterLeaf struct {
+	// leaf is the queued leaf, with its submitter set.
+	Leaf *LogLeaf `protobuf:"bytes,1,opt,name=leaf" json:"leaf,omitempty"`
+	// error describes why the leaf couldn't be integrated.
+	Error string `protobuf:"bytes,2,opt,name=error" json:"error,omitempty"`
+	// dead_letter_timestamp_nanos is the time at which the leaf was set aside,
+	// in nanoseconds since the epoch.
+	DeadLetterTimestampNanos int64 `protobuf:"varint,3,opt,name=dead_letter_timestamp_nanos,json=deadLetterTimestampNanos" json:"dead_letter_timestamp_nanos,omitempty"`
+}
+
+func (m *DeadLetterLeaf) Reset()                    { *m = DeadLetterLeaf{} }
+func (m *DeadLetterLeaf) String() string            { return proto.CompactTextString(m) }
+func (*DeadLetterLeaf) ProtoMessage()               {}
+func (*DeadLetterLeaf) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{26} }
+
+func (m *DeadLetterLeaf) GetLeaf() *LogLeaf {
+	if m != nil {
+		return m.Leaf
+	}
+	return nil
+}
+
+func (m *DeadLetterLeaf) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *DeadLetterLeaf) GetDeadLetterTimestampNanos() int64 {
+	if m != nil {
+		return m.DeadLetterTimestampNanos
+	}
+	return 0
+}
+
+type ListDeadLetterLeavesRequest struct {
+	LogId int64 `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
+	// max_leaves is the most leaves to return, or zero for the default.
+	MaxLeaves int32 `protobuf:"varint,2,opt,name=max_leaves,json=maxLeaves" json:"max_leaves,omitempty"`
+}
+
+func (m *ListDeadLetterLeavesRequest) Reset()                    { *m = ListDeadLetterLeavesRequest{} }
+func (m *ListDeadLetterLeavesRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListDeadLetterLeavesRequest) ProtoMessage()               {}
+func (*ListDeadLetterLeavesRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{27} }
+
+func (m *ListDeadLetterLeavesRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *ListDeadLetterLeavesRequest) GetMaxLeaves() int32 {
+	if m != nil {
+		return m.MaxLeaves
+	}
+	return 0
+}
+
+type ListDeadLetterLeavesResponse struct {
+	// leaves holds the dead-lettered leaves, oldest first.
+	Leaves []*DeadLetterLeaf `protobuf:"bytes,1,rep,name=leaves" json:"leaves,omitempty"`
+}
+
+func (m *ListDeadLetterLeavesResponse) Reset()                    { *m = ListDeadLetterLeavesResponse{} }
+func (m *ListDeadLetterLeavesResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListDeadLetterLeavesResponse) ProtoMessage()               {}
+func (*ListDeadLetterLeavesResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{28} }
+
+func (m *ListDeadLetterLeavesResponse) GetLeaves() []*DeadLetterLeaf {
+	if m != nil {
+		return m.Leaves
+	}
+	return nil
+}
+
+type RequeueDeadLetterLeavesRequest struct {
+	LogId            int64    `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
+	LeafIdentityHash [][]byte `protobuf:"bytes,2,rep,name=leaf_identity_hash,json=leafIdentityHash,proto3" json:"leaf_identity_hash,omitempty"`
+}
+
+func (m *RequeueDeadLetterLeavesRequest) Reset()                    { *m = RequeueDeadLetterLeavesRequest{} }
+func (m *RequeueDeadLetterLeavesRequest) String() string            { return proto.CompactTextString(m) }
+func (*RequeueDeadLetterLeavesRequest) ProtoMessage()               {}
+func (*RequeueDeadLetterLeavesRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{29} }
+
+func (m *RequeueDeadLetterLeavesRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *RequeueDeadLetterLeavesRequest) GetLeafIdentityHash() [][]byte {
+	if m != nil {
+		return m.LeafIdentityHash
+	}
+	return nil
+}
+
+type RequeueDeadLetterLeavesResponse struct {
+}
+
+func (m *RequeueDeadLetterLeavesResponse) Reset()         { *m = RequeueDeadLetterLeavesResponse{} }
+func (m *RequeueDeadLetterLeavesResponse) String() string { return proto.CompactTextString(m) }
+func (*RequeueDeadLetterLeavesResponse) ProtoMessage()    {}
+func (*RequeueDeadLetterLeavesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{30}
+}
+
+type DiscardDeadLetterLeavesRequest struct {
+	LogId            int64    `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
+	LeafIdentityHash [][]byte `protobuf:"bytes,2,rep,name=leaf_identity_hash,json=leafIdentityHash,proto3" json:"leaf_identity_hash,omitempty"`
+}
+
+func (m *DiscardDeadLetterLeavesRequest) Reset()                    { *m = DiscardDeadLetterLeavesRequest{} }
+func (m *DiscardDeadLetterLeavesRequest) String() string            { return proto.CompactTextString(m) }
+func (*DiscardDeadLetterLeavesRequest) ProtoMessage()               {}
+func (*DiscardDeadLetterLeavesRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{31} }
+
+func (m *DiscardDeadLetterLeavesRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *DiscardDeadLetterLeavesRequest) GetLeafIdentityHash() [][]byte {
+	if m != nil {
+		return m.LeafIdentityHash
+	}
+	return nil
+}
+
+type DiscardDeadLetterLeavesResponse struct {
+}
+
+func (m *DiscardDeadLetterLeavesResponse) Reset()         { *m = DiscardDeadLetterLeavesResponse{} }
+func (m *DiscardDeadLetterLeavesResponse) String() string { return proto.CompactTextString(m) }
+func (*DiscardDeadLetterLeavesResponse) ProtoMessage()    {}
+func (*DiscardDeadLetterLeavesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{32}
+}
+
+type WaitForInclusionRequest struct {
+	LogId int64 `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
+	// leaf_hash is the Merkle leaf hash of the leaf to wait for.
+	LeafHash []byte `protobuf:"bytes,2,opt,name=leaf_hash,json=leafHash,proto3" json:"leaf_hash,omitempty"`
+	// max_wait_millis is how long to wait at most before failing with
+	// DEADLINE_EXCEEDED. Zero means the server's maximum, which also bounds
+	// longer waits.
+	MaxWaitMillis int64 `protobuf:"varint,3,opt,name=max_wait_millis,json=maxWaitMillis" json:"max_wait_millis,omitempty"`
+}
+
+func (m *WaitForInclusionRequest) Reset()                    { *m = WaitForInclusionRequest{} }
+func (m *WaitForInclusionRequest) String() string            { return proto.CompactTextString(m) }
+func (*WaitForInclusionRequest) ProtoMessage()               {}
+func (*WaitForInclusionRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{33} }
+
+func (m *WaitForInclusionRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *WaitForInclusionRequest) GetLeafHash() []byte {
+	if m != nil {
+		return m.LeafHash
+	}
+	return nil
+}
+
+func (m *WaitForInclusionRequest) GetMaxWaitMillis() int64 {
+	if m != nil {
+		return m.MaxWaitMillis
+	}
+	return 0
+}
+
+type WaitForInclusionResponse struct {
+	// leaf_index is the index at which the leaf was integrated.
+	LeafIndex int64 `protobuf:"varint,1,opt,name=leaf_index,json=leafIndex" json:"leaf_index,omitempty"`
+	// signed_log_root is the root the proof is relative to, which covers the
+	// leaf.
+	SignedLogRoot *SignedLogRoot `protobuf:"bytes,2,opt,name=signed_log_root,json=signedLogRoot" json:"signed_log_root,omitempty"`
+	Proof         *Proof         `protobuf:"bytes,3,opt,name=proof" json:"proof,omitempty"`
+}
+
+func (m *WaitForInclusionResponse) Reset()                    { *m = WaitForInclusionResponse{} }
+func (m *WaitForInclusionResponse) String() string            { return proto.CompactTextString(m) }
+func (*WaitForInclusionResponse) ProtoMessage()               {}
+func (*WaitForInclusionResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{34} }
+
+func (m *WaitForInclusionResponse) GetLeafIndex() int64 {
+	if m != nil {
+		return m.LeafIndex
+	}
+	return 0
+}
+
+func (m *WaitForInclusionResponse) GetSignedLogRoot() *SignedLogRoot {
+	if m != nil {
+		return m.SignedLogRoot
+	}
+	return nil
+}
+
+func (m *WaitForInclusionResponse) GetProof() *Proof {
+	if m != nil {
+		return m.Proof
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*LogLeaf)(nil), "trillian.LogLeaf")
 	proto.RegisterType((*Node)(nil), "trillian.Node")
@@ -702,12 +1057,24 @@ func init() {
 	proto.RegisterType((*GetLeavesByHashResponse)(nil), "trillian.GetLeavesByHashResponse")
 	proto.RegisterType((*GetLeavesByIndexRequest)(nil), "trillian.GetLeavesByIndexRequest")
 	proto.RegisterType((*GetLeavesByIndexResponse)(nil), "trillian.GetLeavesByIndexResponse")
+	proto.RegisterType((*GetLeafSubmittersRequest)(nil), "trillian.GetLeafSubmittersRequest")
+	proto.RegisterType((*GetLeafSubmittersResponse)(nil), "trillian.GetLeafSubmittersResponse")
 	proto.RegisterType((*GetSequencedLeafCountRequest)(nil), "trillian.GetSequencedLeafCountRequest")
 	proto.RegisterType((*GetSequencedLeafCountResponse)(nil), "trillian.GetSequencedLeafCountResponse")
 	proto.RegisterType((*GetLatestSignedLogRootRequest)(nil), "trillian.GetLatestSignedLogRootRequest")
 	proto.RegisterType((*GetLatestSignedLogRootResponse)(nil), "trillian.GetLatestSignedLogRootResponse")
 	proto.RegisterType((*GetEntryAndProofRequest)(nil), "trillian.GetEntryAndProofRequest")
 	proto.RegisterType((*GetEntryAndProofResponse)(nil), "trillian.GetEntryAndProofResponse")
+	proto.RegisterType((*DeadLetterLeaf)(nil), "trillian.DeadLetterLeaf")
+	proto.RegisterType((*ListDeadLetterLeavesRequest)(nil), "trillian.ListDeadLetterLeavesRequest")
+	proto.RegisterType((*ListDeadLetterLeavesResponse)(nil), "trillian.ListDeadLetterLeavesResponse")
+	proto.RegisterType((*RequeueDeadLetterLeavesRequest)(nil), "trillian.RequeueDeadLetterLeavesRequest")
+	proto.RegisterType((*RequeueDeadLetterLeavesResponse)(nil), "trillian.RequeueDeadLetterLeavesResponse")
+	proto.RegisterType((*DiscardDeadLetterLeavesRequest)(nil), "trillian.DiscardDeadLetterLeavesRequest")
+	proto.RegisterType((*DiscardDeadLetterLeavesResponse)(nil), "trillian.DiscardDeadLetterLeavesResponse")
+	proto.RegisterType((*WaitForInclusionRequest)(nil), "trillian.WaitForInclusionRequest")
+	proto.RegisterType((*WaitForInclusionResponse)(nil), "trillian.WaitForInclusionResponse")
+	proto.RegisterEnum("trillian.LeafPriority", LeafPriority_name, LeafPriority_value)
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -736,6 +1103,26 @@ type TrillianLogClient interface {
 	GetLeavesByIndex(ctx context.Context, in *GetLeavesByIndexRequest, opts ...grpc.CallOption) (*GetLeavesByIndexResponse, error)
 	GetLeavesByHash(ctx context.Context, in *GetLeavesByHashRequest, opts ...grpc.CallOption) (*GetLeavesByHashResponse, error)
 	GetEntryAndProof(ctx context.Context, in *GetEntryAndProofRequest, opts ...grpc.CallOption) (*GetEntryAndProofResponse, error)
+	// GetLeafSubmitters returns sequenced leaves along with the identity of
+	// the client that queued each of them. It's only available to admins.
+	GetLeafSubmitters(ctx context.Context, in *GetLeafSubmittersRequest, opts ...grpc.CallOption) (*GetLeafSubmittersResponse, error)
+	// WaitForInclusion blocks until the leaf with the given hash has been
+	// integrated and a root covering it signed, then returns the root and an
+	// inclusion proof against it. The wait is bounded by the RPC's deadline,
+	// by the request's max_wait_millis and by a maximum set by the server,
+	// after which it fails with DEADLINE_EXCEEDED.
+	// The server finds new roots by polling storage, so a response may come
+	// up to the server's poll interval after the root is signed.
+	WaitForInclusion(ctx context.Context, in *WaitForInclusionRequest, opts ...grpc.CallOption) (*WaitForInclusionResponse, error)
+	// ListDeadLetterLeaves returns queued leaves which the sequencer failed to
+	// integrate and set aside. It's only available to admins.
+	ListDeadLetterLeaves(ctx context.Context, in *ListDeadLetterLeavesRequest, opts ...grpc.CallOption) (*ListDeadLetterLeavesResponse, error)
+	// RequeueDeadLetterLeaves moves dead-lettered leaves back to the queue.
+	// It's only available to admins.
+	RequeueDeadLetterLeaves(ctx context.Context, in *RequeueDeadLetterLeavesRequest, opts ...grpc.CallOption) (*RequeueDeadLetterLeavesResponse, error)
+	// DiscardDeadLetterLeaves deletes dead-lettered leaves, which are then
+	// never integrated. It's only available to admins.
+	DiscardDeadLetterLeaves(ctx context.Context, in *DiscardDeadLetterLeavesRequest, opts ...grpc.CallOption) (*DiscardDeadLetterLeavesResponse, error)
 }
 
 type trillianLogClient struct {
@@ -836,6 +1223,51 @@ func (c *trillianLogClient) GetEntryAndProof(ctx context.Context, in *GetEntryAn
 	return out, nil
 }
 
+func (c *trillianLogClient) GetLeafSubmitters(ctx context.Context, in *GetLeafSubmittersRequest, opts ...grpc.CallOption) (*GetLeafSubmittersResponse, error) {
+	out := new(GetLeafSubmittersResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianLog/GetLeafSubmitters", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianLogClient) WaitForInclusion(ctx context.Context, in *WaitForInclusionRequest, opts ...grpc.CallOption) (*WaitForInclusionResponse, error) {
+	out := new(WaitForInclusionResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianLog/WaitForInclusion", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianLogClient) ListDeadLetterLeaves(ctx context.Context, in *ListDeadLetterLeavesRequest, opts ...grpc.CallOption) (*ListDeadLetterLeavesResponse, error) {
+	out := new(ListDeadLetterLeavesResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianLog/ListDeadLetterLeaves", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianLogClient) RequeueDeadLetterLeaves(ctx context.Context, in *RequeueDeadLetterLeavesRequest, opts ...grpc.CallOption) (*RequeueDeadLetterLeavesResponse, error) {
+	out := new(RequeueDeadLetterLeavesResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianLog/RequeueDeadLetterLeaves", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianLogClient) DiscardDeadLetterLeaves(ctx context.Context, in *DiscardDeadLetterLeavesRequest, opts ...grpc.CallOption) (*DiscardDeadLetterLeavesResponse, error) {
+	out := new(DiscardDeadLetterLeavesResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianLog/DiscardDeadLetterLeaves", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for TrillianLog service
 
 type TrillianLogServer interface {
@@ -854,6 +1286,26 @@ type TrillianLogServer interface {
 	GetLeavesByIndex(context.Context, *GetLeavesByIndexRequest) (*GetLeavesByIndexResponse, error)
 	GetLeavesByHash(context.Context, *GetLeavesByHashRequest) (*GetLeavesByHashResponse, error)
 	GetEntryAndProof(context.Context, *GetEntryAndProofRequest) (*GetEntryAndProofResponse, error)
+	// GetLeafSubmitters returns sequenced leaves along with the identity of
+	// the client that queued each of them. It's only available to admins.
+	GetLeafSubmitters(context.Context, *GetLeafSubmittersRequest) (*GetLeafSubmittersResponse, error)
+	// WaitForInclusion blocks until the leaf with the given hash has been
+	// integrated and a root covering it signed, then returns the root and an
+	// inclusion proof against it. The wait is bounded by the RPC's deadline,
+	// by the request's max_wait_millis and by a maximum set by the server,
+	// after which it fails with DEADLINE_EXCEEDED.
+	// The server finds new roots by polling storage, so a response may come
+	// up to the server's poll interval after the root is signed.
+	WaitForInclusion(context.Context, *WaitForInclusionRequest) (*WaitForInclusionResponse, error)
+	// ListDeadLetterLeaves returns queued leaves which the sequencer failed to
+	// integrate and set aside. It's only available to admins.
+	ListDeadLetterLeaves(context.Context, *ListDeadLetterLeavesRequest) (*ListDeadLetterLeavesResponse, error)
+	// RequeueDeadLetterLeaves moves dead-lettered leaves back to the queue.
+	// It's only available to admins.
+	RequeueDeadLetterLeaves(context.Context, *RequeueDeadLetterLeavesRequest) (*RequeueDeadLetterLeavesResponse, error)
+	// DiscardDeadLetterLeaves deletes dead-lettered leaves, which are then
+	// never integrated. It's only available to admins.
+	DiscardDeadLetterLeaves(context.Context, *DiscardDeadLetterLeavesRequest) (*DiscardDeadLetterLeavesResponse, error)
 }
 
 func RegisterTrillianLogServer(s *grpc.Server, srv TrillianLogServer) {
@@ -1040,6 +1492,96 @@ func _TrillianLog_GetEntryAndProof_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TrillianLog_GetLeafSubmitters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLeafSubmittersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogServer).GetLeafSubmitters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLog/GetLeafSubmitters",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogServer).GetLeafSubmitters(ctx, req.(*GetLeafSubmittersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianLog_WaitForInclusion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WaitForInclusionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogServer).WaitForInclusion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLog/WaitForInclusion",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogServer).WaitForInclusion(ctx, req.(*WaitForInclusionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianLog_ListDeadLetterLeaves_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDeadLetterLeavesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogServer).ListDeadLetterLeaves(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLog/ListDeadLetterLeaves",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogServer).ListDeadLetterLeaves(ctx, req.(*ListDeadLetterLeavesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianLog_RequeueDeadLetterLeaves_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequeueDeadLetterLeavesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogServer).RequeueDeadLetterLeaves(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLog/RequeueDeadLetterLeaves",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogServer).RequeueDeadLetterLeaves(ctx, req.(*RequeueDeadLetterLeavesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianLog_DiscardDeadLetterLeaves_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DiscardDeadLetterLeavesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogServer).DiscardDeadLetterLeaves(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLog/DiscardDeadLetterLeaves",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogServer).DiscardDeadLetterLeaves(ctx, req.(*DiscardDeadLetterLeavesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _TrillianLog_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "trillian.TrillianLog",
 	HandlerType: (*TrillianLogServer)(nil),
@@ -1084,6 +1626,26 @@ var _TrillianLog_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetEntryAndProof",
 			Handler:    _TrillianLog_GetEntryAndProof_Handler,
 		},
+		{
+			MethodName: "GetLeafSubmitters",
+			Handler:    _TrillianLog_GetLeafSubmitters_Handler,
+		},
+		{
+			MethodName: "WaitForInclusion",
+			Handler:    _TrillianLog_WaitForInclusion_Handler,
+		},
+		{
+			MethodName: "ListDeadLetterLeaves",
+			Handler:    _TrillianLog_ListDeadLetterLeaves_Handler,
+		},
+		{
+			MethodName: "RequeueDeadLetterLeaves",
+			Handler:    _TrillianLog_RequeueDeadLetterLeaves_Handler,
+		},
+		{
+			MethodName: "DiscardDeadLetterLeaves",
+			Handler:    _TrillianLog_DiscardDeadLetterLeaves_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "trillian_log_api.proto",
@@ -1092,69 +1654,94 @@ var _TrillianLog_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("trillian_log_api.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 1013 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x57, 0xef, 0x6e, 0x1b, 0x45,
-	0x10, 0xaf, 0x73, 0xf9, 0xe7, 0x71, 0x1c, 0x3b, 0x5b, 0xb5, 0x71, 0xcf, 0x0d, 0xa4, 0x5b, 0xd2,
-	0xba, 0x15, 0x38, 0x52, 0x10, 0x88, 0x0f, 0x08, 0x54, 0x37, 0x25, 0x8d, 0x64, 0x20, 0x9c, 0x4b,
-	0x85, 0x84, 0xc4, 0xe9, 0xe2, 0x5b, 0x3b, 0x07, 0x97, 0x5b, 0xf7, 0x6e, 0x1d, 0xc5, 0xfd, 0xce,
-	0x63, 0xf0, 0x2a, 0x3c, 0x00, 0x4f, 0x85, 0x76, 0x76, 0xef, 0xec, 0x3b, 0x9f, 0xcf, 0x35, 0x12,
-	0xdf, 0x2e, 0x33, 0xbf, 0xf9, 0xcd, 0x6f, 0x76, 0x76, 0x66, 0x1d, 0xb8, 0x2f, 0x42, 0xcf, 0xf7,
-	0x3d, 0x27, 0xb0, 0x7d, 0x3e, 0xb4, 0x9d, 0x91, 0xd7, 0x1e, 0x85, 0x5c, 0x70, 0xb2, 0x1d, 0xdb,
-	0xcd, 0xdd, 0xf8, 0x4b, 0x79, 0xcc, 0xfd, 0x21, 0xe7, 0x43, 0x9f, 0x1d, 0x87, 0xa3, 0xfe, 0x71,
-	0x24, 0x1c, 0x31, 0x8e, 0x94, 0x83, 0xfe, 0x5d, 0x82, 0xad, 0x2e, 0x1f, 0x76, 0x99, 0x33, 0x20,
-	0x2d, 0xa8, 0x5f, 0xb3, 0xf0, 0x0f, 0x9f, 0xd9, 0x3e, 0x73, 0x06, 0xf6, 0x95, 0x13, 0x5d, 0x35,
-	0x4a, 0x87, 0xa5, 0xd6, 0x8e, 0xb5, 0xab, 0xec, 0x12, 0xf5, 0xda, 0x89, 0xae, 0xc8, 0x01, 0x00,
-	0x42, 0x6e, 0x1c, 0x7f, 0xcc, 0x1a, 0x6b, 0x88, 0x29, 0x4b, 0xcb, 0x5b, 0x69, 0x90, 0x6e, 0x76,
-	0x2b, 0x42, 0xc7, 0x76, 0x1d, 0xe1, 0x34, 0x0c, 0xe5, 0x46, 0xcb, 0xa9, 0x23, 0x9c, 0x24, 0xda,
-	0x0b, 0x5c, 0x76, 0xdb, 0x58, 0x3f, 0x2c, 0xb5, 0x0c, 0x15, 0x7d, 0x2e, 0x0d, 0xe4, 0x53, 0x20,
-	0xca, 0xed, 0xb2, 0x40, 0x78, 0x62, 0xa2, 0x84, 0x6c, 0x20, 0x4b, 0x1d, 0x61, 0xda, 0x21, 0xa5,
-	0x50, 0x07, 0xd6, 0x7f, 0xe0, 0x2e, 0x23, 0xfb, 0xb0, 0x15, 0x70, 0x97, 0xd9, 0x9e, 0xab, 0x35,
-	0x6f, 0xca, 0x3f, 0xcf, 0x5d, 0xd2, 0x84, 0x32, 0x3a, 0x90, 0x45, 0x49, 0xdd, 0x96, 0x06, 0x2c,
-	0xe4, 0x31, 0x54, 0xd1, 0x19, 0xb2, 0x1b, 0x2f, 0xf2, 0x78, 0x80, 0x62, 0x0d, 0x6b, 0x47, 0x1a,
-	0x2d, 0x6d, 0xa3, 0x3f, 0xc3, 0xc6, 0x45, 0xc8, 0xf9, 0x20, 0x23, 0xbc, 0x94, 0x15, 0xfe, 0x19,
-	0xc0, 0x48, 0xe2, 0x6c, 0x19, 0xdd, 0x58, 0x3b, 0x34, 0x5a, 0x95, 0x93, 0xdd, 0x76, 0xd2, 0x09,
-	0x29, 0xd3, 0x2a, 0x23, 0x42, 0x7e, 0xd2, 0x4b, 0xa8, 0xfe, 0x34, 0x66, 0x63, 0xe6, 0xc6, 0xe7,
-	0x7f, 0x04, 0xeb, 0x92, 0x0c, 0x89, 0x2b, 0x27, 0x7b, 0xd3, 0x48, 0x0d, 0xb0, 0xd0, 0x4d, 0x9e,
-	0xc3, 0xa6, 0x6a, 0x21, 0x56, 0x53, 0x39, 0x21, 0x6d, 0xd5, 0xdc, 0x76, 0x38, 0xea, 0xb7, 0x7b,
-	0xe8, 0xb1, 0x34, 0x82, 0xbe, 0x05, 0x82, 0x39, 0xba, 0xcc, 0xb9, 0x61, 0x91, 0xc5, 0xde, 0x8d,
-	0x59, 0x24, 0xc8, 0x3d, 0xd8, 0x94, 0x17, 0x47, 0x1f, 0x95, 0x61, 0x6d, 0xf8, 0x7c, 0x78, 0xee,
-	0x92, 0x67, 0xb0, 0xe9, 0x23, 0x4e, 0x6b, 0xcf, 0x51, 0xa0, 0x01, 0xf4, 0x02, 0xea, 0x31, 0xef,
-	0x60, 0x09, 0x6b, 0x5c, 0xd5, 0x5a, 0x61, 0x55, 0xf4, 0x7b, 0xd8, 0x9b, 0x61, 0x8c, 0x46, 0x3c,
-	0x88, 0x18, 0xf9, 0x0a, 0x2a, 0xef, 0xf0, 0x88, 0xec, 0x19, 0x8a, 0xfd, 0x29, 0x45, 0xea, 0xfc,
-	0x2c, 0x50, 0x58, 0xf9, 0x4d, 0x7b, 0x70, 0x37, 0x55, 0xb8, 0x26, 0xfc, 0x1a, 0xaa, 0x53, 0xc2,
-	0x69, 0xa5, 0x0b, 0x29, 0x77, 0x12, 0x4a, 0x59, 0xf5, 0x35, 0x34, 0xce, 0x98, 0x38, 0x0f, 0xfa,
-	0xfe, 0x58, 0x5e, 0x0c, 0xbc, 0x14, 0x4b, 0xaa, 0x4f, 0x5f, 0x99, 0xb5, 0xec, 0x95, 0x69, 0x42,
-	0x59, 0x84, 0x8c, 0xd9, 0x91, 0xf7, 0x9e, 0xe9, 0xbb, 0xb7, 0x2d, 0x0d, 0x3d, 0xef, 0x3d, 0xa3,
-	0x1d, 0x78, 0x90, 0x93, 0x4e, 0x57, 0x72, 0x04, 0x1b, 0x78, 0x95, 0xf4, 0xa1, 0xd4, 0xa6, 0x15,
-	0x28, 0x9c, 0xf2, 0xd2, 0xbf, 0x4a, 0xf0, 0xd1, 0x1c, 0x49, 0x07, 0x47, 0x67, 0x89, 0xf2, 0x26,
-	0x94, 0xa7, 0x6b, 0x40, 0xcf, 0x8d, 0x1f, 0x2f, 0x80, 0x22, 0xdd, 0xe4, 0x39, 0xec, 0xf1, 0xd0,
-	0x65, 0xa1, 0x7d, 0x39, 0xb1, 0x23, 0x99, 0x24, 0xe8, 0x33, 0x1c, 0xf3, 0x6d, 0xab, 0x86, 0x8e,
-	0xce, 0xa4, 0xa7, 0xcd, 0xf4, 0x35, 0x7c, 0xbc, 0x50, 0xde, 0x7c, 0xa5, 0x46, 0x41, 0xa5, 0x7f,
-	0x96, 0xc0, 0x3c, 0x63, 0xe2, 0x25, 0x0f, 0x22, 0x2f, 0x12, 0x2c, 0xe8, 0x4f, 0x3e, 0xa4, 0x3f,
-	0x4f, 0xa0, 0x36, 0xf0, 0xc2, 0x48, 0xd8, 0xd3, 0x72, 0x54, 0x93, 0xaa, 0x68, 0x7e, 0x13, 0xd7,
-	0xd4, 0x82, 0x7a, 0xc4, 0xfa, 0x3c, 0x70, 0xed, 0x6c, 0xdd, 0xbb, 0xca, 0x1e, 0x23, 0xe9, 0x29,
-	0x34, 0x73, 0x65, 0xac, 0xd6, 0xb7, 0x5b, 0xb8, 0x7f, 0xc6, 0x84, 0xba, 0x77, 0xff, 0xa5, 0x5d,
-	0x46, 0xaa, 0x5d, 0xb9, 0x1d, 0x31, 0xf2, 0x3b, 0x72, 0x0a, 0xfb, 0x73, 0x99, 0xb5, 0xf6, 0x15,
-	0x16, 0xc4, 0x8f, 0x29, 0x16, 0xbc, 0xec, 0x2b, 0x4e, 0x8a, 0x91, 0x9a, 0x14, 0xfa, 0x0a, 0x67,
-	0x2f, 0x43, 0xb8, 0xba, 0xae, 0x2f, 0xe0, 0xe1, 0x19, 0x13, 0x71, 0xb1, 0xb8, 0x2b, 0x5e, 0xf2,
-	0x71, 0x20, 0x8a, 0xc5, 0xd1, 0x6f, 0xe0, 0x60, 0x41, 0x98, 0x96, 0x10, 0xab, 0xef, 0x4b, 0xeb,
-	0xec, 0x9c, 0x23, 0x8c, 0x7e, 0x89, 0xf1, 0x5d, 0x47, 0xb0, 0x48, 0xf4, 0xbc, 0x61, 0x80, 0x1b,
-	0xc6, 0xe2, 0x7c, 0x59, 0x5e, 0x07, 0xa7, 0x37, 0x37, 0x4e, 0x27, 0xfe, 0x16, 0x6a, 0x11, 0x3a,
-	0xf0, 0xb7, 0x40, 0xc8, 0xb9, 0x98, 0x5f, 0x93, 0xe9, 0xc8, 0x6a, 0x34, 0xfb, 0x27, 0xf5, 0xb1,
-	0x53, 0xaf, 0x02, 0x11, 0x4e, 0x5e, 0x04, 0xee, 0xff, 0xbd, 0xd3, 0xae, 0xb0, 0x8d, 0x99, 0x6c,
-	0x2b, 0x8d, 0x46, 0xf2, 0xa0, 0x18, 0x85, 0x0f, 0xca, 0xc9, 0x3f, 0x5b, 0x50, 0x79, 0xa3, 0x5d,
-	0x5d, 0x3e, 0x24, 0xdf, 0x41, 0x39, 0x79, 0x60, 0x88, 0x99, 0x59, 0xf8, 0x33, 0xef, 0x98, 0xd9,
-	0xcc, 0xf5, 0x29, 0x8d, 0xf4, 0x0e, 0xe9, 0x42, 0x65, 0xe6, 0x65, 0x21, 0x0f, 0xe7, 0xd1, 0xd3,
-	0x97, 0xd6, 0x3c, 0x58, 0xe0, 0x4d, 0xd8, 0x7e, 0x83, 0xbd, 0xb9, 0xfd, 0x47, 0xe8, 0x34, 0x6a,
-	0xd1, 0x7b, 0x63, 0x3e, 0x2e, 0xc4, 0x24, 0xfc, 0x23, 0xec, 0x6e, 0xde, 0x7e, 0x25, 0xad, 0x02,
-	0x86, 0xd4, 0xca, 0x31, 0x9f, 0x7d, 0x00, 0x32, 0xc9, 0xe8, 0xc2, 0xdd, 0x9c, 0xfd, 0x47, 0x3e,
-	0x49, 0x71, 0x2c, 0xd8, 0xd2, 0xe6, 0xd1, 0x12, 0x54, 0x92, 0xe5, 0x5a, 0xed, 0xc7, 0xf9, 0xc1,
-	0x20, 0x4f, 0x53, 0x14, 0x8b, 0x47, 0xce, 0x6c, 0x2d, 0x07, 0x26, 0xe9, 0x7e, 0x87, 0x7b, 0xb9,
-	0xf3, 0x4f, 0x9e, 0xa4, 0x48, 0x16, 0xee, 0x15, 0xf3, 0xe9, 0x52, 0x5c, 0x92, 0xeb, 0x57, 0xa8,
-	0x67, 0x37, 0x1d, 0x79, 0x94, 0xd6, 0x9a, 0xb3, 0x56, 0x4d, 0x5a, 0x04, 0x49, 0xc8, 0x7f, 0x81,
-	0x5a, 0x66, 0xbb, 0x93, 0xc3, 0xdc, 0xc0, 0xd9, 0xfe, 0x3f, 0x2a, 0x40, 0x64, 0x64, 0xa7, 0x26,
-	0x3b, 0x23, 0x3b, 0x6f, 0xc7, 0x64, 0x64, 0xe7, 0x2e, 0x06, 0x7a, 0xa7, 0x73, 0x0c, 0x0f, 0xfa,
-	0xfc, 0x3a, 0xfe, 0xa1, 0x9b, 0xfe, 0xe7, 0xa6, 0x53, 0x8f, 0xc7, 0xfc, 0xc5, 0xc8, 0xbb, 0x90,
-	0x96, 0x8b, 0xd2, 0xe5, 0x26, 0xba, 0x3e, 0xff, 0x37, 0x00, 0x00, 0xff, 0xff, 0xa1, 0x13, 0xac,
-	0x08, 0x2b, 0x0d, 0x00, 0x00,
+	// 1414 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x58, 0xdd, 0x72, 0x13, 0xc7,
+	0x12, 0x66, 0x2d, 0x6c, 0xac, 0x96, 0x25, 0xcb, 0x63, 0xb0, 0x97, 0x95, 0x0d, 0x66, 0x39, 0x80,
+	0xa0, 0xce, 0x31, 0xa7, 0x94, 0x4a, 0x2a, 0x17, 0x21, 0x29, 0x8c, 0xc1, 0x56, 0x45, 0x80, 0xb3,
+	0x32, 0xf9, 0xa9, 0x54, 0x65, 0x6b, 0xac, 0x1d, 0xcb, 0x9b, 0x48, 0x3b, 0x62, 0x67, 0xe4, 0xb2,
+	0xb9, 0x4e, 0x6e, 0x52, 0x79, 0x85, 0x5c, 0xe4, 0x89, 0x72, 0x93, 0x07, 0x4a, 0xcd, 0xec, 0xff,
+	0xaf, 0x6c, 0x28, 0xee, 0xb4, 0xdd, 0xdf, 0x74, 0x7f, 0xdd, 0xd3, 0xd3, 0xdd, 0x25, 0x58, 0xe3,
+	0xae, 0x3d, 0x1a, 0xd9, 0xd8, 0x31, 0x47, 0x74, 0x68, 0xe2, 0x89, 0xbd, 0x3d, 0x71, 0x29, 0xa7,
+	0x68, 0x31, 0x90, 0x6b, 0x8d, 0xe0, 0x97, 0xa7, 0xd1, 0xd6, 0x87, 0x94, 0x0e, 0x47, 0xe4, 0xb1,
+	0x3b, 0x19, 0x3c, 0x66, 0x1c, 0xf3, 0x29, 0xf3, 0x14, 0xfa, 0xdf, 0x73, 0x70, 0xad, 0x47, 0x87,
+	0x3d, 0x82, 0x8f, 0x51, 0x1b, 0x9a, 0x63, 0xe2, 0xfe, 0x32, 0x22, 0xe6, 0x88, 0xe0, 0x63, 0xf3,
+	0x04, 0xb3, 0x13, 0x55, 0xd9, 0x52, 0xda, 0x4b, 0x46, 0xc3, 0x93, 0x0b, 0xd4, 0x3e, 0x66, 0x27,
+	0x68, 0x13, 0x40, 0x42, 0x4e, 0xf1, 0x68, 0x4a, 0xd4, 0x39, 0x89, 0xa9, 0x0a, 0xc9, 0xb7, 0x42,
+	0x20, 0xd4, 0xe4, 0x8c, 0xbb, 0xd8, 0xb4, 0x30, 0xc7, 0x6a, 0xc5, 0x53, 0x4b, 0xc9, 0x2e, 0xe6,
+	0x38, 0x3c, 0x6d, 0x3b, 0x16, 0x39, 0x53, 0xaf, 0x6e, 0x29, 0xed, 0x8a, 0x77, 0xba, 0x2b, 0x04,
+	0xe8, 0xbf, 0x80, 0x3c, 0xb5, 0x45, 0x1c, 0x6e, 0xf3, 0x73, 0x8f, 0xc8, 0xbc, 0xb4, 0xd2, 0x94,
+	0x30, 0x5f, 0x21, 0xa9, 0x74, 0xe0, 0xc6, 0xdb, 0x29, 0x99, 0x12, 0x93, 0xdb, 0x63, 0xc2, 0x38,
+	0x1e, 0x4f, 0x4c, 0x07, 0x3b, 0x94, 0xa9, 0x0b, 0xd2, 0xee, 0xaa, 0x54, 0x1e, 0x06, 0xba, 0x57,
+	0x42, 0x85, 0x36, 0xa0, 0xca, 0xa6, 0x47, 0x63, 0x9b, 0x73, 0xe2, 0xaa, 0xd7, 0xb6, 0x94, 0x76,
+	0xd5, 0x88, 0x04, 0xe8, 0x09, 0x34, 0x3c, 0x8b, 0x13, 0xd7, 0xa6, 0xae, 0xcd, 0xcf, 0xd5, 0xc5,
+	0x2d, 0xa5, 0xdd, 0xe8, 0xac, 0x6d, 0x87, 0x49, 0x15, 0x89, 0x38, 0xf0, 0xb5, 0x46, 0x5d, 0xa2,
+	0x83, 0x4f, 0x1d, 0xc3, 0xd5, 0x57, 0xd4, 0x22, 0x68, 0x1d, 0xae, 0x39, 0xd4, 0x22, 0xa6, 0x6d,
+	0xf9, 0x49, 0x5c, 0x10, 0x9f, 0x5d, 0x0b, 0xb5, 0xa0, 0x2a, 0x15, 0x32, 0x2c, 0x2f, 0x77, 0x8b,
+	0x42, 0x20, 0xc3, 0xb9, 0x0b, 0x75, 0xa9, 0x74, 0xc9, 0xa9, 0xcd, 0x6c, 0xea, 0xc8, 0xec, 0x55,
+	0x8c, 0x25, 0x21, 0x34, 0x7c, 0x99, 0xfe, 0x06, 0xe6, 0x0f, 0x5c, 0x4a, 0x8f, 0x53, 0x99, 0x54,
+	0xd2, 0x99, 0xfc, 0x1f, 0xc0, 0x44, 0xe0, 0x4c, 0x71, 0x5a, 0x9d, 0xdb, 0xaa, 0xb4, 0x6b, 0x9d,
+	0x46, 0x14, 0x85, 0xa0, 0x69, 0x54, 0x25, 0x42, 0xfc, 0xd4, 0x8f, 0xa0, 0xfe, 0x8d, 0x08, 0xc5,
+	0x0a, 0x0a, 0xe2, 0x1e, 0x5c, 0x15, 0xc6, 0xa4, 0xe1, 0x5a, 0x67, 0x25, 0x16, 0xbf, 0x07, 0x30,
+	0xa4, 0x1a, 0x3d, 0x82, 0x05, 0xaf, 0xa6, 0x64, 0x34, 0xb5, 0x0e, 0xda, 0xf6, 0xaa, 0x6d, 0xdb,
+	0x9d, 0x0c, 0xb6, 0xfb, 0x52, 0x63, 0xf8, 0x08, 0xfd, 0x77, 0x05, 0x90, 0x74, 0xd2, 0x23, 0xf8,
+	0x94, 0x30, 0x83, 0xbc, 0x9d, 0x12, 0xc6, 0xd1, 0x0d, 0x58, 0x10, 0xa5, 0xec, 0xe7, 0xaa, 0x62,
+	0xcc, 0x8f, 0xe8, 0xb0, 0x6b, 0xa1, 0x87, 0xb0, 0x30, 0x92, 0x38, 0x9f, 0x7c, 0x0e, 0x05, 0x1f,
+	0x80, 0x3a, 0xb0, 0x18, 0xde, 0x57, 0xa5, 0xf4, 0xbe, 0x42, 0x9c, 0xfe, 0xab, 0x02, 0xcd, 0x80,
+	0xcc, 0xf1, 0x0c, 0x2a, 0x41, 0x2e, 0xe6, 0xca, 0x73, 0xf1, 0x3e, 0x34, 0x5e, 0xc2, 0x4a, 0x8c,
+	0x05, 0x9b, 0x50, 0x87, 0x11, 0xf4, 0x39, 0xd4, 0x64, 0x5d, 0x59, 0x66, 0xcc, 0xed, 0x7a, 0x64,
+	0x2b, 0x71, 0x53, 0x06, 0x78, 0x58, 0xf1, 0x5b, 0xef, 0xc3, 0x6a, 0x22, 0xc3, 0xbe, 0xc1, 0x2f,
+	0xa0, 0x1e, 0x19, 0x8c, 0x52, 0x5a, 0x68, 0x72, 0x29, 0x34, 0x79, 0x4a, 0x98, 0x3e, 0x06, 0x75,
+	0x8f, 0xf0, 0xae, 0x33, 0x18, 0x4d, 0x45, 0x09, 0xca, 0xf2, 0x9b, 0x91, 0xb1, 0x64, 0x71, 0xce,
+	0xa5, 0x8b, 0xb3, 0x05, 0x55, 0xee, 0x12, 0x62, 0x32, 0xfb, 0x1d, 0xf1, 0xab, 0x7c, 0x51, 0x08,
+	0xfa, 0xf6, 0x3b, 0xa2, 0xef, 0xc0, 0xcd, 0x1c, 0x77, 0x7e, 0x24, 0xf7, 0x60, 0x5e, 0x16, 0xad,
+	0x9f, 0x94, 0xe5, 0x28, 0x02, 0x0f, 0xe7, 0x69, 0xf5, 0x3f, 0x15, 0xb8, 0x95, 0x31, 0xb2, 0x23,
+	0xbb, 0xc6, 0x0c, 0xe6, 0x2d, 0xa8, 0x46, 0x1d, 0xd0, 0x7f, 0xa1, 0xa3, 0xa0, 0xf7, 0x95, 0xf1,
+	0x46, 0x8f, 0x60, 0x85, 0xba, 0x16, 0x71, 0xcd, 0xa3, 0x73, 0x93, 0x09, 0x27, 0xce, 0x80, 0xc8,
+	0x0e, 0xb7, 0x68, 0x2c, 0x4b, 0xc5, 0xce, 0x79, 0xdf, 0x17, 0xeb, 0xfb, 0x70, 0xbb, 0x90, 0x5e,
+	0x36, 0xd2, 0x4a, 0x49, 0xa4, 0xbf, 0x29, 0xa0, 0xed, 0x11, 0xfe, 0x8c, 0x3a, 0xcc, 0x66, 0x9c,
+	0x38, 0x83, 0xf3, 0x8b, 0xdc, 0xcf, 0x7d, 0x58, 0x3e, 0xb6, 0x5d, 0xc6, 0xcd, 0x28, 0x1c, 0xef,
+	0x92, 0xea, 0x52, 0x7c, 0x18, 0xc4, 0xd4, 0x86, 0x26, 0x23, 0x03, 0xea, 0x58, 0x66, 0x3a, 0xee,
+	0x86, 0x27, 0x0f, 0x90, 0xfa, 0x2e, 0xb4, 0x72, 0x69, 0x5c, 0xee, 0xde, 0xce, 0x60, 0x6d, 0x8f,
+	0x70, 0xaf, 0xee, 0xde, 0xe7, 0xba, 0x2a, 0x89, 0xeb, 0xca, 0xbd, 0x91, 0x4a, 0xfe, 0x8d, 0xec,
+	0xc2, 0x7a, 0xc6, 0xb3, 0xcf, 0xfd, 0xe2, 0x9d, 0x48, 0x7f, 0x9d, 0xb0, 0x22, 0x8b, 0xfd, 0x92,
+	0x2f, 0xa5, 0x92, 0x78, 0x29, 0xfa, 0x73, 0x50, 0xb3, 0x06, 0x2f, 0xcf, 0xeb, 0x20, 0x30, 0x73,
+	0xdc, 0x0f, 0x66, 0x1d, 0xfb, 0x30, 0x62, 0x2f, 0xe0, 0x66, 0x8e, 0xc5, 0x0c, 0x33, 0x65, 0x16,
+	0xb3, 0x4f, 0x61, 0x63, 0x8f, 0xf0, 0xe0, 0x1a, 0x64, 0x17, 0x7b, 0x46, 0xa7, 0x0e, 0x2f, 0x67,
+	0xa7, 0x7f, 0x09, 0x9b, 0x05, 0xc7, 0x7c, 0x0a, 0x01, 0xfd, 0x81, 0x90, 0xc6, 0x3b, 0x90, 0x84,
+	0xe9, 0x9f, 0xc9, 0xf3, 0x3d, 0xcc, 0x09, 0xe3, 0x7d, 0x7b, 0xe8, 0xc8, 0xde, 0x67, 0x50, 0x3a,
+	0xcb, 0x2f, 0x86, 0x5b, 0x45, 0xe7, 0x7c, 0xc7, 0x5f, 0xc1, 0x32, 0x93, 0x0a, 0xb9, 0xa0, 0xb9,
+	0x94, 0xf2, 0x6c, 0x03, 0x4f, 0x9e, 0xac, 0xb3, 0xf8, 0xa7, 0x3e, 0x92, 0x35, 0xf4, 0xdc, 0xe1,
+	0xee, 0xf9, 0x53, 0xc7, 0xfa, 0xd8, 0xdd, 0xf6, 0x04, 0xd4, 0xac, 0xb7, 0x4b, 0x3d, 0xda, 0x70,
+	0x3c, 0x56, 0x4a, 0xc7, 0xa3, 0xfe, 0x87, 0x02, 0x8d, 0x5d, 0x82, 0xad, 0x1e, 0x11, 0xc5, 0x72,
+	0x99, 0x25, 0xe3, 0x3a, 0xcc, 0x13, 0xd7, 0xa5, 0xae, 0xe4, 0x51, 0x35, 0xbc, 0x0f, 0xf4, 0x04,
+	0x5a, 0x16, 0xc1, 0x62, 0xa4, 0x09, 0x7b, 0x99, 0x1d, 0xd0, 0x0b, 0x54, 0xb5, 0x42, 0x8f, 0xc9,
+	0x45, 0x50, 0xef, 0x43, 0xab, 0x67, 0x33, 0x9e, 0x60, 0x34, 0x7b, 0x2b, 0xd9, 0x04, 0x18, 0xe3,
+	0xb3, 0x68, 0x8c, 0x2a, 0xed, 0x79, 0xa3, 0x3a, 0xc6, 0x67, 0xbd, 0xe0, 0x9d, 0x6d, 0xe4, 0x1b,
+	0xf5, 0x33, 0xfa, 0xff, 0xd4, 0xc3, 0x50, 0xa3, 0x90, 0x93, 0xa9, 0x09, 0xdf, 0x07, 0x81, 0x5b,
+	0x92, 0xd2, 0x94, 0x5c, 0x92, 0x69, 0xfe, 0x2a, 0xed, 0xb5, 0xc8, 0xcc, 0x2a, 0xad, 0xdf, 0x81,
+	0xdb, 0x85, 0x6e, 0x3c, 0xee, 0x82, 0xc9, 0xae, 0xcd, 0x06, 0xd8, 0xb5, 0x3e, 0x36, 0x93, 0x42,
+	0x37, 0x3e, 0x93, 0x97, 0xb0, 0xfe, 0x1d, 0xb6, 0xf9, 0x0b, 0xea, 0x86, 0x13, 0xf4, 0x03, 0xa6,
+	0xba, 0xfe, 0x97, 0x02, 0x6a, 0xd6, 0x5e, 0xaa, 0x8f, 0x14, 0xac, 0xd9, 0x1f, 0xfa, 0xda, 0xa3,
+	0x37, 0x56, 0x29, 0x7b, 0x63, 0x8f, 0xba, 0xb0, 0x14, 0xdf, 0x20, 0xd1, 0x2a, 0x2c, 0x1f, 0x18,
+	0xdd, 0xd7, 0x46, 0xf7, 0xf0, 0x07, 0xf3, 0xd5, 0x6b, 0xe3, 0xe5, 0xd3, 0x5e, 0xf3, 0x0a, 0x5a,
+	0x81, 0x7a, 0x28, 0xdc, 0xef, 0xee, 0xed, 0x37, 0x95, 0x84, 0x68, 0xe7, 0x4d, 0xef, 0xeb, 0xe6,
+	0x5c, 0xe7, 0x9f, 0x1a, 0xd4, 0x0e, 0x7d, 0x27, 0x3d, 0x3a, 0x44, 0x2f, 0xa0, 0x1a, 0xae, 0xa0,
+	0x48, 0x4b, 0xad, 0x84, 0xb1, 0xed, 0x58, 0x6b, 0xe5, 0xea, 0xfc, 0x3b, 0xb9, 0x82, 0x7a, 0x50,
+	0x8b, 0xed, 0x9e, 0x68, 0x23, 0x8b, 0x8e, 0x4a, 0x45, 0xdb, 0x2c, 0xd0, 0x86, 0xd6, 0x7e, 0x82,
+	0x95, 0xcc, 0x86, 0x84, 0xf4, 0xe8, 0x54, 0xd1, 0x46, 0xaa, 0xdd, 0x2d, 0xc5, 0x84, 0xf6, 0x27,
+	0xb0, 0x9e, 0x51, 0x7b, 0x73, 0x1f, 0xb5, 0x4b, 0x2c, 0x24, 0x96, 0x12, 0xed, 0xe1, 0x05, 0x90,
+	0xa1, 0x47, 0x0b, 0x56, 0x73, 0x36, 0x24, 0xf4, 0x9f, 0x84, 0x8d, 0x82, 0x3d, 0x4e, 0xbb, 0x37,
+	0x03, 0x15, 0x7a, 0x19, 0xc3, 0x5a, 0xfe, 0x80, 0x42, 0x0f, 0x12, 0x26, 0x8a, 0x47, 0x9f, 0xd6,
+	0x9e, 0x0d, 0x0c, 0xdd, 0xfd, 0x0c, 0x37, 0x72, 0xe7, 0x30, 0xba, 0x9f, 0x30, 0x52, 0x38, 0xdf,
+	0xb5, 0x07, 0x33, 0x71, 0xa1, 0xaf, 0x1f, 0xa1, 0x99, 0xde, 0x85, 0xd0, 0x9d, 0x24, 0xd7, 0x9c,
+	0xc5, 0x4b, 0xd3, 0xcb, 0x20, 0xa1, 0xf1, 0xef, 0x61, 0x39, 0xb5, 0xff, 0xa1, 0xad, 0xdc, 0x83,
+	0xf1, 0xfb, 0xbf, 0x53, 0x82, 0x48, 0xd1, 0x4e, 0x4c, 0xd8, 0x14, 0xed, 0xbc, 0x59, 0xaf, 0xe9,
+	0x65, 0x90, 0xd4, 0x33, 0x49, 0xae, 0x61, 0x28, 0x13, 0x71, 0x76, 0xeb, 0xd3, 0xee, 0x96, 0x62,
+	0xe2, 0xe4, 0xd3, 0xad, 0x31, 0x4e, 0xbe, 0xa0, 0x0d, 0x6b, 0x7a, 0x19, 0x24, 0x34, 0x3e, 0x84,
+	0xeb, 0x79, 0xd3, 0x12, 0xc5, 0x8a, 0xbd, 0x64, 0x44, 0x6b, 0xf7, 0x67, 0xc1, 0xe2, 0x8f, 0xbd,
+	0x60, 0xba, 0xc5, 0x1f, 0x7b, 0xf9, 0x9c, 0x8d, 0x3f, 0xf6, 0x59, 0xa3, 0x52, 0x7a, 0x2c, 0x98,
+	0x62, 0x71, 0x8f, 0xe5, 0xf3, 0x54, 0x7b, 0x78, 0x01, 0x64, 0xe0, 0x71, 0xe7, 0x31, 0xdc, 0x1c,
+	0xd0, 0x71, 0xf0, 0xf7, 0x4b, 0xf2, 0x3f, 0xc0, 0x9d, 0x66, 0xd0, 0xf0, 0x9f, 0x4e, 0xec, 0x03,
+	0x21, 0x39, 0x50, 0x8e, 0x16, 0xa4, 0xea, 0x93, 0x7f, 0x03, 0x00, 0x00, 0xff, 0xff, 0x9f, 0x42,
+	0xb4, 0x92, 0x52, 0x14, 0x00, 0x00,
 }
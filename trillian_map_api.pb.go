@@ -192,6 +192,92 @@ func (m *SetMapLeavesResponse) GetMapRoot() *SignedMapRoot {
 	return nil
 }
 
+type GetMapLeafHistoryRequest struct {
+	MapId int64  `protobuf:"varint,1,opt,name=map_id,json=mapId" json:"map_id,omitempty"`
+	Index []byte `protobuf:"bytes,2,opt,name=index,proto3" json:"index,omitempty"`
+	// from_revision and to_revision bound the revisions returned, inclusively.
+	// A negative to_revision means the latest revision of the map.
+	FromRevision int64 `protobuf:"varint,3,opt,name=from_revision,json=fromRevision" json:"from_revision,omitempty"`
+	ToRevision   int64 `protobuf:"varint,4,opt,name=to_revision,json=toRevision" json:"to_revision,omitempty"`
+}
+
+func (m *GetMapLeafHistoryRequest) Reset()                    { *m = GetMapLeafHistoryRequest{} }
+func (m *GetMapLeafHistoryRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetMapLeafHistoryRequest) ProtoMessage()               {}
+func (*GetMapLeafHistoryRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{6} }
+
+func (m *GetMapLeafHistoryRequest) GetMapId() int64 {
+	if m != nil {
+		return m.MapId
+	}
+	return 0
+}
+
+func (m *GetMapLeafHistoryRequest) GetIndex() []byte {
+	if m != nil {
+		return m.Index
+	}
+	return nil
+}
+
+func (m *GetMapLeafHistoryRequest) GetFromRevision() int64 {
+	if m != nil {
+		return m.FromRevision
+	}
+	return 0
+}
+
+func (m *GetMapLeafHistoryRequest) GetToRevision() int64 {
+	if m != nil {
+		return m.ToRevision
+	}
+	return 0
+}
+
+// MapLeafHistoryEntry holds the value a key was set to at a revision, with
+// its inclusion proof and the signed root of the map at that revision.
+type MapLeafHistoryEntry struct {
+	MapLeafInclusion *MapLeafInclusion `protobuf:"bytes,1,opt,name=map_leaf_inclusion,json=mapLeafInclusion" json:"map_leaf_inclusion,omitempty"`
+	MapRoot          *SignedMapRoot    `protobuf:"bytes,2,opt,name=map_root,json=mapRoot" json:"map_root,omitempty"`
+}
+
+func (m *MapLeafHistoryEntry) Reset()                    { *m = MapLeafHistoryEntry{} }
+func (m *MapLeafHistoryEntry) String() string            { return proto.CompactTextString(m) }
+func (*MapLeafHistoryEntry) ProtoMessage()               {}
+func (*MapLeafHistoryEntry) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{7} }
+
+func (m *MapLeafHistoryEntry) GetMapLeafInclusion() *MapLeafInclusion {
+	if m != nil {
+		return m.MapLeafInclusion
+	}
+	return nil
+}
+
+func (m *MapLeafHistoryEntry) GetMapRoot() *SignedMapRoot {
+	if m != nil {
+		return m.MapRoot
+	}
+	return nil
+}
+
+type GetMapLeafHistoryResponse struct {
+	// entries holds an entry for each revision at which the key was set,
+	// oldest first.
+	Entries []*MapLeafHistoryEntry `protobuf:"bytes,1,rep,name=entries" json:"entries,omitempty"`
+}
+
+func (m *GetMapLeafHistoryResponse) Reset()                    { *m = GetMapLeafHistoryResponse{} }
+func (m *GetMapLeafHistoryResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetMapLeafHistoryResponse) ProtoMessage()               {}
+func (*GetMapLeafHistoryResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{8} }
+
+func (m *GetMapLeafHistoryResponse) GetEntries() []*MapLeafHistoryEntry {
+	if m != nil {
+		return m.Entries
+	}
+	return nil
+}
+
 type GetSignedMapRootRequest struct {
 	MapId int64 `protobuf:"varint,1,opt,name=map_id,json=mapId" json:"map_id,omitempty"`
 }
@@ -199,7 +285,7 @@ type GetSignedMapRootRequest struct {
 func (m *GetSignedMapRootRequest) Reset()                    { *m = GetSignedMapRootRequest{} }
 func (m *GetSignedMapRootRequest) String() string            { return proto.CompactTextString(m) }
 func (*GetSignedMapRootRequest) ProtoMessage()               {}
-func (*GetSignedMapRootRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{6} }
+func (*GetSignedMapRootRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{9} }
 
 func (m *GetSignedMapRootRequest) GetMapId() int64 {
 	if m != nil {
@@ -215,7 +301,7 @@ type GetSignedMapRootResponse struct {
 func (m *GetSignedMapRootResponse) Reset()                    { *m = GetSignedMapRootResponse{} }
 func (m *GetSignedMapRootResponse) String() string            { return proto.CompactTextString(m) }
 func (*GetSignedMapRootResponse) ProtoMessage()               {}
-func (*GetSignedMapRootResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{7} }
+func (*GetSignedMapRootResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{10} }
 
 func (m *GetSignedMapRootResponse) GetMapRoot() *SignedMapRoot {
 	if m != nil {
@@ -231,6 +317,9 @@ func init() {
 	proto.RegisterType((*GetMapLeavesResponse)(nil), "trillian.GetMapLeavesResponse")
 	proto.RegisterType((*SetMapLeavesRequest)(nil), "trillian.SetMapLeavesRequest")
 	proto.RegisterType((*SetMapLeavesResponse)(nil), "trillian.SetMapLeavesResponse")
+	proto.RegisterType((*GetMapLeafHistoryRequest)(nil), "trillian.GetMapLeafHistoryRequest")
+	proto.RegisterType((*MapLeafHistoryEntry)(nil), "trillian.MapLeafHistoryEntry")
+	proto.RegisterType((*GetMapLeafHistoryResponse)(nil), "trillian.GetMapLeafHistoryResponse")
 	proto.RegisterType((*GetSignedMapRootRequest)(nil), "trillian.GetSignedMapRootRequest")
 	proto.RegisterType((*GetSignedMapRootResponse)(nil), "trillian.GetSignedMapRootResponse")
 }
@@ -249,6 +338,9 @@ type TrillianMapClient interface {
 	GetLeaves(ctx context.Context, in *GetMapLeavesRequest, opts ...grpc.CallOption) (*GetMapLeavesResponse, error)
 	SetLeaves(ctx context.Context, in *SetMapLeavesRequest, opts ...grpc.CallOption) (*SetMapLeavesResponse, error)
 	GetSignedMapRoot(ctx context.Context, in *GetSignedMapRootRequest, opts ...grpc.CallOption) (*GetSignedMapRootResponse, error)
+	// GetMapLeafHistory returns the values that a key was set to over a range
+	// of revisions, each with a proof against the root of its revision.
+	GetMapLeafHistory(ctx context.Context, in *GetMapLeafHistoryRequest, opts ...grpc.CallOption) (*GetMapLeafHistoryResponse, error)
 }
 
 type trillianMapClient struct {
@@ -286,12 +378,24 @@ func (c *trillianMapClient) GetSignedMapRoot(ctx context.Context, in *GetSignedM
 	return out, nil
 }
 
+func (c *trillianMapClient) GetMapLeafHistory(ctx context.Context, in *GetMapLeafHistoryRequest, opts ...grpc.CallOption) (*GetMapLeafHistoryResponse, error) {
+	out := new(GetMapLeafHistoryResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianMap/GetMapLeafHistory", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for TrillianMap service
 
 type TrillianMapServer interface {
 	GetLeaves(context.Context, *GetMapLeavesRequest) (*GetMapLeavesResponse, error)
 	SetLeaves(context.Context, *SetMapLeavesRequest) (*SetMapLeavesResponse, error)
 	GetSignedMapRoot(context.Context, *GetSignedMapRootRequest) (*GetSignedMapRootResponse, error)
+	// GetMapLeafHistory returns the values that a key was set to over a range
+	// of revisions, each with a proof against the root of its revision.
+	GetMapLeafHistory(context.Context, *GetMapLeafHistoryRequest) (*GetMapLeafHistoryResponse, error)
 }
 
 func RegisterTrillianMapServer(s *grpc.Server, srv TrillianMapServer) {
@@ -352,6 +456,24 @@ func _TrillianMap_GetSignedMapRoot_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TrillianMap_GetMapLeafHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMapLeafHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianMapServer).GetMapLeafHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianMap/GetMapLeafHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianMapServer).GetMapLeafHistory(ctx, req.(*GetMapLeafHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _TrillianMap_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "trillian.TrillianMap",
 	HandlerType: (*TrillianMapServer)(nil),
@@ -368,6 +490,10 @@ var _TrillianMap_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetSignedMapRoot",
 			Handler:    _TrillianMap_GetSignedMapRoot_Handler,
 		},
+		{
+			MethodName: "GetMapLeafHistory",
+			Handler:    _TrillianMap_GetMapLeafHistory_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "trillian_map_api.proto",
@@ -376,36 +502,43 @@ var _TrillianMap_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("trillian_map_api.proto", fileDescriptor1) }
 
 var fileDescriptor1 = []byte{
-	// 485 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x54, 0x41, 0x6f, 0xd3, 0x4c,
-	0x10, 0xfd, 0x9c, 0xb4, 0x69, 0x32, 0xfe, 0x84, 0xc2, 0x36, 0x50, 0x63, 0x28, 0x2a, 0x96, 0x90,
-	0xe0, 0x12, 0x90, 0x39, 0x71, 0xa4, 0x42, 0x4a, 0x8b, 0x1a, 0x54, 0xd9, 0x08, 0x0e, 0x48, 0x44,
-	0x43, 0x3d, 0x4d, 0x56, 0xb2, 0xbd, 0x8b, 0xbd, 0x89, 0x2a, 0x7e, 0x00, 0x3f, 0x00, 0xf1, 0x83,
-	0xd1, 0xee, 0xda, 0x09, 0x6e, 0x4d, 0x54, 0x71, 0xdb, 0x9d, 0xf7, 0xe6, 0xcd, 0x7b, 0xb3, 0x96,
-	0xe1, 0xbe, 0x2a, 0x78, 0x9a, 0x72, 0xcc, 0x67, 0x19, 0xca, 0x19, 0x4a, 0x3e, 0x96, 0x85, 0x50,
-	0x82, 0xf5, 0xeb, 0xba, 0x7f, 0xa7, 0x3e, 0x59, 0x24, 0xf8, 0x0e, 0x7b, 0x53, 0x94, 0x67, 0x84,
-	0x97, 0x6c, 0x04, 0xbb, 0x3c, 0x4f, 0xe8, 0xca, 0x73, 0x8e, 0x9c, 0x67, 0xff, 0x47, 0xf6, 0xc2,
-	0x1e, 0xc2, 0x20, 0x25, 0xbc, 0x9c, 0x2d, 0xb0, 0x5c, 0x78, 0x1d, 0x83, 0xf4, 0x75, 0xe1, 0x04,
-	0xcb, 0x05, 0x3b, 0x04, 0x30, 0xe0, 0x0a, 0xd3, 0x25, 0x79, 0x5d, 0x83, 0x1a, 0xfa, 0x47, 0x5d,
-	0xd0, 0x30, 0x5d, 0xa9, 0x02, 0x67, 0x09, 0x2a, 0xf4, 0x76, 0x2c, 0x6c, 0x2a, 0x6f, 0x51, 0x61,
-	0xf0, 0x09, 0x86, 0xd5, 0xec, 0xd3, 0xfc, 0x22, 0x5d, 0x96, 0x5c, 0xe4, 0xec, 0x29, 0xec, 0xe8,
-	0x7e, 0xe3, 0xc1, 0x0d, 0xef, 0x8e, 0xd7, 0x76, 0x2b, 0x66, 0x64, 0x60, 0xf6, 0x08, 0x06, 0xbc,
-	0xee, 0xf1, 0x3a, 0x47, 0x5d, 0x2d, 0xbc, 0x2e, 0x04, 0x5f, 0x60, 0x7f, 0x42, 0xca, 0x76, 0xac,
-	0xa8, 0x8c, 0xe8, 0xdb, 0x92, 0x4a, 0xc5, 0xee, 0x41, 0x4f, 0xaf, 0x85, 0x27, 0x46, 0xbd, 0x1b,
-	0xed, 0x66, 0x28, 0x4f, 0x93, 0x4d, 0x6e, 0xab, 0x53, 0xe5, 0xf6, 0xa1, 0x5f, 0xd0, 0x8a, 0x9b,
-	0x01, 0x5d, 0x43, 0x5f, 0xdf, 0x83, 0x5f, 0x0e, 0x8c, 0x9a, 0x03, 0x4a, 0x29, 0xf2, 0x92, 0xd8,
-	0x09, 0x30, 0x3d, 0xc1, 0xec, 0xa4, 0xe9, 0xcf, 0x0d, 0xfd, 0x1b, 0x59, 0xd6, 0xa9, 0xa3, 0x61,
-	0x76, 0x7d, 0x0f, 0x21, 0xf4, 0xb5, 0x52, 0x21, 0x84, 0x32, 0xe3, 0xdd, 0xf0, 0x60, 0xd3, 0x1f,
-	0xf3, 0x79, 0x4e, 0xc9, 0x14, 0x65, 0x24, 0x84, 0x8a, 0xf6, 0x32, 0x7b, 0x08, 0x7e, 0x3a, 0xb0,
-	0x1f, 0xdf, 0x3e, 0xf7, 0x73, 0xe8, 0xa5, 0x86, 0x57, 0x19, 0x6c, 0x59, 0x76, 0x45, 0x60, 0xaf,
-	0xc1, 0xcd, 0x50, 0x4a, 0x2a, 0xec, 0x4b, 0x5a, 0x43, 0x5e, 0x83, 0x2f, 0xa9, 0x98, 0x92, 0x42,
-	0x8d, 0x47, 0x60, 0xc9, 0xe6, 0x91, 0xdf, 0xc1, 0x28, 0x6e, 0x5b, 0xd5, 0x9f, 0x01, 0x3b, 0xb7,
-	0x0c, 0xf8, 0x12, 0x0e, 0x26, 0xa4, 0x9a, 0xe0, 0xd6, 0x8c, 0xc1, 0x7b, 0xf0, 0x6e, 0x76, 0xfc,
-	0xbb, 0x83, 0xf0, 0x47, 0x07, 0xdc, 0x0f, 0x15, 0x67, 0x8a, 0x92, 0x9d, 0xc1, 0x60, 0x42, 0xca,
-	0x46, 0x63, 0x87, 0x9b, 0xf6, 0x96, 0xcf, 0xcf, 0x7f, 0xfc, 0x37, 0xd8, 0xfa, 0x09, 0xfe, 0xd3,
-	0x6a, 0x71, 0x9b, 0x5a, 0xbc, 0x5d, 0x2d, 0x6e, 0x57, 0xfb, 0x0c, 0xc3, 0xeb, 0xd9, 0xd9, 0x93,
-	0x86, 0x87, 0xb6, 0x4d, 0xfa, 0xc1, 0x36, 0x4a, 0x2d, 0x7e, 0xfc, 0x02, 0x1e, 0x5c, 0x88, 0x6c,
-	0x3c, 0x17, 0x62, 0x9e, 0xd2, 0xb8, 0xf9, 0x53, 0x39, 0x1e, 0xd6, 0x2b, 0x7a, 0x23, 0xf9, 0xb9,
-	0xae, 0x9c, 0x3b, 0x5f, 0x7b, 0x06, 0x7a, 0xf5, 0x3b, 0x00, 0x00, 0xff, 0xff, 0x1d, 0xb5, 0xa6,
-	0x26, 0xa3, 0x04, 0x00, 0x00,
+	// 597 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x55, 0x5f, 0x6f, 0xd3, 0x3e,
+	0x14, 0xfd, 0xa5, 0xed, 0xfa, 0xe7, 0x66, 0x3f, 0xd4, 0xb9, 0x85, 0x65, 0x81, 0xc1, 0xc8, 0x84,
+	0x04, 0x2f, 0x05, 0x95, 0x07, 0xc4, 0x23, 0x13, 0xa8, 0x1d, 0x5a, 0xd1, 0x94, 0x4c, 0xf0, 0x80,
+	0xb4, 0xca, 0xac, 0x6e, 0x6b, 0x29, 0x89, 0x4d, 0xe2, 0x56, 0x1b, 0xdf, 0x81, 0x17, 0xe0, 0x33,
+	0xf2, 0x39, 0x90, 0xed, 0x24, 0x6d, 0xda, 0xac, 0xaa, 0xf6, 0x96, 0xdc, 0x73, 0x7c, 0xee, 0x3d,
+	0xe7, 0x3a, 0x0a, 0x3c, 0x10, 0x11, 0xf5, 0x7d, 0x8a, 0xc3, 0x61, 0x80, 0xf9, 0x10, 0x73, 0xda,
+	0xe1, 0x11, 0x13, 0x0c, 0xd5, 0xd3, 0xba, 0x7d, 0x2f, 0x7d, 0xd2, 0x88, 0xf3, 0x03, 0x6a, 0x03,
+	0xcc, 0xcf, 0x08, 0x1e, 0xa3, 0x36, 0xec, 0xd0, 0x70, 0x44, 0xae, 0x2d, 0xe3, 0xc8, 0x78, 0xbe,
+	0xeb, 0xea, 0x17, 0xf4, 0x10, 0x1a, 0x3e, 0xc1, 0xe3, 0xe1, 0x14, 0xc7, 0x53, 0xab, 0xa4, 0x90,
+	0xba, 0x2c, 0xf4, 0x71, 0x3c, 0x45, 0x87, 0x00, 0x0a, 0x9c, 0x63, 0x7f, 0x46, 0xac, 0xb2, 0x42,
+	0x15, 0xfd, 0xb3, 0x2c, 0x48, 0x98, 0x5c, 0x8b, 0x08, 0x0f, 0x47, 0x58, 0x60, 0xab, 0xa2, 0x61,
+	0x55, 0x79, 0x8f, 0x05, 0x76, 0xbe, 0x40, 0x33, 0xe9, 0x7d, 0x1a, 0x5e, 0xf9, 0xb3, 0x98, 0xb2,
+	0x10, 0x3d, 0x83, 0x8a, 0x3c, 0xaf, 0x66, 0x30, 0xbb, 0x7b, 0x9d, 0x6c, 0xdc, 0x84, 0xe9, 0x2a,
+	0x18, 0x3d, 0x82, 0x06, 0x4d, 0xcf, 0x58, 0xa5, 0xa3, 0xb2, 0x14, 0xce, 0x0a, 0xce, 0x25, 0xb4,
+	0x7a, 0x44, 0xe8, 0x13, 0x73, 0x12, 0xbb, 0xe4, 0xfb, 0x8c, 0xc4, 0x02, 0xdd, 0x87, 0xaa, 0x8c,
+	0x85, 0x8e, 0x94, 0x7a, 0xd9, 0xdd, 0x09, 0x30, 0x3f, 0x1d, 0x2d, 0x7c, 0x6b, 0x9d, 0xc4, 0xb7,
+	0x0d, 0xf5, 0x88, 0xcc, 0xa9, 0x6a, 0x50, 0x56, 0xf4, 0xec, 0xdd, 0xf9, 0x63, 0x40, 0x3b, 0xdf,
+	0x20, 0xe6, 0x2c, 0x8c, 0x09, 0xea, 0x03, 0x92, 0x1d, 0x54, 0x26, 0xf9, 0xf9, 0xcc, 0xae, 0xbd,
+	0xe6, 0x25, 0x73, 0xed, 0x36, 0x83, 0xd5, 0x1c, 0xba, 0x50, 0x97, 0x4a, 0x11, 0x63, 0x42, 0xb5,
+	0x37, 0xbb, 0xfb, 0x8b, 0xf3, 0x1e, 0x9d, 0x84, 0x64, 0x34, 0xc0, 0xdc, 0x65, 0x4c, 0xb8, 0xb5,
+	0x40, 0x3f, 0x38, 0xbf, 0x0c, 0x68, 0x79, 0xdb, 0xfb, 0x7e, 0x01, 0x55, 0x5f, 0xf1, 0x92, 0x01,
+	0x0b, 0xc2, 0x4e, 0x08, 0xe8, 0x2d, 0x98, 0x01, 0xe6, 0x9c, 0x44, 0x7a, 0x93, 0x7a, 0x20, 0x2b,
+	0xc7, 0xe7, 0x24, 0x1a, 0x10, 0x81, 0x25, 0xee, 0x82, 0x26, 0xab, 0x25, 0x7f, 0x84, 0xb6, 0x57,
+	0x14, 0xd5, 0xb2, 0xc1, 0xd2, 0x96, 0x06, 0x7f, 0x1a, 0x60, 0x65, 0xb9, 0x8f, 0xfb, 0x34, 0x16,
+	0x2c, 0xba, 0xd9, 0x7e, 0xbb, 0x4b, 0xb7, 0xfa, 0x18, 0xfe, 0x1f, 0x47, 0x2c, 0x18, 0xae, 0xac,
+	0x78, 0x57, 0x16, 0xdd, 0xa4, 0x86, 0x9e, 0x80, 0x29, 0xd8, 0x82, 0x52, 0x51, 0x14, 0x10, 0x2c,
+	0x25, 0x38, 0xbf, 0x0d, 0x68, 0xe5, 0x87, 0xf9, 0x10, 0x8a, 0xe8, 0xe6, 0x96, 0x6b, 0xa0, 0xaf,
+	0xf4, 0xdd, 0xaf, 0xc1, 0xb6, 0x29, 0x5d, 0xc0, 0x41, 0x41, 0x48, 0x49, 0xec, 0x6f, 0xa0, 0x46,
+	0x42, 0x11, 0x51, 0x12, 0x5b, 0x86, 0xda, 0xfa, 0xe1, 0xda, 0x3c, 0xcb, 0x56, 0xdc, 0x94, 0xed,
+	0xbc, 0x82, 0xfd, 0x1e, 0x11, 0xf9, 0x96, 0x1b, 0x93, 0x77, 0x3e, 0x81, 0xb5, 0x7e, 0xe2, 0xee,
+	0xdb, 0xef, 0xfe, 0x2d, 0x81, 0x79, 0x91, 0x70, 0x06, 0x98, 0xa3, 0x33, 0x68, 0xf4, 0x88, 0xd0,
+	0xd7, 0x0a, 0x2d, 0xd9, 0x28, 0xf8, 0xf4, 0xed, 0xc7, 0xb7, 0xc1, 0x7a, 0x1e, 0xe7, 0x3f, 0xa9,
+	0xe6, 0x15, 0xa9, 0x79, 0x9b, 0xd5, 0xbc, 0x62, 0xb5, 0xaf, 0xd0, 0x5c, 0xf5, 0x8e, 0x9e, 0xe6,
+	0x66, 0x28, 0x4a, 0xd2, 0x76, 0x36, 0x51, 0x32, 0xf1, 0x4b, 0xd8, 0x5b, 0x5b, 0x30, 0x72, 0x0a,
+	0x1c, 0xae, 0x7c, 0x22, 0xf6, 0xf1, 0x46, 0x4e, 0xaa, 0x7f, 0xf2, 0x12, 0x0e, 0xae, 0x58, 0xd0,
+	0x99, 0x30, 0x36, 0xf1, 0x49, 0x27, 0xff, 0xc3, 0x38, 0x69, 0xa6, 0x2b, 0x78, 0xc7, 0xe9, 0xb9,
+	0xac, 0x9c, 0x1b, 0xdf, 0xaa, 0x0a, 0x7a, 0xfd, 0x2f, 0x00, 0x00, 0xff, 0xff, 0x1b, 0xf3, 0xdb,
+	0xf1, 0x7f, 0x06, 0x00, 0x00,
 }
@@ -82,3 +82,28 @@ func (p *Log) GetLeavesByHash(ctx context.Context, in *trillian.GetLeavesByHashR
 func (p *Log) GetEntryAndProof(ctx context.Context, in *trillian.GetEntryAndProofRequest) (*trillian.GetEntryAndProofResponse, error) {
 	return p.c.GetEntryAndProof(ctx, in)
 }
+
+// GetLeafSubmitters forwards the RPC.
+func (p *Log) GetLeafSubmitters(ctx context.Context, in *trillian.GetLeafSubmittersRequest) (*trillian.GetLeafSubmittersResponse, error) {
+	return p.c.GetLeafSubmitters(ctx, in)
+}
+
+// WaitForInclusion forwards the RPC.
+func (p *Log) WaitForInclusion(ctx context.Context, in *trillian.WaitForInclusionRequest) (*trillian.WaitForInclusionResponse, error) {
+	return p.c.WaitForInclusion(ctx, in)
+}
+
+// ListDeadLetterLeaves forwards the RPC.
+func (p *Log) ListDeadLetterLeaves(ctx context.Context, in *trillian.ListDeadLetterLeavesRequest) (*trillian.ListDeadLetterLeavesResponse, error) {
+	return p.c.ListDeadLetterLeaves(ctx, in)
+}
+
+// RequeueDeadLetterLeaves forwards the RPC.
+func (p *Log) RequeueDeadLetterLeaves(ctx context.Context, in *trillian.RequeueDeadLetterLeavesRequest) (*trillian.RequeueDeadLetterLeavesResponse, error) {
+	return p.c.RequeueDeadLetterLeaves(ctx, in)
+}
+
+// DiscardDeadLetterLeaves forwards the RPC.
+func (p *Log) DiscardDeadLetterLeaves(ctx context.Context, in *trillian.DiscardDeadLetterLeavesRequest) (*trillian.DiscardDeadLetterLeavesResponse, error) {
+	return p.c.DiscardDeadLetterLeaves(ctx, in)
+}